@@ -49,10 +49,18 @@ type Rule struct {
 	// code of ICMP traffic.  This should only be specified if the Protocol field is set to
 	// "ICMP" or "ICMPv6".
 	ICMP *ICMPFields `json:"icmp,omitempty" validate:"omitempty"`
+	// ICMPs is an optional field that restricts the rule to apply to any of a list of types, or
+	// types and codes, of ICMP traffic.  A packet matches if it matches the ICMP field or any
+	// entry in the list.  Each entry must specify a type.  This should only be specified if the
+	// Protocol field is set to "ICMP" or "ICMPv6".
+	ICMPs []ICMPFields `json:"icmps,omitempty" validate:"omitempty,dive"`
 	// NotProtocol is the negated version of the Protocol field.
 	NotProtocol *numorstring.Protocol `json:"notProtocol,omitempty" validate:"omitempty"`
 	// NotICMP is the negated version of the ICMP field.
 	NotICMP *ICMPFields `json:"notICMP,omitempty" validate:"omitempty"`
+	// NotICMPs is the negated version of the ICMPs field: a packet matches only if it matches
+	// none of the entries.
+	NotICMPs []ICMPFields `json:"notICMPs,omitempty" validate:"omitempty,dive"`
 	// Source contains the match criteria that apply to source entity.
 	Source EntityRule `json:"source,omitempty" validate:"omitempty"`
 	// Destination contains the match criteria that apply to destination entity.
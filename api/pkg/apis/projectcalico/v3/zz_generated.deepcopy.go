@@ -2678,6 +2678,13 @@ func (in *Rule) DeepCopyInto(out *Rule) {
 		*out = new(ICMPFields)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ICMPs != nil {
+		in, out := &in.ICMPs, &out.ICMPs
+		*out = make([]ICMPFields, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.NotProtocol != nil {
 		in, out := &in.NotProtocol, &out.NotProtocol
 		*out = new(numorstring.Protocol)
@@ -2688,6 +2695,13 @@ func (in *Rule) DeepCopyInto(out *Rule) {
 		*out = new(ICMPFields)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.NotICMPs != nil {
+		in, out := &in.NotICMPs, &out.NotICMPs
+		*out = make([]ICMPFields, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.Source.DeepCopyInto(&out.Source)
 	in.Destination.DeepCopyInto(&out.Destination)
 	if in.HTTP != nil {
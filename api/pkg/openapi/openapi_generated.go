@@ -5038,6 +5038,20 @@ func schema_pkg_apis_projectcalico_v3_Rule(ref common.ReferenceCallback) common.
 							Ref:         ref("github.com/projectcalico/api/pkg/apis/projectcalico/v3.ICMPFields"),
 						},
 					},
+					"icmps": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ICMPs is an optional field that restricts the rule to apply to any of a list of types, or types and codes, of ICMP traffic.  A packet matches if it matches the ICMP field or any entry in the list.  Each entry must specify a type.  This should only be specified if the Protocol field is set to \"ICMP\" or \"ICMPv6\".",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/projectcalico/api/pkg/apis/projectcalico/v3.ICMPFields"),
+									},
+								},
+							},
+						},
+					},
 					"notProtocol": {
 						SchemaProps: spec.SchemaProps{
 							Description: "NotProtocol is the negated version of the Protocol field.",
@@ -5050,6 +5064,20 @@ func schema_pkg_apis_projectcalico_v3_Rule(ref common.ReferenceCallback) common.
 							Ref:         ref("github.com/projectcalico/api/pkg/apis/projectcalico/v3.ICMPFields"),
 						},
 					},
+					"notICMPs": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NotICMPs is the negated version of the ICMPs field: a packet matches only if it matches none of the entries.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/projectcalico/api/pkg/apis/projectcalico/v3.ICMPFields"),
+									},
+								},
+							},
+						},
+					},
 					"source": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Source contains the match criteria that apply to source entity.",
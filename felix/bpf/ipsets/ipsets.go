@@ -128,6 +128,13 @@ func (m *bpfIPSets) AddOrReplaceIPSet(setMetadata ipsets.IPSetMetadata, members
 	ipSet := m.getOrCreateIPSet(setMetadata.SetID)
 	ipSet.Type = setMetadata.Type
 	log.WithFields(log.Fields{"stringID": setMetadata.SetID, "uint64ID": ipSet.ID}).Info("IP set added")
+	if !SupportsIPSetType(ipSet.Type) {
+		// Rendering the IP set as empty would make deny rules that refer to it fail open.
+		log.WithFields(log.Fields{
+			"stringID": setMetadata.SetID,
+			"type":     ipSet.Type,
+		}).Panic("IP set type not supported by the BPF dataplane")
+	}
 	ipSet.ReplaceMembers(members)
 	m.markIPSetDirty(ipSet)
 }
@@ -26,6 +26,7 @@ import (
 
 	"github.com/projectcalico/calico/felix/bpf/maps"
 	"github.com/projectcalico/calico/felix/ip"
+	"github.com/projectcalico/calico/felix/ipsets"
 )
 
 // WARNING: must be kept in sync with the definitions in bpf/polprog/pol_prog_builder.go.
@@ -100,6 +101,17 @@ func MakeBPFIPSetEntry(setID uint64, cidr ip.V4CIDR, port uint16, proto uint8) *
 
 var DummyValue = []byte{1, 0, 0, 0}
 
+// SupportsIPSetType returns true if IP sets of the given type can be rendered into the BPF IP sets
+// map.  The map is an LPM trie keyed on set ID, then address, then port and protocol, so it can hold
+// CIDRs and IP,port members but not members with a further address or an interface name.
+func SupportsIPSetType(t ipsets.IPSetType) bool {
+	switch t {
+	case ipsets.IPSetTypeHashIP, ipsets.IPSetTypeHashNet, ipsets.IPSetTypeHashIPPort, ipsets.IPSetTypeHashNetPort:
+		return true
+	}
+	return false
+}
+
 func ProtoIPSetMemberToBPFEntry(id uint64, member string) *IPSetEntry {
 	var cidrStr string
 	var port uint16
@@ -114,6 +126,8 @@ func ProtoIPSetMemberToBPFEntry(id uint64, member string) *IPSetEntry {
 			protocol = 6
 		case "udp":
 			protocol = 17
+		case "sctp":
+			protocol = 132
 		default:
 			logrus.WithField("member", member).Warn("Unknown protocol in named port member")
 			return nil
@@ -130,6 +144,11 @@ func ProtoIPSetMemberToBPFEntry(id uint64, member string) *IPSetEntry {
 	if !v4 {
 		return nil
 	}
+	if protocol != 0 && cidr.Prefix() != 32 {
+		// Port-based entries use the full length of the key so the LPM trie can't match a
+		// prefix of the address.  Skipping the member would make deny rules fail open.
+		logrus.WithField("member", member).Panic("Net,port member with a non-host CIDR can't be rendered into BPF IP set")
+	}
 	entry := MakeBPFIPSetEntry(id, cidr, port, protocol)
 	return entry
 }
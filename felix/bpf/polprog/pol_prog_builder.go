@@ -568,6 +568,10 @@ func (p *Builder) writeRule(r Rule, actionLabel string, destLeg matchLeg) {
 		log.Debugf("Version mismatch, skipping rule")
 		return
 	}
+	if err := rules.ValidateICMPLists(p.ipVersion(), rule); err != nil {
+		log.WithError(err).WithField("rule", rule).Warn("Invalid ICMP match in rule, skipping rule")
+		return
+	}
 	p.writeStartOfRule()
 
 	if rule.Protocol != nil {
@@ -656,7 +660,14 @@ func (p *Builder) writeRule(r Rule, actionLabel string, destLeg matchLeg) {
 		p.writePortsMatch(true, destLeg, rule.NotDstPorts, rule.NotDstNamedPortIpSetIds)
 	}
 
-	if rule.Icmp != nil {
+	if len(rule.IcmpTypes) > 0 || len(rule.IcmpTypeCodes) > 0 {
+		icmpTypes, icmpTypeCodes := rules.PositiveICMPMatches(rule)
+		log.WithFields(log.Fields{
+			"types":      icmpTypes,
+			"type/codes": icmpTypeCodes,
+		}).Debugf("ICMP types match")
+		p.writeICMPTypesMatch(icmpTypes, icmpTypeCodes)
+	} else if rule.Icmp != nil {
 		log.WithField("icmpv4", rule.Icmp).Debugf("ICMP type/code match")
 		switch icmp := rule.Icmp.(type) {
 		case *proto.Rule_IcmpTypeCode:
@@ -674,6 +685,13 @@ func (p *Builder) writeRule(r Rule, actionLabel string, destLeg matchLeg) {
 			p.writeICMPTypeMatch(true, uint8(icmp.NotIcmpType))
 		}
 	}
+	// Negated matches are ANDed together so each entry can be checked on its own.
+	for _, t := range rule.NotIcmpTypes {
+		p.writeICMPTypeMatch(true, uint8(t))
+	}
+	for _, tc := range rule.NotIcmpTypeCodes {
+		p.writeICMPTypeCodeMatch(true, uint8(tc.Type), uint8(tc.Code))
+	}
 
 	p.writeEndOfRule(r, actionLabel)
 	p.ruleID++
@@ -745,6 +763,38 @@ func (p *Builder) writeICMPTypeCodeMatch(negate bool, icmpType, icmpCode uint8)
 		p.b.JumpNEImm64(R1, (int32(icmpCode)<<8)|int32(icmpType), p.endOfRuleLabel())
 	}
 }
+
+func (p *Builder) writeICMPTypesMatch(icmpTypes []int32, icmpTypeCodes []*proto.IcmpTypeAndCode) {
+	// The entries are ORed together; if any of them match, go to the next match criteria.
+	onMatchLabel := p.freshPerRuleLabel()
+
+	var entries []string
+	for _, t := range icmpTypes {
+		entries = append(entries, fmt.Sprint(t))
+	}
+	for _, tc := range icmpTypeCodes {
+		entries = append(entries, fmt.Sprintf("%d/%d", tc.Type, tc.Code))
+	}
+	p.b.AddComment(fmt.Sprintf("If ICMP type/code is not any of {%s}, skip to next rule", strings.Join(entries, ",")))
+
+	if len(icmpTypes) > 0 {
+		p.b.Load8(R1, R9, stateOffICMPType)
+		for _, t := range icmpTypes {
+			p.b.JumpEqImm64(R1, int32(uint8(t)), onMatchLabel)
+		}
+	}
+	if len(icmpTypeCodes) > 0 {
+		p.b.Load16(R1, R9, stateOffICMPType)
+		for _, tc := range icmpTypeCodes {
+			p.b.JumpEqImm64(R1, (int32(uint8(tc.Code))<<8)|int32(uint8(tc.Type)), onMatchLabel)
+		}
+	}
+
+	// If we fall through then none of the entries matched so the rule doesn't match.
+	p.b.Jump(p.endOfRuleLabel())
+	p.b.LabelNextInsn(onMatchLabel)
+}
+
 func (p *Builder) writeCIDRSMatch(negate bool, leg matchLeg, cidrs []string) {
 	if p.policyDebugEnabled {
 		comment := ""
@@ -161,6 +161,11 @@ func TestPolicyDump(t *testing.T) {
 	checkLabelsAndComments(proto.Rule{NotIcmp: &proto.Rule_NotIcmpTypeCode{NotIcmpTypeCode: &proto.IcmpTypeAndCode{Type: 10, Code: 12}}}, "If ICMP type == 10 and code == 12, skip to next rule", "comment")
 	checkLabelsAndComments(proto.Rule{Icmp: &proto.Rule_IcmpType{IcmpType: 10}}, "If ICMP type != 10, skip to next rule", "comment")
 	checkLabelsAndComments(proto.Rule{NotIcmp: &proto.Rule_NotIcmpType{NotIcmpType: 10}}, "If ICMP type == 10, skip to next rule", "comment")
+
+	icmp := &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "icmp"}}
+	checkLabelsAndComments(proto.Rule{Protocol: icmp, Icmp: &proto.Rule_IcmpType{IcmpType: 8}, IcmpTypes: []int32{0}, IcmpTypeCodes: []*proto.IcmpTypeAndCode{{Type: 3, Code: 4}}}, "If ICMP type/code is not any of {8,0,3/4}, skip to next rule", "comment")
+	checkLabelsAndComments(proto.Rule{Protocol: icmp, NotIcmpTypes: []int32{0, 8}}, "If ICMP type == 8, skip to next rule", "comment")
+	checkLabelsAndComments(proto.Rule{Protocol: icmp, NotIcmpTypeCodes: []*proto.IcmpTypeAndCode{{Type: 3, Code: 4}}}, "If ICMP type == 3 and code == 4, skip to next rule", "comment")
 }
 
 func aggregateCommentsAndLabels(insns *asm.Insns) ([]string, []string) {
@@ -86,6 +86,8 @@ type AsyncCalcGraph struct {
 	dirty            bool
 
 	debugHangC <-chan time.Time
+	// debugRequests carries diagnostic requests, which are run on the loop's goroutine.
+	debugRequests chan func()
 }
 
 const (
@@ -102,6 +104,7 @@ func NewAsyncCalcGraph(
 	eventSequencer := NewEventSequencer(conf)
 	g := &AsyncCalcGraph{
 		inputEvents:      make(chan interface{}, 10),
+		debugRequests:    make(chan func()),
 		outputChannels:   outputChannels,
 		eventSequencer:   eventSequencer,
 		healthAggregator: healthAggregator,
@@ -184,6 +187,8 @@ func (acg *AsyncCalcGraph) loop() {
 			}
 		case <-acg.healthTicks:
 			acg.reportHealth()
+		case req := <-acg.debugRequests:
+			req()
 		case <-acg.debugHangC:
 			log.Warning("Debug hang simulation timer popped, hanging the calculation graph!!")
 			time.Sleep(1 * time.Hour)
@@ -352,4 +352,20 @@ var _ = Describe("specific scenario tests", func() {
 
 		Expect(mockDataplane.NumEventsRecorded()).To(Equal(numEventsBeforeSendingDupe))
 	})
+
+	It("should attribute IP set members to their policies and endpoints", func() {
+		validationFilter.OnUpdates(localEp1WithPolicy.KVDeltas(empty))
+		validationFilter.OnStatusUpdated(api.InSync)
+		eventBuf.Flush()
+
+		attributions := calcGraph.IPSetAttributions([]string{allSelectorId, "unknown"})
+		Expect(attributions).To(HaveLen(1))
+		a := attributions[0]
+		Expect(a.SetID).To(Equal(allSelectorId))
+		Expect(a.Selector).To(Equal("all()"))
+		Expect(a.Rules).To(ConsistOf(model.PolicyKey{Name: "pol-1"}.String()))
+		Expect(a.Contributors).To(HaveKeyWithValue("10.0.0.1/32", []string{localWlEpKey1.String()}))
+
+		Expect(calcGraph.IPSetAttributions(nil)).To(HaveLen(2))
+	})
 })
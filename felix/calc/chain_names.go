@@ -0,0 +1,126 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/hashutils"
+	"github.com/projectcalico/calico/felix/proto"
+	"github.com/projectcalico/calico/felix/rules"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+)
+
+// chainNameAllocator chooses the names under which the policies (or profiles) are sent to the
+// dataplane.  The dataplane renders each name into chain names, shortening names that are too
+// long to a hash, so two names can render to the same chain.  Since endpoints jump to a chain by
+// name, the endpoints of one policy would then run the rules of the other.
+//
+// A policy is normally sent under its own name.  If one of its chain names would collide with
+// one that belongs to another active policy, it is sent under its name plus a "_<n>" suffix
+// instead, with n chosen so that its chain names are unique.  Since equal names render to equal
+// chain names, unique chain names also mean that no two policies are sent with the same name.
+type chainNameAllocator[K comparable] struct {
+	kind       string
+	chainNames func(name string) []string
+
+	namesByKey      map[K]string
+	keysByChainName map[string]K
+}
+
+func newChainNameAllocator[K comparable](kind string, chainNames func(name string) []string) *chainNameAllocator[K] {
+	return &chainNameAllocator[K]{
+		kind:            kind,
+		chainNames:      chainNames,
+		namesByKey:      map[K]string{},
+		keysByChainName: map[string]K{},
+	}
+}
+
+// Allocate returns the name under which the given key is sent to the dataplane, choosing one if
+// the key doesn't have one yet.
+func (a *chainNameAllocator[K]) Allocate(key K, name string) string {
+	if allocated, ok := a.namesByKey[key]; ok {
+		return allocated
+	}
+	candidate := name
+	for i := 1; ; i++ {
+		chainNames := a.chainNames(candidate)
+		collision := false
+		for _, chainName := range chainNames {
+			if otherKey, ok := a.keysByChainName[chainName]; ok {
+				log.WithFields(log.Fields{
+					a.kind:    key,
+					"other":   otherKey,
+					"chain":   chainName,
+					"attempt": candidate,
+				}).Warn("Chain name collision; sending the " + a.kind + " to the dataplane under a different name.")
+				collision = true
+				break
+			}
+		}
+		if !collision {
+			a.namesByKey[key] = candidate
+			for _, chainName := range chainNames {
+				a.keysByChainName[chainName] = key
+			}
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+}
+
+// Name returns the name that was allocated to the given key or, if it doesn't have one, the
+// given name.
+func (a *chainNameAllocator[K]) Name(key K, name string) string {
+	if allocated, ok := a.namesByKey[key]; ok {
+		return allocated
+	}
+	return name
+}
+
+// Release frees the name of the given key and its chain names.
+func (a *chainNameAllocator[K]) Release(key K) {
+	name, ok := a.namesByKey[key]
+	if !ok {
+		return
+	}
+	for _, chainName := range a.chainNames(name) {
+		delete(a.keysByChainName, chainName)
+	}
+	delete(a.namesByKey, key)
+}
+
+func newPolicyNameAllocator(version hashutils.IDVersion) *chainNameAllocator[model.PolicyKey] {
+	return newChainNameAllocator[model.PolicyKey]("policy", func(name string) []string {
+		id := &proto.PolicyID{Tier: "default", Name: name}
+		return []string{
+			rules.VersionedPolicyChainName(version, rules.PolicyInboundPfx, id),
+			rules.VersionedPolicyChainName(version, rules.PolicyOutboundPfx, id),
+		}
+	})
+}
+
+func newProfileNameAllocator(version hashutils.IDVersion) *chainNameAllocator[model.ProfileRulesKey] {
+	return newChainNameAllocator[model.ProfileRulesKey]("profile", func(name string) []string {
+		id := &proto.ProfileID{Name: name}
+		return []string{
+			rules.VersionedProfileChainName(version, rules.ProfileInboundPfx, id),
+			rules.VersionedProfileChainName(version, rules.ProfileOutboundPfx, id),
+		}
+	})
+}
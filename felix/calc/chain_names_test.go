@@ -0,0 +1,214 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/hashutils"
+	"github.com/projectcalico/calico/felix/proto"
+	"github.com/projectcalico/calico/felix/rules"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+)
+
+// truncatingChainNames renders names into chain names that collide whenever the first three
+// characters and the last character of the names match.
+func truncatingChainNames(name string) []string {
+	if len(name) > 3 {
+		name = name[:3] + "~" + name[len(name)-1:]
+	}
+	return []string{"in-" + name, "out-" + name}
+}
+
+var _ = Describe("chainNameAllocator", func() {
+	var a *chainNameAllocator[string]
+
+	BeforeEach(func() {
+		a = newChainNameAllocator[string]("policy", truncatingChainNames)
+	})
+
+	It("should use the name when there's no collision", func() {
+		Expect(a.Allocate("a", "pol-a")).To(Equal("pol-a"))
+		Expect(a.Allocate("b", "abc")).To(Equal("abc"))
+		Expect(a.Name("a", "pol-a")).To(Equal("pol-a"))
+	})
+
+	It("should add a suffix until the chain names are unique", func() {
+		Expect(a.Allocate("a", "pol-a")).To(Equal("pol-a"))
+		Expect(a.Allocate("b", "pol-b")).To(Equal("pol-b"))
+		Expect(a.Allocate("c", "pol-a-2")).To(Equal("pol-a-2"))
+		Expect(a.Allocate("d", "pol-a")).To(Equal("pol-a_1"))
+		// "pol-a_2" would collide with "pol-a-2".
+		Expect(a.Allocate("e", "pol-a")).To(Equal("pol-a_3"))
+		Expect(a.Name("e", "pol-a")).To(Equal("pol-a_3"))
+	})
+
+	It("should keep a name until it is released", func() {
+		Expect(a.Allocate("a", "pol-a")).To(Equal("pol-a"))
+		Expect(a.Allocate("b", "pol-a")).To(Equal("pol-a_1"))
+		Expect(a.Allocate("b", "pol-a")).To(Equal("pol-a_1"))
+
+		a.Release("a")
+		Expect(a.Name("a", "pol-a")).To(Equal("pol-a"))
+		Expect(a.Name("b", "pol-a")).To(Equal("pol-a_1"))
+		Expect(a.Allocate("c", "pol-a")).To(Equal("pol-a"))
+
+		a.Release("b")
+		a.Release("c")
+		Expect(a.namesByKey).To(BeEmpty())
+		Expect(a.keysByChainName).To(BeEmpty())
+	})
+
+	It("should render the same chain names as the dataplane", func() {
+		name := "a-policy-name-that-is-long-enough-to-be-hashed"
+		names := newPolicyNameAllocator(hashutils.IDVersion2).chainNames(name)
+		id := &proto.PolicyID{Tier: "default", Name: name}
+		Expect(names).To(Equal([]string{
+			rules.VersionedPolicyChainName(hashutils.IDVersion2, rules.PolicyInboundPfx, id),
+			rules.VersionedPolicyChainName(hashutils.IDVersion2, rules.PolicyOutboundPfx, id),
+		}))
+	})
+})
+
+var _ = Describe("EventSequencer with colliding chain names", func() {
+	var buf *EventSequencer
+	var messages []interface{}
+
+	polA := model.PolicyKey{Name: "pol-a"}
+	polB := model.PolicyKey{Name: "pol-b"}
+	profA := model.ProfileRulesKey{ProfileKey: model.ProfileKey{Name: "prof-a"}}
+	profB := model.ProfileRulesKey{ProfileKey: model.ProfileKey{Name: "prof-b"}}
+	wepKey := model.WorkloadEndpointKey{
+		Hostname:       "host",
+		OrchestratorID: "k8s",
+		WorkloadID:     "ns/pod",
+		EndpointID:     "eth0",
+	}
+	wepID := &proto.WorkloadEndpointID{OrchestratorId: "k8s", WorkloadId: "ns/pod", EndpointId: "eth0"}
+
+	BeforeEach(func() {
+		buf = NewEventSequencer(nil)
+		messages = nil
+		buf.Callback = func(message interface{}) {
+			messages = append(messages, message)
+		}
+		// Drop the letter that tells the policies (and the profiles) apart so that their
+		// chain names collide.
+		buf.policyNames.chainNames = func(name string) []string {
+			name = name[:3] + name[5:]
+			return []string{"pi-" + name, "po-" + name}
+		}
+		buf.profileNames.chainNames = func(name string) []string {
+			name = name[:4] + name[6:]
+			return []string{"pri-" + name, "pro-" + name}
+		}
+
+		buf.OnPolicyActive(polA, &ParsedRules{})
+		buf.OnPolicyActive(polB, &ParsedRules{})
+		buf.OnProfileActive(profA, &ParsedRules{})
+		buf.OnProfileActive(profB, &ParsedRules{})
+		buf.OnEndpointTierUpdate(wepKey, &model.WorkloadEndpoint{
+			Name:       "cali1234",
+			ProfileIDs: []string{"prof-a", "prof-b"},
+		}, []TierInfo{{
+			Name: "default",
+			OrderedPolicies: []PolKV{
+				{Key: polA, Value: &model.Policy{Types: []string{"ingress"}}},
+				{Key: polB, Value: &model.Policy{Types: []string{"ingress"}}},
+			},
+		}})
+		buf.Flush()
+	})
+
+	policyIDs := func() (ids []string) {
+		for _, m := range messages {
+			switch m := m.(type) {
+			case *proto.ActivePolicyUpdate:
+				ids = append(ids, "update "+m.Id.Name)
+			case *proto.ActivePolicyRemove:
+				ids = append(ids, "remove "+m.Id.Name)
+			case *proto.ActiveProfileUpdate:
+				ids = append(ids, "update profile "+m.Id.Name)
+			case *proto.ActiveProfileRemove:
+				ids = append(ids, "remove profile "+m.Id.Name)
+			}
+		}
+		return
+	}
+
+	It("should send the policies and profiles under distinct names", func() {
+		Expect(policyIDs()).To(ConsistOf(
+			"update pol-a",
+			"update pol-b_1",
+			"update profile prof-a",
+			"update profile prof-b_1",
+		))
+		var wepUpdate *proto.WorkloadEndpointUpdate
+		for _, m := range messages {
+			if u, ok := m.(*proto.WorkloadEndpointUpdate); ok {
+				wepUpdate = u
+			}
+		}
+		Expect(wepUpdate).NotTo(BeNil())
+		Expect(wepUpdate.Id).To(Equal(wepID))
+		Expect(wepUpdate.Endpoint.ProfileIds).To(Equal([]string{"prof-a", "prof-b_1"}))
+		Expect(wepUpdate.Endpoint.Tiers).To(Equal([]*proto.TierInfo{{
+			Name:            "default",
+			IngressPolicies: []string{"pol-a", "pol-b_1"},
+		}}))
+	})
+
+	It("should remove policies under the names they were sent with", func() {
+		messages = nil
+		buf.OnEndpointTierUpdate(wepKey, nil, nil)
+		buf.OnPolicyInactive(polA)
+		buf.OnPolicyInactive(polB)
+		buf.OnProfileInactive(profA)
+		buf.OnProfileInactive(profB)
+		buf.Flush()
+		Expect(policyIDs()).To(ConsistOf(
+			"remove pol-a",
+			"remove pol-b_1",
+			"remove profile prof-a",
+			"remove profile prof-b_1",
+		))
+		Expect(buf.policyNames.namesByKey).To(BeEmpty())
+		Expect(buf.profileNames.namesByKey).To(BeEmpty())
+	})
+
+	It("should keep the name of a policy that is re-added before its remove is sent", func() {
+		messages = nil
+		buf.OnPolicyInactive(polB)
+		buf.OnPolicyActive(polB, &ParsedRules{})
+		buf.Flush()
+		Expect(policyIDs()).To(Equal([]string{"update pol-b_1"}))
+	})
+
+	It("should give a new policy a fresh name while a removed policy still holds its name", func() {
+		messages = nil
+		polC := model.PolicyKey{Name: "pol-c"}
+		buf.OnPolicyInactive(polA)
+		buf.OnPolicyActive(polC, &ParsedRules{})
+		buf.Flush()
+		Expect(policyIDs()).To(Equal([]string{"update pol-c_2", "remove pol-a"}))
+
+		// Once the remove has been sent, the name is free again.
+		messages = nil
+		buf.OnPolicyActive(polA, &ParsedRules{})
+		buf.Flush()
+		Expect(policyIDs()).To(Equal([]string{"update pol-a"}))
+	})
+})
@@ -23,6 +23,7 @@ import (
 	v3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
 
 	"github.com/projectcalico/calico/felix/config"
+	"github.com/projectcalico/calico/felix/hashutils"
 	"github.com/projectcalico/calico/felix/ip"
 	"github.com/projectcalico/calico/felix/labelindex"
 	"github.com/projectcalico/calico/felix/multidict"
@@ -101,6 +102,11 @@ type EventSequencer struct {
 	sentWireguardV6     set.Set[string]
 	sentServices        set.Set[serviceID]
 
+	// policyNames and profileNames choose the names under which policies and profiles are sent,
+	// so that their chain names don't collide in the dataplane.
+	policyNames  *chainNameAllocator[model.PolicyKey]
+	profileNames *chainNameAllocator[model.ProfileRulesKey]
+
 	Callback EventHandler
 }
 
@@ -127,6 +133,11 @@ type serviceID struct {
 // }
 
 func NewEventSequencer(conf configInterface) *EventSequencer {
+	// The chain name scheme can only be set at start of day so we can read it once, here.
+	chainNameVersion := hashutils.IDVersion1
+	if c, ok := conf.(*config.Config); ok && c.ChainNameVersion != 0 {
+		chainNameVersion = hashutils.IDVersion(c.ChainNameVersion)
+	}
 	buf := &EventSequencer{
 		config:                     conf,
 		pendingAddedIPSets:         map[string]proto.IPSetUpdate_IPSetType{},
@@ -178,6 +189,9 @@ func NewEventSequencer(conf configInterface) *EventSequencer {
 		sentWireguard:       set.New[string](),
 		sentWireguardV6:     set.New[string](),
 		sentServices:        set.New[serviceID](),
+
+		policyNames:  newPolicyNameAllocator(chainNameVersion),
+		profileNames: newProfileNameAllocator(chainNameVersion),
 	}
 	return buf
 }
@@ -291,11 +305,14 @@ func (buf *EventSequencer) flushConfigUpdate() {
 func (buf *EventSequencer) OnPolicyActive(key model.PolicyKey, rules *ParsedRules) {
 	buf.pendingPolicyDeletes.Discard(key)
 	buf.pendingPolicyUpdates[key] = rules
+	buf.policyNames.Allocate(key, key.Name)
 }
 
 func (buf *EventSequencer) flushPolicyUpdates() {
 	for key, rules := range buf.pendingPolicyUpdates {
-		buf.Callback(ParsedRulesToActivePolicyUpdate(key, rules))
+		update := ParsedRulesToActivePolicyUpdate(key, rules)
+		update.Id.Name = buf.policyNames.Name(key, key.Name)
+		buf.Callback(update)
 		buf.sentPolicies.Add(key)
 		delete(buf.pendingPolicyUpdates, key)
 	}
@@ -326,7 +343,10 @@ func ParsedRulesToActivePolicyUpdate(key model.PolicyKey, rules *ParsedRules) *p
 func (buf *EventSequencer) OnPolicyInactive(key model.PolicyKey) {
 	delete(buf.pendingPolicyUpdates, key)
 	if buf.sentPolicies.Contains(key) {
+		// Keep the name until we've sent the remove.
 		buf.pendingPolicyDeletes.Add(key)
+	} else {
+		buf.policyNames.Release(key)
 	}
 }
 
@@ -335,9 +355,10 @@ func (buf *EventSequencer) flushPolicyDeletes() {
 		buf.Callback(&proto.ActivePolicyRemove{
 			Id: &proto.PolicyID{
 				Tier: "default",
-				Name: item.Name,
+				Name: buf.policyNames.Name(item, item.Name),
 			},
 		})
+		buf.policyNames.Release(item)
 		buf.sentPolicies.Discard(item)
 		return set.RemoveItem
 	})
@@ -346,13 +367,14 @@ func (buf *EventSequencer) flushPolicyDeletes() {
 func (buf *EventSequencer) OnProfileActive(key model.ProfileRulesKey, rules *ParsedRules) {
 	buf.pendingProfileDeletes.Discard(key)
 	buf.pendingProfileUpdates[key] = rules
+	buf.profileNames.Allocate(key, key.Name)
 }
 
 func (buf *EventSequencer) flushProfileUpdates() {
 	for key, rulesOrNil := range buf.pendingProfileUpdates {
 		buf.Callback(&proto.ActiveProfileUpdate{
 			Id: &proto.ProfileID{
-				Name: key.Name,
+				Name: buf.profileNames.Name(key, key.Name),
 			},
 			Profile: &proto.Profile{
 				InboundRules: parsedRulesToProtoRules(
@@ -373,7 +395,10 @@ func (buf *EventSequencer) flushProfileUpdates() {
 func (buf *EventSequencer) OnProfileInactive(key model.ProfileRulesKey) {
 	delete(buf.pendingProfileUpdates, key)
 	if buf.sentProfiles.Contains(key) {
+		// Keep the name until we've sent the remove.
 		buf.pendingProfileDeletes.Add(key)
+	} else {
+		buf.profileNames.Release(key)
 	}
 }
 
@@ -381,9 +406,10 @@ func (buf *EventSequencer) flushProfileDeletes() {
 	buf.pendingProfileDeletes.Iter(func(item model.ProfileRulesKey) error {
 		buf.Callback(&proto.ActiveProfileRemove{
 			Id: &proto.ProfileID{
-				Name: item.Name,
+				Name: buf.profileNames.Name(item, item.Name),
 			},
 		})
+		buf.profileNames.Release(item)
 		buf.sentProfiles.Discard(item)
 		return set.RemoveItem
 	})
@@ -444,25 +470,29 @@ func (buf *EventSequencer) OnEndpointTierUpdate(key model.Key,
 
 func (buf *EventSequencer) flushEndpointTierUpdates() {
 	for key, endpoint := range buf.pendingEndpointUpdates {
-		tiers, untrackedTiers, preDNATTiers, forwardTiers := tierInfoToProtoTierInfo(buf.pendingEndpointTierUpdates[key])
+		tiers, untrackedTiers, preDNATTiers, forwardTiers := buf.tierInfoToProtoTierInfo(buf.pendingEndpointTierUpdates[key])
 		switch key := key.(type) {
 		case model.WorkloadEndpointKey:
 			wlep := endpoint.(*model.WorkloadEndpoint)
+			protoEP := ModelWorkloadEndpointToProto(wlep, tiers)
+			protoEP.ProfileIds = buf.dataplaneProfileIDs(protoEP.ProfileIds)
 			buf.Callback(&proto.WorkloadEndpointUpdate{
 				Id: &proto.WorkloadEndpointID{
 					OrchestratorId: key.OrchestratorID,
 					WorkloadId:     key.WorkloadID,
 					EndpointId:     key.EndpointID,
 				},
-				Endpoint: ModelWorkloadEndpointToProto(wlep, tiers),
+				Endpoint: protoEP,
 			})
 		case model.HostEndpointKey:
 			hep := endpoint.(*model.HostEndpoint)
+			protoEP := ModelHostEndpointToProto(hep, tiers, untrackedTiers, preDNATTiers, forwardTiers)
+			protoEP.ProfileIds = buf.dataplaneProfileIDs(protoEP.ProfileIds)
 			buf.Callback(&proto.HostEndpointUpdate{
 				Id: &proto.HostEndpointID{
 					EndpointId: key.EndpointID,
 				},
-				Endpoint: ModelHostEndpointToProto(hep, tiers, untrackedTiers, preDNATTiers, forwardTiers),
+				Endpoint: protoEP,
 			})
 		}
 		// Record that we've sent this endpoint.
@@ -473,6 +503,25 @@ func (buf *EventSequencer) flushEndpointTierUpdates() {
 	}
 }
 
+// dataplaneProfileIDs maps an endpoint's profile IDs to the names that the profiles were sent
+// under.  It only copies the slice if a name differs.
+func (buf *EventSequencer) dataplaneProfileIDs(profileIDs []string) []string {
+	var out []string
+	for i, id := range profileIDs {
+		name := buf.profileNames.Name(model.ProfileRulesKey{ProfileKey: model.ProfileKey{Name: id}}, id)
+		if name != id && out == nil {
+			out = append([]string(nil), profileIDs...)
+		}
+		if out != nil {
+			out[i] = name
+		}
+	}
+	if out == nil {
+		return profileIDs
+	}
+	return out
+}
+
 func (buf *EventSequencer) flushEndpointTierDeletes() {
 	buf.pendingEndpointDeletes.Iter(func(item model.Key) error {
 		switch key := item.(type) {
@@ -1131,16 +1180,16 @@ func cidrToIPPoolID(cidr ip.CIDR) string {
 	return strings.Replace(cidr.String(), "/", "-", 1)
 }
 
-func addPolicyToTierInfo(pol *PolKV, tierInfo *proto.TierInfo, egressAllowed bool) {
+func addPolicyToTierInfo(pol *PolKV, name string, tierInfo *proto.TierInfo, egressAllowed bool) {
 	if pol.GovernsIngress() {
-		tierInfo.IngressPolicies = append(tierInfo.IngressPolicies, pol.Key.Name)
+		tierInfo.IngressPolicies = append(tierInfo.IngressPolicies, name)
 	}
 	if egressAllowed && pol.GovernsEgress() {
-		tierInfo.EgressPolicies = append(tierInfo.EgressPolicies, pol.Key.Name)
+		tierInfo.EgressPolicies = append(tierInfo.EgressPolicies, name)
 	}
 }
 
-func tierInfoToProtoTierInfo(filteredTiers []TierInfo) (normalTiers, untrackedTiers, preDNATTiers, forwardTiers []*proto.TierInfo) {
+func (buf *EventSequencer) tierInfoToProtoTierInfo(filteredTiers []TierInfo) (normalTiers, untrackedTiers, preDNATTiers, forwardTiers []*proto.TierInfo) {
 	if len(filteredTiers) > 0 {
 		for _, ti := range filteredTiers {
 			untrackedTierInfo := &proto.TierInfo{Name: ti.Name}
@@ -1148,15 +1197,16 @@ func tierInfoToProtoTierInfo(filteredTiers []TierInfo) (normalTiers, untrackedTi
 			forwardTierInfo := &proto.TierInfo{Name: ti.Name}
 			normalTierInfo := &proto.TierInfo{Name: ti.Name}
 			for _, pol := range ti.OrderedPolicies {
+				name := buf.policyNames.Name(pol.Key, pol.Key.Name)
 				if pol.Value.DoNotTrack {
-					addPolicyToTierInfo(&pol, untrackedTierInfo, true)
+					addPolicyToTierInfo(&pol, name, untrackedTierInfo, true)
 				} else if pol.Value.PreDNAT {
-					addPolicyToTierInfo(&pol, preDNATTierInfo, false)
+					addPolicyToTierInfo(&pol, name, preDNATTierInfo, false)
 				} else {
 					if pol.Value.ApplyOnForward {
-						addPolicyToTierInfo(&pol, forwardTierInfo, true)
+						addPolicyToTierInfo(&pol, name, forwardTierInfo, true)
 					}
-					addPolicyToTierInfo(&pol, normalTierInfo, true)
+					addPolicyToTierInfo(&pol, name, normalTierInfo, true)
 				}
 			}
 
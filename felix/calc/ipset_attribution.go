@@ -0,0 +1,112 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/projectcalico/calico/felix/labelindex"
+)
+
+// IPSetAttribution explains where an IP set that was calculated by the calculation graph comes
+// from: the selector (or service) that it represents, the policies and profiles that refer to it
+// and, for selector-based IP sets, the endpoints and network sets that contribute each member.
+type IPSetAttribution struct {
+	SetID string `json:"setID"`
+
+	// Selector, NamedPortProtocol and NamedPort are set for an IP set that represents a selector
+	// and/or named port.
+	Selector          string `json:"selector,omitempty"`
+	NamedPortProtocol string `json:"namedPortProtocol,omitempty"`
+	NamedPort         string `json:"namedPort,omitempty"`
+	// Service is set, in namespace/name format, for an IP set that represents a service.
+	Service string `json:"service,omitempty"`
+
+	// Rules holds the keys of the active policies and profiles whose rules refer to the IP set.
+	Rules []string `json:"rules"`
+	// Contributors maps each member of a selector-based IP set, in the format that is sent to the
+	// dataplane, to the keys of the endpoints and network sets that contribute it.  It is nil for
+	// a service IP set.
+	Contributors map[string][]string `json:"contributors,omitempty"`
+}
+
+// IPSetAttributions returns the attributions of the given active IP sets, or of all active IP sets
+// if no IDs are given.  Unknown IDs are skipped.  Since it scans the matching endpoints of each IP
+// set, it is intended for diagnostics only.
+func (g *CalcGraph) IPSetAttributions(setIDs []string) []*IPSetAttribution {
+	rs := g.ruleScanner
+	if len(setIDs) == 0 {
+		for uid := range rs.ipSetsByUID {
+			setIDs = append(setIDs, uid)
+		}
+		sort.Strings(setIDs)
+	}
+	var attributions []*IPSetAttribution
+	for _, uid := range setIDs {
+		ipSet := rs.ipSetsByUID[uid]
+		if ipSet == nil {
+			continue
+		}
+		a := &IPSetAttribution{
+			SetID:     uid,
+			NamedPort: ipSet.NamedPort,
+			Service:   ipSet.Service,
+			Rules:     []string{},
+		}
+		if ipSet.Selector != nil {
+			a.Selector = ipSet.Selector.String()
+		}
+		if ipSet.NamedPortProtocol != labelindex.ProtocolNone {
+			a.NamedPortProtocol = ipSet.NamedPortProtocol.String()
+		}
+		rs.uidsToRulesIDs.Iter(uid, func(key any) {
+			a.Rules = append(a.Rules, fmt.Sprint(key))
+		})
+		sort.Strings(a.Rules)
+		if ipSet.Service == "" {
+			a.Contributors = map[string][]string{}
+			for member, epIDs := range g.ipsetMemberIndex.IPSetContributors(uid) {
+				var keys []string
+				for _, id := range epIDs {
+					keys = append(keys, fmt.Sprint(id))
+				}
+				sort.Strings(keys)
+				a.Contributors[memberToProto(member)] = keys
+			}
+		}
+		attributions = append(attributions, a)
+	}
+	return attributions
+}
+
+// IPSetAttributions returns the attributions of the given IP sets, as calculated by
+// CalcGraph.IPSetAttributions.  It may be called from any goroutine; the work is done on the
+// calculation graph's goroutine, in between updates.
+func (acg *AsyncCalcGraph) IPSetAttributions(ctx context.Context, setIDs []string) ([]*IPSetAttribution, error) {
+	resultC := make(chan []*IPSetAttribution, 1)
+	select {
+	case acg.debugRequests <- func() { resultC <- acg.CalcGraph.IPSetAttributions(setIDs) }:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case result := <-resultC:
+		return result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
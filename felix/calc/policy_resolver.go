@@ -188,6 +188,9 @@ func (pr *PolicyResolver) sendEndpointUpdate(endpointID interface{}) error {
 				polKV)
 		}
 	}
+	if _, isHostEndpoint := endpointID.(model.HostEndpointKey); isHostEndpoint {
+		filteredTier.OrderedPolicies = moveInsertAtTopPoliciesFirst(filteredTier.OrderedPolicies)
+	}
 	if tierMatches {
 		log.Debugf("Tier %v matches %v", tier.Name, endpointID)
 		applicableTiers = append(applicableTiers, filteredTier)
@@ -197,3 +200,25 @@ func (pr *PolicyResolver) sendEndpointUpdate(endpointID interface{}) error {
 		endpoint, applicableTiers)
 	return nil
 }
+
+// moveInsertAtTopPoliciesFirst moves the policies that have asked to be inserted at the top of the
+// host endpoint chains to the front of the list, keeping the calculated order within each group.
+//
+// The PolicySorter keeps all policies in the single "default" tier, so moving a policy to the
+// front of its tier puts it ahead of every other policy that applies to the endpoint, including
+// policies that allow or pass traffic.  If the calculation graph ever gains multiple tiers, this
+// needs to move the policies across tiers too.
+func moveInsertAtTopPoliciesFirst(policies []PolKV) []PolKV {
+	var top, rest []PolKV
+	for _, polKV := range policies {
+		if policyInsertsAtTop(polKV.Value) {
+			top = append(top, polKV)
+		} else {
+			rest = append(rest, polKV)
+		}
+	}
+	if len(top) == 0 {
+		return policies
+	}
+	return append(top, rest...)
+}
@@ -185,3 +185,140 @@ func TestPolicyResolver_OnPolicyMatchStopped(t *testing.T) {
 		t.Error("Incorrect update:", d)
 	}
 }
+
+func TestPolicyResolver_InsertAtTop(t *testing.T) {
+	pr, recorder := createPolicyResolver()
+	pr.OnDatamodelStatus(api.InSync)
+
+	zero, one, ten, twenty := 0.0, 1.0, 10.0, 20.0
+	insertAtTop := map[string]string{model.AnnotationInsertAtTop: "true"}
+	policies := map[string]*model.Policy{
+		"allow-all": {
+			Order:        &zero,
+			InboundRules: []model.Rule{{Action: "allow"}},
+		},
+		"pass-all": {
+			Order:        &zero,
+			InboundRules: []model.Rule{{Action: "pass"}},
+		},
+		"normal": {Order: &one},
+		"block": {
+			Order:        &ten,
+			Annotations:  insertAtTop,
+			InboundRules: []model.Rule{{Action: "log"}, {Action: "deny"}},
+		},
+		"allow": {
+			Order:        &twenty,
+			Annotations:  insertAtTop,
+			InboundRules: []model.Rule{{Action: "allow"}},
+		},
+	}
+
+	hostEndpointKey := model.HostEndpointKey{Hostname: "host", EndpointID: "eth0"}
+	workloadEndpointKey := model.WorkloadEndpointKey{Hostname: "host", WorkloadID: "wl"}
+	pr.endpoints[hostEndpointKey] = "the host endpoint"
+	pr.endpoints[workloadEndpointKey] = "the workload endpoint"
+	for name, pol := range policies {
+		polKey := model.PolicyKey{Name: name}
+		pr.allPolicies[polKey] = pol
+		pr.OnPolicyMatch(polKey, hostEndpointKey)
+		pr.OnPolicyMatch(polKey, workloadEndpointKey)
+	}
+	pr.Flush()
+
+	orderedNames := map[model.Key][]string{}
+	for _, upd := range recorder.updates {
+		// All policies are in the one tier, so moving a policy to the front of its tier puts it
+		// ahead of policies that would otherwise allow or pass the traffic.
+		if len(upd.Tiers) != 1 {
+			t.Fatalf("Expected exactly one tier for %v, got %v", upd.Key, upd.Tiers)
+		}
+		for _, pol := range upd.Tiers[0].OrderedPolicies {
+			orderedNames[upd.Key] = append(orderedNames[upd.Key], pol.Key.Name)
+		}
+	}
+	// Only the deny-only policy jumps the queue, and only for the host endpoint.
+	if d := cmp.Diff(orderedNames, map[model.Key][]string{
+		hostEndpointKey:     {"block", "allow-all", "pass-all", "normal", "allow"},
+		workloadEndpointKey: {"allow-all", "pass-all", "normal", "block", "allow"},
+	}); d != "" {
+		t.Error("Incorrect policy order:", d)
+	}
+}
+
+func TestCheckInsertAtTop(t *testing.T) {
+	for _, tc := range []struct {
+		policy    model.Policy
+		requested bool
+		wantErr   bool
+	}{
+		{policy: model.Policy{}},
+		{policy: model.Policy{Annotations: map[string]string{model.AnnotationInsertAtTop: "false"}}},
+		{
+			policy:    model.Policy{Annotations: map[string]string{model.AnnotationInsertAtTop: "true"}},
+			requested: true,
+		},
+		{
+			policy: model.Policy{
+				Namespace:   "ns",
+				Annotations: map[string]string{model.AnnotationInsertAtTop: "true"},
+			},
+			requested: true,
+			wantErr:   true,
+		},
+		{
+			policy: model.Policy{
+				Annotations:   map[string]string{model.AnnotationInsertAtTop: "true"},
+				OutboundRules: []model.Rule{{Action: "pass"}},
+			},
+			requested: true,
+			wantErr:   true,
+		},
+	} {
+		requested, err := checkInsertAtTop(&tc.policy)
+		if requested != tc.requested || (err != nil) != tc.wantErr {
+			t.Errorf("Unexpected result for %v: requested=%v err=%v", tc.policy, requested, err)
+		}
+	}
+}
+
+func TestInsertAtTopInputsChanged(t *testing.T) {
+	insertAtTop := map[string]string{model.AnnotationInsertAtTop: "true"}
+	invalid := &model.Policy{
+		Annotations:  insertAtTop,
+		InboundRules: []model.Rule{{Action: "allow"}},
+	}
+	order := 10.0
+	for _, tc := range []struct {
+		name     string
+		old, new *model.Policy
+		changed  bool
+	}{
+		{name: "new policy", old: nil, new: invalid, changed: true},
+		{name: "unchanged copy", old: invalid, new: &model.Policy{
+			Annotations:  map[string]string{model.AnnotationInsertAtTop: "true"},
+			InboundRules: []model.Rule{{Action: "allow"}},
+		}},
+		{name: "order change only", old: invalid, new: &model.Policy{
+			Order:        &order,
+			Annotations:  insertAtTop,
+			InboundRules: []model.Rule{{Action: "allow"}},
+		}},
+		{name: "annotation change", old: invalid, new: &model.Policy{
+			InboundRules: []model.Rule{{Action: "allow"}},
+		}, changed: true},
+		{name: "rule change", old: invalid, new: &model.Policy{
+			Annotations:  insertAtTop,
+			InboundRules: []model.Rule{{Action: "deny"}},
+		}, changed: true},
+		{name: "namespace change", old: invalid, new: &model.Policy{
+			Namespace:    "ns",
+			Annotations:  insertAtTop,
+			InboundRules: []model.Rule{{Action: "allow"}},
+		}, changed: true},
+	} {
+		if changed := insertAtTopInputsChanged(tc.old, tc.new); changed != tc.changed {
+			t.Errorf("%s: expected changed=%v, got %v", tc.name, tc.changed, changed)
+		}
+	}
+}
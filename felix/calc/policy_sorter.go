@@ -16,9 +16,11 @@ package calc
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/google/btree"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
@@ -87,9 +89,18 @@ func (poc *PolicySorter) UpdatePolicy(key model.PolicyKey, newPolicy *model.Poli
 			oldPolicy.DoNotTrack != newPolicy.DoNotTrack ||
 			oldPolicy.PreDNAT != newPolicy.PreDNAT ||
 			oldPolicy.ApplyOnForward != newPolicy.ApplyOnForward ||
-			!policyTypesEqual(oldPolicy, newPolicy) {
+			!policyTypesEqual(oldPolicy, newPolicy) ||
+			policyInsertsAtTop(oldPolicy) != policyInsertsAtTop(newPolicy) {
 			dirty = true
 		}
+		if insertAtTopInputsChanged(oldPolicy, newPolicy) {
+			// Only warn when something relevant has changed, to avoid repeating the warning
+			// for every resync.
+			if _, err := checkInsertAtTop(newPolicy); err != nil {
+				log.WithError(err).WithField("policy", key.Name).Warn(
+					"Ignoring request to insert policy at the top of host endpoint chains.")
+			}
+		}
 		if oldPolicy != nil {
 			// Need to do delete prior to ReplaceOrInsert because we don't insert strictly based on key but rather a
 			// combination of key + value so if for instance we add PolKV{k1, v1} then add PolKV{k1, v2} we'll simply have
@@ -110,6 +121,46 @@ func (poc *PolicySorter) UpdatePolicy(key model.PolicyKey, newPolicy *model.Poli
 	return
 }
 
+// policyInsertsAtTop returns true if the policy uses the model.AnnotationInsertAtTop annotation to
+// ask to be applied to host endpoints ahead of the other policies, and the request is valid.
+func policyInsertsAtTop(policy *model.Policy) bool {
+	requested, err := checkInsertAtTop(policy)
+	return requested && err == nil
+}
+
+// insertAtTopInputsChanged returns true if the policy is new or if any of the fields that
+// checkInsertAtTop looks at differ between the old and new versions.
+func insertAtTopInputsChanged(oldPolicy, newPolicy *model.Policy) bool {
+	if oldPolicy == nil || newPolicy == nil {
+		return oldPolicy != newPolicy
+	}
+	return oldPolicy.Annotations[model.AnnotationInsertAtTop] != newPolicy.Annotations[model.AnnotationInsertAtTop] ||
+		oldPolicy.Namespace != newPolicy.Namespace ||
+		!reflect.DeepEqual(oldPolicy.InboundRules, newPolicy.InboundRules) ||
+		!reflect.DeepEqual(oldPolicy.OutboundRules, newPolicy.OutboundRules)
+}
+
+// checkInsertAtTop returns whether the policy requests insertion at the top of the host endpoint
+// chains and, if so, an error if the request is not allowed.  To stop the annotation being used to
+// get around the calculated ordering, it is only honoured for global policies whose rules can
+// only deny or log traffic.
+func checkInsertAtTop(policy *model.Policy) (requested bool, err error) {
+	if policy == nil || policy.Annotations[model.AnnotationInsertAtTop] != "true" {
+		return false, nil
+	}
+	if policy.Namespace != "" {
+		return true, fmt.Errorf("policy is namespaced")
+	}
+	for _, rules := range [][]model.Rule{policy.InboundRules, policy.OutboundRules} {
+		for _, r := range rules {
+			if r.Action != "deny" && r.Action != "log" {
+				return true, fmt.Errorf("policy has a rule with action %q", r.Action)
+			}
+		}
+	}
+	return true, nil
+}
+
 // PolKV is really internal to the calc package.  It is named with an initial capital so that
 // the test package calc_test can also use it.
 type PolKV struct {
@@ -23,6 +23,7 @@ import (
 	"github.com/projectcalico/api/pkg/lib/numorstring"
 
 	"github.com/projectcalico/calico/felix/proto"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
 	"github.com/projectcalico/calico/libcalico-go/lib/net"
 )
 
@@ -206,6 +207,10 @@ func parsedRuleToProtoRule(in *ParsedRule) *proto.Rule {
 			}
 		}
 	}
+	out.IcmpTypes = icmpTypesToProtoTypes(in.ICMPTypes)
+	out.IcmpTypeCodes = icmpTypeCodesToProtoTypeCodes(in.ICMPTypeCodes)
+	out.NotIcmpTypes = icmpTypesToProtoTypes(in.NotICMPTypes)
+	out.NotIcmpTypeCodes = icmpTypeCodesToProtoTypeCodes(in.NotICMPTypeCodes)
 
 	log.WithFields(log.Fields{
 		"in":  in,
@@ -282,3 +287,28 @@ func portToProtoPort(in numorstring.Port) (out *proto.PortRange) {
 	}
 	return
 }
+
+func icmpTypesToProtoTypes(in []int) (out []int32) {
+	if len(in) == 0 {
+		return
+	}
+	out = make([]int32, len(in))
+	for ii, t := range in {
+		out[ii] = int32(t)
+	}
+	return
+}
+
+func icmpTypeCodesToProtoTypeCodes(in []model.ICMPTypeAndCode) (out []*proto.IcmpTypeAndCode) {
+	if len(in) == 0 {
+		return
+	}
+	out = make([]*proto.IcmpTypeAndCode, len(in))
+	for ii, tc := range in {
+		out[ii] = &proto.IcmpTypeAndCode{
+			Type: int32(tc.Type),
+			Code: int32(tc.Code),
+		}
+	}
+	return
+}
@@ -24,9 +24,14 @@ import (
 	v3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
 	"github.com/projectcalico/api/pkg/lib/numorstring"
 
+	"github.com/projectcalico/calico/felix/environment"
+	"github.com/projectcalico/calico/felix/ipsets"
 	"github.com/projectcalico/calico/felix/proto"
+	"github.com/projectcalico/calico/felix/rules"
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/syncersv1/updateprocessors"
 	"github.com/projectcalico/calico/libcalico-go/lib/net"
+	validator "github.com/projectcalico/calico/libcalico-go/lib/validator/v3"
 )
 
 var ipv0 = 0
@@ -212,6 +217,19 @@ var _ = DescribeTable("ParsedRulesToProtoRules",
 				NotIcmpType: 11,
 			},
 		}),
+	Entry("ICMP list rule",
+		ParsedRule{
+			ICMPTypes:        []int{0, 8},
+			ICMPTypeCodes:    []model.ICMPTypeAndCode{{Type: 3, Code: 4}},
+			NotICMPTypes:     []int{5},
+			NotICMPTypeCodes: []model.ICMPTypeAndCode{{Type: 3, Code: 1}},
+		},
+		proto.Rule{
+			IcmpTypes:        []int32{0, 8},
+			IcmpTypeCodes:    []*proto.IcmpTypeAndCode{{Type: 3, Code: 4}},
+			NotIcmpTypes:     []int32{5},
+			NotIcmpTypeCodes: []*proto.IcmpTypeAndCode{{Type: 3, Code: 1}},
+		}),
 	Entry("Service match rule",
 		ParsedRule{
 			DstIPPortSetIDs: []string{"ipPortSetID"},
@@ -260,6 +278,62 @@ var _ = Describe("rule ID tests", func() {
 	})
 })
 
+var _ = Describe("ICMP lists from the v3 API", func() {
+	It("should render a v3 policy's ICMPs and NotICMPs", func() {
+		icmp := numorstring.ProtocolFromString("ICMP")
+		t0, t3, t8, c1, c4 := 0, 3, 8, 1, 4
+		gnp := v3.NewGlobalNetworkPolicy()
+		gnp.Name = "icmp-lists"
+		gnp.Spec.Ingress = []v3.Rule{{
+			Action:   v3.Allow,
+			Protocol: &icmp,
+			ICMP:     &v3.ICMPFields{Type: &t8},
+			ICMPs: []v3.ICMPFields{
+				{Type: &t0},
+				{Type: &t3, Code: &c4},
+			},
+			NotICMPs: []v3.ICMPFields{
+				{Type: &t3, Code: &c1},
+			},
+		}}
+		Expect(validator.Validate(gnp)).To(Succeed())
+
+		kvps, err := updateprocessors.NewGlobalNetworkPolicyUpdateProcessor().Process(&model.KVPair{
+			Key:   model.ResourceKey{Kind: v3.KindGlobalNetworkPolicy, Name: gnp.Name},
+			Value: gnp,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kvps).To(HaveLen(1))
+		policy := kvps[0].Value.(*model.Policy)
+		Expect(policy.InboundRules).To(HaveLen(1))
+
+		parsedRule, _ := ruleToParsedRule(&policy.InboundRules[0])
+		protoRule := parsedRuleToProtoRule(parsedRule)
+
+		renderer := rules.NewRenderer(rules.Config{
+			IPSetConfigV4:        ipsets.NewIPVersionConfig(ipsets.IPFamilyV4, "cali", nil, nil),
+			IPSetConfigV6:        ipsets.NewIPVersionConfig(ipsets.IPFamilyV6, "cali", nil, nil),
+			IptablesMarkAccept:   0x8,
+			IptablesMarkPass:     0x10,
+			IptablesMarkScratch0: 0x20,
+			IptablesMarkScratch1: 0x40,
+			IptablesMarkEndpoint: 0xff00,
+		})
+		var rendered []string
+		for _, r := range renderer.ProtoRuleToIptablesRules(protoRule, 4) {
+			rendered = append(rendered, r.RenderAppend("test", "", &environment.Features{}))
+		}
+		Expect(rendered).To(Equal([]string{
+			"-A test --jump MARK --set-mark 0x0/0x60",
+			"-A test -p icmp -m icmp --icmp-type 8 --jump MARK --set-mark 0x20/0x20",
+			"-A test -p icmp -m icmp --icmp-type 0 --jump MARK --set-mark 0x20/0x20",
+			"-A test -p icmp -m icmp --icmp-type 3/4 --jump MARK --set-mark 0x20/0x20",
+			"-A test -p icmp -m icmp ! --icmp-type 3/1 -m mark --mark 0x20/0x20 --jump MARK --set-mark 0x8/0x8",
+			"-A test -m mark --mark 0x8/0x8 --jump RETURN",
+		}))
+	})
+})
+
 func calculateRuleID(seed string, in ParsedRule) string {
 	out := parsedRulesToProtoRules(
 		[]*ParsedRule{&in},
@@ -294,6 +294,8 @@ type ParsedRule struct {
 	DstNamedPortIPSetIDs []string
 	ICMPType             *int
 	ICMPCode             *int
+	ICMPTypes            []int
+	ICMPTypeCodes        []model.ICMPTypeAndCode
 	SrcIPSetIDs          []string
 	DstIPSetIDs          []string
 	DstIPPortSetIDs      []string
@@ -307,6 +309,8 @@ type ParsedRule struct {
 	NotDstNamedPortIPSetIDs []string
 	NotICMPType             *int
 	NotICMPCode             *int
+	NotICMPTypes            []int
+	NotICMPTypeCodes        []model.ICMPTypeAndCode
 	NotSrcIPSetIDs          []string
 	NotDstIPSetIDs          []string
 
@@ -424,8 +428,10 @@ func ruleToParsedRule(rule *model.Rule) (parsedRule *ParsedRule, allIPSets []*IP
 		DstIPSetIDs:          ipSetsToUIDs(dstSelIPSets),
 		DstIPPortSetIDs:      ipSetsToUIDs(dstIPPortSets),
 
-		ICMPType: rule.ICMPType,
-		ICMPCode: rule.ICMPCode,
+		ICMPType:      rule.ICMPType,
+		ICMPCode:      rule.ICMPCode,
+		ICMPTypes:     rule.ICMPTypes,
+		ICMPTypeCodes: rule.ICMPTypeCodes,
 
 		NotProtocol: rule.NotProtocol,
 
@@ -439,8 +445,10 @@ func ruleToParsedRule(rule *model.Rule) (parsedRule *ParsedRule, allIPSets []*IP
 		NotDstNamedPortIPSetIDs: ipSetsToUIDs(notDstNamedPortIPSets),
 		NotDstIPSetIDs:          ipSetsToUIDs(notDstSelIPSets),
 
-		NotICMPType: rule.NotICMPType,
-		NotICMPCode: rule.NotICMPCode,
+		NotICMPType:      rule.NotICMPType,
+		NotICMPCode:      rule.NotICMPCode,
+		NotICMPTypes:     rule.NotICMPTypes,
+		NotICMPTypeCodes: rule.NotICMPTypeCodes,
 
 		// Pass through original values of some fields for the policy API.
 		OriginalSrcSelector:               rule.OriginalSrcSelector,
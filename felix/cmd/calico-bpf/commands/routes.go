@@ -16,7 +16,6 @@ package commands
 
 import (
 	"fmt"
-	"sort"
 
 	"github.com/projectcalico/calico/felix/bpf/maps"
 	"github.com/projectcalico/calico/felix/bpf/routes"
@@ -73,7 +72,7 @@ func dumpRoutes() error {
 		return err
 	}
 
-	sortCIDRs(dests)
+	ip.SortCIDRs(dests)
 
 	for _, dest := range dests {
 		v := valueByDest[dest]
@@ -82,19 +81,3 @@ func dumpRoutes() error {
 
 	return nil
 }
-
-func sortCIDRs(cidrs []ip.CIDR) {
-	sort.Slice(cidrs, func(i, j int) bool {
-		addrA := cidrs[i].Addr().(ip.V4Addr) // FIXME IPv6
-		addrB := cidrs[j].Addr().(ip.V4Addr)
-		for byteIdx := 0; byteIdx < 4; byteIdx++ {
-			if addrA[byteIdx] < addrB[byteIdx] {
-				return true
-			}
-			if addrA[byteIdx] > addrB[byteIdx] {
-				return false
-			}
-		}
-		return cidrs[i].Prefix() < cidrs[j].Prefix()
-	})
-}
@@ -42,7 +42,7 @@ func TestSortCIDRs(t *testing.T) {
 		ip.MustParseCIDROrIP("10.65.0.3/32"),
 		ip.MustParseCIDROrIP("172.17.0.7/32"),
 	}
-	sortCIDRs(cidrs)
+	ip.SortCIDRs(cidrs)
 	expectedResult := []ip.CIDR{
 		ip.MustParseCIDROrIP("10.65.0.1/32"),
 		ip.MustParseCIDROrIP("10.65.0.2/32"),
@@ -67,7 +67,7 @@ func TestSortCIDRsAlreadySorted(t *testing.T) {
 		ip.MustParseCIDROrIP("172.17.0.6/32"),
 		ip.MustParseCIDROrIP("172.17.0.7/32"),
 	}
-	sortCIDRs(cidrs)
+	ip.SortCIDRs(cidrs)
 	expectedResult := []ip.CIDR{
 		ip.MustParseCIDROrIP("10.65.0.1/32"),
 		ip.MustParseCIDROrIP("10.65.0.2/32"),
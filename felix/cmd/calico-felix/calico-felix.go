@@ -27,10 +27,34 @@ const usage = `Felix, the Calico per-host daemon.
 
 Usage:
   calico-felix [options]
+  calico-felix debug-ipsets [--debug-addr=<addr>] [--member=<member>] [--json] [<set-id>...]
+  calico-felix debug-explain [--debug-addr=<addr>] [--json] [--protocol=<proto>] [--src-port=<port>]
+                             [--dst-port=<port>] [--icmp-type=<type>] [--icmp-code=<code>]
+                             [--ctstate=<state>] <iface> (ingress|egress) <src> <dst>
 
 Options:
   -c --config-file=<filename>  Config file to load [default: /etc/calico/felix.cfg].
   --version                    Print the version and exit.
+
+The debug-ipsets command reports the contents of a running Felix's IP sets, along with the
+policies and endpoints that produced each member.  The debug-explain command walks a packet to
+or from the endpoint with the given interface through a running Felix's calculated rules and
+reports the rules that it matches, the verdict and the policy that produced it.
+
+Debug options:
+  --debug-addr=<addr>          Address of Felix's debug endpoint [default: localhost:9094].
+  --json                       Print the report as JSON.
+
+debug-ipsets options:
+  --member=<member>            Only report the given member of each IP set.
+
+debug-explain options:
+  --protocol=<proto>           IP protocol of the packet, by name or number.
+  --src-port=<port>            Source port of the packet.
+  --dst-port=<port>            Destination port of the packet.
+  --icmp-type=<type>           ICMP type of the packet.
+  --icmp-code=<code>           ICMP code of the packet.
+  --ctstate=<state>            Conntrack state of the packet [default: NEW].
 `
 
 // main is the entry point to the calico-felix binary.
@@ -44,6 +68,18 @@ func main() {
 		println(usage)
 		log.Fatalf("Failed to parse usage, exiting: %v", err)
 	}
+	if debug, _ := arguments["debug-ipsets"].(bool); debug {
+		if err := debugIPSets(arguments); err != nil {
+			log.Fatalf("Failed to get IP sets report: %v", err)
+		}
+		return
+	}
+	if debug, _ := arguments["debug-explain"].(bool); debug {
+		if err := debugExplain(arguments); err != nil {
+			log.Fatalf("Failed to explain packet: %v", err)
+		}
+		return
+	}
 	configFile := arguments["--config-file"].(string)
 
 	// Execute felix.
@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	docopt "github.com/docopt/docopt-go"
+
+	"github.com/projectcalico/calico/felix/debugapi"
+	"github.com/projectcalico/calico/felix/iptables"
+)
+
+// debugExplain implements the debug-explain command, which asks a running Felix's debug endpoint
+// to explain how its rules treat a packet.
+func debugExplain(arguments docopt.Opts) error {
+	addr := arguments["--debug-addr"].(string)
+	req := &debugapi.ExplainRequest{
+		Iface:     arguments["<iface>"].(string),
+		Direction: debugapi.DirectionEgress,
+		Packet: iptables.Packet{
+			SrcIP: net.ParseIP(arguments["<src>"].(string)),
+			DstIP: net.ParseIP(arguments["<dst>"].(string)),
+		},
+	}
+	if ingress, _ := arguments["ingress"].(bool); ingress {
+		req.Direction = debugapi.DirectionIngress
+	}
+	if req.Packet.SrcIP == nil || req.Packet.DstIP == nil {
+		return fmt.Errorf("invalid source or destination IP")
+	}
+	req.Packet.Protocol, _ = arguments["--protocol"].(string)
+	req.Packet.ConntrackState, _ = arguments["--ctstate"].(string)
+	for _, opt := range []struct {
+		name string
+		bits int
+		set  func(uint64)
+	}{
+		{"--src-port", 16, func(v uint64) { req.Packet.SrcPort = uint16(v) }},
+		{"--dst-port", 16, func(v uint64) { req.Packet.DstPort = uint16(v) }},
+		{"--icmp-type", 8, func(v uint64) { req.Packet.ICMPType = uint8(v) }},
+		{"--icmp-code", 8, func(v uint64) { req.Packet.ICMPCode = uint8(v) }},
+	} {
+		value, _ := arguments[opt.name].(string)
+		if value == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(value, 10, opt.bits)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", opt.name, err)
+		}
+		opt.set(v)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	report, err := debugapi.GetExplanation(ctx, addr, req)
+	if err != nil {
+		return err
+	}
+	if asJSON, _ := arguments["--json"].(bool); asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	printExplainReport(os.Stdout, report)
+	return nil
+}
+
+func printExplainReport(w io.Writer, r *debugapi.ExplainReport) {
+	fmt.Fprintf(w, "Verdict:    %s\n", r.Verdict)
+	if r.Policy != "" {
+		fmt.Fprintf(w, "Decided by: %s\n", r.Policy)
+	} else {
+		fmt.Fprintln(w, "Decided by: Felix's own rules (no policy or profile rule matched)")
+	}
+	fmt.Fprintln(w, "Rules:")
+	for _, s := range r.Steps {
+		if s.Note != "" {
+			fmt.Fprintf(w, "  %s: %s\n", s.Chain, s.Note)
+			continue
+		}
+		fmt.Fprintf(w, "  %s #%d: %s\n", s.Chain, s.RuleNum, s.Rule)
+		if len(s.Comment) > 0 {
+			fmt.Fprintf(w, "    Comment: %s\n", strings.Join(s.Comment, "; "))
+		}
+		if !s.Matched {
+			fmt.Fprintf(w, "    Assumed not to match; couldn't evaluate: %s\n", strings.Join(s.Unevaluated, ", "))
+		}
+	}
+}
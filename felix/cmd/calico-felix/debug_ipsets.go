@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	docopt "github.com/docopt/docopt-go"
+
+	"github.com/projectcalico/calico/felix/debugapi"
+)
+
+// debugIPSets implements the debug-ipsets command, which prints the IP sets report from a running
+// Felix's debug endpoint.
+func debugIPSets(arguments docopt.Opts) error {
+	addr := arguments["--debug-addr"].(string)
+	member, _ := arguments["--member"].(string)
+	setIDs, _ := arguments["<set-id>"].([]string)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	reports, err := debugapi.GetIPSetReports(ctx, addr, setIDs, member)
+	if err != nil {
+		return err
+	}
+	if asJSON, _ := arguments["--json"].(bool); asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+	for _, r := range reports {
+		printIPSetReport(os.Stdout, r)
+	}
+	return nil
+}
+
+func printIPSetReport(w io.Writer, r *debugapi.IPSetReport) {
+	fmt.Fprintf(w, "IP set %s\n", r.SetID)
+	if a := r.Attribution; a != nil {
+		if a.Selector != "" {
+			fmt.Fprintf(w, "  Selector:   %s\n", a.Selector)
+		}
+		if a.NamedPort != "" {
+			fmt.Fprintf(w, "  Named port: %s (%s)\n", a.NamedPort, a.NamedPortProtocol)
+		}
+		if a.Service != "" {
+			fmt.Fprintf(w, "  Service:    %s\n", a.Service)
+		}
+		fmt.Fprintf(w, "  Used by:    %s\n", strings.Join(a.Rules, ", "))
+	} else {
+		fmt.Fprintln(w, "  Not calculated from policy; maintained internally by Felix")
+	}
+	if len(r.Dataplane) == 0 {
+		fmt.Fprintln(w, "  Not programmed in the dataplane")
+	}
+	for _, d := range r.Dataplane {
+		status := "in sync"
+		if d.Pending {
+			status = "update pending"
+		} else if len(d.Missing) > 0 || len(d.Unexpected) > 0 {
+			status = "OUT OF SYNC"
+		}
+		if d.Filtered {
+			status = "not needed by any rule"
+		} else if d.Dataplane == nil {
+			status = "MISSING from dataplane"
+		}
+		fmt.Fprintf(w, "  %s %s (%s): %d desired, %d in dataplane, %s\n",
+			d.Family, d.MainIPSetName, d.Type, len(d.Desired), len(d.Dataplane), status)
+		for _, m := range d.Desired {
+			if sources, ok := d.Sources[m]; ok {
+				fmt.Fprintf(w, "    %s <- %s\n", m, strings.Join(sources, ", "))
+			} else {
+				fmt.Fprintf(w, "    %s\n", m)
+			}
+		}
+		for _, m := range d.Missing {
+			fmt.Fprintf(w, "    Missing from dataplane: %s\n", m)
+		}
+		for _, m := range d.Unexpected {
+			fmt.Fprintf(w, "    Unexpected in dataplane: %s\n", m)
+		}
+	}
+	fmt.Fprintln(w)
+}
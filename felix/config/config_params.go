@@ -52,6 +52,9 @@ var (
 	IfaceParamRegexp         = regexp.MustCompile(`^[a-zA-Z0-9:._+-]{1,15}$`)
 	// Hostname  have to be valid ipv4, ipv6 or strings up to 64 characters.
 	HostAddressRegexp = regexp.MustCompile(`^[a-zA-Z0-9:._+-]{1,64}$`)
+	// TrafficClassRegexp matches the traffic classes that can be failed open; see
+	// DataplaneApplyFailOpenClasses.
+	TrafficClassRegexp = regexp.MustCompile(`^(WorkloadIngress|WorkloadEgress|WorkloadToHost)$`)
 )
 
 const (
@@ -174,6 +177,12 @@ type Config struct {
 	WireguardHostEncryptionEnabled bool          `config:"bool;false"`
 	WireguardPersistentKeepAlive   time.Duration `config:"seconds;0"`
 
+	// TPROXY configuration
+	TPROXYMode                string      `config:"oneof(Disabled,Enabled);Disabled;non-zero,local"`
+	TPROXYPort                int         `config:"int(1,65535);16001;local"`
+	TPROXYPorts               []ProtoPort `config:"port-list;;local"`
+	TPROXYRoutingRulePriority int         `config:"int(1,32765);98;local"`
+
 	BPFEnabled                         bool              `config:"bool;false"`
 	BPFDisableUnprivileged             bool              `config:"bool;true"`
 	BPFLogLevel                        string            `config:"oneof(off,info,debug);off;non-zero"`
@@ -242,6 +251,7 @@ type Config struct {
 	BpfIpv6Support bool `config:"bool;false"`
 
 	IptablesBackend                    string            `config:"oneof(legacy,nft,auto);auto"`
+	RulesBackend                       string            `config:"oneof(iptables,nftables);iptables;local"`
 	RouteRefreshInterval               time.Duration     `config:"seconds;90"`
 	InterfaceRefreshInterval           time.Duration     `config:"seconds;90"`
 	DeviceRouteSourceAddress           net.IP            `config:"ipv4;"`
@@ -249,6 +259,9 @@ type Config struct {
 	DeviceRouteProtocol                int               `config:"int;3"`
 	RemoveExternalRoutes               bool              `config:"bool;true"`
 	IptablesRefreshInterval            time.Duration     `config:"seconds;90"`
+	IptablesDriftMode                  string            `config:"oneof(Repair,Audit);Repair;non-zero,local"`
+	DataplaneApplyFailureMode          string            `config:"oneof(Panic,KeepLastGood,FailOpen,FailClosed);Panic;non-zero,local"`
+	DataplaneApplyFailOpenClasses      []string          `config:"traffic-class-slice;WorkloadIngress,WorkloadEgress,WorkloadToHost;local"`
 	IptablesPostWriteCheckIntervalSecs time.Duration     `config:"seconds;1"`
 	IptablesLockFilePath               string            `config:"file;/run/xtables.lock"`
 	IptablesLockTimeoutSecs            time.Duration     `config:"seconds;0"`
@@ -256,6 +269,13 @@ type Config struct {
 	FeatureDetectOverride              map[string]string `config:"keyvaluelist;;"`
 	FeatureGates                       map[string]string `config:"keyvaluelist;;"`
 	IpsetsRefreshInterval              time.Duration     `config:"seconds;10"`
+	IpsetsBackend                      string            `config:"oneof(ipset,nft);ipset;local"`
+	IpsetsMembershipCheckInterval      time.Duration     `config:"seconds;0;local"`
+	IpsetsDryRun                       bool              `config:"bool;false;local"`
+	IpsetsShardingEnabled              bool              `config:"bool;false;local"`
+	IpsetsMaxSizeLimit                 int               `config:"int;0;local"`
+	IpsetsSwapMode                     string            `config:"oneof(always,deny-rules,never);deny-rules;local"`
+	IpsetsStaleQuarantinePeriod        time.Duration     `config:"seconds;30;local"`
 	MaxIpsetSize                       int               `config:"int;1048576;non-zero"`
 	XDPRefreshInterval                 time.Duration     `config:"seconds;90"`
 
@@ -270,6 +290,9 @@ type Config struct {
 
 	InterfacePrefix  string           `config:"iface-list;cali;non-zero,die-on-fail"`
 	InterfaceExclude []*regexp.Regexp `config:"iface-list-regexp;kube-ipvs0"`
+	// TrustedHostInterfaces lists host interfaces, such as storage NICs, whose traffic skips host
+	// endpoint policy entirely.
+	TrustedHostInterfaces string `config:"iface-list;;local"`
 
 	ChainInsertMode             string `config:"oneof(insert,append);insert;non-zero,die-on-fail"`
 	DefaultEndpointToHostAction string `config:"oneof(DROP,RETURN,ACCEPT);DROP;non-zero,die-on-fail"`
@@ -277,6 +300,14 @@ type Config struct {
 	IptablesMangleAllowAction   string `config:"oneof(ACCEPT,RETURN);ACCEPT;non-zero,die-on-fail"`
 	IptablesFilterDenyAction    string `config:"oneof(DROP,REJECT);DROP;non-zero,die-on-fail"`
 	LogPrefix                   string `config:"string;calico-packet"`
+	PolicyLogVerdicts           string `config:"oneof(None,Deny,Allow,All);None;non-zero,local"`
+	PolicyLogPrefix             string `config:"string;calico-pol;local"`
+	PolicyLogNFLOGGroup         int    `config:"int(0,65535);0;local"`
+
+	// ChainNameVersion selects the scheme used to shorten the names of policy and profile chains
+	// that would be too long.  Changing it renames those chains; Felix programs the chains under
+	// their new names, along with the jumps to them, and only then removes the old ones.
+	ChainNameVersion int `config:"int(1,2);1;non-zero,local"`
 
 	LogFilePath string `config:"file;/var/log/calico/felix.log;die-on-fail"`
 
@@ -329,6 +360,13 @@ type Config struct {
 	IptablesMarkMask uint32 `config:"mark-bitmask;0xffff0000;non-zero,die-on-fail"`
 
 	DisableConntrackInvalidCheck bool `config:"bool;false"`
+	// NoTrackCIDRs lists CIDRs whose traffic bypasses connection tracking, for example for
+	// high-PPS storage replication flows.  Policy must allow the traffic in both directions.
+	NoTrackCIDRs []string `config:"cidr-list;;local"`
+	// VerdictCacheConnmark, if non-zero, is the connmark bit that is used to record that policy
+	// has accepted a forwarded connection, so that its later packets can skip the dispatch chains.
+	// It must not be used by anything else that sets the connmark.
+	VerdictCacheConnmark int `config:"int;0;local"`
 
 	HealthEnabled          bool                     `config:"bool;false"`
 	HealthPort             int                      `config:"int(0,65535);9099"`
@@ -348,6 +386,11 @@ type Config struct {
 	KubeNodePortRanges []numorstring.Port `config:"portrange-list;30000:32767"`
 	NATPortRange       numorstring.Port   `config:"portrange;"`
 	NATOutgoingAddress net.IP             `config:"ipv4;"`
+	// NATRandomFully controls whether SNAT and MASQUERADE rules use --random-fully to randomise
+	// the source port, which avoids port collisions when many connections are NATted to the same
+	// address.  "Auto" uses it where the kernel and iptables support it; "Enabled" does the same
+	// but warns if it isn't supported.
+	NATRandomFully string `config:"oneof(Auto,Enabled,Disabled);Auto;non-zero,local"`
 
 	UsageReportingEnabled          bool          `config:"bool;true"`
 	UsageReportingInitialDelaySecs time.Duration `config:"seconds;300"`
@@ -366,6 +409,12 @@ type Config struct {
 	DebugPanicAfter                 time.Duration `config:"seconds;0"`
 	DebugSimulateDataRace           bool          `config:"bool;false"`
 
+	// DebugHost and DebugPort control where Felix serves its debug endpoint, which reports the
+	// calculated and programmed contents of its IP sets.  The endpoint is disabled if DebugPort is
+	// 0.  The "calico-felix debug-ipsets" command connects to localhost:9094 by default.
+	DebugHost string `config:"host-address;localhost;local"`
+	DebugPort int    `config:"int(0,65535);0;local"`
+
 	// Configure where Felix gets its routing information.
 	// - workloadIPs: use workload endpoints to construct routes.
 	// - calicoIPAM: use IPAM data to construct routes.
@@ -375,6 +424,9 @@ type Config struct {
 	RouteTableRange   idalloc.IndexRange   `config:"route-table-range;;die-on-fail"`
 	RouteTableRanges  []idalloc.IndexRange `config:"route-table-ranges;;die-on-fail"`
 	RouteSyncDisabled bool                 `config:"bool;false"`
+	// RouteAggregationEnabled, if true, merges contiguous remote workload routes that have the
+	// same next hop into covering prefixes before programming them.
+	RouteAggregationEnabled bool `config:"bool;false;local"`
 
 	IptablesNATOutgoingInterfaceFilter string `config:"iface-param;"`
 
@@ -515,6 +567,13 @@ func (config *Config) InterfacePrefixes() []string {
 	return strings.Split(config.InterfacePrefix, ",")
 }
 
+func (config *Config) TrustedHostInterfaceNames() []string {
+	if config.TrustedHostInterfaces == "" {
+		return nil
+	}
+	return strings.Split(config.TrustedHostInterfaces, ",")
+}
+
 func (config *Config) OpenstackActive() bool {
 	if strings.Contains(strings.ToLower(config.ClusterType), "openstack") {
 		// OpenStack is explicitly known to be present.  Newer versions of the OpenStack plugin
@@ -973,6 +1032,8 @@ func loadParams() {
 			param = &StringSliceParam{ValidationRegex: InterfaceRegex}
 		case "iface-filter-slice":
 			param = &StringSliceParam{ValidationRegex: IfaceParamRegexp}
+		case "traffic-class-slice":
+			param = &StringSliceParam{ValidationRegex: TrafficClassRegexp}
 		case "route-table-range":
 			param = &RouteTableRangeParam{}
 		case "route-table-ranges":
@@ -382,6 +382,11 @@ var _ = DescribeTable("Config parsing",
 			{Protocol: "tcp", Port: 1},
 			{Protocol: "udp", Port: 2},
 		}),
+	Entry("FailsafeInboundHostPorts SCTP", "FailsafeInboundHostPorts", "sctp:3868,SCTP:0.0.0.0/0:38412",
+		[]config.ProtoPort{
+			{Protocol: "sctp", Port: 3868},
+			{Protocol: "sctp", Port: 38412, Net: "0.0.0.0/0"},
+		}),
 	Entry("FailsafeInboundHostPorts new cidr syntax", "FailsafeInboundHostPorts", "tcp:0.0.0.0/0:1,udp:0.0.0.0/0:2",
 		[]config.ProtoPort{
 			{Net: "0.0.0.0/0", Protocol: "tcp", Port: 1},
@@ -512,6 +517,17 @@ var _ = DescribeTable("Config parsing",
 	Entry("BPFForceTrackPacketsFromIfaces Multiple valid entries", "BPFForceTrackPacketsFromIfaces", "docker0,docker1", []string{"docker0", "docker1"}),
 	Entry("BPFForceTrackPacketsFromIfaces Single invalid entry", "BPFForceTrackPacketsFromIfaces", "cali@123", []string{"docker+"}),
 	Entry("BPFForceTrackPacketsFromIfaces Multiple invalid entries", "BPFForceTrackPacketsFromIfaces", "cali-123,cali@123", []string{"docker+"}),
+
+	Entry("DataplaneApplyFailureMode", "DataplaneApplyFailureMode", "FailClosed", "FailClosed"),
+	Entry("DataplaneApplyFailureMode bad value", "DataplaneApplyFailureMode", "Maybe", "Panic"),
+	Entry("DataplaneApplyFailOpenClasses single", "DataplaneApplyFailOpenClasses", "WorkloadEgress", []string{"WorkloadEgress"}),
+	Entry("DataplaneApplyFailOpenClasses multiple", "DataplaneApplyFailOpenClasses", "WorkloadIngress, WorkloadToHost",
+		[]string{"WorkloadIngress", "WorkloadToHost"}),
+	Entry("DataplaneApplyFailOpenClasses invalid entry", "DataplaneApplyFailOpenClasses", "WorkloadEgress,HostEgress",
+		[]string{"WorkloadIngress", "WorkloadEgress", "WorkloadToHost"}),
+
+	Entry("RouteAggregationEnabled", "RouteAggregationEnabled", "true", true),
+	Entry("RouteAggregationEnabled default", "RouteAggregationEnabled", "", false),
 )
 
 var _ = DescribeTable("OpenStack heuristic tests",
@@ -391,7 +391,7 @@ func (p *PortListParam) Parse(raw string) (interface{}, error) {
 			portStr = parts[1]
 		}
 
-		if protocolStr != "tcp" && protocolStr != "udp" {
+		if protocolStr != "tcp" && protocolStr != "udp" && protocolStr != "sctp" {
 			return nil, p.parseFailed(raw, "unknown protocol: "+protocolStr)
 		}
 
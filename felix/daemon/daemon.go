@@ -62,6 +62,7 @@ import (
 	"github.com/projectcalico/calico/felix/calc"
 	"github.com/projectcalico/calico/felix/config"
 	dp "github.com/projectcalico/calico/felix/dataplane"
+	"github.com/projectcalico/calico/felix/debugapi"
 	"github.com/projectcalico/calico/felix/jitter"
 	"github.com/projectcalico/calico/felix/logutils"
 	"github.com/projectcalico/calico/felix/policysync"
@@ -679,6 +680,15 @@ configRetry:
 		go dp.ServePrometheusMetrics(configParams)
 	}
 
+	if configParams.DebugPort != 0 {
+		// The dataplane parts of the reports are only available if the driver supports them;
+		// the external dataplane driver doesn't.
+		ipSetsDebugger, _ := dpDriver.(debugapi.IPSetsDebugger)
+		packetExplainer, _ := dpDriver.(debugapi.PacketExplainer)
+		debugServer := debugapi.NewServer(asyncCalcGraph, ipSetsDebugger, packetExplainer)
+		go debugServer.Serve(configParams.DebugHost, configParams.DebugPort)
+	}
+
 	// Register signal handlers to dump memory/CPU profiles.
 	logutils.RegisterProfilingSignalHandlers(configParams)
 
@@ -1032,9 +1042,10 @@ func (fc *DataplaneConnector) readMessagesFromDataplane() {
 func (fc *DataplaneConnector) handleProcessStatusUpdate(ctx context.Context, msg *proto.ProcessStatusUpdate) {
 	log.Debugf("Status update from dataplane driver: %v", *msg)
 	statusReport := model.StatusReport{
-		Timestamp:     msg.IsoTimestamp,
-		UptimeSeconds: msg.Uptime,
-		FirstUpdate:   !fc.firstStatusReportSent,
+		Timestamp:      msg.IsoTimestamp,
+		UptimeSeconds:  msg.Uptime,
+		FirstUpdate:    !fc.firstStatusReportSent,
+		DataplaneState: msg.DataplaneState,
 	}
 
 	var hostname, regionString string
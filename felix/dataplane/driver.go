@@ -50,6 +50,7 @@ import (
 	extdataplane "github.com/projectcalico/calico/felix/dataplane/external"
 	"github.com/projectcalico/calico/felix/dataplane/inactive"
 	intdataplane "github.com/projectcalico/calico/felix/dataplane/linux"
+	"github.com/projectcalico/calico/felix/hashutils"
 	"github.com/projectcalico/calico/felix/idalloc"
 	"github.com/projectcalico/calico/felix/ifacemonitor"
 	"github.com/projectcalico/calico/felix/ipsets"
@@ -132,6 +133,23 @@ func StartDataplaneDriver(configParams *config.Config,
 			}
 		}
 
+		tproxyEnabled := configParams.TPROXYMode == "Enabled"
+		if tproxyEnabled && configParams.BPFEnabled {
+			log.Warn("TPROXY is not supported in BPF mode; ignoring TPROXYMode.")
+			tproxyEnabled = false
+		}
+		var markTPROXY uint32
+		if tproxyEnabled {
+			log.Info("TPROXY enabled, allocating a mark bit")
+			markTPROXY, _ = markBitsManager.NextSingleBitMark()
+			if markTPROXY == 0 {
+				log.WithFields(log.Fields{
+					"Name":     "felix-iptables",
+					"MarkMask": allowedMarkBits,
+				}).Panic("Failed to allocate a mark bit for TPROXY, not enough mark bits available.")
+			}
+		}
+
 		if markAccept == 0 || markScratch0 == 0 || markPass == 0 || markScratch1 == 0 {
 			log.WithFields(log.Fields{
 				"Name":     "felix-iptables",
@@ -187,10 +205,44 @@ func StartDataplaneDriver(configParams *config.Config,
 			log.WithError(err).Warning("Unable to assign table index for IPv6 wireguard")
 		}
 
+		// Similarly, always allocate the TPROXY table index so that its route and routing rule
+		// are removed if TPROXY is disabled.
+		var tproxyTableIndex int
+		if idx, err := routeTableIndexAllocator.GrabIndex(); err == nil {
+			log.Debugf("Assigned TPROXY table index: %d", idx)
+			tproxyTableIndex = idx
+		} else {
+			log.WithError(err).Warning("Unable to assign table index for TPROXY")
+			tproxyEnabled = false
+		}
+
 		// Extract node labels from the hosts such they could be referenced later
 		// e.g. Topology Aware Hints.
 		felixHostname := configParams.FelixHostname
 
+		// The nftables rules backend can only refer to nftables sets.
+		ipSetsBackend := configParams.IpsetsBackend
+		if configParams.RulesBackend == "nftables" && ipSetsBackend != string(ipsets.BackendNFT) {
+			log.WithField("ipsetsBackend", ipSetsBackend).Warn(
+				"The nftables rules backend requires the nft IP sets backend; using nft IP sets.")
+			ipSetsBackend = string(ipsets.BackendNFT)
+		}
+
+		var ipSetConfigOpts []ipsets.IPVersionConfigOpt
+		if configParams.IpsetsShardingEnabled {
+			if configParams.BPFEnabled || ipSetsBackend != string(ipsets.BackendIPSet) {
+				log.Warn("IP set sharding is only supported by the ipset backend in iptables mode; ignoring.")
+			} else {
+				ipSetConfigOpts = append(ipSetConfigOpts, ipsets.WithShardedIPSets())
+			}
+		}
+
+		ipSetsMaxSizeLimit := configParams.IpsetsMaxSizeLimit
+		if ipSetsMaxSizeLimit > 0 && ipSetsBackend != string(ipsets.BackendIPSet) {
+			log.Warn("Automatic IP set resizing is only supported by the ipset backend; ignoring.")
+			ipSetsMaxSizeLimit = 0
+		}
+
 		var felixNodeZone string
 		if k8sClientSet != nil {
 
@@ -216,18 +268,21 @@ func StartDataplaneDriver(configParams *config.Config,
 			},
 			RulesConfig: rules.Config{
 				WorkloadIfacePrefixes: configParams.InterfacePrefixes(),
+				TrustedHostInterfaces: configParams.TrustedHostInterfaceNames(),
 
 				IPSetConfigV4: ipsets.NewIPVersionConfig(
 					ipsets.IPFamilyV4,
 					rules.IPSetNamePrefix,
 					rules.AllHistoricIPSetNamePrefixes,
 					rules.LegacyV4IPSetNames,
+					ipSetConfigOpts...,
 				),
 				IPSetConfigV6: ipsets.NewIPVersionConfig(
 					ipsets.IPFamilyV6,
 					rules.IPSetNamePrefix,
 					rules.AllHistoricIPSetNamePrefixes,
 					nil,
+					ipSetConfigOpts...,
 				),
 
 				KubeNodePortRanges:     configParams.KubeNodePortRanges,
@@ -268,16 +323,29 @@ func StartDataplaneDriver(configParams *config.Config,
 				WireguardEncryptHostTraffic: configParams.WireguardHostEncryptionEnabled,
 				RouteSource:                 configParams.RouteSource,
 
+				TPROXYEnabled:      tproxyEnabled,
+				TPROXYPort:         uint16(configParams.TPROXYPort),
+				TPROXYPorts:        configParams.TPROXYPorts,
+				TPROXYIptablesMark: markTPROXY,
+
 				IptablesLogPrefix:         configParams.LogPrefix,
 				EndpointToHostAction:      configParams.DefaultEndpointToHostAction,
 				IptablesFilterAllowAction: configParams.IptablesFilterAllowAction,
 				IptablesMangleAllowAction: configParams.IptablesMangleAllowAction,
 				IptablesFilterDenyAction:  configParams.IptablesFilterDenyAction,
 
+				PolicyLogVerdicts:   configParams.PolicyLogVerdicts,
+				PolicyLogPrefix:     configParams.PolicyLogPrefix,
+				PolicyLogNFLOGGroup: uint16(configParams.PolicyLogNFLOGGroup),
+
+				ChainNameVersion: hashutils.IDVersion(configParams.ChainNameVersion),
+
 				FailsafeInboundHostPorts:  configParams.FailsafeInboundHostPorts,
 				FailsafeOutboundHostPorts: configParams.FailsafeOutboundHostPorts,
 
 				DisableConntrackInvalid: configParams.DisableConntrackInvalidCheck,
+				NoTrackCIDRs:            configParams.NoTrackCIDRs,
+				VerdictCacheConnmark:    uint32(configParams.VerdictCacheConnmark),
 
 				NATPortRange:                       configParams.NATPortRange,
 				IptablesNATOutgoingInterfaceFilter: configParams.IptablesNATOutgoingInterfaceFilter,
@@ -304,19 +372,32 @@ func StartDataplaneDriver(configParams *config.Config,
 				PersistentKeepAlive: configParams.WireguardPersistentKeepAlive,
 				RouteSyncDisabled:   configParams.RouteSyncDisabled,
 			},
+			TPROXYRoutingTableIndex:        tproxyTableIndex,
+			TPROXYRoutingRulePriority:      configParams.TPROXYRoutingRulePriority,
 			IPIPMTU:                        configParams.IpInIpMtu,
 			VXLANMTU:                       configParams.VXLANMTU,
 			VXLANMTUV6:                     configParams.VXLANMTUV6,
 			VXLANPort:                      configParams.VXLANPort,
 			IptablesBackend:                configParams.IptablesBackend,
+			RulesBackend:                   configParams.RulesBackend,
 			IptablesRefreshInterval:        configParams.IptablesRefreshInterval,
+			IptablesAuditOnly:              configParams.IptablesDriftMode == "Audit",
+			DataplaneApplyFailureMode:      configParams.DataplaneApplyFailureMode,
+			DataplaneApplyFailOpenClasses:  configParams.DataplaneApplyFailOpenClasses,
 			RouteSyncDisabled:              configParams.RouteSyncDisabled,
+			RouteAggregationEnabled:        configParams.RouteAggregationEnabled,
 			RouteRefreshInterval:           configParams.RouteRefreshInterval,
 			DeviceRouteSourceAddress:       configParams.DeviceRouteSourceAddress,
 			DeviceRouteSourceAddressIPv6:   configParams.DeviceRouteSourceAddressIPv6,
 			DeviceRouteProtocol:            netlink.RouteProtocol(configParams.DeviceRouteProtocol),
 			RemoveExternalRoutes:           configParams.RemoveExternalRoutes,
 			IPSetsRefreshInterval:          configParams.IpsetsRefreshInterval,
+			IPSetsBackend:                  ipSetsBackend,
+			IPSetsMembershipCheckInterval:  configParams.IpsetsMembershipCheckInterval,
+			IPSetsDryRun:                   configParams.IpsetsDryRun,
+			IPSetsMaxSizeLimit:             ipSetsMaxSizeLimit,
+			IPSetsSwapMode:                 ipsets.SwapMode(configParams.IpsetsSwapMode),
+			IPSetsStaleQuarantinePeriod:    configParams.IpsetsStaleQuarantinePeriod,
 			IptablesPostWriteCheckInterval: configParams.IptablesPostWriteCheckIntervalSecs,
 			IptablesInsertMode:             configParams.ChainInsertMode,
 			IptablesLockFilePath:           configParams.IptablesLockFilePath,
@@ -382,6 +463,7 @@ func StartDataplaneDriver(configParams *config.Config,
 			KubeClientSet: k8sClientSet,
 
 			FeatureDetectOverrides: configParams.FeatureDetectOverride,
+			NATRandomFully:         configParams.NATRandomFully,
 			FeatureGates:           configParams.FeatureGates,
 
 			RouteSource: configParams.RouteSource,
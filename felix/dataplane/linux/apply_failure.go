@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Values for Config.DataplaneApplyFailureMode, which controls what we do when we fail to program
+// iptables or IP sets even after retries.
+const (
+	// ApplyFailureModePanic restarts Felix, which then starts programming from scratch.
+	ApplyFailureModePanic = "Panic"
+	// ApplyFailureModeKeepLastGood leaves the dataplane as it is and keeps retrying.
+	ApplyFailureModeKeepLastGood = "KeepLastGood"
+	// ApplyFailureModeFailOpen accepts workload traffic in the configured classes and keeps
+	// retrying.
+	ApplyFailureModeFailOpen = "FailOpen"
+	// ApplyFailureModeFailClosed drops all workload traffic and keeps retrying.
+	ApplyFailureModeFailClosed = "FailClosed"
+)
+
+// Dataplane states, as reported in the process status.
+const (
+	DataplaneStatePending         = "Pending"
+	DataplaneStateProgrammed      = "Programmed"
+	DataplaneStateKeepingLastGood = "KeepingLastGood"
+	DataplaneStateFailedOpen      = "FailedOpen"
+	DataplaneStateFailedClosed    = "FailedClosed"
+
+	// The "Unenforced" states are reported if we've failed open or closed but couldn't program
+	// the corresponding rules; typically because the filter table is the table that's failing.
+	DataplaneStateFailedOpenUnenforced   = "FailedOpenUnenforced"
+	DataplaneStateFailedClosedUnenforced = "FailedClosedUnenforced"
+)
+
+// applyFailureTracker collects the persistent failures reported by the iptables tables and IP
+// sets during an apply() and works out the resulting dataplane state.
+type applyFailureTracker struct {
+	mode string
+
+	lock       sync.Mutex
+	failures   []error
+	state      string
+	unenforced bool
+}
+
+func newApplyFailureTracker(mode string) *applyFailureTracker {
+	if mode == "" {
+		mode = ApplyFailureModePanic
+	}
+	return &applyFailureTracker{
+		mode:  mode,
+		state: DataplaneStatePending,
+	}
+}
+
+// Callback returns the function for the tables and IP sets to call when they give up on
+// programming the dataplane, or nil if they should panic instead.
+func (t *applyFailureTracker) Callback() func(err error) {
+	if t.mode == ApplyFailureModePanic {
+		return nil
+	}
+	return t.onPersistentFailure
+}
+
+func (t *applyFailureTracker) onPersistentFailure(err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.failures = append(t.failures, err)
+}
+
+// CompleteApply is called at the end of each apply() to update the state based on the failures
+// recorded since the last call.  It returns the previous state and the new state.
+func (t *applyFailureTracker) CompleteApply() (oldState, newState string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	oldState = t.state
+	if len(t.failures) == 0 {
+		t.state = DataplaneStateProgrammed
+	} else {
+		switch t.mode {
+		case ApplyFailureModeFailOpen:
+			t.state = DataplaneStateFailedOpen
+		case ApplyFailureModeFailClosed:
+			t.state = DataplaneStateFailedClosed
+		default:
+			t.state = DataplaneStateKeepingLastGood
+		}
+		if oldState != t.state {
+			log.WithFields(log.Fields{
+				"errors": t.failures,
+				"mode":   t.mode,
+			}).Error("Gave up on programming the dataplane; applying the configured failure mode.")
+		}
+	}
+	if oldState != t.state && t.state == DataplaneStateProgrammed && oldState != DataplaneStatePending {
+		log.WithField("previousState", oldState).Info("Dataplane programming recovered.")
+	}
+	if t.state == DataplaneStateProgrammed {
+		t.unenforced = false
+	}
+	t.failures = nil
+	return oldState, t.state
+}
+
+// SetFailureRulesEnforced records whether the rules for the failed-open or failed-closed state
+// are in place.
+func (t *applyFailureTracker) SetFailureRulesEnforced(enforced bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.unenforced == !enforced {
+		return
+	}
+	if enforced {
+		log.WithField("state", t.state).Info("Dataplane failure mode rules now in place.")
+	} else {
+		log.WithField("state", t.state).Error("Failed to program the rules for the dataplane failure mode.")
+	}
+	t.unenforced = !enforced
+}
+
+// State returns the current state, as reported in the process status; it may be called from
+// any goroutine.
+func (t *applyFailureTracker) State() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.unenforced {
+		switch t.state {
+		case DataplaneStateFailedOpen:
+			return DataplaneStateFailedOpenUnenforced
+		case DataplaneStateFailedClosed:
+			return DataplaneStateFailedClosedUnenforced
+		}
+	}
+	return t.state
+}
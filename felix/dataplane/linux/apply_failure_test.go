@@ -0,0 +1,199 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ipsets"
+	"github.com/projectcalico/calico/felix/iptables"
+	"github.com/projectcalico/calico/felix/rules"
+)
+
+var _ = Describe("Apply failure tracker", func() {
+	It("should have no callback in Panic mode", func() {
+		Expect(newApplyFailureTracker(ApplyFailureModePanic).Callback()).To(BeNil())
+		Expect(newApplyFailureTracker("").Callback()).To(BeNil())
+	})
+
+	It("should start pending and become programmed after an apply without failures", func() {
+		t := newApplyFailureTracker(ApplyFailureModeFailOpen)
+		Expect(t.State()).To(Equal(DataplaneStatePending))
+		oldState, newState := t.CompleteApply()
+		Expect(oldState).To(Equal(DataplaneStatePending))
+		Expect(newState).To(Equal(DataplaneStateProgrammed))
+		Expect(t.State()).To(Equal(DataplaneStateProgrammed))
+	})
+
+	DescribeTable("should move to the mode's state after a failure and recover",
+		func(mode, expectedState string) {
+			t := newApplyFailureTracker(mode)
+			t.CompleteApply()
+
+			t.Callback()(errors.New("iptables-restore failed"))
+			oldState, newState := t.CompleteApply()
+			Expect(oldState).To(Equal(DataplaneStateProgrammed))
+			Expect(newState).To(Equal(expectedState))
+			Expect(t.State()).To(Equal(expectedState))
+
+			// Failures only count towards the apply that they happened in.
+			oldState, newState = t.CompleteApply()
+			Expect(oldState).To(Equal(expectedState))
+			Expect(newState).To(Equal(DataplaneStateProgrammed))
+		},
+		Entry("KeepLastGood", ApplyFailureModeKeepLastGood, DataplaneStateKeepingLastGood),
+		Entry("FailOpen", ApplyFailureModeFailOpen, DataplaneStateFailedOpen),
+		Entry("FailClosed", ApplyFailureModeFailClosed, DataplaneStateFailedClosed),
+	)
+
+	It("should report when the failure mode rules couldn't be programmed", func() {
+		t := newApplyFailureTracker(ApplyFailureModeFailClosed)
+		t.Callback()(errors.New("iptables-restore failed"))
+		t.CompleteApply()
+		t.SetFailureRulesEnforced(false)
+		Expect(t.State()).To(Equal(DataplaneStateFailedClosedUnenforced))
+		t.SetFailureRulesEnforced(true)
+		Expect(t.State()).To(Equal(DataplaneStateFailedClosed))
+
+		t.SetFailureRulesEnforced(false)
+		t.CompleteApply()
+		Expect(t.State()).To(Equal(DataplaneStateProgrammed))
+	})
+})
+
+// mockRulesTable is a RulesTable that records the rules inserted into each chain.  Rules written
+// by InsertRulesNow are recorded in the chain's "dataplane" rules.
+type mockRulesTable struct {
+	*mockTable
+	inserts          map[string][]iptables.Rule
+	dataplaneInserts map[string][]iptables.Rule
+	failInsertNow    bool
+}
+
+func newMockRulesTable() *mockRulesTable {
+	return &mockRulesTable{
+		mockTable:        newMockTable("filter"),
+		inserts:          map[string][]iptables.Rule{},
+		dataplaneInserts: map[string][]iptables.Rule{},
+	}
+}
+
+func (t *mockRulesTable) TableName() string     { return "filter" }
+func (t *mockRulesTable) TableIPVersion() uint8 { return 4 }
+
+func (t *mockRulesTable) InsertOrAppendRules(chainName string, rules []iptables.Rule) {
+	t.inserts[chainName] = rules
+}
+
+func (t *mockRulesTable) AppendRules(string, []iptables.Rule)    {}
+func (t *mockRulesTable) Apply() time.Duration                   { return 0 }
+func (t *mockRulesTable) IPSetIsReferenced(string) bool          { return false }
+func (t *mockRulesTable) InvalidateDataplaneCache(reason string) {}
+
+func (t *mockRulesTable) CheckRulesPresent(chain string, rules []iptables.Rule) []iptables.Rule {
+	var present []iptables.Rule
+	for i, r := range rules {
+		if i < len(t.dataplaneInserts[chain]) {
+			present = append(present, r)
+		}
+	}
+	return present
+}
+
+func (t *mockRulesTable) InsertRulesNow(chain string, rules []iptables.Rule) error {
+	if t.failInsertNow {
+		return errors.New("iptables-restore failed")
+	}
+	t.dataplaneInserts[chain] = rules
+	return nil
+}
+
+func (t *mockRulesTable) Explain(string, iptables.Packet, iptables.ExplainOptions) (*iptables.Explanation, error) {
+	return nil, nil
+}
+
+var _ = Describe("Apply failure rules in the filter table", func() {
+	var (
+		d       *InternalDataplane
+		tbl     *mockRulesTable
+		jumpFwd = iptables.Rule{Action: iptables.JumpAction{Target: rules.ChainFilterForward}}
+	)
+
+	BeforeEach(func() {
+		tbl = newMockRulesTable()
+		d = &InternalDataplane{
+			ruleRenderer: rules.NewRenderer(rules.Config{
+				WorkloadIfacePrefixes: []string{"cali"},
+				IPSetConfigV4:         ipsets.NewIPVersionConfig(ipsets.IPFamilyV4, "cali", nil, nil),
+				IPSetConfigV6:         ipsets.NewIPVersionConfig(ipsets.IPFamilyV6, "cali", nil, nil),
+				IptablesMarkAccept:    0x10,
+				IptablesMarkPass:      0x20,
+				IptablesMarkScratch0:  0x40,
+				IptablesMarkScratch1:  0x80,
+				IptablesMarkEndpoint:  0xff00,
+			}),
+			applyFailures:            newApplyFailureTracker(ApplyFailureModeFailClosed),
+			filterKernelChainInserts: map[RulesTable]map[string][]iptables.Rule{},
+			iptablesFilterTables:     []RulesTable{tbl},
+		}
+		d.insertFilterKernelChainRules(tbl, "FORWARD", []iptables.Rule{jumpFwd})
+		Expect(d.updateApplyFailureState()).To(BeZero())
+		Expect(tbl.inserts["FORWARD"]).To(Equal([]iptables.Rule{jumpFwd}))
+	})
+
+	failClosedRules := func(chain string) []iptables.Rule {
+		return d.ruleRenderer.ApplyFailureRules(false, nil)[chain]
+	}
+
+	It("should insert the rules ahead of our other inserts and remove them on recovery", func() {
+		d.applyFailures.Callback()(errors.New("iptables-restore failed"))
+		Expect(d.updateApplyFailureState()).To(Equal(iptables.PersistentFailureRetryInterval))
+		Expect(d.dataplaneNeedsSync).To(BeTrue())
+
+		Expect(tbl.inserts["FORWARD"]).To(Equal(append(failClosedRules("FORWARD"), jumpFwd)))
+		Expect(tbl.inserts["INPUT"]).To(Equal(failClosedRules("INPUT")))
+		Expect(tbl.dataplaneInserts["FORWARD"]).To(Equal(failClosedRules("FORWARD")))
+		Expect(d.applyFailures.State()).To(Equal(DataplaneStateFailedClosed))
+
+		// Later inserts keep the failure rules at the front.
+		d.insertFilterKernelChainRules(tbl, "FORWARD", []iptables.Rule{jumpFwd, jumpFwd})
+		Expect(tbl.inserts["FORWARD"]).To(Equal(append(failClosedRules("FORWARD"), jumpFwd, jumpFwd)))
+
+		d.dataplaneNeedsSync = false
+		Expect(d.updateApplyFailureState()).To(BeZero())
+		Expect(d.dataplaneNeedsSync).To(BeTrue())
+		Expect(tbl.inserts["FORWARD"]).To(Equal([]iptables.Rule{jumpFwd, jumpFwd}))
+		Expect(tbl.inserts["INPUT"]).To(BeEmpty())
+		Expect(d.applyFailures.State()).To(Equal(DataplaneStateProgrammed))
+	})
+
+	It("should report the state as unenforced if the rules can't be written", func() {
+		tbl.failInsertNow = true
+		d.applyFailures.Callback()(errors.New("iptables-restore failed"))
+		d.updateApplyFailureState()
+		Expect(tbl.inserts["FORWARD"]).To(Equal(append(failClosedRules("FORWARD"), jumpFwd)))
+		Expect(d.applyFailures.State()).To(Equal(DataplaneStateFailedClosedUnenforced))
+
+		tbl.failInsertNow = false
+		d.applyFailures.Callback()(errors.New("iptables-restore failed"))
+		d.updateApplyFailureState()
+		Expect(d.applyFailures.State()).To(Equal(DataplaneStateFailedClosed))
+	})
+})
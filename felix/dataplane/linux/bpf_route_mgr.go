@@ -473,7 +473,7 @@ func (m *bpfRouteManager) onIfaceAddrsUpdate(update *ifaceAddrsUpdate) {
 		newCIDRs = set.New[ip.V4CIDR]()
 		update.Addrs.Iter(func(cidrStr string) error {
 			cidr := ip.MustParseCIDROrIP(cidrStr)
-			if v4CIDR, ok := cidr.(ip.V4CIDR); ok && cidr.Addr().AsNetIP().IsGlobalUnicast() {
+			if v4CIDR, ok := cidr.(ip.V4CIDR); ok && cidr.Addr().IsGlobalUnicast() {
 				newCIDRs.Add(v4CIDR)
 			}
 			return nil
@@ -15,6 +15,8 @@
 package intdataplane
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -60,6 +62,7 @@ import (
 	"github.com/projectcalico/calico/felix/jitter"
 	"github.com/projectcalico/calico/felix/labelindex"
 	"github.com/projectcalico/calico/felix/logutils"
+	"github.com/projectcalico/calico/felix/nftables"
 	"github.com/projectcalico/calico/felix/proto"
 	"github.com/projectcalico/calico/felix/routerule"
 	"github.com/projectcalico/calico/felix/routetable"
@@ -143,8 +146,16 @@ type Config struct {
 	MaxIPSetSize int
 
 	RouteSyncDisabled              bool
+	RouteAggregationEnabled        bool
 	IptablesBackend                string
+	RulesBackend                   string
 	IPSetsRefreshInterval          time.Duration
+	IPSetsBackend                  string
+	IPSetsMembershipCheckInterval  time.Duration
+	IPSetsDryRun                   bool
+	IPSetsMaxSizeLimit             int
+	IPSetsSwapMode                 ipsets.SwapMode
+	IPSetsStaleQuarantinePeriod    time.Duration
 	RouteRefreshInterval           time.Duration
 	DeviceRouteSourceAddress       net.IP
 	DeviceRouteSourceAddressIPv6   net.IP
@@ -152,6 +163,9 @@ type Config struct {
 	RemoveExternalRoutes           bool
 	IptablesRefreshInterval        time.Duration
 	IptablesPostWriteCheckInterval time.Duration
+	IptablesAuditOnly              bool
+	DataplaneApplyFailureMode      string
+	DataplaneApplyFailOpenClasses  []string
 	IptablesInsertMode             string
 	IptablesLockFilePath           string
 	IptablesLockTimeout            time.Duration
@@ -162,6 +176,12 @@ type Config struct {
 
 	Wireguard wireguard.Config
 
+	// TPROXYRoutingTableIndex is the routing table used to deliver traffic diverted by the
+	// TPROXY rules to the local proxy; TPROXYRoutingRulePriority is the priority of the
+	// routing rule that selects it.
+	TPROXYRoutingTableIndex   int
+	TPROXYRoutingRulePriority int
+
 	NetlinkTimeout time.Duration
 
 	RulesConfig rules.Config
@@ -222,6 +242,9 @@ type Config struct {
 	FeatureDetectOverrides map[string]string
 	FeatureGates           map[string]string
 
+	// NATRandomFully is one of "Auto", "Enabled" or "Disabled"; see natRandomFullyOverrides.
+	NATRandomFully string
+
 	// Populated with the smallest host MTU based on auto-detection.
 	hostMTU         int
 	MTUIfacePattern *regexp.Regexp
@@ -271,11 +294,11 @@ type InternalDataplane struct {
 	toDataplane   chan interface{}
 	fromDataplane chan interface{}
 
-	allIptablesTables    []*iptables.Table
-	iptablesMangleTables []*iptables.Table
-	iptablesNATTables    []*iptables.Table
-	iptablesRawTables    []*iptables.Table
-	iptablesFilterTables []*iptables.Table
+	allIptablesTables    []RulesTable
+	iptablesMangleTables []RulesTable
+	iptablesNATTables    []RulesTable
+	iptablesRawTables    []RulesTable
+	iptablesFilterTables []RulesTable
 	ipSets               []common.IPSetsDataplane
 
 	ipipManager *ipipManager
@@ -306,6 +329,9 @@ type InternalDataplane struct {
 	// forceIPSetsRefresh is set by the IP sets refresh timer to indicate that we should
 	// check the IP sets in the dataplane.
 	forceIPSetsRefresh bool
+	// forceIPSetsMembershipCheck is set by the IP sets membership check timer to indicate
+	// that we should look for IP sets that have been modified by another process.
+	forceIPSetsMembershipCheck bool
 	// forceRouteRefresh is set by the route refresh timer to indicate that we should
 	// check the routes in the dataplane.
 	forceRouteRefresh bool
@@ -321,9 +347,20 @@ type InternalDataplane struct {
 
 	applyThrottle *throttle.Throttle
 
+	// applyFailures tracks whether we've given up on programming iptables or IP sets; see
+	// Config.DataplaneApplyFailureMode.
+	applyFailures *applyFailureTracker
+	// filterKernelChainInserts holds the rules that we insert into each filter table's kernel
+	// chains.  applyFailureRules holds the rules for the current failed-open or failed-closed
+	// state, if any, which go ahead of them.
+	filterKernelChainInserts map[RulesTable]map[string][]iptables.Rule
+	applyFailureRules        map[string][]iptables.Rule
+
 	config Config
 
 	debugHangC <-chan time.Time
+	// debugRequests carries diagnostic requests, which are run on the main loop's goroutine.
+	debugRequests chan func()
 
 	xdpState          *xdpState
 	sockmapState      *sockmapState
@@ -374,16 +411,30 @@ func NewIntDataplaneDriver(config Config) *InternalDataplane {
 		log.WithError(err).Error("Failed to write MTU file, pod MTU may not be properly set")
 	}
 
-	featureDetector := environment.NewFeatureDetector(config.FeatureDetectOverrides)
+	featureDetector := environment.NewFeatureDetector(
+		natRandomFullyOverrides(config.NATRandomFully, config.FeatureDetectOverrides))
+	if config.NATRandomFully == "Enabled" {
+		features := featureDetector.GetFeatures()
+		if !features.SNATFullyRandom || !features.MASQFullyRandom {
+			log.WithFields(log.Fields{
+				"snat":       features.SNATFullyRandom,
+				"masquerade": features.MASQFullyRandom,
+			}).Warn("NATRandomFully is enabled but --random-fully isn't supported by this kernel " +
+				"and iptables; NAT rules will be programmed without it where it isn't supported.")
+		}
+	}
 	dp := &InternalDataplane{
-		toDataplane:    make(chan interface{}, msgPeekLimit),
-		fromDataplane:  make(chan interface{}, 100),
-		ruleRenderer:   ruleRenderer,
-		ifaceMonitor:   ifacemonitor.New(config.IfaceMonitorConfig, featureDetector, config.FatalErrorRestartCallback),
-		ifaceUpdates:   make(chan any, 100),
-		config:         config,
-		applyThrottle:  throttle.New(10),
-		loopSummarizer: logutils.NewSummarizer("dataplane reconciliation loops"),
+		toDataplane:              make(chan interface{}, msgPeekLimit),
+		fromDataplane:            make(chan interface{}, 100),
+		ruleRenderer:             ruleRenderer,
+		ifaceMonitor:             ifacemonitor.New(config.IfaceMonitorConfig, featureDetector, config.FatalErrorRestartCallback),
+		ifaceUpdates:             make(chan any, 100),
+		debugRequests:            make(chan func()),
+		config:                   config,
+		applyThrottle:            throttle.New(10),
+		applyFailures:            newApplyFailureTracker(config.DataplaneApplyFailureMode),
+		filterKernelChainInserts: map[RulesTable]map[string][]iptables.Rule{},
+		loopSummarizer:           logutils.NewSummarizer("dataplane reconciliation loops"),
 	}
 	dp.applyThrottle.Refill() // Allow the first apply() immediately.
 	dp.ifaceMonitor.StateCallback = dp.onIfaceStateChange
@@ -395,9 +446,12 @@ func NewIntDataplaneDriver(config Config) *InternalDataplane {
 	// Most iptables tables need the same options.
 	iptablesOptions := iptables.TableOptions{
 		HistoricChainPrefixes: rules.AllHistoricChainNamePrefixes,
+		ChainGroup:            rules.ChainGroup,
 		InsertMode:            config.IptablesInsertMode,
 		RefreshInterval:       config.IptablesRefreshInterval,
 		PostWriteInterval:     config.IptablesPostWriteCheckInterval,
+		AuditOnly:             config.IptablesAuditOnly,
+		OnPersistentFailure:   dp.applyFailures.Callback(),
 		LockTimeout:           config.IptablesLockTimeout,
 		LockProbeInterval:     config.IptablesLockProbeInterval,
 		BackendMode:           backendMode,
@@ -450,37 +504,53 @@ func NewIntDataplaneDriver(config Config) *InternalDataplane {
 		)
 	}
 
-	mangleTableV4 := iptables.NewTable(
-		"mangle",
-		4,
-		rules.RuleHashPrefix,
-		iptablesLock,
-		featureDetector,
-		iptablesOptions)
-	natTableV4 := iptables.NewTable(
-		"nat",
-		4,
-		rules.RuleHashPrefix,
-		iptablesLock,
-		featureDetector,
-		iptablesNATOptions,
-	)
-	rawTableV4 := iptables.NewTable(
-		"raw",
-		4,
-		rules.RuleHashPrefix,
-		iptablesLock,
-		featureDetector,
-		iptablesOptions)
-	filterTableV4 := iptables.NewTable(
-		"filter",
-		4,
-		rules.RuleHashPrefix,
-		iptablesLock,
-		featureDetector,
-		iptablesOptions)
+	// newTable creates a table for the configured rules backend.  The nftables backend doesn't
+	// need the iptables lock, or most of the iptables options.
+	newTable := func(name string, ipVersion uint8, options iptables.TableOptions) RulesTable {
+		if config.RulesBackend == "nftables" {
+			return nftables.NewTable(name, ipVersion, rules.RuleHashPrefix, featureDetector, nftables.TableOptions{
+				RefreshInterval:     options.RefreshInterval,
+				PostWriteInterval:   options.PostWriteInterval,
+				OnPersistentFailure: options.OnPersistentFailure,
+				OnStillAlive:        options.OnStillAlive,
+				OpRecorder:          options.OpRecorder,
+			})
+		}
+		return iptables.NewTable(name, ipVersion, rules.RuleHashPrefix, iptablesLock, featureDetector, options)
+	}
+
+	mangleTableV4 := newTable("mangle", 4, iptablesOptions)
+	natTableV4 := newTable("nat", 4, iptablesNATOptions)
+	rawTableV4 := newTable("raw", 4, iptablesOptions)
+	filterTableV4 := newTable("filter", 4, iptablesOptions)
+	ipSetsOpts := []ipsets.IPSetsOpt{ipsets.WithBackend(ipsets.Backend(config.IPSetsBackend))}
+	if config.IPSetsDryRun {
+		log.Warn("IP sets dry-run mode enabled; IP set updates will be logged but not applied.")
+		ipSetsOpts = append(ipSetsOpts, ipsets.WithDryRun(nil))
+	}
+	if config.IPSetsMaxSizeLimit > 0 {
+		ipSetsOpts = append(ipSetsOpts, ipsets.WithAutoResize(config.IPSetsMaxSizeLimit))
+	}
+	if config.IPSetsSwapMode == ipsets.SwapModeAlways {
+		ipSetsOpts = append(ipSetsOpts, ipsets.WithAlwaysSwap())
+	}
+	if config.IPSetsStaleQuarantinePeriod > 0 {
+		ipSetsOpts = append(ipSetsOpts, ipsets.WithStaleIPSetQuarantine(config.IPSetsStaleQuarantinePeriod))
+	}
+	if onFailure := dp.applyFailures.Callback(); onFailure != nil {
+		ipSetsOpts = append(ipSetsOpts, ipsets.WithPersistentFailureCallback(onFailure))
+	}
+	// denyIPSetsCallback returns the callback that the policy manager uses to tell an IPSets
+	// object which IP sets are referenced by deny rules, or nil if we don't need to know.
+	denyIPSetsCallback := func(ipSets *ipsets.IPSets) func(set.Set[string]) {
+		if config.IPSetsSwapMode != ipsets.SwapModeDenyRules {
+			return nil
+		}
+		return ipSets.SetSwapOnlyIPSets
+	}
 	ipSetsConfigV4 := config.RulesConfig.IPSetConfigV4
-	ipSetsV4 := ipsets.NewIPSets(ipSetsConfigV4, dp.loopSummarizer)
+	ipSetsV4 := ipsets.NewIPSets(ipSetsConfigV4, dp.loopSummarizer, append(ipSetsOpts,
+		ipsets.WithReferenceChecker(ipSetReferenceChecker(rawTableV4, mangleTableV4, natTableV4, filterTableV4)))...)
 	dp.iptablesNATTables = append(dp.iptablesNATTables, natTableV4)
 	dp.iptablesRawTables = append(dp.iptablesRawTables, rawTableV4)
 	dp.iptablesMangleTables = append(dp.iptablesMangleTables, mangleTableV4)
@@ -592,7 +662,9 @@ func NewIntDataplaneDriver(config Config) *InternalDataplane {
 			rules.IPSetIDThisHostIPs,
 			ipSetsV4,
 			config.MaxIPSetSize))
-		dp.RegisterManager(newPolicyManager(rawTableV4, mangleTableV4, filterTableV4, ruleRenderer, 4))
+		addNoTrackIPSet(ipSetsV4, config, 4)
+		dp.RegisterManager(newPolicyManager(rawTableV4, mangleTableV4, filterTableV4, ruleRenderer, 4,
+			denyIPSetsCallback(ipSetsV4)))
 
 		// Clean up any leftover BPF state.
 		err := bpfnat.RemoveConnectTimeLoadBalancer("")
@@ -824,43 +896,21 @@ func NewIntDataplaneDriver(config Config) *InternalDataplane {
 	dp.RegisterManager(dp.wireguardManager) // IPv4
 
 	dp.RegisterManager(newServiceLoopManager(filterTableV4, ruleRenderer, 4))
+	dp.RegisterManager(newQoSPolicyManager(mangleTableV4, ruleRenderer, 4, config.BPFEnabled))
+
+	// Add managers for the TPROXY routing, irrespective of whether TPROXY is enabled, so that the
+	// routing rules are tidied up when it is disabled.
+	dp.registerTPROXYManager(4, featureDetector)
 
 	if config.IPv6Enabled {
-		mangleTableV6 := iptables.NewTable(
-			"mangle",
-			6,
-			rules.RuleHashPrefix,
-			iptablesLock,
-			featureDetector,
-			iptablesOptions,
-		)
-		natTableV6 := iptables.NewTable(
-			"nat",
-			6,
-			rules.RuleHashPrefix,
-			iptablesLock,
-			featureDetector,
-			iptablesNATOptions,
-		)
-		rawTableV6 := iptables.NewTable(
-			"raw",
-			6,
-			rules.RuleHashPrefix,
-			iptablesLock,
-			featureDetector,
-			iptablesOptions,
-		)
-		filterTableV6 := iptables.NewTable(
-			"filter",
-			6,
-			rules.RuleHashPrefix,
-			iptablesLock,
-			featureDetector,
-			iptablesOptions,
-		)
+		mangleTableV6 := newTable("mangle", 6, iptablesOptions)
+		natTableV6 := newTable("nat", 6, iptablesNATOptions)
+		rawTableV6 := newTable("raw", 6, iptablesOptions)
+		filterTableV6 := newTable("filter", 6, iptablesOptions)
 
 		ipSetsConfigV6 := config.RulesConfig.IPSetConfigV6
-		ipSetsV6 := ipsets.NewIPSets(ipSetsConfigV6, dp.loopSummarizer)
+		ipSetsV6 := ipsets.NewIPSets(ipSetsConfigV6, dp.loopSummarizer, append(ipSetsOpts,
+			ipsets.WithReferenceChecker(ipSetReferenceChecker(rawTableV6, mangleTableV6, natTableV6, filterTableV6)))...)
 		dp.ipSets = append(dp.ipSets, ipSetsV6)
 		dp.iptablesNATTables = append(dp.iptablesNATTables, natTableV6)
 		dp.iptablesRawTables = append(dp.iptablesRawTables, rawTableV6)
@@ -915,7 +965,9 @@ func NewIntDataplaneDriver(config Config) *InternalDataplane {
 				rules.IPSetIDThisHostIPs,
 				ipSetsV6,
 				config.MaxIPSetSize))
-			dp.RegisterManager(newPolicyManager(rawTableV6, mangleTableV6, filterTableV6, ruleRenderer, 6))
+			addNoTrackIPSet(ipSetsV6, config, 6)
+			dp.RegisterManager(newPolicyManager(rawTableV6, mangleTableV6, filterTableV6, ruleRenderer, 6,
+				denyIPSetsCallback(ipSetsV6)))
 		}
 		dp.RegisterManager(newEndpointManager(
 			rawTableV6,
@@ -937,6 +989,7 @@ func NewIntDataplaneDriver(config Config) *InternalDataplane {
 		dp.RegisterManager(newFloatingIPManager(natTableV6, ruleRenderer, 6, config.FloatingIPsEnabled))
 		dp.RegisterManager(newMasqManager(ipSetsV6, natTableV6, ruleRenderer, config.MaxIPSetSize, 6))
 		dp.RegisterManager(newServiceLoopManager(filterTableV6, ruleRenderer, 6))
+		dp.RegisterManager(newQoSPolicyManager(mangleTableV6, ruleRenderer, 6, config.BPFEnabled))
 
 		// Add a manager for IPv6 wireguard configuration. This is added irrespective of whether wireguard is actually enabled
 		// because it may need to tidy up some of the routing rules when disabled.
@@ -953,6 +1006,8 @@ func NewIntDataplaneDriver(config Config) *InternalDataplane {
 			featureDetector)
 		dp.wireguardManagerV6 = newWireguardManager(cryptoRouteTableWireguardV6, config, 6)
 		dp.RegisterManager(dp.wireguardManagerV6)
+
+		dp.registerTPROXYManager(6, featureDetector)
 	}
 
 	dp.allIptablesTables = append(dp.allIptablesTables, dp.iptablesMangleTables...)
@@ -984,6 +1039,116 @@ func NewIntDataplaneDriver(config Config) *InternalDataplane {
 	return dp
 }
 
+// ipSetsForIPVersion returns the IP sets dataplanes that program IP sets of the given IP version.
+func (d *InternalDataplane) ipSetsForIPVersion(ipVersion uint8) []common.IPSetsDataplane {
+	var ipSets []common.IPSetsDataplane
+	for _, s := range d.ipSets {
+		if s.GetIPFamily().Version() == int(ipVersion) {
+			ipSets = append(ipSets, s)
+		}
+	}
+	return ipSets
+}
+
+// rulesTablesForIPVersion returns the rules tables of the given IP version.
+func (d *InternalDataplane) rulesTablesForIPVersion(ipVersion uint8) []RulesTable {
+	var tables []RulesTable
+	for _, t := range d.allIptablesTables {
+		if t.TableIPVersion() == ipVersion {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// ipFamilyPipeline holds the IP sets and rules tables of one IP family.  They only depend on other
+// IP sets and tables of the same family so each family is programmed independently.
+type ipFamilyPipeline struct {
+	ipSets []common.IPSetsDataplane
+	tables []RulesTable
+}
+
+// ipFamilyPipelines returns the pipelines of the IP families that have any IP sets or tables.
+func (d *InternalDataplane) ipFamilyPipelines() []ipFamilyPipeline {
+	var pipelines []ipFamilyPipeline
+	for _, ipVersion := range []uint8{4, 6} {
+		p := ipFamilyPipeline{
+			ipSets: d.ipSetsForIPVersion(ipVersion),
+			tables: d.rulesTablesForIPVersion(ipVersion),
+		}
+		if len(p.ipSets) == 0 && len(p.tables) == 0 {
+			continue
+		}
+		pipelines = append(pipelines, p)
+	}
+	return pipelines
+}
+
+// applyIPFamilyPipelines programs the IP sets and rules tables of each IP family, running the
+// families in parallel so that, for example, v4 rules don't have to wait for a large v6 IP set
+// update to finish.  Within a family, IP sets are created and updated before the tables are
+// applied, and deleted afterwards.  Each IP set and table belongs to exactly one pipeline and we
+// wait for all of them to finish, so they don't need any locking of their own.
+//
+// Returns the soonest that any table asked to be rescheduled, or 0 if none did.
+func applyIPFamilyPipelines(pipelines []ipFamilyPipeline, reportHealth func()) time.Duration {
+	var reschedDelayMutex sync.Mutex
+	var reschedDelay time.Duration
+	applyInParallel(pipelines, func(p ipFamilyPipeline) {
+		// Create/update IP sets first; rules that reference an unknown IP set fail.  We
+		// defer deletions of IP sets until after we update iptables.
+		applyInParallel(p.ipSets, func(s common.IPSetsDataplane) {
+			s.ApplyUpdates()
+			reportHealth()
+		})
+
+		// Update iptables, this should sever any references to now-unused IP sets.
+		applyInParallel(p.tables, func(t RulesTable) {
+			tableReschedAfter := t.Apply()
+
+			reschedDelayMutex.Lock()
+			defer reschedDelayMutex.Unlock()
+			if tableReschedAfter != 0 && (reschedDelay == 0 || tableReschedAfter < reschedDelay) {
+				reschedDelay = tableReschedAfter
+			}
+			reportHealth()
+		})
+
+		// Now clean up any left-over IP sets.
+		applyInParallel(p.ipSets, func(s common.IPSetsDataplane) {
+			s.ApplyDeletions()
+			reportHealth()
+		})
+	})
+	return reschedDelay
+}
+
+// applyInParallel calls apply for each item in its own goroutine and waits for them all to finish.
+func applyInParallel[T any](items []T, apply func(T)) {
+	var wg sync.WaitGroup
+	for _, item := range items {
+		wg.Add(1)
+		go func(item T) {
+			defer wg.Done()
+			apply(item)
+		}(item)
+	}
+	wg.Wait()
+}
+
+// ipSetReferenceChecker returns a function that reports whether any of the given tables' rules
+// refer to an IP set.  It's only called from ApplyDeletions(), after the tables have been applied.
+func ipSetReferenceChecker(tables ...RulesTable) func(setName string) bool {
+	return func(setName string) bool {
+		for _, t := range tables {
+			if t.IPSetIsReferenced(setName) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 // findHostMTU auto-detects the smallest host interface MTU.
 func findHostMTU(matchRegex *regexp.Regexp) (int, error) {
 	// Find all the interfaces on the host.
@@ -1216,6 +1381,15 @@ type Manager interface {
 	CompleteDeferredWork() error
 }
 
+// ManagerWithReschedule is implemented by managers that sometimes need CompleteDeferredWork() to
+// be called again even if they receive no further updates.
+type ManagerWithReschedule interface {
+	Manager
+	// RescheduleAfter is called after each apply(); it returns the delay after which the manager
+	// needs another apply(), or 0 if it doesn't need one.
+	RescheduleAfter() time.Duration
+}
+
 type ManagerWithRouteTables interface {
 	Manager
 	GetRouteTableSyncers() []routetable.RouteTableSyncer
@@ -1233,6 +1407,38 @@ type routeRules interface {
 	Apply() error
 }
 
+// addNoTrackIPSet creates the IP set of NoTrackCIDRs that the raw table's NOTRACK rules match on.
+// The CIDRs only change on restart so there's no need for a manager.
+func addNoTrackIPSet(ipSets common.IPSetsDataplane, config Config, ipVersion uint8) {
+	cidrs := config.RulesConfig.NoTrackCIDRsForIPVersion(ipVersion)
+	if len(cidrs) == 0 {
+		return
+	}
+	ipSets.AddOrReplaceIPSet(ipsets.IPSetMetadata{
+		MaxSize: config.MaxIPSetSize,
+		SetID:   rules.IPSetIDNoTrackNets,
+		Type:    ipsets.IPSetTypeHashNet,
+	}, cidrs)
+}
+
+// natRandomFullyOverrides returns the feature detection overrides to use for the given
+// NATRandomFully mode.  --random-fully is used by the SNAT and MASQUERADE actions if the feature
+// detector finds that it is supported, so "Disabled" is implemented by overriding the detected
+// features; explicit overrides in the FeatureDetectOverride config take precedence.
+func natRandomFullyOverrides(mode string, overrides map[string]string) map[string]string {
+	if mode != "Disabled" {
+		return overrides
+	}
+	result := map[string]string{
+		"SNATFullyRandom": "false",
+		"MASQFullyRandom": "false",
+	}
+	for k, v := range overrides {
+		result[k] = v
+	}
+	return result
+}
+
 func (d *InternalDataplane) routeTableSyncers() []routetable.RouteTableSyncer {
 	var rts []routetable.RouteTableSyncer
 	for _, mrts := range d.managersWithRouteTables {
@@ -1366,6 +1572,125 @@ func (d *InternalDataplane) RecvMessage() (interface{}, error) {
 	return <-d.fromDataplane, nil
 }
 
+// IPSetsDebugInfo returns the state of the given IP sets, or of all IP sets if no IDs are given, in
+// each IP family.  It may be called from any goroutine; the IP sets are listed on the main loop's
+// goroutine, in between updates.
+func (d *InternalDataplane) IPSetsDebugInfo(ctx context.Context, setIDs []string) ([]*ipsets.IPSetDebugInfo, error) {
+	type result struct {
+		infos []*ipsets.IPSetDebugInfo
+		err   error
+	}
+	resultC := make(chan result, 1)
+	req := func() {
+		var r result
+		for _, ipSets := range d.ipSets {
+			ipSets, ok := ipSets.(*ipsets.IPSets)
+			if !ok {
+				// The BPF IP sets don't support listing.
+				continue
+			}
+			infos, err := ipSets.DebugInfo(setIDs)
+			if err != nil {
+				r.err = err
+				break
+			}
+			r.infos = append(r.infos, infos...)
+		}
+		resultC <- r
+	}
+	select {
+	case d.debugRequests <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case r := <-resultC:
+		return r.infos, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ExplainPacket walks a packet through the calculated filter rules of the endpoint with the given
+// interface and reports the rules that it would match, along with the verdict.  If ingress is
+// true, the packet is heading to the endpoint; otherwise, it's coming from the endpoint.  It may
+// be called from any goroutine; the walk happens on the main loop's goroutine, in between updates.
+func (d *InternalDataplane) ExplainPacket(ctx context.Context, iface string, ingress bool, pkt iptables.Packet) (*iptables.Explanation, error) {
+	type result struct {
+		explanation *iptables.Explanation
+		err         error
+	}
+	resultC := make(chan result, 1)
+	req := func() {
+		var r result
+		r.explanation, r.err = d.explainPacket(iface, ingress, pkt)
+		resultC <- r
+	}
+	select {
+	case d.debugRequests <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case r := <-resultC:
+		return r.explanation, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (d *InternalDataplane) explainPacket(iface string, ingress bool, pkt iptables.Packet) (*iptables.Explanation, error) {
+	if d.config.BPFEnabled {
+		return nil, fmt.Errorf("explaining packets is not supported in BPF mode")
+	}
+	ipVersion := uint8(4)
+	if (pkt.SrcIP != nil && pkt.SrcIP.To4() == nil) || (pkt.DstIP != nil && pkt.DstIP.To4() == nil) {
+		ipVersion = 6
+	}
+	var table RulesTable
+	for _, t := range d.iptablesFilterTables {
+		if t.TableIPVersion() == ipVersion {
+			table = t
+		}
+	}
+	if table == nil {
+		return nil, fmt.Errorf("IPv%d is not enabled", ipVersion)
+	}
+	opts := iptables.ExplainOptions{
+		IPSetMembers: func(setName string) ([]string, bool) {
+			for _, s := range d.ipSetsForIPVersion(ipVersion) {
+				if s, ok := s.(*ipsets.IPSets); ok {
+					if members, ok := s.GetMembersByName(setName); ok {
+						return members, true
+					}
+				}
+			}
+			return nil, false
+		},
+		AcceptMark: d.config.RulesConfig.IptablesMarkAccept,
+	}
+
+	// The endpoint chains are named after the interface; try the workload chain first, then the
+	// host endpoint chain.
+	chainPrefixes := []string{rules.WorkloadFromEndpointPfx, rules.HostFromEndpointPfx}
+	if ingress {
+		chainPrefixes = []string{rules.WorkloadToEndpointPfx, rules.HostToEndpointPfx}
+		if pkt.OutIface == "" {
+			pkt.OutIface = iface
+		}
+	} else if pkt.InIface == "" {
+		pkt.InIface = iface
+	}
+	for _, prefix := range chainPrefixes {
+		explanation, err := table.Explain(rules.EndpointChainName(prefix, iface), pkt, opts)
+		if errors.Is(err, iptables.ErrUnknownChain) {
+			continue
+		}
+		return explanation, err
+	}
+	return nil, fmt.Errorf("no endpoint chains found for interface %q", iface)
+}
+
 func (d *InternalDataplane) monitorHostMTU() {
 	for {
 		mtu, err := findHostMTU(d.config.MTUIfacePattern)
@@ -1489,7 +1814,7 @@ func (d *InternalDataplane) setUpIptablesBPF() {
 			Action: iptables.AcceptAction{},
 		})
 
-		if t.IPVersion == 6 {
+		if t.TableIPVersion() == 6 {
 			for _, prefix := range rulesConfig.WorkloadIfacePrefixes {
 				// In BPF mode, we don't support IPv6 yet.  Drop it.
 				fwdRules = append(fwdRules, iptables.Rule{
@@ -1537,26 +1862,26 @@ func (d *InternalDataplane) setUpIptablesBPF() {
 			)
 		}
 
-		t.InsertOrAppendRules("INPUT", inputRules)
-		t.InsertOrAppendRules("FORWARD", fwdRules)
-		t.InsertOrAppendRules("OUTPUT", outputRules)
+		d.insertFilterKernelChainRules(t, "INPUT", inputRules)
+		d.insertFilterKernelChainRules(t, "FORWARD", fwdRules)
+		d.insertFilterKernelChainRules(t, "OUTPUT", outputRules)
 	}
 
 	for _, t := range d.iptablesNATTables {
-		t.UpdateChains(d.ruleRenderer.StaticNATPostroutingChains(t.IPVersion))
+		t.UpdateChains(d.ruleRenderer.StaticNATPostroutingChains(t.TableIPVersion()))
 		t.InsertOrAppendRules("POSTROUTING", []iptables.Rule{{
 			Action: iptables.JumpAction{Target: rules.ChainNATPostrouting},
 		}})
 	}
 
 	for _, t := range d.iptablesRawTables {
-		t.UpdateChains(d.ruleRenderer.StaticBPFModeRawChains(t.IPVersion,
+		t.UpdateChains(d.ruleRenderer.StaticBPFModeRawChains(t.TableIPVersion(),
 			d.config.Wireguard.EncryptHostTraffic, d.config.BPFHostConntrackBypass,
 		))
 		t.InsertOrAppendRules("PREROUTING", []iptables.Rule{{
 			Action: iptables.JumpAction{Target: rules.ChainRawPrerouting},
 		}})
-		if t.IPVersion == 4 {
+		if t.TableIPVersion() == 4 {
 			t.InsertOrAppendRules("OUTPUT", []iptables.Rule{{
 				Action: iptables.JumpAction{Target: rules.ChainRawOutput},
 			}})
@@ -1616,7 +1941,7 @@ func (d *InternalDataplane) setUpIptablesBPFEarly() {
 
 func (d *InternalDataplane) setUpIptablesNormal() {
 	for _, t := range d.iptablesRawTables {
-		rawChains := d.ruleRenderer.StaticRawTableChains(t.IPVersion)
+		rawChains := d.ruleRenderer.StaticRawTableChains(t.TableIPVersion())
 		t.UpdateChains(rawChains)
 		t.InsertOrAppendRules("PREROUTING", []iptables.Rule{{
 			Action: iptables.JumpAction{Target: rules.ChainRawPrerouting},
@@ -1626,15 +1951,15 @@ func (d *InternalDataplane) setUpIptablesNormal() {
 		}})
 	}
 	for _, t := range d.iptablesFilterTables {
-		filterChains := d.ruleRenderer.StaticFilterTableChains(t.IPVersion)
+		filterChains := d.ruleRenderer.StaticFilterTableChains(t.TableIPVersion())
 		t.UpdateChains(filterChains)
-		t.InsertOrAppendRules("FORWARD", []iptables.Rule{{
+		d.insertFilterKernelChainRules(t, "FORWARD", []iptables.Rule{{
 			Action: iptables.JumpAction{Target: rules.ChainFilterForward},
 		}})
-		t.InsertOrAppendRules("INPUT", []iptables.Rule{{
+		d.insertFilterKernelChainRules(t, "INPUT", []iptables.Rule{{
 			Action: iptables.JumpAction{Target: rules.ChainFilterInput},
 		}})
-		t.InsertOrAppendRules("OUTPUT", []iptables.Rule{{
+		d.insertFilterKernelChainRules(t, "OUTPUT", []iptables.Rule{{
 			Action: iptables.JumpAction{Target: rules.ChainFilterOutput},
 		}})
 
@@ -1642,7 +1967,7 @@ func (d *InternalDataplane) setUpIptablesNormal() {
 		t.AppendRules("FORWARD", d.ruleRenderer.StaticFilterForwardAppendRules())
 	}
 	for _, t := range d.iptablesNATTables {
-		t.UpdateChains(d.ruleRenderer.StaticNATTableChains(t.IPVersion))
+		t.UpdateChains(d.ruleRenderer.StaticNATTableChains(t.TableIPVersion()))
 		t.InsertOrAppendRules("PREROUTING", []iptables.Rule{{
 			Action: iptables.JumpAction{Target: rules.ChainNATPrerouting},
 		}})
@@ -1654,7 +1979,7 @@ func (d *InternalDataplane) setUpIptablesNormal() {
 		}})
 	}
 	for _, t := range d.iptablesMangleTables {
-		t.UpdateChains(d.ruleRenderer.StaticMangleTableChains(t.IPVersion))
+		t.UpdateChains(d.ruleRenderer.StaticMangleTableChains(t.TableIPVersion()))
 		t.InsertOrAppendRules("PREROUTING", []iptables.Rule{{
 			Action: iptables.JumpAction{Target: rules.ChainManglePrerouting},
 		}})
@@ -1740,6 +2065,7 @@ func (d *InternalDataplane) loopUpdatingDataplane() {
 
 	// If configured, start tickers to refresh the IP sets and routing table entries.
 	ipSetsRefreshC := newRefreshTicker("IP sets", d.config.IPSetsRefreshInterval)
+	ipSetsCheckC := newRefreshTicker("IP sets membership check", d.config.IPSetsMembershipCheckInterval)
 	routeRefreshC := newRefreshTicker("routes", d.config.RouteRefreshInterval)
 	var xdpRefreshC <-chan time.Time
 	if d.xdpState != nil {
@@ -1762,6 +2088,10 @@ func (d *InternalDataplane) loopUpdatingDataplane() {
 			log.Debug("Refreshing IP sets state")
 			d.forceIPSetsRefresh = true
 			d.dataplaneNeedsSync = true
+		case <-ipSetsCheckC:
+			log.Debug("Checking IP sets membership")
+			d.forceIPSetsMembershipCheck = true
+			d.dataplaneNeedsSync = true
 		case <-routeRefreshC:
 			log.Debug("Refreshing routes")
 			d.forceRouteRefresh = true
@@ -1780,6 +2110,8 @@ func (d *InternalDataplane) loopUpdatingDataplane() {
 		case <-healthTicks:
 			d.reportHealth()
 		case <-retryTicker.C:
+		case req := <-d.debugRequests:
+			req()
 		case <-d.debugHangC:
 			log.Warning("Debug hang simulation timer popped, hanging the dataplane!!")
 			time.Sleep(1 * time.Hour)
@@ -1831,6 +2163,12 @@ func (d *InternalDataplane) loopUpdatingDataplane() {
 	}
 }
 
+// ipSetsMembershipChecker is implemented by IP sets dataplanes that support a lightweight check
+// for IP sets that have been modified by another process.
+type ipSetsMembershipChecker interface {
+	QueueMembershipCheck()
+}
+
 func newRefreshTicker(name string, interval time.Duration) <-chan time.Time {
 	if interval <= 0 {
 		log.Infof("Refresh of %s on timer disabled", name)
@@ -2086,16 +2424,14 @@ func (d *InternalDataplane) apply() {
 		d.forceIPSetsRefresh = false
 	}
 
-	// Next, create/update IP sets.  We defer deletions of IP sets until after we update
-	// iptables.
-	var ipSetsWG sync.WaitGroup
-	for _, ipSets := range d.ipSets {
-		ipSetsWG.Add(1)
-		go func(ipSets common.IPSetsDataplane) {
-			ipSets.ApplyUpdates()
-			d.reportHealth()
-			ipSetsWG.Done()
-		}(ipSets)
+	if d.forceIPSetsMembershipCheck {
+		// Membership check timer popped.
+		for _, r := range d.ipSets {
+			if c, ok := r.(ipSetsMembershipChecker); ok {
+				c.QueueMembershipCheck()
+			}
+		}
+		d.forceIPSetsMembershipCheck = false
 	}
 
 	// Update the routing table in parallel with the other updates.  We'll wait for it to finish
@@ -2130,39 +2466,8 @@ func (d *InternalDataplane) apply() {
 		}(r)
 	}
 
-	// Wait for the IP sets update to finish.  We can't update iptables until it has.
-	ipSetsWG.Wait()
-
-	// Update iptables, this should sever any references to now-unused IP sets.
-	var reschedDelayMutex sync.Mutex
-	var reschedDelay time.Duration
-	var iptablesWG sync.WaitGroup
-	for _, t := range d.allIptablesTables {
-		iptablesWG.Add(1)
-		go func(t *iptables.Table) {
-			tableReschedAfter := t.Apply()
-
-			reschedDelayMutex.Lock()
-			defer reschedDelayMutex.Unlock()
-			if tableReschedAfter != 0 && (reschedDelay == 0 || tableReschedAfter < reschedDelay) {
-				reschedDelay = tableReschedAfter
-			}
-			d.reportHealth()
-			iptablesWG.Done()
-		}(t)
-	}
-	iptablesWG.Wait()
-
-	// Now clean up any left-over IP sets.
-	for _, ipSets := range d.ipSets {
-		ipSetsWG.Add(1)
-		go func(s common.IPSetsDataplane) {
-			s.ApplyDeletions()
-			d.reportHealth()
-			ipSetsWG.Done()
-		}(ipSets)
-	}
-	ipSetsWG.Wait()
+	// Update IP sets and iptables, each IP family in its own pipeline.
+	reschedDelay := applyIPFamilyPipelines(d.ipFamilyPipelines(), d.reportHealth)
 
 	// Wait for the route updates to finish.
 	routesWG.Wait()
@@ -2170,6 +2475,23 @@ func (d *InternalDataplane) apply() {
 	// Wait for the rule updates to finish.
 	rulesWG.Wait()
 
+	// If we gave up on programming iptables or IP sets, apply the configured failure mode and
+	// make sure that we try again.
+	if retryAfter := d.updateApplyFailureState(); retryAfter != 0 &&
+		(reschedDelay == 0 || retryAfter < reschedDelay) {
+		reschedDelay = retryAfter
+	}
+
+	// Some managers need another pass once their updates have been programmed.
+	for _, mgr := range d.allManagers {
+		if m, ok := mgr.(ManagerWithReschedule); ok {
+			if mgrReschedAfter := m.RescheduleAfter(); mgrReschedAfter != 0 &&
+				(reschedDelay == 0 || mgrReschedAfter < reschedDelay) {
+				reschedDelay = mgrReschedAfter
+			}
+		}
+	}
+
 	// And publish and status updates.
 	d.endpointStatusCombiner.Apply()
 
@@ -2199,6 +2521,79 @@ func (d *InternalDataplane) apply() {
 	}
 }
 
+// insertFilterKernelChainRules sets the rules that we insert into one of the filter table's kernel
+// chains.  Any rules for the current dataplane failure mode go ahead of them.
+func (d *InternalDataplane) insertFilterKernelChainRules(t RulesTable, chain string, rules []iptables.Rule) {
+	if d.filterKernelChainInserts[t] == nil {
+		d.filterKernelChainInserts[t] = map[string][]iptables.Rule{}
+	}
+	d.filterKernelChainInserts[t][chain] = rules
+
+	var allRules []iptables.Rule
+	allRules = append(allRules, d.applyFailureRules[chain]...)
+	allRules = append(allRules, rules...)
+	t.InsertOrAppendRules(chain, allRules)
+}
+
+// updateApplyFailureState updates the dataplane state after an apply().  While we've failed open or
+// closed, the corresponding rules are inserted at the start of the filter table's kernel chains,
+// along with our other inserts, and removed again once we recover.  Returns the time after which
+// we should try again, or 0 if nothing failed.
+//
+// If the filter table is the table that's failing, it may not be able to program the failure
+// rules either.  We try to write them directly, in a transaction of their own, and report an
+// "Unenforced" state if that fails too.
+func (d *InternalDataplane) updateApplyFailureState() time.Duration {
+	_, newState := d.applyFailures.CompleteApply()
+
+	var failureRules map[string][]iptables.Rule
+	switch newState {
+	case DataplaneStateKeepingLastGood:
+		return iptables.PersistentFailureRetryInterval
+	case DataplaneStateFailedOpen:
+		failureRules = d.ruleRenderer.ApplyFailureRules(true, d.config.DataplaneApplyFailOpenClasses)
+	case DataplaneStateFailedClosed:
+		failureRules = d.ruleRenderer.ApplyFailureRules(false, nil)
+	}
+
+	if !reflect.DeepEqual(failureRules, d.applyFailureRules) {
+		// Entering or leaving a failed state; the tables will add or remove the rules on
+		// their next Apply().
+		d.applyFailureRules = failureRules
+		for t, chainToRules := range d.filterKernelChainInserts {
+			for _, chain := range []string{"INPUT", "FORWARD", "OUTPUT"} {
+				d.insertFilterKernelChainRules(t, chain, chainToRules[chain])
+			}
+		}
+		d.dataplaneNeedsSync = true
+	}
+	if failureRules == nil {
+		return 0
+	}
+
+	// Don't wait for the next Apply() to put the failure rules in place.  Since they're already
+	// part of the tables' desired state, the tables won't see the rules that we write here as
+	// drift.
+	enforced := true
+	for _, t := range d.iptablesFilterTables {
+		for chain, rules := range failureRules {
+			if len(rules) == 0 || len(t.CheckRulesPresent(chain, rules)) == len(rules) {
+				continue
+			}
+			if err := t.InsertRulesNow(chain, rules); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"ipVersion": t.TableIPVersion(),
+					"chain":     chain,
+					"state":     newState,
+				}).Warn("Failed to insert rules for dataplane failure mode.")
+				enforced = false
+			}
+		}
+	}
+	d.applyFailures.SetFailureRulesEnforced(enforced)
+	return iptables.PersistentFailureRetryInterval
+}
+
 func (d *InternalDataplane) applyXDPActions() error {
 	var err error = nil
 	for i := 0; i < 10; i++ {
@@ -2226,13 +2621,30 @@ func (d *InternalDataplane) loopReportingStatus() {
 	for {
 		uptimeSecs := time.Since(processStartTime).Seconds()
 		d.fromDataplane <- &proto.ProcessStatusUpdate{
-			IsoTimestamp: time.Now().UTC().Format(time.RFC3339),
-			Uptime:       uptimeSecs,
+			IsoTimestamp:   time.Now().UTC().Format(time.RFC3339),
+			Uptime:         uptimeSecs,
+			DataplaneState: d.applyFailures.State(),
 		}
 		time.Sleep(d.config.StatusReportingInterval)
 	}
 }
 
+// RulesTable is the interface to the objects that program a table of rules into the dataplane:
+// iptables.Table and nftables.Table.
+type RulesTable interface {
+	IptablesTable
+	TableName() string
+	TableIPVersion() uint8
+	InsertOrAppendRules(chainName string, rules []iptables.Rule)
+	AppendRules(chainName string, rules []iptables.Rule)
+	Apply() (rescheduleAfter time.Duration)
+	IPSetIsReferenced(setName string) bool
+	CheckRulesPresent(chain string, rules []iptables.Rule) []iptables.Rule
+	InsertRulesNow(chain string, rules []iptables.Rule) error
+	InvalidateDataplaneCache(reason string)
+	Explain(chainName string, pkt iptables.Packet, opts iptables.ExplainOptions) (*iptables.Explanation, error)
+}
+
 // IptablesTable is a shim interface for iptables.Table.
 type IptablesTable interface {
 	UpdateChain(chain *iptables.Chain)
@@ -0,0 +1,215 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/dataplane/common"
+	"github.com/projectcalico/calico/felix/ipsets"
+	"github.com/projectcalico/calico/felix/rules"
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+)
+
+var _ = Describe("natRandomFullyOverrides", func() {
+	It("should leave the overrides alone in Auto and Enabled modes", func() {
+		overrides := map[string]string{"RestoreSupportsLock": "false"}
+		Expect(natRandomFullyOverrides("Auto", overrides)).To(Equal(overrides))
+		Expect(natRandomFullyOverrides("Enabled", nil)).To(BeNil())
+	})
+
+	It("should disable --random-fully in Disabled mode", func() {
+		Expect(natRandomFullyOverrides("Disabled", map[string]string{"RestoreSupportsLock": "false"})).To(Equal(map[string]string{
+			"SNATFullyRandom":     "false",
+			"MASQFullyRandom":     "false",
+			"RestoreSupportsLock": "false",
+		}))
+	})
+
+	It("should give precedence to explicit overrides", func() {
+		Expect(natRandomFullyOverrides("Disabled", map[string]string{"SNATFullyRandom": "true"})).To(Equal(map[string]string{
+			"SNATFullyRandom": "true",
+			"MASQFullyRandom": "false",
+		}))
+	})
+})
+
+var _ = Describe("addNoTrackIPSet", func() {
+	var config Config
+
+	BeforeEach(func() {
+		config = Config{
+			MaxIPSetSize: 1024,
+			RulesConfig: rules.Config{
+				NoTrackCIDRs: []string{"10.1.0.0/16", "fd00::/64"},
+			},
+		}
+	})
+
+	It("should create an IP set of the CIDRs of the IP version", func() {
+		ipSets := common.NewMockIPSets()
+		addNoTrackIPSet(ipSets, config, 6)
+		Expect(ipSets.Metadata[rules.IPSetIDNoTrackNets]).To(Equal(ipsets.IPSetMetadata{
+			MaxSize: 1024,
+			SetID:   rules.IPSetIDNoTrackNets,
+			Type:    ipsets.IPSetTypeHashNet,
+		}))
+		Expect(ipSets.Members[rules.IPSetIDNoTrackNets]).To(Equal(set.From("fd00::/64")))
+	})
+
+	It("should not create the IP set if there are no CIDRs of the IP version", func() {
+		config.RulesConfig.NoTrackCIDRs = []string{"fd00::/64"}
+		ipSets := common.NewMockIPSets()
+		addNoTrackIPSet(ipSets, config, 4)
+		Expect(ipSets.AddOrReplaceCalled).To(BeFalse())
+	})
+})
+
+// pipelineRecorder records the calls that the IP family pipelines make to IP sets and tables.
+type pipelineRecorder struct {
+	lock  sync.Mutex
+	calls []string
+}
+
+func (r *pipelineRecorder) record(call string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+// callsTo returns the recorded calls to IP sets and tables whose names have the given prefix.
+func (r *pipelineRecorder) callsTo(prefix string) []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var calls []string
+	for _, c := range r.calls {
+		if strings.HasPrefix(c, prefix) {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+type recordingIPSets struct {
+	*common.MockIPSets
+	name     string
+	recorder *pipelineRecorder
+	// unblockUpdates, if non-nil, holds up ApplyUpdates() until it's closed.
+	unblockUpdates chan struct{}
+}
+
+func (s *recordingIPSets) ApplyUpdates() {
+	if s.unblockUpdates != nil {
+		<-s.unblockUpdates
+	}
+	s.recorder.record(s.name + " updates")
+}
+
+func (s *recordingIPSets) ApplyDeletions() {
+	s.recorder.record(s.name + " deletions")
+}
+
+type recordingTable struct {
+	*mockRulesTable
+	name         string
+	recorder     *pipelineRecorder
+	reschedAfter time.Duration
+}
+
+func (t *recordingTable) Apply() time.Duration {
+	t.recorder.record(t.name + " apply")
+	return t.reschedAfter
+}
+
+var _ = Describe("applyIPFamilyPipelines", func() {
+	var (
+		recorder  *pipelineRecorder
+		pipelines []ipFamilyPipeline
+	)
+
+	newIPSets := func(name string) *recordingIPSets {
+		return &recordingIPSets{MockIPSets: common.NewMockIPSets(), name: name, recorder: recorder}
+	}
+	newTable := func(name string, reschedAfter time.Duration) *recordingTable {
+		return &recordingTable{mockRulesTable: newMockRulesTable(), name: name, recorder: recorder, reschedAfter: reschedAfter}
+	}
+
+	BeforeEach(func() {
+		recorder = &pipelineRecorder{}
+		pipelines = []ipFamilyPipeline{
+			{
+				ipSets: []common.IPSetsDataplane{newIPSets("v4-ipsets")},
+				tables: []RulesTable{newTable("v4-filter", 5*time.Second), newTable("v4-nat", 0)},
+			},
+			{
+				ipSets: []common.IPSetsDataplane{newIPSets("v6-ipsets")},
+				tables: []RulesTable{newTable("v6-filter", 3*time.Second), newTable("v6-nat", 2*time.Second)},
+			},
+		}
+	})
+
+	expectFamilyOrder := func(family string) {
+		calls := recorder.callsTo(family)
+		Expect(calls).To(HaveLen(4))
+		Expect(calls[0]).To(Equal(family + "-ipsets updates"))
+		Expect(calls[1:3]).To(ConsistOf(family+"-filter apply", family+"-nat apply"))
+		Expect(calls[3]).To(Equal(family + "-ipsets deletions"))
+	}
+
+	It("should update IP sets before the tables and delete them after, in each family", func() {
+		applyIPFamilyPipelines(pipelines, func() {})
+		expectFamilyOrder("v4")
+		expectFamilyOrder("v6")
+	})
+
+	It("should program each family independently", func() {
+		unblock := make(chan struct{})
+		pipelines[1].ipSets[0].(*recordingIPSets).unblockUpdates = unblock
+		done := make(chan time.Duration)
+		go func() {
+			done <- applyIPFamilyPipelines(pipelines, func() {})
+		}()
+
+		// v4 should finish while v6 is still stuck updating its IP sets.
+		Eventually(func() []string { return recorder.callsTo("v4") }).Should(HaveLen(4))
+		expectFamilyOrder("v4")
+		Expect(recorder.callsTo("v6")).To(BeEmpty())
+		Consistently(done).ShouldNot(Receive())
+
+		close(unblock)
+		Eventually(done).Should(Receive(Equal(2 * time.Second)))
+		expectFamilyOrder("v6")
+	})
+
+	It("should return the soonest reschedule delay of any table", func() {
+		Expect(applyIPFamilyPipelines(pipelines, func() {})).To(Equal(2 * time.Second))
+	})
+
+	It("should ignore tables that don't ask to be rescheduled", func() {
+		pipelines[1].tables = []RulesTable{newTable("v6-filter", 0)}
+		Expect(applyIPFamilyPipelines(pipelines, func() {})).To(Equal(5 * time.Second))
+	})
+
+	It("should return 0 if no table asks to be rescheduled", func() {
+		pipelines[0].tables = []RulesTable{newTable("v4-filter", 0)}
+		pipelines[1].tables = nil
+		Expect(applyIPFamilyPipelines(pipelines, func() {})).To(BeZero())
+	})
+})
@@ -37,6 +37,33 @@ type policyManager struct {
 	rawEgressOnly  bool
 	neededIPSets   map[proto.PolicyID]set.Set[string]
 	ipSetsCallback func(neededIPSets set.Set[string])
+
+	// denyIPSetsCallback, if non-nil, is called from CompleteDeferredWork with the IDs of the
+	// IP sets that are referenced by deny rules, whenever they change.  denyIPSetsByOwner holds
+	// the IDs for each policy and profile (keyed by proto.PolicyID or proto.ProfileID) and
+	// denyIPSetRefCounts counts the policies and profiles that refer to each IP set.
+	denyIPSetsCallback func(setIDs set.Set[string])
+	denyIPSetsByOwner  map[interface{}]set.Set[string]
+	denyIPSetRefCounts map[string]int
+	denyIPSetsDirty    bool
+
+	// chainOwners maps the name of each programmed policy and profile chain to the ID
+	// (proto.PolicyID or proto.ProfileID) of its owner and chainsByOwner holds the names of each
+	// owner's chains.  The calculation graph renames policies and profiles whose chain names would
+	// collide after shortening so this is a backstop: it stops one policy or profile from
+	// overwriting another's chain.  pendingChains holds, in arrival order, the chains that lost
+	// such a collision; when the owner of the name goes away, the first pending chain takes its
+	// place.
+	chainOwners   map[string]interface{}
+	chainsByOwner map[interface{}][]string
+	pendingChains map[string][]chainClaim
+}
+
+// chainClaim is a chain rendered for a policy or profile, along with the tables it belongs in.
+type chainClaim struct {
+	owner  interface{}
+	chain  *iptables.Chain
+	tables []IptablesTable
 }
 
 type policyRenderer interface {
@@ -44,13 +71,20 @@ type policyRenderer interface {
 	ProfileToIptablesChains(profileID *proto.ProfileID, policy *proto.Profile, ipVersion uint8) (inbound, outbound *iptables.Chain)
 }
 
-func newPolicyManager(rawTable, mangleTable, filterTable IptablesTable, ruleRenderer policyRenderer, ipVersion uint8) *policyManager {
+func newPolicyManager(rawTable, mangleTable, filterTable IptablesTable, ruleRenderer policyRenderer, ipVersion uint8,
+	denyIPSetsCallback func(setIDs set.Set[string])) *policyManager {
 	return &policyManager{
-		rawTable:     rawTable,
-		mangleTable:  mangleTable,
-		filterTable:  filterTable,
-		ruleRenderer: ruleRenderer,
-		ipVersion:    ipVersion,
+		rawTable:           rawTable,
+		mangleTable:        mangleTable,
+		filterTable:        filterTable,
+		ruleRenderer:       ruleRenderer,
+		ipVersion:          ipVersion,
+		denyIPSetsCallback: denyIPSetsCallback,
+		denyIPSetsByOwner:  map[interface{}]set.Set[string]{},
+		denyIPSetRefCounts: map[string]int{},
+		chainOwners:        map[string]interface{}{},
+		chainsByOwner:      map[interface{}][]string{},
+		pendingChains:      map[string][]chainClaim{},
 	}
 }
 
@@ -65,6 +99,9 @@ func newRawEgressPolicyManager(rawTable IptablesTable, ruleRenderer policyRender
 		rawEgressOnly:  true,
 		neededIPSets:   make(map[proto.PolicyID]set.Set[string]),
 		ipSetsCallback: ipSetsCallback,
+		chainOwners:    map[string]interface{}{},
+		chainsByOwner:  map[interface{}][]string{},
+		pendingChains:  map[string][]chainClaim{},
 	}
 }
 
@@ -84,6 +121,142 @@ func (m *policyManager) mergeNeededIPSets(id *proto.PolicyID, neededIPSets set.S
 	m.ipSetsCallback(merged)
 }
 
+// updateDenyIPSets records the IP sets referenced by the deny rules of a policy or profile.
+// Passing nil rules removes the owner.
+func (m *policyManager) updateDenyIPSets(owner interface{}, ruleLists ...[]*proto.Rule) {
+	if m.denyIPSetsCallback == nil {
+		return
+	}
+	newIDs := set.New[string]()
+	for _, rules := range ruleLists {
+		for _, r := range rules {
+			if r.Action != "deny" {
+				continue
+			}
+			for _, ids := range [][]string{
+				r.SrcIpSetIds, r.DstIpSetIds, r.NotSrcIpSetIds, r.NotDstIpSetIds,
+				r.SrcNamedPortIpSetIds, r.DstNamedPortIpSetIds,
+				r.NotSrcNamedPortIpSetIds, r.NotDstNamedPortIpSetIds,
+				r.DstIpPortSetIds,
+			} {
+				newIDs.AddAll(ids)
+			}
+		}
+	}
+	oldIDs := m.denyIPSetsByOwner[owner]
+	if oldIDs == nil && newIDs.Len() == 0 {
+		return
+	}
+	if oldIDs != nil {
+		oldIDs.Iter(func(id string) error {
+			if m.denyIPSetRefCounts[id]--; m.denyIPSetRefCounts[id] == 0 {
+				delete(m.denyIPSetRefCounts, id)
+			}
+			return nil
+		})
+	}
+	newIDs.Iter(func(id string) error {
+		m.denyIPSetRefCounts[id]++
+		return nil
+	})
+	if newIDs.Len() > 0 {
+		m.denyIPSetsByOwner[owner] = newIDs
+	} else {
+		delete(m.denyIPSetsByOwner, owner)
+	}
+	if oldIDs == nil || !oldIDs.Equals(newIDs) {
+		m.denyIPSetsDirty = true
+	}
+}
+
+// claimChains records owner as the owner of the given chains and programs them into their
+// tables.  A chain whose name is already owned by a different policy or profile is not
+// programmed, since overwriting it would silently merge the two; it is kept pending instead and
+// takes over the name if the current owner is removed.
+func (m *policyManager) claimChains(owner interface{}, claims []chainClaim) {
+	var names []string
+	for _, claim := range claims {
+		claim.owner = owner
+		name := claim.chain.Name
+		names = append(names, name)
+		if otherOwner, ok := m.chainOwners[name]; ok && otherOwner != owner {
+			if m.setPendingClaim(claim) {
+				log.WithFields(log.Fields{
+					"chain":      name,
+					"owner":      otherOwner,
+					"otherOwner": owner,
+				}).Error("Bug: chain name collision between policies or profiles that the calculation " +
+					"graph should have prevented; ignoring the later one until the first is removed.")
+			}
+			continue
+		}
+		m.chainOwners[name] = owner
+		for _, t := range claim.tables {
+			t.UpdateChain(claim.chain)
+		}
+	}
+	if len(names) > 0 {
+		m.chainsByOwner[owner] = names
+	} else {
+		delete(m.chainsByOwner, owner)
+	}
+}
+
+// setPendingClaim adds or updates the owner's pending claim on a chain name.  It returns true if
+// the claim is new.
+func (m *policyManager) setPendingClaim(claim chainClaim) bool {
+	pending := m.pendingChains[claim.chain.Name]
+	for i, c := range pending {
+		if c.owner == claim.owner {
+			pending[i] = claim
+			return false
+		}
+	}
+	m.pendingChains[claim.chain.Name] = append(pending, claim)
+	return true
+}
+
+// releaseChains forgets the chains of the given owner.  Chains that it had programmed are removed
+// from the given tables, unless another policy or profile is waiting for the name, in which case
+// that one's chain is programmed in their place.
+func (m *policyManager) releaseChains(owner interface{}, tables ...IptablesTable) {
+	for _, name := range m.chainsByOwner[owner] {
+		pending := m.pendingChains[name]
+		if m.chainOwners[name] != owner {
+			for i, c := range pending {
+				if c.owner == owner {
+					pending = append(pending[:i:i], pending[i+1:]...)
+					break
+				}
+			}
+		} else {
+			delete(m.chainOwners, name)
+			for _, t := range tables {
+				t.RemoveChainByName(name)
+			}
+			if len(pending) > 0 {
+				next := pending[0]
+				pending = pending[1:]
+				log.WithFields(log.Fields{
+					"chain":    name,
+					"oldOwner": owner,
+					"owner":    next.owner,
+				}).Info("Owner of colliding chain name removed; programming the next policy or profile's chain.")
+				m.chainOwners[name] = next.owner
+				for _, t := range next.tables {
+					t.UpdateChain(next.chain)
+				}
+			}
+		}
+		if len(pending) > 0 {
+			m.pendingChains[name] = pending
+		} else {
+			delete(m.pendingChains, name)
+		}
+	}
+	delete(m.chainsByOwner, owner)
+}
+
 func (m *policyManager) OnUpdate(msg interface{}) {
 	switch msg := msg.(type) {
 	case *proto.ActivePolicyUpdate:
@@ -108,23 +281,23 @@ func (m *policyManager) OnUpdate(msg interface{}) {
 		// We can't easily tell whether the policy is in use in a particular table, and, if the policy
 		// type gets changed it may move between tables.  Hence, we put the policy into all tables.
 		// The iptables layer will avoid programming it if it is not actually used.
-		m.rawTable.UpdateChains(chains)
-		m.mangleTable.UpdateChains(chains)
-		m.filterTable.UpdateChains(chains)
+		claims := make([]chainClaim, len(chains))
+		for i, chain := range chains {
+			claims[i] = chainClaim{
+				chain:  chain,
+				tables: []IptablesTable{m.rawTable, m.mangleTable, m.filterTable},
+			}
+		}
+		m.claimChains(*msg.Id, claims)
+		m.updateDenyIPSets(*msg.Id, msg.Policy.InboundRules, msg.Policy.OutboundRules)
 	case *proto.ActivePolicyRemove:
 		log.WithField("id", msg.Id).Debug("Removing policy chains")
 		if m.rawEgressOnly {
 			m.mergeNeededIPSets(msg.Id, nil)
 		}
-		inName := rules.PolicyChainName(rules.PolicyInboundPfx, msg.Id)
-		outName := rules.PolicyChainName(rules.PolicyOutboundPfx, msg.Id)
+		m.updateDenyIPSets(*msg.Id)
 		// As above, we need to clean up in all the tables.
-		m.filterTable.RemoveChainByName(inName)
-		m.filterTable.RemoveChainByName(outName)
-		m.mangleTable.RemoveChainByName(inName)
-		m.mangleTable.RemoveChainByName(outName)
-		m.rawTable.RemoveChainByName(inName)
-		m.rawTable.RemoveChainByName(outName)
+		m.releaseChains(*msg.Id, m.filterTable, m.mangleTable, m.rawTable)
 	case *proto.ActiveProfileUpdate:
 		if m.rawEgressOnly {
 			log.WithField("id", msg.Id).Debug("Ignore non-untracked profile")
@@ -132,19 +305,28 @@ func (m *policyManager) OnUpdate(msg interface{}) {
 		}
 		log.WithField("id", msg.Id).Debug("Updating profile chains")
 		inbound, outbound := m.ruleRenderer.ProfileToIptablesChains(msg.Id, msg.Profile, m.ipVersion)
-		m.filterTable.UpdateChains([]*iptables.Chain{inbound, outbound})
-		m.mangleTable.UpdateChains([]*iptables.Chain{outbound})
+		m.claimChains(*msg.Id, []chainClaim{
+			{chain: inbound, tables: []IptablesTable{m.filterTable}},
+			{chain: outbound, tables: []IptablesTable{m.filterTable, m.mangleTable}},
+		})
+		m.updateDenyIPSets(*msg.Id, msg.Profile.InboundRules, msg.Profile.OutboundRules)
 	case *proto.ActiveProfileRemove:
 		log.WithField("id", msg.Id).Debug("Removing profile chains")
-		inName := rules.ProfileChainName(rules.ProfileInboundPfx, msg.Id)
-		outName := rules.ProfileChainName(rules.ProfileOutboundPfx, msg.Id)
-		m.filterTable.RemoveChainByName(inName)
-		m.filterTable.RemoveChainByName(outName)
-		m.mangleTable.RemoveChainByName(outName)
+		m.updateDenyIPSets(*msg.Id)
+		// Only the outbound chain is in the mangle table but removing a chain that isn't
+		// there is harmless.
+		m.releaseChains(*msg.Id, m.filterTable, m.mangleTable)
 	}
 }
 
 func (m *policyManager) CompleteDeferredWork() error {
-	// Nothing to do, we don't defer any work.
+	if m.denyIPSetsDirty {
+		setIDs := set.New[string]()
+		for id := range m.denyIPSetRefCounts {
+			setIDs.Add(id)
+		}
+		m.denyIPSetsCallback(setIDs)
+		m.denyIPSetsDirty = false
+	}
 	return nil
 }
@@ -41,7 +41,7 @@ var _ = Describe("Policy manager", func() {
 		mangleTable = newMockTable("mangle")
 		filterTable = newMockTable("filter")
 		ruleRenderer = newMockPolRenderer()
-		policyMgr = newPolicyManager(rawTable, mangleTable, filterTable, ruleRenderer, 4)
+		policyMgr = newPolicyManager(rawTable, mangleTable, filterTable, ruleRenderer, 4, nil)
 	})
 
 	It("shouldn't touch iptables", func() {
@@ -91,6 +91,71 @@ var _ = Describe("Policy manager", func() {
 		})
 	})
 
+	Describe("after two policies with colliding chain names", func() {
+		BeforeEach(func() {
+			// The mock renderer only uses the name of the policy so policies in different
+			// tiers with the same name collide.
+			for _, tier := range []string{"tier1", "tier2"} {
+				policyMgr.OnUpdate(&proto.ActivePolicyUpdate{
+					Id: &proto.PolicyID{Name: "pol1", Tier: tier},
+					Policy: &proto.Policy{
+						InboundRules: []*proto.Rule{{Action: "deny"}},
+					},
+				})
+			}
+			err := policyMgr.CompleteDeferredWork()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should keep the chains with their first owner", func() {
+			Expect(policyMgr.chainOwners).To(Equal(map[string]interface{}{
+				"cali-pi-pol1": proto.PolicyID{Name: "pol1", Tier: "tier1"},
+				"cali-po-pol1": proto.PolicyID{Name: "pol1", Tier: "tier1"},
+			}))
+			filterTable.checkChains([][]*iptables.Chain{{
+				{Name: "cali-pi-pol1"},
+				{Name: "cali-po-pol1"},
+			}})
+		})
+
+		It("should not remove the chains when the later policy is removed", func() {
+			policyMgr.OnUpdate(&proto.ActivePolicyRemove{
+				Id: &proto.PolicyID{Name: "pol1", Tier: "tier2"},
+			})
+			filterTable.checkChains([][]*iptables.Chain{{
+				{Name: "cali-pi-pol1"},
+				{Name: "cali-po-pol1"},
+			}})
+			Expect(policyMgr.pendingChains).To(BeEmpty())
+		})
+
+		It("should hand the chains to the later policy when the first policy is removed", func() {
+			policyMgr.OnUpdate(&proto.ActivePolicyRemove{
+				Id: &proto.PolicyID{Name: "pol1", Tier: "tier1"},
+			})
+			Expect(policyMgr.chainOwners).To(Equal(map[string]interface{}{
+				"cali-pi-pol1": proto.PolicyID{Name: "pol1", Tier: "tier2"},
+				"cali-po-pol1": proto.PolicyID{Name: "pol1", Tier: "tier2"},
+			}))
+			Expect(policyMgr.pendingChains).To(BeEmpty())
+			filterTable.checkChains([][]*iptables.Chain{{
+				{Name: "cali-pi-pol1"},
+				{Name: "cali-po-pol1"},
+			}})
+			mangleTable.checkChains([][]*iptables.Chain{{
+				{Name: "cali-pi-pol1"},
+				{Name: "cali-po-pol1"},
+			}})
+
+			policyMgr.OnUpdate(&proto.ActivePolicyRemove{
+				Id: &proto.PolicyID{Name: "pol1", Tier: "tier2"},
+			})
+			filterTable.checkChains([][]*iptables.Chain{})
+			mangleTable.checkChains([][]*iptables.Chain{})
+			Expect(policyMgr.chainOwners).To(BeEmpty())
+		})
+	})
+
 	Describe("after an untracked policy update", func() {
 		BeforeEach(func() {
 			policyMgr.OnUpdate(&proto.ActivePolicyUpdate{
@@ -248,6 +313,69 @@ var _ = Describe("Policy manager", func() {
 	})
 })
 
+var _ = Describe("Policy manager with deny IP sets callback", func() {
+	var (
+		policyMgr    *policyManager
+		denyIPSetIDs set.Set[string]
+	)
+
+	BeforeEach(func() {
+		denyIPSetIDs = nil
+		policyMgr = newPolicyManager(newMockTable("raw"), newMockTable("mangle"), newMockTable("filter"),
+			newMockPolRenderer(), 4, func(setIDs set.Set[string]) { denyIPSetIDs = setIDs })
+	})
+
+	It("correctly reports IP sets used by deny rules", func() {
+		By("defining a policy with deny and allow rules")
+		policyMgr.OnUpdate(&proto.ActivePolicyUpdate{
+			Id: &proto.PolicyID{Tier: "default", Name: "pol1"},
+			Policy: &proto.Policy{
+				InboundRules: []*proto.Rule{
+					{Action: "deny", SrcIpSetIds: []string{"ipsetA"}, NotDstIpSetIds: []string{"ipsetB"}},
+					{Action: "allow", SrcIpSetIds: []string{"ipsetC"}},
+				},
+			},
+		})
+		Expect(policyMgr.CompleteDeferredWork()).NotTo(HaveOccurred())
+		Expect(denyIPSetIDs).To(Equal(set.From("ipsetA", "ipsetB")))
+
+		By("defining a profile that shares an IP set")
+		policyMgr.OnUpdate(&proto.ActiveProfileUpdate{
+			Id: &proto.ProfileID{Name: "prof1"},
+			Profile: &proto.Profile{
+				OutboundRules: []*proto.Rule{
+					{Action: "deny", DstIpSetIds: []string{"ipsetA", "ipsetD"}},
+				},
+			},
+		})
+		Expect(policyMgr.CompleteDeferredWork()).NotTo(HaveOccurred())
+		Expect(denyIPSetIDs).To(Equal(set.From("ipsetA", "ipsetB", "ipsetD")))
+
+		By("removing the policy")
+		policyMgr.OnUpdate(&proto.ActivePolicyRemove{
+			Id: &proto.PolicyID{Tier: "default", Name: "pol1"},
+		})
+		Expect(policyMgr.CompleteDeferredWork()).NotTo(HaveOccurred())
+		Expect(denyIPSetIDs).To(Equal(set.From("ipsetA", "ipsetD")))
+
+		By("removing the profile")
+		policyMgr.OnUpdate(&proto.ActiveProfileRemove{
+			Id: &proto.ProfileID{Name: "prof1"},
+		})
+		Expect(policyMgr.CompleteDeferredWork()).NotTo(HaveOccurred())
+		Expect(denyIPSetIDs.Len()).To(BeZero())
+	})
+
+	It("should only call the callback when the IP sets change", func() {
+		policyMgr.OnUpdate(&proto.ActivePolicyUpdate{
+			Id:     &proto.PolicyID{Tier: "default", Name: "pol1"},
+			Policy: &proto.Policy{InboundRules: []*proto.Rule{{Action: "allow", SrcIpSetIds: []string{"ipsetA"}}}},
+		})
+		Expect(policyMgr.CompleteDeferredWork()).NotTo(HaveOccurred())
+		Expect(denyIPSetIDs).To(BeNil())
+	})
+})
+
 var _ = Describe("Raw egress policy manager", func() {
 	var (
 		policyMgr    *policyManager
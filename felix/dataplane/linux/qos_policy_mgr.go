@@ -0,0 +1,166 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/iptables"
+	"github.com/projectcalico/calico/felix/proto"
+	"github.com/projectcalico/calico/felix/rules"
+)
+
+// AnnotationDSCP is the workload endpoint annotation that requests DSCP marking of the traffic
+// that the workload sends.  Its value is either a DSCP value, from 0 to 63, or the name of a
+// standard class, such as "EF", "AF21" or "CS3".  The BPF dataplane does not support DSCP marking
+// and ignores the annotation.
+const AnnotationDSCP = "qos.projectcalico.org/dscp"
+
+// qosPolicyManager programs the 'cali-qos-policy' chain in the mangle table, which sets the DSCP
+// field of packets sent by local workloads that have the AnnotationDSCP annotation.  The chain is
+// statically linked from the start of cali-POSTROUTING, so it applies to forwarded traffic as
+// well as to traffic to the host.
+//
+// In BPF mode, forwarded traffic doesn't go through the mangle table, so the manager refuses to
+// program any marking rather than marking only some of the workload's traffic.
+type qosPolicyManager struct {
+	ipVersion  uint8
+	bpfEnabled bool
+
+	// Our dependencies.
+	mangleTable  IptablesTable
+	ruleRenderer rules.RuleRenderer
+
+	// Internal state.
+	activeChain *iptables.Chain
+	policies    map[proto.WorkloadEndpointID][]rules.QoSPolicy
+	dirty       bool
+}
+
+func newQoSPolicyManager(
+	mangleTable IptablesTable,
+	ruleRenderer rules.RuleRenderer,
+	ipVersion uint8,
+	bpfEnabled bool,
+) *qosPolicyManager {
+	return &qosPolicyManager{
+		ipVersion:    ipVersion,
+		bpfEnabled:   bpfEnabled,
+		mangleTable:  mangleTable,
+		ruleRenderer: ruleRenderer,
+		policies:     map[proto.WorkloadEndpointID][]rules.QoSPolicy{},
+		dirty:        true,
+	}
+}
+
+func (m *qosPolicyManager) OnUpdate(protoBufMsg interface{}) {
+	switch msg := protoBufMsg.(type) {
+	case *proto.WorkloadEndpointUpdate:
+		policies := m.workloadQoSPolicies(msg.Id, msg.Endpoint)
+		if len(policies) == 0 && len(m.policies[*msg.Id]) == 0 {
+			return
+		}
+		if len(policies) == 0 {
+			delete(m.policies, *msg.Id)
+		} else {
+			m.policies[*msg.Id] = policies
+		}
+		m.dirty = true
+	case *proto.WorkloadEndpointRemove:
+		if _, ok := m.policies[*msg.Id]; ok {
+			delete(m.policies, *msg.Id)
+			m.dirty = true
+		}
+	}
+}
+
+func (m *qosPolicyManager) workloadQoSPolicies(id *proto.WorkloadEndpointID, ep *proto.WorkloadEndpoint) []rules.QoSPolicy {
+	value, ok := ep.Annotations[AnnotationDSCP]
+	if !ok {
+		return nil
+	}
+	dscp, err := parseDSCP(value)
+	if err != nil {
+		log.WithError(err).WithField("id", id).Warn("Ignoring invalid DSCP annotation on workload endpoint")
+		return nil
+	}
+	if m.bpfEnabled {
+		log.WithField("id", id).Warn("Ignoring DSCP annotation on workload endpoint; " +
+			"DSCP marking is not supported by the BPF dataplane")
+		return nil
+	}
+
+	nets := ep.Ipv4Nets
+	if m.ipVersion == 6 {
+		nets = ep.Ipv6Nets
+	}
+	var policies []rules.QoSPolicy
+	for _, n := range nets {
+		policies = append(policies, rules.QoSPolicy{SrcAddrs: n, DSCP: dscp})
+	}
+	return policies
+}
+
+func (m *qosPolicyManager) CompleteDeferredWork() error {
+	if !m.dirty {
+		return nil
+	}
+
+	var policies []rules.QoSPolicy
+	for _, p := range m.policies {
+		policies = append(policies, p...)
+	}
+	// Sort the policies so we program rules in a determined order.
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].SrcAddrs < policies[j].SrcAddrs
+	})
+
+	chain := m.ruleRenderer.QoSPolicyChain(policies)
+	if !reflect.DeepEqual(m.activeChain, chain) {
+		m.mangleTable.UpdateChain(chain)
+		m.activeChain = chain
+	}
+	m.dirty = false
+	return nil
+}
+
+// parseDSCP parses a DSCP value, given as a number or as the name of a standard class.
+func parseDSCP(value string) (uint8, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	switch {
+	case value == "DF":
+		return 0, nil
+	case value == "EF":
+		return 46, nil
+	case len(value) == 3 && strings.HasPrefix(value, "CS") && value[2] >= '0' && value[2] <= '7':
+		// Class selectors use the top three bits.
+		return (value[2] - '0') << 3, nil
+	case len(value) == 4 && strings.HasPrefix(value, "AF") &&
+		value[2] >= '1' && value[2] <= '4' && value[3] >= '1' && value[3] <= '3':
+		// Assured forwarding class x, drop precedence y is 8x+2y.
+		return (value[2]-'0')<<3 | (value[3]-'0')<<1, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 8)
+	if err != nil || n > 63 {
+		return 0, fmt.Errorf("invalid DSCP value %q", value)
+	}
+	return uint8(n), nil
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ipsets"
+	"github.com/projectcalico/calico/felix/iptables"
+	"github.com/projectcalico/calico/felix/proto"
+	"github.com/projectcalico/calico/felix/rules"
+)
+
+type dscpMarking struct {
+	src  string
+	dscp uint8
+}
+
+func expectedQoSPolicyChain(markings ...dscpMarking) *iptables.Chain {
+	rules := []iptables.Rule{}
+	for _, m := range markings {
+		rules = append(rules, iptables.Rule{
+			Match:  iptables.Match().SourceNet(m.src),
+			Action: iptables.SetDSCPAction{Value: m.dscp},
+		})
+	}
+	return &iptables.Chain{
+		Name:  "cali-qos-policy",
+		Rules: rules,
+	}
+}
+
+var _ = Describe("QoS policy manager", func() {
+	var (
+		mgr         *qosPolicyManager
+		mangleTable *mockTable
+	)
+
+	wepID := func(name string) *proto.WorkloadEndpointID {
+		return &proto.WorkloadEndpointID{
+			OrchestratorId: "k8s",
+			WorkloadId:     name,
+			EndpointId:     "eth0",
+		}
+	}
+	wep := func(dscp string, v4Net, v6Net string) *proto.WorkloadEndpoint {
+		ep := &proto.WorkloadEndpoint{
+			State:    "up",
+			Name:     "cali12345-ab",
+			Ipv4Nets: []string{v4Net},
+			Ipv6Nets: []string{v6Net},
+		}
+		if dscp != "" {
+			ep.Annotations = map[string]string{AnnotationDSCP: dscp}
+		}
+		return ep
+	}
+
+	for _, ipVersion := range []uint8{4, 6} {
+		ipVersion := ipVersion
+		addr := func(v4, v6 string) string {
+			if ipVersion == 4 {
+				return v4
+			}
+			return v6
+		}
+
+		Describe(fmt.Sprintf("IPv%d", ipVersion), func() {
+			BeforeEach(func() {
+				renderer := rules.NewRenderer(rules.Config{
+					IPSetConfigV4:        ipsets.NewIPVersionConfig(ipsets.IPFamilyV4, "cali", nil, nil),
+					IPSetConfigV6:        ipsets.NewIPVersionConfig(ipsets.IPFamilyV6, "cali", nil, nil),
+					IptablesMarkAccept:   0x8,
+					IptablesMarkPass:     0x10,
+					IptablesMarkScratch0: 0x20,
+					IptablesMarkScratch1: 0x40,
+					IptablesMarkEndpoint: 0xff00,
+				})
+				mangleTable = newMockTable("mangle")
+				mgr = newQoSPolicyManager(mangleTable, renderer, ipVersion, false)
+			})
+
+			It("should program an empty chain initially", func() {
+				Expect(mgr.CompleteDeferredWork()).To(Succeed())
+				mangleTable.checkChains([][]*iptables.Chain{{expectedQoSPolicyChain()}})
+			})
+
+			It("should mark traffic from annotated workloads only", func() {
+				mgr.OnUpdate(&proto.WorkloadEndpointUpdate{
+					Id:       wepID("pod-1"),
+					Endpoint: wep("AF21", "10.0.0.2/32", "fd00::2/128"),
+				})
+				mgr.OnUpdate(&proto.WorkloadEndpointUpdate{
+					Id:       wepID("pod-2"),
+					Endpoint: wep("", "10.0.0.3/32", "fd00::3/128"),
+				})
+				mgr.OnUpdate(&proto.WorkloadEndpointUpdate{
+					Id:       wepID("pod-3"),
+					Endpoint: wep("40", "10.0.0.1/32", "fd00::1/128"),
+				})
+				Expect(mgr.CompleteDeferredWork()).To(Succeed())
+				mangleTable.checkChains([][]*iptables.Chain{{expectedQoSPolicyChain(
+					dscpMarking{addr("10.0.0.1/32", "fd00::1/128"), 40},
+					dscpMarking{addr("10.0.0.2/32", "fd00::2/128"), 18},
+				)}})
+
+				By("removing the annotation")
+				mgr.OnUpdate(&proto.WorkloadEndpointUpdate{
+					Id:       wepID("pod-3"),
+					Endpoint: wep("", "10.0.0.1/32", "fd00::1/128"),
+				})
+				Expect(mgr.CompleteDeferredWork()).To(Succeed())
+				mangleTable.checkChains([][]*iptables.Chain{{expectedQoSPolicyChain(
+					dscpMarking{addr("10.0.0.2/32", "fd00::2/128"), 18},
+				)}})
+
+				By("removing the endpoint")
+				mgr.OnUpdate(&proto.WorkloadEndpointRemove{Id: wepID("pod-1")})
+				Expect(mgr.CompleteDeferredWork()).To(Succeed())
+				mangleTable.checkChains([][]*iptables.Chain{{expectedQoSPolicyChain()}})
+			})
+
+			It("should ignore an invalid annotation", func() {
+				mgr.OnUpdate(&proto.WorkloadEndpointUpdate{
+					Id:       wepID("pod-1"),
+					Endpoint: wep("64", "10.0.0.2/32", "fd00::2/128"),
+				})
+				Expect(mgr.CompleteDeferredWork()).To(Succeed())
+				mangleTable.checkChains([][]*iptables.Chain{{expectedQoSPolicyChain()}})
+			})
+
+			It("should not mark traffic in BPF mode", func() {
+				mgr.bpfEnabled = true
+				mgr.OnUpdate(&proto.WorkloadEndpointUpdate{
+					Id:       wepID("pod-1"),
+					Endpoint: wep("AF21", "10.0.0.2/32", "fd00::2/128"),
+				})
+				Expect(mgr.CompleteDeferredWork()).To(Succeed())
+				mangleTable.checkChains([][]*iptables.Chain{{expectedQoSPolicyChain()}})
+			})
+		})
+	}
+})
+
+var _ = DescribeTable("parseDSCP",
+	func(value string, expected uint8, expectErr bool) {
+		dscp, err := parseDSCP(value)
+		if expectErr {
+			Expect(err).To(HaveOccurred())
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dscp).To(Equal(expected))
+	},
+	Entry("number", "26", uint8(26), false),
+	Entry("zero", "0", uint8(0), false),
+	Entry("DF", "DF", uint8(0), false),
+	Entry("EF", "EF", uint8(46), false),
+	Entry("lower case", "ef", uint8(46), false),
+	Entry("CS6", "CS6", uint8(48), false),
+	Entry("AF11", "AF11", uint8(10), false),
+	Entry("AF43", "AF43", uint8(38), false),
+	Entry("too large", "64", uint8(0), true),
+	Entry("negative", "-1", uint8(0), true),
+	Entry("unknown class", "AF44", uint8(0), true),
+	Entry("garbage", "fast", uint8(0), true),
+)
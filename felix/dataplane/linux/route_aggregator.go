@@ -0,0 +1,146 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/ip"
+	"github.com/projectcalico/calico/felix/routetable"
+)
+
+// routeAggregator merges routes that share a next hop into the minimal set of covering prefixes
+// before they're passed to a route table.  For example, the routes for two sibling /26 IPAM
+// blocks on the same remote node become a single /25 route.  A covering prefix only ever contains
+// the addresses of the routes that it replaces, so aggregation doesn't change where any packet is
+// sent.
+//
+// When a block within an aggregate diverges (for example, because it moves to a different node or
+// is released), the aggregate has to be split back into more-specific routes.  The route table
+// deletes routes before it adds them so, to avoid a window where the block's neighbours have no
+// route at all, the aggregator keeps the old aggregate for one more round after it is split.  The
+// more-specific routes win the longest-prefix match as soon as they're programmed and the
+// aggregate is removed on the following round.
+type routeAggregator struct {
+	// aggregates contains the aggregate routes that we returned last time, keyed on CIDR.
+	aggregates map[ip.CIDR]routetable.Target
+	// retiring contains the aggregates that have been split and that we're keeping for one round.
+	retiring map[ip.CIDR]routetable.Target
+
+	logCtx *logrus.Entry
+}
+
+// nextHopKey identifies routes that can be merged; that is, routes that differ only in their CIDR.
+type nextHopKey struct {
+	Type    routetable.TargetType
+	GW      ip.Addr
+	Src     ip.Addr
+	DestMAC string
+}
+
+func newRouteAggregator(logCtx *logrus.Entry) *routeAggregator {
+	return &routeAggregator{
+		aggregates: map[ip.CIDR]routetable.Target{},
+		retiring:   map[ip.CIDR]routetable.Target{},
+		logCtx:     logCtx,
+	}
+}
+
+// Aggregate returns the aggregated equivalent of the given routes, which must have unique CIDRs.
+// If retiring is true, the result includes aggregates that have been split; the caller should call
+// Aggregate again once the result has been programmed so that they can be removed.
+func (a *routeAggregator) Aggregate(targets []routetable.Target) (aggregated []routetable.Target, retiring bool) {
+	inputCIDRs := map[ip.CIDR]bool{}
+	var keys []nextHopKey
+	groups := map[nextHopKey]map[ip.CIDR]routetable.Target{}
+	for _, t := range targets {
+		cidr := t.CIDR.Canonicalize()
+		inputCIDRs[cidr] = true
+		k := nextHopKey{Type: t.Type, GW: t.GW, Src: t.Src, DestMAC: t.DestMAC.String()}
+		if groups[k] == nil {
+			keys = append(keys, k)
+			groups[k] = map[ip.CIDR]routetable.Target{}
+		}
+		groups[k][cidr] = t
+	}
+
+	outputCIDRs := map[ip.CIDR]bool{}
+	newAggregates := map[ip.CIDR]routetable.Target{}
+	for _, k := range keys {
+		group := groups[k]
+		cidrs := make([]ip.CIDR, 0, len(group))
+		for cidr := range group {
+			cidrs = append(cidrs, cidr)
+		}
+		for _, cidr := range ip.Aggregate(cidrs) {
+			if t, ok := group[cidr]; ok {
+				// Either a lone route or one that covers the rest of its group.
+				aggregated = append(aggregated, t)
+				outputCIDRs[cidr] = true
+				continue
+			}
+			if inputCIDRs[cidr] || outputCIDRs[cidr] {
+				// Overlapping routes with different next hops would give us two routes for the
+				// same CIDR; fall back to the routes that we were given.
+				a.logCtx.WithField("cidr", cidr).Debug("Aggregate clashes with another route, not aggregating.")
+				for c, t := range group {
+					if cidr.ContainsCIDR(c) {
+						aggregated = append(aggregated, t)
+						outputCIDRs[c] = true
+					}
+				}
+				continue
+			}
+			t := group[cidrs[0]]
+			t.CIDR = cidr
+			aggregated = append(aggregated, t)
+			outputCIDRs[cidr] = true
+			newAggregates[cidr] = t
+		}
+	}
+
+	// Keep any aggregates that have just been split until their more-specific routes are in place.
+	// Aggregates that were already retiring last time are dropped now.
+	newRetiring := map[ip.CIDR]routetable.Target{}
+	for cidr, old := range a.aggregates {
+		if _, ok := a.retiring[cidr]; ok || outputCIDRs[cidr] {
+			continue
+		}
+		split := false
+		for c := range outputCIDRs {
+			if cidr.ContainsCIDR(c) {
+				split = true
+				break
+			}
+		}
+		if !split {
+			continue
+		}
+		a.logCtx.WithField("cidr", cidr).Debug("Route aggregate split, keeping it until its replacements are programmed.")
+		aggregated = append(aggregated, old)
+		newAggregates[cidr] = old
+		newRetiring[cidr] = old
+	}
+
+	a.aggregates = newAggregates
+	a.retiring = newRetiring
+	if len(targets) != len(aggregated) {
+		a.logCtx.WithFields(logrus.Fields{
+			"numRoutes":     len(targets),
+			"numAggregated": len(aggregated),
+		}).Debug("Aggregated routes.")
+	}
+	return aggregated, len(newRetiring) > 0
+}
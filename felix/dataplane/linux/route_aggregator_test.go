@@ -0,0 +1,189 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/ip"
+	"github.com/projectcalico/calico/felix/routetable"
+)
+
+var _ = Describe("Route aggregator", func() {
+	var a *routeAggregator
+
+	vxlanRoute := func(cidr, gw string) routetable.Target {
+		return routetable.Target{
+			Type: routetable.TargetTypeVXLAN,
+			CIDR: ip.MustParseCIDROrIP(cidr),
+			GW:   ip.FromString(gw),
+		}
+	}
+
+	BeforeEach(func() {
+		a = newRouteAggregator(logrus.WithField("test", "aggregator"))
+	})
+
+	It("should merge sibling blocks with the same next hop", func() {
+		routes, retiring := a.Aggregate([]routetable.Target{
+			vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+			vxlanRoute("10.0.0.64/26", "172.16.0.1"),
+			vxlanRoute("10.0.0.128/25", "172.16.0.1"),
+			vxlanRoute("10.0.1.0/26", "172.16.0.1"),
+		})
+		Expect(retiring).To(BeFalse())
+		Expect(routes).To(ConsistOf(
+			vxlanRoute("10.0.0.0/24", "172.16.0.1"),
+			vxlanRoute("10.0.1.0/26", "172.16.0.1"),
+		))
+	})
+
+	It("should not merge blocks with different next hops", func() {
+		routes, _ := a.Aggregate([]routetable.Target{
+			vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+			vxlanRoute("10.0.0.64/26", "172.16.0.2"),
+		})
+		Expect(routes).To(ConsistOf(
+			vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+			vxlanRoute("10.0.0.64/26", "172.16.0.2"),
+		))
+	})
+
+	It("should not merge blocks with different route types", func() {
+		noEncap := vxlanRoute("10.0.0.64/26", "172.16.0.1")
+		noEncap.Type = routetable.TargetTypeNoEncap
+		routes, _ := a.Aggregate([]routetable.Target{
+			vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+			noEncap,
+		})
+		Expect(routes).To(ConsistOf(vxlanRoute("10.0.0.0/26", "172.16.0.1"), noEncap))
+	})
+
+	It("should leave a more-specific route to another node alone", func() {
+		routes, _ := a.Aggregate([]routetable.Target{
+			vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+			vxlanRoute("10.0.0.64/26", "172.16.0.1"),
+			vxlanRoute("10.0.0.5/32", "172.16.0.2"),
+		})
+		Expect(routes).To(ConsistOf(
+			vxlanRoute("10.0.0.0/25", "172.16.0.1"),
+			vxlanRoute("10.0.0.5/32", "172.16.0.2"),
+		))
+	})
+
+	It("should not aggregate into a CIDR that another node has a route for", func() {
+		routes, _ := a.Aggregate([]routetable.Target{
+			vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+			vxlanRoute("10.0.0.64/26", "172.16.0.1"),
+			vxlanRoute("10.0.0.0/25", "172.16.0.2"),
+		})
+		Expect(routes).To(ConsistOf(
+			vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+			vxlanRoute("10.0.0.64/26", "172.16.0.1"),
+			vxlanRoute("10.0.0.0/25", "172.16.0.2"),
+		))
+	})
+
+	It("should aggregate IPv6 routes", func() {
+		routes, _ := a.Aggregate([]routetable.Target{
+			vxlanRoute("fd00:10:244::/122", "fd00::1"),
+			vxlanRoute("fd00:10:244::40/122", "fd00::1"),
+		})
+		Expect(routes).To(ConsistOf(vxlanRoute("fd00:10:244::/121", "fd00::1")))
+	})
+
+	Describe("after aggregating", func() {
+		BeforeEach(func() {
+			routes, _ := a.Aggregate([]routetable.Target{
+				vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+				vxlanRoute("10.0.0.64/26", "172.16.0.1"),
+				vxlanRoute("10.0.0.128/25", "172.16.0.1"),
+			})
+			Expect(routes).To(ConsistOf(vxlanRoute("10.0.0.0/24", "172.16.0.1")))
+		})
+
+		It("should keep the aggregate for one round when a block moves to another node", func() {
+			newRoutes := []routetable.Target{
+				vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+				vxlanRoute("10.0.0.64/26", "172.16.0.2"),
+				vxlanRoute("10.0.0.128/25", "172.16.0.1"),
+			}
+			routes, retiring := a.Aggregate(newRoutes)
+			Expect(retiring).To(BeTrue())
+			Expect(routes).To(ConsistOf(
+				vxlanRoute("10.0.0.0/24", "172.16.0.1"),
+				vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+				vxlanRoute("10.0.0.64/26", "172.16.0.2"),
+				vxlanRoute("10.0.0.128/25", "172.16.0.1"),
+			))
+
+			routes, retiring = a.Aggregate(newRoutes)
+			Expect(retiring).To(BeFalse())
+			Expect(routes).To(ConsistOf(newRoutes))
+		})
+
+		It("should keep the aggregate for one round when a block is removed", func() {
+			newRoutes := []routetable.Target{
+				vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+				vxlanRoute("10.0.0.128/25", "172.16.0.1"),
+			}
+			routes, retiring := a.Aggregate(newRoutes)
+			Expect(retiring).To(BeTrue())
+			Expect(routes).To(ConsistOf(
+				vxlanRoute("10.0.0.0/24", "172.16.0.1"),
+				vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+				vxlanRoute("10.0.0.128/25", "172.16.0.1"),
+			))
+
+			routes, retiring = a.Aggregate(newRoutes)
+			Expect(retiring).To(BeFalse())
+			Expect(routes).To(ConsistOf(newRoutes))
+		})
+
+		It("should remove the aggregate straight away when all its blocks are removed", func() {
+			routes, retiring := a.Aggregate(nil)
+			Expect(retiring).To(BeFalse())
+			Expect(routes).To(BeEmpty())
+		})
+
+		It("should replace the aggregate straight away when all its blocks move together", func() {
+			routes, retiring := a.Aggregate([]routetable.Target{
+				vxlanRoute("10.0.0.0/26", "172.16.0.2"),
+				vxlanRoute("10.0.0.64/26", "172.16.0.2"),
+				vxlanRoute("10.0.0.128/25", "172.16.0.2"),
+			})
+			Expect(retiring).To(BeFalse())
+			Expect(routes).To(ConsistOf(vxlanRoute("10.0.0.0/24", "172.16.0.2")))
+		})
+
+		It("should not keep a retiring aggregate after it has been re-formed", func() {
+			_, retiring := a.Aggregate([]routetable.Target{
+				vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+				vxlanRoute("10.0.0.128/25", "172.16.0.1"),
+			})
+			Expect(retiring).To(BeTrue())
+
+			routes, retiring := a.Aggregate([]routetable.Target{
+				vxlanRoute("10.0.0.0/26", "172.16.0.1"),
+				vxlanRoute("10.0.0.64/26", "172.16.0.1"),
+				vxlanRoute("10.0.0.128/25", "172.16.0.1"),
+			})
+			Expect(retiring).To(BeFalse())
+			Expect(routes).To(ConsistOf(vxlanRoute("10.0.0.0/24", "172.16.0.1")))
+		})
+	})
+})
@@ -0,0 +1,146 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/environment"
+	"github.com/projectcalico/calico/felix/ip"
+	"github.com/projectcalico/calico/felix/logutils"
+	"github.com/projectcalico/calico/felix/netlinkshim"
+	"github.com/projectcalico/calico/felix/routerule"
+	"github.com/projectcalico/calico/felix/routetable"
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+)
+
+// tproxyManager manages the routing that delivers traffic diverted by the TPROXY rules in the
+// mangle table to the local transparent proxy.  The TPROXY target marks the diverted packets but
+// leaves their destination alone, so we need:
+//   - a routing rule that sends marked packets to our TPROXY routing table
+//   - a local default route in that table, so that the packets are delivered to the proxy's socket.
+//
+// The manager is added irrespective of whether TPROXY is enabled so that the route and routing
+// rule are tidied up if it is disabled.  Its state is static; programming happens through the
+// routetable and routerule interfaces.
+type tproxyManager struct {
+	routeTable routetable.RouteTableInterface
+	routeRules routeRules
+}
+
+// registerTPROXYManager creates and registers the TPROXY manager for the given IP version, if a
+// routing table was allocated for it.
+func (d *InternalDataplane) registerTPROXYManager(ipVersion uint8, featureDetector environment.FeatureDetectorIface) {
+	if d.config.TPROXYRoutingTableIndex == 0 {
+		log.Debug("No TPROXY routing table allocated")
+		return
+	}
+	m, err := newTPROXYManager(d.config, ipVersion, d.loopSummarizer, featureDetector)
+	if err != nil {
+		log.WithError(err).WithField("ipVersion", ipVersion).Panic("Unexpected error creating TPROXY manager")
+	}
+	d.RegisterManager(m)
+}
+
+func newTPROXYManager(
+	dpConfig Config,
+	ipVersion uint8,
+	opRecorder logutils.OpRecorder,
+	featureDetector environment.FeatureDetectorIface,
+) (*tproxyManager, error) {
+	var routeTable routetable.RouteTableInterface
+	if !dpConfig.RouteSyncDisabled {
+		routeTable = routetable.New(
+			[]string{"^lo$"},
+			ipVersion,
+			false, // vxlan
+			dpConfig.NetlinkTimeout,
+			nil, // deviceRouteSourceAddress
+			dpConfig.DeviceRouteProtocol,
+			true, // removeExternalRoutes
+			dpConfig.TPROXYRoutingTableIndex,
+			opRecorder,
+			featureDetector,
+		)
+	} else {
+		log.Info("RouteSyncDisabled is true, using DummyTable for TPROXY.")
+		routeTable = &routetable.DummyTable{}
+	}
+
+	rr, err := routerule.New(
+		int(ipVersion),
+		set.From(dpConfig.TPROXYRoutingTableIndex),
+		routerule.RulesMatchSrcFWMarkTable,
+		routerule.RulesMatchSrcFWMarkTable,
+		dpConfig.NetlinkTimeout,
+		func() (routerule.HandleIface, error) {
+			return netlinkshim.NewRealNetlink()
+		},
+		opRecorder,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTPROXYManagerWithShims(routeTable, rr, dpConfig, ipVersion), nil
+}
+
+func newTPROXYManagerWithShims(
+	routeTable routetable.RouteTableInterface,
+	routeRules routeRules,
+	dpConfig Config,
+	ipVersion uint8,
+) *tproxyManager {
+	if ipVersion != 4 && ipVersion != 6 {
+		log.Panicf("Unknown IP version: %d", ipVersion)
+	}
+
+	if dpConfig.RulesConfig.TPROXYEnabled {
+		defaultCIDR := ip.MustParseCIDROrIP("0.0.0.0/0")
+		if ipVersion == 6 {
+			defaultCIDR = ip.MustParseCIDROrIP("::/0")
+		}
+		routeTable.SetRoutes("lo", []routetable.Target{{
+			Type: routetable.TargetTypeLocal,
+			CIDR: defaultCIDR,
+		}})
+
+		mark := dpConfig.RulesConfig.TPROXYIptablesMark
+		routeRules.SetRule(routerule.NewRule(int(ipVersion), dpConfig.TPROXYRoutingRulePriority).
+			MatchFWMarkWithMask(mark, mark).
+			GoToTable(dpConfig.TPROXYRoutingTableIndex))
+	}
+
+	return &tproxyManager{
+		routeTable: routeTable,
+		routeRules: routeRules,
+	}
+}
+
+func (m *tproxyManager) OnUpdate(protoBufMsg interface{}) {
+}
+
+func (m *tproxyManager) CompleteDeferredWork() error {
+	// Dataplane programming is handled through the routetable and routerule interfaces.
+	return nil
+}
+
+func (m *tproxyManager) GetRouteTableSyncers() []routetable.RouteTableSyncer {
+	return []routetable.RouteTableSyncer{m.routeTable}
+}
+
+func (m *tproxyManager) GetRouteRules() []routeRules {
+	return []routeRules{m.routeRules}
+}
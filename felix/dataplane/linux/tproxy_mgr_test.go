@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+	"github.com/projectcalico/calico/felix/routerule"
+	"github.com/projectcalico/calico/felix/routetable"
+	"github.com/projectcalico/calico/felix/rules"
+)
+
+type mockRouteRules struct {
+	rules []*routerule.Rule
+}
+
+func (r *mockRouteRules) SetRule(rule *routerule.Rule) {
+	r.rules = append(r.rules, rule)
+}
+
+func (r *mockRouteRules) RemoveRule(rule *routerule.Rule) {}
+func (r *mockRouteRules) QueueResync()                    {}
+func (r *mockRouteRules) Apply() error {
+	return nil
+}
+
+var _ = Describe("TPROXY manager", func() {
+	var (
+		rt     *mockRouteTable
+		rr     *mockRouteRules
+		config Config
+	)
+
+	BeforeEach(func() {
+		rt = &mockRouteTable{
+			currentRoutes:   map[string][]routetable.Target{},
+			currentL2Routes: map[string][]routetable.L2Target{},
+		}
+		rr = &mockRouteRules{}
+		config = Config{
+			RulesConfig: rules.Config{
+				TPROXYEnabled:      true,
+				TPROXYIptablesMark: 0x400,
+			},
+			TPROXYRoutingTableIndex:   250,
+			TPROXYRoutingRulePriority: 98,
+		}
+	})
+
+	It("should program a local default route and a routing rule for the TPROXY mark", func() {
+		m := newTPROXYManagerWithShims(rt, rr, config, 4)
+
+		rt.checkRoutes("lo", []routetable.Target{{
+			Type: routetable.TargetTypeLocal,
+			CIDR: ip.MustParseCIDROrIP("0.0.0.0/0"),
+		}})
+		Expect(rr.rules).To(ConsistOf(routerule.NewRule(4, 98).
+			MatchFWMarkWithMask(0x400, 0x400).
+			GoToTable(250)))
+		Expect(m.GetRouteTableSyncers()).To(ConsistOf(rt))
+		Expect(m.GetRouteRules()).To(ConsistOf(rr))
+	})
+
+	It("should use an IPv6 default route for IPv6", func() {
+		newTPROXYManagerWithShims(rt, rr, config, 6)
+
+		rt.checkRoutes("lo", []routetable.Target{{
+			Type: routetable.TargetTypeLocal,
+			CIDR: ip.MustParseCIDROrIP("::/0"),
+		}})
+		Expect(rr.rules).To(HaveLen(1))
+	})
+
+	It("should program nothing when TPROXY is disabled", func() {
+		config.RulesConfig.TPROXYEnabled = false
+		newTPROXYManagerWithShims(rt, rr, config, 4)
+
+		Expect(rt.currentRoutes).To(BeEmpty())
+		Expect(rr.rules).To(BeEmpty())
+	})
+})
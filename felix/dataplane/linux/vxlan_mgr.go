@@ -88,12 +88,23 @@ type vxlanManager struct {
 	noEncapRTConstruct func(interfacePrefixes []string, ipVersion uint8, vxlan bool, netlinkTimeout time.Duration,
 		deviceRouteSourceAddress net.IP, deviceRouteProtocol netlink.RouteProtocol, removeExternalRoutes bool) routetable.RouteTableInterface
 
+	// Route aggregators for the VXLAN and no-encap routes; nil if route aggregation is disabled.
+	vxlanRouteAggregator   *routeAggregator
+	noEncapRouteAggregator *routeAggregator
+	// Set if split aggregates are still programmed; we need another pass to remove them.
+	retiringAggregates bool
+
 	// Log context
 	logCtx *logrus.Entry
 }
 
 const (
 	defaultVXLANProto netlink.RouteProtocol = 80
+
+	// retireAggregatesDelay is how soon we come back to remove split route aggregates.  The
+	// aggregates' replacements are programmed in the same apply() that splits them so we only
+	// need to wait for the next one.
+	retireAggregatesDelay = 100 * time.Millisecond
 )
 
 func newVXLANManager(
@@ -181,6 +192,11 @@ func newVXLANManagerWithShims(
 	}
 
 	logCtx := logrus.WithField("ipVersion", ipVersion)
+	var vxlanRouteAggregator, noEncapRouteAggregator *routeAggregator
+	if dpConfig.RouteAggregationEnabled {
+		vxlanRouteAggregator = newRouteAggregator(logCtx.WithField("routes", "vxlan"))
+		noEncapRouteAggregator = newRouteAggregator(logCtx.WithField("routes", "noencap"))
+	}
 	return &vxlanManager{
 		ipsetsDataplane: ipsetsDataplane,
 		ipSetMetadata: ipsets.IPSetMetadata{
@@ -188,24 +204,26 @@ func newVXLANManagerWithShims(
 			SetID:   rules.IPSetIDAllVXLANSourceNets,
 			Type:    ipsets.IPSetTypeHashNet,
 		},
-		hostname:            dpConfig.Hostname,
-		routeTable:          rt,
-		blackholeRouteTable: brt,
-		routesByDest:        map[string]*proto.RouteUpdate{},
-		localIPAMBlocks:     map[string]*proto.RouteUpdate{},
-		vtepsByNode:         map[string]*proto.VXLANTunnelEndpointUpdate{},
-		vxlanDevice:         deviceName,
-		vxlanID:             dpConfig.RulesConfig.VXLANVNI,
-		vxlanPort:           dpConfig.RulesConfig.VXLANPort,
-		ipVersion:           ipVersion,
-		externalNodeCIDRs:   dpConfig.ExternalNodesCidrs,
-		routesDirty:         true,
-		vtepsDirty:          true,
-		dpConfig:            dpConfig,
-		nlHandle:            nlHandle,
-		noEncapProtocol:     noEncapProtocol,
-		noEncapRTConstruct:  noEncapRTConstruct,
-		logCtx:              logCtx,
+		hostname:               dpConfig.Hostname,
+		routeTable:             rt,
+		blackholeRouteTable:    brt,
+		routesByDest:           map[string]*proto.RouteUpdate{},
+		localIPAMBlocks:        map[string]*proto.RouteUpdate{},
+		vtepsByNode:            map[string]*proto.VXLANTunnelEndpointUpdate{},
+		vxlanDevice:            deviceName,
+		vxlanID:                dpConfig.RulesConfig.VXLANVNI,
+		vxlanPort:              dpConfig.RulesConfig.VXLANPort,
+		ipVersion:              ipVersion,
+		externalNodeCIDRs:      dpConfig.ExternalNodesCidrs,
+		routesDirty:            true,
+		vtepsDirty:             true,
+		dpConfig:               dpConfig,
+		nlHandle:               nlHandle,
+		noEncapProtocol:        noEncapProtocol,
+		noEncapRTConstruct:     noEncapRTConstruct,
+		vxlanRouteAggregator:   vxlanRouteAggregator,
+		noEncapRouteAggregator: noEncapRouteAggregator,
+		logCtx:                 logCtx,
 	}
 }
 
@@ -497,6 +515,14 @@ func (m *vxlanManager) CompleteDeferredWork() error {
 			}
 		}
 
+		// If enabled, merge routes with the same next hop.  When an aggregate is split, the
+		// aggregator keeps it until the next apply; we come back to remove it once its
+		// replacements are programmed (see RescheduleAfter).
+		retiringAggregates := false
+		if m.vxlanRouteAggregator != nil {
+			vxlanRoutes, retiringAggregates = m.vxlanRouteAggregator.Aggregate(vxlanRoutes)
+		}
+
 		m.logCtx.WithField("vxlanroutes", vxlanRoutes).Debug("VXLAN manager sending VXLAN L3 updates")
 		m.routeTable.SetRoutes(m.vxlanDevice, vxlanRoutes)
 
@@ -508,6 +534,11 @@ func (m *vxlanManager) CompleteDeferredWork() error {
 		if noEncapRouteTable != nil {
 			if parentDevice, err := m.getLocalVTEPParent(); err == nil {
 				ifName := parentDevice.Attrs().Name
+				if m.noEncapRouteAggregator != nil {
+					var retiring bool
+					noEncapRoutes, retiring = m.noEncapRouteAggregator.Aggregate(noEncapRoutes)
+					retiringAggregates = retiringAggregates || retiring
+				}
 				m.logCtx.WithField("link", parentDevice).WithField("routes", noEncapRoutes).Debug("VXLAN manager sending unencapsulated L3 updates")
 				noEncapRouteTable.SetRoutes(ifName, noEncapRoutes)
 			} else {
@@ -519,12 +550,24 @@ func (m *vxlanManager) CompleteDeferredWork() error {
 
 		m.logCtx.Info("VXLAN Manager completed deferred work")
 
-		m.routesDirty = false
+		// Leave the routes dirty so that we recalculate them, and drop the split aggregates, on
+		// the next pass.
+		m.retiringAggregates = retiringAggregates
+		m.routesDirty = retiringAggregates
 	}
 
 	return nil
 }
 
+// RescheduleAfter asks for another apply() if we're keeping split route aggregates until their
+// replacements are programmed.
+func (m *vxlanManager) RescheduleAfter() time.Duration {
+	if m.retiringAggregates {
+		return retireAggregatesDelay
+	}
+	return 0
+}
+
 // KeepVXLANDeviceInSync is a goroutine that configures the VXLAN tunnel device, then periodically
 // checks that it is still correctly configured.
 func (m *vxlanManager) KeepVXLANDeviceInSync(mtu int, xsumBroken bool, wait time.Duration) {
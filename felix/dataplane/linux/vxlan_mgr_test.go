@@ -421,4 +421,111 @@ var _ = Describe("VXLANManager", func() {
 		Expect(managerV6.routesDirty).To(BeFalse())
 		Expect(prt.currentRoutes["eth0"]).To(HaveLen(1))
 	})
+
+	It("aggregates routes when route aggregation is enabled", func() {
+		la := netlink.NewLinkAttrs()
+		la.Name = "eth0"
+		manager = newVXLANManagerWithShims(
+			common.NewMockIPSets(),
+			rt, brt,
+			"vxlan.calico",
+			Config{
+				MaxIPSetSize:            5,
+				Hostname:                "node1",
+				ExternalNodesCidrs:      []string{"10.0.0.0/24"},
+				RouteAggregationEnabled: true,
+				RulesConfig: rules.Config{
+					VXLANVNI:  1,
+					VXLANPort: 20,
+				},
+			},
+			&mockVXLANDataplane{
+				links:     []netlink.Link{&mockLink{attrs: la}},
+				ipVersion: 4,
+			},
+			4,
+			func(interfacePrefixes []string, ipVersion uint8, vxlan bool, netlinkTimeout time.Duration,
+				deviceRouteSourceAddress net.IP, deviceRouteProtocol netlink.RouteProtocol, removeExternalRoutes bool) routetable.RouteTableInterface {
+				return prt
+			},
+		)
+		manager.OnUpdate(&proto.VXLANTunnelEndpointUpdate{
+			Node:           "node1",
+			Mac:            "00:0a:74:9d:68:16",
+			Ipv4Addr:       "10.0.0.0",
+			ParentDeviceIp: "172.0.0.2",
+		})
+		manager.OnUpdate(&proto.VXLANTunnelEndpointUpdate{
+			Node:           "node2",
+			Mac:            "00:0a:95:9d:68:16",
+			Ipv4Addr:       "10.0.80.0",
+			ParentDeviceIp: "172.0.12.1",
+		})
+		manager.OnUpdate(&proto.VXLANTunnelEndpointUpdate{
+			Node:           "node3",
+			Mac:            "00:0a:95:9d:68:17",
+			Ipv4Addr:       "10.0.81.0",
+			ParentDeviceIp: "172.0.12.2",
+		})
+		manager.noEncapRouteTable = prt
+		for _, dst := range []string{"192.168.0.0/26", "192.168.0.64/26", "192.168.0.128/25"} {
+			manager.OnUpdate(&proto.RouteUpdate{
+				Type:        proto.RouteType_REMOTE_WORKLOAD,
+				IpPoolType:  proto.IPPoolType_VXLAN,
+				Dst:         dst,
+				DstNodeName: "node2",
+				DstNodeIp:   "172.8.8.8",
+			})
+		}
+
+		err := manager.CompleteDeferredWork()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rt.currentRoutes["vxlan.calico"]).To(ConsistOf(routetable.Target{
+			Type: routetable.TargetTypeVXLAN,
+			CIDR: ip.MustParseCIDROrIP("192.168.0.0/24"),
+			GW:   ip.FromString("10.0.80.0"),
+		}))
+
+		// Move one of the blocks to another node.  The aggregate should be kept until the
+		// more-specific routes have been programmed.
+		manager.OnUpdate(&proto.RouteUpdate{
+			Type:        proto.RouteType_REMOTE_WORKLOAD,
+			IpPoolType:  proto.IPPoolType_VXLAN,
+			Dst:         "192.168.0.64/26",
+			DstNodeName: "node3",
+			DstNodeIp:   "172.8.8.9",
+		})
+		err = manager.CompleteDeferredWork()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manager.routesDirty).To(BeTrue())
+		Expect(manager.RescheduleAfter()).To(Equal(retireAggregatesDelay))
+		Expect(rt.currentRoutes["vxlan.calico"]).To(HaveLen(4))
+		Expect(rt.currentRoutes["vxlan.calico"]).To(ContainElement(routetable.Target{
+			Type: routetable.TargetTypeVXLAN,
+			CIDR: ip.MustParseCIDROrIP("192.168.0.0/24"),
+			GW:   ip.FromString("10.0.80.0"),
+		}))
+
+		err = manager.CompleteDeferredWork()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manager.routesDirty).To(BeFalse())
+		Expect(manager.RescheduleAfter()).To(BeZero())
+		Expect(rt.currentRoutes["vxlan.calico"]).To(ConsistOf(
+			routetable.Target{
+				Type: routetable.TargetTypeVXLAN,
+				CIDR: ip.MustParseCIDROrIP("192.168.0.0/26"),
+				GW:   ip.FromString("10.0.80.0"),
+			},
+			routetable.Target{
+				Type: routetable.TargetTypeVXLAN,
+				CIDR: ip.MustParseCIDROrIP("192.168.0.64/26"),
+				GW:   ip.FromString("10.0.81.0"),
+			},
+			routetable.Target{
+				Type: routetable.TargetTypeVXLAN,
+				CIDR: ip.MustParseCIDROrIP("192.168.0.128/25"),
+				GW:   ip.FromString("10.0.80.0"),
+			},
+		))
+	})
 })
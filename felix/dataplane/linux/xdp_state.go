@@ -1473,6 +1473,10 @@ func isValidRuleForXDP(rule *proto.Rule) bool {
 		// have no icmp stuff
 		rule.Icmp == nil &&
 		rule.NotIcmp == nil &&
+		len(rule.IcmpTypes) == 0 &&
+		len(rule.IcmpTypeCodes) == 0 &&
+		len(rule.NotIcmpTypes) == 0 &&
+		len(rule.NotIcmpTypeCodes) == 0 &&
 		// have no destination stuff
 		len(rule.DstNet) == 0 &&
 		len(rule.DstPorts) == 0 &&
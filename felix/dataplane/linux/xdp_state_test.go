@@ -88,6 +88,10 @@ var knownProtoRuleFields = set.From(
 	"NotProtocol",
 	"Icmp",
 	"NotIcmp",
+	"IcmpTypes",
+	"IcmpTypeCodes",
+	"NotIcmpTypes",
+	"NotIcmpTypeCodes",
 	"Action",
 	"IpVersion",
 	"DstNet",
@@ -1532,6 +1536,22 @@ var _ = Describe("XDP state", func() {
 							name: "notIcmpDefined",
 							rule: modifiedRule("NotIcmp", &proto.Rule_NotIcmpType{}),
 						},
+						{
+							name: "icmpTypesDefined",
+							rule: modifiedRule("IcmpTypes", []int32{8}),
+						},
+						{
+							name: "icmpTypeCodesDefined",
+							rule: modifiedRule("IcmpTypeCodes", []*proto.IcmpTypeAndCode{{Type: 3, Code: 4}}),
+						},
+						{
+							name: "notIcmpTypesDefined",
+							rule: modifiedRule("NotIcmpTypes", []int32{8}),
+						},
+						{
+							name: "notIcmpTypeCodesDefined",
+							rule: modifiedRule("NotIcmpTypeCodes", []*proto.IcmpTypeAndCode{{Type: 3, Code: 4}}),
+						},
 						{
 							name: "srcNetDefined",
 							rule: modifiedRule("SrcNet", []string{"net"}),
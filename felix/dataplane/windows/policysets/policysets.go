@@ -302,7 +302,7 @@ func (s *PolicySets) protoRuleToHnsRules(policyId string, pRule *proto.Rule, isI
 	}
 
 	// Skip rules with ICMP type/codes, these are not supported
-	if pRule.Icmp != nil {
+	if pRule.Icmp != nil || len(pRule.IcmpTypes) > 0 || len(pRule.IcmpTypeCodes) > 0 {
 		log.WithField("rule", pRule).Info("Skipping rule because it contains ICMP type or code (currently unsupported).")
 		return nil, ErrNotSupported
 	}
@@ -644,7 +644,7 @@ func ruleHasNegativeMatches(pRule *proto.Rule) bool {
 	if pRule.NotProtocol != nil {
 		return true
 	}
-	if pRule.NotIcmp != nil {
+	if pRule.NotIcmp != nil || len(pRule.NotIcmpTypes) > 0 || len(pRule.NotIcmpTypeCodes) > 0 {
 		return true
 	}
 	return false
@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debugapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// GetIPSetReports fetches reports for the given IP sets (or for all IP sets, if no IDs are given)
+// from the debug endpoint at addr, which is in host:port form.  If member is non-empty, only that
+// member is included in the member lists.
+func GetIPSetReports(ctx context.Context, addr string, setIDs []string, member string) ([]*IPSetReport, error) {
+	query := url.Values{}
+	for _, id := range setIDs {
+		query.Add("id", id)
+	}
+	if member != "" {
+		query.Set("member", member)
+	}
+	var reports []*IPSetReport
+	if err := getJSON(ctx, addr, IPSetsPath, query, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// GetExplanation asks the debug endpoint at addr, which is in host:port form, to explain how
+// the calculated rules treat the given packet.
+func GetExplanation(ctx context.Context, addr string, req *ExplainRequest) (*ExplainReport, error) {
+	query := url.Values{}
+	query.Set("iface", req.Iface)
+	query.Set("direction", req.Direction)
+	query.Set("src", req.Packet.SrcIP.String())
+	query.Set("dst", req.Packet.DstIP.String())
+	if req.Packet.Protocol != "" {
+		query.Set("protocol", req.Packet.Protocol)
+	}
+	if req.Packet.ConntrackState != "" {
+		query.Set("ctstate", req.Packet.ConntrackState)
+	}
+	for name, v := range map[string]uint16{
+		"sport":     req.Packet.SrcPort,
+		"dport":     req.Packet.DstPort,
+		"icmp-type": uint16(req.Packet.ICMPType),
+		"icmp-code": uint16(req.Packet.ICMPCode),
+	} {
+		if v != 0 {
+			query.Set(name, strconv.Itoa(int(v)))
+		}
+	}
+	var report ExplainReport
+	if err := getJSON(ctx, addr, ExplainPath, query, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func getJSON(ctx context.Context, addr, path string, query url.Values, v interface{}) error {
+	u := url.URL{
+		Scheme:   "http",
+		Host:     addr,
+		Path:     path,
+		RawQuery: query.Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("debug endpoint returned %s: %s", resp.Status, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode debug report: %w", err)
+	}
+	return nil
+}
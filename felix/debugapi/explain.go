@@ -0,0 +1,167 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debugapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/iptables"
+)
+
+const (
+	// ExplainPath is the path of the packet explanation report.  It takes the following query
+	// parameters: "iface" and "direction" (required), which select the endpoint and whether the
+	// packet is heading to it ("ingress") or coming from it ("egress"); "src" and "dst"
+	// (required), "protocol", "sport", "dport", "icmp-type", "icmp-code" and "ctstate", which
+	// describe the packet.
+	ExplainPath = "/explain"
+
+	DirectionIngress = "ingress"
+	DirectionEgress  = "egress"
+)
+
+// policyCommentRegexp matches the comment that the rule renderer puts on the first rule of each
+// policy and profile chain.
+var policyCommentRegexp = regexp.MustCompile(`^(Policy|Profile) \S+ (ingress|egress)$`)
+
+// ExplainRequest describes a packet to explain.
+type ExplainRequest struct {
+	// Iface is the interface of the endpoint.
+	Iface string `json:"iface"`
+	// Direction is DirectionIngress, for a packet that is heading to the endpoint, or
+	// DirectionEgress, for a packet that is coming from it.
+	Direction string          `json:"direction"`
+	Packet    iptables.Packet `json:"packet"`
+}
+
+// ExplainReport describes how the calculated rules treat a packet.
+type ExplainReport struct {
+	*iptables.Explanation
+	// Policy is the last policy or profile with a rule that matched the packet, as named by the
+	// comment on its chain (for example, "Policy default.allow-db ingress").  It is empty if no
+	// policy or profile rule matched, in which case the verdict comes from Felix's own rules,
+	// such as the drop for packets that no policy accepted.
+	Policy string `json:"policy,omitempty"`
+}
+
+// PacketExplainer is implemented by dataplane drivers that can explain how their rules treat a
+// packet.
+type PacketExplainer interface {
+	ExplainPacket(ctx context.Context, iface string, ingress bool, pkt iptables.Packet) (*iptables.Explanation, error)
+}
+
+func (s *Server) serveExplain(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "Only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.explainer == nil {
+		http.Error(w, "The dataplane driver doesn't support explaining packets", http.StatusNotImplemented)
+		return
+	}
+	explainReq, err := parseExplainQuery(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), requestTimeout)
+	defer cancel()
+	report, err := s.ExplainPacket(ctx, explainReq)
+	if err != nil {
+		log.WithError(err).Warn("Failed to explain packet")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, report)
+}
+
+// ExplainPacket walks the packet through the dataplane's calculated rules and reports the rules
+// that it matches, the verdict and the policy that produced it.
+func (s *Server) ExplainPacket(ctx context.Context, req *ExplainRequest) (*ExplainReport, error) {
+	if s.explainer == nil {
+		return nil, fmt.Errorf("the dataplane driver doesn't support explaining packets")
+	}
+	explanation, err := s.explainer.ExplainPacket(ctx, req.Iface, req.Direction == DirectionIngress, req.Packet)
+	if err != nil {
+		return nil, err
+	}
+	report := &ExplainReport{Explanation: explanation}
+	for _, step := range explanation.Steps {
+		if !step.Matched {
+			continue
+		}
+		for _, c := range step.ChainComment {
+			if policyCommentRegexp.MatchString(c) {
+				report.Policy = c
+			}
+		}
+	}
+	return report, nil
+}
+
+func parseExplainQuery(req *http.Request) (*ExplainRequest, error) {
+	query := req.URL.Query()
+	r := &ExplainRequest{
+		Iface:     query.Get("iface"),
+		Direction: query.Get("direction"),
+		Packet: iptables.Packet{
+			Protocol:       query.Get("protocol"),
+			ConntrackState: query.Get("ctstate"),
+		},
+	}
+	if r.Iface == "" {
+		return nil, fmt.Errorf("missing iface parameter")
+	}
+	if r.Direction != DirectionIngress && r.Direction != DirectionEgress {
+		return nil, fmt.Errorf("direction must be %q or %q", DirectionIngress, DirectionEgress)
+	}
+	for _, p := range []struct {
+		name string
+		ip   *net.IP
+	}{{"src", &r.Packet.SrcIP}, {"dst", &r.Packet.DstIP}} {
+		*p.ip = net.ParseIP(query.Get(p.name))
+		if *p.ip == nil {
+			return nil, fmt.Errorf("missing or invalid %s parameter", p.name)
+		}
+	}
+	for _, p := range []struct {
+		name string
+		bits int
+		set  func(uint64)
+	}{
+		{"sport", 16, func(v uint64) { r.Packet.SrcPort = uint16(v) }},
+		{"dport", 16, func(v uint64) { r.Packet.DstPort = uint16(v) }},
+		{"icmp-type", 8, func(v uint64) { r.Packet.ICMPType = uint8(v) }},
+		{"icmp-code", 8, func(v uint64) { r.Packet.ICMPCode = uint8(v) }},
+	} {
+		value := query.Get(p.name)
+		if value == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(value, 10, p.bits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s parameter: %w", p.name, err)
+		}
+		p.set(v)
+	}
+	return r, nil
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debugapi_test
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/projectcalico/calico/felix/debugapi"
+	"github.com/projectcalico/calico/felix/iptables"
+)
+
+type mockExplainer struct {
+	iface       string
+	ingress     bool
+	pkt         iptables.Packet
+	explanation *iptables.Explanation
+}
+
+func (m *mockExplainer) ExplainPacket(_ context.Context, iface string, ingress bool, pkt iptables.Packet) (*iptables.Explanation, error) {
+	m.iface = iface
+	m.ingress = ingress
+	m.pkt = pkt
+	return m.explanation, nil
+}
+
+var _ = Describe("Debug API packet explanations", func() {
+	var (
+		explainer *mockExplainer
+		server    *httptest.Server
+		addr      string
+	)
+
+	BeforeEach(func() {
+		explainer = &mockExplainer{explanation: &iptables.Explanation{
+			Verdict: iptables.VerdictAccept,
+			Mark:    0x10000,
+			Steps: []*iptables.ExplainStep{
+				{
+					Chain:   "cali-tw-cali1234",
+					RuleNum: 4,
+					Rule:    "--jump cali-pi-_abcd",
+					Matched: true,
+				},
+				{
+					Chain:        "cali-pi-_abcd",
+					RuleNum:      1,
+					Rule:         "-p tcp --jump MARK --set-mark 0x10000/0x10000",
+					ChainComment: []string{"Policy default.allow-db ingress"},
+					Matched:      true,
+				},
+				{
+					Chain:   "cali-tw-cali1234",
+					RuleNum: 5,
+					Rule:    "-m mark --mark 0x10000/0x10000 --jump RETURN",
+					Matched: true,
+				},
+			},
+		}}
+		server = httptest.NewServer(NewServer(&mockAttributor{}, nil, explainer).Handler())
+		addr = strings.TrimPrefix(server.URL, "http://")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should pass the packet to the dataplane and attribute the verdict", func() {
+		report, err := GetExplanation(context.Background(), addr, &ExplainRequest{
+			Iface:     "cali1234",
+			Direction: DirectionIngress,
+			Packet: iptables.Packet{
+				Protocol: "tcp",
+				SrcIP:    net.ParseIP("10.0.0.1"),
+				DstIP:    net.ParseIP("10.0.0.2"),
+				SrcPort:  34567,
+				DstPort:  5432,
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(explainer.iface).To(Equal("cali1234"))
+		Expect(explainer.ingress).To(BeTrue())
+		Expect(explainer.pkt.Protocol).To(Equal("tcp"))
+		Expect(explainer.pkt.SrcIP.String()).To(Equal("10.0.0.1"))
+		Expect(explainer.pkt.DstPort).To(Equal(uint16(5432)))
+
+		Expect(report.Verdict).To(Equal(iptables.VerdictAccept))
+		Expect(report.Steps).To(HaveLen(3))
+		Expect(report.Policy).To(Equal("Policy default.allow-db ingress"))
+	})
+
+	It("should not attribute a verdict that no policy produced", func() {
+		explainer.explanation.Steps = explainer.explanation.Steps[:1]
+		report, err := GetExplanation(context.Background(), addr, &ExplainRequest{
+			Iface:     "cali1234",
+			Direction: DirectionEgress,
+			Packet: iptables.Packet{
+				SrcIP: net.ParseIP("10.0.0.2"),
+				DstIP: net.ParseIP("10.0.0.1"),
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(explainer.ingress).To(BeFalse())
+		Expect(report.Policy).To(BeEmpty())
+	})
+
+	It("should reject requests without a direction", func() {
+		_, err := GetExplanation(context.Background(), addr, &ExplainRequest{
+			Iface: "cali1234",
+			Packet: iptables.Packet{
+				SrcIP: net.ParseIP("10.0.0.2"),
+				DstIP: net.ParseIP("10.0.0.1"),
+			},
+		})
+		Expect(err).To(MatchError(ContainSubstring("direction must be")))
+	})
+
+	It("should report that explanations aren't supported", func() {
+		_, err := NewServer(&mockAttributor{}, nil, nil).ExplainPacket(context.Background(), &ExplainRequest{})
+		Expect(err).To(MatchError(ContainSubstring("doesn't support")))
+	})
+})
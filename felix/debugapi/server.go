@@ -0,0 +1,252 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package debugapi implements Felix's debug HTTP endpoint, which reports the calculated and
+// programmed contents of Felix's IP sets, along with the policies and endpoints that produced
+// each member, and explains how the calculated rules treat a given packet.
+package debugapi
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/calc"
+	"github.com/projectcalico/calico/felix/ip"
+	"github.com/projectcalico/calico/felix/ipsets"
+)
+
+const (
+	// IPSetsPath is the path of the IP sets report.  It accepts any number of "id" query
+	// parameters, to limit the report to particular IP sets, and a "member" parameter, to limit
+	// the members that are reported.
+	IPSetsPath = "/ipsets"
+
+	requestTimeout = 30 * time.Second
+)
+
+// IPSetReport describes an IP set, as calculated by the calculation graph and as programmed by
+// the dataplane.
+type IPSetReport struct {
+	SetID string `json:"setID"`
+	// Attribution is nil if the IP set was not calculated by the calculation graph; for example,
+	// for the IP sets that Felix maintains internally.
+	Attribution *calc.IPSetAttribution `json:"attribution,omitempty"`
+	// Dataplane holds the state of the IP set in each IP family.  It is empty if the IP set has
+	// not been programmed or if the dataplane driver doesn't support reporting its IP sets.
+	Dataplane []*DataplaneIPSet `json:"dataplane,omitempty"`
+}
+
+// DataplaneIPSet describes an IP set in one IP family.
+type DataplaneIPSet struct {
+	*ipsets.IPSetDebugInfo
+	// Sources maps each desired member of the IP set to the endpoints and network sets that
+	// contribute it.  Members with no known source are omitted.
+	Sources map[string][]string `json:"sources,omitempty"`
+}
+
+// IPSetAttributor is implemented by the calculation graph.
+type IPSetAttributor interface {
+	IPSetAttributions(ctx context.Context, setIDs []string) ([]*calc.IPSetAttribution, error)
+}
+
+// IPSetsDebugger is implemented by dataplane drivers that can report the state of their IP sets.
+type IPSetsDebugger interface {
+	IPSetsDebugInfo(ctx context.Context, setIDs []string) ([]*ipsets.IPSetDebugInfo, error)
+}
+
+// Server serves the debug endpoint.
+type Server struct {
+	calcGraph IPSetAttributor
+	// dataplane is nil if the dataplane driver doesn't support reporting its IP sets.
+	dataplane IPSetsDebugger
+	// explainer is nil if the dataplane driver doesn't support explaining packets.
+	explainer PacketExplainer
+}
+
+func NewServer(calcGraph IPSetAttributor, dataplane IPSetsDebugger, explainer PacketExplainer) *Server {
+	return &Server{
+		calcGraph: calcGraph,
+		dataplane: dataplane,
+		explainer: explainer,
+	}
+}
+
+// Handler returns an http.Handler for the debug endpoint.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(IPSetsPath, s.serveIPSets)
+	mux.HandleFunc(ExplainPath, s.serveExplain)
+	return mux
+}
+
+// Serve listens on the given host and port and serves the debug endpoint.  It never returns;
+// if the server fails, it is restarted.
+func (s *Server) Serve(host string, port int) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	log.WithField("addr", addr).Info("Starting debug endpoint")
+	for {
+		err := http.ListenAndServe(addr, s.Handler())
+		log.WithError(err).Error("Debug endpoint failed, trying to restart it...")
+		time.Sleep(1 * time.Second)
+	}
+}
+
+func (s *Server) serveIPSets(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "Only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), requestTimeout)
+	defer cancel()
+	query := req.URL.Query()
+	reports, err := s.IPSetReports(ctx, query["id"], query.Get("member"))
+	if err != nil {
+		log.WithError(err).Warn("Failed to generate IP sets debug report")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, reports)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.WithError(err).Warn("Failed to write debug report")
+	}
+}
+
+// IPSetReports returns reports for the given IP sets, or for all IP sets if no IDs are given.
+// If member is non-empty, only that member is included in the member lists.
+func (s *Server) IPSetReports(ctx context.Context, setIDs []string, member string) ([]*IPSetReport, error) {
+	attributions, err := s.calcGraph.IPSetAttributions(ctx, setIDs)
+	if err != nil {
+		return nil, err
+	}
+	var infos []*ipsets.IPSetDebugInfo
+	if s.dataplane != nil {
+		infos, err = s.dataplane.IPSetsDebugInfo(ctx, setIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reportsByID := map[string]*IPSetReport{}
+	reportFor := func(setID string) *IPSetReport {
+		r := reportsByID[setID]
+		if r == nil {
+			r = &IPSetReport{SetID: setID}
+			reportsByID[setID] = r
+		}
+		return r
+	}
+	for _, a := range attributions {
+		reportFor(a.SetID).Attribution = a
+	}
+	for _, info := range infos {
+		r := reportFor(info.SetID)
+		r.Dataplane = append(r.Dataplane, &DataplaneIPSet{IPSetDebugInfo: info})
+	}
+
+	var reports []*IPSetReport
+	for _, r := range reportsByID {
+		r.calculateSources()
+		if member != "" {
+			r.filterMembers(member)
+		}
+		reports = append(reports, r)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].SetID < reports[j].SetID
+	})
+	return reports, nil
+}
+
+// calculateSources fills in the sources of the dataplane members from the attribution.  The
+// calculation graph and the dataplane format members slightly differently, so members are
+// compared in normalised form.
+func (r *IPSetReport) calculateSources() {
+	if r.Attribution == nil {
+		return
+	}
+	contributors := map[string][]string{}
+	for m, c := range r.Attribution.Contributors {
+		contributors[normaliseMember(m)] = c
+	}
+	for _, d := range r.Dataplane {
+		for _, m := range d.Desired {
+			if c, ok := contributors[normaliseMember(m)]; ok {
+				if d.Sources == nil {
+					d.Sources = map[string][]string{}
+				}
+				d.Sources[m] = c
+			}
+		}
+	}
+}
+
+// filterMembers removes all but the given member from the report's member lists.
+func (r *IPSetReport) filterMembers(member string) {
+	member = normaliseMember(member)
+	filter := func(members []string) []string {
+		if members == nil {
+			return nil
+		}
+		filtered := []string{}
+		for _, m := range members {
+			if normaliseMember(m) == member {
+				filtered = append(filtered, m)
+			}
+		}
+		return filtered
+	}
+	filterMap := func(members map[string][]string) {
+		for m := range members {
+			if normaliseMember(m) != member {
+				delete(members, m)
+			}
+		}
+	}
+	if r.Attribution != nil {
+		filterMap(r.Attribution.Contributors)
+	}
+	for _, d := range r.Dataplane {
+		d.Desired = filter(d.Desired)
+		d.Dataplane = filter(d.Dataplane)
+		d.Missing = filter(d.Missing)
+		d.Unexpected = filter(d.Unexpected)
+		filterMap(d.Sources)
+	}
+}
+
+// normaliseMember converts a member to a form that can be compared, by converting a single-host
+// CIDR to a plain IP address.  Other members are returned unchanged.
+func normaliseMember(member string) string {
+	cidr, err := ip.ParseCIDROrIP(member)
+	if err != nil {
+		return member
+	}
+	if (cidr.Version() == 4 && cidr.Prefix() == 32) || (cidr.Version() == 6 && cidr.Prefix() == 128) {
+		return cidr.Addr().String()
+	}
+	return cidr.String()
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debugapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/calc"
+	. "github.com/projectcalico/calico/felix/debugapi"
+	"github.com/projectcalico/calico/felix/ipsets"
+)
+
+type mockAttributor struct {
+	attributions []*calc.IPSetAttribution
+}
+
+func (m *mockAttributor) IPSetAttributions(_ context.Context, _ []string) ([]*calc.IPSetAttribution, error) {
+	return m.attributions, nil
+}
+
+type mockDebugger struct {
+	infos []*ipsets.IPSetDebugInfo
+	err   error
+}
+
+func (m *mockDebugger) IPSetsDebugInfo(_ context.Context, _ []string) ([]*ipsets.IPSetDebugInfo, error) {
+	return m.infos, m.err
+}
+
+var _ = Describe("Debug API", func() {
+	var (
+		attributor *mockAttributor
+		debugger   *mockDebugger
+		server     *httptest.Server
+		addr       string
+	)
+
+	BeforeEach(func() {
+		attributor = &mockAttributor{attributions: []*calc.IPSetAttribution{{
+			SetID:    "s:abcd",
+			Selector: "role == 'db'",
+			Rules:    []string{"Policy(name=default.allow-db)"},
+			Contributors: map[string][]string{
+				"10.0.0.1/32": {"WorkloadEndpoint(node=n1, name=db-1)"},
+				"10.0.0.2/32": {"WorkloadEndpoint(node=n1, name=db-2)"},
+			},
+		}}}
+		debugger = &mockDebugger{infos: []*ipsets.IPSetDebugInfo{
+			{
+				SetID:         "s:abcd",
+				Family:        ipsets.IPFamilyV4,
+				Type:          ipsets.IPSetTypeHashNet,
+				MainIPSetName: "cali40s:abcd",
+				Desired:       []string{"10.0.0.1/32", "10.0.0.2/32"},
+				Dataplane:     []string{"10.0.0.1/32"},
+				Missing:       []string{"10.0.0.2/32"},
+				Unexpected:    []string{},
+			},
+			{
+				SetID:         "all-ipam-pools",
+				Family:        ipsets.IPFamilyV4,
+				Type:          ipsets.IPSetTypeHashNet,
+				MainIPSetName: "cali40all-ipam-pools",
+				Desired:       []string{"192.168.0.0/16"},
+				Dataplane:     []string{"192.168.0.0/16"},
+				Missing:       []string{},
+				Unexpected:    []string{},
+			},
+		}}
+		server = httptest.NewServer(NewServer(attributor, debugger, nil).Handler())
+		addr = strings.TrimPrefix(server.URL, "http://")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should combine the calculated and dataplane state", func() {
+		reports, err := GetIPSetReports(context.Background(), addr, nil, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reports).To(HaveLen(2))
+
+		Expect(reports[0].SetID).To(Equal("all-ipam-pools"))
+		Expect(reports[0].Attribution).To(BeNil())
+		Expect(reports[0].Dataplane).To(HaveLen(1))
+		Expect(reports[0].Dataplane[0].Sources).To(BeNil())
+
+		Expect(reports[1].SetID).To(Equal("s:abcd"))
+		Expect(reports[1].Attribution.Selector).To(Equal("role == 'db'"))
+		Expect(reports[1].Dataplane).To(HaveLen(1))
+		Expect(reports[1].Dataplane[0].Missing).To(Equal([]string{"10.0.0.2/32"}))
+		Expect(reports[1].Dataplane[0].Sources).To(Equal(map[string][]string{
+			"10.0.0.1/32": {"WorkloadEndpoint(node=n1, name=db-1)"},
+			"10.0.0.2/32": {"WorkloadEndpoint(node=n1, name=db-2)"},
+		}))
+	})
+
+	It("should match members that are formatted differently", func() {
+		debugger.infos[0].Type = ipsets.IPSetTypeHashIP
+		debugger.infos[0].Desired = []string{"10.0.0.1", "10.0.0.2"}
+		reports, err := GetIPSetReports(context.Background(), addr, nil, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reports[1].Dataplane[0].Sources).To(HaveKeyWithValue(
+			"10.0.0.1", []string{"WorkloadEndpoint(node=n1, name=db-1)"}))
+	})
+
+	It("should filter the members", func() {
+		reports, err := GetIPSetReports(context.Background(), addr, nil, "10.0.0.2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reports[1].Attribution.Contributors).To(Equal(map[string][]string{
+			"10.0.0.2/32": {"WorkloadEndpoint(node=n1, name=db-2)"},
+		}))
+		dp := reports[1].Dataplane[0]
+		Expect(dp.Desired).To(Equal([]string{"10.0.0.2/32"}))
+		Expect(dp.Dataplane).To(BeEmpty())
+		Expect(dp.Missing).To(Equal([]string{"10.0.0.2/32"}))
+		Expect(reports[0].Dataplane[0].Desired).To(BeEmpty())
+	})
+
+	It("should report dataplane errors", func() {
+		debugger.err = errors.New("ipset list failed")
+		_, err := GetIPSetReports(context.Background(), addr, nil, "")
+		Expect(err).To(MatchError(ContainSubstring("ipset list failed")))
+	})
+
+	It("should work without dataplane support", func() {
+		reports, err := NewServer(attributor, nil, nil).IPSetReports(context.Background(), nil, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reports).To(HaveLen(1))
+		Expect(reports[0].Dataplane).To(BeEmpty())
+	})
+})
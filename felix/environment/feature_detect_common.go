@@ -8,6 +8,10 @@ type Features struct {
 	// RestoreSupportsLock is true if the iptables-restore command supports taking the xtables lock and the
 	// associated -w and -W arguments.
 	RestoreSupportsLock bool
+	// NFTRestoreReplacesByIndex is true if iptables-nft-restore honours the rule number of every replace command
+	// in its input.  Before v1.8.3, only the first replace took effect at the right index
+	// (https://bugzilla.netfilter.org/show_bug.cgi?id=1348).
+	NFTRestoreReplacesByIndex bool
 	// ChecksumOffloadBroken is true for kernels that have broken checksum offload for packets with SNATted source
 	// ports. See https://github.com/projectcalico/calico/issues/3145.  On such kernels we disable checksum offload
 	// on our VXLAN device.
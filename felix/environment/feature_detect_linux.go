@@ -44,6 +44,8 @@ var (
 	v1Dot6Dot0 = MustParseVersion("1.6.0")
 	// v1Dot6Dot2 added --random-fully to MASQUERADE and the xtables lock to iptables-restore.
 	v1Dot6Dot2 = MustParseVersion("1.6.2")
+	// v1Dot8Dot3 fixed iptables-nft-restore's handling of replace commands.
+	v1Dot8Dot3 = MustParseVersion("1.8.3")
 
 	// Linux kernel versions:
 	// v3Dot10Dot0 is the oldest version we support at time of writing.
@@ -125,12 +127,13 @@ func (d *FeatureDetector) refreshFeaturesLockHeld() {
 
 	// Calculate the features.
 	features := Features{
-		SNATFullyRandom:          iptV.Compare(v1Dot6Dot0) >= 0 && kerV.Compare(v3Dot14Dot0) >= 0,
-		MASQFullyRandom:          iptV.Compare(v1Dot6Dot2) >= 0 && kerV.Compare(v3Dot14Dot0) >= 0,
-		RestoreSupportsLock:      iptV.Compare(v1Dot6Dot2) >= 0,
-		ChecksumOffloadBroken:    true, // Was supposed to be fixed in v5.7 but still seems to be broken.
-		IPIPDeviceIsL3:           d.ipipDeviceIsL3(),
-		KernelSideRouteFiltering: netlinkSupportsStrict,
+		SNATFullyRandom:           iptV.Compare(v1Dot6Dot0) >= 0 && kerV.Compare(v3Dot14Dot0) >= 0,
+		MASQFullyRandom:           iptV.Compare(v1Dot6Dot2) >= 0 && kerV.Compare(v3Dot14Dot0) >= 0,
+		RestoreSupportsLock:       iptV.Compare(v1Dot6Dot2) >= 0,
+		NFTRestoreReplacesByIndex: iptV.Compare(v1Dot8Dot3) >= 0,
+		ChecksumOffloadBroken:     true, // Was supposed to be fixed in v5.7 but still seems to be broken.
+		IPIPDeviceIsL3:            d.ipipDeviceIsL3(),
+		KernelSideRouteFiltering:  netlinkSupportsStrict,
 	}
 
 	for k, v := range d.featureOverride {
@@ -141,10 +141,11 @@ func TestFeatureDetection(t *testing.T) {
 			"iptables v1.8.4",
 			"Linux version 5.7.0",
 			Features{
-				RestoreSupportsLock:   true,
-				SNATFullyRandom:       true,
-				MASQFullyRandom:       true,
-				ChecksumOffloadBroken: true,
+				RestoreSupportsLock:       true,
+				NFTRestoreReplacesByIndex: true,
+				SNATFullyRandom:           true,
+				MASQFullyRandom:           true,
+				ChecksumOffloadBroken:     true,
 			},
 		},
 	} {
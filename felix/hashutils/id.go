@@ -23,10 +23,29 @@ import (
 
 const shortenedPrefix = "_"
 
+// IDVersion identifies the scheme that GetVersionedLengthLimitedID uses to shorten IDs.
+type IDVersion int
+
+const (
+	// IDVersion1 hashes the suffix alone and marks shortened IDs with "_".
+	IDVersion1 IDVersion = 1
+	// IDVersion2 hashes the prefix along with the suffix, so that a suffix hashes differently
+	// under each prefix, and marks shortened IDs with "_2" so that they can't be mistaken for
+	// version 1 IDs.
+	IDVersion2 IDVersion = 2
+)
+
 // GetLengthLimitedID returns an ID that consists of the given prefix and, either the given suffix,
 // or, if that would exceed the length limit, a cryptographic hash of the suffix, truncated to the
 // required length.
 func GetLengthLimitedID(fixedPrefix, suffix string, maxLength int) string {
+	return GetVersionedLengthLimitedID(IDVersion1, fixedPrefix, suffix, maxLength)
+}
+
+// GetVersionedLengthLimitedID is like GetLengthLimitedID but it shortens the suffix using the
+// given version of the hashing scheme.  IDs that don't need shortening are the same in every
+// version.
+func GetVersionedLengthLimitedID(version IDVersion, fixedPrefix, suffix string, maxLength int) string {
 	prefixLen := len(fixedPrefix)
 	suffixLen := len(suffix)
 	totalLen := prefixLen + suffixLen
@@ -34,14 +53,24 @@ func GetLengthLimitedID(fixedPrefix, suffix string, maxLength int) string {
 		// Either it's just too long, or it's exactly the right length but it happens to
 		// start with the character that we use to denote a shortened string, which could
 		// result in a clash.  Hash the value and truncate...
+		marker := shortenedPrefix
+		hashInput := suffix
+		switch version {
+		case IDVersion1:
+		case IDVersion2:
+			marker = shortenedPrefix + "2"
+			hashInput = fixedPrefix + ":" + suffix
+		default:
+			log.WithField("version", version).Panic("Unknown ID version.")
+		}
 		hasher := sha256.New()
-		_, err := hasher.Write([]byte(suffix))
+		_, err := hasher.Write([]byte(hashInput))
 		if err != nil {
 			log.WithError(err).Panic("Failed to write suffix to hash.")
 		}
 		hash := base64.RawURLEncoding.EncodeToString(hasher.Sum(nil))
-		charsLeftForHash := maxLength - 1 - prefixLen
-		return fixedPrefix + shortenedPrefix + hash[0:charsLeftForHash]
+		charsLeftForHash := maxLength - len(marker) - prefixLen
+		return fixedPrefix + marker + hash[0:charsLeftForHash]
 	}
 	// No need to shorten.
 	return fixedPrefix + suffix
@@ -35,3 +35,24 @@ var _ = Describe("Id", func() {
 		Expect(GetLengthLimitedID("felix", "12345678910", 13)).To(Equal("felix_Y2QCZIS"))
 	})
 })
+
+var _ = Describe("Versioned Id", func() {
+	It("should match GetLengthLimitedID for version 1", func() {
+		Expect(GetVersionedLengthLimitedID(IDVersion1, "felix", "12345678910", 13)).To(Equal("felix_Y2QCZIS"))
+		Expect(GetVersionedLengthLimitedID(IDVersion1, "felix", "_2345", 10)).To(Equal("felix_kMQI"))
+	})
+	It("should return the suffix if short enough in version 2", func() {
+		Expect(GetVersionedLengthLimitedID(IDVersion2, "felix", "1234", 10)).To(Equal("felix1234"))
+	})
+	It("should return a marked hash if too long in version 2", func() {
+		id := GetVersionedLengthLimitedID(IDVersion2, "felix", "12345678910", 13)
+		Expect(id).To(HaveLen(13))
+		Expect(id).To(HavePrefix("felix_2"))
+		Expect(id).NotTo(Equal(GetLengthLimitedID("felix", "12345678910", 13)))
+	})
+	It("should hash the prefix in version 2", func() {
+		a := GetVersionedLengthLimitedID(IDVersion2, "felix", "12345678910", 13)
+		b := GetVersionedLengthLimitedID(IDVersion2, "xilef", "12345678910", 13)
+		Expect(a[len("felix"):]).NotTo(Equal(b[len("xilef"):]))
+	})
+})
@@ -0,0 +1,177 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrPoolExhausted      = errors.New("no free blocks remaining in pool")
+	ErrBlockNotInPool     = errors.New("block is not a valid block of the pool")
+	ErrBlockAllocated     = errors.New("block is already allocated")
+	ErrBlockNotAllocated  = errors.New("block is not allocated")
+	ErrInvalidBlockPrefix = errors.New("block prefix length is not valid for the pool")
+)
+
+// BlockAllocator carves fixed-size blocks out of a pool CIDR, always handing out the lowest free block.
+// Each allocated block is labelled with an affinity string (typically the name of the host that owns the
+// block).
+//
+// The free space is tracked as a CIDRSet so that finding the lowest free block is proportional to the
+// depth of the trie rather than to the number of allocated blocks.  BlockAllocator is not thread safe.
+type BlockAllocator struct {
+	pool           CIDR
+	blockPrefixLen uint8
+
+	free      *CIDRSet
+	allocated *Trie[string]
+}
+
+// NewBlockAllocator creates an allocator that splits pool into blocks of the given prefix length.  The
+// pool is canonicalised; blockPrefixLen must be between the pool's prefix length and the address length.
+func NewBlockAllocator(pool CIDR, blockPrefixLen int) (*BlockAllocator, error) {
+	pool = pool.Canonicalize()
+	maxPrefixLen := 32
+	if pool.Version() == 6 {
+		maxPrefixLen = 128
+	}
+	if blockPrefixLen < int(pool.Prefix()) || blockPrefixLen > maxPrefixLen {
+		return nil, fmt.Errorf("%w: /%d blocks in pool %s", ErrInvalidBlockPrefix, blockPrefixLen, pool)
+	}
+	return &BlockAllocator{
+		pool:           pool,
+		blockPrefixLen: uint8(blockPrefixLen),
+		free:           NewCIDRSet(pool),
+		allocated:      NewTrie[string](),
+	}, nil
+}
+
+// Pool returns the (canonical) pool CIDR.
+func (a *BlockAllocator) Pool() CIDR {
+	return a.pool
+}
+
+// BlockPrefixLen returns the prefix length of the blocks handed out by the allocator.
+func (a *BlockAllocator) BlockPrefixLen() int {
+	return int(a.blockPrefixLen)
+}
+
+// AllocateBlock allocates the lowest free block in the pool and labels it with the given affinity.  It
+// returns ErrPoolExhausted if there are no free blocks.
+func (a *BlockAllocator) AllocateBlock(affinity string) (CIDR, error) {
+	var block CIDR
+	a.free.Visit(func(cidr CIDR) bool {
+		// The free set only ever loses whole blocks so every CIDR in it is at least block-sized and
+		// block-aligned.  The first one in address order therefore starts with the lowest free block.
+		block = CIDRFromAddrAndPrefix(cidr.Addr(), int(a.blockPrefixLen))
+		return false
+	})
+	if block == nil {
+		return nil, ErrPoolExhausted
+	}
+	a.markAllocated(block, affinity)
+	return block, nil
+}
+
+// AllocateSpecificBlock allocates the given block, which must be a block of the pool that is not already
+// allocated.
+func (a *BlockAllocator) AllocateSpecificBlock(block CIDR, affinity string) error {
+	if err := a.checkBlock(block); err != nil {
+		return err
+	}
+	if !a.free.ContainsCIDR(block) {
+		return fmt.Errorf("%w: %s", ErrBlockAllocated, block)
+	}
+	a.markAllocated(block, affinity)
+	return nil
+}
+
+func (a *BlockAllocator) markAllocated(block CIDR, affinity string) {
+	a.free.Discard(block)
+	a.allocated.Update(block, affinity)
+}
+
+// ReleaseBlock returns the given block to the free pool.
+func (a *BlockAllocator) ReleaseBlock(block CIDR) error {
+	if err := a.checkBlock(block); err != nil {
+		return err
+	}
+	if _, ok := a.allocated.GetExact(block); !ok {
+		return fmt.Errorf("%w: %s", ErrBlockNotAllocated, block)
+	}
+	a.allocated.Delete(block)
+	a.free.Add(block)
+	return nil
+}
+
+// SetAffinity changes the affinity label of an allocated block.
+func (a *BlockAllocator) SetAffinity(block CIDR, affinity string) error {
+	if _, ok := a.allocated.GetExact(block); !ok {
+		return fmt.Errorf("%w: %s", ErrBlockNotAllocated, block)
+	}
+	a.allocated.Update(block, affinity)
+	return nil
+}
+
+// Affinity returns the affinity label of the given block and true, or "" and false if the block is not
+// allocated.
+func (a *BlockAllocator) Affinity(block CIDR) (string, bool) {
+	return a.allocated.GetExact(block)
+}
+
+// BlockFor returns the allocated block that contains addr, along with its affinity.  ok is false if addr
+// isn't in an allocated block.
+func (a *BlockAllocator) BlockFor(addr Addr) (block CIDR, affinity string, ok bool) {
+	if addr.Version() != a.pool.Version() {
+		return nil, "", false
+	}
+	return a.allocated.LookupLongestPrefix(addr)
+}
+
+// BlocksWithAffinity returns the allocated blocks with the given affinity, in address order.
+func (a *BlockAllocator) BlocksWithAffinity(affinity string) []CIDR {
+	var blocks []CIDR
+	a.allocated.Visit(func(cidr CIDR, aff string) bool {
+		if aff == affinity {
+			blocks = append(blocks, cidr)
+		}
+		return true
+	})
+	return blocks
+}
+
+// VisitAllocatedBlocks calls f for each allocated block, in address order.  If f returns false, iteration
+// stops.
+func (a *BlockAllocator) VisitAllocatedBlocks(f func(block CIDR, affinity string) bool) {
+	a.allocated.Visit(f)
+}
+
+// NumAllocatedBlocks returns the number of allocated blocks.
+func (a *BlockAllocator) NumAllocatedBlocks() int {
+	return a.allocated.Len()
+}
+
+// checkBlock returns an error if block isn't exactly one of the pool's blocks.
+func (a *BlockAllocator) checkBlock(block CIDR) error {
+	if block.Version() != a.pool.Version() ||
+		block.Prefix() != a.blockPrefixLen ||
+		!a.pool.ContainsCIDR(block) ||
+		block.Canonicalize() != block {
+		return fmt.Errorf("%w: %s is not a /%d block of %s", ErrBlockNotInPool, block, a.blockPrefixLen, a.pool)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = Describe("BlockAllocator", func() {
+	var alloc *ip.BlockAllocator
+
+	mustAllocate := func(affinity string) string {
+		block, err := alloc.AllocateBlock(affinity)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		return block.String()
+	}
+
+	BeforeEach(func() {
+		var err error
+		alloc, err = ip.NewBlockAllocator(ip.MustParseCIDROrIP("10.0.0.0/24"), 26)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject invalid block sizes", func() {
+		_, err := ip.NewBlockAllocator(ip.MustParseCIDROrIP("10.0.0.0/24"), 23)
+		Expect(err).To(MatchError(ip.ErrInvalidBlockPrefix))
+		_, err = ip.NewBlockAllocator(ip.MustParseCIDROrIP("10.0.0.0/24"), 33)
+		Expect(err).To(MatchError(ip.ErrInvalidBlockPrefix))
+		_, err = ip.NewBlockAllocator(ip.MustParseCIDROrIP("fd00::/48"), 128)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should allocate blocks in address order until exhausted", func() {
+		Expect(mustAllocate("a")).To(Equal("10.0.0.0/26"))
+		Expect(mustAllocate("b")).To(Equal("10.0.0.64/26"))
+		Expect(mustAllocate("a")).To(Equal("10.0.0.128/26"))
+		Expect(mustAllocate("b")).To(Equal("10.0.0.192/26"))
+		_, err := alloc.AllocateBlock("c")
+		Expect(err).To(Equal(ip.ErrPoolExhausted))
+		Expect(alloc.NumAllocatedBlocks()).To(Equal(4))
+	})
+
+	It("should reuse the lowest released block", func() {
+		for i := 0; i < 4; i++ {
+			mustAllocate("a")
+		}
+		Expect(alloc.ReleaseBlock(ip.MustParseCIDROrIP("10.0.0.192/26"))).To(Succeed())
+		Expect(alloc.ReleaseBlock(ip.MustParseCIDROrIP("10.0.0.64/26"))).To(Succeed())
+		Expect(mustAllocate("b")).To(Equal("10.0.0.64/26"))
+		Expect(mustAllocate("b")).To(Equal("10.0.0.192/26"))
+	})
+
+	It("should track affinities", func() {
+		mustAllocate("host-a")
+		mustAllocate("host-b")
+		mustAllocate("host-a")
+
+		aff, ok := alloc.Affinity(ip.MustParseCIDROrIP("10.0.0.64/26"))
+		Expect(ok).To(BeTrue())
+		Expect(aff).To(Equal("host-b"))
+		_, ok = alloc.Affinity(ip.MustParseCIDROrIP("10.0.0.192/26"))
+		Expect(ok).To(BeFalse())
+
+		Expect(alloc.BlocksWithAffinity("host-a")).To(Equal([]ip.CIDR{
+			ip.MustParseCIDROrIP("10.0.0.0/26"),
+			ip.MustParseCIDROrIP("10.0.0.128/26"),
+		}))
+
+		Expect(alloc.SetAffinity(ip.MustParseCIDROrIP("10.0.0.128/26"), "host-b")).To(Succeed())
+		Expect(alloc.BlocksWithAffinity("host-b")).To(HaveLen(2))
+		Expect(alloc.SetAffinity(ip.MustParseCIDROrIP("10.0.0.192/26"), "host-b")).To(MatchError(ip.ErrBlockNotAllocated))
+
+		block, aff, ok := alloc.BlockFor(ip.FromString("10.0.0.70"))
+		Expect(ok).To(BeTrue())
+		Expect(block.String()).To(Equal("10.0.0.64/26"))
+		Expect(aff).To(Equal("host-b"))
+		_, _, ok = alloc.BlockFor(ip.FromString("10.0.0.200"))
+		Expect(ok).To(BeFalse())
+		_, _, ok = alloc.BlockFor(ip.FromString("fd00::1"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should allocate specific blocks", func() {
+		Expect(alloc.AllocateSpecificBlock(ip.MustParseCIDROrIP("10.0.0.64/26"), "a")).To(Succeed())
+		Expect(alloc.AllocateSpecificBlock(ip.MustParseCIDROrIP("10.0.0.64/26"), "a")).To(MatchError(ip.ErrBlockAllocated))
+		Expect(mustAllocate("b")).To(Equal("10.0.0.0/26"))
+		Expect(mustAllocate("b")).To(Equal("10.0.0.128/26"))
+	})
+
+	It("should reject blocks that aren't part of the pool", func() {
+		for _, c := range []string{"10.0.1.0/26", "10.0.0.0/25", "10.0.0.0/27", "fd00::/26"} {
+			Expect(alloc.AllocateSpecificBlock(ip.MustParseCIDROrIP(c), "a")).To(MatchError(ip.ErrBlockNotInPool), c)
+			Expect(alloc.ReleaseBlock(ip.MustParseCIDROrIP(c))).To(MatchError(ip.ErrBlockNotInPool), c)
+		}
+		Expect(alloc.ReleaseBlock(ip.MustParseCIDROrIP("10.0.0.0/26"))).To(MatchError(ip.ErrBlockNotAllocated))
+	})
+
+	It("should handle large IPv6 pools", func() {
+		var err error
+		alloc, err = ip.NewBlockAllocator(ip.MustParseCIDROrIP("fd00:1::/48"), 122)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mustAllocate("a")).To(Equal("fd00:1::/122"))
+		Expect(mustAllocate("a")).To(Equal("fd00:1::40/122"))
+		Expect(alloc.ReleaseBlock(ip.MustParseCIDROrIP("fd00:1::/122"))).To(Succeed())
+		Expect(mustAllocate("a")).To(Equal("fd00:1::/122"))
+	})
+
+	It("should always hand out the lowest free block under random churn", func() {
+		alloc, _ = ip.NewBlockAllocator(ip.MustParseCIDROrIP("10.0.0.0/20"), 28)
+		rng := rand.New(rand.NewSource(1))
+		allocated := map[uint32]bool{}
+		for i := 0; i < 2000; i++ {
+			if rng.Intn(3) > 0 {
+				var expected uint32
+				for allocated[expected] {
+					expected++
+				}
+				block, err := alloc.AllocateBlock("a")
+				if expected == 256 {
+					Expect(err).To(Equal(ip.ErrPoolExhausted))
+					continue
+				}
+				Expect(err).NotTo(HaveOccurred())
+				Expect(block.Addr().(ip.V4Addr).AsUint32()).To(Equal(0x0a000000 + expected*16))
+				allocated[expected] = true
+			} else if len(allocated) > 0 {
+				n := uint32(rng.Intn(256))
+				for !allocated[n] {
+					n = (n + 1) % 256
+				}
+				addr, _ := ip.FromString("10.0.0.0").Add(uint64(n) * 16)
+				Expect(alloc.ReleaseBlock(ip.CIDRFromAddrAndPrefix(addr, 28))).To(Succeed())
+				delete(allocated, n)
+			}
+			Expect(alloc.NumAllocatedBlocks()).To(Equal(len(allocated)))
+		}
+	})
+})
@@ -0,0 +1,178 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// AddrsOpt is an option for VisitAddrs.
+type AddrsOpt func(opts *addrsOpts)
+
+type addrsOpts struct {
+	skipNetwork   bool
+	skipBroadcast bool
+}
+
+// WithoutNetworkAddr causes VisitAddrs to skip the first (all-zeros host part) address of the CIDR.
+// For IPv6, that is the subnet-router anycast address.
+func WithoutNetworkAddr() AddrsOpt {
+	return func(opts *addrsOpts) {
+		opts.skipNetwork = true
+	}
+}
+
+// WithoutBroadcastAddr causes VisitAddrs to skip the last (all-ones host part) address of the CIDR.
+func WithoutBroadcastAddr() AddrsOpt {
+	return func(opts *addrsOpts) {
+		opts.skipBroadcast = true
+	}
+}
+
+// WithoutNetworkAndBroadcastAddrs is shorthand for WithoutNetworkAddr and WithoutBroadcastAddr, which
+// results in only the usable host addresses being visited.
+func WithoutNetworkAndBroadcastAddrs() AddrsOpt {
+	return func(opts *addrsOpts) {
+		opts.skipNetwork = true
+		opts.skipBroadcast = true
+	}
+}
+
+func makeAddrsOpts(opts []AddrsOpt) addrsOpts {
+	var o addrsOpts
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// VisitAddrs calls f for each address in the CIDR, in order.  If f returns false, iteration stops.
+// Following RFC 3021, /31 and /32 CIDRs have no network or broadcast address so the options to skip
+// those addresses have no effect on them.
+func (c V4CIDR) VisitAddrs(f func(addr Addr) bool, opts ...AddrsOpt) {
+	c.VisitV4Addrs(func(addr V4Addr) bool {
+		return f(addr)
+	}, opts...)
+}
+
+// VisitV4Addrs is a typed version of VisitAddrs.
+func (c V4CIDR) VisitV4Addrs(f func(addr V4Addr) bool, opts ...AddrsOpt) {
+	o := makeAddrsOpts(opts)
+	hostBits := 32 - uint(c.prefix)
+	first := c.addr.AsUint32() &^ (uint32(1<<hostBits) - 1)
+	last := first | (uint32(1<<hostBits) - 1)
+	if hostBits > 1 {
+		if o.skipNetwork {
+			first++
+		}
+		if o.skipBroadcast {
+			last--
+		}
+	}
+	for u := first; ; u++ {
+		var addr V4Addr
+		addr[0], addr[1], addr[2], addr[3] = byte(u>>24), byte(u>>16), byte(u>>8), byte(u)
+		if !f(addr) || u == last {
+			return
+		}
+	}
+}
+
+// VisitAddrs calls f for each address in the CIDR, in order.  If f returns false, iteration stops.
+// /127 and /128 CIDRs (RFC 6164) are treated as having no network or broadcast address so the options
+// to skip those addresses have no effect on them.
+//
+// Note: large IPv6 CIDRs contain an astronomical number of addresses; it's up to the caller to stop
+// iteration.
+func (c V6CIDR) VisitAddrs(f func(addr Addr) bool, opts ...AddrsOpt) {
+	c.VisitV6Addrs(func(addr V6Addr) bool {
+		return f(addr)
+	}, opts...)
+}
+
+// VisitV6Addrs is a typed version of VisitAddrs.
+func (c V6CIDR) VisitV6Addrs(f func(addr V6Addr) bool, opts ...AddrsOpt) {
+	o := makeAddrsOpts(opts)
+	hostBits := 128 - uint(c.prefix)
+	hostMask := uint128Max
+	if hostBits < 128 {
+		hostMask = Uint128{Lo: 1}.Lsh(hostBits).Sub(Uint128{Lo: 1})
+	}
+	first := addrToUint128(c.addr).And(hostMask.Not())
+	last := first.Or(hostMask)
+	one := Uint128{Lo: 1}
+	if hostBits > 1 {
+		if o.skipNetwork {
+			first = first.Add(one)
+		}
+		if o.skipBroadcast {
+			last = last.Sub(one)
+		}
+	}
+	for u := first; ; u = u.Add(one) {
+		if !f(V6AddrFromUint128(u)) || u == last {
+			return
+		}
+	}
+}
+
+// VisitSubnets splits the CIDR into subnets of the given prefix length and calls f for each one, in
+// order.  If f returns false, iteration stops.  For example, splitting a /24 into /26s visits four
+// CIDRs.  Returns an error if newPrefixLen is shorter than the CIDR's prefix or longer than 32.
+func (c V4CIDR) VisitSubnets(newPrefixLen int, f func(subnet CIDR) bool) error {
+	if newPrefixLen < int(c.prefix) || newPrefixLen > 32 {
+		return fmt.Errorf("invalid subnet prefix length /%d for %v", newPrefixLen, c)
+	}
+	hostBits := 32 - uint(c.prefix)
+	first := uint64(c.addr.AsUint32()) &^ (1<<hostBits - 1)
+	last := first | (1<<hostBits - 1)
+	step := uint64(1) << (32 - uint(newPrefixLen))
+	for u := first; u <= last; u += step {
+		var addr V4Addr
+		binary.BigEndian.PutUint32(addr[:], uint32(u))
+		if !f(V4CIDR{addr: addr, prefix: uint8(newPrefixLen)}) {
+			break
+		}
+	}
+	return nil
+}
+
+// VisitSubnets splits the CIDR into subnets of the given prefix length and calls f for each one, in
+// order.  If f returns false, iteration stops.  Returns an error if newPrefixLen is shorter than the
+// CIDR's prefix or longer than 128.
+func (c V6CIDR) VisitSubnets(newPrefixLen int, f func(subnet CIDR) bool) error {
+	if newPrefixLen < int(c.prefix) || newPrefixLen > 128 {
+		return fmt.Errorf("invalid subnet prefix length /%d for %v", newPrefixLen, c)
+	}
+	one := Uint128{Lo: 1}
+	hostMask := uint128Max
+	if c.prefix > 0 {
+		hostMask = one.Lsh(128 - uint(c.prefix)).Sub(one)
+	}
+	first := addrToUint128(c.addr).And(hostMask.Not())
+	last := first.Or(hostMask)
+	step := one.Lsh(128 - uint(newPrefixLen))
+	for u := first; ; u = u.Add(step) {
+		if !f(V6CIDR{addr: V6AddrFromUint128(u), prefix: uint8(newPrefixLen)}) {
+			break
+		}
+		if u.Or(step.Sub(one)) == last {
+			// This was the last subnet; stop before we wrap around.
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+func visitAddrStrings(cidr ip.CIDR, opts ...ip.AddrsOpt) []string {
+	var addrs []string
+	cidr.VisitAddrs(func(addr ip.Addr) bool {
+		addrs = append(addrs, addr.String())
+		return true
+	}, opts...)
+	return addrs
+}
+
+var _ = DescribeTable("VisitAddrs",
+	func(cidr string, opts []ip.AddrsOpt, expected []string) {
+		Expect(visitAddrStrings(ip.MustParseCIDROrIP(cidr), opts...)).To(Equal(expected))
+	},
+	Entry("IPv4 /32", "10.0.0.1/32", nil, []string{"10.0.0.1"}),
+	Entry("IPv4 /32 without network and broadcast", "10.0.0.1/32",
+		[]ip.AddrsOpt{ip.WithoutNetworkAndBroadcastAddrs()}, []string{"10.0.0.1"}),
+	Entry("IPv4 /31 without network and broadcast", "10.0.0.0/31",
+		[]ip.AddrsOpt{ip.WithoutNetworkAndBroadcastAddrs()}, []string{"10.0.0.0", "10.0.0.1"}),
+	Entry("IPv4 /30", "10.0.0.0/30", nil, []string{"10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.3"}),
+	Entry("IPv4 /30 without network", "10.0.0.0/30",
+		[]ip.AddrsOpt{ip.WithoutNetworkAddr()}, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}),
+	Entry("IPv4 /30 without broadcast", "10.0.0.0/30",
+		[]ip.AddrsOpt{ip.WithoutBroadcastAddr()}, []string{"10.0.0.0", "10.0.0.1", "10.0.0.2"}),
+	Entry("IPv4 /30 without network and broadcast", "10.0.0.0/30",
+		[]ip.AddrsOpt{ip.WithoutNetworkAndBroadcastAddrs()}, []string{"10.0.0.1", "10.0.0.2"}),
+	Entry("IPv4 at top of address space", "255.255.255.254/31", nil, []string{"255.255.255.254", "255.255.255.255"}),
+	Entry("IPv6 /128", "fc00::1/128", nil, []string{"fc00::1"}),
+	Entry("IPv6 /126", "fc00::/126", nil, []string{"fc00::", "fc00::1", "fc00::2", "fc00::3"}),
+	Entry("IPv6 /126 without network and broadcast", "fc00::/126",
+		[]ip.AddrsOpt{ip.WithoutNetworkAndBroadcastAddrs()}, []string{"fc00::1", "fc00::2"}),
+	Entry("IPv6 /127 without network and broadcast", "fc00::/127",
+		[]ip.AddrsOpt{ip.WithoutNetworkAndBroadcastAddrs()}, []string{"fc00::", "fc00::1"}),
+	Entry("IPv6 at top of address space", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/127", nil,
+		[]string{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"}),
+)
+
+var _ = Describe("VisitAddrs early exit", func() {
+	It("should stop when the callback returns false", func() {
+		for _, cidr := range []string{"0.0.0.0/0", "::/0"} {
+			var addrs []string
+			ip.MustParseCIDROrIP(cidr).VisitAddrs(func(addr ip.Addr) bool {
+				addrs = append(addrs, addr.String())
+				return len(addrs) < 3
+			}, ip.WithoutNetworkAddr())
+			Expect(addrs).To(HaveLen(3), cidr)
+		}
+	})
+
+	It("should visit every address of a /24", func() {
+		addrs := visitAddrStrings(ip.MustParseCIDROrIP("10.0.1.0/24"), ip.WithoutNetworkAndBroadcastAddrs())
+		Expect(addrs).To(HaveLen(254))
+		Expect(addrs[0]).To(Equal("10.0.1.1"))
+		Expect(addrs[253]).To(Equal("10.0.1.254"))
+	})
+})
+
+var _ = DescribeTable("VisitSubnets",
+	func(cidr string, newPrefixLen int, expected []string) {
+		var subnets []string
+		err := ip.MustParseCIDROrIP(cidr).VisitSubnets(newPrefixLen, func(subnet ip.CIDR) bool {
+			subnets = append(subnets, subnet.String())
+			return true
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subnets).To(Equal(expected))
+	},
+	Entry("IPv4 same length", "10.0.0.0/24", 24, []string{"10.0.0.0/24"}),
+	Entry("IPv4 /24 into /26s", "10.0.0.0/24", 26, []string{"10.0.0.0/26", "10.0.0.64/26", "10.0.0.128/26", "10.0.0.192/26"}),
+	Entry("IPv4 /30 into /32s", "10.0.0.0/30", 32, []string{"10.0.0.0/32", "10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32"}),
+	Entry("IPv4 /0 into /2s", "0.0.0.0/0", 2, []string{"0.0.0.0/2", "64.0.0.0/2", "128.0.0.0/2", "192.0.0.0/2"}),
+	Entry("IPv6 /64 into /66s", "fc00::/64", 66, []string{"fc00::/66", "fc00::4000:0:0:0/66", "fc00::8000:0:0:0/66", "fc00::c000:0:0:0/66"}),
+	Entry("IPv6 /63 into /64s", "fc00::/63", 64, []string{"fc00::/64", "fc00:0:0:1::/64"}),
+	Entry("IPv6 /126 into /128s", "fc00::/126", 128, []string{"fc00::/128", "fc00::1/128", "fc00::2/128", "fc00::3/128"}),
+	Entry("IPv6 /0 into /1s", "::/0", 1, []string{"::/1", "8000::/1"}),
+	Entry("IPv6 top /127 into /128s", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/127", 128, []string{
+		"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/128", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff/128",
+	}),
+)
+
+var _ = Describe("VisitSubnets errors", func() {
+	It("should reject prefix lengths out of range", func() {
+		noop := func(ip.CIDR) bool { return true }
+		Expect(ip.MustParseCIDROrIP("10.0.0.0/24").VisitSubnets(23, noop)).To(HaveOccurred())
+		Expect(ip.MustParseCIDROrIP("10.0.0.0/24").VisitSubnets(33, noop)).To(HaveOccurred())
+		Expect(ip.MustParseCIDROrIP("fc00::/64").VisitSubnets(63, noop)).To(HaveOccurred())
+		Expect(ip.MustParseCIDROrIP("fc00::/64").VisitSubnets(129, noop)).To(HaveOccurred())
+	})
+
+	It("should stop when the callback returns false", func() {
+		var n int
+		Expect(ip.MustParseCIDROrIP("::/0").VisitSubnets(128, func(ip.CIDR) bool {
+			n++
+			return n < 5
+		})).To(Succeed())
+		Expect(n).To(Equal(5))
+	})
+})
@@ -0,0 +1,267 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// CIDRSet is a set of IP addresses, represented as CIDRs.  Unlike a set of CIDR values, a CIDRSet
+// deals in address space: adding 10.0.0.0/25 and 10.0.0.128/25 results in a set containing 10.0.0.0/24,
+// and removing 10.0.0.1/32 from a set containing 10.0.0.0/24 leaves the remaining CIDRs.  It may hold
+// a mix of IPv4 and IPv6 CIDRs.
+//
+// Internally, the set is kept normalised as the unique minimal list of disjoint CIDRs that covers its
+// addresses.
+type CIDRSet struct {
+	v4 *Trie[struct{}]
+	v6 *Trie[struct{}]
+}
+
+func NewCIDRSet(cidrs ...CIDR) *CIDRSet {
+	s := &CIDRSet{
+		v4: NewTrie[struct{}](),
+		v6: NewTrie[struct{}](),
+	}
+	for _, c := range cidrs {
+		s.Add(c)
+	}
+	return s
+}
+
+func (s *CIDRSet) trieFor(cidr CIDR) *Trie[struct{}] {
+	switch cidr.Version() {
+	case 4:
+		return s.v4
+	case 6:
+		return s.v6
+	}
+	logrus.WithField("cidr", cidr).Panic("Invalid CIDR IP version")
+	return nil
+}
+
+// Add adds the addresses in the given CIDR to the set.
+func (s *CIDRSet) Add(cidr CIDR) {
+	cidr = cidr.Canonicalize()
+	t := s.trieFor(cidr)
+	if t.Covers(cidr) {
+		return
+	}
+	// Remove anything that the new CIDR covers then merge it with its sibling as far as possible.
+	t.DeletePrefix(cidr)
+	for cidr.Prefix() > 0 {
+		sibling := flipBit(cidr, cidr.Prefix())
+		if _, ok := t.root.get(sibling); !ok {
+			break
+		}
+		t.Delete(sibling)
+		cidr = cidr.Supernet(1)
+	}
+	t.Update(cidr, struct{}{})
+}
+
+// Discard removes the addresses in the given CIDR from the set.
+func (s *CIDRSet) Discard(cidr CIDR) {
+	cidr = cidr.Canonicalize()
+	t := s.trieFor(cidr)
+	if t.Covers(cidr) {
+		// There's a (single, since the set is disjoint) CIDR that covers the discarded CIDR.  Replace it
+		// with the parts that remain, which are the siblings of each CIDR on the path down to the
+		// discarded CIDR.
+		covering, _ := t.LPM(cidr)
+		t.Delete(covering)
+		for p := covering.Prefix() + 1; p <= cidr.Prefix(); p++ {
+			t.Update(flipBit(cidr.Supernet(cidr.Prefix()-p), p), struct{}{})
+		}
+		return
+	}
+	t.DeletePrefix(cidr)
+}
+
+// Contains returns true if the given address is in the set.
+func (s *CIDRSet) Contains(addr Addr) bool {
+	return s.ContainsCIDR(addr.AsCIDR())
+}
+
+// ContainsCIDR returns true if all the addresses in the given CIDR are in the set.
+func (s *CIDRSet) ContainsCIDR(cidr CIDR) bool {
+	return s.trieFor(cidr).Covers(cidr)
+}
+
+// Len returns the number of CIDRs in the normalised form of the set.
+func (s *CIDRSet) Len() int {
+	return s.v4.Len() + s.v6.Len()
+}
+
+// Visit calls f for each CIDR in the normalised form of the set; IPv4 CIDRs first, then IPv6, each in
+// address order.  If f returns false, iteration stops.
+func (s *CIDRSet) Visit(f func(cidr CIDR) bool) {
+	keepGoing := true
+	visitor := func(cidr CIDR, _ struct{}) bool {
+		keepGoing = f(cidr)
+		return keepGoing
+	}
+	s.v4.Visit(visitor)
+	if keepGoing {
+		s.v6.Visit(visitor)
+	}
+}
+
+// Slice returns the normalised form of the set, in the same order as Visit.
+func (s *CIDRSet) Slice() []CIDR {
+	cidrs := make([]CIDR, 0, s.Len())
+	s.Visit(func(cidr CIDR) bool {
+		cidrs = append(cidrs, cidr)
+		return true
+	})
+	return cidrs
+}
+
+// Copy returns an independent copy of the set.  The copy is cheap; it shares storage with the
+// original until one of them is modified.
+func (s *CIDRSet) Copy() *CIDRSet {
+	return &CIDRSet{
+		v4: s.v4.Copy(),
+		v6: s.v6.Copy(),
+	}
+}
+
+// AddSet adds all the addresses in other to this set.
+func (s *CIDRSet) AddSet(other *CIDRSet) {
+	other.Visit(func(cidr CIDR) bool {
+		s.Add(cidr)
+		return true
+	})
+}
+
+// DiscardSet removes all the addresses in other from this set.
+func (s *CIDRSet) DiscardSet(other *CIDRSet) {
+	other.Visit(func(cidr CIDR) bool {
+		s.Discard(cidr)
+		return true
+	})
+}
+
+// Union returns a new set containing the addresses that are in either set.
+func (s *CIDRSet) Union(other *CIDRSet) *CIDRSet {
+	result := s.Copy()
+	result.AddSet(other)
+	return result
+}
+
+// Intersect returns a new set containing the addresses that are in both sets.
+func (s *CIDRSet) Intersect(other *CIDRSet) *CIDRSet {
+	result := NewCIDRSet()
+	addToResult := func(cidr CIDR, _, _ struct{}) bool {
+		result.Add(cidr)
+		return true
+	}
+	IntersectTries(s.v4, other.v4, addToResult)
+	IntersectTries(s.v6, other.v6, addToResult)
+	return result
+}
+
+// Subtract returns a new set containing the addresses that are in this set but not in other.
+func (s *CIDRSet) Subtract(other *CIDRSet) *CIDRSet {
+	result := s.Copy()
+	result.DiscardSet(other)
+	return result
+}
+
+// Equal returns true if the two sets contain the same addresses.
+func (s *CIDRSet) Equal(other *CIDRSet) bool {
+	alwaysEqual := func(a, b struct{}) bool { return true }
+	return s.v4.Equal(other.v4, alwaysEqual) && s.v6.Equal(other.v6, alwaysEqual)
+}
+
+// Aggregate returns the minimal list of CIDRs that covers exactly the same addresses as the input; CIDRs
+// that are contained in other CIDRs are dropped and adjacent CIDRs are merged (for example, two sibling
+// /25s become a /24).  The result is sorted with IPv4 CIDRs first, then IPv6, each in address order.
+func Aggregate(cidrs []CIDR) []CIDR {
+	return NewCIDRSet(cidrs...).Slice()
+}
+
+// Subtract returns the addresses in from that are not in any of the excluded CIDRs, as the minimal list
+// of disjoint CIDRs in address order.  For example, subtracting 10.0.0.64/26 from 10.0.0.0/24 gives
+// 10.0.0.0/26 and 10.0.0.128/25.  Excluded CIDRs of the other IP version are ignored.
+func Subtract(from CIDR, exclude []CIDR) []CIDR {
+	s := NewCIDRSet(from)
+	for _, e := range exclude {
+		if e.Version() != from.Version() {
+			continue
+		}
+		s.Discard(e)
+	}
+	return s.Slice()
+}
+
+// FindOverlaps returns every pair of CIDRs in the input that overlap; that is, where one CIDR contains
+// the other.  Each pair is ordered (containing CIDR, contained CIDR) and pairs are returned in address
+// order of their contained CIDR, IPv4 first.  A CIDR that appears more than once in the input is reported
+// once, paired with itself.  IPv4 and IPv6 CIDRs may be mixed.
+//
+// The CIDRs are loaded into a trie so the cost is O(n log n) plus the number of overlaps, rather than
+// comparing every pair.
+func FindOverlaps(cidrs []CIDR) [][2]CIDR {
+	v4 := NewTrie[int]()
+	v6 := NewTrie[int]()
+	for _, c := range cidrs {
+		t := v4
+		if c.Version() == 6 {
+			t = v6
+		}
+		count, _ := t.GetExact(c)
+		t.Update(c, count+1)
+	}
+
+	var overlaps [][2]CIDR
+	var enclosing []CIDR
+	for _, t := range []*Trie[int]{v4, v6} {
+		// Visit returns CIDRs in address order, with each CIDR before any CIDRs that it contains, so we
+		// can keep a stack of the CIDRs that enclose the current one.
+		enclosing = enclosing[:0]
+		t.Visit(func(cidr CIDR, count int) bool {
+			for len(enclosing) > 0 && !enclosing[len(enclosing)-1].ContainsCIDR(cidr) {
+				enclosing = enclosing[:len(enclosing)-1]
+			}
+			for _, e := range enclosing {
+				overlaps = append(overlaps, [2]CIDR{e, cidr})
+			}
+			if count > 1 {
+				overlaps = append(overlaps, [2]CIDR{cidr, cidr})
+			}
+			enclosing = append(enclosing, cidr)
+			return true
+		})
+	}
+	return overlaps
+}
+
+// flipBit returns the given CIDR with the nth bit (counting from 1 at the most significant bit) of its
+// address inverted.
+func flipBit(cidr CIDR, n uint8) CIDR {
+	byteIdx := (n - 1) / 8
+	mask := byte(0x80) >> ((n - 1) % 8)
+	switch c := cidr.(type) {
+	case V4CIDR:
+		c.addr[byteIdx] ^= mask
+		return c
+	case V6CIDR:
+		c.addr[byteIdx] ^= mask
+		return c
+	}
+	logrus.WithField("cidr", cidr).Panic("Invalid CIDR type")
+	return nil
+}
@@ -0,0 +1,275 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+func newCIDRSet(cidrs ...string) *ip.CIDRSet {
+	s := ip.NewCIDRSet()
+	for _, c := range cidrs {
+		s.Add(ip.MustParseCIDROrIP(c))
+	}
+	return s
+}
+
+func cidrSetStrings(s *ip.CIDRSet) []string {
+	var out []string
+	for _, c := range s.Slice() {
+		out = append(out, c.String())
+	}
+	return out
+}
+
+var _ = Describe("CIDRSet", func() {
+	It("should start empty", func() {
+		s := ip.NewCIDRSet()
+		Expect(s.Len()).To(Equal(0))
+		Expect(s.Contains(ip.FromString("10.0.0.1"))).To(BeFalse())
+	})
+
+	It("should merge sibling CIDRs", func() {
+		s := newCIDRSet("10.0.0.0/25", "10.0.0.128/26", "10.0.0.192/26")
+		Expect(cidrSetStrings(s)).To(Equal([]string{"10.0.0.0/24"}))
+	})
+
+	It("should absorb contained CIDRs", func() {
+		s := newCIDRSet("10.0.1.0/24", "10.0.2.1/32", "10.0.0.0/16", "10.0.3.0/24")
+		Expect(cidrSetStrings(s)).To(Equal([]string{"10.0.0.0/16"}))
+	})
+
+	It("should canonicalise CIDRs", func() {
+		s := ip.NewCIDRSet(ip.V4CIDR{}, ip.MustParseCIDROrIP("10.0.0.1/8"))
+		Expect(cidrSetStrings(s)).To(Equal([]string{"0.0.0.0/0"}))
+	})
+
+	It("should hold both IP versions", func() {
+		s := newCIDRSet("fc00::/7", "10.0.0.0/8")
+		Expect(cidrSetStrings(s)).To(Equal([]string{"10.0.0.0/8", "fc00::/7"}))
+		Expect(s.Contains(ip.FromString("fd00::1"))).To(BeTrue())
+		Expect(s.Contains(ip.FromString("10.1.2.3"))).To(BeTrue())
+		Expect(s.ContainsCIDR(ip.MustParseCIDROrIP("10.0.0.0/7"))).To(BeFalse())
+	})
+
+	It("should discard a CIDR from within a larger one", func() {
+		s := newCIDRSet("10.0.0.0/24")
+		s.Discard(ip.MustParseCIDROrIP("10.0.0.5/32"))
+		Expect(cidrSetStrings(s)).To(Equal([]string{
+			"10.0.0.0/30",
+			"10.0.0.4/32",
+			"10.0.0.6/31",
+			"10.0.0.8/29",
+			"10.0.0.16/28",
+			"10.0.0.32/27",
+			"10.0.0.64/26",
+			"10.0.0.128/25",
+		}))
+		s.Add(ip.MustParseCIDROrIP("10.0.0.5/32"))
+		Expect(cidrSetStrings(s)).To(Equal([]string{"10.0.0.0/24"}))
+	})
+
+	It("should discard CIDRs inside the discarded CIDR", func() {
+		s := newCIDRSet("10.0.1.0/24", "10.0.3.0/24", "11.0.0.0/8")
+		s.Discard(ip.MustParseCIDROrIP("10.0.0.0/16"))
+		Expect(cidrSetStrings(s)).To(Equal([]string{"11.0.0.0/8"}))
+		s.Discard(ip.MustParseCIDROrIP("11.0.0.0/8"))
+		Expect(s.Len()).To(Equal(0))
+	})
+
+	It("should do set algebra", func() {
+		a := newCIDRSet("10.0.0.0/16", "10.2.0.0/16", "fc00::/64")
+		b := newCIDRSet("10.1.0.0/16", "10.2.128.0/17", "fc00::1/128")
+
+		Expect(cidrSetStrings(a.Union(b))).To(Equal([]string{"10.0.0.0/15", "10.2.0.0/16", "fc00::/64"}))
+		Expect(cidrSetStrings(a.Intersect(b))).To(Equal([]string{"10.2.128.0/17", "fc00::1/128"}))
+		Expect(cidrSetStrings(b.Subtract(a))).To(Equal([]string{"10.1.0.0/16"}))
+
+		// The operands should be unchanged.
+		Expect(cidrSetStrings(a)).To(Equal([]string{"10.0.0.0/16", "10.2.0.0/16", "fc00::/64"}))
+		Expect(cidrSetStrings(b)).To(Equal([]string{"10.1.0.0/16", "10.2.128.0/17", "fc00::1/128"}))
+	})
+
+	It("should compare sets by address", func() {
+		a := newCIDRSet("10.0.0.0/25", "10.0.0.128/25")
+		b := newCIDRSet("10.0.0.0/24")
+		Expect(a.Equal(b)).To(BeTrue())
+		b.Discard(ip.MustParseCIDROrIP("10.0.0.1/32"))
+		Expect(a.Equal(b)).To(BeFalse())
+		Expect(newCIDRSet("10.0.0.0/24").Equal(newCIDRSet("10.0.1.0/24"))).To(BeFalse())
+		Expect(newCIDRSet("::/0").Equal(newCIDRSet("::/1", "8000::/1"))).To(BeTrue())
+	})
+
+	It("should keep copies independent", func() {
+		a := newCIDRSet("10.0.0.0/24")
+		b := a.Copy()
+		b.Discard(ip.MustParseCIDROrIP("10.0.0.0/25"))
+		a.Add(ip.MustParseCIDROrIP("10.0.1.0/24"))
+		Expect(cidrSetStrings(a)).To(Equal([]string{"10.0.0.0/23"}))
+		Expect(cidrSetStrings(b)).To(Equal([]string{"10.0.0.128/25"}))
+	})
+
+	It("should match a brute-force model", func() {
+		// Model the addresses 10.0.0.0-10.0.0.255 as a bitmap.
+		rng := rand.New(rand.NewSource(3))
+		randomCIDR := func() ip.CIDR {
+			return ip.CIDRFromAddrAndPrefix(ip.V4Addr{10, 0, 0, byte(rng.Intn(256))}, 24+rng.Intn(9))
+		}
+		for i := 0; i < 50; i++ {
+			s := ip.NewCIDRSet()
+			var model [256]bool
+			for j := 0; j < 20; j++ {
+				c := randomCIDR()
+				add := rng.Intn(2) == 0
+				if add {
+					s.Add(c)
+				} else {
+					s.Discard(c)
+				}
+				for k := 0; k < 256; k++ {
+					if c.Contains(ip.V4Addr{10, 0, 0, byte(k)}) {
+						model[k] = add
+					}
+				}
+				for k := 0; k < 256; k++ {
+					Expect(s.Contains(ip.V4Addr{10, 0, 0, byte(k)})).To(Equal(model[k]))
+				}
+				// Normalised form should have no mergeable or overlapping CIDRs.
+				Expect(ip.NewCIDRSet(s.Slice()...).Slice()).To(Equal(s.Slice()))
+			}
+		}
+	})
+})
+
+var _ = DescribeTable("Aggregate",
+	func(input []string, expected []string) {
+		var cidrs []ip.CIDR
+		for _, c := range input {
+			cidrs = append(cidrs, ip.MustParseCIDROrIP(c))
+		}
+		var actual []string
+		for _, c := range ip.Aggregate(cidrs) {
+			actual = append(actual, c.String())
+		}
+		Expect(actual).To(Equal(expected))
+	},
+	Entry("empty", nil, nil),
+	Entry("single", []string{"10.0.0.0/8"}, []string{"10.0.0.0/8"}),
+	Entry("siblings", []string{"10.0.0.128/25", "10.0.0.0/25"}, []string{"10.0.0.0/24"}),
+	Entry("non-siblings", []string{"10.0.0.128/25", "10.0.1.0/25"}, []string{"10.0.0.128/25", "10.0.1.0/25"}),
+	Entry("contained", []string{"10.0.0.1", "10.0.0.0/24", "10.0.0.2"}, []string{"10.0.0.0/24"}),
+	Entry("duplicates", []string{"10.0.0.1", "10.0.0.1"}, []string{"10.0.0.1/32"}),
+	Entry("contiguous /32s", []string{"10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"},
+		[]string{"10.0.0.0/30", "10.0.0.4/32"}),
+	Entry("cascade", []string{"10.0.0.0/26", "10.0.0.64/26", "10.0.0.128/25", "10.0.1.0/24"}, []string{"10.0.0.0/23"}),
+	Entry("mixed versions", []string{"fc00::/8", "10.0.0.0/8", "fd00::/8"}, []string{"10.0.0.0/8", "fc00::/7"}),
+)
+
+var _ = DescribeTable("Subtract",
+	func(from string, exclude []string, expected []string) {
+		var cidrs []ip.CIDR
+		for _, c := range exclude {
+			cidrs = append(cidrs, ip.MustParseCIDROrIP(c))
+		}
+		actual := []string{}
+		for _, c := range ip.Subtract(ip.MustParseCIDROrIP(from), cidrs) {
+			actual = append(actual, c.String())
+		}
+		Expect(actual).To(Equal(expected))
+	},
+	Entry("nothing excluded", "10.0.0.0/24", nil, []string{"10.0.0.0/24"}),
+	Entry("one block", "10.0.0.0/24", []string{"10.0.0.64/26"}, []string{"10.0.0.0/26", "10.0.0.128/25"}),
+	Entry("single address", "10.0.0.0/30", []string{"10.0.0.2"}, []string{"10.0.0.0/31", "10.0.0.3/32"}),
+	Entry("several blocks", "10.0.0.0/24", []string{"10.0.0.0/26", "10.0.0.192/26"}, []string{"10.0.0.64/26", "10.0.0.128/26"}),
+	Entry("everything", "10.0.0.0/24", []string{"10.0.0.0/25", "10.0.0.128/25"}, []string{}),
+	Entry("supernet", "10.0.0.0/24", []string{"10.0.0.0/8"}, []string{}),
+	Entry("overlapping exclusions", "10.0.0.0/24", []string{"10.0.0.0/25", "10.0.0.64/26"}, []string{"10.0.0.128/25"}),
+	Entry("disjoint exclusion", "10.0.0.0/24", []string{"10.0.1.0/24"}, []string{"10.0.0.0/24"}),
+	Entry("other IP version", "10.0.0.0/24", []string{"::/0"}, []string{"10.0.0.0/24"}),
+	Entry("IPv6", "fd00::/64", []string{"fd00::/66"}, []string{"fd00::4000:0:0:0/66", "fd00::8000:0:0:0/65"}),
+)
+
+var _ = DescribeTable("FindOverlaps",
+	func(input []string, expected []string) {
+		var cidrs []ip.CIDR
+		for _, c := range input {
+			cidrs = append(cidrs, ip.MustParseCIDROrIP(c))
+		}
+		var actual []string
+		for _, pair := range ip.FindOverlaps(cidrs) {
+			actual = append(actual, pair[0].String()+" "+pair[1].String())
+		}
+		Expect(actual).To(Equal(expected))
+	},
+	Entry("empty", nil, nil),
+	Entry("disjoint", []string{"10.0.0.0/24", "10.0.1.0/24", "fc00::/64"}, nil),
+	Entry("nested", []string{"10.0.1.0/24", "10.0.0.0/8", "10.0.1.1/32", "11.0.0.0/8"}, []string{
+		"10.0.0.0/8 10.0.1.0/24",
+		"10.0.0.0/8 10.0.1.1/32",
+		"10.0.1.0/24 10.0.1.1/32",
+	}),
+	Entry("siblings under a common parent", []string{"10.0.0.0/25", "10.0.0.0/24", "10.0.0.128/25"}, []string{
+		"10.0.0.0/24 10.0.0.0/25",
+		"10.0.0.0/24 10.0.0.128/25",
+	}),
+	Entry("duplicates", []string{"10.0.0.0/24", "10.0.0.0/24", "10.0.0.0/24"}, []string{
+		"10.0.0.0/24 10.0.0.0/24",
+	}),
+	Entry("mixed versions", []string{"fc00::/7", "10.0.0.0/8", "fd00::1/128", "0.0.0.0/0"}, []string{
+		"0.0.0.0/0 10.0.0.0/8",
+		"fc00::/7 fd00::1/128",
+	}),
+)
+
+var _ = Describe("FindOverlaps brute force", func() {
+	It("should agree with a pairwise check", func() {
+		rng := rand.New(rand.NewSource(1))
+		for iter := 0; iter < 20; iter++ {
+			seen := map[ip.CIDR]bool{}
+			var cidrs []ip.CIDR
+			for i := 0; i < 100; i++ {
+				var c ip.CIDR = ip.RandomV4CIDR(rng)
+				if rng.Intn(2) == 0 {
+					c = ip.RandomV6CIDR(rng)
+				}
+				if seen[c] {
+					continue
+				}
+				seen[c] = true
+				cidrs = append(cidrs, c)
+			}
+			expected := map[[2]ip.CIDR]bool{}
+			for _, a := range cidrs {
+				for _, b := range cidrs {
+					if a != b && a.ContainsCIDR(b) {
+						expected[[2]ip.CIDR{a, b}] = true
+					}
+				}
+			}
+			actual := map[[2]ip.CIDR]bool{}
+			for _, pair := range ip.FindOverlaps(cidrs) {
+				Expect(actual).NotTo(HaveKey(pair), "Duplicate pair")
+				actual[pair] = true
+			}
+			Expect(actual).To(Equal(expected))
+		}
+	})
+})
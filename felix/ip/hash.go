@@ -0,0 +1,53 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+// FNV-1a constants.
+const (
+	fnv64Offset = 14695981039346656037
+	fnv64Prime  = 1099511628211
+)
+
+func fnv64a(h uint64, b []byte) uint64 {
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= fnv64Prime
+	}
+	return h
+}
+
+// Hash64 returns a non-cryptographic hash of the address.  The hash is stable; it depends only on the
+// address and will not change between processes or releases, so it is suitable for consistent hashing.
+// It is the 64-bit FNV-1a hash of the address's bytes in network order.
+func (a V4Addr) Hash64() uint64 {
+	return fnv64a(fnv64Offset, a[:])
+}
+
+// Hash64 returns a non-cryptographic hash of the address.  See V4Addr.Hash64.
+func (a V6Addr) Hash64() uint64 {
+	return fnv64a(fnv64Offset, a[:])
+}
+
+// Hash64 returns a non-cryptographic hash of the CIDR.  The hash is stable; it depends only on the
+// CIDR and will not change between processes or releases.  It is the 64-bit FNV-1a hash of the CIDR's
+// address bytes in network order followed by its prefix length as a single byte.
+func (c V4CIDR) Hash64() uint64 {
+	return fnv64a(fnv64a(fnv64Offset, c.addr[:]), []byte{c.prefix})
+}
+
+// Hash64 returns a non-cryptographic hash of the CIDR.  See V4CIDR.Hash64.
+func (c V6CIDR) Hash64() uint64 {
+	return fnv64a(fnv64a(fnv64Offset, c.addr[:]), []byte{c.prefix})
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+var (
+	ErrBlockFull       = errors.New("no free addresses remaining in block")
+	ErrAddrNotInBlock  = errors.New("address is not in the block")
+	ErrAddrReserved    = errors.New("address is reserved")
+	ErrAddrAllocated   = errors.New("address is already allocated")
+	ErrAddrUnallocated = errors.New("address is not allocated")
+)
+
+// maxBitmapHostBits is the largest block (in host bits) for which HostAllocator uses a bitmap.  A /16
+// worth of addresses costs 8KiB of bitmap; beyond that we switch to tracking the free space in a trie.
+const maxBitmapHostBits = 16
+
+// HostAllocator tracks the allocation of individual addresses within a block.  Allocate always returns
+// the lowest free address, so, for a given sequence of operations, the addresses handed out are
+// deterministic.
+//
+// Small blocks are tracked with a bitmap.  Larger blocks (such as an IPv6 /64) are tracked as a CIDRSet
+// of free space, which stays small as long as allocations are reasonably dense.  HostAllocator is not
+// thread safe.
+type HostAllocator struct {
+	block    CIDR
+	base     Uint128
+	hostBits uint
+
+	// bitmap has a bit set for each allocated or reserved address in bitmap mode.  Any bits past the end
+	// of a block smaller than 64 addresses are also set so that they're never handed out.
+	bitmap []uint64
+	// free holds the unallocated addresses in trie mode.
+	free *CIDRSet
+
+	reserved     [2]Addr
+	numReserved  uint64
+	numAllocated uint64
+}
+
+// NewHostAllocator creates an allocator for the addresses in block.  The AddrsOpts that VisitAddrs
+// accepts can be used to reserve the network and/or broadcast address; as with VisitAddrs, they have no
+// effect on /31 and /32 (or /127 and /128) blocks.
+func NewHostAllocator(block CIDR, opts ...AddrsOpt) *HostAllocator {
+	block = block.Canonicalize()
+	addrBits := uint(32)
+	if block.Version() == 6 {
+		addrBits = 128
+	}
+	a := &HostAllocator{
+		block:    block,
+		base:     addrToUint128(block.Addr()),
+		hostBits: addrBits - uint(block.Prefix()),
+	}
+	if a.hostBits <= maxBitmapHostBits {
+		numAddrs := uint64(1) << a.hostBits
+		a.bitmap = make([]uint64, (numAddrs+63)/64)
+		if numAddrs < 64 {
+			a.bitmap[0] = ^uint64(0) << numAddrs
+		}
+	} else {
+		a.free = NewCIDRSet(block)
+	}
+
+	o := makeAddrsOpts(opts)
+	if a.hostBits > 1 {
+		if o.skipNetwork {
+			a.reserve(Uint128{})
+		}
+		if o.skipBroadcast {
+			a.reserve(uint128Max.Rsh(128 - a.hostBits))
+		}
+	}
+	return a
+}
+
+func (a *HostAllocator) reserve(offset Uint128) {
+	addr := a.addrAt(offset)
+	a.reserved[a.numReserved] = addr
+	a.numReserved++
+	a.markAllocated(addr, offset.Lo)
+}
+
+// Block returns the (canonical) CIDR that the allocator manages.
+func (a *HostAllocator) Block() CIDR {
+	return a.block
+}
+
+// Allocate allocates the lowest free address in the block.  It returns ErrBlockFull if there are no free
+// addresses.
+func (a *HostAllocator) Allocate() (Addr, error) {
+	var addr Addr
+	var offset uint64
+	if a.bitmap != nil {
+		for i, w := range a.bitmap {
+			if w == math.MaxUint64 {
+				continue
+			}
+			offset = uint64(i)*64 + uint64(bits.TrailingZeros64(^w))
+			addr = a.addrAt(Uint128{Lo: offset})
+			break
+		}
+	} else {
+		a.free.Visit(func(cidr CIDR) bool {
+			addr = cidr.Addr()
+			return false
+		})
+	}
+	if addr == nil {
+		return nil, ErrBlockFull
+	}
+	a.markAllocated(addr, offset)
+	a.numAllocated++
+	return addr, nil
+}
+
+// AllocateSpecific allocates the given address, which must be a free address in the block.
+func (a *HostAllocator) AllocateSpecific(addr Addr) error {
+	offset, err := a.checkAddr(addr)
+	if err != nil {
+		return err
+	}
+	if a.isAllocated(addr, offset) {
+		return fmt.Errorf("%w: %s", ErrAddrAllocated, addr)
+	}
+	a.markAllocated(addr, offset)
+	a.numAllocated++
+	return nil
+}
+
+// Release frees a previously-allocated address.
+func (a *HostAllocator) Release(addr Addr) error {
+	offset, err := a.checkAddr(addr)
+	if err != nil {
+		return err
+	}
+	if !a.isAllocated(addr, offset) {
+		return fmt.Errorf("%w: %s", ErrAddrUnallocated, addr)
+	}
+	if a.bitmap != nil {
+		a.bitmap[offset/64] &^= 1 << (offset % 64)
+	} else {
+		a.free.Add(addr.AsCIDR())
+	}
+	a.numAllocated--
+	return nil
+}
+
+// IsAllocated returns true if the given address is in the block and is currently allocated.  Reserved
+// addresses are not considered allocated.
+func (a *HostAllocator) IsAllocated(addr Addr) bool {
+	offset, err := a.checkAddr(addr)
+	return err == nil && a.isAllocated(addr, offset)
+}
+
+// NumAllocated returns the number of allocated addresses, not including reserved addresses.
+func (a *HostAllocator) NumAllocated() uint64 {
+	return a.numAllocated
+}
+
+// NumFree returns the number of addresses that are available for allocation.  Since an IPv6 block can
+// hold more than 2^64 addresses, the result saturates at math.MaxUint64.
+func (a *HostAllocator) NumFree() uint64 {
+	if a.hostBits > 64 {
+		// At most 2^64 addresses can be in use so there must be more than 2^64 free.
+		return math.MaxUint64
+	}
+	free := Uint128{Lo: 1}.Lsh(a.hostBits).Sub(Uint128{Lo: a.numAllocated + a.numReserved})
+	if free.Hi != 0 {
+		return math.MaxUint64
+	}
+	return free.Lo
+}
+
+// checkAddr checks that addr is an allocatable address in the block and returns its offset within the
+// block.
+func (a *HostAllocator) checkAddr(addr Addr) (uint64, error) {
+	if !a.block.Contains(addr) {
+		return 0, fmt.Errorf("%w: %s is not in %s", ErrAddrNotInBlock, addr, a.block)
+	}
+	for _, r := range a.reserved[:a.numReserved] {
+		if r == addr {
+			return 0, fmt.Errorf("%w: %s", ErrAddrReserved, addr)
+		}
+	}
+	return addrToUint128(addr).Sub(a.base).Lo, nil
+}
+
+func (a *HostAllocator) isAllocated(addr Addr, offset uint64) bool {
+	if a.bitmap != nil {
+		return a.bitmap[offset/64]&(1<<(offset%64)) != 0
+	}
+	return !a.free.Contains(addr)
+}
+
+// markAllocated marks the address as used.  offset is only used in bitmap mode.
+func (a *HostAllocator) markAllocated(addr Addr, offset uint64) {
+	if a.bitmap != nil {
+		a.bitmap[offset/64] |= 1 << (offset % 64)
+	} else {
+		a.free.Discard(addr.AsCIDR())
+	}
+}
+
+func (a *HostAllocator) addrAt(offset Uint128) Addr {
+	u := a.base.Add(offset)
+	if a.block.Version() == 4 {
+		return u.toV4Addr()
+	}
+	return V6AddrFromUint128(u)
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"math"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = Describe("HostAllocator", func() {
+	mustAllocate := func(a *ip.HostAllocator) string {
+		addr, err := a.Allocate()
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		return addr.String()
+	}
+
+	DescribeTable("should allocate every address in order then report full",
+		func(block string, opts []ip.AddrsOpt, expected []string) {
+			a := ip.NewHostAllocator(ip.MustParseCIDROrIP(block), opts...)
+			Expect(a.NumFree()).To(BeNumerically("==", len(expected)))
+			var allocated []string
+			for range expected {
+				allocated = append(allocated, mustAllocate(a))
+			}
+			Expect(allocated).To(Equal(expected))
+			_, err := a.Allocate()
+			Expect(err).To(Equal(ip.ErrBlockFull))
+			Expect(a.NumFree()).To(BeZero())
+			Expect(a.NumAllocated()).To(BeNumerically("==", len(expected)))
+		},
+		Entry("/30", "10.0.0.0/30", nil, []string{"10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.3"}),
+		Entry("/30 without network and broadcast", "10.0.0.0/30",
+			[]ip.AddrsOpt{ip.WithoutNetworkAndBroadcastAddrs()}, []string{"10.0.0.1", "10.0.0.2"}),
+		Entry("/31 ignores reservations", "10.0.0.0/31",
+			[]ip.AddrsOpt{ip.WithoutNetworkAndBroadcastAddrs()}, []string{"10.0.0.0", "10.0.0.1"}),
+		Entry("/32", "10.0.0.5/32", nil, []string{"10.0.0.5"}),
+		Entry("/126", "fd00::/126", []ip.AddrsOpt{ip.WithoutNetworkAddr()}, []string{"fd00::1", "fd00::2", "fd00::3"}),
+	)
+
+	It("should handle bitmaps spanning multiple words", func() {
+		a := ip.NewHostAllocator(ip.MustParseCIDROrIP("10.0.0.0/24"), ip.WithoutBroadcastAddr())
+		Expect(a.NumFree()).To(BeNumerically("==", 255))
+		for i := 0; i < 255; i++ {
+			mustAllocate(a)
+		}
+		_, err := a.Allocate()
+		Expect(err).To(Equal(ip.ErrBlockFull))
+		Expect(a.Release(ip.FromString("10.0.0.130"))).To(Succeed())
+		Expect(mustAllocate(a)).To(Equal("10.0.0.130"))
+	})
+
+	It("should allocate huge IPv6 blocks", func() {
+		a := ip.NewHostAllocator(ip.MustParseCIDROrIP("fd00:1::/64"), ip.WithoutNetworkAndBroadcastAddrs())
+		Expect(a.NumFree()).To(Equal(uint64(math.MaxUint64 - 1)))
+		Expect(mustAllocate(a)).To(Equal("fd00:1::1"))
+		Expect(mustAllocate(a)).To(Equal("fd00:1::2"))
+		Expect(a.AllocateSpecific(ip.FromString("fd00:1::ffff:ffff:ffff:fffe"))).To(Succeed())
+		Expect(a.AllocateSpecific(ip.FromString("fd00:1::ffff:ffff:ffff:ffff"))).To(MatchError(ip.ErrAddrReserved))
+		Expect(a.Release(ip.FromString("fd00:1::1"))).To(Succeed())
+		Expect(mustAllocate(a)).To(Equal("fd00:1::1"))
+		Expect(mustAllocate(a)).To(Equal("fd00:1::3"))
+		Expect(a.NumAllocated()).To(BeNumerically("==", 4))
+		Expect(a.NumFree()).To(Equal(uint64(math.MaxUint64 - 5)))
+
+		a = ip.NewHostAllocator(ip.MustParseCIDROrIP("::/0"), ip.WithoutBroadcastAddr())
+		Expect(a.NumFree()).To(Equal(uint64(math.MaxUint64)))
+		Expect(a.IsAllocated(ip.FromString("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"))).To(BeFalse())
+		Expect(mustAllocate(a)).To(Equal("::"))
+	})
+
+	It("should reject invalid addresses", func() {
+		a := ip.NewHostAllocator(ip.MustParseCIDROrIP("10.0.0.0/29"), ip.WithoutNetworkAddr())
+		Expect(a.AllocateSpecific(ip.FromString("10.0.0.8"))).To(MatchError(ip.ErrAddrNotInBlock))
+		Expect(a.AllocateSpecific(ip.FromString("fd00::1"))).To(MatchError(ip.ErrAddrNotInBlock))
+		Expect(a.AllocateSpecific(ip.FromString("10.0.0.0"))).To(MatchError(ip.ErrAddrReserved))
+		Expect(a.Release(ip.FromString("10.0.0.0"))).To(MatchError(ip.ErrAddrReserved))
+		Expect(a.Release(ip.FromString("10.0.0.3"))).To(MatchError(ip.ErrAddrUnallocated))
+		Expect(a.AllocateSpecific(ip.FromString("10.0.0.3"))).To(Succeed())
+		Expect(a.AllocateSpecific(ip.FromString("10.0.0.3"))).To(MatchError(ip.ErrAddrAllocated))
+		Expect(a.IsAllocated(ip.FromString("10.0.0.3"))).To(BeTrue())
+		Expect(a.IsAllocated(ip.FromString("10.0.0.0"))).To(BeFalse())
+	})
+
+	DescribeTable("should always hand out the lowest free address under random churn",
+		func(block string) {
+			a := ip.NewHostAllocator(ip.MustParseCIDROrIP(block))
+			base := a.Block().Addr()
+			rng := rand.New(rand.NewSource(1))
+			allocated := map[uint64]bool{}
+			for i := 0; i < 5000; i++ {
+				if rng.Intn(3) > 0 {
+					var expected uint64
+					for allocated[expected] {
+						expected++
+					}
+					addr, err := a.Allocate()
+					Expect(err).NotTo(HaveOccurred())
+					expectedAddr, _ := base.Add(expected)
+					Expect(addr).To(Equal(expectedAddr))
+					allocated[expected] = true
+				} else if len(allocated) > 0 {
+					n := uint64(rng.Intn(len(allocated) * 2))
+					for !allocated[n] {
+						n = (n + 1) % 4096
+					}
+					addr, _ := base.Add(n)
+					Expect(a.Release(addr)).To(Succeed())
+					delete(allocated, n)
+				}
+				Expect(a.NumAllocated()).To(BeNumerically("==", len(allocated)))
+			}
+		},
+		Entry("bitmap", "10.0.0.0/20"),
+		Entry("trie", "fd00::/96"),
+	)
+})
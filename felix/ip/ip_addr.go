@@ -21,11 +21,17 @@
 package ip
 
 import (
+	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"math/bits"
 	"net"
+	"net/netip"
+	"sort"
+	"strconv"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -38,7 +44,10 @@ const (
 	IPv6SizeDword = 4
 )
 
-var ErrInvalidIP = errors.New("Failed to parse IP address")
+var (
+	ErrInvalidIP        = errors.New("Failed to parse IP address")
+	ErrNonCanonicalCIDR = errors.New("CIDR has bits set beyond its prefix length")
+)
 
 // Addr represents either an IPv4 or IPv6 IP address.
 type Addr interface {
@@ -47,10 +56,36 @@ type Addr interface {
 	// AsNetIP returns a net.IP, which is backed by/shares storage with
 	// this object.
 	AsNetIP() net.IP
+	// AsNetIPAddr returns the address as a netip.Addr.  Unlike AsNetIP, it does not allocate.
+	AsNetIPAddr() netip.Addr
 	AsCalicoNetIP() calinet.IP
 	AsCIDR() CIDR
 	String() string
+	// AppendString appends the String() form of the address to b and returns the extended buffer.  It
+	// avoids allocating a new string when rendering many addresses into one buffer.
+	AppendString(b []byte) []byte
 	NthBit(uint) int
+	// Next returns the next address.  ok is false if the address was the last address in the address
+	// space, in which case the returned address wraps around to zero.
+	Next() (next Addr, ok bool)
+	// Prev returns the previous address.  ok is false if the address was the zero address, in which
+	// case the returned address wraps around to the last address.
+	Prev() (prev Addr, ok bool)
+	// Add returns the address n addresses after this one.  ok is false if the result overflowed the
+	// address space, in which case the returned address has wrapped around.
+	Add(n uint64) (sum Addr, ok bool)
+	// Hash64 returns a stable, non-cryptographic hash of the address.
+	Hash64() uint64
+
+	// Classification helpers, which behave like their netip.Addr equivalents.
+	IsUnspecified() bool
+	IsLoopback() bool
+	IsPrivate() bool
+	IsLinkLocalUnicast() bool
+	IsMulticast() bool
+	IsLinkLocalMulticast() bool
+	IsInterfaceLocalMulticast() bool
+	IsGlobalUnicast() bool
 }
 
 type V4Addr [4]byte
@@ -63,6 +98,10 @@ func (a V4Addr) AsNetIP() net.IP {
 	return net.IP(a[0:net.IPv4len])
 }
 
+func (a V4Addr) AsNetIPAddr() netip.Addr {
+	return netip.AddrFrom4(a)
+}
+
 func (a V4Addr) AsCalicoNetIP() calinet.IP {
 	return calinet.IP{IP: a.AsNetIP()}
 }
@@ -82,8 +121,49 @@ func (a V4Addr) NthBit(n uint) int {
 	return int(a.AsUint32() >> (32 - n) & 1)
 }
 
+func (a V4Addr) Next() (Addr, bool) {
+	return a.Add(1)
+}
+
+func (a V4Addr) Prev() (Addr, bool) {
+	u := a.AsUint32()
+	var prev V4Addr
+	binary.BigEndian.PutUint32(prev[:], u-1)
+	return prev, u != 0
+}
+
+func (a V4Addr) Add(n uint64) (Addr, bool) {
+	sum := uint64(a.AsUint32()) + n
+	var result V4Addr
+	binary.BigEndian.PutUint32(result[:], uint32(sum))
+	return result, sum >= uint64(a.AsUint32()) && sum <= math.MaxUint32
+}
+
 func (a V4Addr) String() string {
-	return a.AsNetIP().String()
+	var buf [len("255.255.255.255")]byte
+	return string(a.AppendString(buf[:0]))
+}
+
+func (a V4Addr) AppendString(b []byte) []byte {
+	for i, octet := range a {
+		if i > 0 {
+			b = append(b, '.')
+		}
+		b = strconv.AppendUint(b, uint64(octet), 10)
+	}
+	return b
+}
+
+func (a V4Addr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+func (a *V4Addr) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSONString(data)
+	if err != nil {
+		return err
+	}
+	return a.UnmarshalText(text)
 }
 
 type V6Addr [16]byte
@@ -96,6 +176,10 @@ func (a V6Addr) AsNetIP() net.IP {
 	return net.IP(a[0:net.IPv6len])
 }
 
+func (a V6Addr) AsNetIPAddr() netip.Addr {
+	return netip.AddrFrom16(a)
+}
+
 func (a V6Addr) AsCalicoNetIP() calinet.IP {
 	return calinet.IP{IP: a.AsNetIP()}
 }
@@ -122,8 +206,82 @@ func (a V6Addr) NthBit(n uint) int {
 	return int(l >> (128 - n) & 1)
 }
 
+func (a V6Addr) Next() (Addr, bool) {
+	return a.Add(1)
+}
+
+func (a V6Addr) Prev() (Addr, bool) {
+	h, l := a.AsUint64Pair()
+	l, borrow := bits.Sub64(l, 1, 0)
+	h, borrow = bits.Sub64(h, 0, borrow)
+	return v6AddrFromUint64Pair(h, l), borrow == 0
+}
+
+func (a V6Addr) Add(n uint64) (Addr, bool) {
+	h, l := a.AsUint64Pair()
+	l, carry := bits.Add64(l, n, 0)
+	h, carry = bits.Add64(h, 0, carry)
+	return v6AddrFromUint64Pair(h, l), carry == 0
+}
+
+// IsV4Mapped returns true if the address is an IPv4-mapped IPv6 address (::ffff:a.b.c.d).
+func (a V6Addr) IsV4Mapped() bool {
+	_, ok := a.Unmap()
+	return ok
+}
+
+// Unmap returns the IPv4 address embedded in an IPv4-mapped IPv6 address.  ok is false if the address is
+// not IPv4-mapped.
+func (a V6Addr) Unmap() (v4 V4Addr, ok bool) {
+	for _, b := range a[:10] {
+		if b != 0 {
+			return v4, false
+		}
+	}
+	if a[10] != 0xff || a[11] != 0xff {
+		return v4, false
+	}
+	copy(v4[:], a[12:])
+	return v4, true
+}
+
+func v6AddrFromUint64Pair(h, l uint64) (a V6Addr) {
+	binary.BigEndian.PutUint64(a[:8], h)
+	binary.BigEndian.PutUint64(a[8:], l)
+	return
+}
+
 func (a V6Addr) String() string {
-	return a.AsNetIP().String()
+	var buf [len("ffff:ffff:ffff:ffff:ffff:ffff:255.255.255.255")]byte
+	return string(a.AppendString(buf[:0]))
+}
+
+func (a V6Addr) AppendString(b []byte) []byte {
+	if v4, ok := a.Unmap(); ok {
+		// Match net.IP, which renders IPv4-mapped addresses in dotted-quad form.
+		return v4.AppendString(b)
+	}
+	return netip.AddrFrom16(a).AppendTo(b)
+}
+
+func (a V6Addr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+func (a *V6Addr) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSONString(data)
+	if err != nil {
+		return err
+	}
+	return a.UnmarshalText(text)
+}
+
+func unmarshalJSONString(data []byte) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
 }
 
 type CIDR interface {
@@ -131,8 +289,33 @@ type CIDR interface {
 	Addr() Addr
 	Prefix() uint8
 	String() string
+	// AppendString appends the String() form of the CIDR to b and returns the extended buffer.
+	AppendString(b []byte) []byte
 	ToIPNet() net.IPNet
+	// AsNetIPPrefix returns the CIDR as a netip.Prefix.  Unlike ToIPNet, it does not allocate.
+	AsNetIPPrefix() netip.Prefix
 	Contains(addr Addr) bool
+	// ContainsCIDR returns true if the given CIDR is of the same IP version and is equal to, or a
+	// subnet of, this CIDR.
+	ContainsCIDR(other CIDR) bool
+	// Supernet returns the CIDR that is n bits shorter than this one and contains it, with any host bits
+	// cleared.  If n is greater than the prefix length, returns the /0 CIDR.
+	Supernet(n uint8) CIDR
+	// Canonicalize returns the CIDR with any bits beyond its prefix length cleared.  CIDRs returned by
+	// the parsing functions in this package are already canonical.
+	Canonicalize() CIDR
+	// VisitAddrs calls f for each address in the CIDR, in order, until f returns false.
+	VisitAddrs(f func(addr Addr) bool, opts ...AddrsOpt)
+	// VisitSubnets calls f for each subnet of the CIDR with the given prefix length, in order, until f
+	// returns false.
+	VisitSubnets(newPrefixLen int, f func(subnet CIDR) bool) error
+	// Hash64 returns a stable, non-cryptographic hash of the CIDR.
+	Hash64() uint64
+	// NetworkAddr returns the first address in the CIDR; i.e. its address with the host bits cleared.
+	NetworkAddr() Addr
+	// NthAddr returns the nth address in the CIDR, counting from 0 for the network address.  ok is false
+	// if n is beyond the end of the CIDR.
+	NthAddr(n uint64) (addr Addr, ok bool)
 }
 
 type V4CIDR struct {
@@ -159,6 +342,10 @@ func (c V4CIDR) ToIPNet() net.IPNet {
 	}
 }
 
+func (c V4CIDR) AsNetIPPrefix() netip.Prefix {
+	return netip.PrefixFrom(c.addr.AsNetIPAddr(), int(c.prefix))
+}
+
 func (c V4CIDR) Contains(addr Addr) bool {
 	v4Addr, ok := addr.(V4Addr)
 	if !ok {
@@ -176,8 +363,72 @@ func (c V4CIDR) ContainsV4(addr V4Addr) bool {
 	return commonPrefixLen >= c.prefix
 }
 
+func (c V4CIDR) ContainsCIDR(other CIDR) bool {
+	v4CIDR, ok := other.(V4CIDR)
+	if !ok {
+		return false
+	}
+	return c.ContainsV4CIDR(v4CIDR)
+}
+
+func (c V4CIDR) ContainsV4CIDR(other V4CIDR) bool {
+	return other.prefix >= c.prefix && c.ContainsV4(other.addr)
+}
+
+func (c V4CIDR) Supernet(n uint8) CIDR {
+	if n > c.prefix {
+		n = c.prefix
+	}
+	c.prefix -= n
+	return V4CommonPrefix(c, c)
+}
+
+func (c V4CIDR) Canonicalize() CIDR {
+	return V4CommonPrefix(c, c)
+}
+
+func (c V4CIDR) NetworkAddr() Addr {
+	return V4CommonPrefix(c, c).addr
+}
+
+// BroadcastAddr returns the last address in the CIDR; i.e. its address with all the host bits set.
+// Note: /31 and /32 CIDRs have no broadcast address (RFC 3021) but BroadcastAddr still returns their
+// last address.
+func (c V4CIDR) BroadcastAddr() V4Addr {
+	var a V4Addr
+	binary.BigEndian.PutUint32(a[:], c.addr.AsUint32()|(^uint32(0)>>c.prefix))
+	return a
+}
+
+func (c V4CIDR) NthAddr(n uint64) (Addr, bool) {
+	hostBits := 32 - uint(c.prefix)
+	if n >= uint64(1)<<hostBits {
+		return nil, false
+	}
+	return V4CommonPrefix(c, c).addr.Add(n)
+}
+
 func (c V4CIDR) String() string {
-	return fmt.Sprintf("%s/%v", c.addr.String(), c.prefix)
+	var buf [len("255.255.255.255/32")]byte
+	return string(c.AppendString(buf[:0]))
+}
+
+func (c V4CIDR) AppendString(b []byte) []byte {
+	b = c.addr.AppendString(b)
+	b = append(b, '/')
+	return strconv.AppendUint(b, uint64(c.prefix), 10)
+}
+
+func (c V4CIDR) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+func (c *V4CIDR) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSONString(data)
+	if err != nil {
+		return err
+	}
+	return c.UnmarshalText(text)
 }
 
 type V6CIDR struct {
@@ -204,6 +455,10 @@ func (c V6CIDR) ToIPNet() net.IPNet {
 	}
 }
 
+func (c V6CIDR) AsNetIPPrefix() netip.Prefix {
+	return netip.PrefixFrom(c.addr.AsNetIPAddr(), int(c.prefix))
+}
+
 func (c V6CIDR) Contains(addr Addr) bool {
 	v6Addr, ok := addr.(V6Addr)
 	if !ok {
@@ -227,10 +482,67 @@ func (c V6CIDR) ContainsV6(addr V6Addr) bool {
 	return commonPrefixLen >= c.prefix
 }
 
+func (c V6CIDR) ContainsCIDR(other CIDR) bool {
+	v6CIDR, ok := other.(V6CIDR)
+	if !ok {
+		return false
+	}
+	return c.ContainsV6CIDR(v6CIDR)
+}
+
+func (c V6CIDR) ContainsV6CIDR(other V6CIDR) bool {
+	return other.prefix >= c.prefix && c.ContainsV6(other.addr)
+}
+
+func (c V6CIDR) Supernet(n uint8) CIDR {
+	if n > c.prefix {
+		n = c.prefix
+	}
+	c.prefix -= n
+	return V6CommonPrefix(c, c)
+}
+
+func (c V6CIDR) Canonicalize() CIDR {
+	return V6CommonPrefix(c, c)
+}
+
+func (c V6CIDR) NetworkAddr() Addr {
+	return V6CommonPrefix(c, c).addr
+}
+
+func (c V6CIDR) NthAddr(n uint64) (Addr, bool) {
+	hostBits := 128 - uint(c.prefix)
+	if hostBits < 64 && n >= uint64(1)<<hostBits {
+		return nil, false
+	}
+	return V6CommonPrefix(c, c).addr.Add(n)
+}
+
 func (c V6CIDR) String() string {
-	return fmt.Sprintf("%s/%v", c.addr.String(), c.prefix)
+	var buf [len("ffff:ffff:ffff:ffff:ffff:ffff:255.255.255.255/128")]byte
+	return string(c.AppendString(buf[:0]))
+}
+
+func (c V6CIDR) AppendString(b []byte) []byte {
+	b = c.addr.AppendString(b)
+	b = append(b, '/')
+	return strconv.AppendUint(b, uint64(c.prefix), 10)
 }
 
+func (c V6CIDR) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+func (c *V6CIDR) UnmarshalJSON(data []byte) error {
+	text, err := unmarshalJSONString(data)
+	if err != nil {
+		return err
+	}
+	return c.UnmarshalText(text)
+}
+
+// FromString parses the given IP address, returning nil if it is not valid.  See ParseAddr for a version
+// that returns an error explaining the failure.
 func FromString(s string) Addr {
 	return FromNetIP(net.ParseIP(s))
 }
@@ -252,6 +564,39 @@ func FromNetIP(netIP net.IP) Addr {
 	return nil
 }
 
+// FromNetIPAddr converts a netip.Addr to our Addr representation.  As with FromNetIP, IPv4-mapped IPv6
+// addresses are converted to V4Addr.  Returns nil if the netip.Addr is the invalid zero value.
+func FromNetIPAddr(addr netip.Addr) Addr {
+	if !addr.IsValid() {
+		return nil
+	}
+	addr = addr.Unmap()
+	if addr.Is4() {
+		return V4Addr(addr.As4())
+	}
+	return V6Addr(addr.As16())
+}
+
+// FromNetIPPrefix converts a netip.Prefix to our CIDR representation, masking off any host bits.  As with
+// the parsing functions, IPv4-mapped IPv6 prefixes are converted to IPv4; see NormalizeMappedCIDR.
+// Returns nil if the netip.Prefix is invalid.
+func FromNetIPPrefix(prefix netip.Prefix) CIDR {
+	if !prefix.IsValid() {
+		return nil
+	}
+	prefix = prefix.Masked()
+	if prefix.Addr().Is4() {
+		return V4CIDR{
+			addr:   prefix.Addr().As4(),
+			prefix: uint8(prefix.Bits()),
+		}
+	}
+	return NormalizeMappedCIDR(V6CIDR{
+		addr:   prefix.Addr().As16(),
+		prefix: uint8(prefix.Bits()),
+	})
+}
+
 func CIDRFromString(cidrStr string) (CIDR, error) {
 	_, cidr, err := net.ParseCIDR(cidrStr)
 	if err != nil {
@@ -269,10 +614,16 @@ func FromCalicoIP(ip calinet.IP) Addr {
 }
 
 func CIDRFromIPNet(ipNet *net.IPNet) CIDR {
-	ones, _ := ipNet.Mask.Size()
+	ones, bits := ipNet.Mask.Size()
 	// Mask the IP before creating the CIDR so that we have it in canonical format.
 	ip := FromNetIP(ipNet.IP.Mask(ipNet.Mask))
 	if ip.Version() == 4 {
+		if bits == 128 {
+			// IPv4-mapped IPv6 CIDR, such as ::ffff:10.0.0.0/120.  FromNetIP has already converted the
+			// address to IPv4 so convert the prefix length to match.  (The mask can only leave the
+			// address IPv4-mapped if the prefix length is at least 96.)
+			ones -= 96
+		}
 		return V4CIDR{
 			addr:   ip.(V4Addr),
 			prefix: uint8(ones),
@@ -285,6 +636,30 @@ func CIDRFromIPNet(ipNet *net.IPNet) CIDR {
 	}
 }
 
+// NormalizeMapped converts an IPv4-mapped IPv6 address, such as ::ffff:10.0.0.1, to the equivalent
+// V4Addr.  Other addresses are returned unchanged.  The parsing functions in this package already perform
+// this conversion; NormalizeMapped is for addresses that were constructed from raw bytes.
+func NormalizeMapped(addr Addr) Addr {
+	if a, ok := addr.(V6Addr); ok {
+		if v4, ok := a.Unmap(); ok {
+			return v4
+		}
+	}
+	return addr
+}
+
+// NormalizeMappedCIDR converts an IPv4-mapped IPv6 CIDR with a prefix length of at least 96, such as
+// ::ffff:10.0.0.0/120, to the equivalent V4CIDR (10.0.0.0/24 in that example).  Other CIDRs are returned
+// unchanged.
+func NormalizeMappedCIDR(cidr CIDR) CIDR {
+	if c, ok := cidr.(V6CIDR); ok && c.prefix >= 96 {
+		if v4, ok := c.addr.Unmap(); ok {
+			return V4CIDR{addr: v4, prefix: c.prefix - 96}
+		}
+	}
+	return cidr
+}
+
 // CIDRFromAddrAndPrefix.
 func CIDRFromAddrAndPrefix(addr Addr, prefixLen int) CIDR {
 	netIP := addr.AsNetIP()
@@ -310,23 +685,68 @@ func MustParseCIDROrIP(s string) CIDR {
 	return cidr
 }
 
+// ParseAddr parses the given IP address.  On failure, it returns a *ParseError wrapping ErrInvalidIP.
+func ParseAddr(s string) (Addr, error) {
+	addr := FromString(s)
+	if addr == nil {
+		return nil, newAddrParseError(s, 0)
+	}
+	return addr, nil
+}
+
 // ParseCIDROrIP parses the given IP address or CIDR, treating IP addresses as "full length"
-// CIDRs.  For example, "10.0.0.1" is treated as "10.0.0.1/32".
+// CIDRs.  For example, "10.0.0.1" is treated as "10.0.0.1/32".  On failure, it returns a *ParseError
+// describing which part of the input was rejected.
 func ParseCIDROrIP(s string) (CIDR, error) {
 	if !strings.Contains(s, "/") {
 		ip := net.ParseIP(s)
 		if ip == nil {
-			return nil, ErrInvalidIP
+			return nil, newAddrParseError(s, 0)
 		}
 		return CIDRFromNetIP(ip), nil
 	}
-	_, netCIDR, err := net.ParseCIDR(s)
+	_, netCIDR, err := parseNetCIDR(s)
 	if err != nil {
 		return nil, err
 	}
 	return CIDRFromIPNet(netCIDR), nil
 }
 
+// parseNetCIDR wraps net.ParseCIDR, converting its errors to *ParseError.
+func parseNetCIDR(s string) (net.IP, *net.IPNet, error) {
+	netIP, netCIDR, err := net.ParseCIDR(s)
+	if err != nil {
+		slashIdx := strings.IndexByte(s, '/')
+		if net.ParseIP(s[:slashIdx]) == nil {
+			return nil, nil, newAddrParseError(s, 0)
+		}
+		return nil, nil, newPrefixLenParseError(s, slashIdx+1)
+	}
+	return netIP, netCIDR, nil
+}
+
+// ParseCIDRStrict parses the given IP address or CIDR, like ParseCIDROrIP, but returns a *ParseError
+// wrapping ErrNonCanonicalCIDR if the CIDR has bits set beyond its prefix length.  For example,
+// "64.0.3.0/8" is rejected, whereas ParseCIDROrIP would silently treat it as "64.0.0.0/8".
+func ParseCIDRStrict(s string) (CIDR, error) {
+	if !strings.Contains(s, "/") {
+		return ParseCIDROrIP(s)
+	}
+	netIP, netCIDR, err := parseNetCIDR(s)
+	if err != nil {
+		return nil, err
+	}
+	cidr := CIDRFromIPNet(netCIDR)
+	if !netIP.Equal(netCIDR.IP) {
+		return nil, &ParseError{
+			Input:     s,
+			Component: ParseComponentAddr,
+			Err:       fmt.Errorf("%w; the canonical form is %s", ErrNonCanonicalCIDR, cidr),
+		}
+	}
+	return cidr, nil
+}
+
 func IPNetsEqual(net1, net2 *net.IPNet) bool {
 	if net1 == nil && net2 == nil {
 		// Both are nil, therefore equal.
@@ -338,3 +758,41 @@ func IPNetsEqual(net1, net2 *net.IPNet) bool {
 	}
 	return CIDRFromIPNet(net1) == CIDRFromIPNet(net2)
 }
+
+// CompareCIDRs returns -1, 0 or 1 depending on whether a sorts before, the same as or after b.  IPv4
+// CIDRs sort before IPv6 CIDRs; within an IP version, CIDRs are ordered by address and then by prefix
+// length, so a CIDR sorts immediately before the more-specific CIDRs that share its address.
+func CompareCIDRs(a, b CIDR) int {
+	if a.Version() != b.Version() {
+		if a.Version() < b.Version() {
+			return -1
+		}
+		return 1
+	}
+	var cmp int
+	switch a := a.(type) {
+	case V4CIDR:
+		bAddr := b.(V4CIDR).addr
+		cmp = bytes.Compare(a.addr[:], bAddr[:])
+	case V6CIDR:
+		bAddr := b.(V6CIDR).addr
+		cmp = bytes.Compare(a.addr[:], bAddr[:])
+	}
+	if cmp != 0 {
+		return cmp
+	}
+	switch {
+	case a.Prefix() < b.Prefix():
+		return -1
+	case a.Prefix() > b.Prefix():
+		return 1
+	}
+	return 0
+}
+
+// SortCIDRs sorts the given slice in place, in the order defined by CompareCIDRs.
+func SortCIDRs(cidrs []CIDR) {
+	sort.Slice(cidrs, func(i, j int) bool {
+		return CompareCIDRs(cidrs[i], cidrs[j]) < 0
+	})
+}
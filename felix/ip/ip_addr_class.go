@@ -0,0 +1,135 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+// Address classification helpers.  These mirror the methods of the same names on netip.Addr (and
+// net.IP), including the treatment of IPv4-mapped IPv6 addresses, which are classified as the IPv4
+// address that they map.
+
+// IsUnspecified returns true if the address is 0.0.0.0.
+func (a V4Addr) IsUnspecified() bool {
+	return a == V4Addr{}
+}
+
+// IsLoopback returns true if the address is in 127.0.0.0/8.
+func (a V4Addr) IsLoopback() bool {
+	return a[0] == 127
+}
+
+// IsPrivate returns true if the address is in one of the RFC 1918 private ranges: 10.0.0.0/8,
+// 172.16.0.0/12 or 192.168.0.0/16.
+func (a V4Addr) IsPrivate() bool {
+	return a[0] == 10 ||
+		(a[0] == 172 && a[1]&0xf0 == 16) ||
+		(a[0] == 192 && a[1] == 168)
+}
+
+// IsLinkLocalUnicast returns true if the address is in 169.254.0.0/16.
+func (a V4Addr) IsLinkLocalUnicast() bool {
+	return a[0] == 169 && a[1] == 254
+}
+
+// IsMulticast returns true if the address is in 224.0.0.0/4.
+func (a V4Addr) IsMulticast() bool {
+	return a[0]&0xf0 == 0xe0
+}
+
+// IsLinkLocalMulticast returns true if the address is in 224.0.0.0/24.
+func (a V4Addr) IsLinkLocalMulticast() bool {
+	return a[0] == 224 && a[1] == 0 && a[2] == 0
+}
+
+// IsInterfaceLocalMulticast always returns false; interface-local multicast is an IPv6 concept.
+func (a V4Addr) IsInterfaceLocalMulticast() bool {
+	return false
+}
+
+// IsGlobalUnicast returns true if the address is not unspecified, the limited broadcast address,
+// loopback, multicast or link-local unicast.  As with net.IP, private addresses are considered
+// global unicast.
+func (a V4Addr) IsGlobalUnicast() bool {
+	return !a.IsUnspecified() &&
+		a != V4Addr{255, 255, 255, 255} &&
+		!a.IsLoopback() &&
+		!a.IsMulticast() &&
+		!a.IsLinkLocalUnicast()
+}
+
+// IsUnspecified returns true if the address is ::.  Note: the IPv4-mapped address ::ffff:0.0.0.0 is
+// not considered unspecified.
+func (a V6Addr) IsUnspecified() bool {
+	return a == V6Addr{}
+}
+
+// IsLoopback returns true if the address is ::1 (or an IPv4-mapped loopback address).
+func (a V6Addr) IsLoopback() bool {
+	if v4, ok := a.Unmap(); ok {
+		return v4.IsLoopback()
+	}
+	return a == V6Addr{15: 1}
+}
+
+// IsPrivate returns true if the address is a unique local address in fc00::/7 (or an IPv4-mapped
+// RFC 1918 address).
+func (a V6Addr) IsPrivate() bool {
+	if v4, ok := a.Unmap(); ok {
+		return v4.IsPrivate()
+	}
+	return a[0]&0xfe == 0xfc
+}
+
+// IsLinkLocalUnicast returns true if the address is in fe80::/10 (or is an IPv4-mapped link-local
+// address).
+func (a V6Addr) IsLinkLocalUnicast() bool {
+	if v4, ok := a.Unmap(); ok {
+		return v4.IsLinkLocalUnicast()
+	}
+	return a[0] == 0xfe && a[1]&0xc0 == 0x80
+}
+
+// IsMulticast returns true if the address is in ff00::/8 (or is an IPv4-mapped multicast address).
+func (a V6Addr) IsMulticast() bool {
+	if v4, ok := a.Unmap(); ok {
+		return v4.IsMulticast()
+	}
+	return a[0] == 0xff
+}
+
+// IsLinkLocalMulticast returns true if the address is a link-local-scope multicast address, such as
+// ff02::1 (or is an IPv4-mapped link-local multicast address).
+func (a V6Addr) IsLinkLocalMulticast() bool {
+	if v4, ok := a.Unmap(); ok {
+		return v4.IsLinkLocalMulticast()
+	}
+	return a[0] == 0xff && a[1]&0x0f == 0x02
+}
+
+// IsInterfaceLocalMulticast returns true if the address is an interface-local-scope multicast
+// address, such as ff01::1.
+func (a V6Addr) IsInterfaceLocalMulticast() bool {
+	return a[0] == 0xff && a[1]&0x0f == 0x01
+}
+
+// IsGlobalUnicast returns true if the address is not unspecified, loopback, multicast or link-local
+// unicast.  As with net.IP, unique local addresses are considered global unicast.
+func (a V6Addr) IsGlobalUnicast() bool {
+	if v4, ok := a.Unmap(); ok {
+		return v4.IsGlobalUnicast()
+	}
+	return !a.IsUnspecified() &&
+		!a.IsLoopback() &&
+		!a.IsMulticast() &&
+		!a.IsLinkLocalUnicast()
+}
@@ -15,10 +15,21 @@
 package ip_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+
 	calinet "github.com/projectcalico/calico/libcalico-go/lib/net"
 
 	. "github.com/projectcalico/calico/felix/ip"
 
+	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 )
@@ -75,6 +86,96 @@ var _ = DescribeTable("CIDR",
 	),
 )
 
+var _ = DescribeTable("ParseCIDRStrict",
+	func(input string, expected string, expectedErr error) {
+		cidr, err := ParseCIDRStrict(input)
+		if expectedErr != nil {
+			Expect(err).To(MatchError(expectedErr))
+			Expect(cidr).To(BeNil())
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cidr.String()).To(Equal(expected))
+	},
+	Entry("IPv4 CIDR", "10.0.0.0/16", "10.0.0.0/16", nil),
+	Entry("IPv4 address", "10.0.0.1", "10.0.0.1/32", nil),
+	Entry("IPv4 host bits set", "64.0.3.0/8", "", ErrNonCanonicalCIDR),
+	Entry("IPv4 /0 with host bits set", "10.0.0.0/0", "", ErrNonCanonicalCIDR),
+	Entry("IPv6 CIDR", "dead::/16", "dead::/16", nil),
+	Entry("IPv6 address", "dead::beef", "dead::beef/128", nil),
+	Entry("IPv6 host bits set", "dead:0:0::beef/16", "", ErrNonCanonicalCIDR),
+	Entry("invalid address", "10.0.0.256", "", ErrInvalidIP),
+)
+
+var _ = Describe("ParseCIDRStrict errors", func() {
+	It("should mention the canonical form", func() {
+		_, err := ParseCIDRStrict("64.0.3.0/8")
+		Expect(err).To(MatchError(ContainSubstring("64.0.0.0/8")))
+	})
+	It("should reject a malformed CIDR", func() {
+		_, err := ParseCIDRStrict("10.0.0.0/33")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = DescribeTable("ParseError",
+	func(input string, component ParseComponent, pos int, reason error) {
+		check := func(err error) {
+			var parseErr *ParseError
+			Expect(errors.As(err, &parseErr)).To(BeTrue(), fmt.Sprintf("expected a *ParseError, not %v", err))
+			Expect(parseErr.Input).To(Equal(input))
+			Expect(parseErr.Component).To(Equal(component))
+			Expect(parseErr.Pos).To(Equal(pos))
+			Expect(err).To(MatchError(reason))
+			Expect(err.Error()).To(ContainSubstring(component.String()))
+		}
+		_, err := ParseCIDROrIP(input)
+		check(err)
+		_, err = ParseCIDRStrict(input)
+		check(err)
+		_, err = ParseCIDRBytes([]byte(input))
+		check(err)
+	},
+	Entry("IPv4 address", "10.0.0.256", ParseComponentAddr, 0, ErrInvalidIP),
+	Entry("IPv4 CIDR address", "10.0.0.256/24", ParseComponentAddr, 0, ErrInvalidIP),
+	Entry("IPv4 prefix too long", "10.0.0.0/33", ParseComponentPrefixLen, 9, ErrInvalidPrefixLen),
+	Entry("IPv4 prefix empty", "10.0.0.0/", ParseComponentPrefixLen, 9, ErrInvalidPrefixLen),
+	Entry("IPv4 prefix junk", "10.0.0.0/2x", ParseComponentPrefixLen, 9, ErrInvalidPrefixLen),
+	Entry("IPv6 address", "fc00:::1", ParseComponentAddr, 0, ErrInvalidIP),
+	Entry("IPv6 CIDR address", "fc00::g/64", ParseComponentAddr, 0, ErrInvalidIP),
+	Entry("IPv6 prefix too long", "fc00::/129", ParseComponentPrefixLen, 7, ErrInvalidPrefixLen),
+	Entry("empty", "", ParseComponentAddr, 0, ErrInvalidIP),
+)
+
+var _ = Describe("ParseAddr", func() {
+	It("should parse valid addresses", func() {
+		Expect(ParseAddr("10.0.0.1")).To(Equal(FromString("10.0.0.1")))
+		Expect(ParseAddr("fc00::1")).To(Equal(FromString("fc00::1")))
+	})
+	It("should return a ParseError for an invalid address", func() {
+		_, err := ParseAddr("10.0.0.1/32")
+		Expect(err).To(MatchError(ErrInvalidIP))
+		Expect(err).To(BeAssignableToTypeOf(&ParseError{}))
+	})
+	It("should return a ParseError for a non-canonical CIDR in strict mode", func() {
+		_, err := ParseCIDRStrict("64.0.3.0/8")
+		Expect(err).To(MatchError(ErrNonCanonicalCIDR))
+		Expect(err).To(BeAssignableToTypeOf(&ParseError{}))
+	})
+})
+
+var _ = DescribeTable("Canonicalize",
+	func(inputCIDR string) {
+		cidr := MustParseCIDROrIP(inputCIDR)
+		Expect(cidr.Canonicalize()).To(Equal(cidr))
+		Expect(cidr.Supernet(0)).To(Equal(cidr))
+	},
+	Entry("IPv4", "10.0.0.0/16"),
+	Entry("IPv4 /0", "0.0.0.0/0"),
+	Entry("IPv6", "dead::/16"),
+	Entry("IPv6 /128", "dead::beef/128"),
+)
+
 var _ = DescribeTable("NthBit",
 	func(inputAddr string, n uint, expected int) {
 		addr := FromString(inputAddr)
@@ -107,3 +208,414 @@ var _ = DescribeTable("Contains",
 	Entry("IPv6 /112 true", "fc00:fe11::/112", "fc00:fe11::3", true),
 	Entry("IPv6 /112 false", "fc00:fe11::/112", "fc00:fe12::3", false),
 )
+
+var _ = DescribeTable("ContainsCIDR",
+	func(inputCIDR string, otherCIDR string, expected bool) {
+		cidr := MustParseCIDROrIP(inputCIDR)
+		other := MustParseCIDROrIP(otherCIDR)
+		Expect(cidr.ContainsCIDR(other)).To(Equal(expected))
+	},
+	Entry("IPv4 equal", "10.10.10.0/24", "10.10.10.0/24", true),
+	Entry("IPv4 subnet", "10.10.0.0/16", "10.10.10.0/24", true),
+	Entry("IPv4 /0", "0.0.0.0/0", "10.10.10.1/32", true),
+	Entry("IPv4 supernet", "10.10.10.0/24", "10.10.0.0/16", false),
+	Entry("IPv4 disjoint", "10.10.10.0/24", "10.10.11.0/24", false),
+	Entry("IPv4 vs IPv6", "0.0.0.0/0", "::/0", false),
+	Entry("IPv6 equal", "fc00:fe11::/112", "fc00:fe11::/112", true),
+	Entry("IPv6 subnet", "fc00:fe11::/64", "fc00:fe11::1:0/112", true),
+	Entry("IPv6 /0", "::/0", "fc00:fe11::1/128", true),
+	Entry("IPv6 supernet", "fc00:fe11::/112", "fc00:fe11::/64", false),
+	Entry("IPv6 disjoint", "fc00:fe11::/112", "fc00:fe12::/112", false),
+	Entry("IPv6 vs IPv4", "::/0", "0.0.0.0/0", false),
+)
+
+var _ = DescribeTable("Supernet",
+	func(inputCIDR string, n uint8, expected string) {
+		Expect(MustParseCIDROrIP(inputCIDR).Supernet(n)).To(Equal(MustParseCIDROrIP(expected)))
+	},
+	Entry("IPv4 zero", "10.10.10.0/24", uint8(0), "10.10.10.0/24"),
+	Entry("IPv4 one bit", "10.10.11.0/24", uint8(1), "10.10.10.0/23"),
+	Entry("IPv4 octet", "10.10.10.1/32", uint8(8), "10.10.10.0/24"),
+	Entry("IPv4 to /0", "10.10.10.0/24", uint8(24), "0.0.0.0/0"),
+	Entry("IPv4 clamped", "10.10.10.0/24", uint8(100), "0.0.0.0/0"),
+	Entry("IPv4 non-canonical", "10.10.10.1/24", uint8(0), "10.10.10.0/24"),
+	Entry("IPv6 zero", "fc00:fe11::/112", uint8(0), "fc00:fe11::/112"),
+	Entry("IPv6 one bit", "fc00:fe11::1:0/112", uint8(1), "fc00:fe11::/111"),
+	Entry("IPv6 across 64-bit boundary", "fc00:fe11:0:1::/66", uint8(4), "fc00:fe11::/62"),
+	Entry("IPv6 below 64 bits", "fc00:fe11::1/128", uint8(100), "fc00:fe10::/28"),
+	Entry("IPv6 to /0", "fc00:fe11::/112", uint8(112), "::/0"),
+	Entry("IPv6 clamped", "fc00:fe11::/112", uint8(255), "::/0"),
+)
+
+var _ = DescribeTable("JSON round trip",
+	func(input interface{}, expectedJSON string, output interface{}) {
+		b, err := json.Marshal(input)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(expectedJSON))
+		Expect(json.Unmarshal(b, output)).To(Succeed())
+		Expect(reflect.ValueOf(output).Elem().Interface()).To(Equal(input))
+	},
+	Entry("V4Addr", FromString("10.0.0.1").(V4Addr), `"10.0.0.1"`, new(V4Addr)),
+	Entry("V6Addr", FromString("dead::beef").(V6Addr), `"dead::beef"`, new(V6Addr)),
+	Entry("V4CIDR", MustParseCIDROrIP("10.0.0.0/16").(V4CIDR), `"10.0.0.0/16"`, new(V4CIDR)),
+	Entry("V6CIDR", MustParseCIDROrIP("dead::/16").(V6CIDR), `"dead::/16"`, new(V6CIDR)),
+)
+
+var _ = DescribeTable("JSON decode failures",
+	func(input string, output interface{}) {
+		Expect(json.Unmarshal([]byte(input), output)).NotTo(Succeed())
+	},
+	Entry("V4Addr not a string", `[10, 0, 0, 1]`, new(V4Addr)),
+	Entry("V4Addr bad IP", `"10.0.0.300"`, new(V4Addr)),
+	Entry("V4Addr wrong version", `"dead::beef"`, new(V4Addr)),
+	Entry("V6Addr wrong version", `"10.0.0.1"`, new(V6Addr)),
+	Entry("V4CIDR bad CIDR", `"10.0.0.0/33"`, new(V4CIDR)),
+	Entry("V4CIDR wrong version", `"dead::/16"`, new(V4CIDR)),
+	Entry("V6CIDR wrong version", `"10.0.0.0/16"`, new(V6CIDR)),
+)
+
+var _ = Describe("JSON in structs", func() {
+	type status struct {
+		Addr V4Addr `json:"addr"`
+		CIDR V6CIDR `json:"cidr"`
+	}
+
+	It("should round trip", func() {
+		in := status{Addr: FromString("10.0.0.1").(V4Addr), CIDR: MustParseCIDROrIP("dead::/16").(V6CIDR)}
+		b, err := json.Marshal(in)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`{"addr":"10.0.0.1","cidr":"dead::/16"}`))
+		var out status
+		Expect(json.Unmarshal(b, &out)).To(Succeed())
+		Expect(out).To(Equal(in))
+	})
+})
+
+var _ = DescribeTable("netip conversion",
+	func(input, canonical string) {
+		if addr := FromString(input); addr != nil {
+			netipAddr := addr.AsNetIPAddr()
+			Expect(netipAddr.String()).To(Equal(canonical))
+			Expect(FromNetIPAddr(netipAddr)).To(Equal(addr))
+			Expect(FromNetIPAddr(netip.MustParseAddr(input))).To(Equal(addr))
+		}
+		cidr := MustParseCIDROrIP(input)
+		prefix := cidr.AsNetIPPrefix()
+		Expect(FromNetIPPrefix(prefix)).To(Equal(cidr))
+		Expect(cidr.String()).To(HavePrefix(canonical))
+	},
+	Entry("IPv4 address", "10.0.0.1", "10.0.0.1"),
+	Entry("IPv6 address", "dead:0:0::beef", "dead::beef"),
+	Entry("IPv4-mapped address", "::ffff:10.0.0.1", "10.0.0.1"),
+	Entry("IPv4 CIDR", "10.0.0.0/16", "10.0.0.0/16"),
+	Entry("IPv6 CIDR", "dead::/16", "dead::/16"),
+)
+
+var _ = Describe("netip edge cases", func() {
+	It("should mask host bits in a prefix", func() {
+		Expect(FromNetIPPrefix(netip.MustParsePrefix("10.0.0.1/16"))).To(Equal(MustParseCIDROrIP("10.0.0.0/16")))
+		Expect(FromNetIPPrefix(netip.MustParsePrefix("dead::beef/16"))).To(Equal(MustParseCIDROrIP("dead::/16")))
+	})
+
+	It("should return nil for invalid values", func() {
+		Expect(FromNetIPAddr(netip.Addr{})).To(BeNil())
+		Expect(FromNetIPPrefix(netip.Prefix{})).To(BeNil())
+	})
+})
+
+var _ = DescribeTable("Address arithmetic",
+	func(inputAddr string, op string, n uint64, expected string, expectedOK bool) {
+		addr := FromString(inputAddr)
+		var result Addr
+		var ok bool
+		switch op {
+		case "next":
+			result, ok = addr.Next()
+		case "prev":
+			result, ok = addr.Prev()
+		case "add":
+			result, ok = addr.Add(n)
+		}
+		Expect(result).To(Equal(FromString(expected)))
+		Expect(ok).To(Equal(expectedOK))
+	},
+	Entry("IPv4 next", "10.0.0.1", "next", uint64(0), "10.0.0.2", true),
+	Entry("IPv4 next carry", "10.0.0.255", "next", uint64(0), "10.0.1.0", true),
+	Entry("IPv4 next overflow", "255.255.255.255", "next", uint64(0), "0.0.0.0", false),
+	Entry("IPv4 prev", "10.0.1.0", "prev", uint64(0), "10.0.0.255", true),
+	Entry("IPv4 prev underflow", "0.0.0.0", "prev", uint64(0), "255.255.255.255", false),
+	Entry("IPv4 add", "10.0.0.1", "add", uint64(1000), "10.0.3.233", true),
+	Entry("IPv4 add zero", "10.0.0.1", "add", uint64(0), "10.0.0.1", true),
+	Entry("IPv4 add to last", "0.0.0.1", "add", uint64(0xfffffffe), "255.255.255.255", true),
+	Entry("IPv4 add overflow", "0.0.0.2", "add", uint64(0xfffffffe), "0.0.0.0", false),
+	Entry("IPv4 add huge", "10.0.0.1", "add", ^uint64(0), "10.0.0.0", false),
+	Entry("IPv6 next", "fc00::1", "next", uint64(0), "fc00::2", true),
+	Entry("IPv6 next carry", "fc00::ffff:ffff:ffff:ffff", "next", uint64(0), "fc00:0:0:1::", true),
+	Entry("IPv6 next overflow", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", "next", uint64(0), "::", false),
+	Entry("IPv6 prev", "fc00:0:0:1::", "prev", uint64(0), "fc00::ffff:ffff:ffff:ffff", true),
+	Entry("IPv6 prev underflow", "::", "prev", uint64(0), "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", false),
+	Entry("IPv6 add", "fc00::1", "add", uint64(0x10000), "fc00::1:1", true),
+	Entry("IPv6 add carry", "fc00::ffff:ffff:ffff:fff0", "add", uint64(0x20), "fc00:0:0:1::10", true),
+	Entry("IPv6 add overflow", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fff0", "add", uint64(0x20), "::10", false),
+)
+
+var _ = DescribeTable("CompareCIDRs",
+	func(a, b string, expected int) {
+		aCIDR := MustParseCIDROrIP(a)
+		bCIDR := MustParseCIDROrIP(b)
+		Expect(CompareCIDRs(aCIDR, bCIDR)).To(Equal(expected))
+		Expect(CompareCIDRs(bCIDR, aCIDR)).To(Equal(-expected))
+	},
+	Entry("IPv4 equal", "10.0.0.0/8", "10.0.0.0/8", 0),
+	Entry("IPv4 by address", "10.0.0.0/8", "11.0.0.0/8", -1),
+	Entry("IPv4 by address before prefix", "10.0.0.1/32", "10.0.0.2/31", -1),
+	Entry("IPv4 by prefix", "10.0.0.0/8", "10.0.0.0/16", -1),
+	Entry("IPv4 numeric not lexical", "9.0.0.0/8", "10.0.0.0/8", -1),
+	Entry("IPv4 before IPv6", "255.255.255.255/32", "::/0", -1),
+	Entry("IPv6 equal", "fc00::/64", "fc00::/64", 0),
+	Entry("IPv6 by address", "fc00::/64", "fc00:0:0:1::/64", -1),
+	Entry("IPv6 by prefix", "fc00::/64", "fc00::/65", -1),
+)
+
+var _ = Describe("SortCIDRs", func() {
+	It("should sort mixed IP versions", func() {
+		var cidrs []CIDR
+		for _, s := range []string{
+			"fc00::1/128", "10.0.1.0/24", "::/0", "10.0.0.0/16", "10.0.0.0/8", "9.0.0.0/8", "fc00::/64",
+		} {
+			cidrs = append(cidrs, MustParseCIDROrIP(s))
+		}
+		SortCIDRs(cidrs)
+		var sorted []string
+		for _, c := range cidrs {
+			sorted = append(sorted, c.String())
+		}
+		Expect(sorted).To(Equal([]string{
+			"9.0.0.0/8", "10.0.0.0/8", "10.0.0.0/16", "10.0.1.0/24", "::/0", "fc00::/64", "fc00::1/128",
+		}))
+	})
+})
+
+var _ = DescribeTable("Hash64",
+	func(input string) {
+		cidr := MustParseCIDROrIP(input)
+		addr := cidr.Addr()
+
+		// Should be the FNV-1a hash of the bytes.
+		h := fnv.New64a()
+		_, _ = h.Write(addr.AsNetIP())
+		Expect(addr.Hash64()).To(Equal(h.Sum64()))
+		_, _ = h.Write([]byte{cidr.Prefix()})
+		Expect(cidr.Hash64()).To(Equal(h.Sum64()))
+
+		// Should be sensitive to the prefix length.
+		Expect(cidr.Hash64()).NotTo(Equal(cidr.Supernet(1).Hash64()))
+		Expect(cidr.Hash64()).NotTo(Equal(addr.AsCIDR().Supernet(0).Hash64()))
+	},
+	Entry("IPv4", "10.0.0.0/16"),
+	Entry("IPv6", "fc00::/64"),
+)
+
+var _ = Describe("Hash64 stability", func() {
+	It("should return the same values across releases", func() {
+		Expect(MustParseCIDROrIP("10.0.0.0/16").Hash64()).To(Equal(uint64(0x63911f820a45f5fd)))
+		Expect(MustParseCIDROrIP("fc00::/64").Hash64()).To(Equal(uint64(0xbd5657ac59fe4d3b)))
+	})
+})
+
+var _ = DescribeTable("IPv4-mapped CIDR parsing",
+	func(input, expected string) {
+		expCIDR := MustParseCIDROrIP(expected)
+		Expect(MustParseCIDROrIP(input)).To(Equal(expCIDR))
+		Expect(ParseCIDRBytes([]byte(input))).To(Equal(expCIDR))
+		Expect(FromNetIPPrefix(netip.MustParsePrefix(input))).To(Equal(expCIDR))
+	},
+	Entry("/128", "::ffff:10.0.0.1/128", "10.0.0.1/32"),
+	Entry("/120", "::ffff:10.0.0.0/120", "10.0.0.0/24"),
+	Entry("/96", "::ffff:10.0.0.0/96", "0.0.0.0/0"),
+	Entry("/80 stays IPv6", "::ffff:10.0.0.0/80", "::/80"),
+)
+
+var _ = Describe("IPv4-mapped normalization", func() {
+	mapped := V6Addr{10: 0xff, 11: 0xff, 12: 10, 15: 1}
+
+	It("should detect mapped addresses", func() {
+		Expect(mapped.IsV4Mapped()).To(BeTrue())
+		Expect(FromString("fc00::ffff:a00:1").(V6Addr).IsV4Mapped()).To(BeFalse())
+		Expect(FromString("::a00:1").(V6Addr).IsV4Mapped()).To(BeFalse())
+		v4, ok := mapped.Unmap()
+		Expect(ok).To(BeTrue())
+		Expect(v4).To(Equal(FromString("10.0.0.1")))
+	})
+
+	It("should normalize mapped addresses", func() {
+		Expect(NormalizeMapped(mapped)).To(Equal(FromString("10.0.0.1")))
+		Expect(NormalizeMapped(FromString("fc00::1"))).To(Equal(FromString("fc00::1")))
+		Expect(NormalizeMapped(FromString("10.0.0.1"))).To(Equal(FromString("10.0.0.1")))
+	})
+
+	It("should normalize mapped CIDRs", func() {
+		Expect(NormalizeMappedCIDR(mapped.AsCIDR())).To(Equal(MustParseCIDROrIP("10.0.0.1/32")))
+		Expect(NormalizeMappedCIDR(MustParseCIDROrIP("::/80"))).To(Equal(MustParseCIDROrIP("::/80")))
+		Expect(NormalizeMappedCIDR(MustParseCIDROrIP("fc00::/64"))).To(Equal(MustParseCIDROrIP("fc00::/64")))
+		Expect(NormalizeMappedCIDR(MustParseCIDROrIP("10.0.0.0/8"))).To(Equal(MustParseCIDROrIP("10.0.0.0/8")))
+	})
+})
+
+var _ = Describe("Address classification", func() {
+	type classification struct {
+		Unspecified, Loopback, Private, LinkLocalUnicast, Multicast, LinkLocalMulticast,
+		InterfaceLocalMulticast, GlobalUnicast bool
+	}
+	classify := func(a Addr) classification {
+		return classification{
+			a.IsUnspecified(), a.IsLoopback(), a.IsPrivate(), a.IsLinkLocalUnicast(), a.IsMulticast(),
+			a.IsLinkLocalMulticast(), a.IsInterfaceLocalMulticast(), a.IsGlobalUnicast(),
+		}
+	}
+	classifyNetIP := func(a netip.Addr) classification {
+		return classification{
+			a.IsUnspecified(), a.IsLoopback(), a.IsPrivate(), a.IsLinkLocalUnicast(), a.IsMulticast(),
+			a.IsLinkLocalMulticast(), a.IsInterfaceLocalMulticast(), a.IsGlobalUnicast(),
+		}
+	}
+	mapped := func(v4 V4Addr) V6Addr {
+		return V6Addr{10: 0xff, 11: 0xff, 12: v4[0], 13: v4[1], 14: v4[2], 15: v4[3]}
+	}
+
+	DescribeTable("should match netip for well-known addresses",
+		func(addr string) {
+			a := FromString(addr)
+			Expect(classify(a)).To(Equal(classifyNetIP(netip.MustParseAddr(addr))))
+			if v4, ok := a.(V4Addr); ok {
+				m := mapped(v4)
+				Expect(classify(m)).To(Equal(classifyNetIP(netip.AddrFrom16(m))), "mapped form")
+			}
+		},
+		Entry("0.0.0.0", "0.0.0.0"),
+		Entry("127.0.0.1", "127.0.0.1"),
+		Entry("10.1.2.3", "10.1.2.3"),
+		Entry("172.16.0.1", "172.16.0.1"),
+		Entry("172.31.255.255", "172.31.255.255"),
+		Entry("172.32.0.0", "172.32.0.0"),
+		Entry("192.168.5.5", "192.168.5.5"),
+		Entry("169.254.169.254", "169.254.169.254"),
+		Entry("224.0.0.5", "224.0.0.5"),
+		Entry("239.1.1.1", "239.1.1.1"),
+		Entry("255.255.255.255", "255.255.255.255"),
+		Entry("8.8.8.8", "8.8.8.8"),
+		Entry("::", "::"),
+		Entry("::1", "::1"),
+		Entry("fc00::1", "fc00::1"),
+		Entry("fd12:3456::1", "fd12:3456::1"),
+		Entry("fe80::1", "fe80::1"),
+		Entry("febf::1", "febf::1"),
+		Entry("fec0::1", "fec0::1"),
+		Entry("ff01::1", "ff01::1"),
+		Entry("ff02::1:ff00:1", "ff02::1:ff00:1"),
+		Entry("ff12::1", "ff12::1"),
+		Entry("ff05::2", "ff05::2"),
+		Entry("2001:db8::1", "2001:db8::1"),
+	)
+
+	It("should match netip for random addresses", func() {
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 10000; i++ {
+			var v4 V4Addr
+			rng.Read(v4[:])
+			Expect(classify(v4)).To(Equal(classifyNetIP(v4.AsNetIPAddr())), v4.String())
+			Expect(classify(mapped(v4))).To(Equal(classifyNetIP(netip.AddrFrom16(mapped(v4)))), v4.String())
+
+			var v6 V6Addr
+			rng.Read(v6[:])
+			// Bias towards the interesting prefixes.
+			v6[0] = []byte{0xfc, 0xfd, 0xfe, 0xff, v6[0]}[rng.Intn(5)]
+			Expect(classify(v6)).To(Equal(classifyNetIP(v6.AsNetIPAddr())), v6.String())
+		}
+	})
+})
+
+var _ = DescribeTable("CIDR network and Nth addresses",
+	func(cidr string, network string, nth map[uint64]string) {
+		c := MustParseCIDROrIP(cidr)
+		Expect(c.NetworkAddr().String()).To(Equal(network))
+		for n, expected := range nth {
+			addr, ok := c.NthAddr(n)
+			if expected == "" {
+				Expect(ok).To(BeFalse(), fmt.Sprint(n))
+				Expect(addr).To(BeNil())
+				continue
+			}
+			Expect(ok).To(BeTrue(), fmt.Sprint(n))
+			Expect(addr.String()).To(Equal(expected))
+		}
+	},
+	Entry("IPv4 /24", "10.0.1.0/24", "10.0.1.0", map[uint64]string{0: "10.0.1.0", 1: "10.0.1.1", 255: "10.0.1.255", 256: ""}),
+	Entry("IPv4 /32", "10.0.1.5/32", "10.0.1.5", map[uint64]string{0: "10.0.1.5", 1: ""}),
+	Entry("IPv4 /0", "0.0.0.0/0", "0.0.0.0", map[uint64]string{1 << 32: "", 1<<32 - 1: "255.255.255.255"}),
+	Entry("IPv6 /120", "fd00::/120", "fd00::", map[uint64]string{1: "fd00::1", 255: "fd00::ff", 256: ""}),
+	Entry("IPv6 /64", "fd00::/64", "fd00::", map[uint64]string{1 << 63: "fd00::8000:0:0:0", 1<<64 - 1: "fd00::ffff:ffff:ffff:ffff"}),
+	Entry("IPv6 /0", "::/0", "::", map[uint64]string{1<<64 - 1: "::ffff:ffff:ffff:ffff"}),
+)
+
+var _ = DescribeTable("V4CIDR.BroadcastAddr",
+	func(cidr string, expected string) {
+		Expect(MustParseCIDROrIP(cidr).(V4CIDR).BroadcastAddr().String()).To(Equal(expected))
+	},
+	Entry("/24", "10.0.1.0/24", "10.0.1.255"),
+	Entry("/22", "10.0.4.0/22", "10.0.7.255"),
+	Entry("/31", "10.0.0.0/31", "10.0.0.1"),
+	Entry("/32", "10.0.0.7/32", "10.0.0.7"),
+	Entry("/0", "0.0.0.0/0", "255.255.255.255"),
+)
+
+var _ = Describe("String formatting", func() {
+	It("should match net.IP for random addresses and CIDRs", func() {
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 10000; i++ {
+			var v4 V4Addr
+			rng.Read(v4[:])
+			Expect(v4.String()).To(Equal(net.IP(v4[:]).String()))
+			v4CIDR := CIDRFromAddrAndPrefix(v4, rng.Intn(33))
+			v4Net := v4CIDR.ToIPNet()
+			Expect(v4CIDR.String()).To(Equal(v4Net.String()))
+
+			var v6 V6Addr
+			rng.Read(v6[:])
+			// Zero out some of the 16-bit groups so that we exercise the "::" compression.
+			for g := 0; g < 8; g++ {
+				if rng.Intn(2) == 0 {
+					v6[g*2], v6[g*2+1] = 0, 0
+				}
+			}
+			Expect(v6.String()).To(Equal(net.IP(v6[:]).String()))
+			v6CIDR := CIDRFromAddrAndPrefix(v6, rng.Intn(129))
+			v6Net := v6CIDR.ToIPNet()
+			Expect(v6CIDR.String()).To(Equal(v6Net.String()))
+
+			mappedV4 := V6Addr{10: 0xff, 11: 0xff}
+			copy(mappedV4[12:], v4[:])
+			Expect(mappedV4.String()).To(Equal(net.IP(mappedV4[:]).String()))
+		}
+	})
+
+	It("should append to an existing buffer", func() {
+		b := []byte("members: ")
+		b = MustParseCIDROrIP("10.0.0.0/8").AppendString(b)
+		b = append(b, ' ')
+		b = MustParseCIDROrIP("fd00::1").AppendString(b)
+		b = append(b, ' ')
+		b = FromString("::ffff:10.0.0.1").AppendString(b)
+		Expect(string(b)).To(Equal("members: 10.0.0.0/8 fd00::1/128 10.0.0.1"))
+	})
+
+	It("should not allocate when appending to a buffer with spare capacity", func() {
+		buf := make([]byte, 0, 64)
+		v4 := MustParseCIDROrIP("10.0.0.0/8")
+		v6 := MustParseCIDROrIP("2001:db8::1:0:0:1/96")
+		Expect(testing.AllocsPerRun(100, func() {
+			buf = v4.AppendString(buf[:0])
+			buf = v6.AppendString(buf[:0])
+		})).To(BeZero())
+	})
+})
@@ -0,0 +1,162 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"errors"
+	"fmt"
+)
+
+// This file implements encoding.TextMarshaler/TextUnmarshaler and encoding.BinaryMarshaler/
+// BinaryUnmarshaler for the concrete address and CIDR types.  The text form is the same as String() and
+// the JSON encoding.  The binary form is the address in network byte order (4 or 16 bytes), followed,
+// for CIDRs, by the prefix length as a single byte; this matches the per-entry encoding used by
+// Trie.WriteBinary.
+
+var ErrBadBinaryEncoding = errors.New("invalid binary encoding")
+
+func (a V4Addr) MarshalText() ([]byte, error) {
+	return a.AppendString(nil), nil
+}
+
+func (a *V4Addr) UnmarshalText(text []byte) error {
+	addr, err := ParseAddrBytes(text)
+	if err != nil {
+		return err
+	}
+	v4Addr, ok := addr.(V4Addr)
+	if !ok {
+		return fmt.Errorf("expected an IPv4 address, not %v", addr)
+	}
+	*a = v4Addr
+	return nil
+}
+
+func (a V4Addr) MarshalBinary() ([]byte, error) {
+	return append([]byte(nil), a[:]...), nil
+}
+
+func (a *V4Addr) UnmarshalBinary(data []byte) error {
+	if len(data) != len(a) {
+		return fmt.Errorf("%w: IPv4 address must be %d bytes, not %d", ErrBadBinaryEncoding, len(a), len(data))
+	}
+	copy(a[:], data)
+	return nil
+}
+
+func (a V6Addr) MarshalText() ([]byte, error) {
+	return a.AppendString(nil), nil
+}
+
+func (a *V6Addr) UnmarshalText(text []byte) error {
+	addr, err := ParseAddrBytes(text)
+	if err != nil {
+		return err
+	}
+	v6Addr, ok := addr.(V6Addr)
+	if !ok {
+		return fmt.Errorf("expected an IPv6 address, not %v", addr)
+	}
+	*a = v6Addr
+	return nil
+}
+
+func (a V6Addr) MarshalBinary() ([]byte, error) {
+	return append([]byte(nil), a[:]...), nil
+}
+
+func (a *V6Addr) UnmarshalBinary(data []byte) error {
+	if len(data) != len(a) {
+		return fmt.Errorf("%w: IPv6 address must be %d bytes, not %d", ErrBadBinaryEncoding, len(a), len(data))
+	}
+	copy(a[:], data)
+	return nil
+}
+
+func (c V4CIDR) MarshalText() ([]byte, error) {
+	return c.AppendString(nil), nil
+}
+
+func (c *V4CIDR) UnmarshalText(text []byte) error {
+	cidr, err := ParseCIDRBytes(text)
+	if err != nil {
+		return err
+	}
+	v4CIDR, ok := cidr.(V4CIDR)
+	if !ok {
+		return fmt.Errorf("expected an IPv4 CIDR, not %v", cidr)
+	}
+	*c = v4CIDR
+	return nil
+}
+
+func (c V4CIDR) MarshalBinary() ([]byte, error) {
+	return append(c.addr[:len(c.addr):len(c.addr)], c.prefix), nil
+}
+
+func (c *V4CIDR) UnmarshalBinary(data []byte) error {
+	var v4CIDR V4CIDR
+	if len(data) != len(v4CIDR.addr)+1 {
+		return fmt.Errorf("%w: IPv4 CIDR must be %d bytes, not %d", ErrBadBinaryEncoding, len(v4CIDR.addr)+1, len(data))
+	}
+	copy(v4CIDR.addr[:], data)
+	v4CIDR.prefix = data[len(v4CIDR.addr)]
+	if v4CIDR.prefix > 32 {
+		return fmt.Errorf("%w: IPv4 prefix length %d", ErrBadBinaryEncoding, v4CIDR.prefix)
+	}
+	if V4CommonPrefix(v4CIDR, v4CIDR) != v4CIDR {
+		return fmt.Errorf("%w: %v", ErrNonCanonicalCIDR, v4CIDR)
+	}
+	*c = v4CIDR
+	return nil
+}
+
+func (c V6CIDR) MarshalText() ([]byte, error) {
+	return c.AppendString(nil), nil
+}
+
+func (c *V6CIDR) UnmarshalText(text []byte) error {
+	cidr, err := ParseCIDRBytes(text)
+	if err != nil {
+		return err
+	}
+	v6CIDR, ok := cidr.(V6CIDR)
+	if !ok {
+		return fmt.Errorf("expected an IPv6 CIDR, not %v", cidr)
+	}
+	*c = v6CIDR
+	return nil
+}
+
+func (c V6CIDR) MarshalBinary() ([]byte, error) {
+	return append(c.addr[:len(c.addr):len(c.addr)], c.prefix), nil
+}
+
+func (c *V6CIDR) UnmarshalBinary(data []byte) error {
+	var v6CIDR V6CIDR
+	if len(data) != len(v6CIDR.addr)+1 {
+		return fmt.Errorf("%w: IPv6 CIDR must be %d bytes, not %d", ErrBadBinaryEncoding, len(v6CIDR.addr)+1, len(data))
+	}
+	copy(v6CIDR.addr[:], data)
+	v6CIDR.prefix = data[len(v6CIDR.addr)]
+	if v6CIDR.prefix > 128 {
+		return fmt.Errorf("%w: IPv6 prefix length %d", ErrBadBinaryEncoding, v6CIDR.prefix)
+	}
+	if V6CommonPrefix(v6CIDR, v6CIDR) != v6CIDR {
+		return fmt.Errorf("%w: %v", ErrNonCanonicalCIDR, v6CIDR)
+	}
+	*c = v6CIDR
+	return nil
+}
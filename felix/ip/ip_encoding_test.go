@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = DescribeTable("Text and binary round trip",
+	func(input interface{}, expectedText string, expectedBinary []byte, output interface{}) {
+		text, err := input.(encoding.TextMarshaler).MarshalText()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(text)).To(Equal(expectedText))
+		Expect(output.(encoding.TextUnmarshaler).UnmarshalText(text)).To(Succeed())
+		Expect(reflect.ValueOf(output).Elem().Interface()).To(Equal(input))
+
+		reflect.ValueOf(output).Elem().Set(reflect.Zero(reflect.TypeOf(input)))
+		bin, err := input.(encoding.BinaryMarshaler).MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bin).To(Equal(expectedBinary))
+		Expect(output.(encoding.BinaryUnmarshaler).UnmarshalBinary(bin)).To(Succeed())
+		Expect(reflect.ValueOf(output).Elem().Interface()).To(Equal(input))
+	},
+	Entry("V4Addr", ip.FromString("10.0.0.1").(ip.V4Addr), "10.0.0.1",
+		[]byte{10, 0, 0, 1}, new(ip.V4Addr)),
+	Entry("V6Addr", ip.FromString("fd00::1").(ip.V6Addr), "fd00::1",
+		[]byte{0xfd, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, new(ip.V6Addr)),
+	Entry("V4CIDR", ip.MustParseCIDROrIP("10.1.0.0/16").(ip.V4CIDR), "10.1.0.0/16",
+		[]byte{10, 1, 0, 0, 16}, new(ip.V4CIDR)),
+	Entry("V6CIDR", ip.MustParseCIDROrIP("fd00::/8").(ip.V6CIDR), "fd00::/8",
+		[]byte{0xfd, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 8}, new(ip.V6CIDR)),
+)
+
+var _ = DescribeTable("Text decode failures",
+	func(input string, output encoding.TextUnmarshaler) {
+		Expect(output.UnmarshalText([]byte(input))).NotTo(Succeed())
+	},
+	Entry("V4Addr bad IP", "10.0.0.300", new(ip.V4Addr)),
+	Entry("V4Addr wrong version", "dead::beef", new(ip.V4Addr)),
+	Entry("V6Addr wrong version", "10.0.0.1", new(ip.V6Addr)),
+	Entry("V4CIDR bad CIDR", "10.0.0.0/33", new(ip.V4CIDR)),
+	Entry("V4CIDR wrong version", "dead::/16", new(ip.V4CIDR)),
+	Entry("V6CIDR wrong version", "10.0.0.0/16", new(ip.V6CIDR)),
+)
+
+var _ = DescribeTable("Binary decode failures",
+	func(input []byte, output encoding.BinaryUnmarshaler, expectedErr error) {
+		Expect(output.UnmarshalBinary(input)).To(MatchError(expectedErr))
+	},
+	Entry("V4Addr too short", []byte{10, 0, 0}, new(ip.V4Addr), ip.ErrBadBinaryEncoding),
+	Entry("V6Addr too short", []byte{10, 0, 0, 1}, new(ip.V6Addr), ip.ErrBadBinaryEncoding),
+	Entry("V4CIDR too long", []byte{10, 0, 0, 0, 8, 0}, new(ip.V4CIDR), ip.ErrBadBinaryEncoding),
+	Entry("V4CIDR bad prefix", []byte{10, 0, 0, 0, 33}, new(ip.V4CIDR), ip.ErrBadBinaryEncoding),
+	Entry("V4CIDR non-canonical", []byte{10, 0, 0, 1, 8}, new(ip.V4CIDR), ip.ErrNonCanonicalCIDR),
+	Entry("V6CIDR bad prefix", append(make([]byte, 16), 129), new(ip.V6CIDR), ip.ErrBadBinaryEncoding),
+	Entry("V6CIDR non-canonical", append(append([]byte{0xfd}, make([]byte, 15)...), 4), new(ip.V6CIDR), ip.ErrNonCanonicalCIDR),
+)
+
+var _ = Describe("Encoding in containers", func() {
+	It("should allow addresses and CIDRs as JSON map keys", func() {
+		in := map[ip.V4CIDR]ip.V4Addr{
+			ip.MustParseCIDROrIP("10.0.0.0/8").(ip.V4CIDR): ip.FromString("10.0.0.1").(ip.V4Addr),
+		}
+		b, err := json.Marshal(in)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`{"10.0.0.0/8":"10.0.0.1"}`))
+		var out map[ip.V4CIDR]ip.V4Addr
+		Expect(json.Unmarshal(b, &out)).To(Succeed())
+		Expect(out).To(Equal(in))
+	})
+
+	It("should round trip through gob", func() {
+		type snapshot struct {
+			Addr   ip.V6Addr
+			Routes map[ip.V6CIDR]ip.V6Addr
+		}
+		in := snapshot{
+			Addr: ip.FromString("fd00::1").(ip.V6Addr),
+			Routes: map[ip.V6CIDR]ip.V6Addr{
+				ip.MustParseCIDROrIP("fd00:1::/64").(ip.V6CIDR): ip.FromString("fd00::2").(ip.V6Addr),
+			},
+		}
+		var buf bytes.Buffer
+		Expect(gob.NewEncoder(&buf).Encode(in)).To(Succeed())
+		var out snapshot
+		Expect(gob.NewDecoder(&buf).Decode(&out)).To(Succeed())
+		Expect(out).To(Equal(in))
+	})
+})
@@ -0,0 +1,250 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ErrInvalidAddrRange = errors.New("invalid address range")
+
+// AddrRange is an inclusive range of addresses, from Start to End.  Both addresses must be of the same IP
+// version and Start must not be after End; see IsValid.  Ranges that don't line up with CIDR boundaries
+// are common in network sets and threat feeds, and keeping them as ranges avoids expanding them into
+// long CIDR lists until that's actually needed.
+type AddrRange struct {
+	Start, End Addr
+}
+
+// ParseAddrRange parses an address range of the form "10.0.0.1-10.0.0.9".  A single address is accepted
+// as a range of one address.  On failure, it returns an error wrapping ErrInvalidAddrRange.
+func ParseAddrRange(s string) (AddrRange, error) {
+	startStr, endStr, found := strings.Cut(s, "-")
+	if !found {
+		endStr = startStr
+	}
+	r := AddrRange{
+		Start: FromString(strings.TrimSpace(startStr)),
+		End:   FromString(strings.TrimSpace(endStr)),
+	}
+	if r.Start == nil || r.End == nil {
+		return AddrRange{}, fmt.Errorf("%w: failed to parse %q", ErrInvalidAddrRange, s)
+	}
+	if !r.IsValid() {
+		return AddrRange{}, fmt.Errorf("%w: %q has mismatched IP versions or is backwards", ErrInvalidAddrRange, s)
+	}
+	return r, nil
+}
+
+// AddrRangeFromCIDR returns the range of addresses in the given CIDR.
+func AddrRangeFromCIDR(cidr CIDR) AddrRange {
+	width := uint(32)
+	if cidr.Version() == 6 {
+		width = 128
+	}
+	start := addrToUint128(cidr.NetworkAddr())
+	hostMask := uint128Max.Rsh(128 - (width - uint(cidr.Prefix())))
+	return AddrRange{
+		Start: cidr.NetworkAddr(),
+		End:   addrFromUint128(start.Or(hostMask), cidr.Version()),
+	}
+}
+
+// AddrRangesFromCIDRs returns the minimal list of disjoint ranges that covers the addresses in the given
+// CIDRs.  Overlapping and adjacent CIDRs are merged into a single range.  The result is sorted with
+// IPv4 ranges first, then IPv6, each in address order.
+func AddrRangesFromCIDRs(cidrs []CIDR) []AddrRange {
+	var ranges []AddrRange
+	for _, cidr := range Aggregate(cidrs) {
+		r := AddrRangeFromCIDR(cidr)
+		if len(ranges) > 0 {
+			last := &ranges[len(ranges)-1]
+			if next, ok := last.End.Next(); ok && next == r.Start {
+				last.End = r.End
+				continue
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// MergeAddrRanges returns the minimal list of disjoint ranges that covers the same addresses as the
+// input, merging ranges that overlap or are adjacent.  The result is sorted with IPv4 ranges first,
+// then IPv6, each in address order.  Invalid ranges are ignored.
+func MergeAddrRanges(ranges []AddrRange) []AddrRange {
+	var sorted []AddrRange
+	for _, r := range ranges {
+		if r.IsValid() {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Version() != sorted[j].Version() {
+			return sorted[i].Version() < sorted[j].Version()
+		}
+		return addrToUint128(sorted[i].Start).Compare(addrToUint128(sorted[j].Start)) < 0
+	})
+	var merged []AddrRange
+	for _, r := range sorted {
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			if last.Version() == r.Version() && last.touches(r) {
+				if addrToUint128(r.End).Compare(addrToUint128(last.End)) > 0 {
+					last.End = r.End
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// touches returns true if other starts within, or immediately after, r.  It assumes that other doesn't
+// start before r.
+func (r AddrRange) touches(other AddrRange) bool {
+	next, ok := r.End.Next()
+	return !ok || addrToUint128(other.Start).Compare(addrToUint128(next)) <= 0
+}
+
+// Version returns the IP version of the range's addresses.
+func (r AddrRange) Version() uint8 {
+	return r.Start.Version()
+}
+
+// IsValid returns true if the range has both addresses set, of the same IP version, with Start not after
+// End.
+func (r AddrRange) IsValid() bool {
+	return r.Start != nil && r.End != nil &&
+		r.Start.Version() == r.End.Version() &&
+		addrToUint128(r.Start).Compare(addrToUint128(r.End)) <= 0
+}
+
+func (r AddrRange) String() string {
+	return fmt.Sprintf("%v-%v", r.Start, r.End)
+}
+
+// Contains returns true if the given address is of the same IP version and within the range.
+func (r AddrRange) Contains(addr Addr) bool {
+	if addr.Version() != r.Version() {
+		return false
+	}
+	a := addrToUint128(addr)
+	return addrToUint128(r.Start).Compare(a) <= 0 && a.Compare(addrToUint128(r.End)) <= 0
+}
+
+// ContainsRange returns true if other is of the same IP version and entirely within this range.
+func (r AddrRange) ContainsRange(other AddrRange) bool {
+	return r.Contains(other.Start) && r.Contains(other.End)
+}
+
+// Overlaps returns true if the two ranges are of the same IP version and have at least one address in
+// common.
+func (r AddrRange) Overlaps(other AddrRange) bool {
+	_, ok := r.Intersect(other)
+	return ok
+}
+
+// Intersect returns the range of addresses that are in both ranges.  ok is false if the ranges don't
+// overlap (or are of different IP versions).
+func (r AddrRange) Intersect(other AddrRange) (intersection AddrRange, ok bool) {
+	if r.Version() != other.Version() {
+		return
+	}
+	intersection = r
+	if addrToUint128(other.Start).Compare(addrToUint128(r.Start)) > 0 {
+		intersection.Start = other.Start
+	}
+	if addrToUint128(other.End).Compare(addrToUint128(r.End)) < 0 {
+		intersection.End = other.End
+	}
+	if !intersection.IsValid() {
+		return AddrRange{}, false
+	}
+	return intersection, true
+}
+
+// ToCIDRs returns the minimal list of CIDRs that covers exactly the range, in address order.
+func (r AddrRange) ToCIDRs() []CIDR {
+	return RangeToCIDRs(r.Start, r.End)
+}
+
+func addrFromUint128(u Uint128, ipVersion uint8) Addr {
+	if ipVersion == 4 {
+		return u.toV4Addr()
+	}
+	return V6AddrFromUint128(u)
+}
+
+// RangeToCIDRs converts the inclusive range of addresses from start to end into the minimal list of
+// CIDRs that covers exactly that range, in address order.  Returns nil if start is after end.  The
+// addresses must be of the same IP version.
+func RangeToCIDRs(start, end Addr) []CIDR {
+	if start.Version() != end.Version() {
+		logrus.WithFields(logrus.Fields{"start": start, "end": end}).Panic("Mismatched IP versions")
+	}
+	width := 32
+	if start.Version() == 6 {
+		width = 128
+	}
+
+	s, e := addrToUint128(start), addrToUint128(end)
+	if s.Compare(e) > 0 {
+		return nil
+	}
+
+	var cidrs []CIDR
+	for {
+		// Find the largest block that starts at s (so its size is limited by the alignment of s) and
+		// fits within the remaining range.
+		blockBits := s.TrailingZeros()
+		if blockBits > width {
+			blockBits = width
+		}
+		remaining := e.Sub(s)
+		var rangeBits int
+		if width == 128 && remaining == uint128Max {
+			// Full range; remaining+1 would overflow.
+			rangeBits = 128
+		} else {
+			rangeBits = remaining.Add(Uint128{Lo: 1}).BitLen() - 1
+		}
+		if rangeBits < blockBits {
+			blockBits = rangeBits
+		}
+
+		prefixLen := uint8(width - blockBits)
+		if width == 32 {
+			cidrs = append(cidrs, V4CIDR{addr: s.toV4Addr(), prefix: prefixLen})
+		} else {
+			cidrs = append(cidrs, V6CIDR{addr: V6AddrFromUint128(s), prefix: prefixLen})
+		}
+
+		if blockBits == width {
+			return cidrs
+		}
+		lastInBlock := s.Add(Uint128{Lo: 1}.Lsh(uint(blockBits)).Sub(Uint128{Lo: 1}))
+		if lastInBlock == e {
+			return cidrs
+		}
+		s = lastInBlock.Add(Uint128{Lo: 1})
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = DescribeTable("RangeToCIDRs",
+	func(start, end string, expected []string) {
+		var actual []string
+		for _, c := range ip.RangeToCIDRs(ip.FromString(start), ip.FromString(end)) {
+			actual = append(actual, c.String())
+		}
+		Expect(actual).To(Equal(expected))
+	},
+	Entry("single IPv4 address", "10.0.0.1", "10.0.0.1", []string{"10.0.0.1/32"}),
+	Entry("reversed", "10.0.0.2", "10.0.0.1", nil),
+	Entry("aligned IPv4 block", "10.0.0.0", "10.0.0.255", []string{"10.0.0.0/24"}),
+	Entry("unaligned IPv4 range", "10.0.0.1", "10.0.0.10", []string{
+		"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/30", "10.0.0.8/31", "10.0.0.10/32",
+	}),
+	Entry("IPv4 range crossing octets", "10.0.0.254", "10.0.1.1", []string{"10.0.0.254/31", "10.0.1.0/31"}),
+	Entry("all of IPv4", "0.0.0.0", "255.255.255.255", []string{"0.0.0.0/0"}),
+	Entry("top of IPv4", "255.255.255.254", "255.255.255.255", []string{"255.255.255.254/31"}),
+	Entry("IPv4 almost everything", "0.0.0.1", "255.255.255.255", []string{
+		"0.0.0.1/32", "0.0.0.2/31", "0.0.0.4/30", "0.0.0.8/29", "0.0.0.16/28", "0.0.0.32/27", "0.0.0.64/26",
+		"0.0.0.128/25", "0.0.1.0/24", "0.0.2.0/23", "0.0.4.0/22", "0.0.8.0/21", "0.0.16.0/20", "0.0.32.0/19",
+		"0.0.64.0/18", "0.0.128.0/17", "0.1.0.0/16", "0.2.0.0/15", "0.4.0.0/14", "0.8.0.0/13", "0.16.0.0/12",
+		"0.32.0.0/11", "0.64.0.0/10", "0.128.0.0/9", "1.0.0.0/8", "2.0.0.0/7", "4.0.0.0/6", "8.0.0.0/5",
+		"16.0.0.0/4", "32.0.0.0/3", "64.0.0.0/2", "128.0.0.0/1",
+	}),
+	Entry("single IPv6 address", "fc00::1", "fc00::1", []string{"fc00::1/128"}),
+	Entry("unaligned IPv6 range", "fc00::1", "fc00::6", []string{"fc00::1/128", "fc00::2/127", "fc00::4/127", "fc00::6/128"}),
+	Entry("IPv6 range crossing 64-bit boundary", "fc00::ffff:ffff:ffff:ffff", "fc00:0:0:1::", []string{
+		"fc00::ffff:ffff:ffff:ffff/128", "fc00:0:0:1::/128",
+	}),
+	Entry("aligned IPv6 /64", "fc00::", "fc00::ffff:ffff:ffff:ffff", []string{"fc00::/64"}),
+	Entry("all of IPv6", "::", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", []string{"::/0"}),
+	Entry("top of IPv6", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+		[]string{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/127"}),
+)
+
+func mustParseAddrRange(s string) ip.AddrRange {
+	r, err := ip.ParseAddrRange(s)
+	Expect(err).NotTo(HaveOccurred())
+	return r
+}
+
+var _ = DescribeTable("ParseAddrRange",
+	func(input, expected string) {
+		r, err := ip.ParseAddrRange(input)
+		if expected == "" {
+			Expect(err).To(MatchError(ip.ErrInvalidAddrRange))
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.String()).To(Equal(expected))
+	},
+	Entry("IPv4 range", "10.0.0.1-10.0.0.9", "10.0.0.1-10.0.0.9"),
+	Entry("with spaces", "10.0.0.1 - 10.0.0.9", "10.0.0.1-10.0.0.9"),
+	Entry("single address", "10.0.0.1", "10.0.0.1-10.0.0.1"),
+	Entry("IPv6 range", "fd00::1-fd00::ff", "fd00::1-fd00::ff"),
+	Entry("backwards", "10.0.0.9-10.0.0.1", ""),
+	Entry("mixed versions", "10.0.0.1-fd00::1", ""),
+	Entry("bad address", "10.0.0.1-10.0.0.256", ""),
+	Entry("CIDR", "10.0.0.0/24", ""),
+)
+
+var _ = DescribeTable("AddrRange set operations",
+	func(a, b string, overlaps bool, intersection string, aContainsB bool) {
+		ra, rb := mustParseAddrRange(a), mustParseAddrRange(b)
+		Expect(ra.Overlaps(rb)).To(Equal(overlaps))
+		Expect(rb.Overlaps(ra)).To(Equal(overlaps))
+		i, ok := ra.Intersect(rb)
+		Expect(ok).To(Equal(overlaps))
+		if ok {
+			Expect(i.String()).To(Equal(intersection))
+		}
+		Expect(ra.ContainsRange(rb)).To(Equal(aContainsB))
+	},
+	Entry("disjoint", "10.0.0.1-10.0.0.9", "10.0.0.10-10.0.0.20", false, "", false),
+	Entry("touching", "10.0.0.1-10.0.0.10", "10.0.0.10-10.0.0.20", true, "10.0.0.10-10.0.0.10", false),
+	Entry("partial overlap", "10.0.0.1-10.0.0.15", "10.0.0.10-10.0.0.20", true, "10.0.0.10-10.0.0.15", false),
+	Entry("contained", "10.0.0.1-10.0.0.30", "10.0.0.10-10.0.0.20", true, "10.0.0.10-10.0.0.20", true),
+	Entry("equal", "fd00::1-fd00::5", "fd00::1-fd00::5", true, "fd00::1-fd00::5", true),
+	Entry("mixed versions", "0.0.0.0-255.255.255.255", "::-::ffff", false, "", false),
+)
+
+var _ = DescribeTable("AddrRangeFromCIDR",
+	func(cidr, expected string) {
+		r := ip.AddrRangeFromCIDR(ip.MustParseCIDROrIP(cidr))
+		Expect(r.String()).To(Equal(expected))
+		Expect(r.ToCIDRs()).To(Equal([]ip.CIDR{ip.MustParseCIDROrIP(cidr)}))
+	},
+	Entry("IPv4 /24", "10.0.0.0/24", "10.0.0.0-10.0.0.255"),
+	Entry("IPv4 /32", "10.0.0.1/32", "10.0.0.1-10.0.0.1"),
+	Entry("IPv4 /0", "0.0.0.0/0", "0.0.0.0-255.255.255.255"),
+	Entry("IPv6 /64", "fd00::/64", "fd00::-fd00::ffff:ffff:ffff:ffff"),
+	Entry("IPv6 /0", "::/0", "::-ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"),
+)
+
+var _ = Describe("AddrRange conversions", func() {
+	It("should merge adjacent CIDRs into ranges", func() {
+		var cidrs []ip.CIDR
+		for _, c := range []string{"10.0.0.3/32", "fd00::/127", "10.0.0.4/30", "10.0.0.8/32", "10.0.1.0/24", "fd00::2/128"} {
+			cidrs = append(cidrs, ip.MustParseCIDROrIP(c))
+		}
+		Expect(ip.AddrRangesFromCIDRs(cidrs)).To(Equal([]ip.AddrRange{
+			mustParseAddrRange("10.0.0.3-10.0.0.8"),
+			mustParseAddrRange("10.0.1.0-10.0.1.255"),
+			mustParseAddrRange("fd00::-fd00::2"),
+		}))
+	})
+
+	It("should merge overlapping and adjacent ranges", func() {
+		Expect(ip.MergeAddrRanges([]ip.AddrRange{
+			mustParseAddrRange("fd00::5-fd00::9"),
+			mustParseAddrRange("10.0.0.20-10.0.0.30"),
+			mustParseAddrRange("10.0.0.1-10.0.0.10"),
+			mustParseAddrRange("10.0.0.5-10.0.0.7"),
+			mustParseAddrRange("10.0.0.11-10.0.0.12"),
+			mustParseAddrRange("fd00::1-fd00::5"),
+			mustParseAddrRange("200.0.0.0-255.255.255.255"),
+			mustParseAddrRange("210.0.0.0-255.255.255.255"),
+			{Start: ip.FromString("10.0.0.9"), End: ip.FromString("10.0.0.1")},
+		})).To(Equal([]ip.AddrRange{
+			mustParseAddrRange("10.0.0.1-10.0.0.12"),
+			mustParseAddrRange("10.0.0.20-10.0.0.30"),
+			mustParseAddrRange("200.0.0.0-255.255.255.255"),
+			mustParseAddrRange("fd00::1-fd00::9"),
+		}))
+	})
+
+	It("should agree with a brute force check on random IPv4 ranges", func() {
+		rng := rand.New(rand.NewSource(1))
+		randRange := func() ip.AddrRange {
+			a, b := uint32(rng.Intn(64)), uint32(rng.Intn(64))
+			if a > b {
+				a, b = b, a
+			}
+			start, _ := ip.FromString("10.0.0.0").Add(uint64(a))
+			end, _ := ip.FromString("10.0.0.0").Add(uint64(b))
+			return ip.AddrRange{Start: start, End: end}
+		}
+		for i := 0; i < 1000; i++ {
+			a, b := randRange(), randRange()
+			var common []string
+			for n := uint64(0); n < 64; n++ {
+				addr, _ := ip.FromString("10.0.0.0").Add(n)
+				if a.Contains(addr) && b.Contains(addr) {
+					common = append(common, addr.String())
+				}
+			}
+			isect, ok := a.Intersect(b)
+			Expect(ok).To(Equal(len(common) > 0), "%v %v", a, b)
+			if ok {
+				Expect(isect.Start.String()).To(Equal(common[0]))
+				Expect(isect.End.String()).To(Equal(common[len(common)-1]))
+			}
+		}
+	})
+})
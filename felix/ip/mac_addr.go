@@ -0,0 +1,125 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+)
+
+var ErrInvalidMAC = errors.New("failed to parse MAC address")
+
+// MACAddr is a 48-bit (EUI-48) MAC address.  Unlike net.HardwareAddr, it is comparable, so it can be
+// used as a map key, and it can be passed around by value without allocating.
+type MACAddr [6]byte
+
+// ParseMAC parses a MAC address in any of the EUI-48 formats accepted by net.ParseMAC, for example
+// "00:00:5e:00:53:01", "00-00-5e-00-53-01" or "0000.5e00.5301".  Longer (EUI-64 or InfiniBand)
+// addresses are rejected.  On failure, it returns an error wrapping ErrInvalidMAC.
+func ParseMAC(s string) (MACAddr, error) {
+	hw, err := net.ParseMAC(s)
+	if err != nil {
+		return MACAddr{}, fmt.Errorf("%w: %q", ErrInvalidMAC, s)
+	}
+	mac, ok := MACFromHardwareAddr(hw)
+	if !ok {
+		return MACAddr{}, fmt.Errorf("%w: %q is not a 48-bit MAC address", ErrInvalidMAC, s)
+	}
+	return mac, nil
+}
+
+// MustParseMAC parses the given MAC address, like ParseMAC.  It panics on failure.
+func MustParseMAC(s string) MACAddr {
+	mac, err := ParseMAC(s)
+	if err != nil {
+		panic(err)
+	}
+	return mac
+}
+
+// MACFromHardwareAddr converts a net.HardwareAddr to a MACAddr.  ok is false if the hardware address
+// is not 6 bytes long.
+func MACFromHardwareAddr(hw net.HardwareAddr) (mac MACAddr, ok bool) {
+	if len(hw) != len(mac) {
+		return
+	}
+	copy(mac[:], hw)
+	return mac, true
+}
+
+// AsHardwareAddr returns the MAC address as a newly-allocated net.HardwareAddr.
+func (m MACAddr) AsHardwareAddr() net.HardwareAddr {
+	return append(net.HardwareAddr(nil), m[:]...)
+}
+
+// IsZero returns true if the MAC address is all zeros, which is also the zero value of MACAddr.
+func (m MACAddr) IsZero() bool {
+	return m == MACAddr{}
+}
+
+// IsBroadcast returns true if the MAC address is ff:ff:ff:ff:ff:ff.
+func (m MACAddr) IsBroadcast() bool {
+	return m == MACAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+}
+
+// IsMulticast returns true if the MAC address is a group address (including broadcast).
+func (m MACAddr) IsMulticast() bool {
+	return m[0]&0x01 != 0
+}
+
+// IsLocallyAdministered returns true if the locally-administered bit is set, as it is for the MACs
+// that we generate for VXLAN tunnel devices, for example.
+func (m MACAddr) IsLocallyAdministered() bool {
+	return m[0]&0x02 != 0
+}
+
+// String returns the MAC address in the same lower-case, colon-separated form as net.HardwareAddr.
+func (m MACAddr) String() string {
+	const hexDigits = "0123456789abcdef"
+	var buf [17]byte
+	for i, b := range m {
+		if i > 0 {
+			buf[i*3-1] = ':'
+		}
+		buf[i*3] = hexDigits[b>>4]
+		buf[i*3+1] = hexDigits[b&0xf]
+	}
+	return string(buf[:])
+}
+
+// Hash64 returns a non-cryptographic hash of the MAC address.  Like V4Addr.Hash64, it is the stable
+// 64-bit FNV-1a hash of the address's bytes.
+func (m MACAddr) Hash64() uint64 {
+	return fnv64a(fnv64Offset, m[:])
+}
+
+func (m MACAddr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+func (m *MACAddr) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	mac, err := ParseMAC(s)
+	if err != nil {
+		return err
+	}
+	*m = mac
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"encoding/json"
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = DescribeTable("ParseMAC",
+	func(input, expected string) {
+		mac, err := ip.ParseMAC(input)
+		if expected == "" {
+			Expect(err).To(MatchError(ip.ErrInvalidMAC))
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mac.String()).To(Equal(expected))
+		hw, _ := net.ParseMAC(input)
+		Expect(mac.String()).To(Equal(hw.String()))
+		Expect(mac.AsHardwareAddr()).To(Equal(hw))
+	},
+	Entry("colons", "00:00:5e:00:53:01", "00:00:5e:00:53:01"),
+	Entry("upper case", "00:00:5E:00:53:AB", "00:00:5e:00:53:ab"),
+	Entry("dashes", "00-00-5e-00-53-01", "00:00:5e:00:53:01"),
+	Entry("dots", "0000.5e00.5301", "00:00:5e:00:53:01"),
+	Entry("broadcast", "ff:ff:ff:ff:ff:ff", "ff:ff:ff:ff:ff:ff"),
+	Entry("too short", "00:00:5e:00:53", ""),
+	Entry("EUI-64", "00:00:5e:00:53:01:02:03", ""),
+	Entry("garbage", "not-a-mac", ""),
+)
+
+var _ = Describe("MACAddr", func() {
+	It("should be usable as a map key", func() {
+		m := map[ip.MACAddr]int{}
+		m[ip.MustParseMAC("00:00:5e:00:53:01")] = 1
+		m[ip.MustParseMAC("00-00-5E-00-53-01")]++
+		Expect(m).To(HaveLen(1))
+		Expect(m[ip.MACAddr{0x00, 0x00, 0x5e, 0x00, 0x53, 0x01}]).To(Equal(2))
+	})
+
+	It("should convert from net.HardwareAddr", func() {
+		mac, ok := ip.MACFromHardwareAddr(net.HardwareAddr{1, 2, 3, 4, 5, 6})
+		Expect(ok).To(BeTrue())
+		Expect(mac).To(Equal(ip.MACAddr{1, 2, 3, 4, 5, 6}))
+		_, ok = ip.MACFromHardwareAddr(net.HardwareAddr{1, 2, 3})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should classify addresses", func() {
+		Expect(ip.MACAddr{}.IsZero()).To(BeTrue())
+		Expect(ip.MustParseMAC("ff:ff:ff:ff:ff:ff").IsBroadcast()).To(BeTrue())
+		Expect(ip.MustParseMAC("ff:ff:ff:ff:ff:ff").IsMulticast()).To(BeTrue())
+		Expect(ip.MustParseMAC("01:00:5e:00:00:01").IsMulticast()).To(BeTrue())
+		Expect(ip.MustParseMAC("00:00:5e:00:53:01").IsMulticast()).To(BeFalse())
+		Expect(ip.MustParseMAC("66:00:5e:00:53:01").IsLocallyAdministered()).To(BeTrue())
+		Expect(ip.MustParseMAC("64:00:5e:00:53:01").IsLocallyAdministered()).To(BeFalse())
+	})
+
+	It("should have a stable hash", func() {
+		mac := ip.MustParseMAC("00:00:5e:00:53:01")
+		Expect(mac.Hash64()).To(Equal(ip.MustParseMAC("00:00:5e:00:53:01").Hash64()))
+		Expect(mac.Hash64()).NotTo(Equal(ip.MustParseMAC("00:00:5e:00:53:02").Hash64()))
+	})
+
+	It("should round trip through JSON", func() {
+		mac := ip.MustParseMAC("00:00:5e:00:53:01")
+		data, err := json.Marshal(mac)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(`"00:00:5e:00:53:01"`))
+		var out ip.MACAddr
+		Expect(json.Unmarshal(data, &out)).To(Succeed())
+		Expect(out).To(Equal(mac))
+		Expect(json.Unmarshal([]byte(`"bad"`), &out)).To(MatchError(ip.ErrInvalidMAC))
+	})
+})
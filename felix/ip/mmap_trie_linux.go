@@ -0,0 +1,643 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// MmapTrie is a persistent PATRICIA trie whose nodes live in a memory-mapped file rather than on the Go
+// heap.  It is intended for very large, mostly-static prefix datasets (such as a full Internet routing
+// table) that should survive restarts without being rebuilt.  Like Trie, an MmapTrie holds CIDRs of a
+// single IP version.  Values are fixed-width uint64s (typically an index into some other table).
+//
+// The file is an append-only arena of fixed-size nodes.  Nodes that are reachable from the last committed
+// root are never modified; updates copy them (in the same way that Trie copies nodes that are shared with
+// a snapshot) and changes only become visible to other openers of the file, and durable, when Sync is
+// called.  The superseded nodes are left in place as garbage until Compact rewrites the file.  Since a
+// committed view is never modified in place, any number of read-only openers can use the file
+// concurrently with a single writer; each sees the state as of the last Sync before it opened the file.
+//
+// The file format uses the host's byte order and is not portable between architectures.  MmapTrie is not
+// thread safe.
+type MmapTrie struct {
+	path     string
+	file     *os.File
+	data     []byte
+	readOnly bool
+
+	ipVersion  uint8
+	root       uint32
+	numNodes   uint32
+	numEntries int
+	numGarbage int
+	// frozen is the number of nodes that existed at the last commit.  Nodes with lower indexes may be
+	// reachable from the committed root and must be copied before they are modified.
+	frozen uint32
+}
+
+var (
+	ErrMmapTrieReadOnly = errors.New("mmap trie is read-only")
+	ErrMmapTrieCorrupt  = errors.New("mmap trie file is corrupt or has an unsupported format")
+)
+
+const (
+	mmapTrieMagic         = "CALTRIE\x00"
+	mmapTrieFormatVersion = 1
+
+	mmapTrieHeaderSize  = int(unsafe.Sizeof(mmapTrieHeader{}))
+	mmapTrieNodeSize    = int(unsafe.Sizeof(mmapTrieNode{}))
+	mmapTrieInitialSize = 64 * 1024
+
+	mmapTrieNodeHasData = 1
+)
+
+// mmapTrieHeader is stored at the start of the file.  It records the committed state of the trie.
+type mmapTrieHeader struct {
+	magic         [8]byte
+	formatVersion uint32
+	ipVersion     uint32
+	root          uint32
+	numNodes      uint32
+	numEntries    uint64
+	numGarbage    uint64
+	_             [24]byte
+}
+
+// mmapTrieNode is the on-disk form of a trie node.  Children are node indexes; index 0 is reserved so it
+// can be used as the nil child.  IPv4 addresses occupy the first four bytes of addr.
+type mmapTrieNode struct {
+	addr     [16]byte
+	children [2]uint32
+	value    uint64
+	prefix   uint8
+	flags    uint8
+	_        [6]byte
+}
+
+// CreateMmapTrie creates a new, empty trie file for CIDRs of the given IP version, replacing any existing
+// file at path.
+func CreateMmapTrie(path string, ipVersion uint8) (*MmapTrie, error) {
+	if ipVersion != 4 && ipVersion != 6 {
+		return nil, fmt.Errorf("invalid IP version %d", ipVersion)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(mmapTrieInitialSize); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	t, err := mapMmapTrie(path, f, false)
+	if err != nil {
+		return nil, err
+	}
+	h := t.header()
+	copy(h.magic[:], mmapTrieMagic)
+	h.formatVersion = mmapTrieFormatVersion
+	h.ipVersion = uint32(ipVersion)
+	t.ipVersion = ipVersion
+	// Node 0 is the nil sentinel.
+	t.numNodes = 1
+	if err := t.Sync(); err != nil {
+		_ = t.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// OpenMmapTrie opens an existing trie file for reading and writing.  Only one writer may have the file
+// open at a time; the file is locked to enforce that.
+func OpenMmapTrie(path string) (*MmapTrie, error) {
+	return openMmapTrie(path, false)
+}
+
+// OpenMmapTrieReadOnly opens an existing trie file read-only.  The returned trie reflects the state of
+// the file when it was opened, even if a writer subsequently updates the file.  Attempts to modify
+// the trie return ErrMmapTrieReadOnly.
+func OpenMmapTrieReadOnly(path string) (*MmapTrie, error) {
+	return openMmapTrie(path, true)
+}
+
+func openMmapTrie(path string, readOnly bool) (*MmapTrie, error) {
+	flags := os.O_RDWR
+	if readOnly {
+		flags = os.O_RDONLY
+	}
+	f, err := os.OpenFile(path, flags, 0)
+	if err != nil {
+		return nil, err
+	}
+	t, err := mapMmapTrie(path, f, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	h := t.header()
+	if string(h.magic[:]) != mmapTrieMagic ||
+		h.formatVersion != mmapTrieFormatVersion ||
+		(h.ipVersion != 4 && h.ipVersion != 6) ||
+		h.numNodes == 0 || int(h.numNodes) > t.capacity() || h.root >= h.numNodes {
+		_ = t.Close()
+		return nil, fmt.Errorf("%w: %s", ErrMmapTrieCorrupt, path)
+	}
+	t.ipVersion = uint8(h.ipVersion)
+	t.root = h.root
+	t.numNodes = h.numNodes
+	t.numEntries = int(h.numEntries)
+	t.numGarbage = int(h.numGarbage)
+	t.frozen = t.numNodes
+	return t, nil
+}
+
+// mapMmapTrie maps the given file.  It takes ownership of f, closing it on failure.
+func mapMmapTrie(path string, f *os.File, readOnly bool) (*MmapTrie, error) {
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if info.Size() < int64(mmapTrieHeaderSize) || info.Size() > math.MaxInt {
+		_ = f.Close()
+		return nil, fmt.Errorf("%w: %s", ErrMmapTrieCorrupt, path)
+	}
+	prot := unix.PROT_READ
+	if !readOnly {
+		prot |= unix.PROT_WRITE
+		// Readers can safely share the file with a writer, but two writers would corrupt it.
+		if err := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to lock %s, is it already open for writing? %w", path, err)
+		}
+	}
+	data, err := unix.Mmap(int(f.Fd()), 0, int(info.Size()), prot, unix.MAP_SHARED)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to map %s: %w", path, err)
+	}
+	return &MmapTrie{
+		path:     path,
+		file:     f,
+		data:     data,
+		readOnly: readOnly,
+	}, nil
+}
+
+// Close syncs any pending changes (if the trie is writable) and unmaps the file.
+func (t *MmapTrie) Close() error {
+	var err error
+	if !t.readOnly {
+		err = t.Sync()
+	}
+	if t.data != nil {
+		if unmapErr := unix.Munmap(t.data); unmapErr != nil && err == nil {
+			err = unmapErr
+		}
+		t.data = nil
+	}
+	if closeErr := t.file.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Sync commits the current state of the trie to the file.  Once Sync returns, the changes will survive a
+// restart and are visible to subsequent openers of the file.
+func (t *MmapTrie) Sync() error {
+	if t.readOnly {
+		return ErrMmapTrieReadOnly
+	}
+	// Flush the nodes before we update the header to point at them so that a crash can't leave the header
+	// referring to nodes that never made it to disk.
+	if err := unix.Msync(t.data, unix.MS_SYNC); err != nil {
+		return err
+	}
+	h := t.header()
+	h.root = t.root
+	h.numNodes = t.numNodes
+	h.numEntries = uint64(t.numEntries)
+	h.numGarbage = uint64(t.numGarbage)
+	if err := unix.Msync(t.data[:os.Getpagesize()], unix.MS_SYNC); err != nil {
+		return err
+	}
+	t.frozen = t.numNodes
+	return nil
+}
+
+// Compact rewrites the file so that it contains only the live nodes, discarding any garbage left behind
+// by updates.  The new file replaces the old one atomically; read-only openers of the old file are
+// unaffected.  Compact also commits any pending changes.
+func (t *MmapTrie) Compact() error {
+	if t.readOnly {
+		return ErrMmapTrieReadOnly
+	}
+	tmpPath := t.path + ".compact"
+	compacted, err := CreateMmapTrie(tmpPath, t.ipVersion)
+	if err != nil {
+		return err
+	}
+	root, err := compacted.copySubtree(t, t.root)
+	if err != nil {
+		_ = compacted.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	compacted.root = root
+	compacted.numEntries = t.numEntries
+	if err := compacted.Sync(); err != nil {
+		_ = compacted.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, t.path); err != nil {
+		_ = compacted.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	_ = unix.Munmap(t.data)
+	_ = t.file.Close()
+	compacted.path = t.path
+	*t = *compacted
+	return nil
+}
+
+func (t *MmapTrie) copySubtree(src *MmapTrie, srcIdx uint32) (uint32, error) {
+	if srcIdx == 0 {
+		return 0, nil
+	}
+	var children [2]uint32
+	for i, c := range src.node(srcIdx).children {
+		var err error
+		children[i], err = t.copySubtree(src, c)
+		if err != nil {
+			return 0, err
+		}
+	}
+	idx, err := t.allocNode()
+	if err != nil {
+		return 0, err
+	}
+	n := t.node(idx)
+	*n = *src.node(srcIdx)
+	n.children = children
+	return idx, nil
+}
+
+// IPVersion returns the IP version of the CIDRs held by the trie.
+func (t *MmapTrie) IPVersion() uint8 {
+	return t.ipVersion
+}
+
+// ReadOnly returns true if the trie was opened read-only.
+func (t *MmapTrie) ReadOnly() bool {
+	return t.readOnly
+}
+
+// Len returns the number of entries in the trie.
+func (t *MmapTrie) Len() int {
+	return t.numEntries
+}
+
+// NumGarbageNodes returns the number of nodes in the file that are no longer reachable and would be
+// reclaimed by Compact.
+func (t *MmapTrie) NumGarbageNodes() int {
+	return t.numGarbage
+}
+
+// Update stores value against the given CIDR, replacing any existing value.
+func (t *MmapTrie) Update(cidr CIDR, value uint64) error {
+	if err := t.checkWritable(cidr); err != nil {
+		return err
+	}
+	root, added, err := t.update(t.root, cidr.Canonicalize(), value)
+	if err != nil {
+		return err
+	}
+	t.root = root
+	if added {
+		t.numEntries++
+	}
+	return nil
+}
+
+// update stores the value in the subtree rooted at idx and returns the new root of the subtree.
+// Note: any allocation may remap the file so node pointers must not be held across calls that allocate.
+func (t *MmapTrie) update(idx uint32, cidr CIDR, value uint64) (uint32, bool, error) {
+	if idx == 0 {
+		leaf, err := t.newLeaf(cidr, value)
+		return leaf, true, err
+	}
+
+	nodeCIDR := t.nodeCIDR(idx)
+	if nodeCIDR == cidr {
+		idx, err := t.writable(idx)
+		if err != nil {
+			return 0, false, err
+		}
+		n := t.node(idx)
+		added := n.flags&mmapTrieNodeHasData == 0
+		n.value = value
+		n.flags |= mmapTrieNodeHasData
+		return idx, added, nil
+	}
+
+	common := CommonPrefix(nodeCIDR, cidr)
+	switch common {
+	case nodeCIDR:
+		// This node is a parent of the CIDR, recurse on the appropriate child.
+		childIdx := cidr.Addr().NthBit(uint(nodeCIDR.Prefix() + 1))
+		oldChild := t.node(idx).children[childIdx]
+		newChild, added, err := t.update(oldChild, cidr, value)
+		if err != nil || newChild == oldChild {
+			return idx, added, err
+		}
+		idx, err = t.setChild(idx, childIdx, newChild)
+		return idx, added, err
+	case cidr:
+		// The new CIDR is a parent of this node.
+		parent, err := t.newLeaf(cidr, value)
+		if err != nil {
+			return 0, false, err
+		}
+		t.node(parent).children[nodeCIDR.Addr().NthBit(uint(cidr.Prefix()+1))] = idx
+		return parent, true, nil
+	default:
+		// The CIDRs are disjoint, join them with an intermediate node.
+		leaf, err := t.newLeaf(cidr, value)
+		if err != nil {
+			return 0, false, err
+		}
+		intermediate, err := t.newNode(common)
+		if err != nil {
+			return 0, false, err
+		}
+		childIdx := cidr.Addr().NthBit(uint(common.Prefix() + 1))
+		n := t.node(intermediate)
+		n.children[childIdx] = leaf
+		n.children[1-childIdx] = idx
+		return intermediate, true, nil
+	}
+}
+
+// Delete removes the given CIDR from the trie, if present.
+func (t *MmapTrie) Delete(cidr CIDR) error {
+	if err := t.checkWritable(cidr); err != nil {
+		return err
+	}
+	root, deleted, err := t.delete(t.root, cidr.Canonicalize())
+	if err != nil {
+		return err
+	}
+	t.root = root
+	if deleted {
+		t.numEntries--
+	}
+	return nil
+}
+
+func (t *MmapTrie) delete(idx uint32, cidr CIDR) (uint32, bool, error) {
+	if idx == 0 {
+		return 0, false, nil
+	}
+	nodeCIDR := t.nodeCIDR(idx)
+	if !nodeCIDR.ContainsCIDR(cidr) {
+		return idx, false, nil
+	}
+
+	n := t.node(idx)
+	if nodeCIDR == cidr {
+		if n.flags&mmapTrieNodeHasData == 0 {
+			// Intermediate node, CIDR isn't actually in the trie.
+			return idx, false, nil
+		}
+		if n.children[0] == 0 || n.children[1] == 0 {
+			// Node is no longer needed, replace it by its remaining child (if any).
+			t.numGarbage++
+			if n.children[0] == 0 {
+				return n.children[1], true, nil
+			}
+			return n.children[0], true, nil
+		}
+		// Node still joins two subtrees; turn it into an intermediate node.
+		idx, err := t.writable(idx)
+		if err != nil {
+			return 0, false, err
+		}
+		n = t.node(idx)
+		n.flags &^= mmapTrieNodeHasData
+		n.value = 0
+		return idx, true, nil
+	}
+
+	childIdx := cidr.Addr().NthBit(uint(nodeCIDR.Prefix() + 1))
+	oldChild := n.children[childIdx]
+	newChild, deleted, err := t.delete(oldChild, cidr)
+	if err != nil || newChild == oldChild {
+		return idx, deleted, err
+	}
+	n = t.node(idx)
+	if newChild == 0 && n.flags&mmapTrieNodeHasData == 0 {
+		// Intermediate node that now only has one child, replace it by that child.
+		t.numGarbage++
+		return n.children[1-childIdx], deleted, nil
+	}
+	idx, err = t.setChild(idx, childIdx, newChild)
+	return idx, deleted, err
+}
+
+// Get returns the value stored against exactly the given CIDR.  ok is false if there is no such entry.
+func (t *MmapTrie) Get(cidr CIDR) (value uint64, ok bool) {
+	if cidr.Version() != t.ipVersion {
+		return 0, false
+	}
+	cidr = cidr.Canonicalize()
+	for idx := t.root; idx != 0; {
+		nodeCIDR := t.nodeCIDR(idx)
+		if !nodeCIDR.ContainsCIDR(cidr) {
+			break
+		}
+		n := t.node(idx)
+		if nodeCIDR == cidr {
+			return n.value, n.flags&mmapTrieNodeHasData != 0
+		}
+		idx = n.children[cidr.Addr().NthBit(uint(nodeCIDR.Prefix()+1))]
+	}
+	return 0, false
+}
+
+// LookupLongestPrefix returns the most specific CIDR in the trie that contains the given address, along
+// with its value.  The final return value is false if no CIDR in the trie contains the address.
+func (t *MmapTrie) LookupLongestPrefix(addr Addr) (CIDR, uint64, bool) {
+	var match CIDR
+	var value uint64
+	if addr.Version() != t.ipVersion {
+		return nil, 0, false
+	}
+	maxLen := uint8(32)
+	if addr.Version() == 6 {
+		maxLen = 128
+	}
+	for idx := t.root; idx != 0; {
+		nodeCIDR := t.nodeCIDR(idx)
+		if !nodeCIDR.Contains(addr) {
+			break
+		}
+		n := t.node(idx)
+		if n.flags&mmapTrieNodeHasData != 0 {
+			match, value = nodeCIDR, n.value
+		}
+		if nodeCIDR.Prefix() == maxLen {
+			// Full-length CIDR, can't have any children.
+			break
+		}
+		idx = n.children[addr.NthBit(uint(nodeCIDR.Prefix()+1))]
+	}
+	return match, value, match != nil
+}
+
+// Visit calls f for each entry in the trie, in address order.  If f returns false, iteration stops.
+func (t *MmapTrie) Visit(f func(cidr CIDR, value uint64) bool) {
+	t.visit(t.root, f)
+}
+
+func (t *MmapTrie) visit(idx uint32, f func(cidr CIDR, value uint64) bool) bool {
+	if idx == 0 {
+		return true
+	}
+	n := t.node(idx)
+	if n.flags&mmapTrieNodeHasData != 0 && !f(t.nodeCIDR(idx), n.value) {
+		return false
+	}
+	return t.visit(n.children[0], f) && t.visit(n.children[1], f)
+}
+
+func (t *MmapTrie) checkWritable(cidr CIDR) error {
+	if t.readOnly {
+		return ErrMmapTrieReadOnly
+	}
+	if cidr.Version() != t.ipVersion {
+		return fmt.Errorf("cannot store IPv%d CIDR %s in IPv%d trie", cidr.Version(), cidr, t.ipVersion)
+	}
+	return nil
+}
+
+func (t *MmapTrie) header() *mmapTrieHeader {
+	return (*mmapTrieHeader)(unsafe.Pointer(&t.data[0]))
+}
+
+func (t *MmapTrie) capacity() int {
+	return (len(t.data) - mmapTrieHeaderSize) / mmapTrieNodeSize
+}
+
+// node returns a pointer to the node with the given index.  The pointer is only valid until the next
+// allocation.
+func (t *MmapTrie) node(idx uint32) *mmapTrieNode {
+	return (*mmapTrieNode)(unsafe.Pointer(&t.data[mmapTrieHeaderSize+int(idx)*mmapTrieNodeSize]))
+}
+
+func (t *MmapTrie) nodeCIDR(idx uint32) CIDR {
+	n := t.node(idx)
+	if t.ipVersion == 4 {
+		return V4CIDR{addr: V4Addr{n.addr[0], n.addr[1], n.addr[2], n.addr[3]}, prefix: n.prefix}
+	}
+	return V6CIDR{addr: n.addr, prefix: n.prefix}
+}
+
+func (t *MmapTrie) newNode(cidr CIDR) (uint32, error) {
+	idx, err := t.allocNode()
+	if err != nil {
+		return 0, err
+	}
+	n := t.node(idx)
+	switch a := cidr.Addr().(type) {
+	case V4Addr:
+		copy(n.addr[:], a[:])
+	case V6Addr:
+		n.addr = a
+	}
+	n.prefix = cidr.Prefix()
+	return idx, nil
+}
+
+func (t *MmapTrie) newLeaf(cidr CIDR, value uint64) (uint32, error) {
+	idx, err := t.newNode(cidr)
+	if err != nil {
+		return 0, err
+	}
+	n := t.node(idx)
+	n.value = value
+	n.flags = mmapTrieNodeHasData
+	return idx, nil
+}
+
+// writable returns the index of a version of the node that is safe to modify; either the node itself, if
+// it was created since the last commit, or a copy.
+func (t *MmapTrie) writable(idx uint32) (uint32, error) {
+	if idx >= t.frozen {
+		return idx, nil
+	}
+	newIdx, err := t.allocNode()
+	if err != nil {
+		return 0, err
+	}
+	*t.node(newIdx) = *t.node(idx)
+	t.numGarbage++
+	return newIdx, nil
+}
+
+func (t *MmapTrie) setChild(idx uint32, childIdx int, child uint32) (uint32, error) {
+	idx, err := t.writable(idx)
+	if err != nil {
+		return 0, err
+	}
+	t.node(idx).children[childIdx] = child
+	return idx, nil
+}
+
+func (t *MmapTrie) allocNode() (uint32, error) {
+	if t.numNodes == math.MaxUint32 {
+		return 0, errors.New("mmap trie is full")
+	}
+	if int(t.numNodes) >= t.capacity() {
+		if err := t.grow(); err != nil {
+			return 0, err
+		}
+	}
+	idx := t.numNodes
+	t.numNodes++
+	*t.node(idx) = mmapTrieNode{}
+	return idx, nil
+}
+
+// grow doubles the size of the file and remaps it.
+func (t *MmapTrie) grow() error {
+	newSize := len(t.data) * 2
+	if err := t.file.Truncate(int64(newSize)); err != nil {
+		return err
+	}
+	data, err := unix.Mmap(int(t.file.Fd()), 0, newSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		return fmt.Errorf("failed to remap %s: %w", t.path, err)
+	}
+	_ = unix.Munmap(t.data)
+	t.data = data
+	return nil
+}
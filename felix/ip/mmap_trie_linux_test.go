@@ -0,0 +1,225 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = Describe("MmapTrie", func() {
+	var dir, path string
+	var trie *ip.MmapTrie
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "mmap-trie")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "trie")
+		trie, err = ip.CreateMmapTrie(path, 4)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if trie != nil {
+			_ = trie.Close()
+		}
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	contents := func(t *ip.MmapTrie) map[string]uint64 {
+		m := map[string]uint64{}
+		t.Visit(func(cidr ip.CIDR, value uint64) bool {
+			m[cidr.String()] = value
+			return true
+		})
+		return m
+	}
+
+	update := func(cidr string, value uint64) {
+		ExpectWithOffset(1, trie.Update(ip.MustParseCIDROrIP(cidr), value)).To(Succeed())
+	}
+
+	reopen := func() {
+		Expect(trie.Close()).To(Succeed())
+		var err error
+		trie, err = ip.OpenMmapTrie(path)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("should support basic operations", func() {
+		update("10.0.0.0/8", 1)
+		update("10.0.1.0/24", 2)
+		update("10.0.2.0/24", 3)
+		update("10.0.1.0/24", 4)
+		Expect(trie.Len()).To(Equal(3))
+
+		v, ok := trie.Get(ip.MustParseCIDROrIP("10.0.1.0/24"))
+		Expect(ok).To(BeTrue())
+		Expect(v).To(BeNumerically("==", 4))
+		_, ok = trie.Get(ip.MustParseCIDROrIP("10.0.0.0/22"))
+		Expect(ok).To(BeFalse(), "intermediate node shouldn't be returned")
+
+		cidr, v, ok := trie.LookupLongestPrefix(ip.FromString("10.0.2.7"))
+		Expect(ok).To(BeTrue())
+		Expect(cidr.String()).To(Equal("10.0.2.0/24"))
+		Expect(v).To(BeNumerically("==", 3))
+		cidr, _, _ = trie.LookupLongestPrefix(ip.FromString("10.0.3.7"))
+		Expect(cidr.String()).To(Equal("10.0.0.0/8"))
+		_, _, ok = trie.LookupLongestPrefix(ip.FromString("11.0.0.1"))
+		Expect(ok).To(BeFalse())
+
+		Expect(trie.Delete(ip.MustParseCIDROrIP("10.0.0.0/8"))).To(Succeed())
+		Expect(trie.Delete(ip.MustParseCIDROrIP("10.0.3.0/24"))).To(Succeed())
+		Expect(contents(trie)).To(Equal(map[string]uint64{"10.0.1.0/24": 4, "10.0.2.0/24": 3}))
+		Expect(trie.Len()).To(Equal(2))
+	})
+
+	It("should reject CIDRs of the wrong IP version", func() {
+		Expect(trie.Update(ip.MustParseCIDROrIP("fd00::/64"), 1)).NotTo(Succeed())
+		_, ok := trie.Get(ip.MustParseCIDROrIP("fd00::/64"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should persist committed changes and drop uncommitted ones", func() {
+		update("10.0.0.0/8", 1)
+		update("10.0.1.0/24", 2)
+		Expect(trie.Sync()).To(Succeed())
+		update("10.0.2.0/24", 3)
+		Expect(trie.Delete(ip.MustParseCIDROrIP("10.0.0.0/8"))).To(Succeed())
+
+		// Opening read-only sees the committed state.
+		ro, err := ip.OpenMmapTrieReadOnly(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(contents(ro)).To(Equal(map[string]uint64{"10.0.0.0/8": 1, "10.0.1.0/24": 2}))
+		Expect(ro.Len()).To(Equal(2))
+
+		// Close commits.
+		reopen()
+		Expect(contents(trie)).To(Equal(map[string]uint64{"10.0.1.0/24": 2, "10.0.2.0/24": 3}))
+
+		// The reader's view is unaffected by the writer's commits.
+		Expect(contents(ro)).To(Equal(map[string]uint64{"10.0.0.0/8": 1, "10.0.1.0/24": 2}))
+		Expect(ro.Close()).To(Succeed())
+	})
+
+	It("should only allow one writer", func() {
+		_, err := ip.OpenMmapTrie(path)
+		Expect(err).To(HaveOccurred())
+		ro, err := ip.OpenMmapTrieReadOnly(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ro.Close()).To(Succeed())
+	})
+
+	It("should reject writes in read-only mode", func() {
+		update("10.0.0.0/8", 1)
+		Expect(trie.Close()).To(Succeed())
+		var err error
+		trie, err = ip.OpenMmapTrieReadOnly(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trie.ReadOnly()).To(BeTrue())
+		Expect(trie.Update(ip.MustParseCIDROrIP("10.0.0.0/16"), 1)).To(Equal(ip.ErrMmapTrieReadOnly))
+		Expect(trie.Delete(ip.MustParseCIDROrIP("10.0.0.0/8"))).To(Equal(ip.ErrMmapTrieReadOnly))
+		Expect(trie.Compact()).To(Equal(ip.ErrMmapTrieReadOnly))
+		Expect(contents(trie)).To(Equal(map[string]uint64{"10.0.0.0/8": 1}))
+	})
+
+	It("should reject files that aren't tries", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "junk"), make([]byte, 4096), 0o644)).To(Succeed())
+		_, err := ip.OpenMmapTrie(filepath.Join(dir, "junk"))
+		Expect(err).To(MatchError(ip.ErrMmapTrieCorrupt))
+		Expect(os.WriteFile(filepath.Join(dir, "short"), []byte("CALTRIE"), 0o644)).To(Succeed())
+		_, err = ip.OpenMmapTrieReadOnly(filepath.Join(dir, "short"))
+		Expect(err).To(MatchError(ip.ErrMmapTrieCorrupt))
+	})
+
+	It("should match an in-memory trie under random churn, across syncs, reopens and compactions", func() {
+		rng := rand.New(rand.NewSource(1))
+		expected := ip.NewTrie[uint64]()
+		checkContents := func() {
+			exp := map[string]uint64{}
+			expected.Visit(func(cidr ip.CIDR, value uint64) bool {
+				exp[cidr.String()] = value
+				return true
+			})
+			ExpectWithOffset(1, contents(trie)).To(Equal(exp))
+			ExpectWithOffset(1, trie.Len()).To(Equal(expected.Len()))
+		}
+		for i := 0; i < 20000; i++ {
+			cidr := ip.RandomV4CIDR(rng)
+			if rng.Intn(3) > 0 {
+				Expect(trie.Update(cidr, uint64(i))).To(Succeed())
+				expected.Update(cidr, uint64(i))
+			} else {
+				Expect(trie.Delete(cidr)).To(Succeed())
+				expected.Delete(cidr)
+			}
+			switch i % 5000 {
+			case 1000:
+				Expect(trie.Sync()).To(Succeed())
+			case 2000:
+				reopen()
+				checkContents()
+			case 3000:
+				Expect(trie.NumGarbageNodes()).To(BeNumerically(">", 0))
+				Expect(trie.Compact()).To(Succeed())
+				Expect(trie.NumGarbageNodes()).To(BeZero())
+				checkContents()
+			}
+		}
+		checkContents()
+
+		// Spot-check lookups against the in-memory trie.
+		for i := 0; i < 1000; i++ {
+			addr := ip.RandomV4Addr(rng)
+			expCIDR, expValue, expOK := expected.LookupLongestPrefix(addr)
+			cidr, value, ok := trie.LookupLongestPrefix(addr)
+			Expect(ok).To(Equal(expOK))
+			Expect(cidr).To(Equal(expCIDR))
+			Expect(value).To(Equal(expValue))
+		}
+
+		Expect(trie.Compact()).To(Succeed())
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Size()).To(BeNumerically("<", 1<<20))
+		reopen()
+		checkContents()
+	})
+
+	It("should handle IPv6", func() {
+		Expect(trie.Close()).To(Succeed())
+		var err error
+		trie, err = ip.CreateMmapTrie(path, 6)
+		Expect(err).NotTo(HaveOccurred())
+		update("fd00::/48", 1)
+		update("fd00:0:0:1::/64", 2)
+		update("fd00:0:0:1::1/128", 3)
+		reopen()
+		Expect(trie.IPVersion()).To(BeNumerically("==", 6))
+		cidr, v, ok := trie.LookupLongestPrefix(ip.FromString("fd00:0:0:1::1"))
+		Expect(ok).To(BeTrue())
+		Expect(cidr.String()).To(Equal("fd00:0:0:1::1/128"))
+		Expect(v).To(BeNumerically("==", 3))
+		cidr, _, _ = trie.LookupLongestPrefix(ip.FromString("fd00:0:0:1::2"))
+		Expect(cidr.String()).To(Equal("fd00:0:0:1::/64"))
+	})
+})
@@ -0,0 +1,241 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+// ParseAddrBytes parses an IPv4 or IPv6 address from the given bytes.  It is equivalent to FromString
+// (including converting IPv4-mapped IPv6 addresses to V4Addr) but it works directly on the byte slice,
+// avoiding the string conversion and the allocations made by the net package.  IPv6 zones are not
+// supported.  Returns a *ParseError wrapping ErrInvalidIP if the address is not valid.
+func ParseAddrBytes(b []byte) (Addr, error) {
+	for _, c := range b {
+		if c == ':' {
+			a, ok := parseV6AddrBytes(b)
+			if !ok {
+				return nil, newAddrParseError(string(b), 0)
+			}
+			if v4, ok := a.Unmap(); ok {
+				return v4, nil
+			}
+			return a, nil
+		}
+	}
+	a, ok := parseV4AddrBytes(b)
+	if !ok {
+		return nil, newAddrParseError(string(b), 0)
+	}
+	return a, nil
+}
+
+// ParseCIDRBytes parses an IP address or CIDR from the given bytes, treating IP addresses as "full
+// length" CIDRs, as ParseCIDROrIP does.  Any bits beyond the prefix length are cleared.  It avoids
+// the string conversion and the allocations made by the net package; the only allocation is the
+// one needed to return the result as a CIDR interface.
+//
+// An IPv4-mapped IPv6 CIDR with a prefix length of at least 96 is converted to the equivalent IPv4
+// CIDR.  On failure, returns a *ParseError describing which part of the input was rejected.
+func ParseCIDRBytes(b []byte) (CIDR, error) {
+	slashIdx := -1
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] == '/' {
+			slashIdx = i
+			break
+		}
+	}
+	if slashIdx < 0 {
+		addr, err := ParseAddrBytes(b)
+		if err != nil {
+			return nil, err
+		}
+		return addr.AsCIDR(), nil
+	}
+
+	prefixLen, ok := parseDecimalBytes(b[slashIdx+1:], 3)
+	if !ok {
+		return nil, newPrefixLenParseError(string(b), slashIdx+1)
+	}
+	addrBytes := b[:slashIdx]
+	for _, c := range addrBytes {
+		if c == ':' {
+			a, ok := parseV6AddrBytes(addrBytes)
+			if !ok {
+				return nil, newAddrParseError(string(b), 0)
+			}
+			if prefixLen > 128 {
+				return nil, newPrefixLenParseError(string(b), slashIdx+1)
+			}
+			if v4, ok := a.Unmap(); ok && prefixLen >= 96 {
+				c := V4CIDR{addr: v4, prefix: uint8(prefixLen - 96)}
+				return V4CommonPrefix(c, c), nil
+			}
+			c := V6CIDR{addr: a, prefix: uint8(prefixLen)}
+			return V6CommonPrefix(c, c), nil
+		}
+	}
+	a, ok := parseV4AddrBytes(addrBytes)
+	if !ok {
+		return nil, newAddrParseError(string(b), 0)
+	}
+	if prefixLen > 32 {
+		return nil, newPrefixLenParseError(string(b), slashIdx+1)
+	}
+	c := V4CIDR{addr: a, prefix: uint8(prefixLen)}
+	return V4CommonPrefix(c, c), nil
+}
+
+// parseDecimalBytes parses a non-empty, unsigned decimal number of at most maxDigits digits.
+func parseDecimalBytes(b []byte, maxDigits int) (int, bool) {
+	if len(b) == 0 || len(b) > maxDigits {
+		return 0, false
+	}
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, true
+}
+
+// parseV4AddrBytes parses a dotted-quad IPv4 address.  As with net.ParseIP, octets with leading zeros
+// are rejected to avoid ambiguity with octal notation.
+func parseV4AddrBytes(b []byte) (a V4Addr, ok bool) {
+	octetIdx := 0
+	start := 0
+	for i := 0; i <= len(b); i++ {
+		if i < len(b) && b[i] != '.' {
+			continue
+		}
+		if octetIdx >= 4 {
+			return a, false
+		}
+		field := b[start:i]
+		if len(field) > 1 && field[0] == '0' {
+			return a, false
+		}
+		n, ok := parseDecimalBytes(field, 3)
+		if !ok || n > 255 {
+			return a, false
+		}
+		a[octetIdx] = byte(n)
+		octetIdx++
+		start = i + 1
+	}
+	return a, octetIdx == 4
+}
+
+// parseV6AddrBytes parses an IPv6 address in any of the RFC 4291 text forms, including "::"
+// compression and a trailing dotted-quad IPv4 address.
+func parseV6AddrBytes(b []byte) (a V6Addr, ok bool) {
+	ellipsis := -1 // Index in a of the "::", if any.
+	i := 0         // Index in a of the next group to fill.
+
+	if len(b) >= 2 && b[0] == ':' && b[1] == ':' {
+		ellipsis = 0
+		b = b[2:]
+		if len(b) == 0 {
+			return a, true
+		}
+	}
+
+	for i < 16 {
+		// Parse a group of up to four hex digits.
+		n := 0
+		acc := 0
+		for n < len(b) {
+			v, isHex := hexDigitValue(b[n])
+			if !isHex {
+				break
+			}
+			acc = acc<<4 | v
+			n++
+		}
+		if n == 0 || n > 4 {
+			return a, false
+		}
+
+		if n < len(b) && b[n] == '.' {
+			// Trailing IPv4 address, which takes up the last four bytes.
+			if (ellipsis < 0 && i != 12) || i > 12 {
+				return a, false
+			}
+			v4, ok := parseV4AddrBytes(b)
+			if !ok {
+				return a, false
+			}
+			copy(a[i:], v4[:])
+			i += 4
+			b = nil
+			break
+		}
+
+		a[i] = byte(acc >> 8)
+		a[i+1] = byte(acc)
+		i += 2
+		b = b[n:]
+		if len(b) == 0 {
+			break
+		}
+
+		// Expect a colon, possibly a double colon.
+		if b[0] != ':' || len(b) == 1 {
+			return a, false
+		}
+		b = b[1:]
+		if b[0] == ':' {
+			if ellipsis >= 0 {
+				return a, false
+			}
+			ellipsis = i
+			b = b[1:]
+			if len(b) == 0 {
+				break
+			}
+		}
+	}
+	if len(b) != 0 {
+		return a, false
+	}
+
+	if i < 16 {
+		if ellipsis < 0 {
+			return a, false
+		}
+		// Shift the groups after the "::" to the end and zero-fill the gap.
+		n := 16 - i
+		for j := i - 1; j >= ellipsis; j-- {
+			a[j+n] = a[j]
+		}
+		for j := ellipsis + n - 1; j >= ellipsis; j-- {
+			a[j] = 0
+		}
+	} else if ellipsis >= 0 {
+		// "::" must stand for at least one group.
+		return a, false
+	}
+	return a, true
+}
+
+func hexDigitValue(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10, true
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10, true
+	}
+	return 0, false
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = DescribeTable("ParseAddrBytes should agree with FromString",
+	func(input string) {
+		addr, err := ip.ParseAddrBytes([]byte(input))
+		expected := ip.FromString(input)
+		if expected == nil {
+			Expect(err).To(MatchError(ip.ErrInvalidIP))
+			Expect(addr).To(BeNil())
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addr).To(Equal(expected))
+	},
+	Entry("IPv4", "10.0.0.1"),
+	Entry("IPv4 zero", "0.0.0.0"),
+	Entry("IPv4 broadcast", "255.255.255.255"),
+	Entry("IPv4 octet out of range", "10.0.0.256"),
+	Entry("IPv4 leading zero", "10.0.0.01"),
+	Entry("IPv4 too few octets", "10.0.0"),
+	Entry("IPv4 too many octets", "10.0.0.1.2"),
+	Entry("IPv4 empty octet", "10..0.1"),
+	Entry("IPv4 trailing dot", "10.0.0.1."),
+	Entry("IPv4 junk", "10.0.0.x"),
+	Entry("empty", ""),
+	Entry("IPv6 full", "fc00:1:2:3:4:5:6:7"),
+	Entry("IPv6 upper case", "FC00:ABCD::EF"),
+	Entry("IPv6 unspecified", "::"),
+	Entry("IPv6 loopback", "::1"),
+	Entry("IPv6 trailing ellipsis", "fc00::"),
+	Entry("IPv6 middle ellipsis", "fc00:1::2:3"),
+	Entry("IPv6 ellipsis for one group", "fc00:1:2:3:4:5::7"),
+	Entry("IPv6 embedded IPv4", "64:ff9b::10.0.0.1"),
+	Entry("IPv6 full embedded IPv4", "1:2:3:4:5:6:10.0.0.1"),
+	Entry("IPv4-mapped", "::ffff:10.0.0.1"),
+	Entry("IPv4-mapped hex", "::ffff:a00:1"),
+	Entry("IPv6 two ellipses", "fc00::1::2"),
+	Entry("IPv6 triple colon", "fc00:::1"),
+	Entry("IPv6 too many groups", "1:2:3:4:5:6:7:8:9"),
+	Entry("IPv6 redundant ellipsis", "1:2:3:4::5:6:7:8"),
+	Entry("IPv6 too few groups", "1:2:3:4:5:6:7"),
+	Entry("IPv6 group too long", "fc000::1"),
+	Entry("IPv6 leading colon", ":1:2:3:4:5:6:7"),
+	Entry("IPv6 trailing colon", "1:2:3:4:5:6:7:"),
+	Entry("IPv6 embedded IPv4 too early", "1:2:3:4:5:10.0.0.1"),
+	Entry("IPv6 embedded IPv4 too late", "1:2:3:4:5:6:7:10.0.0.1"),
+	Entry("IPv6 junk", "fc00::g"),
+	Entry("IPv6 zone", "fe80::1%eth0"),
+)
+
+var _ = DescribeTable("ParseCIDRBytes should agree with ParseCIDROrIP",
+	func(input string) {
+		cidr, err := ip.ParseCIDRBytes([]byte(input))
+		expected, expErr := ip.ParseCIDROrIP(input)
+		if expErr != nil {
+			Expect(err).To(HaveOccurred())
+			Expect(cidr).To(BeNil())
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cidr).To(Equal(expected))
+	},
+	Entry("IPv4 CIDR", "10.0.0.0/16"),
+	Entry("IPv4 non-canonical CIDR", "10.0.0.1/16"),
+	Entry("IPv4 /0", "0.0.0.0/0"),
+	Entry("IPv4 /32", "10.0.0.1/32"),
+	Entry("IPv4 address", "10.0.0.1"),
+	Entry("IPv4 prefix too long", "10.0.0.0/33"),
+	Entry("IPv4 empty prefix", "10.0.0.0/"),
+	Entry("IPv4 junk prefix", "10.0.0.0/1x"),
+	Entry("IPv4 bad address", "10.0.0.256/24"),
+	Entry("IPv6 CIDR", "fc00::/64"),
+	Entry("IPv6 non-canonical CIDR", "fc00::1/64"),
+	Entry("IPv6 /0", "::/0"),
+	Entry("IPv6 /128", "fc00::1/128"),
+	Entry("IPv6 address", "fc00::1"),
+	Entry("IPv6 prefix too long", "fc00::/129"),
+	Entry("IPv6 bad address", "fc00:::/64"),
+	Entry("IPv4-mapped address", "::ffff:10.0.0.1"),
+	Entry("two slashes", "10.0.0.0/8/8"),
+)
+
+var _ = DescribeTable("ParseCIDRBytes IPv4-mapped CIDRs",
+	func(input, expected string) {
+		cidr, err := ip.ParseCIDRBytes([]byte(input))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cidr).To(Equal(ip.MustParseCIDROrIP(expected)))
+	},
+	Entry("long prefix", "::ffff:10.0.0.0/120", "10.0.0.0/24"),
+	Entry("exactly 96", "::ffff:10.0.0.0/96", "0.0.0.0/0"),
+	Entry("short prefix", "::ffff:10.0.0.0/80", "::ffff:0:0/80"),
+)
+
+func BenchmarkParseCIDRBytes(b *testing.B) {
+	input := []byte("fc00:fe11:1234::/48")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := ip.ParseCIDRBytes(input)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseCIDROrIP(b *testing.B) {
+	input := []byte("fc00:fe11:1234::/48")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := ip.ParseCIDROrIP(string(input))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidPrefixLen = errors.New("invalid prefix length")
+
+// ParseComponent identifies the part of an address or CIDR string that failed to parse.
+type ParseComponent int
+
+const (
+	ParseComponentAddr ParseComponent = iota
+	ParseComponentPrefixLen
+)
+
+func (c ParseComponent) String() string {
+	switch c {
+	case ParseComponentAddr:
+		return "address"
+	case ParseComponentPrefixLen:
+		return "prefix length"
+	}
+	return "unknown"
+}
+
+// ParseError is returned by the parsing functions in this package to describe why an address or CIDR
+// was rejected.  Its Err field is (or wraps) one of ErrInvalidIP, ErrInvalidPrefixLen or
+// ErrNonCanonicalCIDR, so errors.Is can be used to check for a particular failure.
+type ParseError struct {
+	// Input is the full string that was being parsed.
+	Input string
+	// Component is the part of the input that was rejected.
+	Component ParseComponent
+	// Pos is the byte offset in Input at which the rejected component starts.
+	Pos int
+	// Err is the underlying reason.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse %q: %v (%v at offset %d)", e.Input, e.Err, e.Component, e.Pos)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func newAddrParseError(input string, pos int) *ParseError {
+	return &ParseError{Input: input, Component: ParseComponentAddr, Pos: pos, Err: ErrInvalidIP}
+}
+
+func newPrefixLenParseError(input string, pos int) *ParseError {
+	return &ParseError{Input: input, Component: ParseComponentPrefixLen, Pos: pos, Err: ErrInvalidPrefixLen}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"reflect"
+)
+
+// randomV4Bases and randomV6Bases are the prefixes that the random generators draw most of their
+// addresses from.  Concentrating addresses in a handful of ranges makes overlapping and nested CIDRs
+// (the interesting cases for trie-driven logic) far more likely than with uniformly random addresses.
+var (
+	randomV4Bases = []V4CIDR{
+		MustParseCIDROrIP("10.0.0.0/16").(V4CIDR),
+		MustParseCIDROrIP("10.1.0.0/16").(V4CIDR),
+		MustParseCIDROrIP("192.168.0.0/24").(V4CIDR),
+	}
+	randomV6Bases = []V6CIDR{
+		MustParseCIDROrIP("fd00::/112").(V6CIDR),
+		MustParseCIDROrIP("fd00:1::/112").(V6CIDR),
+		MustParseCIDROrIP("2001:db8::/120").(V6CIDR),
+	}
+)
+
+// RandomV4Addr returns a random IPv4 address.  Three quarters of the addresses come from a few small
+// ranges, so that repeated calls produce related addresses; the rest are uniformly distributed.
+func RandomV4Addr(rng *rand.Rand) V4Addr {
+	var u uint32
+	if rng.Intn(4) == 0 {
+		u = rng.Uint32()
+	} else {
+		base := randomV4Bases[rng.Intn(len(randomV4Bases))]
+		hostBits := 32 - uint(base.prefix)
+		u = base.addr.AsUint32() | rng.Uint32()&(1<<hostBits-1)
+	}
+	var a V4Addr
+	binary.BigEndian.PutUint32(a[:], u)
+	return a
+}
+
+// RandomV6Addr returns a random IPv6 address, with a similar distribution to RandomV4Addr.
+func RandomV6Addr(rng *rand.Rand) V6Addr {
+	if rng.Intn(4) == 0 {
+		return v6AddrFromUint64Pair(rng.Uint64(), rng.Uint64())
+	}
+	base := randomV6Bases[rng.Intn(len(randomV6Bases))]
+	hi, lo := base.addr.AsUint64Pair()
+	hostBits := 128 - uint(base.prefix) // Always < 64 for our bases.
+	return v6AddrFromUint64Pair(hi, lo|rng.Uint64()&(1<<hostBits-1))
+}
+
+// RandomV4CIDR returns a random, canonical IPv4 CIDR.  The address is chosen as for RandomV4Addr and the
+// prefix length is biased towards longer prefixes, which are more common in practice.
+func RandomV4CIDR(rng *rand.Rand) V4CIDR {
+	c := V4CIDR{addr: RandomV4Addr(rng), prefix: randomPrefixLen(rng, 32)}
+	return V4CommonPrefix(c, c)
+}
+
+// RandomV6CIDR returns a random, canonical IPv6 CIDR, with a similar distribution to RandomV4CIDR.
+func RandomV6CIDR(rng *rand.Rand) V6CIDR {
+	c := V6CIDR{addr: RandomV6Addr(rng), prefix: randomPrefixLen(rng, 128)}
+	return V6CommonPrefix(c, c)
+}
+
+// randomPrefixLen returns a prefix length in [0, maxLen]; half of the time it is uniform, otherwise
+// it's within 8 bits of maxLen.
+func randomPrefixLen(rng *rand.Rand, maxLen int) uint8 {
+	if rng.Intn(2) == 0 {
+		return uint8(rng.Intn(maxLen + 1))
+	}
+	return uint8(maxLen - rng.Intn(9))
+}
+
+// Generate implements testing/quick.Generator.
+func (V4Addr) Generate(rng *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(RandomV4Addr(rng))
+}
+
+// Generate implements testing/quick.Generator.
+func (V6Addr) Generate(rng *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(RandomV6Addr(rng))
+}
+
+// Generate implements testing/quick.Generator.
+func (V4CIDR) Generate(rng *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(RandomV4CIDR(rng))
+}
+
+// Generate implements testing/quick.Generator.
+func (V6CIDR) Generate(rng *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(RandomV6CIDR(rng))
+}
+
+// ShrinkCIDRs takes a list of CIDRs for which fails returns true and tries to find a shorter sub-list
+// that still fails, by repeatedly removing CIDRs.  It's intended to be used to minimise the input to a
+// failed randomized test before reporting it.  fails is called with scratch slices that it must not
+// retain.  The order of the CIDRs is preserved.
+func ShrinkCIDRs[C CIDR](cidrs []C, fails func([]C) bool) []C {
+	current := append([]C(nil), cidrs...)
+	scratch := make([]C, 0, len(cidrs))
+	for chunk := len(current); chunk >= 1; chunk /= 2 {
+		for start := 0; start < len(current); {
+			end := start + chunk
+			if end > len(current) {
+				end = len(current)
+			}
+			scratch = append(append(scratch[:0], current[:start]...), current[end:]...)
+			if fails(scratch) {
+				current = append(current[:0], scratch...)
+				// Retry at the same position since the remaining CIDRs have shifted down.
+				continue
+			}
+			start = end
+		}
+	}
+	return current
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"math/rand"
+	"testing/quick"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ quick.Generator = ip.V4CIDR{}
+var _ quick.Generator = ip.V6CIDR{}
+var _ quick.Generator = ip.V4Addr{}
+var _ quick.Generator = ip.V6Addr{}
+
+var _ = Describe("Random generators", func() {
+	var rng *rand.Rand
+
+	BeforeEach(func() {
+		rng = rand.New(rand.NewSource(1))
+	})
+
+	It("should generate canonical CIDRs with a spread of prefix lengths", func() {
+		v4Prefixes := map[uint8]bool{}
+		v6Prefixes := map[uint8]bool{}
+		for i := 0; i < 1000; i++ {
+			v4 := ip.RandomV4CIDR(rng)
+			Expect(v4.Canonicalize()).To(Equal(v4))
+			v4Prefixes[v4.Prefix()] = true
+
+			v6 := ip.RandomV6CIDR(rng)
+			Expect(v6.Canonicalize()).To(Equal(v6))
+			Expect(v6.Version()).To(Equal(uint8(6)))
+			v6Prefixes[v6.Prefix()] = true
+		}
+		Expect(len(v4Prefixes)).To(BeNumerically(">", 25))
+		Expect(len(v6Prefixes)).To(BeNumerically(">", 60))
+	})
+
+	It("should generate overlapping CIDRs", func() {
+		trie := ip.NewTrie[struct{}]()
+		var numOverlaps int
+		for i := 0; i < 200; i++ {
+			cidr := ip.RandomV4CIDR(rng)
+			if trie.Intersects(cidr) {
+				numOverlaps++
+			}
+			trie.Update(cidr, struct{}{})
+		}
+		Expect(numOverlaps).To(BeNumerically(">", 50))
+	})
+
+	It("should work with testing/quick", func() {
+		err := quick.Check(func(c ip.V4CIDR, a ip.V6Addr) bool {
+			return c.Supernet(0) == c && a.AsCIDR().Contains(a)
+		}, &quick.Config{Rand: rng})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("ShrinkCIDRs", func() {
+	It("should find a minimal failing input", func() {
+		rng := rand.New(rand.NewSource(1))
+		var cidrs []ip.CIDR
+		for i := 0; i < 100; i++ {
+			cidrs = append(cidrs, ip.RandomV4CIDR(rng))
+		}
+		a := ip.MustParseCIDROrIP("172.16.0.0/12")
+		b := ip.MustParseCIDROrIP("172.16.1.0/24")
+		cidrs = append(cidrs[:30], append([]ip.CIDR{a}, cidrs[30:]...)...)
+		cidrs = append(cidrs, b)
+
+		// "Fails" if the list contains both a and b.
+		fails := func(cs []ip.CIDR) bool {
+			var foundA, foundB bool
+			for _, c := range cs {
+				foundA = foundA || c == a
+				foundB = foundB || c == b
+			}
+			return foundA && foundB
+		}
+		Expect(ip.ShrinkCIDRs(cidrs, fails)).To(Equal([]ip.CIDR{a, b}))
+	})
+
+	It("should return an empty list if that fails", func() {
+		cidrs := []ip.V4CIDR{ip.MustParseCIDROrIP("10.0.0.0/8").(ip.V4CIDR)}
+		Expect(ip.ShrinkCIDRs(cidrs, func([]ip.V4CIDR) bool { return true })).To(BeEmpty())
+	})
+})
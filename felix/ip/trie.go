@@ -17,114 +17,429 @@ package ip
 import (
 	"encoding/binary"
 	"math/bits"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 )
 
-type CIDRTrie struct {
-	root *CIDRNode
+// Trie is a binary (PATRICIA-style) trie of CIDRs, storing a value of type T against each CIDR.  A trie
+// may hold either IPv4 or IPv6 CIDRs but not a mix of the two; mixing IP versions in the same trie results
+// in a panic.  Dual-stack consumers should maintain one trie per IP version.
+//
+// A Trie supports cheap copy-on-write snapshots; see Snapshot.
+type Trie[T any] struct {
+	root *trieNode[T]
+	// numEntries is the number of nodes in the trie that hold a value.
+	numEntries int
+
+	// gen is the generation of this trie.  Nodes that are stamped with a different generation may be
+	// shared with a snapshot (or another trie) and must be copied before they are modified.  Generations
+	// are allocated from a global counter so that no two tries share a generation, except for the zero
+	// generation of a trie that has never shared its nodes.
+	gen uint64
+	// readOnly is set on snapshots; mutating a snapshot results in a panic.
+	readOnly bool
+
+	// freeNodes is a free list of nodes that have been removed from the trie and can be reused, linked
+	// via their first child pointer.  Only nodes that were created by the current generation are
+	// recycled; older nodes may still be referenced by a snapshot.
+	freeNodes    *trieNode[T]
+	numFreeNodes int
 }
 
-type CIDRNode struct {
+// maxFreeTrieNodes limits the number of removed nodes that a trie holds on to for reuse.
+const maxFreeTrieNodes = 1024
+
+// CIDRTrie is a Trie with untyped payloads.
+type CIDRTrie = Trie[interface{}]
+
+// trieNode is a node of a Trie.  Nodes are individually allocated and pointer-linked, rather than
+// stored in a per-trie slice with index-based children, because snapshots, copies and MergeFrom share
+// subtrees between tries; a node can't belong to any one trie's storage.
+type trieNode[T any] struct {
 	cidr     CIDR
-	children [2]*CIDRNode
-	data     interface{}
+	children [2]*trieNode[T]
+	data     T
+	// hasData is true if this node holds a value; false for intermediate nodes, which exist only to
+	// join two subtrees.  We can't use a nil check on data since T may not be nillable.
+	hasData bool
+	// gen is the generation of the trie that created this node.
+	gen uint64
+}
+
+type CIDRNode = trieNode[interface{}]
+
+func NewTrie[T any]() *Trie[T] {
+	return new(Trie[T])
 }
 
 func NewCIDRTrie() *CIDRTrie {
-	return new(CIDRTrie)
+	return NewTrie[interface{}]()
+}
+
+// Snapshot returns a read-only view of the trie's current contents.  The snapshot shares all its nodes
+// with the trie; subsequent updates to the trie copy only the nodes that they modify, leaving the snapshot
+// intact.  A snapshot may be read concurrently with updates to the trie that it came from.
+func (t *Trie[T]) Snapshot() *Trie[T] {
+	snap := &Trie[T]{
+		root:       t.root,
+		numEntries: t.numEntries,
+		gen:        t.gen,
+		readOnly:   true,
+	}
+	// Move this trie onto a new generation so that all the existing nodes are treated as shared.
+	t.gen = nextTrieGen()
+	return snap
+}
+
+var lastTrieGen atomic.Uint64
+
+func nextTrieGen() uint64 {
+	return lastTrieGen.Add(1)
+}
+
+// Copy returns a mutable copy of the trie.  Like a snapshot, the copy initially shares all its nodes with
+// the trie and the two are copied-on-write as they are modified.
+func (t *Trie[T]) Copy() *Trie[T] {
+	tCopy := &Trie[T]{
+		root:       t.root,
+		numEntries: t.numEntries,
+		gen:        nextTrieGen(),
+	}
+	if !t.readOnly {
+		t.gen = nextTrieGen()
+	}
+	return tCopy
+}
+
+// ReadOnly returns true if the trie is a snapshot.
+func (t *Trie[T]) ReadOnly() bool {
+	return t.readOnly
+}
+
+func (t *Trie[T]) checkWritable() {
+	if t.readOnly {
+		logrus.Panic("Attempt to modify a read-only trie snapshot")
+	}
+}
+
+// writable returns a version of n that is safe to modify; either n itself, if it was created by this
+// generation of the trie, or a copy of n.  The caller is responsible for linking the copy into the trie.
+func (t *Trie[T]) writable(n *trieNode[T]) *trieNode[T] {
+	if n.gen == t.gen {
+		return n
+	}
+	nCopy := *n
+	nCopy.gen = t.gen
+	return &nCopy
+}
+
+func (t *Trie[T]) newNode(cidr CIDR) *trieNode[T] {
+	if n := t.freeNodes; n != nil {
+		t.freeNodes = n.children[0]
+		t.numFreeNodes--
+		n.children[0] = nil
+		n.cidr = cidr
+		n.gen = t.gen
+		return n
+	}
+	return &trieNode[T]{
+		cidr: cidr,
+		gen:  t.gen,
+	}
+}
+
+// releaseNode adds a node that has been unlinked from the trie to the free list, if it is safe to do so.
+func (t *Trie[T]) releaseNode(n *trieNode[T]) {
+	if n.gen != t.gen || t.numFreeNodes >= maxFreeTrieNodes {
+		// Node may be shared with a snapshot, or we already have enough spare nodes.
+		return
+	}
+	// Clear the node so that we don't hold on to the data.
+	*n = trieNode[T]{children: [2]*trieNode[T]{t.freeNodes}}
+	t.freeNodes = n
+	t.numFreeNodes++
+}
+
+// releaseSubtree releases every node in the subtree rooted at n and returns the number of entries that
+// it contained.
+func (t *Trie[T]) releaseSubtree(n *trieNode[T]) int {
+	if n == nil {
+		return 0
+	}
+	count := t.releaseSubtree(n.children[0]) + t.releaseSubtree(n.children[1])
+	if n.hasData {
+		count++
+	}
+	t.releaseNode(n)
+	return count
+}
+
+func (t *Trie[T]) newLeaf(cidr CIDR, value T) *trieNode[T] {
+	n := t.newNode(cidr)
+	n.data = value
+	n.hasData = true
+	return n
 }
 
-func (t *CIDRTrie) Delete(cidr CIDR) {
+func (t *Trie[T]) Delete(cidr CIDR) {
+	t.checkWritable()
 	if t.root == nil {
 		// Trie is empty.
 		return
 	}
-	if pfx := CommonPrefix(t.root.cidr, cidr); pfx != t.root.cidr {
+	if commonPrefixLen(t.root.cidr, cidr) != t.root.cidr.Prefix() {
 		// Trie does not contain prefix.
 		return
 	}
-	t.root = deleteInternal(t.root, cidr)
+	var deleted bool
+	t.root, deleted = t.deleteInternal(t.root, cidr)
+	if deleted {
+		t.numEntries--
+	}
 }
 
-func deleteInternal(n *CIDRNode, cidr CIDR) *CIDRNode {
+// deleteInternal removes the given CIDR from the subtree rooted at n.  It returns the new root of the
+// subtree and whether an entry was actually removed.
+func (t *Trie[T]) deleteInternal(n *trieNode[T], cidr CIDR) (*trieNode[T], bool) {
 	if n.cidr.Version() != cidr.Version() {
 		logrus.WithFields(logrus.Fields{"n.cidr": n.cidr, "cidr": cidr}).Panic("Mismatched CIDR IP versions")
 	}
 
-	if !n.cidr.Contains(cidr.Addr()) {
+	if !containsAddrOf(n.cidr, cidr) {
 		// Not in trie.
-		return n
+		return n, false
 	}
 
 	if cidr == n.cidr {
+		if !n.hasData {
+			// Intermediate node, CIDR isn't actually in the trie.
+			return n, false
+		}
 		// Found the node.  If either child is nil then this was just an intermediate node
 		// and it no longer has any data in it so we replace it by its remaining child.
 		if n.children[0] == nil {
 			// 0th child is nil, return the other child (or nil if both children were nil)
-			return n.children[1]
+			child := n.children[1]
+			t.releaseNode(n)
+			return child, true
 		} else if n.children[1] == nil {
 			// oth child non-nil but 1st child is nil, return oth child.
-			return n.children[0]
+			child := n.children[0]
+			t.releaseNode(n)
+			return child, true
 		} else {
 			// Intermediate node but it has two children so it is still required.
-			n.data = nil
-			return n
+			n = t.writable(n)
+			n.clearData()
+			return n, true
 		}
 	}
 
 	// If we get here, then this node is a parent of the CIDR we're looking for.
 	// Figure out which child to recurse on.
-	childIdx := cidr.Addr().NthBit(uint(n.cidr.Prefix() + 1))
+	childIdx := cidrNthBit(cidr, uint(n.cidr.Prefix()+1))
 	oldChild := n.children[childIdx]
 	if oldChild == nil {
-		return n
+		return n, false
 	}
-	newChild := deleteInternal(oldChild, cidr)
-	n.children[childIdx] = newChild
-	if newChild == nil {
-		// One of our children has been deleted completely, check if this node is an intermediate node
+	newChild, deleted := t.deleteInternal(oldChild, cidr)
+	if newChild == oldChild {
+		return n, deleted
+	}
+	if newChild == nil && !n.hasData {
+		// One of our children has been deleted completely and this node is an intermediate node
 		// that needs to be cleaned up.
-		if n.data == nil {
-			return n.children[1-childIdx]
+		otherChild := n.children[1-childIdx]
+		t.releaseNode(n)
+		return otherChild, deleted
+	}
+	n = t.writable(n)
+	n.children[childIdx] = newChild
+	return n, deleted
+}
+
+// DeletePrefix removes all entries that fall within the given CIDR (including the CIDR itself) in a
+// single traversal.  It returns the number of entries removed.
+func (t *Trie[T]) DeletePrefix(cidr CIDR) int {
+	t.checkWritable()
+	var numRemoved int
+	t.root, numRemoved = t.deletePrefixInternal(t.root, cidr)
+	t.numEntries -= numRemoved
+	return numRemoved
+}
+
+func (t *Trie[T]) deletePrefixInternal(n *trieNode[T], cidr CIDR) (*trieNode[T], int) {
+	if n == nil {
+		return nil, 0
+	}
+
+	common := commonPrefixLen(n.cidr, cidr)
+	if common == cidr.Prefix() {
+		// This node is inside the target CIDR, remove its whole subtree.
+		return nil, t.releaseSubtree(n)
+	}
+	if common != n.cidr.Prefix() {
+		// The CIDRs are disjoint.
+		return n, 0
+	}
+
+	// If we get here, then this node is a parent of the CIDR we're looking for.
+	// Figure out which child to recurse on.
+	childIdx := cidrNthBit(cidr, uint(n.cidr.Prefix()+1))
+	oldChild := n.children[childIdx]
+	newChild, numRemoved := t.deletePrefixInternal(oldChild, cidr)
+	if newChild == oldChild {
+		return n, numRemoved
+	}
+	if newChild == nil && !n.hasData {
+		// This was an intermediate node and it now only has one child, replace it by that child.
+		otherChild := n.children[1-childIdx]
+		t.releaseNode(n)
+		return otherChild, numRemoved
+	}
+	n = t.writable(n)
+	n.children[childIdx] = newChild
+	return n, numRemoved
+}
+
+// DeleteIf removes every entry for which pred returns true, in a single traversal, and returns the number
+// of entries removed.  pred is called once for each entry, in address order; it must not modify the trie.
+func (t *Trie[T]) DeleteIf(pred func(cidr CIDR, data T) bool) int {
+	t.checkWritable()
+	var numRemoved int
+	t.root, numRemoved = t.deleteIfInternal(t.root, pred)
+	t.numEntries -= numRemoved
+	return numRemoved
+}
+
+func (t *Trie[T]) deleteIfInternal(n *trieNode[T], pred func(cidr CIDR, data T) bool) (*trieNode[T], int) {
+	if n == nil {
+		return nil, 0
+	}
+
+	// Check this node before its children so that pred sees the entries in address order.
+	removeData := n.hasData && pred(n.cidr, n.data)
+	oldChildren := n.children
+	child0, removed0 := t.deleteIfInternal(oldChildren[0], pred)
+	child1, removed1 := t.deleteIfInternal(oldChildren[1], pred)
+	numRemoved := removed0 + removed1
+	if removeData {
+		numRemoved++
+	}
+
+	if !n.hasData || removeData {
+		// This node no longer has data so it's only needed if it still has two children.
+		if child0 == nil {
+			t.releaseNode(n)
+			return child1, numRemoved
+		}
+		if child1 == nil {
+			t.releaseNode(n)
+			return child0, numRemoved
 		}
 	}
-	return n
+	if numRemoved == 0 {
+		return n, 0
+	}
+	n = t.writable(n)
+	n.children = [2]*trieNode[T]{child0, child1}
+	if removeData {
+		n.clearData()
+	}
+	return n, numRemoved
+}
+
+func (n *trieNode[T]) clearData() {
+	var zero T
+	n.data = zero
+	n.hasData = false
 }
 
-type CIDRTrieEntry struct {
+type TrieEntry[T any] struct {
 	CIDR CIDR
-	Data interface{}
+	Data T
+}
+
+type CIDRTrieEntry = TrieEntry[interface{}]
+
+// Get returns the value stored against exactly the given CIDR, or the zero value of T if there is no
+// such entry.
+func (t *Trie[T]) Get(cidr CIDR) T {
+	data, _ := t.root.get(cidr)
+	return data
 }
 
-func (t *CIDRTrie) Get(cidr CIDR) interface{} {
+// GetExact returns the value stored against exactly the given CIDR.  ok is false if there is no such
+// entry.  Unlike Get, it distinguishes a missing entry from a stored zero value.
+func (t *Trie[T]) GetExact(cidr CIDR) (data T, ok bool) {
 	return t.root.get(cidr)
 }
 
-// LookupPath looks up the given CIDR in the trie.  It returns a slice containing a CIDRTrieEntry for each
+// LookupPath looks up the given CIDR in the trie.  It returns a slice containing a TrieEntry for each
 // CIDR in the trie that encloses the given CIDR.  If buffer is non-nil, then it is used to store the entries;
 // if it is too short append() is used to extend it and the updated slice is returned.
 //
 // If the CIDR is not in the trie then an empty slice is returned.
-func (t *CIDRTrie) LookupPath(buffer []CIDRTrieEntry, cidr CIDR) []CIDRTrieEntry {
+func (t *Trie[T]) LookupPath(buffer []TrieEntry[T], cidr CIDR) []TrieEntry[T] {
 	return t.root.lookupPath(buffer[:0], cidr)
 }
 
-// LPM does a longest prefix match on the trie
-func (t *CIDRTrie) LPM(cidr CIDR) (CIDR, interface{}) {
+// VisitPath calls f for each CIDR in the trie that contains (or is equal to) the given CIDR, most
+// specific first.  If f returns false, iteration stops, so a caller that only needs the most specific
+// match can stop after the first call.  Unlike LookupPath, the given CIDR doesn't need to be in the trie
+// itself; pass addr.AsCIDR() to visit the entries that contain an address.
+//
+// VisitPath does not allocate.
+func (t *Trie[T]) VisitPath(cidr CIDR, f func(cidr CIDR, data T) bool) {
+	// The path can't be longer than one node per prefix length, so we can record it on the stack.
+	var path [129]*trieNode[T]
+	depth := 0
+	for n := t.root; n != nil && n.cidr.ContainsCIDR(cidr); {
+		if n.hasData {
+			path[depth] = n
+			depth++
+		}
+		if n.cidr.Prefix() == cidr.Prefix() {
+			break
+		}
+		n = n.children[cidrNthBit(cidr, uint(n.cidr.Prefix()+1))]
+	}
+	for i := depth - 1; i >= 0; i-- {
+		if !f(path[i].cidr, path[i].data) {
+			return
+		}
+	}
+}
+
+// cidrNthBit returns the nth bit of the CIDR's address.  Unlike cidr.Addr().NthBit(n), it doesn't need to
+// box the address.
+func cidrNthBit(cidr CIDR, n uint) int {
+	switch c := cidr.(type) {
+	case V4CIDR:
+		return c.addr.NthBit(n)
+	case V6CIDR:
+		return c.addr.NthBit(n)
+	}
+	return cidr.Addr().NthBit(n)
+}
+
+// LPM does a longest prefix match on the trie.  If there is no match, it returns the zero CIDR of the
+// appropriate IP version and the zero value of T.
+func (t *Trie[T]) LPM(cidr CIDR) (CIDR, T) {
 	n := t.root
-	var match *CIDRNode
+	var match *trieNode[T]
 
 	for {
 		if n == nil {
 			break
 		}
 
-		if !n.cidr.Contains(cidr.Addr()) {
+		if !containsAddrOf(n.cidr, cidr) {
 			break
 		}
 
-		if n.data != nil {
+		if n.hasData {
 			match = n
 		}
 
@@ -134,16 +449,17 @@ func (t *CIDRTrie) LPM(cidr CIDR) (CIDR, interface{}) {
 
 		// If we get here, then this node is a parent of the CIDR we're looking for.
 		// Figure out which child to recurse on.
-		childIdx := cidr.Addr().NthBit(uint(n.cidr.Prefix() + 1))
+		childIdx := cidrNthBit(cidr, uint(n.cidr.Prefix()+1))
 		n = n.children[childIdx]
 	}
 
-	if match == nil || match.data == nil {
+	if match == nil {
+		var zero T
 		switch cidr.Version() {
 		case 4:
-			return V4CIDR{}, nil
+			return V4CIDR{}, zero
 		case 6:
-			return V6CIDR{}, nil
+			return V6CIDR{}, zero
 		default:
 			logrus.WithField("cidr", cidr).Panic("Invalid CIDR IP version")
 		}
@@ -151,7 +467,50 @@ func (t *CIDRTrie) LPM(cidr CIDR) (CIDR, interface{}) {
 	return match.cidr, match.data
 }
 
-func (n *CIDRNode) lookupPath(buffer []CIDRTrieEntry, cidr CIDR) []CIDRTrieEntry {
+// LookupLongestPrefix returns the most specific CIDR in the trie that contains the given address, along
+// with its value.  The final return value is false if no CIDR in the trie contains the address.
+func (t *Trie[T]) LookupLongestPrefix(addr Addr) (CIDR, T, bool) {
+	maxLen := uint8(32)
+	if addr.Version() == 6 {
+		maxLen = 128
+	}
+	var match *trieNode[T]
+	for n := t.root; n != nil && n.cidr.Contains(addr); {
+		if n.hasData {
+			match = n
+		}
+		if n.cidr.Prefix() == maxLen {
+			// Full-length CIDR, can't have any children.
+			break
+		}
+		n = n.children[addr.NthBit(uint(n.cidr.Prefix()+1))]
+	}
+	if match == nil {
+		var zero T
+		return nil, zero, false
+	}
+	return match.cidr, match.data, true
+}
+
+// LookupShortestCovering returns the least specific CIDR in the trie that contains (or is equal to)
+// the given CIDR, along with its value.  For example, if the trie holds IP pools, it returns the
+// outermost pool that a workload CIDR belongs to.  The final return value is false if no CIDR in the
+// trie covers the given CIDR.
+func (t *Trie[T]) LookupShortestCovering(cidr CIDR) (CIDR, T, bool) {
+	for n := t.root; n != nil && n.cidr.ContainsCIDR(cidr); {
+		if n.hasData {
+			return n.cidr, n.data, true
+		}
+		if n.cidr.Prefix() == cidr.Prefix() {
+			break
+		}
+		n = n.children[cidrNthBit(cidr, uint(n.cidr.Prefix()+1))]
+	}
+	var zero T
+	return nil, zero, false
+}
+
+func (n *trieNode[T]) lookupPath(buffer []TrieEntry[T], cidr CIDR) []TrieEntry[T] {
 	if n == nil {
 		return buffer[:0]
 	}
@@ -160,17 +519,17 @@ func (n *CIDRNode) lookupPath(buffer []CIDRTrieEntry, cidr CIDR) []CIDRTrieEntry
 		logrus.WithFields(logrus.Fields{"n.cidr": n.cidr, "cidr": cidr}).Panic("Mismatched CIDR IP versions")
 	}
 
-	if !n.cidr.Contains(cidr.Addr()) {
+	if !containsAddrOf(n.cidr, cidr) {
 		// Not in trie.
 		return nil
 	}
 
-	if n.data != nil {
-		buffer = append(buffer, CIDRTrieEntry{CIDR: n.cidr, Data: n.data})
+	if n.hasData {
+		buffer = append(buffer, TrieEntry[T]{CIDR: n.cidr, Data: n.data})
 	}
 
 	if cidr == n.cidr {
-		if n.data == nil {
+		if !n.hasData {
 			// CIDR is an intermediate node with no data so CIDR isn't actually in the trie.
 			return nil
 		}
@@ -179,106 +538,127 @@ func (n *CIDRNode) lookupPath(buffer []CIDRTrieEntry, cidr CIDR) []CIDRTrieEntry
 
 	// If we get here, then this node is a parent of the CIDR we're looking for.
 	// Figure out which child to recurse on.
-	childIdx := cidr.Addr().NthBit(uint(n.cidr.Prefix() + 1))
+	childIdx := cidrNthBit(cidr, uint(n.cidr.Prefix()+1))
 	child := n.children[childIdx]
 	return child.lookupPath(buffer, cidr)
 }
 
-func (n *CIDRNode) get(cidr CIDR) interface{} {
+func (n *trieNode[T]) get(cidr CIDR) (T, bool) {
+	var zero T
 	if n == nil {
-		return nil
+		return zero, false
 	}
 
 	if n.cidr.Version() != cidr.Version() {
 		logrus.WithFields(logrus.Fields{"n.cidr": n.cidr, "cidr": cidr}).Panic("Mismatched CIDR IP versions")
 	}
 
-	if !n.cidr.Contains(cidr.Addr()) {
+	if !containsAddrOf(n.cidr, cidr) {
 		// Not in trie.
-		return nil
+		return zero, false
 	}
 
 	if cidr == n.cidr {
-		if n.data == nil {
+		if !n.hasData {
 			// CIDR is an intermediate node with no data so CIDR isn't actually in the trie.
-			return nil
+			return zero, false
 		}
-		return n.data
+		return n.data, true
 	}
 
 	// If we get here, then this node is a parent of the CIDR we're looking for.
 	// Figure out which child to recurse on.
-	childIdx := cidr.Addr().NthBit(uint(n.cidr.Prefix() + 1))
+	childIdx := cidrNthBit(cidr, uint(n.cidr.Prefix()+1))
 	child := n.children[childIdx]
 	return child.get(cidr)
 }
 
-func (t *CIDRTrie) CoveredBy(cidr CIDR) bool {
-	pfx := CommonPrefix(t.root.cidr, cidr)
-	return pfx == cidr
+// Descendants returns a TrieEntry for each CIDR in the trie that falls within the given CIDR (including
+// the CIDR itself, if present).  As with LookupPath, buffer is used to store the entries if non-nil and
+// the (possibly extended) slice is returned.
+func (t *Trie[T]) Descendants(buffer []TrieEntry[T], cidr CIDR) []TrieEntry[T] {
+	buffer = buffer[:0]
+	n := t.root
+	for n != nil {
+		common := commonPrefixLen(n.cidr, cidr)
+		if common == cidr.Prefix() {
+			// This node is inside the target CIDR, so is its whole subtree.
+			return n.appendTo(buffer)
+		}
+		if common != n.cidr.Prefix() {
+			// The CIDRs are disjoint.
+			break
+		}
+		// This node is a strict parent of the target CIDR, follow the relevant child.
+		n = n.children[cidrNthBit(cidr, uint(n.cidr.Prefix()+1))]
+	}
+	return buffer
+}
+
+func (t *Trie[T]) CoveredBy(cidr CIDR) bool {
+	return commonPrefixLen(t.root.cidr, cidr) == cidr.Prefix()
 }
 
-func (t *CIDRTrie) Covers(cidr CIDR) bool {
+func (t *Trie[T]) Covers(cidr CIDR) bool {
 	return t.root.covers(cidr)
 }
 
-func (n *CIDRNode) covers(cidr CIDR) bool {
+func (n *trieNode[T]) covers(cidr CIDR) bool {
 	if n == nil {
 		return false
 	}
 
-	commonPfx := CommonPrefix(n.cidr, cidr)
-	if commonPfx != n.cidr {
+	if commonPrefixLen(n.cidr, cidr) != n.cidr.Prefix() {
 		// Not in trie.
 		return false
 	}
 
-	if n.data != nil {
+	if n.hasData {
 		return true
 	}
 
 	// If we get here, then this node is a parent of the CIDR we're looking for.
 	// Figure out which child to recurse on.
-	childIdx := cidr.Addr().NthBit(uint(n.cidr.Prefix() + 1))
+	childIdx := cidrNthBit(cidr, uint(n.cidr.Prefix()+1))
 	child := n.children[childIdx]
 	return child.covers(cidr)
 }
 
-func (t *CIDRTrie) Intersects(cidr CIDR) bool {
+func (t *Trie[T]) Intersects(cidr CIDR) bool {
 	return t.root.intersects(cidr)
 }
 
-func (n *CIDRNode) intersects(cidr CIDR) bool {
+func (n *trieNode[T]) intersects(cidr CIDR) bool {
 	if n == nil {
 		return false
 	}
 
-	common := CommonPrefix(n.cidr, cidr)
+	common := commonPrefixLen(n.cidr, cidr)
 
-	if common == cidr {
+	if common == cidr.Prefix() {
 		// This node's CIDR is contained within the target CIDR so we must have
 		// some value that is inside the target CIDR.
 		return true
 	}
 
-	if common != n.cidr {
+	if common != n.cidr.Prefix() {
 		// The CIDRs are disjoint.
 		return false
 	}
 
 	// If we get here, then this node is a parent of the CIDR we're looking for.
 	// Figure out which child to recurse on.
-	childIdx := cidr.Addr().NthBit(uint(n.cidr.Prefix() + 1))
+	childIdx := cidrNthBit(cidr, uint(n.cidr.Prefix()+1))
 	child := n.children[childIdx]
 	return child.intersects(cidr)
 }
 
-func (n *CIDRNode) appendTo(s []CIDRTrieEntry) []CIDRTrieEntry {
+func (n *trieNode[T]) appendTo(s []TrieEntry[T]) []TrieEntry[T] {
 	if n == nil {
 		return s
 	}
-	if n.data != nil {
-		s = append(s, CIDRTrieEntry{
+	if n.hasData {
+		s = append(s, TrieEntry[T]{
 			CIDR: n.cidr,
 			Data: n.data,
 		})
@@ -288,12 +668,12 @@ func (n *CIDRNode) appendTo(s []CIDRTrieEntry) []CIDRTrieEntry {
 	return s
 }
 
-func (n *CIDRNode) visit(f func(cidr CIDR, data interface{}) bool) bool {
+func (n *trieNode[T]) visit(f func(cidr CIDR, data T) bool) bool {
 	if n == nil {
 		return true
 	}
 
-	if n.data != nil {
+	if n.hasData {
 		keepGoing := f(n.cidr, n.data)
 		if !keepGoing {
 			return false
@@ -306,16 +686,26 @@ func (n *CIDRNode) visit(f func(cidr CIDR, data interface{}) bool) bool {
 	return n.children[1].visit(f)
 }
 
-func (t *CIDRTrie) ToSlice() []CIDRTrieEntry {
+// Len returns the number of entries in the trie.  It does not count intermediate nodes.
+func (t *Trie[T]) Len() int {
+	return t.numEntries
+}
+
+func (t *Trie[T]) ToSlice() []TrieEntry[T] {
 	return t.root.appendTo(nil)
 }
 
-func (t *CIDRTrie) Visit(f func(cidr CIDR, data interface{}) bool) {
+// Visit calls f for each entry in the trie, in address order with enclosing CIDRs before the CIDRs that
+// they contain.  If f returns false, iteration stops.  Unlike ToSlice, Visit does not allocate.
+func (t *Trie[T]) Visit(f func(cidr CIDR, data T) bool) {
 	t.root.visit(f)
 }
 
-func (t *CIDRTrie) Update(cidr CIDR, value interface{}) {
-	if value == nil {
+// Update stores the given value against the given CIDR, replacing any existing value.  For a CIDRTrie,
+// storing a nil interface is not allowed and results in a panic.
+func (t *Trie[T]) Update(cidr CIDR, value T) {
+	t.checkWritable()
+	if any(value) == nil {
 		logrus.Panic("Can't store nil in a CIDRTrie")
 	}
 	parentsPtr := &t.root
@@ -324,17 +714,20 @@ func (t *CIDRTrie) Update(cidr CIDR, value interface{}) {
 	for {
 		if thisNode == nil {
 			// We've run off the end of the tree, create new child to hold this data.
-			newNode := &CIDRNode{
-				cidr: cidr,
-				data: value,
-			}
-			*parentsPtr = newNode
+			*parentsPtr = t.newLeaf(cidr, value)
+			t.numEntries++
 			return
 		}
 
 		if thisNode.cidr == cidr {
 			// Found a node with exactly this CIDR, just update the data.
+			thisNode = t.writable(thisNode)
+			*parentsPtr = thisNode
+			if !thisNode.hasData {
+				t.numEntries++
+			}
 			thisNode.data = value
+			thisNode.hasData = true
 			return
 		}
 
@@ -343,39 +736,35 @@ func (t *CIDRTrie) Update(cidr CIDR, value interface{}) {
 		// - The new CIDR contains this node, in which case we need to insert a new node as the parent of this one.
 		// - The two CIDRs are disjoint, in which case we need to insert a new intermediate node as the parent of
 		//   thisNode and the new CIDR.
-		commonPrefix := CommonPrefix(cidr, thisNode.cidr)
+		commonLen := commonPrefixLen(cidr, thisNode.cidr)
 
-		if commonPrefix.Prefix() == thisNode.cidr.Prefix() {
+		if commonLen == thisNode.cidr.Prefix() {
 			// Common is this node's CIDR so this node is parent of the new CIDR. Figure out which child to recurse on.
-			childIdx := cidr.Addr().NthBit(uint(commonPrefix.Prefix() + 1))
+			childIdx := cidrNthBit(cidr, uint(commonLen+1))
+			thisNode = t.writable(thisNode)
+			*parentsPtr = thisNode
 			parentsPtr = &thisNode.children[childIdx]
 			thisNode = thisNode.children[childIdx]
 			continue
 		}
 
-		if commonPrefix.Prefix() == cidr.Prefix() {
+		if commonLen == cidr.Prefix() {
 			// Common is new CIDR so this node is a child of the new CIDR. Insert new node.
-			newNode := &CIDRNode{
-				cidr: cidr,
-				data: value,
-			}
-			childIdx := thisNode.cidr.Addr().NthBit(uint(commonPrefix.Prefix() + 1))
+			newNode := t.newLeaf(cidr, value)
+			childIdx := cidrNthBit(thisNode.cidr, uint(commonLen+1))
 			newNode.children[childIdx] = thisNode
 			*parentsPtr = newNode
+			t.numEntries++
 			return
 		}
 
 		// Neither CIDR contains the other.  Create an internal node with this node and new CIDR as children.
-		newInternalNode := &CIDRNode{
-			cidr: commonPrefix,
-		}
-		childIdx := thisNode.cidr.Addr().NthBit(uint(commonPrefix.Prefix() + 1))
+		newInternalNode := t.newNode(CommonPrefix(cidr, thisNode.cidr))
+		childIdx := cidrNthBit(thisNode.cidr, uint(commonLen+1))
 		newInternalNode.children[childIdx] = thisNode
-		newInternalNode.children[1-childIdx] = &CIDRNode{
-			cidr: cidr,
-			data: value,
-		}
+		newInternalNode.children[1-childIdx] = t.newLeaf(cidr, value)
 		*parentsPtr = newInternalNode
+		t.numEntries++
 		return
 	}
 }
@@ -399,77 +788,94 @@ func CommonPrefix(a, b CIDR) CIDR {
 }
 
 func V4CommonPrefix(a, b V4CIDR) V4CIDR {
-	var result V4CIDR
-	var maxLen uint8
-	if b.prefix < a.prefix {
-		maxLen = b.prefix
-	} else {
-		maxLen = a.prefix
-	}
-
-	a32 := a.addr.AsUint32()
-	b32 := b.addr.AsUint32()
-
-	xored := a32 ^ b32 // Has a zero bit wherever the two values are the same.
-	commonPrefixLen := uint8(bits.LeadingZeros32(xored))
-	if commonPrefixLen > maxLen {
-		result.prefix = maxLen
-	} else {
-		result.prefix = commonPrefixLen
-	}
-
-	mask := uint32(0xffffffff) << (32 - result.prefix)
-	commonPrefix32 := mask & a32
-	binary.BigEndian.PutUint32(result.addr[:], commonPrefix32)
-
+	prefixLen := v4CommonPrefixLen(a, b)
+	// Shifting a uint32 by 32 gives 0 so this works for /0 too.
+	mask := ^uint32(0) << (32 - prefixLen)
+	result := V4CIDR{prefix: prefixLen}
+	binary.BigEndian.PutUint32(result.addr[:], a.addr.AsUint32()&mask)
 	return result
 }
 
 func V6CommonPrefix(a, b V6CIDR) V6CIDR {
-	var result V6CIDR
-	var maxLen uint8
-
-	if b.prefix < a.prefix {
-		maxLen = b.prefix
-	} else {
-		maxLen = a.prefix
+	aHi, aLo := a.addr.AsUint64Pair()
+	bHi, bLo := b.addr.AsUint64Pair()
+	prefixLen := v6CommonPrefixLenPairs(aHi, aLo, bHi, bLo, minUint8(a.prefix, b.prefix))
+	// Shifting a uint64 by 64 or more gives 0, so this gives the right masks without branching on which
+	// half the prefix ends in.
+	maskHi := ^uint64(0) << (64 - uint(minUint8(prefixLen, 64)))
+	maskLo := ^uint64(0) << (128 - uint(maxUint8(prefixLen, 64)))
+	return V6CIDR{
+		addr:   v6AddrFromUint64Pair(aHi&maskHi, aLo&maskLo),
+		prefix: prefixLen,
 	}
+}
 
-	a_h, a_l := a.addr.AsUint64Pair()
-	b_h, b_l := b.addr.AsUint64Pair()
+// v4CommonPrefixLen returns V4CommonPrefix(a, b).prefix without building the CIDR.  The first differing
+// bit of the two addresses is the first set bit of their XOR.
+func v4CommonPrefixLen(a, b V4CIDR) uint8 {
+	diffLen := uint8(bits.LeadingZeros32(a.addr.AsUint32() ^ b.addr.AsUint32()))
+	return minUint8(minUint8(a.prefix, b.prefix), diffLen)
+}
 
-	xored_h := a_h ^ b_h // Has a zero bit wherever the two values are the same.
-	xored_l := a_l ^ b_l
+// v6CommonPrefixLen returns V6CommonPrefix(a, b).prefix without building the CIDR.
+func v6CommonPrefixLen(a, b V6CIDR) uint8 {
+	aHi, aLo := a.addr.AsUint64Pair()
+	bHi, bLo := b.addr.AsUint64Pair()
+	return v6CommonPrefixLenPairs(aHi, aLo, bHi, bLo, minUint8(a.prefix, b.prefix))
+}
 
-	commonPrefixLen := uint8(bits.LeadingZeros64(xored_h))
+func v6CommonPrefixLenPairs(aHi, aLo, bHi, bLo uint64, maxLen uint8) uint8 {
+	diffLen := bits.LeadingZeros64(aHi ^ bHi)
+	if diffLen == 64 {
+		diffLen += bits.LeadingZeros64(aLo ^ bLo)
+	}
+	return minUint8(maxLen, uint8(diffLen))
+}
 
-	if xored_h == 0 {
-		// This means a_h == b_h and commonPrefixLen will be > 64. The first
-		// 8 bytes of the result will be equal to a_h (and b_h), last 8 will
-		// be the common prefix of a_l and b_l.
-		commonPrefixLen = 64 + uint8(bits.LeadingZeros64(xored_l))
-		binary.BigEndian.PutUint64(result.addr[:8], a_h)
-		if commonPrefixLen > maxLen {
-			result.prefix = maxLen
-		} else {
-			result.prefix = commonPrefixLen
+// commonPrefixLen returns CommonPrefix(a, b).Prefix().  Unlike CommonPrefix, it doesn't need to box a
+// new CIDR so it's suitable for use on every step of a trie traversal.
+func commonPrefixLen(a, b CIDR) uint8 {
+	switch a := a.(type) {
+	case V4CIDR:
+		if b, ok := b.(V4CIDR); ok {
+			return v4CommonPrefixLen(a, b)
 		}
-		mask := uint64(0xffffffffffffffff) << (128 - result.prefix)
-		commonPrefix64 := mask & a_l
-		binary.BigEndian.PutUint64(result.addr[8:], commonPrefix64)
-	} else {
-		// This means commonPrefixLen will be < 64. Just the first 8 bytes of
-		// the result will be filled with the common prefix of a_h and b_h,
-		// last 8 will be 0.
-		if commonPrefixLen > maxLen {
-			result.prefix = maxLen
-		} else {
-			result.prefix = commonPrefixLen
+	case V6CIDR:
+		if b, ok := b.(V6CIDR); ok {
+			return v6CommonPrefixLen(a, b)
 		}
-		mask := uint64(0xffffffffffffffff) << (64 - result.prefix)
-		commonPrefix64 := mask & a_h
-		binary.BigEndian.PutUint64(result.addr[:8], commonPrefix64)
 	}
+	logrus.WithFields(logrus.Fields{"a": a, "b": b}).Panic("Mismatched CIDR IP versions")
+	return 0
+}
 
-	return result
+// containsAddrOf returns outer.Contains(inner.Addr()) without boxing inner's address.
+func containsAddrOf(outer, inner CIDR) bool {
+	switch o := outer.(type) {
+	case V4CIDR:
+		if i, ok := inner.(V4CIDR); ok {
+			return o.ContainsV4(i.addr)
+		}
+	case V6CIDR:
+		if i, ok := inner.(V6CIDR); ok {
+			return o.ContainsV6(i.addr)
+		}
+	}
+	return false
+}
+
+// minUint8 and maxUint8 return the smaller and larger of a and b; the compiler turns them into
+// conditional moves.
+func minUint8(a, b uint8) uint8 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxUint8(a, b uint8) uint8 {
+	if a > b {
+		return a
+	}
+	return b
 }
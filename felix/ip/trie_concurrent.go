@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ConcurrentTrie wraps a Trie so that it can be read from many goroutines without locking while a
+// writer makes updates.  Readers always see a consistent, read-only version of the trie.  Writers make
+// their changes to a private copy of the trie and then atomically publish a new version, which shares
+// all unmodified nodes with the previous version (RCU-style).
+type ConcurrentTrie[T any] struct {
+	// writeLock serialises writers.  Readers never take it.
+	writeLock sync.Mutex
+	// writer is the private, mutable copy of the trie.  Only accessed with writeLock held.
+	writer *Trie[T]
+	// current is the most recently published snapshot.
+	current atomic.Pointer[Trie[T]]
+}
+
+func NewConcurrentTrie[T any]() *ConcurrentTrie[T] {
+	t := &ConcurrentTrie[T]{
+		writer: NewTrie[T](),
+	}
+	t.current.Store(t.writer.Snapshot())
+	return t
+}
+
+// Load returns the current version of the trie.  The returned trie is read-only and is never modified
+// so it can be used for as many lookups as the caller needs; it won't reflect subsequent updates.
+func (t *ConcurrentTrie[T]) Load() *Trie[T] {
+	return t.current.Load()
+}
+
+// Update calls f with the mutable copy of the trie and then publishes the result to readers.  Updates
+// from multiple goroutines are serialised; readers see all of the changes made by f at once.
+func (t *ConcurrentTrie[T]) Update(f func(trie *Trie[T])) {
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+	f(t.writer)
+	t.current.Store(t.writer.Snapshot())
+}
+
+func (t *ConcurrentTrie[T]) Get(cidr CIDR) T {
+	return t.Load().Get(cidr)
+}
+
+func (t *ConcurrentTrie[T]) LPM(cidr CIDR) (CIDR, T) {
+	return t.Load().LPM(cidr)
+}
+
+func (t *ConcurrentTrie[T]) LookupLongestPrefix(addr Addr) (CIDR, T, bool) {
+	return t.Load().LookupLongestPrefix(addr)
+}
+
+func (t *ConcurrentTrie[T]) Len() int {
+	return t.Load().Len()
+}
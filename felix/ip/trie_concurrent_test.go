@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = Describe("ConcurrentTrie", func() {
+	var trie *ip.ConcurrentTrie[int]
+
+	BeforeEach(func() {
+		trie = ip.NewConcurrentTrie[int]()
+	})
+
+	It("should start empty", func() {
+		Expect(trie.Len()).To(Equal(0))
+		_, _, ok := trie.LookupLongestPrefix(ip.FromString("10.0.0.1"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should publish updates atomically", func() {
+		before := trie.Load()
+		trie.Update(func(t *ip.Trie[int]) {
+			t.Update(ip.MustParseCIDROrIP("10.0.0.0/8"), 1)
+			t.Update(ip.MustParseCIDROrIP("10.0.1.0/24"), 2)
+		})
+		Expect(before.Len()).To(Equal(0))
+		Expect(trie.Len()).To(Equal(2))
+		Expect(trie.Get(ip.MustParseCIDROrIP("10.0.1.0/24"))).To(Equal(2))
+		cidr, data, ok := trie.LookupLongestPrefix(ip.FromString("10.0.2.1"))
+		Expect(ok).To(BeTrue())
+		Expect(cidr).To(Equal(ip.MustParseCIDROrIP("10.0.0.0/8")))
+		Expect(data).To(Equal(1))
+		Expect(trie.Load().ReadOnly()).To(BeTrue())
+	})
+
+	It("should give readers a consistent view during concurrent updates", func() {
+		// The writer repeatedly replaces the contents of the trie with a fresh set of /32s that all carry
+		// the same value; readers check that every version they see is internally consistent.
+		const numCIDRs = 64
+		var wg sync.WaitGroup
+		done := make(chan struct{})
+		errs := make(chan string, 10)
+		for r := 0; r < 4; r++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					snap := trie.Load()
+					if snap.Len() == 0 {
+						continue
+					}
+					var value, count int
+					snap.Visit(func(cidr ip.CIDR, data int) bool {
+						if count == 0 {
+							value = data
+						} else if data != value {
+							errs <- "saw mixed versions"
+							return false
+						}
+						count++
+						return true
+					})
+					if count != numCIDRs {
+						errs <- "saw partial update"
+					}
+				}
+			}()
+		}
+		for v := 1; v <= 200; v++ {
+			trie.Update(func(t *ip.Trie[int]) {
+				for i := 0; i < numCIDRs; i++ {
+					t.Update(ip.V4Addr{10, 0, 0, byte(i)}.AsCIDR(), v)
+				}
+			})
+		}
+		close(done)
+		wg.Wait()
+		Expect(errs).To(BeEmpty())
+	})
+})
@@ -0,0 +1,149 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// DualTrie holds an IPv4 and an IPv6 Trie and dispatches each operation to the trie for the CIDR's (or
+// address's) IP version, so that callers that handle both families don't have to maintain a pair of
+// tries themselves.  Iteration visits the IPv4 entries first, then the IPv6 entries, each in address
+// order.
+type DualTrie[T any] struct {
+	v4 *Trie[T]
+	v6 *Trie[T]
+}
+
+func NewDualTrie[T any]() *DualTrie[T] {
+	return &DualTrie[T]{
+		v4: NewTrie[T](),
+		v6: NewTrie[T](),
+	}
+}
+
+// V4 returns the underlying IPv4 trie, for access to operations that DualTrie doesn't wrap.
+func (t *DualTrie[T]) V4() *Trie[T] {
+	return t.v4
+}
+
+// V6 returns the underlying IPv6 trie, for access to operations that DualTrie doesn't wrap.
+func (t *DualTrie[T]) V6() *Trie[T] {
+	return t.v6
+}
+
+// TrieFor returns the underlying trie for the given IP version.  It panics if the version is not 4
+// or 6.
+func (t *DualTrie[T]) TrieFor(ipVersion uint8) *Trie[T] {
+	switch ipVersion {
+	case 4:
+		return t.v4
+	case 6:
+		return t.v6
+	}
+	logrus.WithField("version", ipVersion).Panic("Invalid IP version")
+	return nil
+}
+
+func (t *DualTrie[T]) Update(cidr CIDR, value T) {
+	t.TrieFor(cidr.Version()).Update(cidr, value)
+}
+
+func (t *DualTrie[T]) Delete(cidr CIDR) {
+	t.TrieFor(cidr.Version()).Delete(cidr)
+}
+
+// DeletePrefix removes the given CIDR and all CIDRs that it contains.  It returns the number of entries
+// that were removed.
+func (t *DualTrie[T]) DeletePrefix(cidr CIDR) int {
+	return t.TrieFor(cidr.Version()).DeletePrefix(cidr)
+}
+
+// Get returns the value stored against exactly the given CIDR, or the zero value of T if there is no
+// such entry.
+func (t *DualTrie[T]) Get(cidr CIDR) T {
+	return t.TrieFor(cidr.Version()).Get(cidr)
+}
+
+// GetExact returns the value stored against exactly the given CIDR.  ok is false if there is no such
+// entry.
+func (t *DualTrie[T]) GetExact(cidr CIDR) (T, bool) {
+	return t.TrieFor(cidr.Version()).GetExact(cidr)
+}
+
+// LPM does a longest prefix match on the trie for the CIDR's IP version.  See Trie.LPM.
+func (t *DualTrie[T]) LPM(cidr CIDR) (CIDR, T) {
+	return t.TrieFor(cidr.Version()).LPM(cidr)
+}
+
+// LookupLongestPrefix returns the most specific CIDR that contains the given address, along with its
+// value.  See Trie.LookupLongestPrefix.
+func (t *DualTrie[T]) LookupLongestPrefix(addr Addr) (CIDR, T, bool) {
+	return t.TrieFor(addr.Version()).LookupLongestPrefix(addr)
+}
+
+// Covers returns true if the given CIDR is equal to, or contained in, a CIDR in the trie.
+func (t *DualTrie[T]) Covers(cidr CIDR) bool {
+	return t.TrieFor(cidr.Version()).Covers(cidr)
+}
+
+// Intersects returns true if the trie contains a CIDR that is equal to, or contained in, the given CIDR.
+func (t *DualTrie[T]) Intersects(cidr CIDR) bool {
+	return t.TrieFor(cidr.Version()).Intersects(cidr)
+}
+
+// Visit calls f for each entry in the trie; IPv4 entries first, then IPv6, each in address order.  If
+// f returns false, iteration stops.
+func (t *DualTrie[T]) Visit(f func(cidr CIDR, data T) bool) {
+	keepGoing := true
+	t.v4.Visit(func(cidr CIDR, data T) bool {
+		keepGoing = f(cidr, data)
+		return keepGoing
+	})
+	if keepGoing {
+		t.v6.Visit(f)
+	}
+}
+
+// ToSlice returns all the entries in the trie, in the same order as Visit.
+func (t *DualTrie[T]) ToSlice() []TrieEntry[T] {
+	return append(t.v4.ToSlice(), t.v6.ToSlice()...)
+}
+
+// Len returns the total number of entries in both tries.
+func (t *DualTrie[T]) Len() int {
+	return t.v4.Len() + t.v6.Len()
+}
+
+// Snapshot returns a read-only view of the trie's current contents.  See Trie.Snapshot.
+func (t *DualTrie[T]) Snapshot() *DualTrie[T] {
+	return &DualTrie[T]{
+		v4: t.v4.Snapshot(),
+		v6: t.v6.Snapshot(),
+	}
+}
+
+// Copy returns a mutable copy of the trie.  See Trie.Copy.
+func (t *DualTrie[T]) Copy() *DualTrie[T] {
+	return &DualTrie[T]{
+		v4: t.v4.Copy(),
+		v6: t.v6.Copy(),
+	}
+}
+
+// ReadOnly returns true if the trie is a snapshot.
+func (t *DualTrie[T]) ReadOnly() bool {
+	return t.v4.ReadOnly()
+}
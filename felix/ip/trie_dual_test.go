@@ -0,0 +1,112 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = Describe("DualTrie", func() {
+	var trie *ip.DualTrie[string]
+
+	cidr := ip.MustParseCIDROrIP
+
+	BeforeEach(func() {
+		trie = ip.NewDualTrie[string]()
+		trie.Update(cidr("fd00::/8"), "v6-8")
+		trie.Update(cidr("10.0.0.0/8"), "v4-8")
+		trie.Update(cidr("fd00:1::/64"), "v6-64")
+		trie.Update(cidr("10.1.0.0/16"), "v4-16")
+	})
+
+	It("should dispatch by IP version", func() {
+		Expect(trie.Len()).To(Equal(4))
+		Expect(trie.V4().Len()).To(Equal(2))
+		Expect(trie.V6().Len()).To(Equal(2))
+		Expect(trie.Get(cidr("10.0.0.0/8"))).To(Equal("v4-8"))
+		Expect(trie.Get(cidr("fd00::/8"))).To(Equal("v6-8"))
+		_, ok := trie.GetExact(cidr("::/0"))
+		Expect(ok).To(BeFalse())
+
+		c, v, ok := trie.LookupLongestPrefix(ip.FromString("10.1.2.3"))
+		Expect(ok).To(BeTrue())
+		Expect(c).To(Equal(cidr("10.1.0.0/16")))
+		Expect(v).To(Equal("v4-16"))
+		c, v, ok = trie.LookupLongestPrefix(ip.FromString("fd00:1::1"))
+		Expect(ok).To(BeTrue())
+		Expect(c).To(Equal(cidr("fd00:1::/64")))
+		Expect(v).To(Equal("v6-64"))
+		_, _, ok = trie.LookupLongestPrefix(ip.FromString("fe80::1"))
+		Expect(ok).To(BeFalse())
+
+		c, v = trie.LPM(cidr("fd00:2::/64"))
+		Expect(c).To(Equal(cidr("fd00::/8")))
+		Expect(v).To(Equal("v6-8"))
+
+		Expect(trie.Covers(cidr("10.2.0.0/16"))).To(BeTrue())
+		Expect(trie.Covers(cidr("11.0.0.0/16"))).To(BeFalse())
+		Expect(trie.Intersects(cidr("fd00::/7"))).To(BeTrue())
+		Expect(trie.Intersects(cidr("fe80::/64"))).To(BeFalse())
+		Expect(trie.Intersects(cidr("10.1.0.0/17"))).To(BeFalse())
+	})
+
+	It("should visit IPv4 entries first, then IPv6", func() {
+		var visited []string
+		trie.Visit(func(c ip.CIDR, v string) bool {
+			visited = append(visited, c.String()+"="+v)
+			return true
+		})
+		Expect(visited).To(Equal([]string{
+			"10.0.0.0/8=v4-8",
+			"10.1.0.0/16=v4-16",
+			"fd00::/8=v6-8",
+			"fd00:1::/64=v6-64",
+		}))
+		Expect(trie.ToSlice()).To(HaveLen(4))
+		Expect(trie.ToSlice()[2].CIDR).To(Equal(cidr("fd00::/8")))
+	})
+
+	It("should stop visiting when asked, even at the IPv4/IPv6 boundary", func() {
+		var visited []string
+		trie.Visit(func(c ip.CIDR, v string) bool {
+			visited = append(visited, v)
+			return len(visited) < 2
+		})
+		Expect(visited).To(Equal([]string{"v4-8", "v4-16"}))
+	})
+
+	It("should delete from the right trie", func() {
+		trie.Delete(cidr("10.0.0.0/8"))
+		Expect(trie.DeletePrefix(cidr("fd00::/8"))).To(Equal(2))
+		Expect(trie.ToSlice()).To(Equal([]ip.TrieEntry[string]{
+			{CIDR: cidr("10.1.0.0/16"), Data: "v4-16"},
+		}))
+	})
+
+	It("should support snapshots and copies", func() {
+		snap := trie.Snapshot()
+		Expect(snap.ReadOnly()).To(BeTrue())
+		cp := trie.Copy()
+		trie.Delete(cidr("10.0.0.0/8"))
+		cp.Update(cidr("fd00:2::/64"), "copy")
+		Expect(snap.Len()).To(Equal(4))
+		Expect(cp.Len()).To(Equal(5))
+		Expect(trie.Len()).To(Equal(3))
+		Expect(trie.Get(cidr("fd00:2::/64"))).To(Equal(""))
+	})
+})
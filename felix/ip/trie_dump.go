@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DumpDOT writes a Graphviz (DOT) representation of the trie's internal structure to w.  Intermediate
+// nodes, which hold no value, are drawn with a dashed outline.  Edges are labelled with the value of the
+// bit that selects the child.  Intended for debugging only; the output format is not stable.
+func (t *Trie[T]) DumpDOT(w io.Writer) error {
+	d := trieDumper{w: w}
+	d.printf("digraph trie {\n")
+	d.printf("  node [shape=box];\n")
+	if t.root != nil {
+		dumpDOTNode(&d, t.root, new(int))
+	}
+	d.printf("}\n")
+	return d.err
+}
+
+// dumpDOTNode writes the given node and its subtree, returning the ID of the node.  nextID is updated
+// to allocate IDs to the nodes.
+func dumpDOTNode[T any](d *trieDumper, n *trieNode[T], nextID *int) int {
+	id := *nextID
+	*nextID++
+	if n.hasData {
+		d.printf("  n%d [label=%s];\n", id, strconv.Quote(fmt.Sprintf("%v\n%v", n.cidr, n.data)))
+	} else {
+		d.printf("  n%d [label=%s, style=dashed];\n", id, strconv.Quote(n.cidr.String()))
+	}
+	for i, child := range n.children {
+		if child == nil {
+			continue
+		}
+		childID := dumpDOTNode(d, child, nextID)
+		d.printf("  n%d -> n%d [label=\"%d\"];\n", id, childID, i)
+	}
+	return id
+}
+
+// DumpASCII writes a compact, indented text representation of the trie's internal structure to w, one
+// node per line.  Each line shows the bit that selects the node within its parent, the node's CIDR and
+// either its value or, for intermediate nodes, "(intermediate)".  Intended for debugging only; the
+// output format is not stable.
+func (t *Trie[T]) DumpASCII(w io.Writer) error {
+	d := trieDumper{w: w}
+	if t.root == nil {
+		d.printf("(empty)\n")
+	} else {
+		dumpASCIINode(&d, t.root, "", "")
+	}
+	return d.err
+}
+
+func dumpASCIINode[T any](d *trieDumper, n *trieNode[T], indent string, bit string) {
+	if n.hasData {
+		d.printf("%s%s%v = %v\n", indent, bit, n.cidr, n.data)
+	} else {
+		d.printf("%s%s%v (intermediate)\n", indent, bit, n.cidr)
+	}
+	for i, child := range n.children {
+		if child == nil {
+			continue
+		}
+		dumpASCIINode(d, child, indent+"  ", strconv.Itoa(i)+": ")
+	}
+}
+
+// trieDumper wraps a writer, remembering the first error so that the dump functions don't need to check
+// every write.
+type trieDumper struct {
+	w   io.Writer
+	err error
+}
+
+func (d *trieDumper) printf(format string, args ...interface{}) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, format, args...)
+}
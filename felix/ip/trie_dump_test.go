@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = Describe("Trie dumps", func() {
+	var trie *ip.Trie[string]
+
+	BeforeEach(func() {
+		trie = ip.NewTrie[string]()
+		for _, c := range []string{"10.0.0.0/8", "10.0.1.0/24", "10.0.2.0/24", "11.0.0.0/8"} {
+			trie.Update(ip.MustParseCIDROrIP(c), "v:"+c)
+		}
+	})
+
+	It("should dump an empty trie", func() {
+		var buf strings.Builder
+		Expect(ip.NewTrie[string]().DumpASCII(&buf)).To(Succeed())
+		Expect(buf.String()).To(Equal("(empty)\n"))
+
+		buf.Reset()
+		Expect(ip.NewTrie[string]().DumpDOT(&buf)).To(Succeed())
+		Expect(buf.String()).To(Equal("digraph trie {\n  node [shape=box];\n}\n"))
+	})
+
+	It("should dump the structure as ASCII", func() {
+		var buf strings.Builder
+		Expect(trie.DumpASCII(&buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(
+			"10.0.0.0/7 (intermediate)\n" +
+				"  0: 10.0.0.0/8 = v:10.0.0.0/8\n" +
+				"    0: 10.0.0.0/22 (intermediate)\n" +
+				"      0: 10.0.1.0/24 = v:10.0.1.0/24\n" +
+				"      1: 10.0.2.0/24 = v:10.0.2.0/24\n" +
+				"  1: 11.0.0.0/8 = v:11.0.0.0/8\n",
+		))
+	})
+
+	It("should dump the structure as DOT", func() {
+		var buf strings.Builder
+		Expect(trie.DumpDOT(&buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(`digraph trie {
+  node [shape=box];
+  n0 [label="10.0.0.0/7", style=dashed];
+  n1 [label="10.0.0.0/8\nv:10.0.0.0/8"];
+  n2 [label="10.0.0.0/22", style=dashed];
+  n3 [label="10.0.1.0/24\nv:10.0.1.0/24"];
+  n2 -> n3 [label="0"];
+  n4 [label="10.0.2.0/24\nv:10.0.2.0/24"];
+  n2 -> n4 [label="1"];
+  n1 -> n2 [label="0"];
+  n0 -> n1 [label="0"];
+  n5 [label="11.0.0.0/8\nv:11.0.0.0/8"];
+  n0 -> n5 [label="1"];
+}
+`))
+	})
+
+	It("should return write errors", func() {
+		Expect(trie.DumpASCII(failingWriter{})).To(MatchError("bang"))
+		Expect(trie.DumpDOT(failingWriter{})).To(MatchError("bang"))
+	})
+})
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("bang")
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The binary encoding of a trie is:
+//
+//	magic         [4]byte  "CTRI"
+//	formatVersion uint8    trieFormatVersion
+//	ipVersion     uint8    4 or 6 (0 for an empty trie)
+//	numEntries    uvarint
+//	entries       numEntries times:
+//	    addr      [4]byte or [16]byte, depending on ipVersion
+//	    prefixLen uint8
+//	    dataLen   uvarint
+//	    data      [dataLen]byte, as produced by the caller's encoder
+//
+// Entries are written in address order.
+const (
+	trieFormatVersion = 1
+	// maxTrieValueLen limits the size of an encoded value to guard against corrupt input.
+	maxTrieValueLen = 1 << 24
+)
+
+var (
+	trieMagic = [4]byte{'C', 'T', 'R', 'I'}
+
+	ErrBadTrieEncoding = errors.New("invalid trie encoding")
+)
+
+// WriteBinary writes a compact binary encoding of the trie's contents to w, using encodeData to encode
+// each value.  It returns the number of bytes written.
+func (t *Trie[T]) WriteBinary(w io.Writer, encodeData func(T) ([]byte, error)) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	var ipVersion uint8
+	if t.root != nil {
+		ipVersion = t.root.cidr.Version()
+	}
+
+	var scratch [binary.MaxVarintLen64]byte
+	header := append(trieMagic[:], trieFormatVersion, ipVersion)
+	header = append(header, scratch[:binary.PutUvarint(scratch[:], uint64(t.numEntries))]...)
+	if _, err := cw.Write(header); err != nil {
+		return cw.n, err
+	}
+
+	var err error
+	var buf []byte
+	t.Visit(func(cidr CIDR, data T) bool {
+		var encoded []byte
+		encoded, err = encodeData(data)
+		if err != nil {
+			err = fmt.Errorf("failed to encode value for %v: %w", cidr, err)
+			return false
+		}
+		buf = buf[:0]
+		switch c := cidr.(type) {
+		case V4CIDR:
+			buf = append(buf, c.addr[:]...)
+		case V6CIDR:
+			buf = append(buf, c.addr[:]...)
+		}
+		buf = append(buf, cidr.Prefix())
+		buf = append(buf, scratch[:binary.PutUvarint(scratch[:], uint64(len(encoded)))]...)
+		if _, err = cw.Write(buf); err != nil {
+			return false
+		}
+		_, err = cw.Write(encoded)
+		return err == nil
+	})
+	if err != nil {
+		return cw.n, err
+	}
+	return cw.n, cw.w.(*bufio.Writer).Flush()
+}
+
+// ReadTrieBinary reads a trie in the format written by WriteBinary, using decodeData to decode each value.
+// The slice passed to decodeData is only valid for the duration of the call.
+func ReadTrieBinary[T any](r io.Reader, decodeData func([]byte) (T, error)) (*Trie[T], error) {
+	br := bufio.NewReader(r)
+	var header [6]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, fmt.Errorf("failed to read trie header: %w", err)
+	}
+	if [4]byte(header[:4]) != trieMagic {
+		return nil, fmt.Errorf("%w: bad magic %x", ErrBadTrieEncoding, header[:4])
+	}
+	if header[4] != trieFormatVersion {
+		return nil, fmt.Errorf("%w: unsupported format version %d", ErrBadTrieEncoding, header[4])
+	}
+	var addrLen int
+	var maxPrefix uint8
+	switch header[5] {
+	case 0:
+	case 4:
+		addrLen, maxPrefix = 4, 32
+	case 6:
+		addrLen, maxPrefix = 16, 128
+	default:
+		return nil, fmt.Errorf("%w: bad IP version %d", ErrBadTrieEncoding, header[5])
+	}
+	numEntries, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trie header: %w", err)
+	}
+	if addrLen == 0 && numEntries != 0 {
+		return nil, fmt.Errorf("%w: missing IP version", ErrBadTrieEncoding)
+	}
+
+	t := NewTrie[T]()
+	var addrBuf [17]byte
+	var dataBuf []byte
+	for i := uint64(0); i < numEntries; i++ {
+		if _, err := io.ReadFull(br, addrBuf[:addrLen+1]); err != nil {
+			return nil, fmt.Errorf("failed to read trie entry: %w", err)
+		}
+		prefixLen := addrBuf[addrLen]
+		if prefixLen > maxPrefix {
+			return nil, fmt.Errorf("%w: bad prefix length %d", ErrBadTrieEncoding, prefixLen)
+		}
+		var cidr, canonical CIDR
+		if addrLen == 4 {
+			c := V4CIDR{addr: V4Addr(addrBuf[:4]), prefix: prefixLen}
+			cidr, canonical = c, V4CommonPrefix(c, c)
+		} else {
+			c := V6CIDR{addr: V6Addr(addrBuf[:16]), prefix: prefixLen}
+			cidr, canonical = c, V6CommonPrefix(c, c)
+		}
+		if cidr != canonical {
+			return nil, fmt.Errorf("%w: non-canonical CIDR %v", ErrBadTrieEncoding, cidr)
+		}
+		dataLen, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trie entry: %w", err)
+		}
+		if dataLen > maxTrieValueLen {
+			return nil, fmt.Errorf("%w: implausible value length %d", ErrBadTrieEncoding, dataLen)
+		}
+		if uint64(cap(dataBuf)) < dataLen {
+			dataBuf = make([]byte, dataLen)
+		}
+		dataBuf = dataBuf[:dataLen]
+		if _, err := io.ReadFull(br, dataBuf); err != nil {
+			return nil, fmt.Errorf("failed to read trie entry: %w", err)
+		}
+		data, err := decodeData(dataBuf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode value for %v: %w", cidr, err)
+		}
+		t.Update(cidr, data)
+	}
+	return t, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = Describe("Trie binary encoding", func() {
+	encode := func(s string) ([]byte, error) {
+		return []byte(s), nil
+	}
+	decode := func(b []byte) (string, error) {
+		return string(b), nil
+	}
+
+	roundTrip := func(cidrs ...string) *ip.Trie[string] {
+		trie := ip.NewTrie[string]()
+		for _, c := range cidrs {
+			trie.Update(ip.MustParseCIDROrIP(c), "data:"+c)
+		}
+		var buf bytes.Buffer
+		n, err := trie.WriteBinary(&buf, encode)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(BeNumerically("==", buf.Len()))
+
+		decoded, err := ip.ReadTrieBinary(&buf, decode)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded.ToSlice()).To(Equal(trie.ToSlice()))
+		Expect(decoded.Len()).To(Equal(trie.Len()))
+		return decoded
+	}
+
+	It("should round trip an empty trie", func() {
+		Expect(roundTrip().Len()).To(Equal(0))
+	})
+
+	It("should round trip an IPv4 trie", func() {
+		roundTrip("0.0.0.0/0", "10.0.0.0/8", "10.0.1.0/24", "10.0.1.1/32", "192.168.0.0/16")
+	})
+
+	It("should round trip an IPv6 trie", func() {
+		roundTrip("::/0", "fc00:fe11::/96", "fc00:fe11::1/128", "fc00:fe12::/64")
+	})
+
+	It("should propagate encoding errors", func() {
+		trie := ip.NewTrie[string]()
+		trie.Update(ip.MustParseCIDROrIP("10.0.0.0/8"), "a")
+		_, err := trie.WriteBinary(&bytes.Buffer{}, func(s string) ([]byte, error) {
+			return nil, errors.New("bang")
+		})
+		Expect(err).To(MatchError(ContainSubstring("bang")))
+	})
+
+	It("should reject bad input", func() {
+		trie := ip.NewTrie[string]()
+		trie.Update(ip.MustParseCIDROrIP("10.0.0.0/8"), "a")
+		var buf bytes.Buffer
+		_, err := trie.WriteBinary(&buf, encode)
+		Expect(err).NotTo(HaveOccurred())
+		good := buf.Bytes()
+
+		corrupt := func(f func(b []byte) []byte) error {
+			b := f(append([]byte(nil), good...))
+			_, err := ip.ReadTrieBinary(bytes.NewReader(b), decode)
+			return err
+		}
+
+		Expect(corrupt(func(b []byte) []byte { b[0] = 'X'; return b })).To(MatchError(ip.ErrBadTrieEncoding))
+		Expect(corrupt(func(b []byte) []byte { b[4] = 99; return b })).To(MatchError(ip.ErrBadTrieEncoding))
+		Expect(corrupt(func(b []byte) []byte { b[5] = 5; return b })).To(MatchError(ip.ErrBadTrieEncoding))
+		// Prefix length byte follows the header (7 bytes) and the address.
+		Expect(corrupt(func(b []byte) []byte { b[7+4] = 33; return b })).To(MatchError(ip.ErrBadTrieEncoding))
+		// Host bits set.
+		Expect(corrupt(func(b []byte) []byte { b[7+3] = 1; return b })).To(MatchError(ip.ErrBadTrieEncoding))
+		Expect(corrupt(func(b []byte) []byte { return b[:len(b)-1] })).To(HaveOccurred())
+		Expect(corrupt(func(b []byte) []byte { return b[:3] })).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,196 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"container/heap"
+	"time"
+)
+
+// ExpiringTrie is a Trie whose entries can be given a time to live.  Expired entries are not removed
+// automatically; the owner calls SweepExpired periodically (for example, from its main loop) to remove
+// them.  Until they are swept, expired entries remain visible to lookups.
+type ExpiringTrie[T any] struct {
+	trie *Trie[T]
+	// deadlines holds the expiry time of each entry that has a TTL.
+	deadlines map[CIDR]time.Time
+	// expiryHeap holds the same deadlines, ordered by time.  Entries in the heap are not removed when
+	// a CIDR is updated or deleted; instead, stale heap entries are skipped when they reach the top.
+	expiryHeap expiryHeap
+
+	timeNow func() time.Time
+}
+
+// NewExpiringTrie creates an empty ExpiringTrie.  timeNow is used to calculate the expiry time of
+// entries; if nil, time.Now is used.
+func NewExpiringTrie[T any](timeNow func() time.Time) *ExpiringTrie[T] {
+	if timeNow == nil {
+		timeNow = time.Now
+	}
+	return &ExpiringTrie[T]{
+		trie:      NewTrie[T](),
+		deadlines: map[CIDR]time.Time{},
+		timeNow:   timeNow,
+	}
+}
+
+// Update stores value against the given CIDR with no expiry, replacing any existing value (and TTL).
+func (t *ExpiringTrie[T]) Update(cidr CIDR, value T) {
+	t.trie.Update(cidr, value)
+	delete(t.deadlines, cidr)
+}
+
+// UpdateWithTTL stores value against the given CIDR, replacing any existing value, and arranges for it
+// to expire after the given TTL.  Updating an entry again before it expires resets its TTL.
+func (t *ExpiringTrie[T]) UpdateWithTTL(cidr CIDR, value T, ttl time.Duration) {
+	t.trie.Update(cidr, value)
+	deadline := t.timeNow().Add(ttl)
+	t.deadlines[cidr] = deadline
+	heap.Push(&t.expiryHeap, expiryHeapItem{cidr: cidr, deadline: deadline})
+	t.maybeCompactHeap()
+}
+
+// Delete removes the given CIDR, if present.
+func (t *ExpiringTrie[T]) Delete(cidr CIDR) {
+	t.trie.Delete(cidr)
+	delete(t.deadlines, cidr)
+}
+
+// Expiry returns the time at which the given CIDR's entry expires.  ok is false if there is no such
+// entry or it doesn't have a TTL.
+func (t *ExpiringTrie[T]) Expiry(cidr CIDR) (deadline time.Time, ok bool) {
+	deadline, ok = t.deadlines[cidr]
+	return
+}
+
+// NextExpiry returns the time at which the next entry expires, which can be used to schedule the next
+// call to SweepExpired.  ok is false if no entries have a TTL.
+func (t *ExpiringTrie[T]) NextExpiry() (deadline time.Time, ok bool) {
+	t.dropStaleHeapItems()
+	if len(t.expiryHeap) == 0 {
+		return time.Time{}, false
+	}
+	return t.expiryHeap[0].deadline, true
+}
+
+// SweepExpired removes all entries whose expiry time is at or before now.  It returns the removed
+// entries, in expiry order.
+func (t *ExpiringTrie[T]) SweepExpired(now time.Time) []TrieEntry[T] {
+	var expired []TrieEntry[T]
+	for {
+		t.dropStaleHeapItems()
+		if len(t.expiryHeap) == 0 || t.expiryHeap[0].deadline.After(now) {
+			break
+		}
+		item := heap.Pop(&t.expiryHeap).(expiryHeapItem)
+		data, _ := t.trie.GetExact(item.cidr)
+		expired = append(expired, TrieEntry[T]{CIDR: item.cidr, Data: data})
+		t.trie.Delete(item.cidr)
+		delete(t.deadlines, item.cidr)
+	}
+	return expired
+}
+
+// dropStaleHeapItems pops heap items that no longer match the current deadline of their CIDR.
+func (t *ExpiringTrie[T]) dropStaleHeapItems() {
+	for len(t.expiryHeap) > 0 {
+		top := t.expiryHeap[0]
+		if deadline, ok := t.deadlines[top.cidr]; ok && deadline.Equal(top.deadline) {
+			return
+		}
+		heap.Pop(&t.expiryHeap)
+	}
+}
+
+// maybeCompactHeap rebuilds the heap from the deadlines map if it has accumulated a lot of stale items,
+// which happens if entries are refreshed many times before they expire.
+func (t *ExpiringTrie[T]) maybeCompactHeap() {
+	if len(t.expiryHeap) < 2*len(t.deadlines)+64 {
+		return
+	}
+	t.expiryHeap = t.expiryHeap[:0]
+	for cidr, deadline := range t.deadlines {
+		t.expiryHeap = append(t.expiryHeap, expiryHeapItem{cidr: cidr, deadline: deadline})
+	}
+	heap.Init(&t.expiryHeap)
+}
+
+// Get returns the value stored against exactly the given CIDR, or the zero value of T if there is no
+// such entry.
+func (t *ExpiringTrie[T]) Get(cidr CIDR) T {
+	return t.trie.Get(cidr)
+}
+
+// GetExact returns the value stored against exactly the given CIDR.  ok is false if there is no such
+// entry.
+func (t *ExpiringTrie[T]) GetExact(cidr CIDR) (T, bool) {
+	return t.trie.GetExact(cidr)
+}
+
+// LookupLongestPrefix returns the most specific CIDR in the trie that contains the given address, along
+// with its value.  See Trie.LookupLongestPrefix.
+func (t *ExpiringTrie[T]) LookupLongestPrefix(addr Addr) (CIDR, T, bool) {
+	return t.trie.LookupLongestPrefix(addr)
+}
+
+// Visit calls f for each entry in the trie, in address order.  If f returns false, iteration stops.
+func (t *ExpiringTrie[T]) Visit(f func(cidr CIDR, data T) bool) {
+	t.trie.Visit(f)
+}
+
+// Len returns the number of entries in the trie, including any that have expired but not been swept.
+func (t *ExpiringTrie[T]) Len() int {
+	return t.trie.Len()
+}
+
+// Snapshot returns a read-only snapshot of the underlying trie, for access to the rest of the Trie API.
+func (t *ExpiringTrie[T]) Snapshot() *Trie[T] {
+	return t.trie.Snapshot()
+}
+
+type expiryHeapItem struct {
+	cidr     CIDR
+	deadline time.Time
+}
+
+// expiryHeap is a min-heap of expiryHeapItems, ordered by deadline, implementing heap.Interface.
+type expiryHeap []expiryHeapItem
+
+func (h expiryHeap) Len() int {
+	return len(h)
+}
+
+func (h expiryHeap) Less(i, j int) bool {
+	if h[i].deadline.Equal(h[j].deadline) {
+		// Make the sweep order deterministic.
+		return CompareCIDRs(h[i].cidr, h[j].cidr) < 0
+	}
+	return h[i].deadline.Before(h[j].deadline)
+}
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *expiryHeap) Push(x any) {
+	*h = append(*h, x.(expiryHeapItem))
+}
+
+func (h *expiryHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = Describe("ExpiringTrie", func() {
+	var (
+		now  time.Time
+		trie *ip.ExpiringTrie[string]
+	)
+
+	cidr := ip.MustParseCIDROrIP
+
+	BeforeEach(func() {
+		now = time.Unix(1000, 0)
+		trie = ip.NewExpiringTrie[string](func() time.Time { return now })
+	})
+
+	It("should sweep only expired entries, in expiry order", func() {
+		trie.UpdateWithTTL(cidr("10.0.0.0/8"), "a", 30*time.Second)
+		trie.UpdateWithTTL(cidr("10.1.0.0/16"), "b", 10*time.Second)
+		trie.UpdateWithTTL(cidr("10.1.2.0/24"), "c", 20*time.Second)
+		trie.Update(cidr("192.168.0.0/16"), "forever")
+
+		next, ok := trie.NextExpiry()
+		Expect(ok).To(BeTrue())
+		Expect(next).To(Equal(now.Add(10 * time.Second)))
+
+		Expect(trie.SweepExpired(now.Add(5 * time.Second))).To(BeEmpty())
+		Expect(trie.SweepExpired(now.Add(20 * time.Second))).To(Equal([]ip.TrieEntry[string]{
+			{CIDR: cidr("10.1.0.0/16"), Data: "b"},
+			{CIDR: cidr("10.1.2.0/24"), Data: "c"},
+		}))
+		Expect(trie.Len()).To(Equal(2))
+
+		c, v, ok := trie.LookupLongestPrefix(ip.FromString("10.1.2.3"))
+		Expect(ok).To(BeTrue())
+		Expect(c).To(Equal(cidr("10.0.0.0/8")))
+		Expect(v).To(Equal("a"))
+
+		Expect(trie.SweepExpired(now.Add(time.Hour))).To(Equal([]ip.TrieEntry[string]{
+			{CIDR: cidr("10.0.0.0/8"), Data: "a"},
+		}))
+		_, ok = trie.NextExpiry()
+		Expect(ok).To(BeFalse())
+		Expect(trie.Get(cidr("192.168.0.0/16"))).To(Equal("forever"))
+	})
+
+	It("should reset the TTL when an entry is refreshed", func() {
+		trie.UpdateWithTTL(cidr("10.0.0.0/24"), "a", 10*time.Second)
+		now = now.Add(5 * time.Second)
+		trie.UpdateWithTTL(cidr("10.0.0.0/24"), "a2", 10*time.Second)
+
+		Expect(trie.SweepExpired(now.Add(6 * time.Second))).To(BeEmpty())
+		deadline, ok := trie.Expiry(cidr("10.0.0.0/24"))
+		Expect(ok).To(BeTrue())
+		Expect(deadline).To(Equal(now.Add(10 * time.Second)))
+		Expect(trie.SweepExpired(now.Add(10 * time.Second))).To(Equal([]ip.TrieEntry[string]{
+			{CIDR: cidr("10.0.0.0/24"), Data: "a2"},
+		}))
+	})
+
+	It("should not expire entries that were deleted or made permanent", func() {
+		trie.UpdateWithTTL(cidr("10.0.0.0/24"), "a", time.Second)
+		trie.UpdateWithTTL(cidr("10.0.1.0/24"), "b", time.Second)
+		trie.Delete(cidr("10.0.0.0/24"))
+		trie.Update(cidr("10.0.1.0/24"), "b2")
+
+		_, ok := trie.Expiry(cidr("10.0.1.0/24"))
+		Expect(ok).To(BeFalse())
+		_, ok = trie.NextExpiry()
+		Expect(ok).To(BeFalse())
+		Expect(trie.SweepExpired(now.Add(time.Hour))).To(BeEmpty())
+		Expect(trie.Get(cidr("10.0.1.0/24"))).To(Equal("b2"))
+	})
+
+	It("should keep working when entries are refreshed many times", func() {
+		for i := 0; i < 1000; i++ {
+			now = now.Add(time.Millisecond)
+			trie.UpdateWithTTL(cidr("10.0.0.0/24"), "a", time.Second)
+			trie.UpdateWithTTL(cidr("10.0.1.0/24"), "b", 2*time.Second)
+		}
+		Expect(trie.SweepExpired(now.Add(time.Second))).To(Equal([]ip.TrieEntry[string]{
+			{CIDR: cidr("10.0.0.0/24"), Data: "a"},
+		}))
+		Expect(trie.SweepExpired(now.Add(2 * time.Second))).To(Equal([]ip.TrieEntry[string]{
+			{CIDR: cidr("10.0.1.0/24"), Data: "b"},
+		}))
+		Expect(trie.Len()).To(Equal(0))
+	})
+})
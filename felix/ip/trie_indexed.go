@@ -0,0 +1,141 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+)
+
+// IndexedTrie is a Trie that also maintains a reverse index from a key, derived from each value, to the
+// CIDRs whose values have that key.  For example, if the values are route infos, the key might be the
+// name of the node that owns the route, allowing all of a node's routes to be found (or removed) without
+// scanning the whole trie.
+//
+// The index is kept up to date by Update and Delete so the trie itself is only available as a read-only
+// snapshot.
+type IndexedTrie[T any, K comparable] struct {
+	trie  *Trie[T]
+	keyFn func(T) K
+	index map[K]set.Set[CIDR]
+}
+
+// NewIndexedTrie creates an empty IndexedTrie that indexes values by the key returned by keyFn.
+func NewIndexedTrie[T any, K comparable](keyFn func(T) K) *IndexedTrie[T, K] {
+	return &IndexedTrie[T, K]{
+		trie:  NewTrie[T](),
+		keyFn: keyFn,
+		index: map[K]set.Set[CIDR]{},
+	}
+}
+
+// Update stores value against the given CIDR, replacing (and unindexing) any existing value.
+func (t *IndexedTrie[T, K]) Update(cidr CIDR, value T) {
+	old, hadOld := t.trie.GetExact(cidr)
+	t.trie.Update(cidr, value)
+	if hadOld {
+		t.removeFromIndex(t.keyFn(old), cidr)
+	}
+	key := t.keyFn(value)
+	cidrs, ok := t.index[key]
+	if !ok {
+		cidrs = set.New[CIDR]()
+		t.index[key] = cidrs
+	}
+	cidrs.Add(cidr)
+}
+
+// Delete removes the given CIDR, if present.
+func (t *IndexedTrie[T, K]) Delete(cidr CIDR) {
+	old, ok := t.trie.GetExact(cidr)
+	if !ok {
+		return
+	}
+	t.trie.Delete(cidr)
+	t.removeFromIndex(t.keyFn(old), cidr)
+}
+
+// DeleteKey removes all the CIDRs whose values have the given key and returns the number removed.
+func (t *IndexedTrie[T, K]) DeleteKey(key K) int {
+	cidrs, ok := t.index[key]
+	if !ok {
+		return 0
+	}
+	cidrs.Iter(func(cidr CIDR) error {
+		t.trie.Delete(cidr)
+		return nil
+	})
+	delete(t.index, key)
+	return cidrs.Len()
+}
+
+func (t *IndexedTrie[T, K]) removeFromIndex(key K, cidr CIDR) {
+	cidrs := t.index[key]
+	cidrs.Discard(cidr)
+	if cidrs.Len() == 0 {
+		delete(t.index, key)
+	}
+}
+
+// CIDRsWithKey returns the CIDRs whose values have the given key, in address order.
+func (t *IndexedTrie[T, K]) CIDRsWithKey(key K) []CIDR {
+	cidrs, ok := t.index[key]
+	if !ok {
+		return nil
+	}
+	s := cidrs.Slice()
+	SortCIDRs(s)
+	return s
+}
+
+// NumCIDRsWithKey returns the number of CIDRs whose values have the given key.
+func (t *IndexedTrie[T, K]) NumCIDRsWithKey(key K) int {
+	if cidrs, ok := t.index[key]; ok {
+		return cidrs.Len()
+	}
+	return 0
+}
+
+// Get returns the value stored against exactly the given CIDR, or the zero value of T if there is no
+// such entry.
+func (t *IndexedTrie[T, K]) Get(cidr CIDR) T {
+	return t.trie.Get(cidr)
+}
+
+// GetExact returns the value stored against exactly the given CIDR.  ok is false if there is no such
+// entry.
+func (t *IndexedTrie[T, K]) GetExact(cidr CIDR) (T, bool) {
+	return t.trie.GetExact(cidr)
+}
+
+// LookupLongestPrefix returns the most specific CIDR in the trie that contains the given address, along
+// with its value.  See Trie.LookupLongestPrefix.
+func (t *IndexedTrie[T, K]) LookupLongestPrefix(addr Addr) (CIDR, T, bool) {
+	return t.trie.LookupLongestPrefix(addr)
+}
+
+// Visit calls f for each entry in the trie, in address order.  If f returns false, iteration stops.
+func (t *IndexedTrie[T, K]) Visit(f func(cidr CIDR, data T) bool) {
+	t.trie.Visit(f)
+}
+
+// Len returns the number of entries in the trie.
+func (t *IndexedTrie[T, K]) Len() int {
+	return t.trie.Len()
+}
+
+// Snapshot returns a read-only snapshot of the underlying trie, for access to the rest of the Trie API.
+func (t *IndexedTrie[T, K]) Snapshot() *Trie[T] {
+	return t.trie.Snapshot()
+}
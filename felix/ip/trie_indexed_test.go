@@ -0,0 +1,130 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"fmt"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+type ownedRoute struct {
+	Owner  string
+	Metric int
+}
+
+var _ = Describe("IndexedTrie", func() {
+	var trie *ip.IndexedTrie[ownedRoute, string]
+
+	BeforeEach(func() {
+		trie = ip.NewIndexedTrie(func(r ownedRoute) string { return r.Owner })
+	})
+
+	update := func(cidr, owner string) {
+		trie.Update(ip.MustParseCIDROrIP(cidr), ownedRoute{Owner: owner})
+	}
+	cidrsWithKey := func(owner string) []string {
+		var s []string
+		for _, c := range trie.CIDRsWithKey(owner) {
+			s = append(s, c.String())
+		}
+		return s
+	}
+
+	It("should index values by key", func() {
+		update("10.0.2.0/24", "a")
+		update("10.0.1.0/24", "a")
+		update("10.0.3.0/24", "b")
+		Expect(cidrsWithKey("a")).To(Equal([]string{"10.0.1.0/24", "10.0.2.0/24"}))
+		Expect(cidrsWithKey("b")).To(Equal([]string{"10.0.3.0/24"}))
+		Expect(cidrsWithKey("c")).To(BeNil())
+		Expect(trie.NumCIDRsWithKey("a")).To(Equal(2))
+		Expect(trie.NumCIDRsWithKey("c")).To(BeZero())
+	})
+
+	It("should reindex when a value's key changes", func() {
+		update("10.0.1.0/24", "a")
+		update("10.0.1.0/24", "b")
+		Expect(cidrsWithKey("a")).To(BeNil())
+		Expect(cidrsWithKey("b")).To(Equal([]string{"10.0.1.0/24"}))
+		Expect(trie.Len()).To(Equal(1))
+	})
+
+	It("should unindex deleted CIDRs", func() {
+		update("10.0.1.0/24", "a")
+		update("10.0.2.0/24", "a")
+		trie.Delete(ip.MustParseCIDROrIP("10.0.1.0/24"))
+		trie.Delete(ip.MustParseCIDROrIP("10.0.9.0/24"))
+		Expect(cidrsWithKey("a")).To(Equal([]string{"10.0.2.0/24"}))
+		_, ok := trie.GetExact(ip.MustParseCIDROrIP("10.0.1.0/24"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should delete all CIDRs for a key", func() {
+		update("10.0.0.0/16", "pool")
+		update("10.0.1.0/26", "a")
+		update("10.0.1.64/26", "a")
+		update("10.0.1.128/26", "b")
+		Expect(trie.DeleteKey("a")).To(Equal(2))
+		Expect(trie.DeleteKey("a")).To(BeZero())
+		var remaining []string
+		trie.Visit(func(cidr ip.CIDR, r ownedRoute) bool {
+			remaining = append(remaining, cidr.String()+"="+r.Owner)
+			return true
+		})
+		Expect(remaining).To(Equal([]string{"10.0.0.0/16=pool", "10.0.1.128/26=b"}))
+		cidr, r, ok := trie.LookupLongestPrefix(ip.FromString("10.0.1.1"))
+		Expect(ok).To(BeTrue())
+		Expect(cidr.String()).To(Equal("10.0.0.0/16"))
+		Expect(r.Owner).To(Equal("pool"))
+	})
+
+	It("should give a read-only snapshot", func() {
+		update("10.0.1.0/24", "a")
+		snap := trie.Snapshot()
+		update("10.0.2.0/24", "a")
+		Expect(snap.ReadOnly()).To(BeTrue())
+		Expect(snap.Len()).To(Equal(1))
+	})
+
+	It("should keep the index consistent under random churn", func() {
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 5000; i++ {
+			cidr := ip.CIDRFromAddrAndPrefix(ip.V4Addr{10, 0, byte(rng.Intn(16)), 0}, 24)
+			owner := fmt.Sprint(rng.Intn(5))
+			switch rng.Intn(10) {
+			case 0:
+				trie.DeleteKey(owner)
+			case 1, 2, 3:
+				trie.Delete(cidr)
+			default:
+				trie.Update(cidr, ownedRoute{Owner: owner, Metric: i})
+			}
+		}
+		expected := map[string][]string{}
+		trie.Visit(func(cidr ip.CIDR, r ownedRoute) bool {
+			expected[r.Owner] = append(expected[r.Owner], cidr.String())
+			return true
+		})
+		for i := 0; i < 5; i++ {
+			owner := fmt.Sprint(i)
+			Expect(cidrsWithKey(owner)).To(Equal(expected[owner]), owner)
+		}
+	})
+})
@@ -0,0 +1,419 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+type DeltaType int
+
+const (
+	DeltaTypeAdded DeltaType = iota
+	DeltaTypeUpdated
+	DeltaTypeDeleted
+)
+
+func (d DeltaType) String() string {
+	switch d {
+	case DeltaTypeAdded:
+		return "added"
+	case DeltaTypeUpdated:
+		return "updated"
+	case DeltaTypeDeleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+// DiffTries walks the two tries simultaneously and calls cb once for each CIDR that was added, deleted
+// or had its value changed (according to dataEqual) between oldTrie and newTrie.  For additions, oldData
+// is the zero value; for deletions, newData is the zero value.  Deltas are emitted in address order.
+//
+// If the two tries share nodes (for example, because one is a snapshot of the other), the shared
+// subtrees are skipped without being walked, so diffing successive snapshots of a trie is proportional
+// to the size of the change rather than the size of the trie.
+func DiffTries[T any](
+	oldTrie, newTrie *Trie[T],
+	dataEqual func(a, b T) bool,
+	cb func(delta DeltaType, cidr CIDR, oldData, newData T),
+) {
+	diffNodes(oldTrie.root, newTrie.root, dataEqual, cb)
+}
+
+func diffNodes[T any](
+	oldNode, newNode *trieNode[T],
+	dataEqual func(a, b T) bool,
+	cb func(delta DeltaType, cidr CIDR, oldData, newData T),
+) {
+	var zero T
+	if oldNode == newNode {
+		// Identical subtrees (or both nil).
+		return
+	}
+	if oldNode == nil {
+		newNode.visit(func(cidr CIDR, data T) bool {
+			cb(DeltaTypeAdded, cidr, zero, data)
+			return true
+		})
+		return
+	}
+	if newNode == nil {
+		oldNode.visit(func(cidr CIDR, data T) bool {
+			cb(DeltaTypeDeleted, cidr, data, zero)
+			return true
+		})
+		return
+	}
+
+	if oldNode.cidr == newNode.cidr {
+		switch {
+		case oldNode.hasData && newNode.hasData:
+			if !dataEqual(oldNode.data, newNode.data) {
+				cb(DeltaTypeUpdated, oldNode.cidr, oldNode.data, newNode.data)
+			}
+		case oldNode.hasData:
+			cb(DeltaTypeDeleted, oldNode.cidr, oldNode.data, zero)
+		case newNode.hasData:
+			cb(DeltaTypeAdded, newNode.cidr, zero, newNode.data)
+		}
+		diffNodes(oldNode.children[0], newNode.children[0], dataEqual, cb)
+		diffNodes(oldNode.children[1], newNode.children[1], dataEqual, cb)
+		return
+	}
+
+	common := CommonPrefix(oldNode.cidr, newNode.cidr)
+	switch common {
+	case oldNode.cidr:
+		// Old node is a strict parent of the new node.  Its value (if any) has gone, then we diff its
+		// children against the new node.
+		if oldNode.hasData {
+			cb(DeltaTypeDeleted, oldNode.cidr, oldNode.data, zero)
+		}
+		childIdx := cidrNthBit(newNode.cidr, uint(oldNode.cidr.Prefix()+1))
+		for i, oldChild := range oldNode.children {
+			if i == childIdx {
+				diffNodes(oldChild, newNode, dataEqual, cb)
+			} else {
+				diffNodes(oldChild, nil, dataEqual, cb)
+			}
+		}
+	case newNode.cidr:
+		// New node is a strict parent of the old node.
+		if newNode.hasData {
+			cb(DeltaTypeAdded, newNode.cidr, zero, newNode.data)
+		}
+		childIdx := cidrNthBit(oldNode.cidr, uint(newNode.cidr.Prefix()+1))
+		for i, newChild := range newNode.children {
+			if i == childIdx {
+				diffNodes(oldNode, newChild, dataEqual, cb)
+			} else {
+				diffNodes(nil, newChild, dataEqual, cb)
+			}
+		}
+	default:
+		// Disjoint; emit the deltas for whichever comes first in address order.
+		if cidrNthBit(oldNode.cidr, uint(common.Prefix()+1)) == 0 {
+			diffNodes(oldNode, nil, dataEqual, cb)
+			diffNodes(nil, newNode, dataEqual, cb)
+		} else {
+			diffNodes(nil, newNode, dataEqual, cb)
+			diffNodes(oldNode, nil, dataEqual, cb)
+		}
+	}
+}
+
+// Equal returns true if the two tries hold the same CIDRs with equal values (according to dataEqual).
+// Since the shape of a trie is determined by the CIDRs that it holds, the tries are compared with a
+// single structural walk.  As with DiffTries, shared subtrees are skipped so comparing a trie with a
+// recent snapshot of itself is cheap.
+func (t *Trie[T]) Equal(other *Trie[T], dataEqual func(a, b T) bool) bool {
+	if t.numEntries != other.numEntries {
+		return false
+	}
+	return nodesEqual(t.root, other.root, dataEqual)
+}
+
+func nodesEqual[T any](a, b *trieNode[T], dataEqual func(a, b T) bool) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	if a.cidr != b.cidr || a.hasData != b.hasData {
+		return false
+	}
+	if a.hasData && !dataEqual(a.data, b.data) {
+		return false
+	}
+	return nodesEqual(a.children[0], b.children[0], dataEqual) &&
+		nodesEqual(a.children[1], b.children[1], dataEqual)
+}
+
+// MergeFrom merges the contents of other into this trie.  Where both tries have a value for the same
+// CIDR, the value stored is conflictFn(thisValue, otherValue).  other is not modified.
+//
+// Subtrees of other that don't overlap with this trie are shared rather than copied; both tries remain
+// independently modifiable afterwards.
+func (t *Trie[T]) MergeFrom(other *Trie[T], conflictFn func(a, b T) T) {
+	t.checkWritable()
+	if !other.readOnly {
+		// Make sure that other won't modify any nodes that we take from it.
+		other = other.Snapshot()
+	}
+	// Similarly, move ourselves onto a new generation so that we're sure to copy other's nodes before
+	// modifying them.
+	t.gen = nextTrieGen()
+	var numConflicts int
+	t.root = t.mergeNodes(t.root, other.root, conflictFn, &numConflicts)
+	t.numEntries += other.numEntries - numConflicts
+}
+
+func (t *Trie[T]) mergeNodes(ours, theirs *trieNode[T], conflictFn func(a, b T) T, numConflicts *int) *trieNode[T] {
+	if theirs == nil {
+		return ours
+	}
+	if ours == nil {
+		return theirs
+	}
+
+	if ours.cidr == theirs.cidr {
+		n := t.writable(ours)
+		if theirs.hasData {
+			if n.hasData {
+				n.data = conflictFn(n.data, theirs.data)
+				*numConflicts++
+			} else {
+				n.data = theirs.data
+				n.hasData = true
+			}
+		}
+		for i := range n.children {
+			n.children[i] = t.mergeNodes(n.children[i], theirs.children[i], conflictFn, numConflicts)
+		}
+		return n
+	}
+
+	common := CommonPrefix(ours.cidr, theirs.cidr)
+	switch common {
+	case ours.cidr:
+		// Our node is a strict parent of theirs.
+		n := t.writable(ours)
+		childIdx := cidrNthBit(theirs.cidr, uint(n.cidr.Prefix()+1))
+		n.children[childIdx] = t.mergeNodes(n.children[childIdx], theirs, conflictFn, numConflicts)
+		return n
+	case theirs.cidr:
+		// Their node is a strict parent of ours; take a copy of it so that we can modify its children.
+		n := t.writable(theirs)
+		childIdx := cidrNthBit(ours.cidr, uint(n.cidr.Prefix()+1))
+		n.children[childIdx] = t.mergeNodes(ours, n.children[childIdx], conflictFn, numConflicts)
+		return n
+	default:
+		// Disjoint, create an intermediate node to join them.
+		n := t.newNode(common)
+		childIdx := cidrNthBit(ours.cidr, uint(common.Prefix()+1))
+		n.children[childIdx] = ours
+		n.children[1-childIdx] = theirs
+		return n
+	}
+}
+
+// IntersectTries calls cb for each CIDR that is stored in one of the tries and is covered by (or also
+// stored in) the other.  In other words, it emits the set of CIDRs that make up the intersection of the
+// address space covered by the two tries.  aData and bData are the values of the most specific entries in
+// a and b, respectively, that cover the CIDR.  CIDRs are emitted in address order.  If cb returns false,
+// iteration stops.
+func IntersectTries[T any](a, b *Trie[T], cb func(cidr CIDR, aData, bData T) bool) {
+	intersectNodes(a.root, b.root, nil, nil, cb)
+}
+
+// intersectNodes intersects the subtrees rooted at na and nb.  coverA and coverB are the most specific
+// entries in the respective tries that enclose the subtrees.  It returns false if iteration was stopped.
+func intersectNodes[T any](na, nb, coverA, coverB *trieNode[T], cb func(cidr CIDR, aData, bData T) bool) bool {
+	if na == nil && (nb == nil || coverA == nil) {
+		return true
+	}
+	if nb == nil && coverB == nil {
+		return true
+	}
+
+	if na == nil || nb == nil || na.cidr == nb.cidr {
+		// Either both nodes have the same CIDR or one side has run out of nodes.  Either way, we're
+		// looking at the CIDR of the non-nil node(s).
+		var cidr CIDR
+		children := [2][2]*trieNode[T]{}
+		if na != nil {
+			cidr = na.cidr
+			children[0] = na.children
+			if na.hasData {
+				coverA = na
+			}
+		}
+		if nb != nil {
+			cidr = nb.cidr
+			children[1] = nb.children
+			if nb.hasData {
+				coverB = nb
+			}
+		}
+		if coverA != nil && coverB != nil && (coverA.cidr == cidr || coverB.cidr == cidr) {
+			if !cb(cidr, coverA.data, coverB.data) {
+				return false
+			}
+		}
+		for i := 0; i < 2; i++ {
+			if !intersectNodes(children[0][i], children[1][i], coverA, coverB, cb) {
+				return false
+			}
+		}
+		return true
+	}
+
+	common := CommonPrefix(na.cidr, nb.cidr)
+	switch common {
+	case na.cidr:
+		// na is a strict parent of nb.
+		if na.hasData {
+			coverA = na
+			if coverB != nil && !cb(na.cidr, na.data, coverB.data) {
+				return false
+			}
+		}
+		childIdx := cidrNthBit(nb.cidr, uint(na.cidr.Prefix()+1))
+		for i, child := range na.children {
+			var other *trieNode[T]
+			if i == childIdx {
+				other = nb
+			}
+			if !intersectNodes(child, other, coverA, coverB, cb) {
+				return false
+			}
+		}
+		return true
+	case nb.cidr:
+		// nb is a strict parent of na.
+		if nb.hasData {
+			coverB = nb
+			if coverA != nil && !cb(nb.cidr, coverA.data, nb.data) {
+				return false
+			}
+		}
+		childIdx := cidrNthBit(na.cidr, uint(nb.cidr.Prefix()+1))
+		for i, child := range nb.children {
+			var other *trieNode[T]
+			if i == childIdx {
+				other = na
+			}
+			if !intersectNodes(other, child, coverA, coverB, cb) {
+				return false
+			}
+		}
+		return true
+	default:
+		// Disjoint; process whichever comes first in address order first.
+		if cidrNthBit(na.cidr, uint(common.Prefix()+1)) == 0 {
+			return intersectNodes(na, nil, coverA, coverB, cb) && intersectNodes(nil, nb, coverA, coverB, cb)
+		}
+		return intersectNodes(nil, nb, coverA, coverB, cb) && intersectNodes(na, nil, coverA, coverB, cb)
+	}
+}
+
+// BuildTrie constructs a trie from entries that are already sorted into CompareCIDRs order, such as
+// the output of ToSlice or Visit.  Rather than doing an Update per entry, which repeatedly walks
+// down from the root and splits intermediate nodes, it builds the trie bottom-up in a single pass.
+// Where an entry's CIDR is repeated, the last value wins, as if Update had been called for each entry in
+// turn.
+//
+// If the entries turn out not to be sorted, BuildTrie falls back to inserting them one at a time so the
+// result is always correct, just slower.
+func BuildTrie[T any](sortedEntries []TrieEntry[T]) *Trie[T] {
+	t := NewTrie[T]()
+	// stack holds the rightmost path of the trie built so far, from the root down to the most recently
+	// added node.  The link from each node to the next one on the stack is only filled in when the
+	// lower node is popped, since that's when we know whether an intermediate node needs to go between
+	// them.
+	var stack []*trieNode[T]
+	var prev CIDR
+	for i, e := range sortedEntries {
+		if any(e.Data) == nil {
+			logrus.Panic("Can't store nil in a CIDRTrie")
+		}
+		cidr := e.CIDR.Canonicalize()
+		if prev != nil {
+			cmp := CompareCIDRs(prev, cidr)
+			if cmp > 0 || prev.Version() != cidr.Version() {
+				logrus.WithFields(logrus.Fields{"prev": prev, "next": cidr}).Debug(
+					"Entries aren't sorted, falling back to individual updates.")
+				t.root = linkTrieStack(stack)
+				for _, e := range sortedEntries[i:] {
+					t.Update(e.CIDR.Canonicalize(), e.Data)
+				}
+				return t
+			}
+			if cmp == 0 {
+				// Repeated CIDR, which must be the node on top of the stack.
+				stack[len(stack)-1].data = e.Data
+				continue
+			}
+		}
+		prev = cidr
+
+		x := t.newLeaf(cidr, e.Data)
+		t.numEntries++
+		// Pop off the nodes that don't contain the new CIDR; they have no more children to come.
+		var popped *trieNode[T]
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if commonPrefixLen(top.cidr, cidr) == top.cidr.Prefix() {
+				break
+			}
+			if popped != nil {
+				top.children[cidrNthBit(popped.cidr, uint(top.cidr.Prefix()+1))] = popped
+			}
+			popped = top
+			stack = stack[:len(stack)-1]
+		}
+		if popped != nil {
+			// The popped subtree and the new CIDR diverge at their common prefix.  If that is the node now on
+			// top of the stack, the popped subtree can be linked straight into it; otherwise we need a new
+			// intermediate node to join them.
+			commonLen := commonPrefixLen(popped.cidr, cidr)
+			if len(stack) > 0 && stack[len(stack)-1].cidr.Prefix() == commonLen {
+				top := stack[len(stack)-1]
+				top.children[cidrNthBit(popped.cidr, uint(commonLen+1))] = popped
+			} else {
+				intermediate := t.newNode(CommonPrefix(popped.cidr, cidr))
+				intermediate.children[cidrNthBit(popped.cidr, uint(commonLen+1))] = popped
+				stack = append(stack, intermediate)
+			}
+		}
+		stack = append(stack, x)
+	}
+	t.root = linkTrieStack(stack)
+	return t
+}
+
+// linkTrieStack fills in the links between the nodes on BuildTrie's stack and returns the root.
+func linkTrieStack[T any](stack []*trieNode[T]) *trieNode[T] {
+	if len(stack) == 0 {
+		return nil
+	}
+	for i := len(stack) - 1; i > 0; i-- {
+		parent, child := stack[i-1], stack[i]
+		parent.children[cidrNthBit(child.cidr, uint(parent.cidr.Prefix()+1))] = child
+	}
+	return stack[0]
+}
@@ -0,0 +1,505 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"fmt"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+type trieDelta struct {
+	Type    ip.DeltaType
+	CIDR    string
+	OldData string
+	NewData string
+}
+
+func stringsEqual(a, b string) bool {
+	return a == b
+}
+
+var _ = Describe("DiffTries", func() {
+	var oldTrie, newTrie *ip.Trie[string]
+
+	BeforeEach(func() {
+		oldTrie = ip.NewTrie[string]()
+		newTrie = ip.NewTrie[string]()
+	})
+
+	diff := func() []trieDelta {
+		var deltas []trieDelta
+		ip.DiffTries(oldTrie, newTrie, stringsEqual, func(d ip.DeltaType, cidr ip.CIDR, oldData, newData string) {
+			deltas = append(deltas, trieDelta{Type: d, CIDR: cidr.String(), OldData: oldData, NewData: newData})
+		})
+		return deltas
+	}
+
+	set := func(t *ip.Trie[string], cidr, data string) {
+		t.Update(ip.MustParseCIDROrIP(cidr), data)
+	}
+
+	It("should produce no deltas for empty tries", func() {
+		Expect(diff()).To(BeEmpty())
+	})
+
+	It("should produce no deltas for equal tries", func() {
+		for _, t := range []*ip.Trie[string]{oldTrie, newTrie} {
+			set(t, "10.0.0.0/8", "a")
+			set(t, "10.0.1.0/24", "b")
+		}
+		Expect(diff()).To(BeEmpty())
+	})
+
+	It("should produce adds, updates and deletes in order", func() {
+		set(oldTrie, "10.0.0.0/8", "a")
+		set(oldTrie, "10.0.1.0/24", "b")
+		set(oldTrie, "11.0.0.0/8", "c")
+
+		set(newTrie, "9.0.0.0/8", "z")
+		set(newTrie, "10.0.0.0/8", "a")
+		set(newTrie, "10.0.1.0/24", "B")
+		set(newTrie, "10.0.1.1/32", "d")
+
+		Expect(diff()).To(Equal([]trieDelta{
+			{Type: ip.DeltaTypeAdded, CIDR: "9.0.0.0/8", NewData: "z"},
+			{Type: ip.DeltaTypeUpdated, CIDR: "10.0.1.0/24", OldData: "b", NewData: "B"},
+			{Type: ip.DeltaTypeAdded, CIDR: "10.0.1.1/32", NewData: "d"},
+			{Type: ip.DeltaTypeDeleted, CIDR: "11.0.0.0/8", OldData: "c"},
+		}))
+	})
+
+	It("should handle a parent that only exists in one trie", func() {
+		set(oldTrie, "10.0.0.0/8", "a")
+		set(oldTrie, "10.0.1.0/24", "b")
+		set(newTrie, "10.0.1.0/24", "b")
+		Expect(diff()).To(Equal([]trieDelta{
+			{Type: ip.DeltaTypeDeleted, CIDR: "10.0.0.0/8", OldData: "a"},
+		}))
+		oldTrie, newTrie = newTrie, oldTrie
+		Expect(diff()).To(Equal([]trieDelta{
+			{Type: ip.DeltaTypeAdded, CIDR: "10.0.0.0/8", NewData: "a"},
+		}))
+	})
+
+	It("should handle IPv6", func() {
+		set(oldTrie, "fc00:fe11::/96", "a")
+		set(newTrie, "fc00:fe11::/96", "a")
+		set(newTrie, "fc00:fe11::1/128", "b")
+		Expect(diff()).To(Equal([]trieDelta{
+			{Type: ip.DeltaTypeAdded, CIDR: "fc00:fe11::1/128", NewData: "b"},
+		}))
+	})
+
+	It("should match a brute-force diff of successive snapshots", func() {
+		rng := rand.New(rand.NewSource(1))
+		randomCIDR := func() ip.CIDR {
+			addr := ip.V4Addr{10, byte(rng.Intn(4)), byte(rng.Intn(4)), byte(rng.Intn(4))}
+			return ip.CIDRFromAddrAndPrefix(addr, 8+rng.Intn(25))
+		}
+
+		trie := ip.NewTrie[string]()
+		expected := map[ip.CIDR]string{}
+		for i := 0; i < 100; i++ {
+			snap := trie.Snapshot()
+			oldExpected := map[ip.CIDR]string{}
+			for k, v := range expected {
+				oldExpected[k] = v
+			}
+			for j := 0; j < 10; j++ {
+				cidr := randomCIDR()
+				if rng.Intn(3) == 0 {
+					trie.Delete(cidr)
+					delete(expected, cidr)
+				} else {
+					data := fmt.Sprint(rng.Intn(3))
+					trie.Update(cidr, data)
+					expected[cidr] = data
+				}
+			}
+
+			lastCIDR := ""
+			ip.DiffTries(snap, trie, stringsEqual, func(d ip.DeltaType, cidr ip.CIDR, oldData, newData string) {
+				Expect(cidr.String()).NotTo(Equal(lastCIDR), "Duplicate delta")
+				lastCIDR = cidr.String()
+				switch d {
+				case ip.DeltaTypeAdded:
+					Expect(oldExpected).NotTo(HaveKey(cidr))
+					oldExpected[cidr] = newData
+				case ip.DeltaTypeUpdated:
+					Expect(oldExpected).To(HaveKeyWithValue(cidr, oldData))
+					Expect(oldData).NotTo(Equal(newData))
+					oldExpected[cidr] = newData
+				case ip.DeltaTypeDeleted:
+					Expect(oldExpected).To(HaveKeyWithValue(cidr, oldData))
+					delete(oldExpected, cidr)
+				}
+			})
+			Expect(oldExpected).To(Equal(expected))
+		}
+	})
+})
+
+var _ = Describe("Trie.Equal", func() {
+	build := func(cidrs ...string) *ip.Trie[string] {
+		t := ip.NewTrie[string]()
+		for _, c := range cidrs {
+			t.Update(ip.MustParseCIDROrIP(c), c)
+		}
+		return t
+	}
+
+	It("should compare contents regardless of insertion order", func() {
+		a := build("10.0.0.0/8", "10.0.1.0/24", "10.0.2.0/24", "11.0.0.0/16")
+		b := build("11.0.0.0/16", "10.0.2.0/24", "10.0.1.0/24", "10.0.0.0/8")
+		Expect(a.Equal(b, stringsEqual)).To(BeTrue())
+		Expect(b.Equal(a, stringsEqual)).To(BeTrue())
+	})
+
+	It("should compare contents after deletions", func() {
+		a := build("10.0.1.0/24", "10.0.2.0/24")
+		b := build("10.0.0.0/8", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24")
+		Expect(a.Equal(b, stringsEqual)).To(BeFalse())
+		b.Delete(ip.MustParseCIDROrIP("10.0.0.0/8"))
+		b.Delete(ip.MustParseCIDROrIP("10.0.3.0/24"))
+		Expect(a.Equal(b, stringsEqual)).To(BeTrue())
+		b.DeletePrefix(ip.MustParseCIDROrIP("10.0.2.0/23"))
+		a.Delete(ip.MustParseCIDROrIP("10.0.2.0/24"))
+		Expect(a.Equal(b, stringsEqual)).To(BeTrue())
+	})
+
+	It("should detect differing values", func() {
+		a := build("10.0.1.0/24", "10.0.2.0/24")
+		b := build("10.0.1.0/24", "10.0.2.0/24")
+		b.Update(ip.MustParseCIDROrIP("10.0.2.0/24"), "other")
+		Expect(a.Equal(b, stringsEqual)).To(BeFalse())
+		Expect(a.Equal(b, func(a, b string) bool { return true })).To(BeTrue())
+	})
+
+	It("should treat an intermediate node differently from an entry", func() {
+		a := build("10.0.0.0/24", "10.0.1.0/24")
+		b := build("10.0.0.0/23")
+		b.Update(ip.MustParseCIDROrIP("10.0.0.0/24"), "10.0.0.0/24")
+		Expect(a.Equal(b, stringsEqual)).To(BeFalse())
+	})
+
+	It("should compare empty tries and snapshots", func() {
+		Expect(build().Equal(build(), stringsEqual)).To(BeTrue())
+		a := build("10.0.1.0/24", "10.0.2.0/24")
+		snap := a.Snapshot()
+		Expect(a.Equal(snap, stringsEqual)).To(BeTrue())
+		a.Update(ip.MustParseCIDROrIP("10.0.3.0/24"), "x")
+		Expect(a.Equal(snap, stringsEqual)).To(BeFalse())
+	})
+
+	It("should agree with a map comparison under random churn", func() {
+		rng := rand.New(rand.NewSource(1))
+		a, b := ip.NewTrie[string](), ip.NewTrie[string]()
+		aMap, bMap := map[ip.CIDR]string{}, map[ip.CIDR]string{}
+		randomCIDR := func() ip.CIDR {
+			return ip.CIDRFromAddrAndPrefix(ip.V4Addr{10, 0, byte(rng.Intn(4)), byte(rng.Intn(8))}, 22+rng.Intn(11))
+		}
+		for i := 0; i < 2000; i++ {
+			t, m := a, aMap
+			if rng.Intn(2) == 0 {
+				t, m = b, bMap
+			}
+			c := randomCIDR()
+			if rng.Intn(2) == 0 {
+				t.Update(c, "v")
+				m[c] = "v"
+			} else {
+				t.Delete(c)
+				delete(m, c)
+			}
+			Expect(a.Equal(b, stringsEqual)).To(Equal(fmt.Sprint(aMap) == fmt.Sprint(bMap)))
+		}
+	})
+})
+
+var _ = Describe("MergeFrom", func() {
+	var trie, other *ip.Trie[string]
+
+	BeforeEach(func() {
+		trie = ip.NewTrie[string]()
+		other = ip.NewTrie[string]()
+	})
+
+	set := func(t *ip.Trie[string], cidr, data string) {
+		t.Update(ip.MustParseCIDROrIP(cidr), data)
+	}
+
+	contents := func(t *ip.Trie[string]) map[string]string {
+		m := map[string]string{}
+		t.Visit(func(cidr ip.CIDR, data string) bool {
+			m[cidr.String()] = data
+			return true
+		})
+		Expect(t.Len()).To(Equal(len(m)))
+		return m
+	}
+
+	concat := func(a, b string) string {
+		return a + "+" + b
+	}
+
+	It("should merge into an empty trie", func() {
+		set(other, "10.0.0.0/8", "a")
+		trie.MergeFrom(other, concat)
+		Expect(contents(trie)).To(Equal(map[string]string{"10.0.0.0/8": "a"}))
+	})
+
+	It("should merge overlapping tries and resolve conflicts", func() {
+		set(trie, "10.0.0.0/8", "a")
+		set(trie, "10.0.1.0/24", "b")
+		set(trie, "12.0.0.0/8", "c")
+
+		set(other, "10.0.0.0/16", "d")
+		set(other, "10.0.1.0/24", "e")
+		set(other, "11.0.0.0/8", "f")
+		set(other, "0.0.0.0/0", "g")
+
+		trie.MergeFrom(other, concat)
+		Expect(contents(trie)).To(Equal(map[string]string{
+			"0.0.0.0/0":   "g",
+			"10.0.0.0/8":  "a",
+			"10.0.0.0/16": "d",
+			"10.0.1.0/24": "b+e",
+			"11.0.0.0/8":  "f",
+			"12.0.0.0/8":  "c",
+		}))
+		Expect(contents(other)).To(HaveLen(4))
+	})
+
+	It("should fill in an intermediate node", func() {
+		set(trie, "0.0.0.0/1", "a")
+		set(trie, "128.0.0.0/1", "b")
+		set(other, "0.0.0.0/0", "c")
+		trie.MergeFrom(other, concat)
+		Expect(contents(trie)).To(Equal(map[string]string{"0.0.0.0/1": "a", "128.0.0.0/1": "b", "0.0.0.0/0": "c"}))
+	})
+
+	It("should leave the tries independent", func() {
+		set(trie, "10.0.0.0/8", "a")
+		set(other, "11.0.0.0/8", "b")
+		set(other, "11.0.1.0/24", "c")
+		trie.MergeFrom(other, concat)
+
+		set(other, "11.0.1.0/24", "changed")
+		set(other, "11.0.2.0/24", "d")
+		Expect(contents(trie)).To(Equal(map[string]string{"10.0.0.0/8": "a", "11.0.0.0/8": "b", "11.0.1.0/24": "c"}))
+
+		set(trie, "11.0.0.0/8", "changed")
+		trie.Delete(ip.MustParseCIDROrIP("11.0.1.0/24"))
+		Expect(contents(other)).To(Equal(map[string]string{"11.0.0.0/8": "b", "11.0.1.0/24": "changed", "11.0.2.0/24": "d"}))
+	})
+
+	It("should merge from a snapshot", func() {
+		set(other, "11.0.0.0/8", "b")
+		snap := other.Snapshot()
+		trie.MergeFrom(snap, concat)
+		set(trie, "11.0.0.0/8", "changed")
+		Expect(contents(snap)).To(Equal(map[string]string{"11.0.0.0/8": "b"}))
+		Expect(contents(other)).To(Equal(map[string]string{"11.0.0.0/8": "b"}))
+	})
+})
+
+var _ = Describe("IntersectTries", func() {
+	var a, b *ip.Trie[string]
+
+	BeforeEach(func() {
+		a = ip.NewTrie[string]()
+		b = ip.NewTrie[string]()
+	})
+
+	set := func(t *ip.Trie[string], cidr string) {
+		t.Update(ip.MustParseCIDROrIP(cidr), cidr)
+	}
+
+	intersect := func() [][3]string {
+		var result [][3]string
+		ip.IntersectTries(a, b, func(cidr ip.CIDR, aData, bData string) bool {
+			result = append(result, [3]string{cidr.String(), aData, bData})
+			return true
+		})
+		return result
+	}
+
+	It("should return nothing for empty tries", func() {
+		Expect(intersect()).To(BeEmpty())
+		set(a, "10.0.0.0/8")
+		Expect(intersect()).To(BeEmpty())
+	})
+
+	It("should return nothing for disjoint tries", func() {
+		set(a, "10.0.0.0/8")
+		set(a, "12.0.0.0/8")
+		set(b, "11.0.0.0/8")
+		set(b, "10.0.0.0/7")
+		b.Delete(ip.MustParseCIDROrIP("10.0.0.0/7"))
+		Expect(intersect()).To(BeEmpty())
+	})
+
+	It("should return CIDRs stored in both", func() {
+		set(a, "10.0.0.0/8")
+		set(a, "11.0.0.0/8")
+		set(b, "11.0.0.0/8")
+		Expect(intersect()).To(Equal([][3]string{{"11.0.0.0/8", "11.0.0.0/8", "11.0.0.0/8"}}))
+	})
+
+	It("should return covered CIDRs from either side", func() {
+		set(a, "10.0.0.0/8")
+		set(a, "10.0.1.0/24")
+		set(a, "10.1.0.1/32")
+		set(a, "12.0.0.0/8")
+		set(b, "10.0.0.0/16")
+		set(b, "10.0.2.0/24")
+		set(b, "12.1.0.0/16")
+		set(b, "13.0.0.0/8")
+		Expect(intersect()).To(Equal([][3]string{
+			{"10.0.0.0/16", "10.0.0.0/8", "10.0.0.0/16"},
+			{"10.0.1.0/24", "10.0.1.0/24", "10.0.0.0/16"},
+			{"10.0.2.0/24", "10.0.0.0/8", "10.0.2.0/24"},
+			{"12.1.0.0/16", "12.0.0.0/8", "12.1.0.0/16"},
+		}))
+	})
+
+	It("should handle IPv6", func() {
+		set(a, "fc00:fe11::/96")
+		set(b, "fc00:fe11::1/128")
+		set(b, "fc00:fe12::1/128")
+		Expect(intersect()).To(Equal([][3]string{
+			{"fc00:fe11::1/128", "fc00:fe11::/96", "fc00:fe11::1/128"},
+		}))
+	})
+
+	It("should stop early", func() {
+		set(a, "0.0.0.0/0")
+		set(b, "10.0.0.0/8")
+		set(b, "11.0.0.0/8")
+		count := 0
+		ip.IntersectTries(a, b, func(cidr ip.CIDR, aData, bData string) bool {
+			count++
+			return false
+		})
+		Expect(count).To(Equal(1))
+	})
+
+	It("should match a brute-force intersection", func() {
+		rng := rand.New(rand.NewSource(2))
+		randomCIDR := func() ip.CIDR {
+			addr := ip.V4Addr{10, byte(rng.Intn(4)), byte(rng.Intn(4)), byte(rng.Intn(4))}
+			return ip.CIDRFromAddrAndPrefix(addr, 8+rng.Intn(25))
+		}
+		for i := 0; i < 100; i++ {
+			a = ip.NewTrie[string]()
+			b = ip.NewTrie[string]()
+			for j := 0; j < 10; j++ {
+				c := randomCIDR()
+				a.Update(c, c.String())
+				c = randomCIDR()
+				b.Update(c, c.String())
+			}
+			expected := map[string]bool{}
+			for _, pair := range [][2]*ip.Trie[string]{{a, b}, {b, a}} {
+				for _, e := range pair[0].ToSlice() {
+					if pair[1].Covers(e.CIDR) {
+						expected[e.CIDR.String()] = true
+					}
+				}
+			}
+			actual := map[string]bool{}
+			for _, r := range intersect() {
+				actual[r[0]] = true
+			}
+			Expect(actual).To(Equal(expected))
+		}
+	})
+})
+
+var _ = Describe("BuildTrie", func() {
+	entries := func(cidrs ...string) []ip.TrieEntry[string] {
+		var es []ip.TrieEntry[string]
+		for _, c := range cidrs {
+			es = append(es, ip.TrieEntry[string]{CIDR: ip.MustParseCIDROrIP(c), Data: c})
+		}
+		return es
+	}
+	updateAll := func(es []ip.TrieEntry[string]) *ip.Trie[string] {
+		t := ip.NewTrie[string]()
+		for _, e := range es {
+			t.Update(e.CIDR, e.Data)
+		}
+		return t
+	}
+
+	It("should build an empty trie", func() {
+		t := ip.BuildTrie[string](nil)
+		Expect(t.Len()).To(BeZero())
+		Expect(t.Equal(ip.NewTrie[string](), stringsEqual)).To(BeTrue())
+	})
+
+	It("should build the same trie as individual updates", func() {
+		es := entries("10.0.0.0/8", "10.0.0.0/16", "10.0.1.0/24", "10.0.2.0/24", "10.0.2.1/32", "10.128.0.0/9", "11.0.0.0/16", "192.168.0.0/16")
+		t := ip.BuildTrie(es)
+		Expect(t.Len()).To(Equal(len(es)))
+		Expect(t.Equal(updateAll(es), stringsEqual)).To(BeTrue())
+		Expect(t.ToSlice()).To(Equal(es))
+	})
+
+	It("should let the last value win for repeated CIDRs", func() {
+		es := entries("10.0.0.0/8", "10.0.1.0/24", "10.0.2.0/24")
+		es = append(es[:2], append([]ip.TrieEntry[string]{{CIDR: ip.MustParseCIDROrIP("10.0.1.0/24"), Data: "second"}}, es[2:]...)...)
+		t := ip.BuildTrie(es)
+		Expect(t.Len()).To(Equal(3))
+		Expect(t.Get(ip.MustParseCIDROrIP("10.0.1.0/24"))).To(Equal("second"))
+	})
+
+	It("should fall back to updates for unsorted input", func() {
+		es := entries("10.0.1.0/24", "10.0.0.0/8", "9.0.0.0/8", "10.0.2.0/24")
+		t := ip.BuildTrie(es)
+		Expect(t.Equal(updateAll(es), stringsEqual)).To(BeTrue())
+	})
+
+	It("should produce a writable trie", func() {
+		t := ip.BuildTrie(entries("10.0.1.0/24", "10.0.2.0/24"))
+		t.Update(ip.MustParseCIDROrIP("10.0.3.0/24"), "10.0.3.0/24")
+		t.Delete(ip.MustParseCIDROrIP("10.0.1.0/24"))
+		Expect(t.Equal(updateAll(entries("10.0.2.0/24", "10.0.3.0/24")), stringsEqual)).To(BeTrue())
+	})
+
+	It("should match individual updates for random input", func() {
+		rng := rand.New(rand.NewSource(1))
+		for _, version := range []int{4, 6} {
+			for i := 0; i < 50; i++ {
+				src := ip.NewTrie[string]()
+				for j := rng.Intn(500); j > 0; j-- {
+					var c ip.CIDR = ip.RandomV4CIDR(rng)
+					if version == 6 {
+						c = ip.RandomV6CIDR(rng)
+					}
+					src.Update(c, fmt.Sprint(j))
+				}
+				t := ip.BuildTrie(src.ToSlice())
+				Expect(t.Equal(src, stringsEqual)).To(BeTrue())
+				Expect(t.Len()).To(Equal(src.Len()))
+			}
+		}
+	})
+})
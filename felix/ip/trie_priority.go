@@ -0,0 +1,141 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import "sort"
+
+// PriorityTrie is a trie that allows several sources to claim the same CIDR, each with its own
+// priority and value.  Lookups return the longest matching prefix and, within that prefix, the claim
+// with the lowest priority value (as with policy order, lower values take precedence).  Ties between
+// claims with equal priority are broken by source name so that the result is deterministic.
+type PriorityTrie[T any] struct {
+	// trie maps each CIDR to its claims, sorted with the winning claim first.  The slices are
+	// treated as immutable so that snapshots of the trie are unaffected by later updates.
+	trie *Trie[[]PriorityClaim[T]]
+}
+
+// PriorityClaim is a single source's claim on a CIDR.
+type PriorityClaim[T any] struct {
+	Source   string
+	Priority int
+	Data     T
+}
+
+func (c PriorityClaim[T]) beats(other PriorityClaim[T]) bool {
+	if c.Priority != other.Priority {
+		return c.Priority < other.Priority
+	}
+	return c.Source < other.Source
+}
+
+func NewPriorityTrie[T any]() *PriorityTrie[T] {
+	return &PriorityTrie[T]{
+		trie: NewTrie[[]PriorityClaim[T]](),
+	}
+}
+
+// Update adds or replaces the given source's claim on the CIDR.
+func (t *PriorityTrie[T]) Update(cidr CIDR, source string, priority int, data T) {
+	oldClaims, _ := t.trie.GetExact(cidr)
+	claims := make([]PriorityClaim[T], 0, len(oldClaims)+1)
+	for _, c := range oldClaims {
+		if c.Source == source {
+			continue
+		}
+		claims = append(claims, c)
+	}
+	newClaim := PriorityClaim[T]{Source: source, Priority: priority, Data: data}
+	idx := sort.Search(len(claims), func(i int) bool {
+		return newClaim.beats(claims[i])
+	})
+	claims = append(claims, PriorityClaim[T]{})
+	copy(claims[idx+1:], claims[idx:])
+	claims[idx] = newClaim
+	t.trie.Update(cidr, claims)
+}
+
+// Delete removes the given source's claim on the CIDR, if present.  The CIDR is removed from the trie
+// once it has no remaining claims.
+func (t *PriorityTrie[T]) Delete(cidr CIDR, source string) {
+	oldClaims, ok := t.trie.GetExact(cidr)
+	if !ok {
+		return
+	}
+	claims := make([]PriorityClaim[T], 0, len(oldClaims))
+	for _, c := range oldClaims {
+		if c.Source == source {
+			continue
+		}
+		claims = append(claims, c)
+	}
+	if len(claims) == len(oldClaims) {
+		return
+	}
+	if len(claims) == 0 {
+		t.trie.Delete(cidr)
+		return
+	}
+	t.trie.Update(cidr, claims)
+}
+
+// DeleteAll removes all claims on the given CIDR.
+func (t *PriorityTrie[T]) DeleteAll(cidr CIDR) {
+	t.trie.Delete(cidr)
+}
+
+// Claims returns the claims on exactly the given CIDR, with the winning claim first.  The returned
+// slice must not be modified.
+func (t *PriorityTrie[T]) Claims(cidr CIDR) []PriorityClaim[T] {
+	return t.trie.Get(cidr)
+}
+
+// GetExact returns the winning claim on exactly the given CIDR.  ok is false if there are no claims on
+// the CIDR.
+func (t *PriorityTrie[T]) GetExact(cidr CIDR) (claim PriorityClaim[T], ok bool) {
+	claims, ok := t.trie.GetExact(cidr)
+	if !ok {
+		return
+	}
+	return claims[0], true
+}
+
+// LookupLongestPrefix returns the most specific CIDR in the trie that contains the given address, along
+// with its winning claim.  ok is false if no CIDR contains the address.
+func (t *PriorityTrie[T]) LookupLongestPrefix(addr Addr) (cidr CIDR, claim PriorityClaim[T], ok bool) {
+	cidr, claims, ok := t.trie.LookupLongestPrefix(addr)
+	if !ok {
+		return
+	}
+	return cidr, claims[0], true
+}
+
+// Visit calls f with each CIDR in the trie and its winning claim, in address order.  If f returns
+// false, iteration stops.
+func (t *PriorityTrie[T]) Visit(f func(cidr CIDR, claim PriorityClaim[T]) bool) {
+	t.trie.Visit(func(cidr CIDR, claims []PriorityClaim[T]) bool {
+		return f(cidr, claims[0])
+	})
+}
+
+// Len returns the number of CIDRs in the trie (not the number of claims).
+func (t *PriorityTrie[T]) Len() int {
+	return t.trie.Len()
+}
+
+// Snapshot returns a read-only snapshot of the underlying trie of claims.  The claim slices must not be
+// modified.
+func (t *PriorityTrie[T]) Snapshot() *Trie[[]PriorityClaim[T]] {
+	return t.trie.Snapshot()
+}
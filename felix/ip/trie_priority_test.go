@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = Describe("PriorityTrie", func() {
+	var trie *ip.PriorityTrie[string]
+
+	cidr := ip.MustParseCIDROrIP
+
+	lookup := func(addr string) (string, string) {
+		c, claim, ok := trie.LookupLongestPrefix(ip.FromString(addr))
+		if !ok {
+			return "", ""
+		}
+		return c.String(), claim.Data
+	}
+
+	BeforeEach(func() {
+		trie = ip.NewPriorityTrie[string]()
+	})
+
+	It("should return the lowest-priority-value claim on the longest prefix", func() {
+		trie.Update(cidr("10.0.0.0/8"), "tier-a", 10, "a/8")
+		trie.Update(cidr("10.0.0.0/16"), "tier-b", 20, "b/16")
+		trie.Update(cidr("10.0.0.0/16"), "tier-a", 10, "a/16")
+		trie.Update(cidr("10.0.0.0/16"), "tier-c", 30, "c/16")
+
+		c, data := lookup("10.0.1.1")
+		Expect(c).To(Equal("10.0.0.0/16"))
+		Expect(data).To(Equal("a/16"))
+		_, data = lookup("10.1.0.1")
+		Expect(data).To(Equal("a/8"))
+		Expect(trie.Len()).To(Equal(2))
+
+		Expect(trie.Claims(cidr("10.0.0.0/16"))).To(Equal([]ip.PriorityClaim[string]{
+			{Source: "tier-a", Priority: 10, Data: "a/16"},
+			{Source: "tier-b", Priority: 20, Data: "b/16"},
+			{Source: "tier-c", Priority: 30, Data: "c/16"},
+		}))
+	})
+
+	It("should break ties by source name regardless of insertion order", func() {
+		trie.Update(cidr("10.0.0.0/24"), "z", 5, "z")
+		trie.Update(cidr("10.0.0.0/24"), "m", 5, "m")
+		trie.Update(cidr("10.0.0.0/24"), "a", 5, "a")
+		claim, ok := trie.GetExact(cidr("10.0.0.0/24"))
+		Expect(ok).To(BeTrue())
+		Expect(claim.Source).To(Equal("a"))
+
+		trie.Delete(cidr("10.0.0.0/24"), "a")
+		claim, _ = trie.GetExact(cidr("10.0.0.0/24"))
+		Expect(claim.Source).To(Equal("m"))
+	})
+
+	It("should replace an existing claim from the same source", func() {
+		trie.Update(cidr("10.0.0.0/24"), "a", 1, "a1")
+		trie.Update(cidr("10.0.0.0/24"), "b", 2, "b")
+		trie.Update(cidr("10.0.0.0/24"), "a", 3, "a3")
+		Expect(trie.Claims(cidr("10.0.0.0/24"))).To(Equal([]ip.PriorityClaim[string]{
+			{Source: "b", Priority: 2, Data: "b"},
+			{Source: "a", Priority: 3, Data: "a3"},
+		}))
+	})
+
+	It("should remove the CIDR once the last claim is deleted", func() {
+		trie.Update(cidr("10.0.0.0/24"), "a", 1, "a")
+		trie.Update(cidr("10.0.0.0/24"), "b", 2, "b")
+		trie.Delete(cidr("10.0.0.0/24"), "c")
+		trie.Delete(cidr("10.0.0.0/24"), "a")
+		Expect(trie.Len()).To(Equal(1))
+		trie.Delete(cidr("10.0.0.0/24"), "b")
+		Expect(trie.Len()).To(Equal(0))
+		_, _, ok := trie.LookupLongestPrefix(ip.FromString("10.0.0.1"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should not affect snapshots", func() {
+		trie.Update(cidr("10.0.0.0/24"), "b", 2, "b")
+		snap := trie.Snapshot()
+		trie.Update(cidr("10.0.0.0/24"), "a", 1, "a")
+		Expect(snap.Get(cidr("10.0.0.0/24"))).To(Equal([]ip.PriorityClaim[string]{
+			{Source: "b", Priority: 2, Data: "b"},
+		}))
+	})
+})
@@ -0,0 +1,81 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import "unsafe"
+
+// TrieStats describes the shape of a trie.
+type TrieStats struct {
+	// NumNodes is the total number of nodes in the trie, including intermediate nodes.
+	NumNodes int
+	// NumEntries is the number of nodes that hold a value; it is the same as Len().
+	NumEntries int
+	// NumIntermediateNodes is the number of nodes that hold no value and exist only to join two
+	// subtrees.
+	NumIntermediateNodes int
+	// NumLeaves is the number of nodes without children.
+	NumLeaves int
+	// MaxDepth is the number of nodes on the longest path from the root to a leaf; 0 for an empty trie.
+	MaxDepth int
+	// EstimatedBytes is an estimate of the memory used by the trie's nodes and their CIDRs.  It doesn't
+	// include any memory referenced by the stored values, nor does it account for nodes that are shared
+	// with snapshots.
+	EstimatedBytes int
+}
+
+// Stats walks the trie and returns statistics about its structure.  Its cost is proportional to the size
+// of the trie so it is intended for occasional use, such as for periodic metrics reporting.
+func (t *Trie[T]) Stats() TrieStats {
+	var stats TrieStats
+	if t.root != nil {
+		t.root.addStats(&stats, 1)
+	}
+	stats.EstimatedBytes = stats.NumNodes * int(unsafe.Sizeof(trieNode[T]{}))
+	switch t.root.cidrVersion() {
+	case 4:
+		stats.EstimatedBytes += stats.NumNodes * int(unsafe.Sizeof(V4CIDR{}))
+	case 6:
+		stats.EstimatedBytes += stats.NumNodes * int(unsafe.Sizeof(V6CIDR{}))
+	}
+	return stats
+}
+
+func (n *trieNode[T]) addStats(stats *TrieStats, depth int) {
+	stats.NumNodes++
+	if n.hasData {
+		stats.NumEntries++
+	} else {
+		stats.NumIntermediateNodes++
+	}
+	if depth > stats.MaxDepth {
+		stats.MaxDepth = depth
+	}
+	if n.children[0] == nil && n.children[1] == nil {
+		stats.NumLeaves++
+		return
+	}
+	for _, child := range n.children {
+		if child != nil {
+			child.addStats(stats, depth+1)
+		}
+	}
+}
+
+func (n *trieNode[T]) cidrVersion() uint8 {
+	if n == nil {
+		return 0
+	}
+	return n.cidr.Version()
+}
@@ -16,6 +16,7 @@ package ip_test
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
@@ -55,6 +56,36 @@ var _ = DescribeTable("CommonPrefix",
 	cpEntry("fc00:fe11::/96", "fcff:fe11::/120", "fc00::/8"),
 	cpEntry("fc00:fe11::/112", "fcff:fe11::/120", "fc00::/8"),
 	cpEntry("fc00:fe11:3::/112", "fcff:fe11::/120", "fc00::/8"),
+
+	// IPv6 edge cases around the 64-bit halves.
+	cpEntry("fc00::/16", "fc00::/16", "fc00::/16"),
+	cpEntry("fc00::/16", "fc00::1/128", "fc00::/16"),
+	cpEntry("fc00::/64", "fc00::1/128", "fc00::/64"),
+	cpEntry("fc00::/64", "fc00:0:0:1::/64", "fc00::/63"),
+	cpEntry("fc00::8000:0:0:0/65", "fc00::/65", "fc00::/64"),
+	cpEntry("fc00::1/128", "fc00::2/128", "fc00::/126"),
+	cpEntry("fc00::1/128", "fc00::1/128", "fc00::1/128"),
+	cpEntry("::/1", "8000::/1", "::/0"),
+	cpEntry("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff/128", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/128",
+		"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/127"),
+)
+
+var _ = DescribeTable("V6CommonPrefix",
+	func(a, b, expected string) {
+		aCIDR := ip.MustParseCIDROrIP(a).(ip.V6CIDR)
+		bCIDR := ip.MustParseCIDROrIP(b).(ip.V6CIDR)
+		expCIDR := ip.MustParseCIDROrIP(expected).(ip.V6CIDR)
+
+		Expect(ip.V6CommonPrefix(aCIDR, bCIDR)).To(Equal(expCIDR))
+		Expect(ip.V6CommonPrefix(bCIDR, aCIDR)).To(Equal(expCIDR))
+		Expect(ip.V6CommonPrefix(aCIDR, aCIDR)).To(Equal(aCIDR))
+	},
+	cpEntry("::/0", "fc00::1/128", "::/0"),
+	cpEntry("fc00:fe11::/32", "fc00:fe11:1::/48", "fc00:fe11::/32"),
+	cpEntry("fc00:fe11::1:0/112", "fc00:fe11::/96", "fc00:fe11::/96"),
+	cpEntry("fc00::/64", "fc00::8000:0:0:0/65", "fc00::/64"),
+	cpEntry("fc00::/120", "fc00::100/120", "fc00::/119"),
+	cpEntry("fc00::/7", "fe80::/10", "fc00::/6"),
 )
 
 func cpEntry(a, b, exp string) TableEntry {
@@ -144,6 +175,13 @@ var _ = Describe("CIDRTrie tests", func() {
 			Expect(contents()).To(ConsistOf("10.0.0.0/8"))
 		})
 
+		It("should look up the full path to a CIDR correctly", func() {
+			update("10.0.0.0/8")
+			update("10.0.1.0/24")
+			update("10.0.1.1/32")
+			Expect(lookup("10.0.1.1/32")).To(ConsistOf("10.0.0.0/8", "10.0.1.0/24", "10.0.1.1/32"))
+		})
+
 		It("should fail to lookup in empty trie", func() {
 			Expect(lookup("11.0.0.0/8")).To(BeEmpty())
 		})
@@ -159,6 +197,17 @@ var _ = Describe("CIDRTrie tests", func() {
 			Expect(lookup("0.0.0.0/0")).To(BeEmpty())
 		})
 
+		It("should not count intermediate nodes in Len()", func() {
+			update("0.0.0.0/1")
+			update("128.0.0.0/1")
+			Expect(trie.Len()).To(Equal(2))
+			remove("0.0.0.0/0")
+			Expect(trie.Len()).To(Equal(2))
+			update("0.0.0.0/0")
+			update("0.0.0.0/0")
+			Expect(trie.Len()).To(Equal(3))
+		})
+
 		It("should fail to lookup when recursing on child that turns out to have a mismatch with the target", func() {
 			update("10.0.0.0/8")
 			update("10.0.1.0/24")
@@ -212,6 +261,17 @@ var _ = Describe("CIDRTrie tests", func() {
 			Expect(contents()).To(ConsistOf("fc00:fe11::/96"))
 		})
 
+		It("should collapse intermediate nodes on delete", func() {
+			update("fc00:fe11::/96")
+			update("fc00:fe11:0:1::/120")
+			update("fc00:fe11:0:2::/120")
+			remove("fc00:fe11::/96")
+			Expect(contents()).To(ConsistOf("fc00:fe11:0:1::/120", "fc00:fe11:0:2::/120"))
+			remove("fc00:fe11:0:1::/120")
+			Expect(contents()).To(ConsistOf("fc00:fe11:0:2::/120"))
+			Expect(lookup("fc00:fe11:0:2::/120")).To(ConsistOf("fc00:fe11:0:2::/120"))
+		})
+
 		It("should look up the full path to a CIDR correctly", func() {
 			update("feed:beef::/64")
 			update("feed:beef:0:0:1::/96")
@@ -416,6 +476,451 @@ var _ = Describe("CIDRTrie tests", func() {
 		})
 	})
 
+	Context("LookupLongestPrefix", func() {
+		lookupLongest := func(addr string) string {
+			cidr, data, ok := trie.LookupLongestPrefix(ip.FromString(addr))
+			if !ok {
+				Expect(cidr).To(BeNil())
+				Expect(data).To(BeNil())
+				return ""
+			}
+			Expect(data).To(Equal("data:" + cidr.String()))
+			return cidr.String()
+		}
+
+		It("should return nothing for an empty trie", func() {
+			Expect(lookupLongest("10.0.0.1")).To(Equal(""))
+		})
+
+		Context("IPv4", func() {
+			BeforeEach(func() {
+				update("10.0.0.0/8")
+				update("10.0.1.0/24")
+				update("10.0.1.1/32")
+				update("11.0.0.0/8")
+			})
+
+			It("should find the most specific match", func() {
+				Expect(lookupLongest("10.0.1.1")).To(Equal("10.0.1.1/32"))
+				Expect(lookupLongest("10.0.1.2")).To(Equal("10.0.1.0/24"))
+				Expect(lookupLongest("10.0.2.1")).To(Equal("10.0.0.0/8"))
+				Expect(lookupLongest("11.1.2.3")).To(Equal("11.0.0.0/8"))
+			})
+
+			It("should skip intermediate nodes", func() {
+				// 10.0.0.0/7 is an intermediate node joining 10/8 and 11/8.
+				remove("10.0.0.0/8")
+				Expect(lookupLongest("10.0.2.1")).To(Equal(""))
+			})
+
+			It("should return nothing outside the trie", func() {
+				Expect(lookupLongest("12.0.0.1")).To(Equal(""))
+			})
+		})
+
+		Context("IPv6", func() {
+			BeforeEach(func() {
+				update("fc00:fe11::/96")
+				update("fc00:fe11::1:0/112")
+				update("fc00:fe11::1:1/128")
+			})
+
+			It("should find the most specific match", func() {
+				Expect(lookupLongest("fc00:fe11::1:1")).To(Equal("fc00:fe11::1:1/128"))
+				Expect(lookupLongest("fc00:fe11::1:2")).To(Equal("fc00:fe11::1:0/112"))
+				Expect(lookupLongest("fc00:fe11::2:1")).To(Equal("fc00:fe11::/96"))
+				Expect(lookupLongest("fc00:fe12::1")).To(Equal(""))
+			})
+		})
+	})
+
+	Context("LookupShortestCovering", func() {
+		lookupShortest := func(cidr string) string {
+			result, data, ok := trie.LookupShortestCovering(ip.MustParseCIDROrIP(cidr))
+			if !ok {
+				Expect(result).To(BeNil())
+				Expect(data).To(BeNil())
+				return ""
+			}
+			Expect(data).To(Equal("data:" + result.String()))
+			return result.String()
+		}
+
+		It("should return nothing for an empty trie", func() {
+			Expect(lookupShortest("10.0.0.0/24")).To(Equal(""))
+		})
+
+		Context("IPv4", func() {
+			BeforeEach(func() {
+				update("10.0.0.0/16")
+				update("10.0.0.0/8")
+				update("10.1.0.0/16")
+				update("10.1.1.0/24")
+				update("11.0.0.0/16")
+			})
+
+			It("should find the least specific match", func() {
+				Expect(lookupShortest("10.0.1.0/24")).To(Equal("10.0.0.0/8"))
+				Expect(lookupShortest("10.1.1.1/32")).To(Equal("10.0.0.0/8"))
+				Expect(lookupShortest("11.0.1.0/24")).To(Equal("11.0.0.0/16"))
+			})
+
+			It("should match the CIDR itself", func() {
+				Expect(lookupShortest("10.0.0.0/8")).To(Equal("10.0.0.0/8"))
+				Expect(lookupShortest("11.0.0.0/16")).To(Equal("11.0.0.0/16"))
+			})
+
+			It("should skip intermediate nodes", func() {
+				// 10.0.0.0/7 is an intermediate node joining 10/8 and 11/16.
+				Expect(lookupShortest("10.0.0.0/7")).To(Equal(""))
+				remove("10.0.0.0/8")
+				Expect(lookupShortest("10.1.1.0/24")).To(Equal("10.1.0.0/16"))
+			})
+
+			It("should not match CIDRs that only partly cover the query", func() {
+				Expect(lookupShortest("11.0.0.0/8")).To(Equal(""))
+				Expect(lookupShortest("12.0.0.0/24")).To(Equal(""))
+			})
+		})
+
+		Context("IPv6", func() {
+			BeforeEach(func() {
+				update("fc00:fe11::/96")
+				update("fc00:fe11::1:0/112")
+				update("fc00:fe12::1:0/112")
+			})
+
+			It("should find the least specific match", func() {
+				Expect(lookupShortest("fc00:fe11::1:1/128")).To(Equal("fc00:fe11::/96"))
+				Expect(lookupShortest("fc00:fe12::1:1/128")).To(Equal("fc00:fe12::1:0/112"))
+				Expect(lookupShortest("fc00:fe12::/96")).To(Equal(""))
+			})
+		})
+	})
+
+	Context("VisitPath", func() {
+		visitPath := func(cidr string) []string {
+			var s []string
+			trie.VisitPath(ip.MustParseCIDROrIP(cidr), func(c ip.CIDR, data interface{}) bool {
+				Expect(data).To(Equal("data:" + c.String()))
+				s = append(s, c.String())
+				return true
+			})
+			return s
+		}
+
+		BeforeEach(func() {
+			update("10.0.0.0/8")
+			update("10.0.0.0/16")
+			update("10.0.1.0/24")
+			update("10.0.1.1/32")
+			update("11.0.0.0/16")
+		})
+
+		It("should visit the enclosing CIDRs, most specific first", func() {
+			Expect(visitPath("10.0.1.1/32")).To(Equal([]string{"10.0.1.1/32", "10.0.1.0/24", "10.0.0.0/16", "10.0.0.0/8"}))
+			Expect(visitPath("10.0.1.2/32")).To(Equal([]string{"10.0.1.0/24", "10.0.0.0/16", "10.0.0.0/8"}))
+			Expect(visitPath("10.0.1.0/25")).To(Equal([]string{"10.0.1.0/24", "10.0.0.0/16", "10.0.0.0/8"}))
+			Expect(visitPath("10.0.0.0/16")).To(Equal([]string{"10.0.0.0/16", "10.0.0.0/8"}))
+			Expect(visitPath("10.1.0.0/16")).To(Equal([]string{"10.0.0.0/8"}))
+			Expect(visitPath("11.0.0.0/8")).To(BeNil())
+			Expect(visitPath("10.0.0.0/7")).To(BeNil())
+			Expect(visitPath("fd00::/64")).To(BeNil())
+		})
+
+		It("should stop when the callback returns false", func() {
+			var s []string
+			trie.VisitPath(ip.MustParseCIDROrIP("10.0.1.1/32"), func(c ip.CIDR, _ interface{}) bool {
+				s = append(s, c.String())
+				return false
+			})
+			Expect(s).To(Equal([]string{"10.0.1.1/32"}))
+		})
+
+		It("should not allocate", func() {
+			cidr := ip.MustParseCIDROrIP("10.0.1.1/32")
+			var count int
+			f := func(ip.CIDR, interface{}) bool {
+				count++
+				return true
+			}
+			Expect(testing.AllocsPerRun(100, func() {
+				trie.VisitPath(cidr, f)
+			})).To(BeZero())
+			Expect(count).To(Equal(404))
+		})
+	})
+
+	Context("Descendants", func() {
+		descendants := func(cidr string) []string {
+			var s []string
+			for _, t := range trie.Descendants(nil, ip.MustParseCIDROrIP(cidr)) {
+				cidrStr := t.CIDR.String()
+				Expect(t.Data).To(Equal("data:"+cidrStr), "Trie returned entry with unexpected data")
+				s = append(s, cidrStr)
+			}
+			return s
+		}
+
+		It("should return nothing for an empty trie", func() {
+			Expect(descendants("10.0.0.0/8")).To(BeEmpty())
+		})
+
+		Context("IPv4", func() {
+			BeforeEach(func() {
+				update("10.0.0.0/8")
+				update("10.0.1.0/24")
+				update("10.0.1.1/32")
+				update("10.0.2.1/32")
+				update("11.0.0.0/8")
+			})
+
+			It("should return everything under a stored CIDR", func() {
+				Expect(descendants("10.0.0.0/8")).To(ConsistOf("10.0.0.0/8", "10.0.1.0/24", "10.0.1.1/32", "10.0.2.1/32"))
+			})
+
+			It("should return everything under a CIDR that isn't stored", func() {
+				Expect(descendants("10.0.0.0/16")).To(ConsistOf("10.0.1.0/24", "10.0.1.1/32", "10.0.2.1/32"))
+				Expect(descendants("0.0.0.0/0")).To(HaveLen(5))
+			})
+
+			It("should ignore enclosing CIDRs", func() {
+				Expect(descendants("10.0.1.1/32")).To(ConsistOf("10.0.1.1/32"))
+			})
+
+			It("should return nothing for a disjoint CIDR", func() {
+				Expect(descendants("12.0.0.0/8")).To(BeEmpty())
+				Expect(descendants("10.0.3.0/24")).To(BeEmpty())
+			})
+		})
+
+		Context("IPv6", func() {
+			BeforeEach(func() {
+				update("fc00:fe11::/96")
+				update("fc00:fe11::1:0/112")
+				update("fc00:fe11::1:1/128")
+				update("fc00:fe12::/96")
+			})
+
+			It("should return the covered CIDRs", func() {
+				Expect(descendants("fc00:fe11::/64")).To(ConsistOf("fc00:fe11::/96", "fc00:fe11::1:0/112", "fc00:fe11::1:1/128"))
+				Expect(descendants("fc00:fe11::1:0/112")).To(ConsistOf("fc00:fe11::1:0/112", "fc00:fe11::1:1/128"))
+				Expect(descendants("fc00:fe13::/96")).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("DeletePrefix", func() {
+		BeforeEach(func() {
+			update("10.0.0.0/8")
+			update("10.0.1.0/24")
+			update("10.0.1.1/32")
+			update("10.0.2.1/32")
+			update("11.0.0.0/8")
+		})
+
+		It("should remove a stored CIDR and everything under it", func() {
+			Expect(trie.DeletePrefix(ip.MustParseCIDROrIP("10.0.1.0/24"))).To(Equal(2))
+			Expect(trie.Len()).To(Equal(3))
+			Expect(contents()).To(ConsistOf("10.0.0.0/8", "10.0.2.1/32", "11.0.0.0/8"))
+			Expect(lookup("10.0.2.1/32")).To(ConsistOf("10.0.0.0/8", "10.0.2.1/32"))
+		})
+
+		It("should remove everything under a CIDR that isn't stored", func() {
+			Expect(trie.DeletePrefix(ip.MustParseCIDROrIP("10.0.0.0/16"))).To(Equal(3))
+			Expect(contents()).To(ConsistOf("10.0.0.0/8", "11.0.0.0/8"))
+		})
+
+		It("should collapse the intermediate node above the removed subtree", func() {
+			Expect(trie.DeletePrefix(ip.MustParseCIDROrIP("10.0.0.0/8"))).To(Equal(4))
+			Expect(contents()).To(ConsistOf("11.0.0.0/8"))
+			Expect(lookup("11.0.0.0/8")).To(ConsistOf("11.0.0.0/8"))
+			update("10.0.0.0/8")
+			Expect(contents()).To(ConsistOf("10.0.0.0/8", "11.0.0.0/8"))
+		})
+
+		It("should remove everything", func() {
+			Expect(trie.DeletePrefix(ip.MustParseCIDROrIP("0.0.0.0/0"))).To(Equal(5))
+			Expect(contents()).To(BeEmpty())
+			Expect(trie.Len()).To(Equal(0))
+		})
+
+		It("should do nothing for a disjoint CIDR", func() {
+			Expect(trie.DeletePrefix(ip.MustParseCIDROrIP("12.0.0.0/8"))).To(Equal(0))
+			Expect(trie.DeletePrefix(ip.MustParseCIDROrIP("10.0.3.0/24"))).To(Equal(0))
+			Expect(contents()).To(HaveLen(5))
+		})
+	})
+
+	Context("DeleteIf", func() {
+		BeforeEach(func() {
+			update("10.0.0.0/8")
+			update("10.0.1.0/24")
+			update("10.0.1.1/32")
+			update("10.0.2.1/32")
+			update("11.0.0.0/8")
+		})
+
+		It("should call the predicate for each entry in address order", func() {
+			var visited []string
+			Expect(trie.DeleteIf(func(cidr ip.CIDR, data interface{}) bool {
+				Expect(data).To(Equal("data:" + cidr.String()))
+				visited = append(visited, cidr.String())
+				return false
+			})).To(Equal(0))
+			Expect(visited).To(Equal([]string{"10.0.0.0/8", "10.0.1.0/24", "10.0.1.1/32", "10.0.2.1/32", "11.0.0.0/8"}))
+			Expect(trie.Len()).To(Equal(5))
+		})
+
+		It("should remove matching entries and collapse intermediate nodes", func() {
+			Expect(trie.DeleteIf(func(cidr ip.CIDR, _ interface{}) bool {
+				return cidr.Prefix() == 32 || cidr.String() == "10.0.0.0/8"
+			})).To(Equal(3))
+			Expect(trie.Len()).To(Equal(2))
+			Expect(contents()).To(ConsistOf("10.0.1.0/24", "11.0.0.0/8"))
+			Expect(lookup("10.0.1.0/24")).To(ConsistOf("10.0.1.0/24"))
+
+			// The result should have the same shape as a trie built from scratch.
+			expected := ip.NewCIDRTrie()
+			expected.Update(ip.MustParseCIDROrIP("10.0.1.0/24"), "data:10.0.1.0/24")
+			expected.Update(ip.MustParseCIDROrIP("11.0.0.0/8"), "data:11.0.0.0/8")
+			Expect(trie.Equal(expected, func(a, b interface{}) bool { return a == b })).To(BeTrue())
+		})
+
+		It("should remove everything", func() {
+			Expect(trie.DeleteIf(func(ip.CIDR, interface{}) bool { return true })).To(Equal(5))
+			Expect(contents()).To(BeEmpty())
+			update("10.0.0.0/8")
+			Expect(contents()).To(ConsistOf("10.0.0.0/8"))
+		})
+
+		It("should leave snapshots intact", func() {
+			snap := trie.Snapshot()
+			Expect(trie.DeleteIf(func(cidr ip.CIDR, _ interface{}) bool {
+				return cidr.Prefix() == 8
+			})).To(Equal(2))
+			Expect(contents()).To(ConsistOf("10.0.1.0/24", "10.0.1.1/32", "10.0.2.1/32"))
+			Expect(snap.Len()).To(Equal(5))
+			Expect(snap.ToSlice()).To(HaveLen(5))
+		})
+
+		It("should match deleting entries one by one for random tries", func() {
+			rng := rand.New(rand.NewSource(1))
+			for i := 0; i < 100; i++ {
+				trie = ip.NewCIDRTrie()
+				expected := ip.NewCIDRTrie()
+				for j := 0; j < 50; j++ {
+					var addr ip.V4Addr
+					rng.Read(addr[:2])
+					cidr := ip.CIDRFromAddrAndPrefix(addr, rng.Intn(17))
+					trie.Update(cidr, j)
+					expected.Update(cidr, j)
+				}
+				shouldDelete := func(_ ip.CIDR, data interface{}) bool { return data.(int)%3 == 0 }
+				var toDelete []ip.CIDR
+				expected.Visit(func(cidr ip.CIDR, data interface{}) bool {
+					if shouldDelete(cidr, data) {
+						toDelete = append(toDelete, cidr)
+					}
+					return true
+				})
+				for _, c := range toDelete {
+					expected.Delete(c)
+				}
+				Expect(trie.DeleteIf(shouldDelete)).To(Equal(len(toDelete)))
+				Expect(trie.Len()).To(Equal(expected.Len()))
+				Expect(trie.Equal(expected, func(a, b interface{}) bool { return a == b })).To(BeTrue())
+			}
+		})
+	})
+
+	Context("Visit", func() {
+		BeforeEach(func() {
+			update("11.0.0.0/8")
+			update("10.0.1.1/32")
+			update("10.0.0.0/8")
+			update("10.0.1.0/24")
+		})
+
+		It("should visit in order", func() {
+			var s []string
+			trie.Visit(func(cidr ip.CIDR, data interface{}) bool {
+				Expect(data).To(Equal("data:" + cidr.String()))
+				s = append(s, cidr.String())
+				return true
+			})
+			Expect(s).To(Equal([]string{"10.0.0.0/8", "10.0.1.0/24", "10.0.1.1/32", "11.0.0.0/8"}))
+		})
+
+		It("should stop early", func() {
+			var s []string
+			trie.Visit(func(cidr ip.CIDR, data interface{}) bool {
+				s = append(s, cidr.String())
+				return len(s) < 2
+			})
+			Expect(s).To(Equal([]string{"10.0.0.0/8", "10.0.1.0/24"}))
+		})
+	})
+
+	Context("Snapshot", func() {
+		var snap *ip.CIDRTrie
+
+		snapContents := func() []string {
+			var s []string
+			snap.Visit(func(cidr ip.CIDR, data interface{}) bool {
+				s = append(s, cidr.String())
+				return true
+			})
+			return s
+		}
+
+		BeforeEach(func() {
+			update("10.0.0.0/8")
+			update("10.0.1.0/24")
+			update("10.0.1.1/32")
+			update("11.0.0.0/8")
+			snap = trie.Snapshot()
+		})
+
+		It("should be read only", func() {
+			Expect(snap.ReadOnly()).To(BeTrue())
+			Expect(trie.ReadOnly()).To(BeFalse())
+			Expect(func() { snap.Update(ip.MustParseCIDROrIP("12.0.0.0/8"), "data") }).To(Panic())
+			Expect(func() { snap.Delete(ip.MustParseCIDROrIP("10.0.0.0/8")) }).To(Panic())
+			Expect(func() { snap.DeletePrefix(ip.MustParseCIDROrIP("10.0.0.0/8")) }).To(Panic())
+		})
+
+		It("should be unaffected by updates to the trie", func() {
+			update("10.0.1.2/32")
+			update("12.0.0.0/8")
+			trie.Update(ip.MustParseCIDROrIP("10.0.1.0/24"), "changed")
+			remove("10.0.1.1/32")
+			remove("10.0.0.0/8")
+			Expect(trie.Len()).To(Equal(4))
+
+			Expect(snapContents()).To(Equal([]string{"10.0.0.0/8", "10.0.1.0/24", "10.0.1.1/32", "11.0.0.0/8"}))
+			Expect(snap.Len()).To(Equal(4))
+			Expect(snap.Get(ip.MustParseCIDROrIP("10.0.1.0/24"))).To(Equal("data:10.0.1.0/24"))
+			Expect(trie.Get(ip.MustParseCIDROrIP("10.0.1.0/24"))).To(Equal("changed"))
+		})
+
+		It("should be unaffected by DeletePrefix on the trie", func() {
+			Expect(trie.DeletePrefix(ip.MustParseCIDROrIP("10.0.0.0/16"))).To(Equal(2))
+			Expect(contents()).To(ConsistOf("10.0.0.0/8", "11.0.0.0/8"))
+			Expect(snapContents()).To(Equal([]string{"10.0.0.0/8", "10.0.1.0/24", "10.0.1.1/32", "11.0.0.0/8"}))
+		})
+
+		It("should support multiple snapshots", func() {
+			update("12.0.0.0/8")
+			snap2 := trie.Snapshot()
+			remove("10.0.0.0/8")
+			Expect(snap2.Len()).To(Equal(5))
+			Expect(snap2.Get(ip.MustParseCIDROrIP("10.0.0.0/8"))).NotTo(BeNil())
+			Expect(snap.Get(ip.MustParseCIDROrIP("12.0.0.0/8"))).To(BeNil())
+			Expect(trie.Len()).To(Equal(4))
+		})
+	})
+
 	pEntry := func(cidrs ...string) TableEntry {
 		return Entry(fmt.Sprint(cidrs), cidrs)
 	}
@@ -428,6 +933,8 @@ var _ = Describe("CIDRTrie tests", func() {
 			permute(cidrs, func(cidrs []string) {
 				// expected tracks the CIDRs that should be in the trie.
 				expected := set.New[string]()
+				var snap *ip.CIDRTrie
+				var snapExpected []string
 				for _, c := range cidrs {
 					// Add or remove the given CIDR depending on whether it should be there or not.
 					if expected.Contains(c) {
@@ -451,6 +958,18 @@ var _ = Describe("CIDRTrie tests", func() {
 					})
 					Expect(contents()).To(ConsistOf(expSlice),
 						fmt.Sprintf("Trie had incorrect contents with this sequence of CIDRs: %s", cidrs))
+					Expect(trie.Len()).To(Equal(expected.Len()))
+
+					// Check that the previous snapshot wasn't disturbed by this update and then take a new one.
+					if snap != nil {
+						var snapSlice []string
+						for _, e := range snap.ToSlice() {
+							snapSlice = append(snapSlice, e.CIDR.String())
+						}
+						Expect(snapSlice).To(ConsistOf(snapExpected), "Snapshot was modified by update to trie")
+					}
+					snap = trie.Snapshot()
+					snapExpected = expSlice
 				}
 			})
 		},
@@ -466,9 +985,164 @@ var _ = Describe("CIDRTrie tests", func() {
 		pEntry("fc00:fe11::4/128", "fc00:fe11::5/128", "fc00:fe11::6/128"),
 		pEntry("::/0", "8000::/1", "::/1"), // ::/0 is the intermediate node for the other two CIDRs.
 		pEntry("fc00:fe11::/96", "fc00:fe11:1:2:3::/120", "fc00:fe11:1:2:3::1/128"),
+		pEntry("fc00:fe11::/63", "fc00:fe11:0:1::/64", "fc00:fe11::/64"), // Split on the 64th bit.
 	)
 })
 
+var _ = Describe("Trie stats", func() {
+	It("should return zero stats for an empty trie", func() {
+		Expect(ip.NewCIDRTrie().Stats()).To(Equal(ip.TrieStats{}))
+	})
+
+	It("should describe the trie structure", func() {
+		trie := ip.NewTrie[int]()
+		for _, c := range []string{"10.0.0.0/8", "10.0.1.0/24", "10.0.2.0/24", "11.0.0.0/8"} {
+			trie.Update(ip.MustParseCIDROrIP(c), 1)
+		}
+		// 10.0.0.0/7 (intermediate)
+		//   10.0.0.0/8
+		//     10.0.0.0/22 (intermediate)
+		//       10.0.1.0/24
+		//       10.0.2.0/24
+		//   11.0.0.0/8
+		stats := trie.Stats()
+		Expect(stats.NumNodes).To(Equal(6))
+		Expect(stats.NumEntries).To(Equal(4))
+		Expect(stats.NumEntries).To(Equal(trie.Len()))
+		Expect(stats.NumIntermediateNodes).To(Equal(2))
+		Expect(stats.NumLeaves).To(Equal(3))
+		Expect(stats.MaxDepth).To(Equal(4))
+		Expect(stats.EstimatedBytes).To(BeNumerically(">", 6*8))
+	})
+
+	It("should estimate more memory for IPv6", func() {
+		v4 := ip.NewTrie[int]()
+		v4.Update(ip.MustParseCIDROrIP("10.0.0.0/8"), 1)
+		v6 := ip.NewTrie[int]()
+		v6.Update(ip.MustParseCIDROrIP("fc00::/8"), 1)
+		Expect(v6.Stats().EstimatedBytes).To(BeNumerically(">", v4.Stats().EstimatedBytes))
+	})
+})
+
+var _ = Describe("Typed Trie tests", func() {
+	type payload struct {
+		owner string
+	}
+	var trie *ip.Trie[payload]
+
+	BeforeEach(func() {
+		trie = ip.NewTrie[payload]()
+	})
+
+	It("should store and retrieve typed values", func() {
+		trie.Update(ip.MustParseCIDROrIP("10.0.0.0/8"), payload{owner: "a"})
+		trie.Update(ip.MustParseCIDROrIP("10.0.1.0/24"), payload{owner: "b"})
+		Expect(trie.Get(ip.MustParseCIDROrIP("10.0.1.0/24"))).To(Equal(payload{owner: "b"}))
+		Expect(trie.LookupPath(nil, ip.MustParseCIDROrIP("10.0.1.0/24"))).To(Equal([]ip.TrieEntry[payload]{
+			{CIDR: ip.MustParseCIDROrIP("10.0.0.0/8"), Data: payload{owner: "a"}},
+			{CIDR: ip.MustParseCIDROrIP("10.0.1.0/24"), Data: payload{owner: "b"}},
+		}))
+	})
+
+	It("should allow storing the zero value", func() {
+		trie.Update(ip.MustParseCIDROrIP("10.0.0.0/8"), payload{})
+		Expect(trie.ToSlice()).To(HaveLen(1))
+		Expect(trie.Covers(ip.MustParseCIDROrIP("10.0.0.1/32"))).To(BeTrue())
+		trie.Delete(ip.MustParseCIDROrIP("10.0.0.0/8"))
+		Expect(trie.ToSlice()).To(BeEmpty())
+	})
+
+	It("should return the zero value for a missing CIDR", func() {
+		Expect(trie.Get(ip.MustParseCIDROrIP("10.0.0.0/8"))).To(Equal(payload{}))
+		cidr, data := trie.LPM(ip.MustParseCIDROrIP("10.0.0.1/32"))
+		Expect(cidr).To(Equal(ip.V4CIDR{}))
+		Expect(data).To(Equal(payload{}))
+	})
+
+	It("should distinguish a stored zero value from a missing CIDR with GetExact", func() {
+		trie.Update(ip.MustParseCIDROrIP("10.0.0.0/8"), payload{})
+		trie.Update(ip.MustParseCIDROrIP("10.0.1.0/24"), payload{owner: "b"})
+		trie.Update(ip.MustParseCIDROrIP("10.0.2.0/24"), payload{owner: "c"})
+
+		data, ok := trie.GetExact(ip.MustParseCIDROrIP("10.0.0.0/8"))
+		Expect(ok).To(BeTrue())
+		Expect(data).To(Equal(payload{}))
+
+		data, ok = trie.GetExact(ip.MustParseCIDROrIP("10.0.2.0/24"))
+		Expect(ok).To(BeTrue())
+		Expect(data).To(Equal(payload{owner: "c"}))
+
+		By("not matching a covering or covered CIDR")
+		_, ok = trie.GetExact(ip.MustParseCIDROrIP("10.0.1.1/32"))
+		Expect(ok).To(BeFalse())
+		_, ok = trie.GetExact(ip.MustParseCIDROrIP("10.0.0.0/7"))
+		Expect(ok).To(BeFalse())
+
+		By("not matching an intermediate node")
+		_, ok = trie.GetExact(ip.MustParseCIDROrIP("10.0.0.0/22"))
+		Expect(ok).To(BeFalse())
+
+		By("not matching in an empty trie")
+		_, ok = ip.NewTrie[payload]().GetExact(ip.MustParseCIDROrIP("10.0.0.0/8"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should stay consistent with snapshots under churn", func() {
+		// Deleted nodes are recycled; make sure that nodes that are shared with snapshots never are.
+		rng := rand.New(rand.NewSource(3))
+		model := map[ip.CIDR]payload{}
+		type snapshot struct {
+			trie  *ip.Trie[payload]
+			model map[ip.CIDR]payload
+		}
+		var snapshots []snapshot
+		randomCIDR := func() ip.CIDR {
+			return ip.CIDRFromAddrAndPrefix(ip.V4Addr{10, 0, byte(rng.Intn(4)), byte(rng.Intn(256))}, 20+rng.Intn(13))
+		}
+		for i := 0; i < 5000; i++ {
+			switch rng.Intn(10) {
+			case 0:
+				cidr := randomCIDR()
+				trie.DeletePrefix(cidr)
+				for c := range model {
+					if cidr.ContainsCIDR(c) {
+						delete(model, c)
+					}
+				}
+			case 1, 2, 3, 4:
+				cidr := randomCIDR()
+				trie.Delete(cidr)
+				delete(model, cidr)
+			default:
+				cidr := randomCIDR()
+				trie.Update(cidr, payload{owner: fmt.Sprint(i)})
+				model[cidr] = payload{owner: fmt.Sprint(i)}
+			}
+			if i%500 == 0 {
+				modelCopy := map[ip.CIDR]payload{}
+				for k, v := range model {
+					modelCopy[k] = v
+				}
+				snapshots = append(snapshots, snapshot{trie: trie.Snapshot(), model: modelCopy})
+			}
+		}
+		trieContents := func(t *ip.Trie[payload]) map[ip.CIDR]payload {
+			m := map[ip.CIDR]payload{}
+			t.Visit(func(cidr ip.CIDR, data payload) bool {
+				m[cidr] = data
+				return true
+			})
+			return m
+		}
+		Expect(trieContents(trie)).To(Equal(model))
+		Expect(trie.Len()).To(Equal(len(model)))
+		for _, snap := range snapshots {
+			Expect(trieContents(snap.trie)).To(Equal(snap.model))
+			Expect(snap.trie.Len()).To(Equal(len(snap.model)))
+		}
+	})
+})
+
 // Based on the blog post at https://yourbasic.org/golang/generate-permutation-slice-string/ (CC-BY-3.0)
 // permute calls f with each permutation of a.
 func permute(a []string, f func([]string)) {
@@ -497,3 +1171,184 @@ func BenchmarkV4Addr_AsUint32(b *testing.B) {
 		benchmarkResult += a.AsUint32()
 	}
 }
+
+func BenchmarkV4CommonPrefix(b *testing.B) {
+	x := ip.MustParseCIDROrIP("10.0.1.0/24").(ip.V4CIDR)
+	y := ip.MustParseCIDROrIP("10.0.3.128/25").(ip.V4CIDR)
+	for i := 0; i < b.N; i++ {
+		benchmarkResult += uint32(ip.V4CommonPrefix(x, y).Prefix())
+	}
+}
+
+func BenchmarkV6CommonPrefix(b *testing.B) {
+	x := ip.MustParseCIDROrIP("fd00:1:2:3::/64").(ip.V6CIDR)
+	y := ip.MustParseCIDROrIP("fd00:1:2:3:4::/80").(ip.V6CIDR)
+	for i := 0; i < b.N; i++ {
+		benchmarkResult += uint32(ip.V6CommonPrefix(x, y).Prefix())
+	}
+}
+
+func BenchmarkTrie_Update(b *testing.B) {
+	cidrs := make([]ip.CIDR, 4096)
+	for i := range cidrs {
+		cidrs[i] = ip.V4Addr{10, byte(i >> 8), byte(i), 0}.AsCIDR()
+	}
+	trie := ip.NewTrie[int]()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		trie.Update(cidrs[i%len(cidrs)], i)
+	}
+}
+
+func BenchmarkTrie_Visit(b *testing.B) {
+	trie := ip.NewTrie[int]()
+	for i := 0; i < 1000; i++ {
+		trie.Update(ip.V4Addr{10, 0, byte(i >> 8), byte(i)}.AsCIDR(), i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		trie.Visit(func(cidr ip.CIDR, data int) bool {
+			benchmarkResult += uint32(data)
+			return true
+		})
+	}
+}
+
+func BenchmarkTrie_VisitPath(b *testing.B) {
+	trie := ip.NewTrie[int]()
+	for i := 0; i < 1000; i++ {
+		trie.Update(ip.V4Addr{10, 0, byte(i >> 8), byte(i)}.AsCIDR(), i)
+	}
+	trie.Update(ip.MustParseCIDROrIP("10.0.0.0/16"), -1)
+	cidr := ip.MustParseCIDROrIP("10.0.1.1/32")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		trie.VisitPath(cidr, func(cidr ip.CIDR, data int) bool {
+			benchmarkResult += uint32(data)
+			return false
+		})
+	}
+}
+
+// BenchmarkTrie_Churn simulates endpoint churn: it repeatedly removes and re-adds entries in a trie
+// that holds a steady-state population of /32s.
+func BenchmarkTrie_Churn(b *testing.B) {
+	const numCIDRs = 10000
+	cidrs := make([]ip.CIDR, numCIDRs)
+	for i := range cidrs {
+		cidrs[i] = ip.V4Addr{10, byte(i >> 16), byte(i >> 8), byte(i)}.AsCIDR()
+	}
+	trie := ip.NewTrie[int]()
+	for i, c := range cidrs {
+		trie.Update(c, i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := cidrs[(i*7919)%numCIDRs]
+		trie.Delete(c)
+		trie.Update(c, i)
+	}
+}
+
+// BenchmarkTrie_ChurnPrefix is like BenchmarkTrie_Churn but removes and re-adds a block of entries at a
+// time.
+func BenchmarkTrie_ChurnPrefix(b *testing.B) {
+	const numBlocks = 256
+	var cidrs [numBlocks][64]ip.CIDR
+	blocks := make([]ip.CIDR, numBlocks)
+	for i := range cidrs {
+		blocks[i] = ip.MustParseCIDROrIP(fmt.Sprintf("10.0.%d.0/26", i))
+		for j := range cidrs[i] {
+			cidrs[i][j] = ip.V4Addr{10, 0, byte(i), byte(j)}.AsCIDR()
+		}
+	}
+	trie := ip.NewTrie[int]()
+	for i := range cidrs {
+		for _, c := range cidrs[i] {
+			trie.Update(c, i)
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		blockIdx := (i * 31) % numBlocks
+		trie.DeletePrefix(blocks[blockIdx])
+		for _, c := range cidrs[blockIdx] {
+			trie.Update(c, i)
+		}
+	}
+}
+
+// bench1MCIDRs returns a fixed set of one million random IPv4 CIDRs, roughly the size of a full Internet
+// routing table.
+var bench1MCIDRs = func() func() []ip.CIDR {
+	var cidrs []ip.CIDR
+	return func() []ip.CIDR {
+		if cidrs == nil {
+			rng := rand.New(rand.NewSource(1))
+			cidrs = make([]ip.CIDR, 1000000)
+			for i := range cidrs {
+				addr := ip.V4Addr{byte(rng.Intn(224)), byte(rng.Intn(256)), byte(rng.Intn(256)), byte(rng.Intn(256))}
+				cidrs[i] = ip.CIDRFromAddrAndPrefix(addr, 16+rng.Intn(17))
+			}
+		}
+		return cidrs
+	}
+}()
+
+func bench1MTrie() *ip.Trie[int] {
+	trie := ip.NewTrie[int]()
+	for i, c := range bench1MCIDRs() {
+		trie.Update(c, i)
+	}
+	return trie
+}
+
+func BenchmarkTrie_1M_Build(b *testing.B) {
+	bench1MCIDRs()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchmarkResult += uint32(bench1MTrie().Len())
+	}
+}
+
+func BenchmarkTrie_1M_BuildSorted(b *testing.B) {
+	entries := bench1MTrie().ToSlice()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchmarkResult += uint32(ip.BuildTrie(entries).Len())
+	}
+}
+
+func BenchmarkTrie_1M_LookupLongestPrefix(b *testing.B) {
+	trie := bench1MTrie()
+	rng := rand.New(rand.NewSource(2))
+	addrs := make([]ip.Addr, 4096)
+	for i := range addrs {
+		addrs[i] = ip.V4Addr{byte(rng.Intn(224)), byte(rng.Intn(256)), byte(rng.Intn(256)), byte(rng.Intn(256))}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, v, _ := trie.LookupLongestPrefix(addrs[i%len(addrs)])
+		benchmarkResult += uint32(v)
+	}
+}
+
+func BenchmarkTrie_1M_Visit(b *testing.B) {
+	trie := bench1MTrie()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		trie.Visit(func(cidr ip.CIDR, data int) bool {
+			benchmarkResult += uint32(data)
+			return true
+		})
+	}
+}
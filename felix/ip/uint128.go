@@ -0,0 +1,141 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Uint128 is an unsigned 128-bit integer, used for IPv6 address arithmetic without resorting to
+// math/big.  It is the IPv6 analogue of the uint32 returned by V4Addr.AsUint32().  Arithmetic wraps
+// around on overflow.
+type Uint128 struct {
+	Hi, Lo uint64
+}
+
+var uint128Max = Uint128{Hi: ^uint64(0), Lo: ^uint64(0)}
+
+// AsUint128 returns the address as a 128-bit integer.
+func (a V6Addr) AsUint128() Uint128 {
+	hi, lo := a.AsUint64Pair()
+	return Uint128{Hi: hi, Lo: lo}
+}
+
+// V6AddrFromUint128 converts a 128-bit integer to an IPv6 address.
+func V6AddrFromUint128(u Uint128) V6Addr {
+	return v6AddrFromUint64Pair(u.Hi, u.Lo)
+}
+
+// addrToUint128 converts an address of either IP version to a Uint128; IPv4 addresses occupy the low 32
+// bits.
+func addrToUint128(addr Addr) Uint128 {
+	switch a := addr.(type) {
+	case V4Addr:
+		return Uint128{Lo: uint64(a.AsUint32())}
+	case V6Addr:
+		return a.AsUint128()
+	}
+	return Uint128{}
+}
+
+func (u Uint128) toV4Addr() (a V4Addr) {
+	binary.BigEndian.PutUint32(a[:], uint32(u.Lo))
+	return
+}
+
+func (u Uint128) Add(v Uint128) Uint128 {
+	lo, carry := bits.Add64(u.Lo, v.Lo, 0)
+	hi, _ := bits.Add64(u.Hi, v.Hi, carry)
+	return Uint128{Hi: hi, Lo: lo}
+}
+
+func (u Uint128) Sub(v Uint128) Uint128 {
+	lo, borrow := bits.Sub64(u.Lo, v.Lo, 0)
+	hi, _ := bits.Sub64(u.Hi, v.Hi, borrow)
+	return Uint128{Hi: hi, Lo: lo}
+}
+
+// Compare returns -1, 0 or 1 depending on whether u is less than, equal to or greater than v.
+func (u Uint128) Compare(v Uint128) int {
+	switch {
+	case u.Hi < v.Hi:
+		return -1
+	case u.Hi > v.Hi:
+		return 1
+	case u.Lo < v.Lo:
+		return -1
+	case u.Lo > v.Lo:
+		return 1
+	}
+	return 0
+}
+
+func (u Uint128) IsZero() bool {
+	return u.Hi == 0 && u.Lo == 0
+}
+
+func (u Uint128) And(v Uint128) Uint128 {
+	return Uint128{Hi: u.Hi & v.Hi, Lo: u.Lo & v.Lo}
+}
+
+func (u Uint128) Or(v Uint128) Uint128 {
+	return Uint128{Hi: u.Hi | v.Hi, Lo: u.Lo | v.Lo}
+}
+
+func (u Uint128) Xor(v Uint128) Uint128 {
+	return Uint128{Hi: u.Hi ^ v.Hi, Lo: u.Lo ^ v.Lo}
+}
+
+func (u Uint128) Not() Uint128 {
+	return Uint128{Hi: ^u.Hi, Lo: ^u.Lo}
+}
+
+// Lsh returns u shifted left by n bits.
+func (u Uint128) Lsh(n uint) Uint128 {
+	if n >= 64 {
+		return Uint128{Hi: u.Lo << (n - 64)}
+	}
+	return Uint128{Hi: u.Hi<<n | u.Lo>>(64-n), Lo: u.Lo << n}
+}
+
+// Rsh returns u shifted right by n bits.
+func (u Uint128) Rsh(n uint) Uint128 {
+	if n >= 64 {
+		return Uint128{Lo: u.Hi >> (n - 64)}
+	}
+	return Uint128{Hi: u.Hi >> n, Lo: u.Lo>>n | u.Hi<<(64-n)}
+}
+
+// BitLen returns the minimum number of bits needed to represent u.
+func (u Uint128) BitLen() int {
+	if u.Hi != 0 {
+		return 64 + bits.Len64(u.Hi)
+	}
+	return bits.Len64(u.Lo)
+}
+
+// LeadingZeros returns the number of leading zero bits in u; 128 for zero.
+func (u Uint128) LeadingZeros() int {
+	return 128 - u.BitLen()
+}
+
+// TrailingZeros returns the number of trailing zero bits in u; 128 for zero.
+func (u Uint128) TrailingZeros() int {
+	if u.Lo != 0 {
+		return bits.TrailingZeros64(u.Lo)
+	}
+	return 64 + bits.TrailingZeros64(u.Hi)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ip_test
+
+import (
+	"math/big"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+)
+
+var _ = Describe("Uint128", func() {
+	toBig := func(u ip.Uint128) *big.Int {
+		b := new(big.Int).SetUint64(u.Hi)
+		b.Lsh(b, 64)
+		return b.Or(b, new(big.Int).SetUint64(u.Lo))
+	}
+	mod := new(big.Int).Lsh(big.NewInt(1), 128)
+	wrap := func(b *big.Int) *big.Int {
+		return b.Mod(b, mod)
+	}
+	expectEqual := func(u ip.Uint128, b *big.Int, description ...interface{}) {
+		ExpectWithOffset(1, toBig(u).String()).To(Equal(b.String()), description...)
+	}
+
+	It("should round trip through V6Addr", func() {
+		addr := ip.FromString("fc00:1:2:3:4:5:6:7").(ip.V6Addr)
+		u := addr.AsUint128()
+		Expect(u).To(Equal(ip.Uint128{Hi: 0xfc00000100020003, Lo: 0x0004000500060007}))
+		Expect(ip.V6AddrFromUint128(u)).To(Equal(addr))
+	})
+
+	It("should agree with math/big", func() {
+		rng := rand.New(rand.NewSource(1))
+		randomUint128 := func() ip.Uint128 {
+			// Mix in some edge values.
+			switch rng.Intn(4) {
+			case 0:
+				return ip.Uint128{Lo: uint64(rng.Intn(3))}
+			case 1:
+				return ip.Uint128{Hi: ^uint64(0), Lo: ^uint64(0) - uint64(rng.Intn(3))}
+			}
+			return ip.Uint128{Hi: rng.Uint64(), Lo: rng.Uint64()}
+		}
+		for i := 0; i < 1000; i++ {
+			a, b := randomUint128(), randomUint128()
+			n := uint(rng.Intn(129))
+			bigA, bigB := toBig(a), toBig(b)
+
+			expectEqual(a.Add(b), wrap(new(big.Int).Add(bigA, bigB)))
+			expectEqual(a.Sub(b), wrap(new(big.Int).Sub(bigA, bigB)))
+			Expect(a.Compare(b)).To(Equal(bigA.Cmp(bigB)))
+			expectEqual(a.And(b), new(big.Int).And(bigA, bigB))
+			expectEqual(a.Or(b), new(big.Int).Or(bigA, bigB))
+			expectEqual(a.Xor(b), new(big.Int).Xor(bigA, bigB))
+			expectEqual(a.Not(), new(big.Int).Xor(bigA, new(big.Int).Sub(mod, big.NewInt(1))))
+			expectEqual(a.Lsh(n), wrap(new(big.Int).Lsh(bigA, n)), "Lsh %d", n)
+			expectEqual(a.Rsh(n), new(big.Int).Rsh(bigA, n), "Rsh %d", n)
+			Expect(a.BitLen()).To(Equal(bigA.BitLen()))
+			Expect(a.LeadingZeros()).To(Equal(128 - bigA.BitLen()))
+			Expect(a.IsZero()).To(Equal(bigA.Sign() == 0))
+			if !a.IsZero() {
+				Expect(a.TrailingZeros()).To(Equal(int(bigA.TrailingZeroBits())))
+			} else {
+				Expect(a.TrailingZeros()).To(Equal(128))
+			}
+		}
+	})
+})
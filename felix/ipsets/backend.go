@@ -0,0 +1,242 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipsets
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Backend identifies the userspace tooling that is used to program IP sets into the kernel.
+type Backend string
+
+const (
+	// BackendIPSet programs kernel IP sets using the ipset tool.
+	BackendIPSet Backend = "ipset"
+	// BackendNFT programs nftables named sets using the nft tool.  The sets are created in a
+	// dedicated table (one per IP family) and have the same names as their ipset equivalents.
+	BackendNFT Backend = "nft"
+)
+
+// dataplaneBackend encapsulates the differences between the supported ways of programming IP sets.
+// The IPSets object calculates what needs to change; the backend determines the commands that are
+// run and the syntax of their input and output.
+type dataplaneBackend interface {
+	// restoreCmd returns the command (and arguments) that reads a batch of updates on stdin.
+	restoreCmd() []string
+	// listCmd returns the command that lists all IP sets, along with their members.
+	listCmd() []string
+	// destroyCmd returns the command that deletes the named IP set.
+	destroyCmd(setName string) []string
+
+	// scanList parses the output of the list command, passing each IP set and its members to
+	// the visitor.  Read errors are left in the scanner for the caller to check.
+	scanList(scanner *bufio.Scanner, v listVisitor)
+
+	// writeFullRewrite writes the input required to do a full, atomic, idempotent rewrite of
+	// the IP set to contain the members of ipSet.pendingReplace.  tempSetName allocates the
+	// name of a temporary IP set, for backends that need one.
+	writeFullRewrite(writeLine lineWriter, ipSet *ipSet, mainSetExists bool, tempSetName func() string)
+	// writeAdd writes the input required to add a member to an existing IP set.
+	writeAdd(writeLine lineWriter, setName string, member IPSetMember)
+	// writeDel writes the input required to remove a member from an existing IP set; it must
+	// not fail if the member is not present.
+	writeDel(writeLine lineWriter, setName string, member IPSetMember)
+	// commitLine returns the line that terminates the input to the restore command, if any.
+	commitLine() string
+	// atomicRestore returns true if the restore command applies its whole input as a single
+	// transaction, so that packets never see a partially-applied update.
+	atomicRestore() bool
+}
+
+// listSetBackend is implemented by backends that support list:set IP sets, which are needed for
+// sharding.
+type listSetBackend interface {
+	// writeCreateListSet writes the input required to create an empty list:set that can hold up
+	// to size IP sets.
+	writeCreateListSet(writeLine lineWriter, setName string, size int)
+	// writeAddToListSet writes the input required to add an IP set to a list:set; it must not
+	// fail if the IP set is already a member.
+	writeAddToListSet(writeLine lineWriter, listSetName, setName string)
+	// writeDelFromListSet writes the input required to remove an IP set from a list:set; it must
+	// not fail if the IP set is not a member.
+	writeDelFromListSet(writeLine lineWriter, listSetName, setName string)
+}
+
+// listVisitor receives the contents of the dataplane's IP sets, as they are parsed.
+type listVisitor interface {
+	// visitSet is called for each IP set that is found; it returns false if the IP set's members
+	// are not of interest.
+	visitSet(setName string) (wantMembers bool)
+	// visitMember is called for each member of the current IP set, in ipset syntax.
+	visitMember(member string)
+	// endSet is called after the last member of an IP set whose members were wanted.
+	endSet()
+}
+
+// lineWriter writes a line of input to a restore command.
+type lineWriter func(format string, a ...interface{})
+
+func newBackend(backend Backend, family IPFamily) dataplaneBackend {
+	switch backend {
+	case BackendIPSet, "":
+		return ipsetBackend{family: family}
+	case BackendNFT:
+		return newNFTBackend(family, defaultNFTTableName)
+	}
+	log.WithField("backend", backend).Panic("Unknown IP sets backend")
+	return nil
+}
+
+// ipsetBackend programs kernel IP sets using the ipset command.
+type ipsetBackend struct {
+	family IPFamily
+}
+
+func (b ipsetBackend) restoreCmd() []string {
+	return []string{"ipset", "restore"}
+}
+
+func (b ipsetBackend) listCmd() []string {
+	return []string{"ipset", "list"}
+}
+
+func (b ipsetBackend) destroyCmd(setName string) []string {
+	return []string{"ipset", "destroy", setName}
+}
+
+// scanList parses the output of 'ipset list', which has the following form:
+//
+//	Name: test-100
+//	Type: hash:ip
+//	Revision: 4
+//	Header: family inet hashsize 1024 maxelem 65536
+//	Size in memory: 224
+//	References: 0
+//	Members:
+//	10.0.0.2
+//	10.0.0.1
+//
+//	Name: test-1
+//	Type: hash:ip
+//	Revision: 4
+//	Header: family inet hashsize 1024 maxelem 65536
+//	Size in memory: 224
+//	References: 0
+//	Members:
+//	10.0.0.1
+//	10.0.0.2
+func (b ipsetBackend) scanList(scanner *bufio.Scanner, v listVisitor) {
+	wantMembers := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Name:") {
+			wantMembers = v.visitSet(strings.Split(line, " ")[1])
+		}
+		if strings.HasPrefix(line, "Members:") {
+			// Start of a Members entry, following this, there'll be one member per
+			// line then EOF or a blank line.
+			if !wantMembers {
+				// Simply scan past the members.
+				for scanner.Scan() {
+					line := scanner.Bytes()
+					if len(line) == 0 {
+						// End of members
+						break
+					}
+				}
+				continue
+			}
+			for scanner.Scan() {
+				line := scanner.Text()
+				if line == "" {
+					// End of members
+					break
+				}
+				v.visitMember(line)
+			}
+			wantMembers = false
+			if scanner.Err() != nil {
+				return
+			}
+			v.endSet()
+		}
+	}
+}
+
+func (b ipsetBackend) writeFullRewrite(writeLine lineWriter, ipSet *ipSet, mainSetExists bool, tempSetName func() string) {
+	// Our general approach is to create a temporary IP set with the right contents, then
+	// atomically swap it into place.
+	mainSetName := ipSet.MainIPSetName
+	if !mainSetExists {
+		// Create empty main IP set so we can share the atomic swap logic below.
+		// Note: we can't use the -exist flag (which should make the create idempotent)
+		// because it still fails if the IP set was previously created with different
+		// parameters.
+		log.WithField("setID", ipSet.SetID).Debug("Pre-creating main IP set")
+		writeLine("create %s %s family %s maxelem %d",
+			mainSetName, ipSet.Type, b.family, ipSet.MaxSize)
+	}
+	tempName := tempSetName()
+	// Create the temporary IP set with the current parameters.
+	writeLine("create %s %s family %s maxelem %d",
+		tempName, ipSet.Type, b.family, ipSet.MaxSize)
+	// Write all the members into the temporary IP set.
+	ipSet.pendingReplace.Iter(func(member IPSetMember) error {
+		writeLine("add %s %s", tempName, member)
+		return nil
+	})
+	// Atomically swap the temporary set into place.
+	writeLine("swap %s %s", mainSetName, tempName)
+	// Then remove the temporary set (which was the old main set).
+	writeLine("destroy %s", tempName)
+}
+
+func (b ipsetBackend) writeAdd(writeLine lineWriter, setName string, member IPSetMember) {
+	writeLine("add %s %s", setName, member)
+}
+
+func (b ipsetBackend) writeDel(writeLine lineWriter, setName string, member IPSetMember) {
+	writeLine("del %s %s --exist", setName, member)
+}
+
+func (b ipsetBackend) commitLine() string {
+	return "COMMIT"
+}
+
+func (b ipsetBackend) atomicRestore() bool {
+	// ipset restore applies each line individually.
+	return false
+}
+
+func (b ipsetBackend) writeCreateListSet(writeLine lineWriter, setName string, size int) {
+	writeLine("create %s list:set size %d", setName, size)
+}
+
+func (b ipsetBackend) writeAddToListSet(writeLine lineWriter, listSetName, setName string) {
+	writeLine("add %s %s --exist", listSetName, setName)
+}
+
+func (b ipsetBackend) writeDelFromListSet(writeLine lineWriter, listSetName, setName string) {
+	writeLine("del %s %s --exist", listSetName, setName)
+}
+
+// describeCmd formats a command for use in log messages.
+func describeCmd(cmd []string) string {
+	return fmt.Sprintf("'%s'", strings.Join(cmd, " "))
+}
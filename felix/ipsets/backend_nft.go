@@ -0,0 +1,223 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipsets
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultNFTTableName = "calico"
+
+// nftBackend programs nftables named sets using the nft command.  Each IP family gets its own
+// table; the sets in that table have the same names that ipset would use.  Updates are written
+// as a single 'nft -f' transaction, which, unlike 'ipset restore', is atomic.
+type nftBackend struct {
+	// family is the nftables address family, "ip" or "ip6".
+	family string
+	// addrType is the nftables type of the family's addresses.
+	addrType string
+	table    string
+}
+
+func newNFTBackend(family IPFamily, table string) *nftBackend {
+	b := &nftBackend{
+		family:   "ip",
+		addrType: "ipv4_addr",
+		table:    table,
+	}
+	if family == IPFamilyV6 {
+		b.family = "ip6"
+		b.addrType = "ipv6_addr"
+	}
+	return b
+}
+
+func (b *nftBackend) restoreCmd() []string {
+	return []string{"nft", "-f", "-"}
+}
+
+func (b *nftBackend) listCmd() []string {
+	// We list the whole ruleset for our family, rather than our table, so that the command
+	// succeeds before we've created the table.
+	return []string{"nft", "list", "ruleset", b.family}
+}
+
+func (b *nftBackend) destroyCmd(setName string) []string {
+	return []string{"nft", "delete", "set", b.family, b.table, nftQuote(setName)}
+}
+
+// scanList parses the output of 'nft list ruleset', which has the following form:
+//
+//	table ip calico {
+//		set cali40s:qMt7iLlGDhvLnCjM0l9nzxb {
+//			type ipv4_addr
+//			size 1048576
+//			flags interval
+//			elements = { 10.0.0.0/24, 10.0.1.1,
+//				     10.0.2.0/24 }
+//		}
+//	}
+//
+// Tables other than ours, and anything in our table other than sets, are skipped.
+func (b *nftBackend) scanList(scanner *bufio.Scanner, v listVisitor) {
+	tableHeader := fmt.Sprintf("table %s %s {", b.family, b.table)
+	depth := 0
+	inTable := false
+	inSet := false
+	wantMembers := false
+	inElements := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		elements := ""
+		switch {
+		case depth == 0:
+			inTable = line == tableHeader
+		case depth == 1 && inTable && strings.HasPrefix(line, "set "):
+			setName := strings.TrimSuffix(strings.TrimPrefix(line, "set "), " {")
+			inSet = true
+			wantMembers = v.visitSet(strings.Trim(setName, `"`))
+		case depth == 2 && inSet && wantMembers && strings.HasPrefix(line, "elements = {"):
+			inElements = true
+			elements = strings.TrimPrefix(line, "elements = {")
+		case inElements:
+			elements = line
+		}
+		if inElements {
+			// Elements are comma-separated and may be wrapped over several lines; the
+			// list ends with a closing brace.
+			if i := strings.IndexByte(elements, '}'); i >= 0 {
+				elements = elements[:i]
+				inElements = false
+			}
+			for _, e := range strings.Split(elements, ",") {
+				if e = strings.TrimSpace(e); e != "" {
+					v.visitMember(ipsetMemberFromNFTElement(e))
+				}
+			}
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if inSet && depth <= 1 {
+			inSet = false
+			if wantMembers {
+				v.endSet()
+			}
+			wantMembers = false
+		}
+	}
+}
+
+func (b *nftBackend) writeFullRewrite(writeLine lineWriter, ipSet *ipSet, _ bool, _ func() string) {
+	// The whole input is applied as one transaction so there's no need for a temporary set;
+	// we (idempotently) create the set, then flush and refill it.  Note: as with ipset's swap,
+	// this fails if the set already exists with a different type.
+	setName := nftQuote(ipSet.MainIPSetName)
+	writeLine("add table %s %s", b.family, b.table)
+	writeLine("add set %s %s %s { %s; size %d; }",
+		b.family, b.table, setName, b.setSpec(ipSet.Type), ipSet.MaxSize)
+	writeLine("flush set %s %s %s", b.family, b.table, setName)
+	ipSet.pendingReplace.Iter(func(member IPSetMember) error {
+		writeLine("add element %s %s %s { %s }", b.family, b.table, setName, nftElement(member))
+		return nil
+	})
+}
+
+func (b *nftBackend) writeAdd(writeLine lineWriter, setName string, member IPSetMember) {
+	writeLine("add element %s %s %s { %s }", b.family, b.table, nftQuote(setName), nftElement(member))
+}
+
+func (b *nftBackend) writeDel(writeLine lineWriter, setName string, member IPSetMember) {
+	// Deleting a non-existent element fails the whole transaction.  Adding the element first
+	// is a no-op if it's already present and makes the delete safe.
+	b.writeAdd(writeLine, setName, member)
+	writeLine("delete element %s %s %s { %s }", b.family, b.table, nftQuote(setName), nftElement(member))
+}
+
+func (b *nftBackend) commitLine() string {
+	return ""
+}
+
+func (b *nftBackend) atomicRestore() bool {
+	return true
+}
+
+// setSpec returns the nftables type (and flags) of a set that is equivalent to the given ipset
+// type.
+func (b *nftBackend) setSpec(t IPSetType) string {
+	switch t {
+	case IPSetTypeHashIP:
+		return "type " + b.addrType
+	case IPSetTypeHashNet:
+		return "type " + b.addrType + "; flags interval"
+	case IPSetTypeHashIPPort:
+		return "type " + b.addrType + " . inet_proto . inet_service"
+	case IPSetTypeHashNetPort:
+		return "type " + b.addrType + " . inet_proto . inet_service; flags interval"
+	case IPSetTypeHashIPPortNet:
+		return "type " + b.addrType + " . inet_proto . inet_service . " + b.addrType + "; flags interval"
+	case IPSetTypeHashNetIface:
+		return "type " + b.addrType + " . ifname; flags interval"
+	}
+	log.WithField("type", string(t)).Panic("Unknown IPSetType")
+	return ""
+}
+
+// nftQuote quotes a set name; our names contain characters, such as ':', that nft doesn't allow
+// in unquoted identifiers.
+func nftQuote(name string) string {
+	return `"` + name + `"`
+}
+
+// nftElement converts an IP set member to the equivalent nftables set element.
+func nftElement(member IPSetMember) string {
+	switch m := member.(type) {
+	case V4IPPort:
+		return fmt.Sprintf("%s . %s . %d", m.IP, m.Protocol, m.Port)
+	case V6IPPort:
+		return fmt.Sprintf("%s . %s . %d", m.IP, m.Protocol, m.Port)
+	case V4NetPort:
+		return fmt.Sprintf("%s . %s . %d", m.Net, m.Protocol, m.Port)
+	case V6NetPort:
+		return fmt.Sprintf("%s . %s . %d", m.Net, m.Protocol, m.Port)
+	case V4IPPortNet:
+		return fmt.Sprintf("%s . %s . %d . %s", m.IP, m.Protocol, m.Port, m.Net)
+	case V6IPPortNet:
+		return fmt.Sprintf("%s . %s . %d . %s", m.IP, m.Protocol, m.Port, m.Net)
+	case V4NetIface:
+		return fmt.Sprintf("%s . %q", m.Net, m.Iface)
+	case V6NetIface:
+		return fmt.Sprintf("%s . %q", m.Net, m.Iface)
+	}
+	return member.String()
+}
+
+// ipsetMemberFromNFTElement converts an element, as printed by nft, to ipset syntax so that it
+// can be canonicalised in the same way as the output of 'ipset list'.
+func ipsetMemberFromNFTElement(element string) string {
+	parts := strings.Split(element, " . ")
+	switch len(parts) {
+	case 2:
+		// net . ifname; nft prints the interface name as a quoted string.
+		return fmt.Sprintf("%s,%s", parts[0], strings.Trim(parts[1], `"`))
+	case 3:
+		return fmt.Sprintf("%s,%s:%s", parts[0], parts[1], parts[2])
+	case 4:
+		return fmt.Sprintf("%s,%s:%s,%s", parts[0], parts[1], parts[2], parts[3])
+	}
+	return element
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipsets_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/projectcalico/calico/felix/ipsets"
+	"github.com/projectcalico/calico/felix/logutils"
+	"github.com/projectcalico/calico/felix/rules"
+)
+
+var _ = Describe("IPSets with the nft backend", func() {
+	var (
+		nft    *fakeNFT
+		ipsets *IPSets
+	)
+
+	v4VersionConf := NewIPVersionConfig(
+		IPFamilyV4,
+		"cali",
+		rules.AllHistoricIPSetNamePrefixes,
+		rules.LegacyV4IPSetNames,
+	)
+	v6VersionConf := NewIPVersionConfig(IPFamilyV6, "cali", nil, nil)
+	v4SetRef := `ip calico "cali40s:qMt7iLlGDhvLnCjM0l9nzxb"`
+
+	apply := func() {
+		nft.Scripts = nil
+		ipsets.ApplyUpdates()
+		ipsets.ApplyDeletions()
+	}
+
+	BeforeEach(func() {
+		nft = &fakeNFT{}
+		ipsets = NewIPSetsWithShims(
+			v4VersionConf,
+			logutils.NewSummarizer("test loop"),
+			nft.newCmd,
+			func(time.Duration) {},
+			WithBackend(BackendNFT),
+		)
+	})
+
+	It("should create and fill an interval set for a hash:net IP set", func() {
+		ipsets.AddOrReplaceIPSet(IPSetMetadata{SetID: ipSetID, Type: IPSetTypeHashNet, MaxSize: 1234},
+			[]string{"10.0.0.0/24"})
+		apply()
+		Expect(nft.Cmds[0]).To(Equal([]string{"list", "ruleset", "ip"}))
+		Expect(nft.Scripts).To(Equal([]string{
+			"add table ip calico\n" +
+				"add set " + v4SetRef + " { type ipv4_addr; flags interval; size 1234; }\n" +
+				"flush set " + v4SetRef + "\n" +
+				"add element " + v4SetRef + " { 10.0.0.0/24 }\n",
+		}))
+	})
+
+	It("should write deltas, making deletions idempotent", func() {
+		ipsets.AddOrReplaceIPSet(IPSetMetadata{SetID: ipSetID, Type: IPSetTypeHashNet, MaxSize: 1234},
+			[]string{"10.0.0.0/24"})
+		apply()
+		ipsets.AddMembers(ipSetID, []string{"10.0.1.0/24"})
+		ipsets.RemoveMembers(ipSetID, []string{"10.0.0.0/24"})
+		apply()
+		Expect(nft.Scripts).To(Equal([]string{
+			"add element " + v4SetRef + " { 10.0.0.0/24 }\n" +
+				"delete element " + v4SetRef + " { 10.0.0.0/24 }\n" +
+				"add element " + v4SetRef + " { 10.0.1.0/24 }\n",
+		}))
+	})
+
+	It("should resync against the sets in its own table", func() {
+		ipsets.AddOrReplaceIPSet(IPSetMetadata{SetID: ipSetID, Type: IPSetTypeHashIP, MaxSize: 1234},
+			v4Members1And2)
+		apply()
+
+		nft.ListOutput = `table ip filter {
+	set cali40s:elsewhere {
+		type ipv4_addr
+	}
+}
+table ip calico {
+	set cali40s:qMt7iLlGDhvLnCjM0l9nzxb {
+		type ipv4_addr
+		size 1234
+		elements = { 10.0.0.1,
+			     10.0.0.3 }
+	}
+
+	set cali40s:old {
+		type ipv4_addr
+		elements = { 10.0.0.9 }
+	}
+
+	chain input {
+		ip saddr { 10.0.0.5, 10.0.0.6 } accept
+	}
+}
+`
+		nft.Cmds = nil
+		ipsets.QueueResync()
+		apply()
+		Expect(nft.Scripts).To(Equal([]string{
+			"add element " + v4SetRef + " { 10.0.0.3 }\n" +
+				"delete element " + v4SetRef + " { 10.0.0.3 }\n" +
+				"add element " + v4SetRef + " { 10.0.0.2 }\n",
+		}))
+		Expect(nft.Cmds).To(ContainElement([]string{"delete", "set", "ip", "calico", `"cali40s:old"`}))
+		Expect(nft.Cmds).NotTo(ContainElement(ContainElement(`"cali40s:elsewhere"`)))
+	})
+
+	It("should render IP,port sets as concatenations", func() {
+		ipsets = NewIPSetsWithShims(
+			v6VersionConf,
+			logutils.NewSummarizer("test loop"),
+			nft.newCmd,
+			func(time.Duration) {},
+			WithBackend(BackendNFT),
+		)
+		ipsets.AddOrReplaceIPSet(IPSetMetadata{SetID: ipSetID, Type: IPSetTypeHashIPPort, MaxSize: 1234},
+			[]string{"fd00::1,tcp:80"})
+		apply()
+		v6SetRef := `ip6 calico "cali60s:qMt7iLlGDhvLnCjM0l9nzxb"`
+		Expect(nft.Scripts).To(Equal([]string{
+			"add table ip6 calico\n" +
+				"add set " + v6SetRef + " { type ipv6_addr . inet_proto . inet_service; size 1234; }\n" +
+				"flush set " + v6SetRef + "\n" +
+				"add element " + v6SetRef + " { fd00::1 . tcp . 80 }\n",
+		}))
+
+		// The element should be recognised on resync.
+		nft.ListOutput = `table ip6 calico {
+	set cali60s:qMt7iLlGDhvLnCjM0l9nzxb {
+		type ipv6_addr . inet_proto . inet_service
+		elements = { fd00::1 . tcp . 80 }
+	}
+}
+`
+		ipsets.QueueResync()
+		apply()
+		Expect(nft.Scripts).To(BeEmpty())
+	})
+
+	It("should render net,port and IP,port,net sets as interval concatenations", func() {
+		ipsets.AddOrReplaceIPSet(IPSetMetadata{SetID: ipSetID, Type: IPSetTypeHashNetPort, MaxSize: 1234},
+			[]string{"10.0.0.0/24,tcp:80"})
+		ipsets.AddOrReplaceIPSet(IPSetMetadata{SetID: ipSetID2, Type: IPSetTypeHashIPPortNet, MaxSize: 1234},
+			[]string{"10.0.0.1,udp:53,10.1.0.0/16"})
+		apply()
+		v4SetRef2 := `ip calico "cali40t:qMt7iLlGDhvLnCjM0l9nzxb"`
+		Expect(nft.Scripts).To(HaveLen(1))
+		Expect(nft.Scripts[0]).To(ContainSubstring(
+			"add set " + v4SetRef + " { type ipv4_addr . inet_proto . inet_service; flags interval; size 1234; }\n"))
+		Expect(nft.Scripts[0]).To(ContainSubstring(
+			"add element " + v4SetRef + " { 10.0.0.0/24 . tcp . 80 }\n"))
+		Expect(nft.Scripts[0]).To(ContainSubstring(
+			"add set " + v4SetRef2 + " { type ipv4_addr . inet_proto . inet_service . ipv4_addr; flags interval; size 1234; }\n"))
+		Expect(nft.Scripts[0]).To(ContainSubstring(
+			"add element " + v4SetRef2 + " { 10.0.0.1 . udp . 53 . 10.1.0.0/16 }\n"))
+
+		nft.ListOutput = `table ip calico {
+	set cali40s:qMt7iLlGDhvLnCjM0l9nzxb {
+		type ipv4_addr . inet_proto . inet_service
+		flags interval
+		elements = { 10.0.0.0/24 . tcp . 80 }
+	}
+	set cali40t:qMt7iLlGDhvLnCjM0l9nzxb {
+		type ipv4_addr . inet_proto . inet_service . ipv4_addr
+		flags interval
+		elements = { 10.0.0.1 . udp . 53 . 10.1.0.0/16 }
+	}
+}
+`
+		ipsets.QueueResync()
+		apply()
+		Expect(nft.Scripts).To(BeEmpty())
+	})
+
+	It("should render net,iface sets as interval concatenations with ifname", func() {
+		ipsets.AddOrReplaceIPSet(IPSetMetadata{SetID: ipSetID, Type: IPSetTypeHashNetIface, MaxSize: 1234},
+			[]string{"10.0.0.0/24,eth0", "10.0.1.1,eth1"})
+		apply()
+		Expect(nft.Scripts).To(HaveLen(1))
+		Expect(nft.Scripts[0]).To(ContainSubstring(
+			"add set " + v4SetRef + " { type ipv4_addr . ifname; flags interval; size 1234; }\n"))
+		Expect(nft.Scripts[0]).To(ContainSubstring(`10.0.0.0/24 . "eth0"`))
+		Expect(nft.Scripts[0]).To(ContainSubstring(`10.0.1.1/32 . "eth1"`))
+
+		nft.ListOutput = `table ip calico {
+	set cali40s:qMt7iLlGDhvLnCjM0l9nzxb {
+		type ipv4_addr . ifname
+		flags interval
+		elements = { 10.0.0.0/24 . "eth0",
+			     10.0.1.1 . "eth1" }
+	}
+}
+`
+		ipsets.QueueResync()
+		apply()
+		Expect(nft.Scripts).To(BeEmpty())
+	})
+})
+
+// fakeNFT is a minimal stand-in for the nft command.  It records the scripts passed to 'nft -f -'
+// and answers list commands with canned output.
+type fakeNFT struct {
+	ListOutput string
+	Scripts    []string
+	Cmds       [][]string
+}
+
+func (f *fakeNFT) newCmd(name string, arg ...string) CmdIface {
+	Expect(name).To(Equal("nft"))
+	f.Cmds = append(f.Cmds, arg)
+	return &fakeNFTCmd{nft: f, args: arg}
+}
+
+type fakeNFTCmd struct {
+	nft   *fakeNFT
+	args  []string
+	stdin nftStdin
+}
+
+type nftStdin struct {
+	bytes.Buffer
+}
+
+func (s *nftStdin) Flush() error {
+	return nil
+}
+
+func (s *nftStdin) Close() error {
+	return nil
+}
+
+func (c *fakeNFTCmd) StdinPipe() (WriteCloserFlusher, error) {
+	return &c.stdin, nil
+}
+
+func (c *fakeNFTCmd) StdoutPipe() (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(c.nft.ListOutput)), nil
+}
+
+func (c *fakeNFTCmd) SetStdin(io.Reader) {}
+
+func (c *fakeNFTCmd) SetStdout(io.Writer) {}
+
+func (c *fakeNFTCmd) SetStderr(io.Writer) {}
+
+func (c *fakeNFTCmd) Start() error {
+	return nil
+}
+
+func (c *fakeNFTCmd) Wait() error {
+	if c.args[0] == "-f" {
+		c.nft.Scripts = append(c.nft.Scripts, c.stdin.String())
+	}
+	return nil
+}
+
+func (c *fakeNFTCmd) Output() ([]byte, error) {
+	return []byte(c.nft.ListOutput), nil
+}
+
+func (c *fakeNFTCmd) CombinedOutput() ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipsets
+
+import (
+	"sort"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+)
+
+// IPSetDebugInfo describes the state of one of our IP sets, for diagnostics.  It compares the
+// membership that we want with the membership that is actually in the dataplane.
+type IPSetDebugInfo struct {
+	SetID         string    `json:"setID"`
+	Family        IPFamily  `json:"family"`
+	Type          IPSetType `json:"type"`
+	MainIPSetName string    `json:"mainIPSetName"`
+
+	// Desired holds the members that the IP set should contain, before any aggregation.
+	Desired []string `json:"desired"`
+	// Dataplane holds the members that were listed from the dataplane, or is nil if the IP set
+	// was not found there.
+	Dataplane []string `json:"dataplane"`
+	// Missing and Unexpected hold the members that we expect to be in the dataplane but are not,
+	// and vice versa.  While Pending is true, they include the pending updates.
+	Missing    []string `json:"missing"`
+	Unexpected []string `json:"unexpected"`
+
+	// Pending is true if there are updates to the IP set that have not been written yet.
+	Pending bool `json:"pending"`
+	// Filtered is true if the IP set is not programmed because no rule needs it.
+	Filtered bool `json:"filtered"`
+}
+
+// DebugInfo returns the state of the given IP sets, or of all our IP sets if no IDs are given.
+// Unknown IDs are skipped.  It lists the IP sets in the dataplane, so it is relatively expensive
+// and intended for diagnostics only.  Like the other methods, it must be called from the
+// goroutine that owns the IPSets object.
+func (s *IPSets) DebugInfo(setIDs []string) ([]*IPSetDebugInfo, error) {
+	if len(setIDs) == 0 {
+		for setID := range s.ipSetIDToIPSet {
+			setIDs = append(setIDs, setID)
+		}
+		sort.Strings(setIDs)
+	}
+	visitor := &debugVisitor{
+		ipSets:           s,
+		wanted:           map[string]*ipSet{},
+		dataplaneMembers: map[*ipSet]set.Set[IPSetMember]{},
+	}
+	var ipSets []*ipSet
+	for _, setID := range setIDs {
+		ipSet := s.ipSetIDToIPSet[setID]
+		if ipSet == nil {
+			continue
+		}
+		ipSets = append(ipSets, ipSet)
+		visitor.wanted[ipSet.MainIPSetName] = ipSet
+	}
+	if len(ipSets) == 0 {
+		return nil, nil
+	}
+	if err := s.listIPSets(visitor); err != nil {
+		return nil, err
+	}
+
+	var infos []*IPSetDebugInfo
+	for _, ipSet := range ipSets {
+		expected := ipSet.pendingMembers()
+		dataplane := visitor.dataplaneMembers[ipSet]
+		info := &IPSetDebugInfo{
+			SetID:         ipSet.SetID,
+			Family:        s.IPVersionConfig.Family,
+			Type:          ipSet.Type,
+			MainIPSetName: ipSet.MainIPSetName,
+			Desired:       sortedMemberStrings(ipSet.desiredMembers()),
+			Missing:       []string{},
+			Unexpected:    []string{},
+			Pending:       s.dirtyIPSetIDs.Contains(ipSet.SetID) || ipSet.aggregationDirty,
+			Filtered:      !s.ipSetNeeded(ipSet.SetID),
+		}
+		if dataplane == nil {
+			dataplane = set.New[IPSetMember]()
+		} else {
+			info.Dataplane = sortedMemberStrings(dataplane)
+		}
+		expected.Iter(func(m IPSetMember) error {
+			if !dataplane.Contains(m) {
+				info.Missing = append(info.Missing, m.String())
+			}
+			return nil
+		})
+		dataplane.Iter(func(m IPSetMember) error {
+			if !expected.Contains(m) {
+				info.Unexpected = append(info.Unexpected, m.String())
+			}
+			return nil
+		})
+		sort.Strings(info.Missing)
+		sort.Strings(info.Unexpected)
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// debugVisitor collects the members of the wanted IP sets, as they are listed by the backend.  The
+// members of a sharded IP set are collected from its shards.
+type debugVisitor struct {
+	ipSets *IPSets
+	// wanted maps from main IP set name to the IP sets whose members we want.
+	wanted           map[string]*ipSet
+	dataplaneMembers map[*ipSet]set.Set[IPSetMember]
+
+	// ipSet is the IP set whose members are currently being listed, if any.
+	ipSet *ipSet
+}
+
+func (v *debugVisitor) visitSet(setName string) bool {
+	if mainSetName, ok := v.ipSets.IPVersionConfig.mainIPSetNameForShard(setName); ok {
+		v.ipSet = v.wanted[mainSetName]
+	} else {
+		v.ipSet = v.wanted[setName]
+		if v.ipSet != nil && v.ipSets.IPVersionConfig.sharded {
+			// The main IP set only lists the shards, which hold the members; just record that the
+			// IP set exists.
+			if v.dataplaneMembers[v.ipSet] == nil {
+				v.dataplaneMembers[v.ipSet] = set.New[IPSetMember]()
+			}
+			v.ipSet = nil
+		}
+	}
+	if v.ipSet == nil {
+		return false
+	}
+	if v.dataplaneMembers[v.ipSet] == nil {
+		v.dataplaneMembers[v.ipSet] = set.New[IPSetMember]()
+	}
+	return true
+}
+
+func (v *debugVisitor) visitMember(member string) {
+	v.dataplaneMembers[v.ipSet].Add(v.ipSet.Type.CanonicaliseMember(member))
+}
+
+func (v *debugVisitor) endSet() {
+	v.ipSet = nil
+}
+
+func sortedMemberStrings(members set.Set[IPSetMember]) []string {
+	strs := make([]string, 0, members.Len())
+	members.Iter(func(m IPSetMember) error {
+		strs = append(strs, m.String())
+		return nil
+	})
+	sort.Strings(strs)
+	return strs
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipsets
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/ip"
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+)
+
+// DualStackIPSets presents an IPv4 and an IPv6 IPSets object as a single manager.  Members are
+// passed as family-agnostic ip.CIDRs and each one is routed to the IPSets object for its IP
+// version, so callers only need to track one set ID and one copy of the update logic for both
+// families.  Each IP set is created in both families, even if it only has members of one, so
+// that rules in either family can refer to it.
+//
+// Only hash:ip and hash:net IP sets are supported.  For a hash:ip IP set, each CIDR must be a
+// full-length (/32 or /128) CIDR; other CIDRs are ignored with a warning.
+type DualStackIPSets struct {
+	v4 *IPSets
+	v6 *IPSets
+}
+
+// NewDualStackIPSets returns a DualStackIPSets that fans out to the given IPSets objects.  v6
+// may be nil if IPv6 is disabled, in which case IPv6 members are dropped.
+func NewDualStackIPSets(v4, v6 *IPSets) *DualStackIPSets {
+	if v4 == nil || v4.IPVersionConfig.Family != IPFamilyV4 {
+		log.Panic("DualStackIPSets requires an IPv4 IPSets object")
+	}
+	if v6 != nil && v6.IPVersionConfig.Family != IPFamilyV6 {
+		log.Panic("DualStackIPSets given non-IPv6 IPSets object for IPv6")
+	}
+	return &DualStackIPSets{
+		v4: v4,
+		v6: v6,
+	}
+}
+
+// ForFamily returns the underlying IPSets object for the given family, or nil if that family
+// is disabled.
+func (d *DualStackIPSets) ForFamily(family IPFamily) *IPSets {
+	if family == IPFamilyV6 {
+		return d.v6
+	}
+	return d.v4
+}
+
+// AddOrReplaceIPSet queues up the creation (or replacement) of an IP set in each family.
+func (d *DualStackIPSets) AddOrReplaceIPSet(setMetadata IPSetMetadata, members []ip.CIDR) {
+	if setMetadata.Type != IPSetTypeHashIP && setMetadata.Type != IPSetTypeHashNet {
+		log.WithFields(log.Fields{
+			"setID":   setMetadata.SetID,
+			"setType": setMetadata.Type,
+		}).Panic("IP set type doesn't support CIDR members")
+	}
+	d.forEachFamily(func(s *IPSets) {
+		s.addOrReplaceIPSet(setMetadata, d.membersFor(s, setMetadata.SetID, setMetadata.Type, members))
+	})
+}
+
+// AddMembers adds the given members to the IP set in the family of each member.
+func (d *DualStackIPSets) AddMembers(setID string, newMembers []ip.CIDR) {
+	d.forEachFamily(func(s *IPSets) {
+		s.addMembers(setID, d.membersFor(s, setID, s.ipSetIDToIPSet[setID].Type, newMembers))
+	})
+}
+
+// RemoveMembers queues up removal of the given members from the IP set in the family of each
+// member.
+func (d *DualStackIPSets) RemoveMembers(setID string, removedMembers []ip.CIDR) {
+	d.forEachFamily(func(s *IPSets) {
+		s.removeMembers(setID, d.membersFor(s, setID, s.ipSetIDToIPSet[setID].Type, removedMembers))
+	})
+}
+
+// RemoveIPSet queues up the removal of the IP set from both families.
+func (d *DualStackIPSets) RemoveIPSet(setID string) {
+	d.forEachFamily(func(s *IPSets) {
+		s.RemoveIPSet(setID)
+	})
+}
+
+// GetMembers returns the desired members of the IP set across both families.
+func (d *DualStackIPSets) GetMembers(setID string) (set.Set[ip.CIDR], error) {
+	if _, ok := d.v4.ipSetIDToIPSet[setID]; !ok {
+		return nil, fmt.Errorf("ipset %s not found", setID)
+	}
+	members := set.New[ip.CIDR]()
+	d.forEachFamily(func(s *IPSets) {
+		s.ipSetIDToIPSet[setID].desiredMembers().Iter(func(m IPSetMember) error {
+			switch m := m.(type) {
+			case ip.Addr:
+				members.Add(m.AsCIDR())
+			case ip.CIDR:
+				members.Add(m)
+			}
+			return nil
+		})
+	})
+	return members, nil
+}
+
+// QueueResync forces a resync of both families on the next ApplyUpdates() call.
+func (d *DualStackIPSets) QueueResync() {
+	d.forEachFamily((*IPSets).QueueResync)
+}
+
+// ApplyUpdates applies pending updates to both families.
+func (d *DualStackIPSets) ApplyUpdates() {
+	d.forEachFamily((*IPSets).ApplyUpdates)
+}
+
+// ApplyDeletions applies pending deletions to both families.
+func (d *DualStackIPSets) ApplyDeletions() {
+	d.forEachFamily((*IPSets).ApplyDeletions)
+}
+
+func (d *DualStackIPSets) forEachFamily(f func(s *IPSets)) {
+	f(d.v4)
+	if d.v6 != nil {
+		f(d.v6)
+	}
+}
+
+// membersFor converts the members of the given family to their canonical form for an IP set of
+// the given type, dropping members of the other family.
+func (d *DualStackIPSets) membersFor(s *IPSets, setID string, setType IPSetType, cidrs []ip.CIDR) set.Set[IPSetMember] {
+	members := set.New[IPSetMember]()
+	for _, cidr := range cidrs {
+		if m := s.cidrToMember(setID, setType, cidr); m != nil {
+			members.Add(m)
+		}
+	}
+	return members
+}
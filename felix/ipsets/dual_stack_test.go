@@ -0,0 +1,154 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipsets_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ip"
+	. "github.com/projectcalico/calico/felix/ipsets"
+	"github.com/projectcalico/calico/felix/logutils"
+	"github.com/projectcalico/calico/felix/rules"
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+)
+
+const v6MainIPSetName = "cali60s:qMt7iLlGDhvLnCjM0l9nzxb"
+
+var _ = Describe("DualStackIPSets", func() {
+	var (
+		v4Dataplane, v6Dataplane *mockDataplane
+		v4IPSets, v6IPSets       *IPSets
+		dualStack                *DualStackIPSets
+	)
+
+	cidrs := func(ss ...string) (out []ip.CIDR) {
+		for _, s := range ss {
+			out = append(out, ip.MustParseCIDROrIP(s))
+		}
+		return
+	}
+	apply := func() {
+		dualStack.ApplyUpdates()
+		dualStack.ApplyDeletions()
+	}
+	newIPSets := func(family IPFamily, dataplane *mockDataplane) *IPSets {
+		var legacyNames []string
+		if family == IPFamilyV4 {
+			legacyNames = rules.LegacyV4IPSetNames
+		}
+		return NewIPSetsWithShims(
+			NewIPVersionConfig(family, "cali", rules.AllHistoricIPSetNamePrefixes, legacyNames),
+			logutils.NewSummarizer("test loop"),
+			dataplane.newCmd,
+			dataplane.sleep,
+		)
+	}
+
+	BeforeEach(func() {
+		v4Dataplane = newMockDataplane()
+		v6Dataplane = newMockDataplane()
+		v4IPSets = newIPSets(IPFamilyV4, v4Dataplane)
+		v6IPSets = newIPSets(IPFamilyV6, v6Dataplane)
+		dualStack = NewDualStackIPSets(v4IPSets, v6IPSets)
+	})
+
+	It("should return the per-family IPSets", func() {
+		Expect(dualStack.ForFamily(IPFamilyV4)).To(BeIdenticalTo(v4IPSets))
+		Expect(dualStack.ForFamily(IPFamilyV6)).To(BeIdenticalTo(v6IPSets))
+	})
+
+	It("should reject IPSets of the wrong family", func() {
+		Expect(func() { NewDualStackIPSets(v6IPSets, nil) }).To(Panic())
+		Expect(func() { NewDualStackIPSets(v4IPSets, v4IPSets) }).To(Panic())
+	})
+
+	It("should split hash:net members by family", func() {
+		dualStack.AddOrReplaceIPSet(metaCIDRsFor(ipSetID), cidrs("10.0.0.0/24", "fd00::/64"))
+		apply()
+		v4Dataplane.ExpectMembers(map[string][]string{v4MainIPSetName: {"10.0.0.0/24"}})
+		v6Dataplane.ExpectMembers(map[string][]string{v6MainIPSetName: {"fd00::/64"}})
+
+		dualStack.AddMembers(ipSetID, cidrs("10.0.1.0/24", "fd00:1::/64"))
+		dualStack.RemoveMembers(ipSetID, cidrs("10.0.0.0/24"))
+		apply()
+		v4Dataplane.ExpectMembers(map[string][]string{v4MainIPSetName: {"10.0.1.0/24"}})
+		v6Dataplane.ExpectMembers(map[string][]string{v6MainIPSetName: {"fd00::/64", "fd00:1::/64"}})
+
+		members, err := dualStack.GetMembers(ipSetID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(members).To(Equal(set.From(cidrs("10.0.1.0/24", "fd00::/64", "fd00:1::/64")...)))
+	})
+
+	It("should create the IP set in both families even if one has no members", func() {
+		dualStack.AddOrReplaceIPSet(metaCIDRsFor(ipSetID), cidrs("10.0.0.0/24"))
+		apply()
+		v4Dataplane.ExpectMembers(map[string][]string{v4MainIPSetName: {"10.0.0.0/24"}})
+		v6Dataplane.ExpectMembers(map[string][]string{v6MainIPSetName: {}})
+	})
+
+	It("should convert host CIDRs for hash:ip IP sets and ignore other CIDRs", func() {
+		meta := metaCIDRsFor(ipSetID)
+		meta.Type = IPSetTypeHashIP
+		dualStack.AddOrReplaceIPSet(meta, cidrs("10.0.0.1", "10.0.1.0/24", "fd00::1/128"))
+		apply()
+		v4Dataplane.ExpectMembers(map[string][]string{v4MainIPSetName: {"10.0.0.1"}})
+		v6Dataplane.ExpectMembers(map[string][]string{v6MainIPSetName: {"fd00::1"}})
+
+		members, err := dualStack.GetMembers(ipSetID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(members).To(Equal(set.From(cidrs("10.0.0.1/32", "fd00::1/128")...)))
+	})
+
+	It("should reject IP set types that can't hold CIDRs", func() {
+		meta := metaCIDRsFor(ipSetID)
+		meta.Type = IPSetTypeHashIPPort
+		Expect(func() { dualStack.AddOrReplaceIPSet(meta, nil) }).To(Panic())
+	})
+
+	It("should remove the IP set from both families", func() {
+		dualStack.AddOrReplaceIPSet(metaCIDRsFor(ipSetID), cidrs("10.0.0.0/24", "fd00::/64"))
+		apply()
+		dualStack.RemoveIPSet(ipSetID)
+		apply()
+		v4Dataplane.ExpectMembers(map[string][]string{})
+		v6Dataplane.ExpectMembers(map[string][]string{})
+		_, err := dualStack.GetMembers(ipSetID)
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("with IPv6 disabled", func() {
+		BeforeEach(func() {
+			dualStack = NewDualStackIPSets(v4IPSets, nil)
+		})
+
+		It("should drop IPv6 members", func() {
+			Expect(dualStack.ForFamily(IPFamilyV6)).To(BeNil())
+			dualStack.AddOrReplaceIPSet(metaCIDRsFor(ipSetID), cidrs("10.0.0.0/24", "fd00::/64"))
+			dualStack.AddMembers(ipSetID, cidrs("fd00:1::/64"))
+			apply()
+			v4Dataplane.ExpectMembers(map[string][]string{v4MainIPSetName: {"10.0.0.0/24"}})
+			v6Dataplane.ExpectMembers(map[string][]string{})
+		})
+	})
+})
+
+func metaCIDRsFor(setID string) IPSetMetadata {
+	return IPSetMetadata{
+		MaxSize: 1234,
+		SetID:   setID,
+		Type:    IPSetTypeHashNet,
+	}
+}
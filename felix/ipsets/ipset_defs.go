@@ -48,10 +48,31 @@ var (
 		Name: "felix_ipset_errors",
 		Help: "Number of ipset command failures.",
 	})
+	countNumIPSetExternalModifications = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "felix_ipset_external_modifications",
+		Help: "Number of IP sets found to have been modified or deleted by another process.",
+	})
 	countNumIPSetLinesExecuted = prometheus.NewCounter(prometheus.CounterOpts{
 		Name: "felix_ipset_lines_executed",
 		Help: "Number of ipset operations executed.",
 	})
+	countVecIPSetFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "felix_ipset_failures",
+		Help: "Number of failed IP set operations, by operation.",
+	}, []string{"ip_version", "operation"})
+	gaugeVecIPSetMembers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "felix_ipset_members",
+		Help: "Number of members of each Calico IP set in the dataplane.",
+	}, []string{"ip_version", "ipset"})
+	histogramVecIPSetRestoreLines = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "felix_ipset_restore_lines",
+		Help:    "Number of lines written in each IP set restore batch.",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 10),
+	}, []string{"ip_version"})
+	histogramVecIPSetApplySeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "felix_ipset_apply_seconds",
+		Help: "Time taken to apply pending IP set updates to the dataplane, including retries.",
+	}, []string{"ip_version"})
 	summaryExecStart = cprometheus.NewSummary(prometheus.SummaryOpts{
 		Name: "felix_exec_time_micros",
 		Help: "Summary of time taken to fork/exec child processes",
@@ -63,7 +84,12 @@ func init() {
 	prometheus.MustRegister(gaugeNumTotalIpsets)
 	prometheus.MustRegister(countNumIPSetCalls)
 	prometheus.MustRegister(countNumIPSetErrors)
+	prometheus.MustRegister(countNumIPSetExternalModifications)
 	prometheus.MustRegister(countNumIPSetLinesExecuted)
+	prometheus.MustRegister(countVecIPSetFailures)
+	prometheus.MustRegister(gaugeVecIPSetMembers)
+	prometheus.MustRegister(histogramVecIPSetRestoreLines)
+	prometheus.MustRegister(histogramVecIPSetApplySeconds)
 	prometheus.MustRegister(summaryExecStart)
 }
 
@@ -73,11 +99,22 @@ const MaxIPSetNameLength = 31
 type IPSetType string
 
 const (
-	IPSetTypeHashIP     IPSetType = "hash:ip"
-	IPSetTypeHashIPPort IPSetType = "hash:ip,port"
-	IPSetTypeHashNet    IPSetType = "hash:net"
+	IPSetTypeHashIP        IPSetType = "hash:ip"
+	IPSetTypeHashIPPort    IPSetType = "hash:ip,port"
+	IPSetTypeHashNet       IPSetType = "hash:net"
+	IPSetTypeHashNetPort   IPSetType = "hash:net,port"
+	IPSetTypeHashIPPortNet IPSetType = "hash:ip,port,net"
+	IPSetTypeHashNetIface  IPSetType = "hash:net,iface"
 )
 
+// ipSetResizeThreshold is the fraction of an IP set's maxelem at which, if automatic resizing is
+// enabled, we grow the IP set.
+const ipSetResizeThreshold = 0.9
+
+// maxIfaceNameLength is the maximum length of a Linux interface name (IFNAMSIZ minus the
+// terminating null).
+const maxIfaceNameLength = 15
+
 func (t IPSetType) SetType() string {
 	return string(t)
 }
@@ -102,11 +139,71 @@ func (p V6IPPort) String() string {
 	return fmt.Sprintf("%s,%s:%d", p.IP.String(), p.Protocol.String(), p.Port)
 }
 
+type V4NetPort struct {
+	Net      ip.V4CIDR
+	Port     uint16
+	Protocol labelindex.IPSetPortProtocol
+}
+
+func (p V4NetPort) String() string {
+	return fmt.Sprintf("%s,%s:%d", p.Net.String(), p.Protocol.String(), p.Port)
+}
+
+type V6NetPort struct {
+	Net      ip.V6CIDR
+	Port     uint16
+	Protocol labelindex.IPSetPortProtocol
+}
+
+func (p V6NetPort) String() string {
+	return fmt.Sprintf("%s,%s:%d", p.Net.String(), p.Protocol.String(), p.Port)
+}
+
+type V4IPPortNet struct {
+	IP       ip.V4Addr
+	Port     uint16
+	Protocol labelindex.IPSetPortProtocol
+	Net      ip.V4CIDR
+}
+
+func (p V4IPPortNet) String() string {
+	return fmt.Sprintf("%s,%s:%d,%s", p.IP.String(), p.Protocol.String(), p.Port, p.Net.String())
+}
+
+type V6IPPortNet struct {
+	IP       ip.V6Addr
+	Port     uint16
+	Protocol labelindex.IPSetPortProtocol
+	Net      ip.V6CIDR
+}
+
+func (p V6IPPortNet) String() string {
+	return fmt.Sprintf("%s,%s:%d,%s", p.IP.String(), p.Protocol.String(), p.Port, p.Net.String())
+}
+
+type V4NetIface struct {
+	Net   ip.V4CIDR
+	Iface string
+}
+
+func (p V4NetIface) String() string {
+	return fmt.Sprintf("%s,%s", p.Net.String(), p.Iface)
+}
+
+type V6NetIface struct {
+	Net   ip.V6CIDR
+	Iface string
+}
+
+func (p V6NetIface) String() string {
+	return fmt.Sprintf("%s,%s", p.Net.String(), p.Iface)
+}
+
 func (t IPSetType) IsMemberIPV6(member string) bool {
 	switch t {
 	case IPSetTypeHashIP, IPSetTypeHashNet:
 		return strings.Contains(member, ":")
-	case IPSetTypeHashIPPort:
+	case IPSetTypeHashIPPort, IPSetTypeHashNetPort, IPSetTypeHashIPPortNet, IPSetTypeHashNetIface:
 		return strings.Contains(strings.Split(member, ",")[0], ":")
 	}
 	log.WithField("type", string(t)).Panic("Unknown IPSetType")
@@ -136,39 +233,20 @@ func (t IPSetType) CanonicaliseMember(member string) IPSetMember {
 			// This should be prevented by validation.
 			log.WithField("member", member).Panic("Failed to parse IP part of IP,port member")
 		}
-		// parts[1] should contain "(tcp|udp|sctp):<port number>"
-		parts = strings.Split(parts[1], ":")
-		var proto labelindex.IPSetPortProtocol
-		switch strings.ToLower(parts[0]) {
-		case "udp":
-			proto = labelindex.ProtocolUDP
-		case "tcp":
-			proto = labelindex.ProtocolTCP
-		case "sctp":
-			proto = labelindex.ProtocolSCTP
-		default:
-			log.WithField("member", member).Panic("Unknown protocol")
-		}
-		port, err := strconv.Atoi(parts[1])
-		if err != nil {
-			log.WithField("member", member).WithError(err).Panic("Bad port")
-		}
-		if port > math.MaxUint16 || port < 0 {
-			log.WithField("member", member).Panic("Bad port range (should be between 0 and 65535)")
-		}
+		proto, port := canonicaliseProtocolAndPort(member, parts[1])
 		// Return a dedicated struct for V4 or V6.  This slightly reduces occupancy over storing
 		// the address as an interface by storing one fewer interface headers.  That is worthwhile
 		// because we store many IP set members.
 		if ipAddr.Version() == 4 {
 			return V4IPPort{
 				IP:       ipAddr.(ip.V4Addr),
-				Port:     uint16(port),
+				Port:     port,
 				Protocol: proto,
 			}
 		} else {
 			return V6IPPort{
 				IP:       ipAddr.(ip.V6Addr),
-				Port:     uint16(port),
+				Port:     port,
 				Protocol: proto,
 			}
 		}
@@ -177,18 +255,122 @@ func (t IPSetType) CanonicaliseMember(member string) IPSetMember {
 		// pretty-printing, the hash:net ipset type prints IPs with no "/32" or "/128"
 		// suffix.
 		return ip.MustParseCIDROrIP(member)
+	case IPSetTypeHashNetPort:
+		// The member should be of the format <CIDR>,(tcp|udp|sctp):<port number>.  As for
+		// hash:net, 'ipset list' prints full-length CIDRs without a suffix.
+		parts := strings.Split(member, ",")
+		if len(parts) != 2 {
+			log.WithField("member", member).Panic("Failed to parse net,port IP set member")
+		}
+		cidr := ip.MustParseCIDROrIP(parts[0])
+		proto, port := canonicaliseProtocolAndPort(member, parts[1])
+		if cidr.Version() == 4 {
+			return V4NetPort{
+				Net:      cidr.(ip.V4CIDR),
+				Port:     port,
+				Protocol: proto,
+			}
+		} else {
+			return V6NetPort{
+				Net:      cidr.(ip.V6CIDR),
+				Port:     port,
+				Protocol: proto,
+			}
+		}
+	case IPSetTypeHashIPPortNet:
+		// The member should be of the format <IP>,(tcp|udp|sctp):<port number>,<CIDR>.
+		parts := strings.Split(member, ",")
+		if len(parts) != 3 {
+			log.WithField("member", member).Panic("Failed to parse IP,port,net IP set member")
+		}
+		ipAddr := ip.FromString(parts[0])
+		if ipAddr == nil {
+			// This should be prevented by validation.
+			log.WithField("member", member).Panic("Failed to parse IP part of IP,port,net member")
+		}
+		proto, port := canonicaliseProtocolAndPort(member, parts[1])
+		cidr := ip.MustParseCIDROrIP(parts[2])
+		if cidr.Version() != ipAddr.Version() {
+			log.WithField("member", member).Panic("Mismatched IP versions in IP,port,net member")
+		}
+		if ipAddr.Version() == 4 {
+			return V4IPPortNet{
+				IP:       ipAddr.(ip.V4Addr),
+				Port:     port,
+				Protocol: proto,
+				Net:      cidr.(ip.V4CIDR),
+			}
+		} else {
+			return V6IPPortNet{
+				IP:       ipAddr.(ip.V6Addr),
+				Port:     port,
+				Protocol: proto,
+				Net:      cidr.(ip.V6CIDR),
+			}
+		}
+	case IPSetTypeHashNetIface:
+		// The member should be of the format <CIDR>,<interface name>.  As for hash:net,
+		// 'ipset list' prints full-length CIDRs without a suffix.
+		parts := strings.SplitN(member, ",", 2)
+		if len(parts) != 2 {
+			log.WithField("member", member).Panic("Failed to parse net,iface IP set member")
+		}
+		cidr := ip.MustParseCIDROrIP(parts[0])
+		iface := parts[1]
+		if iface == "" || len(iface) > maxIfaceNameLength {
+			log.WithField("member", member).Panic("Bad interface name in net,iface IP set member")
+		}
+		if cidr.Version() == 4 {
+			return V4NetIface{
+				Net:   cidr.(ip.V4CIDR),
+				Iface: iface,
+			}
+		} else {
+			return V6NetIface{
+				Net:   cidr.(ip.V6CIDR),
+				Iface: iface,
+			}
+		}
 	}
 	log.WithField("type", string(t)).Panic("Unknown IPSetType")
 	return nil
 }
 
+// canonicaliseProtocolAndPort parses the "(tcp|udp|sctp):<port number>" part of an IP set member.
+func canonicaliseProtocolAndPort(member, protoAndPort string) (labelindex.IPSetPortProtocol, uint16) {
+	parts := strings.Split(protoAndPort, ":")
+	if len(parts) != 2 {
+		log.WithField("member", member).Panic("Failed to parse protocol and port")
+	}
+	var proto labelindex.IPSetPortProtocol
+	switch strings.ToLower(parts[0]) {
+	case "udp":
+		proto = labelindex.ProtocolUDP
+	case "tcp":
+		proto = labelindex.ProtocolTCP
+	case "sctp":
+		proto = labelindex.ProtocolSCTP
+	default:
+		log.WithField("member", member).Panic("Unknown protocol")
+	}
+	port, err := strconv.Atoi(parts[1])
+	if err != nil {
+		log.WithField("member", member).WithError(err).Panic("Bad port")
+	}
+	if port > math.MaxUint16 || port < 0 {
+		log.WithField("member", member).Panic("Bad port range (should be between 0 and 65535)")
+	}
+	return proto, uint16(port)
+}
+
 type IPSetMember interface {
 	String() string
 }
 
 func (t IPSetType) IsValid() bool {
 	switch t {
-	case IPSetTypeHashIP, IPSetTypeHashNet, IPSetTypeHashIPPort:
+	case IPSetTypeHashIP, IPSetTypeHashNet, IPSetTypeHashIPPort, IPSetTypeHashNetPort, IPSetTypeHashIPPortNet,
+		IPSetTypeHashNetIface:
 		return true
 	}
 	return false
@@ -224,6 +406,10 @@ type IPSetMetadata struct {
 	SetID   string
 	Type    IPSetType
 	MaxSize int
+	// AggregateCIDRs, if set on a hash:net IP set, causes its members to be aggregated before
+	// they are written to the dataplane: CIDRs that are contained in other members are dropped
+	// and adjacent CIDRs are merged.  It is ignored for other types of IP set.
+	AggregateCIDRs bool
 }
 
 // ipSet holds the state for a particular IP set.
@@ -247,6 +433,159 @@ type ipSet struct {
 	// is non-nil then pendingDeletions is empty (and we delete members directly from
 	// pendingReplace instead).
 	pendingDeletions set.Set[IPSetMember]
+
+	// lastMembersTrie is the trie snapshot most recently passed to UpdateMembersFromTrie, if the
+	// desired membership still matches it; nil otherwise.
+	lastMembersTrie *ip.Trie[struct{}]
+
+	// unaggregatedMembers is non-nil for an IP set with CIDR aggregation enabled.  It holds the
+	// desired members, before aggregation.  In that case, the pending state (pendingReplace, etc.)
+	// tracks the aggregated membership.
+	unaggregatedMembers set.Set[IPSetMember]
+	// aggregationDirty is set when unaggregatedMembers has changed since it was last aggregated.
+	aggregationDirty bool
+
+	// numShards is the number of shards that the IP set is split into, if sharding is enabled
+	// and the IP set has been written.  shardSizes holds the number of members in each shard; it
+	// is nil if the IP set isn't sharded.
+	numShards  int
+	shardSizes []int
+}
+
+// addMember adds a member to the desired membership of the IP set.
+func (s *ipSet) addMember(m IPSetMember) {
+	if s.unaggregatedMembers != nil {
+		s.unaggregatedMembers.Add(m)
+		s.aggregationDirty = true
+		return
+	}
+	s.queueAdd(m)
+}
+
+// removeMember removes a member from the desired membership of the IP set.
+func (s *ipSet) removeMember(m IPSetMember) {
+	if s.unaggregatedMembers != nil {
+		s.unaggregatedMembers.Discard(m)
+		s.aggregationDirty = true
+		return
+	}
+	s.queueDelete(m)
+}
+
+// desiredMembers returns a copy of the members that the IP set should contain, before any
+// aggregation.
+func (s *ipSet) desiredMembers() set.Set[IPSetMember] {
+	if s.unaggregatedMembers != nil {
+		return s.unaggregatedMembers.Copy()
+	}
+	return s.pendingMembers()
+}
+
+// pendingMembers returns a copy of the members that will be in the dataplane after the pending
+// updates are applied.
+func (s *ipSet) pendingMembers() set.Set[IPSetMember] {
+	if s.pendingReplace != nil {
+		return s.pendingReplace.Copy()
+	}
+	members := s.members.Copy()
+	s.pendingAdds.Iter(func(m IPSetMember) error {
+		members.Add(m)
+		return nil
+	})
+	s.pendingDeletions.Iter(func(m IPSetMember) error {
+		members.Discard(m)
+		return nil
+	})
+	return members
+}
+
+// queueRewrite switches the IP set to full-rewrite mode, if it isn't already, without changing the
+// membership that will be written.
+func (s *ipSet) queueRewrite() {
+	if s.pendingReplace != nil {
+		return
+	}
+	// Reconstruct what the IP set membership should be from what was programmed, plus any
+	// pending additions, minus any pending deletions.
+	s.pendingReplace = s.pendingMembers()
+	s.members = nil
+	s.pendingAdds.Clear()
+	s.pendingDeletions.Clear()
+}
+
+// queueReplace updates the pending state so that the IP set will contain exactly the given members
+// after the next write to the dataplane.  Where possible, it queues up deltas rather than a full
+// rewrite.
+func (s *ipSet) queueReplace(desired set.Set[IPSetMember]) (numAdds, numDeletes int) {
+	if s.pendingReplace != nil {
+		s.pendingReplace = desired
+		return desired.Len(), 0
+	}
+	current := s.pendingMembers()
+	current.Iter(func(m IPSetMember) error {
+		if !desired.Contains(m) {
+			s.queueDelete(m)
+			numDeletes++
+		}
+		return nil
+	})
+	desired.Iter(func(m IPSetMember) error {
+		if !current.Contains(m) {
+			s.queueAdd(m)
+			numAdds++
+		}
+		return nil
+	})
+	return
+}
+
+// aggregateCIDRs returns the minimal set of CIDRs that covers the same addresses as the given
+// hash:net members.
+func aggregateCIDRs(members set.Set[IPSetMember]) set.Set[IPSetMember] {
+	cidrs := make([]ip.CIDR, 0, members.Len())
+	members.Iter(func(m IPSetMember) error {
+		cidrs = append(cidrs, m.(ip.CIDR))
+		return nil
+	})
+	aggregated := set.New[IPSetMember]()
+	for _, c := range ip.Aggregate(cidrs) {
+		aggregated.Add(c)
+	}
+	return aggregated
+}
+
+// queueAdd updates the pending state so that the given member will be in the IP set after the next
+// write to the dataplane.
+func (s *ipSet) queueAdd(m IPSetMember) {
+	if s.pendingReplace != nil {
+		s.pendingReplace.Add(m)
+		return
+	}
+	// Do a delta update.
+	s.pendingDeletions.Discard(m)
+	if s.members.Contains(m) {
+		// IP already in the set, this happens if the IP is removed and then
+		// re-added in between updates to the dataplane.
+		return
+	}
+	s.pendingAdds.Add(m)
+}
+
+// queueDelete updates the pending state so that the given member will not be in the IP set after the
+// next write to the dataplane.
+func (s *ipSet) queueDelete(m IPSetMember) {
+	if s.pendingReplace != nil {
+		s.pendingReplace.Discard(m)
+		return
+	}
+	// Do a delta update.
+	s.pendingAdds.Discard(m)
+	if !s.members.Contains(m) {
+		// IP not in the dataplane, this occurs if the IP was added and
+		// then removed without any calls to ApplyUpdates().
+		return
+	}
+	s.pendingDeletions.Add(m)
 }
 
 // IPVersionConfig wraps up the metadata for a particular IP version.  It can be used by
@@ -256,7 +595,27 @@ type IPVersionConfig struct {
 	setNamePrefix         string
 	tempSetNamePrefix     string
 	mainSetNamePrefix     string
+	shardSetNamePrefix    string
 	ourNamePrefixesRegexp *regexp.Regexp
+
+	// sharded is set if each main IP set is a list:set, whose members are the shards that hold
+	// the IP set's members.
+	sharded bool
+}
+
+// IPVersionConfigOpt is an optional parameter to NewIPVersionConfig.
+type IPVersionConfigOpt func(c *IPVersionConfig)
+
+// WithShardedIPSets enables sharding of IP sets, which allows an IP set to hold more than its
+// maximum size; it is split across as many kernel IP sets (each limited to the maximum size) as
+// needed.  To make that transparent to the iptables rules, each main IP set becomes a list:set,
+// which matches if any of its shards match.  Since the type of the main IP sets changes, they are
+// given different names.  Only supported by the ipset backend.
+func WithShardedIPSets() IPVersionConfigOpt {
+	return func(c *IPVersionConfig) {
+		c.sharded = true
+		c.mainSetNamePrefix = c.setNamePrefix + listIpsetToken
+	}
 }
 
 const (
@@ -272,6 +631,15 @@ const (
 	// tempIpsetToken similarly, for the temporary copy of each IP set.  Typically, this doesn't
 	// need to be changed because we delete and recreate the temporary IP set before using it.
 	tempIpsetToken = "t"
+	// listIpsetToken replaces mainIpsetToken when sharding is enabled and the main IP sets are
+	// list:sets.
+	listIpsetToken = "l"
+	// shardIpsetToken is followed by the (fixed-width) shard number in the names of shards.
+	shardIpsetToken = "p"
+	// shardNumberWidth is the number of digits used for the shard number; the main IP set names
+	// are shortened by the same amount so that the name of each shard can be derived from the
+	// name of its main IP set, and vice versa.
+	shardNumberWidth = 2
 )
 
 func NewIPVersionConfig(
@@ -279,6 +647,7 @@ func NewIPVersionConfig(
 	namePrefix string,
 	allHistoricPrefixes []string,
 	extraUnversionedIPSets []string,
+	opts ...IPVersionConfigOpt,
 ) *IPVersionConfig {
 	var version string
 	switch family {
@@ -301,13 +670,18 @@ func NewIPVersionConfig(
 	log.WithField("regexp", ourNamesPattern).Debug("Calculated IP set name regexp.")
 	ourNamesRegexp := regexp.MustCompile(ourNamesPattern)
 
-	return &IPVersionConfig{
+	c := &IPVersionConfig{
 		Family:                family,
 		setNamePrefix:         versionedPrefix,
 		tempSetNamePrefix:     versionedPrefix + tempIpsetToken,
 		mainSetNamePrefix:     versionedPrefix + mainIpsetToken,
+		shardSetNamePrefix:    versionedPrefix + shardIpsetToken,
 		ourNamePrefixesRegexp: ourNamesRegexp,
 	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
 }
 
 func (c IPVersionConfig) NameForTempIPSet(n uint) string {
@@ -321,7 +695,28 @@ func (c IPVersionConfig) NameForTempIPSet(n uint) string {
 func (c IPVersionConfig) NameForMainIPSet(setID string) string {
 	// Since IP set IDs are chosen with a secure hash already, we can simply truncate them
 	// to length to get maximum entropy.
-	return combineAndTrunc(c.mainSetNamePrefix, setID, MaxIPSetNameLength)
+	maxLength := MaxIPSetNameLength
+	if c.sharded {
+		// Leave room for the shard number in the names of the shards.
+		maxLength -= shardNumberWidth
+	}
+	return combineAndTrunc(c.mainSetNamePrefix, setID, maxLength)
+}
+
+// nameForShardIPSet returns the name of the given shard of a (sharded) main IP set.
+func (c IPVersionConfig) nameForShardIPSet(mainSetName string, shard int) string {
+	return fmt.Sprintf("%s%0*d%s", c.shardSetNamePrefix, shardNumberWidth, shard,
+		strings.TrimPrefix(mainSetName, c.mainSetNamePrefix))
+}
+
+// mainIPSetNameForShard returns the name of the main IP set that owns the given shard, if the
+// name is that of a shard.
+func (c IPVersionConfig) mainIPSetNameForShard(setName string) (string, bool) {
+	if !c.sharded || !strings.HasPrefix(setName, c.shardSetNamePrefix) ||
+		len(setName) < len(c.shardSetNamePrefix)+shardNumberWidth {
+		return "", false
+	}
+	return c.mainSetNamePrefix + setName[len(c.shardSetNamePrefix)+shardNumberWidth:], true
 }
 
 // OwnsIPSet returns true if the given IP set name appears to belong to Felix.  i.e. whether it
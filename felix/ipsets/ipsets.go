@@ -18,6 +18,7 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"strings"
 	"time"
@@ -27,6 +28,7 @@ import (
 
 	"github.com/projectcalico/calico/libcalico-go/lib/set"
 
+	"github.com/projectcalico/calico/felix/ip"
 	"github.com/projectcalico/calico/felix/logutils"
 )
 
@@ -43,6 +45,9 @@ type IPSets struct {
 	// dirtyIPSetIDs contains IDs of IP sets that need updating.
 	dirtyIPSetIDs  set.Set[string]
 	resyncRequired bool
+	// membershipCheckRequired is set by QueueMembershipCheck(); it is cleared by a check or by a
+	// full resync, which supersedes it.
+	membershipCheckRequired bool
 
 	// pendingTempIPSetDeletions contains names of temporary IP sets that need to be deleted.  We use it to
 	// attempt an early deletion of temporary IP sets, if possible.
@@ -50,13 +55,57 @@ type IPSets struct {
 	// pendingIPSetDeletions contains names of IP sets that need to be deleted (including temporary ones).
 	pendingIPSetDeletions set.Set[string]
 
+	// backend determines the commands that we run to program the IP sets.
+	backend dataplaneBackend
 	// Factory for command objects; shimmed for UT mocking.
 	newCmd cmdFactory
 
+	// dryRun is set if we should log (and export to dryRunOut, if non-nil) the commands that we
+	// would run to update the dataplane, rather than running them.
+	dryRun    bool
+	dryRunOut io.Writer
+
 	// Shim for time.Sleep()
 	sleep func(time.Duration)
-
-	gaugeNumIpsets prometheus.Gauge
+	// Shim for time.Now()
+	now func() time.Time
+
+	// isReferenced, if non-nil, reports whether an IP set is still referenced by the dataplane's
+	// rules.  Deletion of a referenced IP set is deferred until a later ApplyDeletions().
+	isReferenced func(setName string) bool
+	// staleQuarantine is the time that a left-over Calico IP set (for example, one left behind by
+	// an instance that crashed) must have been seen for before we delete it.  staleIPSetFirstSeen
+	// records when each left-over IP set was first seen by a resync.
+	staleQuarantine     time.Duration
+	staleIPSetFirstSeen map[string]time.Time
+
+	gaugeNumIpsets    prometheus.Gauge
+	gaugeVecMembers   *prometheus.GaugeVec
+	histRestoreLines  prometheus.Observer
+	histApplySeconds  prometheus.Observer
+	countVecFailures  *prometheus.CounterVec
+	numLinesInRestore int
+
+	// alwaysSwap is set if every IP set should be treated as swap-only; otherwise,
+	// swapOnlyIPSetIDs holds the IDs of the swap-only IP sets.  An update to a swap-only IP set
+	// that both adds and removes members is applied by filling a replacement IP set and swapping
+	// it into place, rather than as deltas, so that the IP set never passes through a state
+	// that is neither the old nor the new membership.
+	alwaysSwap       bool
+	swapOnlyIPSetIDs set.Set[string]
+
+	// maxSizeLimit, if non-zero, enables automatic resizing of IP sets: when an IP set's
+	// membership approaches its maxelem, we grow the IP set up to this limit.
+	maxSizeLimit int
+
+	// maxRestoreLinesPerCommit is the number of lines that we write to the restore command
+	// before starting a new COMMIT, for backends that use COMMITs.  Splitting a large update
+	// up lets the command start applying it before we've rendered the whole thing.
+	maxRestoreLinesPerCommit int
+
+	// onPersistentFailure, if non-nil, is called instead of panicking if we fail to update the
+	// IP sets after retries.
+	onPersistentFailure func(err error)
 
 	logCxt *log.Entry
 
@@ -77,12 +126,106 @@ type IPSets struct {
 	neededIPSetNames set.Set[string]
 }
 
-func NewIPSets(ipVersionConfig *IPVersionConfig, recorder logutils.OpRecorder) *IPSets {
+// IPSetsOpt is an optional parameter to NewIPSets.
+type IPSetsOpt func(s *IPSets)
+
+// WithBackend selects the tooling used to program the IP sets.  The default is BackendIPSet.
+func WithBackend(backend Backend) IPSetsOpt {
+	return func(s *IPSets) {
+		s.backend = newBackend(backend, s.IPVersionConfig.Family)
+	}
+}
+
+// WithDryRun prevents the IP sets in the dataplane from being modified.  Instead, the commands that
+// would be run are logged and, if out is non-nil, written to out as a shell script.  Our state is
+// updated as if the commands had succeeded, so that each batch shows only the incremental changes;
+// resyncs still read the real dataplane.  Note: iptables rules that refer to IP sets that haven't
+// been created will fail to program.
+func WithDryRun(out io.Writer) IPSetsOpt {
+	return func(s *IPSets) {
+		s.dryRun = true
+		s.dryRunOut = out
+	}
+}
+
+// SwapMode selects which IP sets are swap-only; see SetSwapOnlyIPSets.
+type SwapMode string
+
+const (
+	// SwapModeAlways makes every IP set swap-only.
+	SwapModeAlways SwapMode = "always"
+	// SwapModeDenyRules makes the IP sets that are referenced by deny rules swap-only.
+	SwapModeDenyRules SwapMode = "deny-rules"
+	// SwapModeNever applies all updates that aren't full rewrites as deltas.
+	SwapModeNever SwapMode = "never"
+)
+
+// WithAlwaysSwap makes every IP set swap-only; see SetSwapOnlyIPSets.
+func WithAlwaysSwap() IPSetsOpt {
+	return func(s *IPSets) {
+		s.alwaysSwap = true
+	}
+}
+
+// WithAutoResize enables automatic resizing of IP sets.  When the desired membership of an IP set
+// approaches its maxelem, we double the maxelem (up to limit) and rewrite the IP set.  The rewrite
+// fills a replacement IP set and swaps it into place, so rules that refer to the IP set pick up the
+// larger set atomically.  Sharded IP sets aren't resized; they grow by adding shards instead.
+func WithAutoResize(limit int) IPSetsOpt {
+	return func(s *IPSets) {
+		s.maxSizeLimit = limit
+	}
+}
+
+// WithMaxRestoreLinesPerCommit overrides the number of lines that are written to ipset restore
+// between COMMITs.  Zero disables intermediate COMMITs.
+func WithMaxRestoreLinesPerCommit(n int) IPSetsOpt {
+	return func(s *IPSets) {
+		s.maxRestoreLinesPerCommit = n
+	}
+}
+
+// WithPersistentFailureCallback makes ApplyUpdates() call the given function, rather than
+// panicking, if it fails to update the IP sets even after retries.  The IP sets are left as they
+// are and a resync is queued for the next ApplyUpdates().
+func WithPersistentFailureCallback(onPersistentFailure func(err error)) IPSetsOpt {
+	return func(s *IPSets) {
+		s.onPersistentFailure = onPersistentFailure
+	}
+}
+
+// WithReferenceChecker supplies a function that reports whether an IP set is still referenced
+// by the dataplane's rules.  Deletion of an IP set that is still referenced is deferred until the
+// last reference has gone, rather than failing with "set is in use".
+func WithReferenceChecker(isReferenced func(setName string) bool) IPSetsOpt {
+	return func(s *IPSets) {
+		s.isReferenced = isReferenced
+	}
+}
+
+// WithStaleIPSetQuarantine delays the deletion of left-over Calico IP sets that a resync finds in
+// the dataplane until they have been seen for at least d.  Left-over temporary IP sets are still
+// deleted right away.
+func WithStaleIPSetQuarantine(d time.Duration) IPSetsOpt {
+	return func(s *IPSets) {
+		s.staleQuarantine = d
+	}
+}
+
+// WithNowOverride overrides the function used to get the current time; intended for tests.
+func WithNowOverride(now func() time.Time) IPSetsOpt {
+	return func(s *IPSets) {
+		s.now = now
+	}
+}
+
+func NewIPSets(ipVersionConfig *IPVersionConfig, recorder logutils.OpRecorder, opts ...IPSetsOpt) *IPSets {
 	return NewIPSetsWithShims(
 		ipVersionConfig,
 		recorder,
 		newRealCmd,
 		time.Sleep,
+		opts...,
 	)
 }
 
@@ -92,9 +235,10 @@ func NewIPSetsWithShims(
 	recorder logutils.OpRecorder,
 	cmdFactory cmdFactory,
 	sleep func(time.Duration),
+	opts ...IPSetsOpt,
 ) *IPSets {
 	familyStr := string(ipVersionConfig.Family)
-	return &IPSets{
+	s := &IPSets{
 		IPVersionConfig: ipVersionConfig,
 
 		ipSetIDToIPSet:       map[string]*ipSet{},
@@ -103,18 +247,33 @@ func NewIPSetsWithShims(
 		dirtyIPSetIDs:             set.New[string](),
 		pendingTempIPSetDeletions: set.New[string](),
 		pendingIPSetDeletions:     set.New[string](),
+		backend:                   newBackend(BackendIPSet, ipVersionConfig.Family),
 		newCmd:                    cmdFactory,
 		sleep:                     sleep,
+		now:                       time.Now,
+		staleIPSetFirstSeen:       map[string]time.Time{},
 		existingIPSetNames:        set.New[string](),
 		resyncRequired:            true,
+		maxRestoreLinesPerCommit:  defaultMaxRestoreLinesPerCommit,
 
-		gaugeNumIpsets: gaugeVecNumCalicoIpsets.WithLabelValues(familyStr),
+		gaugeNumIpsets:   gaugeVecNumCalicoIpsets.WithLabelValues(familyStr),
+		gaugeVecMembers:  gaugeVecIPSetMembers.MustCurryWith(prometheus.Labels{"ip_version": familyStr}),
+		histRestoreLines: histogramVecIPSetRestoreLines.WithLabelValues(familyStr),
+		histApplySeconds: histogramVecIPSetApplySeconds.WithLabelValues(familyStr),
+		countVecFailures: countVecIPSetFailures.MustCurryWith(prometheus.Labels{"ip_version": familyStr}),
 
 		logCxt: log.WithFields(log.Fields{
 			"family": ipVersionConfig.Family,
 		}),
 		opReporter: recorder,
 	}
+	for _, o := range opts {
+		o(s)
+	}
+	if _, ok := s.backend.(listSetBackend); ipVersionConfig.sharded && !ok {
+		s.logCxt.Panic("IP set sharding is not supported by the selected IP sets backend")
+	}
+	return s
 }
 
 // AddOrReplaceIPSet queues up the creation (or replacement) of an IP set.  After the next call
@@ -124,11 +283,17 @@ func (s *IPSets) AddOrReplaceIPSet(setMetadata IPSetMetadata, members []string)
 	// We need to convert members to a canonical representation (which may be, for example,
 	// an ip.Addr instead of a string) so that we can compare them with members that we read
 	// back from the dataplane.  This also filters out IPs of the incorrect IP version.
+	canonMembers := s.filterAndCanonicaliseMembers(setMetadata.Type, members)
+	s.addOrReplaceIPSet(setMetadata, canonMembers)
+}
+
+// addOrReplaceIPSet is the core of AddOrReplaceIPSet; it takes members that have already been
+// filtered and canonicalised.
+func (s *IPSets) addOrReplaceIPSet(setMetadata IPSetMetadata, canonMembers set.Set[IPSetMember]) {
 	s.logCxt.WithFields(log.Fields{
 		"setID":   setMetadata.SetID,
 		"setType": setMetadata.Type,
 	}).Info("Queueing IP set for creation")
-	canonMembers := s.filterAndCanonicaliseMembers(setMetadata.Type, members)
 
 	// Create the IP set struct and store it off.
 	setID := setMetadata.SetID
@@ -139,6 +304,17 @@ func (s *IPSets) AddOrReplaceIPSet(setMetadata IPSetMetadata, members []string)
 		pendingAdds:      set.New[IPSetMember](),
 		pendingDeletions: set.New[IPSetMember](),
 	}
+	if setMetadata.AggregateCIDRs {
+		if setMetadata.Type == IPSetTypeHashNet {
+			ipSet.unaggregatedMembers = canonMembers
+			ipSet.pendingReplace = aggregateCIDRs(canonMembers)
+		} else {
+			s.logCxt.WithFields(log.Fields{
+				"setID":   setID,
+				"setType": setMetadata.Type,
+			}).Warn("Ignoring CIDR aggregation option for IP set that isn't hash:net")
+		}
+	}
 	s.ipSetIDToIPSet[setID] = ipSet
 	s.mainIPSetNameToIPSet[ipSet.MainIPSetName] = ipSet
 
@@ -147,6 +323,7 @@ func (s *IPSets) AddOrReplaceIPSet(setMetadata IPSetMetadata, members []string)
 
 	// The IP set may have been previously queued for deletion, undo that.
 	s.pendingIPSetDeletions.Discard(ipSet.MainIPSetName)
+	delete(s.staleIPSetFirstSeen, ipSet.MainIPSetName)
 }
 
 // RemoveIPSet queues up the removal of an IP set, it need not be empty.  The IP sets will be
@@ -166,6 +343,11 @@ func (s *IPSets) AddMembers(setID string, newMembers []string) {
 	ipSet := s.ipSetIDToIPSet[setID]
 	setType := ipSet.Type
 	canonMembers := s.filterAndCanonicaliseMembers(setType, newMembers)
+	s.addMembers(setID, canonMembers)
+}
+
+func (s *IPSets) addMembers(setID string, canonMembers set.Set[IPSetMember]) {
+	ipSet := s.ipSetIDToIPSet[setID]
 	if canonMembers.Len() == 0 {
 		return
 	}
@@ -173,24 +355,12 @@ func (s *IPSets) AddMembers(setID string, newMembers []string) {
 		"setID":           setID,
 		"filteredMembers": canonMembers,
 	}).Debug("Adding new members to IP set")
-	if ipSet.pendingReplace != nil {
-		canonMembers.Iter(func(m IPSetMember) error {
-			ipSet.pendingReplace.Add(m)
-			return nil
-		})
-	} else {
-		// Do a delta update.
-		canonMembers.Iter(func(m IPSetMember) error {
-			ipSet.pendingDeletions.Discard(m)
-			if ipSet.members.Contains(m) {
-				// IP already in the set, this happens if the IP is removed and then
-				// re-added in between updates to the dataplane.
-				return nil
-			}
-			ipSet.pendingAdds.Add(m)
-			return nil
-		})
-	}
+	canonMembers.Iter(func(m IPSetMember) error {
+		ipSet.addMember(m)
+		return nil
+	})
+	// The desired membership no longer matches any trie that we were given.
+	ipSet.lastMembersTrie = nil
 	s.dirtyIPSetIDs.Add(setID)
 }
 
@@ -200,6 +370,11 @@ func (s *IPSets) RemoveMembers(setID string, removedMembers []string) {
 	ipSet := s.ipSetIDToIPSet[setID]
 	setType := ipSet.Type
 	canonMembers := s.filterAndCanonicaliseMembers(setType, removedMembers)
+	s.removeMembers(setID, canonMembers)
+}
+
+func (s *IPSets) removeMembers(setID string, canonMembers set.Set[IPSetMember]) {
+	ipSet := s.ipSetIDToIPSet[setID]
 	if canonMembers.Len() == 0 {
 		s.logCxt.Debug("After filtering, found no members to remove")
 		return
@@ -208,33 +383,141 @@ func (s *IPSets) RemoveMembers(setID string, removedMembers []string) {
 		"setID":           setID,
 		"filteredMembers": canonMembers,
 	}).Debug("Removing members from IP set")
-	if ipSet.pendingReplace != nil {
-		canonMembers.Iter(func(m IPSetMember) error {
-			ipSet.pendingReplace.Discard(m)
-			return nil
-		})
-	} else {
-		// Do a delta update.
-		canonMembers.Iter(func(m IPSetMember) error {
-			ipSet.pendingAdds.Discard(m)
-			if !ipSet.members.Contains(m) {
-				// IP not in the dataplane, this occurs if the IP was added and
-				// then removed without any calls to ApplyUpdates().
-				return nil
+	canonMembers.Iter(func(m IPSetMember) error {
+		ipSet.removeMember(m)
+		return nil
+	})
+	ipSet.lastMembersTrie = nil
+	s.dirtyIPSetIDs.Add(setID)
+}
+
+// UpdateMembersFromTrie sets the desired members of a hash:net or hash:ip IP set to the CIDRs in the
+// given trie; for a hash:ip set, the trie must only contain full-length CIDRs.  The trie should be a
+// snapshot (see ip.Trie.Snapshot); if it isn't, a snapshot is taken.  The values stored in the trie are
+// ignored.
+//
+// Successive calls are diffed against each other using ip.DiffTries, which skips any subtrees that the
+// snapshots share, so the cost of each call is proportional to the size of the change rather than the
+// size of the IP set.  The first call after the IP set is created, or after its members are modified
+// with AddMembers or RemoveMembers, has to compare the whole trie against the current membership.
+func (s *IPSets) UpdateMembersFromTrie(setID string, members *ip.Trie[struct{}]) {
+	ipSet := s.ipSetIDToIPSet[setID]
+	if ipSet.Type != IPSetTypeHashNet && ipSet.Type != IPSetTypeHashIP {
+		s.logCxt.WithFields(log.Fields{
+			"setID":   setID,
+			"setType": ipSet.Type,
+		}).Panic("IP set type doesn't support updates from a trie")
+	}
+	if !members.ReadOnly() {
+		members = members.Snapshot()
+	}
+	toMember := func(cidr ip.CIDR) IPSetMember {
+		return s.cidrToMember(setID, ipSet.Type, cidr)
+	}
+
+	numAdds, numDeletes := 0, 0
+	if ipSet.lastMembersTrie == nil {
+		// Nothing to diff against, compare the trie with the desired membership so far.
+		desired := set.New[IPSetMember]()
+		members.Visit(func(cidr ip.CIDR, _ struct{}) bool {
+			if m := toMember(cidr); m != nil {
+				desired.Add(m)
 			}
-			ipSet.pendingDeletions.Add(m)
-			return nil
+			return true
 		})
+		if ipSet.unaggregatedMembers != nil {
+			ipSet.unaggregatedMembers = desired
+			ipSet.aggregationDirty = true
+		} else {
+			numAdds, numDeletes = ipSet.queueReplace(desired)
+		}
+	} else {
+		alwaysEqual := func(a, b struct{}) bool { return true }
+		ip.DiffTries(ipSet.lastMembersTrie, members, alwaysEqual,
+			func(delta ip.DeltaType, cidr ip.CIDR, _, _ struct{}) {
+				m := toMember(cidr)
+				if m == nil {
+					return
+				}
+				switch delta {
+				case ip.DeltaTypeAdded:
+					ipSet.addMember(m)
+					numAdds++
+				case ip.DeltaTypeDeleted:
+					ipSet.removeMember(m)
+					numDeletes++
+				}
+			})
 	}
+	ipSet.lastMembersTrie = members
+	s.logCxt.WithFields(log.Fields{
+		"setID":      setID,
+		"numAdds":    numAdds,
+		"numDeletes": numDeletes,
+	}).Debug("Updated IP set members from trie")
 	s.dirtyIPSetIDs.Add(setID)
 }
 
+// cidrToMember converts a CIDR to a member of a hash:net or hash:ip IP set.  It returns nil if the
+// CIDR is of the wrong IP version or, for a hash:ip IP set, if it isn't a full-length CIDR.
+func (s *IPSets) cidrToMember(setID string, setType IPSetType, cidr ip.CIDR) IPSetMember {
+	wantVersion := uint8(s.IPVersionConfig.Family.Version())
+	if cidr.Version() != wantVersion {
+		return nil
+	}
+	if setType == IPSetTypeHashIP {
+		fullPrefixLen := uint8(32)
+		if wantVersion == 6 {
+			fullPrefixLen = 128
+		}
+		if cidr.Prefix() != fullPrefixLen {
+			s.logCxt.WithFields(log.Fields{
+				"setID": setID,
+				"cidr":  cidr,
+			}).Warn("Ignoring non-host CIDR for hash:ip IP set")
+			return nil
+		}
+		return cidr.Addr()
+	}
+	return cidr
+}
+
+// SetSwapOnlyIPSets sets the IDs of the IP sets that must only be updated atomically.  A full
+// rewrite of an IP set always fills a temporary IP set and swaps it into place.  For a swap-only IP
+// set, an update that would otherwise be applied as a mix of additions and deletions is done that
+// way too.  Typically, these are the IP sets that are referenced by deny rules, where the
+// intermediate membership could let through traffic that both the old and new policy would drop.
+// Pure additions and pure deletions only pass through states between the old and new membership
+// so they are still applied as deltas.
+//
+// This has no effect for backends that apply each update as a single transaction.
+func (s *IPSets) SetSwapOnlyIPSets(setIDs set.Set[string]) {
+	s.swapOnlyIPSetIDs = setIDs
+}
+
+func (s *IPSets) ipSetIsSwapOnly(setID string) bool {
+	if s.alwaysSwap {
+		return true
+	}
+	return s.swapOnlyIPSetIDs != nil && s.swapOnlyIPSetIDs.Contains(setID)
+}
+
 // QueueResync forces a resync with the dataplane on the next ApplyUpdates() call.
 func (s *IPSets) QueueResync() {
 	s.logCxt.Debug("Asked to resync with the dataplane on next update.")
 	s.resyncRequired = true
 }
 
+// QueueMembershipCheck asks for a lightweight check for IP sets that have been modified by another
+// process on the next ApplyUpdates() call.  Rather than comparing members individually, as a full
+// resync does, the check compares a hash of each IP set's members in the dataplane with a hash of
+// the members that we expect.  Any IP set that doesn't match, or that has gone missing, is queued
+// for a full rewrite; other IP sets are left alone.
+func (s *IPSets) QueueMembershipCheck() {
+	s.logCxt.Debug("Asked to check IP set membership on next update.")
+	s.membershipCheckRequired = true
+}
+
 func (s *IPSets) GetIPFamily() IPFamily {
 	return s.IPVersionConfig.Family
 }
@@ -278,27 +561,30 @@ func (s *IPSets) GetMembers(setID string) (set.Set[string], error) {
 		return nil, fmt.Errorf("ipset %s not found", setID)
 	}
 
-	if ipSet.pendingReplace != nil {
-		return ipSetMemberSetToStringSet(ipSet.pendingReplace), nil
-	}
-
-	realMembers := ipSet.members.Copy()
-
-	ipSet.pendingAdds.Iter(func(m IPSetMember) error {
-		realMembers.Add(m)
-		return nil
-	})
-
-	ipSet.pendingDeletions.Iter(func(m IPSetMember) error {
-		realMembers.Discard(m)
-		return nil
-	})
+	// For an aggregated IP set, this returns the members as they were given to us.
+	return ipSetMemberSetToStringSet(ipSet.desiredMembers()), nil
+}
 
-	return ipSetMemberSetToStringSet(realMembers), nil
+// GetMembersByName is like GetMembers() but it looks up the IP set by the name of its main IP set,
+// as used in rules.  It returns false if there is no such IP set.
+func (s *IPSets) GetMembersByName(setName string) ([]string, bool) {
+	ipSet, ok := s.mainIPSetNameToIPSet[setName]
+	if !ok {
+		return nil, false
+	}
+	return ipSetMemberSetToStringSet(ipSet.desiredMembers()).Slice(), true
 }
 
 func (s *IPSets) ApplyUpdates() {
+	startTime := time.Now()
+	defer func() {
+		s.histApplySeconds.Observe(time.Since(startTime).Seconds())
+	}()
+
+	s.aggregateMembers()
+
 	success := false
+	var lastErr error
 	retryDelay := 1 * time.Millisecond
 	backOff := func() {
 		s.sleep(retryDelay)
@@ -317,6 +603,8 @@ func (s *IPSets) ApplyUpdates() {
 			numProblems, err := s.tryResync()
 			if err != nil {
 				s.logCxt.WithError(err).Warning("Failed to resync with dataplane")
+				lastErr = err
+				s.countVecFailures.WithLabelValues("resync").Inc()
 				backOff()
 				continue
 			}
@@ -325,6 +613,19 @@ func (s *IPSets) ApplyUpdates() {
 					"Found inconsistencies in IP sets in dataplane")
 			}
 			s.resyncRequired = false
+			s.membershipCheckRequired = false
+		}
+
+		if s.membershipCheckRequired {
+			s.logCxt.Debug("Checking IP set membership hashes against dataplane.")
+			if err := s.tryMembershipCheck(); err != nil {
+				s.logCxt.WithError(err).Warning("Failed to check IP set membership")
+				lastErr = err
+				s.countVecFailures.WithLabelValues("membership-check").Inc()
+				backOff()
+				continue
+			}
+			s.membershipCheckRequired = false
 		}
 
 		numTempSets := s.pendingTempIPSetDeletions.Len()
@@ -338,8 +639,10 @@ func (s *IPSets) ApplyUpdates() {
 			// While failed deletions don't cause immediate problems, update failures may mean that our iptables
 			// updates fail.  We need to do an immediate resync.
 			s.logCxt.WithError(err).Warning("Failed to update IP sets. Marking dataplane for resync.")
+			lastErr = err
 			s.resyncRequired = true
 			countNumIPSetErrors.Inc()
+			s.countVecFailures.WithLabelValues("restore").Inc()
 			backOff()
 			continue
 		}
@@ -347,6 +650,12 @@ func (s *IPSets) ApplyUpdates() {
 		success = true
 		break
 	}
+	if !success && s.onPersistentFailure != nil {
+		s.logCxt.WithError(lastErr).Error("Failed to update IP sets after multiple retries, will try again later.")
+		s.resyncRequired = true
+		s.onPersistentFailure(fmt.Errorf("failed to update IPv%d IP sets: %w", s.IPVersionConfig.Family.Version(), lastErr))
+		return
+	}
 	if !success {
 		s.dumpIPSetsToLog()
 		s.logCxt.Panic("Failed to update IP sets after multiple retries.")
@@ -354,6 +663,26 @@ func (s *IPSets) ApplyUpdates() {
 	gaugeNumTotalIpsets.Set(float64(s.existingIPSetNames.Len()))
 }
 
+// aggregateMembers recalculates the aggregated membership of any IP sets whose (unaggregated)
+// members have changed and queues up the resulting changes to the dataplane.
+func (s *IPSets) aggregateMembers() {
+	s.dirtyIPSetIDs.Iter(func(setID string) error {
+		ipSet := s.ipSetIDToIPSet[setID]
+		if !ipSet.aggregationDirty {
+			return nil
+		}
+		numAdds, numDeletes := ipSet.queueReplace(aggregateCIDRs(ipSet.unaggregatedMembers))
+		ipSet.aggregationDirty = false
+		s.logCxt.WithFields(log.Fields{
+			"setID":      setID,
+			"numMembers": ipSet.unaggregatedMembers.Len(),
+			"numAdds":    numAdds,
+			"numDeletes": numDeletes,
+		}).Debug("Aggregated IP set members")
+		return nil
+	})
+}
+
 // tryResync attempts to bring our state into sync with the dataplane.  It scans the contents of the
 // IP sets in the dataplane and queues up updates to any IP sets that are out-of-sync.
 func (s *IPSets) tryResync() (numProblems int, err error) {
@@ -366,213 +695,25 @@ func (s *IPSets) tryResync() (numProblems int, err error) {
 		}).Debug("Finished IPSets resync")
 	}()
 
-	// Start an 'ipset list' child process, which will emit output of the following form:
-	//
-	// 	Name: test-100
-	//	Type: hash:ip
-	//	Revision: 4
-	//	Header: family inet hashsize 1024 maxelem 65536
-	//	Size in memory: 224
-	//	References: 0
-	//	Members:
-	//	10.0.0.2
-	//	10.0.0.1
-	//
-	//	Name: test-1
-	//	Type: hash:ip
-	//	Revision: 4
-	//	Header: family inet hashsize 1024 maxelem 65536
-	//	Size in memory: 224
-	//	References: 0
-	//	Members:
-	//	10.0.0.1
-	//	10.0.0.2
-	//
-	// As we stream through the data, we extract the name of the IP set and its members. We
-	// use the IP set's metadata to convert each member to its canonical form for comparison.
-	cmd := s.newCmd("ipset", "list")
-	// Grab stdout as a pipe so we can stream through the (potentially very large) output.
-	out, err := cmd.StdoutPipe()
-	if err != nil {
-		s.logCxt.WithError(err).Error("Failed to get pipe for 'ipset list'")
-		return
-	}
-	// Capture error output into a buffer.
-	var stderr bytes.Buffer
-	cmd.SetStderr(&stderr)
-	execStartTime := time.Now()
-	err = cmd.Start()
-	if err != nil {
-		s.logCxt.WithError(err).Error("Failed to start 'ipset list'")
-		return
-	}
-	summaryExecStart.Observe(float64(time.Since(execStartTime).Nanoseconds()) / 1000.0)
 	// Clear the set of known IP sets names, we'll fill it back in as we scan.
 	s.existingIPSetNames.Clear()
-	// Use a scanner to chunk the input into lines.
-	scanner := bufio.NewScanner(out)
-	ipSetName := ""
-
-	// Figure out if debug logging is enabled so we can disable some expensive-to-calculate logs
-	// in the tight loop below if they're not going to be emitted.  This speeds up the loop
-	// by a factor of 3-4x!
-	debug := log.GetLevel() >= log.DebugLevel
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "Name:") {
-			ipSetName = strings.Split(line, " ")[1]
-			s.existingIPSetNames.Add(ipSetName)
-			s.logCxt.WithField("setName", ipSetName).Debug("Parsing IP set.")
-		}
-		if strings.HasPrefix(line, "Members:") {
-			// Start of a Members entry, following this, there'll be one member per
-			// line then EOF or a blank line.
-
-			// Look up to see if this is one of our IP sets.
-			ipSet := s.mainIPSetNameToIPSet[ipSetName]
-			logCxt := s.logCxt.WithField("setName", ipSetName)
-			if ipSet == nil || ipSet.members == nil {
-				// Either this is not one of our IP sets, or it's one that we're
-				// about to rewrite.  Either way, we don't care about its members
-				// so simply scan past them.
-				logCxt.Debug("Skipping IP set, either not ours or about to rewrite")
-				for scanner.Scan() {
-					line := scanner.Bytes()
-					if len(line) == 0 {
-						// End of members
-						break
-					}
-				}
-				ipSetName = ""
-				continue
-			}
-
-			// One of our IP sets and we're not planning to rewrite it; we need to
-			// load its members and compare them.
-			logCxt = s.logCxt.WithField("setID", ipSet.SetID)
-			dataplaneMembers := set.New[IPSetMember]()
-			for scanner.Scan() {
-				line := scanner.Text()
-				if line == "" {
-					// End of members
-					break
-				}
-				canonMember := ipSet.Type.CanonicaliseMember(line)
-				dataplaneMembers.Add(canonMember)
-				if debug {
-					logCxt.WithFields(log.Fields{
-						"member": line,
-						"canon":  canonMember,
-					}).Debug("Found member in dataplane")
-				}
-			}
-			ipSetName = ""
-			if scanner.Err() != nil {
-				logCxt.WithError(err).Error("Failed to read members from 'ipset list'.")
-				break
-			}
-
-			// If we get here, we've read all the members of the IP set.  Compare them
-			// with what we expect and queue up any fixes.
-			numMissing := 0
-			ipSet.members.Iter(func(m IPSetMember) error {
-				if dataplaneMembers.Contains(m) {
-					// Mainline (correct) case, member is in memory and in the
-					// dataplane.
-					dataplaneMembers.Discard(m)
-					return nil
-				}
-
-				logCxt := logCxt.WithField("member", m.String())
-				numProblems++
-				if ipSet.pendingDeletions.Contains(m) {
-					// We were trying to delete this item anyway, record that
-					// it's already gone.  We commonly hit this case when we're
-					// doing a retry after a failure and we're not sure which
-					// deltas got applied.
-					logCxt.Debug("Resync found member missing from " +
-						"dataplane. (Already queued for deletion.)")
-					ipSet.pendingDeletions.Discard(m)
-					return set.RemoveItem
-				}
-
-				// The item should be in the dataplane but it's not, queue up an
-				// add to add it back in.
-				if numMissing == 0 {
-					logCxt.Warning("Resync found member missing from " +
-						"dataplane. Queueing up an add to reinstate it. " +
-						"Further inconsistencies will be logged at DEBUG.")
-				} else {
-					logCxt.Debug("Found another member missing")
-				}
-				numMissing++
-				s.dirtyIPSetIDs.Add(ipSet.SetID)
-				ipSet.pendingAdds.Add(m)
-				return set.RemoveItem
-			})
-			if numMissing > 0 {
-				logCxt.WithField("numMissing", numMissing).Warn(
-					"Resync found members missing from dataplane.")
-			}
-
-			// Now look for any members which are in the dataplane but are not expected.
-			// We removed the members we were expecting above so dataplaneMembers now
-			// contains only unexpected members.
-			numExtras := 0
-			dataplaneMembers.Iter(func(m IPSetMember) error {
-				logCxt := logCxt.WithField("member", m.String())
-
-				// Record that this member really is in the dataplane.
-				ipSet.members.Add(m)
-				numProblems++
-
-				if ipSet.pendingAdds.Contains(m) {
-					// We were trying to add this item anyway, record that
-					// it's already there.  We commonly hit this case when we're
-					// doing a retry after a failure and we're not sure which
-					// deltas got applied.
-					logCxt.Debug("Resync found unexpected member in " +
-						"dataplane. (Was about to add it anyway.)")
-					ipSet.pendingAdds.Discard(m)
-					return nil
-				}
-
-				// We weren't planning on adding this member, queue up a deletion.
-				if numExtras == 0 {
-					logCxt.Warning("Resync found unexpected member in " +
-						"dataplane. Queueing it for removal.  Further " +
-						"inconsistencies will be logged at DEBUG.")
-				} else {
-					logCxt.Debug("Found another extra member.")
-				}
-				numExtras++
-				s.dirtyIPSetIDs.Add(ipSet.SetID)
-				ipSet.pendingDeletions.Add(m)
-				return nil
-			})
-			if numExtras > 0 {
-				logCxt.WithField("numExtras", numExtras).Warn(
-					"Resync found extra members in dataplane.")
-			}
-		}
+	visitor := &resyncVisitor{
+		ipSets: s,
+		// Figure out if debug logging is enabled so we can disable some expensive-to-calculate
+		// logs in the tight loop if they're not going to be emitted.  This speeds up the loop
+		// by a factor of 3-4x!
+		debug: log.GetLevel() >= log.DebugLevel,
 	}
-	closeErr := out.Close()
-	err = cmd.Wait()
-	logCxt := s.logCxt.WithField("stderr", stderr.String())
-	if scanner.Err() != nil {
-		logCxt.WithError(scanner.Err()).Error("Failed to read 'ipset list' output.")
-		err = scanner.Err()
-		return
+	if s.IPVersionConfig.sharded {
+		visitor.sharded = s.newShardedResyncState()
 	}
+	err = s.listIPSets(visitor)
+	numProblems = visitor.numProblems
 	if err != nil {
-		logCxt.WithError(err).Error("Bad return code from 'ipset list'.")
 		return
 	}
-	if closeErr != nil {
-		err = closeErr
-		logCxt.WithError(err).Error("Failed to close stdout from 'ipset list'.")
-		return
+	if visitor.sharded != nil {
+		numProblems += s.resyncShardedIPSets(visitor)
 	}
 
 	// Scan for IP sets that need to be cleaned up.  Create list containing the IP sets that we expect to be there.
@@ -596,6 +737,11 @@ func (s *IPSets) tryResync() (numProblems int, err error) {
 	})
 
 	// Now look for any left-over IP sets that we should delete and queue up the deletions.
+	// Rebuild the record of when we first saw each left-over IP set so that it only holds IP
+	// sets that are still left over.
+	now := s.now()
+	oldFirstSeen := s.staleIPSetFirstSeen
+	s.staleIPSetFirstSeen = map[string]time.Time{}
 	s.existingIPSetNames.Iter(func(setName string) error {
 		if !s.IPVersionConfig.OwnsIPSet(setName) {
 			s.logCxt.WithField("setName", setName).Debug(
@@ -606,12 +752,29 @@ func (s *IPSets) tryResync() (numProblems int, err error) {
 			s.logCxt.WithField("setName", setName).Debug("Skipping expected Calico IP set.")
 			return nil
 		}
+		if visitor.sharded != nil && s.isExpectedShard(visitor, setName) {
+			s.logCxt.WithField("setName", setName).Debug("Skipping expected shard of Calico IP set.")
+			return nil
+		}
 		if s.IPVersionConfig.IsTempIPSetName(setName) {
 			// Temporary IP sets get leaked after a failure but they should never be in use by iptables so
 			// we try to delete them early in the processing to free up IP set space.
 			s.logCxt.WithField("setName", setName).Info(
 				"Resync found left-over temporary IP set. Queueing early deletion.")
 			s.pendingTempIPSetDeletions.Add(setName)
+		} else if s.staleQuarantine > 0 {
+			firstSeen, ok := oldFirstSeen[setName]
+			if !ok {
+				firstSeen = now
+			}
+			if now.Sub(firstSeen) < s.staleQuarantine {
+				s.staleIPSetFirstSeen[setName] = firstSeen
+				s.logCxt.WithFields(log.Fields{
+					"setName":   setName,
+					"firstSeen": firstSeen,
+				}).Info("Resync found left-over Calico IP set. Quarantining before deletion.")
+				return nil
+			}
 		}
 		s.logCxt.WithField("setName", setName).Info(
 			"Resync found left-over Calico IP set. Queueing deletion.")
@@ -622,6 +785,316 @@ func (s *IPSets) tryResync() (numProblems int, err error) {
 	return
 }
 
+// tryMembershipCheck compares a hash of the members of each of our IP sets in the dataplane with
+// a hash of the members that we expect and queues up a rewrite of any IP sets that don't match.
+// IP sets that are dirty (and hence about to be written anyway) are skipped.
+func (s *IPSets) tryMembershipCheck() error {
+	visitor := &membershipCheckVisitor{
+		ipSets:     s,
+		seenNames:  set.New[string](),
+		hashes:     map[*ipSet]uint64{},
+		numMembers: map[*ipSet]int{},
+	}
+	if s.IPVersionConfig.sharded {
+		visitor.shards = s.currentShards()
+	}
+	if err := s.listIPSets(visitor); err != nil {
+		return err
+	}
+
+	// Look for IP sets that have been modified or deleted.
+	numModified := 0
+	for _, ipSet := range s.ipSetIDToIPSet {
+		if !s.membershipCheckApplies(ipSet) {
+			continue
+		}
+		logCxt := s.logCxt.WithFields(log.Fields{
+			"setID":   ipSet.SetID,
+			"setName": ipSet.MainIPSetName,
+		})
+		if !visitor.seenNames.Contains(ipSet.MainIPSetName) {
+			logCxt.Warning("IP set was deleted by another process. Queueing a rewrite.")
+			s.existingIPSetNames.Discard(ipSet.MainIPSetName)
+		} else if visitor.modified(ipSet) {
+			logCxt.Warning("IP set was modified by another process. Queueing a rewrite.")
+		} else {
+			continue
+		}
+		ipSet.queueRewrite()
+		s.dirtyIPSetIDs.Add(ipSet.SetID)
+		countNumIPSetExternalModifications.Inc()
+		numModified++
+	}
+	s.logCxt.WithField("numModified", numModified).Debug("Finished IP set membership check")
+	return nil
+}
+
+// membershipCheckApplies returns true if tryMembershipCheck should check the given IP set; that is,
+// if it is in the dataplane and in sync, as far as we know.
+func (s *IPSets) membershipCheckApplies(ipSet *ipSet) bool {
+	return ipSet.members != nil &&
+		!s.dirtyIPSetIDs.Contains(ipSet.SetID) &&
+		s.ipSetNeeded(ipSet.SetID)
+}
+
+// membershipCheckVisitor calculates the hash of the members of each of our IP sets as they are
+// listed by the backend.  Since the hash is order-independent, the members of a sharded IP set
+// can be accumulated across its shards.
+type membershipCheckVisitor struct {
+	ipSets *IPSets
+	// shards is non-nil if sharding is enabled.
+	shards map[string]ipSetShard
+
+	seenNames  set.Set[string]
+	hashes     map[*ipSet]uint64
+	numMembers map[*ipSet]int
+
+	// ipSet is the IP set whose members are currently being listed, if any.
+	ipSet *ipSet
+}
+
+func (v *membershipCheckVisitor) visitSet(setName string) bool {
+	v.seenNames.Add(setName)
+	ipSet := v.ipSets.mainIPSetNameToIPSet[setName]
+	if v.shards != nil {
+		// The main IP set only lists the shards, which hold the members.
+		ipSet = v.shards[setName].ipSet
+	}
+	if ipSet == nil || !v.ipSets.membershipCheckApplies(ipSet) {
+		return false
+	}
+	v.ipSet = ipSet
+	return true
+}
+
+func (v *membershipCheckVisitor) visitMember(member string) {
+	v.hashes[v.ipSet] += hashMember(v.ipSet.Type.CanonicaliseMember(member))
+	v.numMembers[v.ipSet]++
+}
+
+func (v *membershipCheckVisitor) endSet() {
+	v.ipSet = nil
+}
+
+// modified returns true if the hash or size of the members of the given IP set, as listed, doesn't
+// match what we expect.
+func (v *membershipCheckVisitor) modified(ipSet *ipSet) bool {
+	var expectedHash uint64
+	ipSet.members.Iter(func(m IPSetMember) error {
+		expectedHash += hashMember(m)
+		return nil
+	})
+	return v.numMembers[ipSet] != ipSet.members.Len() || v.hashes[ipSet] != expectedHash
+}
+
+// hashMember returns a hash of the given member.  Member hashes are summed to give an
+// order-independent hash of the IP set.
+func hashMember(m IPSetMember) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(m.String()))
+	return h.Sum64()
+}
+
+// listIPSets runs the backend's list command, streaming the IP sets in the dataplane, and their
+// members, to the given visitor.
+func (s *IPSets) listIPSets(visitor listVisitor) error {
+	// Start a child process to list the IP sets in the dataplane.  As we stream through its
+	// output, the backend extracts the name of each IP set and its members.
+	listCmd := s.backend.listCmd()
+	cmd := s.newCmd(listCmd[0], listCmd[1:]...)
+	// Grab stdout as a pipe so we can stream through the (potentially very large) output.
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		s.logCxt.WithError(err).Errorf("Failed to get pipe for %s", describeCmd(listCmd))
+		return err
+	}
+	// Capture error output into a buffer.
+	var stderr bytes.Buffer
+	cmd.SetStderr(&stderr)
+	execStartTime := time.Now()
+	err = cmd.Start()
+	if err != nil {
+		s.logCxt.WithError(err).Errorf("Failed to start %s", describeCmd(listCmd))
+		return err
+	}
+	summaryExecStart.Observe(float64(time.Since(execStartTime).Nanoseconds()) / 1000.0)
+	// Use a scanner to chunk the input into lines.
+	scanner := bufio.NewScanner(out)
+	s.backend.scanList(scanner, visitor)
+	closeErr := out.Close()
+	err = cmd.Wait()
+	logCxt := s.logCxt.WithField("stderr", stderr.String())
+	if scanner.Err() != nil {
+		logCxt.WithError(scanner.Err()).Errorf("Failed to read %s output.", describeCmd(listCmd))
+		return scanner.Err()
+	}
+	if err != nil {
+		logCxt.WithError(err).Errorf("Bad return code from %s.", describeCmd(listCmd))
+		return err
+	}
+	if closeErr != nil {
+		logCxt.WithError(closeErr).Errorf("Failed to close stdout from %s.", describeCmd(listCmd))
+		return closeErr
+	}
+	return nil
+}
+
+// resyncVisitor receives the IP sets listed by the backend during a resync and queues up fixes to
+// any of our IP sets that are out-of-sync.
+type resyncVisitor struct {
+	ipSets *IPSets
+	debug  bool
+
+	// ipSet is the IP set whose members are currently being listed, if any.
+	ipSet            *ipSet
+	logCxt           *log.Entry
+	dataplaneMembers set.Set[IPSetMember]
+
+	// sharded is non-nil if sharding is enabled.
+	sharded *shardedResyncState
+
+	numProblems int
+}
+
+func (v *resyncVisitor) visitSet(setName string) bool {
+	s := v.ipSets
+	s.existingIPSetNames.Add(setName)
+	logCxt := s.logCxt.WithField("setName", setName)
+	logCxt.Debug("Parsing IP set.")
+	if v.sharded != nil {
+		return v.visitShardedSet(setName)
+	}
+
+	// Look up to see if this is one of our IP sets.
+	ipSet := s.mainIPSetNameToIPSet[setName]
+	if ipSet == nil || ipSet.members == nil {
+		// Either this is not one of our IP sets, or it's one that we're about to rewrite.
+		// Either way, we don't care about its members.
+		logCxt.Debug("Skipping IP set, either not ours or about to rewrite")
+		return false
+	}
+
+	// One of our IP sets and we're not planning to rewrite it; we need to load its members
+	// and compare them.
+	v.ipSet = ipSet
+	v.logCxt = s.logCxt.WithField("setID", ipSet.SetID)
+	v.dataplaneMembers = set.New[IPSetMember]()
+	return true
+}
+
+func (v *resyncVisitor) visitMember(member string) {
+	if v.sharded != nil {
+		v.visitShardMember(member)
+		return
+	}
+	canonMember := v.ipSet.Type.CanonicaliseMember(member)
+	v.dataplaneMembers.Add(canonMember)
+	if v.debug {
+		v.logCxt.WithFields(log.Fields{
+			"member": member,
+			"canon":  canonMember,
+		}).Debug("Found member in dataplane")
+	}
+}
+
+func (v *resyncVisitor) endSet() {
+	if v.sharded != nil {
+		v.endShardedSet()
+		return
+	}
+	// We've read all the members of the IP set.  Compare them with what we expect and queue
+	// up any fixes.
+	v.numProblems += v.ipSets.resyncMembers(v.ipSet, v.dataplaneMembers, v.logCxt)
+	v.ipSet = nil
+	v.dataplaneMembers = nil
+}
+
+// resyncMembers compares the members of one of our IP sets, as read from the dataplane, with
+// what we expect and queues up any fixes.  It returns the number of inconsistencies found.
+func (s *IPSets) resyncMembers(ipSet *ipSet, dataplaneMembers set.Set[IPSetMember], logCxt *log.Entry) (numProblems int) {
+	numMissing := 0
+	ipSet.members.Iter(func(m IPSetMember) error {
+		if dataplaneMembers.Contains(m) {
+			// Mainline (correct) case, member is in memory and in the
+			// dataplane.
+			dataplaneMembers.Discard(m)
+			return nil
+		}
+
+		logCxt := logCxt.WithField("member", m.String())
+		numProblems++
+		if ipSet.pendingDeletions.Contains(m) {
+			// We were trying to delete this item anyway, record that
+			// it's already gone.  We commonly hit this case when we're
+			// doing a retry after a failure and we're not sure which
+			// deltas got applied.
+			logCxt.Debug("Resync found member missing from " +
+				"dataplane. (Already queued for deletion.)")
+			ipSet.pendingDeletions.Discard(m)
+			return set.RemoveItem
+		}
+
+		// The item should be in the dataplane but it's not, queue up an
+		// add to add it back in.
+		if numMissing == 0 {
+			logCxt.Warning("Resync found member missing from " +
+				"dataplane. Queueing up an add to reinstate it. " +
+				"Further inconsistencies will be logged at DEBUG.")
+		} else {
+			logCxt.Debug("Found another member missing")
+		}
+		numMissing++
+		s.dirtyIPSetIDs.Add(ipSet.SetID)
+		ipSet.pendingAdds.Add(m)
+		return set.RemoveItem
+	})
+	if numMissing > 0 {
+		logCxt.WithField("numMissing", numMissing).Warn(
+			"Resync found members missing from dataplane.")
+	}
+
+	// Now look for any members which are in the dataplane but are not expected.
+	// We removed the members we were expecting above so dataplaneMembers now
+	// contains only unexpected members.
+	numExtras := 0
+	dataplaneMembers.Iter(func(m IPSetMember) error {
+		logCxt := logCxt.WithField("member", m.String())
+
+		// Record that this member really is in the dataplane.
+		ipSet.members.Add(m)
+		numProblems++
+
+		if ipSet.pendingAdds.Contains(m) {
+			// We were trying to add this item anyway, record that
+			// it's already there.  We commonly hit this case when we're
+			// doing a retry after a failure and we're not sure which
+			// deltas got applied.
+			logCxt.Debug("Resync found unexpected member in " +
+				"dataplane. (Was about to add it anyway.)")
+			ipSet.pendingAdds.Discard(m)
+			return nil
+		}
+
+		// We weren't planning on adding this member, queue up a deletion.
+		if numExtras == 0 {
+			logCxt.Warning("Resync found unexpected member in " +
+				"dataplane. Queueing it for removal.  Further " +
+				"inconsistencies will be logged at DEBUG.")
+		} else {
+			logCxt.Debug("Found another extra member.")
+		}
+		numExtras++
+		s.dirtyIPSetIDs.Add(ipSet.SetID)
+		ipSet.pendingDeletions.Add(m)
+		return nil
+	})
+	if numExtras > 0 {
+		logCxt.WithField("numExtras", numExtras).Warn(
+			"Resync found extra members in dataplane.")
+	}
+	return
+}
+
 // tryUpdates attempts to create and/or update IP sets.  It attempts to do the updates as a single
 // 'ipset restore' session in order to minimise process forking overhead.  Note: unlike
 // 'iptables-restore', 'ipset restore' is not atomic, updates are applied individually.
@@ -645,18 +1118,26 @@ func (s *IPSets) tryUpdates() error {
 
 	s.opReporter.RecordOperation(fmt.Sprint("update-ipsets-", s.IPVersionConfig.Family.Version()))
 
+	if s.dryRun {
+		s.dryRunUpdates()
+		return nil
+	}
+
 	// Set up an ipset restore session.
 	countNumIPSetCalls.Inc()
-	cmd := s.newCmd("ipset", "restore")
+	restoreCmd := s.backend.restoreCmd()
+	cmd := s.newCmd(restoreCmd[0], restoreCmd[1:]...)
 	// Get the pipe for stdin.
 	rawStdin, err := cmd.StdinPipe()
 	if err != nil {
 		s.logCxt.WithError(err).Error("Failed to create pipe for ipset restore.")
 		return err
 	}
-	// "Tee" the data that we write to stdin to a buffer so we can dump it to the log on
-	// failure.
-	stdin := io.MultiWriter(&s.restoreInCopy, rawStdin)
+	// Render the input into batches that a background goroutine streams to the process so
+	// that the process can be applying earlier batches while we render later ones.  "Tee"
+	// the data to a buffer too so we can dump it to the log on failure.
+	pipeline := newPipelinedWriter(rawStdin, restoreBatchSize, restorePipelineDepth)
+	stdin := io.MultiWriter(&s.restoreInCopy, pipeline)
 	defer s.restoreInCopy.Reset()
 
 	// Channel stdout/err to buffers so we can include them in the log on failure.
@@ -670,6 +1151,7 @@ func (s *IPSets) tryUpdates() error {
 	err = cmd.Start()
 	if err != nil {
 		s.logCxt.WithError(err).Error("Failed to start ipset restore.")
+		_ = pipeline.Close()
 		closeErr := rawStdin.Close()
 		if closeErr != nil {
 			s.logCxt.WithError(closeErr).Error(
@@ -680,35 +1162,30 @@ func (s *IPSets) tryUpdates() error {
 	summaryExecStart.Observe(float64(time.Since(startTime).Nanoseconds()) / 1000.0)
 
 	// Ask each dirty IP set to write its updates to the stream.
-	var writeErr error
-	s.dirtyIPSetIDs.Iter(func(setID string) error {
-		if !s.ipSetNeeded(setID) {
-			return nil
-		}
-		ipSet := s.ipSetIDToIPSet[setID]
-		writeErr = s.writeUpdates(ipSet, stdin)
-		if writeErr != nil {
-			return set.StopIteration
-		}
-		return nil
-	})
-	// Finish off the input, then flush and close the input, or the command won't terminate.
-	// We need to close and wait whether we hit a write error or not so we defer the error
-	// handling.
-	_, commitErr := stdin.Write([]byte("COMMIT\n"))
+	writeErr := s.writeDirtyIPSets(stdin)
+	s.histRestoreLines.Observe(float64(s.numLinesInRestore))
+	// Finish off the input, then wait for the pipeline to drain and flush and close the input,
+	// or the command won't terminate.  We need to close and wait whether we hit a write error
+	// or not so we defer the error handling.
+	var commitErr error
+	if commitLine := s.backend.commitLine(); commitLine != "" {
+		_, commitErr = stdin.Write([]byte(commitLine + "\n"))
+	}
+	pipelineErr := pipeline.Close()
 	flushErr := rawStdin.Flush()
 	closeErr := rawStdin.Close()
 	processErr := cmd.Wait()
-	if err = firstNonNilErr(writeErr, commitErr, flushErr, closeErr, processErr); err != nil {
+	if err = firstNonNilErr(writeErr, commitErr, pipelineErr, flushErr, closeErr, processErr); err != nil {
 		s.logCxt.WithFields(log.Fields{
-			"writeErr":   writeErr,
-			"commitErr":  commitErr,
-			"flushErr":   flushErr,
-			"closeErr":   closeErr,
-			"processErr": processErr,
-			"stdout":     s.stdoutCopy.String(),
-			"stderr":     s.stderrCopy.String(),
-			"input":      s.restoreInCopy.String(),
+			"writeErr":    writeErr,
+			"commitErr":   commitErr,
+			"pipelineErr": pipelineErr,
+			"flushErr":    flushErr,
+			"closeErr":    closeErr,
+			"processErr":  processErr,
+			"stdout":      s.stdoutCopy.String(),
+			"stderr":      s.stderrCopy.String(),
+			"input":       s.restoreInCopy.String(),
 		}).Warning("Failed to complete ipset restore, IP sets may be out-of-sync.")
 		return err
 	}
@@ -716,6 +1193,63 @@ func (s *IPSets) tryUpdates() error {
 	// If we get here, the writes were successful, reset the IP sets delta tracking now the
 	// dataplane should be in sync.  If we bail out above, then the resync logic will kick in
 	// and figure out how much of our update succeeded.
+	s.markDirtyIPSetsWritten()
+
+	return nil
+}
+
+// dryRunUpdates renders the updates to the dirty IP sets, as tryUpdates does, then logs and exports
+// them instead of running the restore command.
+func (s *IPSets) dryRunUpdates() {
+	defer s.restoreInCopy.Reset()
+	// Writes to a bytes.Buffer can't fail.
+	_ = s.writeDirtyIPSets(&s.restoreInCopy)
+	if commitLine := s.backend.commitLine(); commitLine != "" {
+		s.restoreInCopy.WriteString(commitLine + "\n")
+	}
+	s.emitDryRunCmd(s.backend.restoreCmd(), s.restoreInCopy.String())
+	s.markDirtyIPSetsWritten()
+}
+
+// emitDryRunCmd logs a command that we would have run, were we not in dry-run mode, and writes it
+// (with its input as a here-document) to the export writer, if there is one.
+func (s *IPSets) emitDryRunCmd(cmd []string, input string) {
+	s.logCxt.WithFields(log.Fields{
+		"cmd":   describeCmd(cmd),
+		"input": input,
+	}).Info("Dry run: skipping update to IP sets")
+	if s.dryRunOut == nil {
+		return
+	}
+	script := strings.Join(cmd, " ")
+	if input != "" {
+		script += " <<'EOF'\n" + input + "EOF"
+	}
+	if _, err := io.WriteString(s.dryRunOut, script+"\n"); err != nil {
+		s.logCxt.WithError(err).Warning("Failed to export dry run command")
+	}
+}
+
+// writeDirtyIPSets writes the updates for each dirty IP set to the given restore input.
+func (s *IPSets) writeDirtyIPSets(w io.Writer) (err error) {
+	s.numLinesInRestore = 0
+	s.dirtyIPSetIDs.Iter(func(setID string) error {
+		if !s.ipSetNeeded(setID) {
+			return nil
+		}
+		ipSet := s.ipSetIDToIPSet[setID]
+		err = s.writeUpdates(ipSet, w)
+		if err != nil {
+			return set.StopIteration
+		}
+		return nil
+	})
+	return
+}
+
+// markDirtyIPSetsWritten updates our record of the dataplane after the updates from
+// writeDirtyIPSets have been applied successfully.
+func (s *IPSets) markDirtyIPSetsWritten() {
 	s.dirtyIPSetIDs.Iter(func(setID string) error {
 		if !s.ipSetNeeded(setID) {
 			return nil
@@ -727,23 +1261,31 @@ func (s *IPSets) tryUpdates() error {
 
 			// Doing a rewrite creates the main IP set.
 			s.existingIPSetNames.Add(ipSet.MainIPSetName)
+			if s.IPVersionConfig.sharded {
+				s.markShardsWritten(ipSet)
+			}
 		} else {
 			ipSet.pendingAdds.Iter(func(m IPSetMember) error {
+				if ipSet.shardSizes != nil && !ipSet.members.Contains(m) {
+					ipSet.shardSizes[shardForMember(m, ipSet.numShards)]++
+				}
 				ipSet.members.Add(m)
 				return set.RemoveItem
 			})
 			ipSet.pendingDeletions.Iter(func(m IPSetMember) error {
+				if ipSet.shardSizes != nil && ipSet.members.Contains(m) {
+					ipSet.shardSizes[shardForMember(m, ipSet.numShards)]--
+				}
 				ipSet.members.Discard(m)
 				return set.RemoveItem
 			})
 		}
+		s.gaugeVecMembers.WithLabelValues(ipSet.MainIPSetName).Set(float64(ipSet.members.Len()))
 		return set.RemoveItem
 	})
-
-	return nil
 }
 
-func (s *IPSets) writeUpdates(ipSet *ipSet, w io.Writer) error {
+func (s *IPSets) writeUpdates(ipSet *ipSet, w io.Writer) (err error) {
 	logCxt := s.logCxt.WithField("setID", ipSet.SetID)
 	if ipSet.members != nil {
 		logCxt = logCxt.WithField("numMembersInDataplane", ipSet.members.Len())
@@ -757,6 +1299,43 @@ func (s *IPSets) writeUpdates(ipSet *ipSet, w io.Writer) error {
 		})
 	}
 
+	// writeLine until an error occurs, writeLine writes a line to the output, after an error,
+	// it is a no-op.
+	commitLine := s.backend.commitLine()
+	writeLine := func(format string, a ...interface{}) {
+		if err != nil {
+			return
+		}
+		if commitLine != "" && s.maxRestoreLinesPerCommit > 0 &&
+			s.numLinesInRestore > 0 && s.numLinesInRestore%s.maxRestoreLinesPerCommit == 0 {
+			// Chunk very large updates.  We only do this before writing another line so that
+			// the final COMMIT (written by our caller) never follows an intermediate one.
+			_, err = w.Write([]byte(commitLine + "\n"))
+			if err != nil {
+				logCxt.WithError(err).Error("Failed to write intermediate COMMIT to ipset restore")
+				return
+			}
+		}
+		line := fmt.Sprintf(format, a...) + "\n"
+		if log.GetLevel() >= log.DebugLevel {
+			logCxt.WithField("line", line).Debug("Writing line to ipset restore")
+		}
+		lineBytes := []byte(line)
+		_, err = w.Write(lineBytes)
+		if err != nil {
+			logCxt.WithError(err).WithFields(log.Fields{
+				"line": lineBytes,
+			}).Error("Failed to write to ipset restore")
+			return
+		}
+		countNumIPSetLinesExecuted.Inc()
+		s.numLinesInRestore++
+	}
+
+	if s.maxSizeLimit > 0 && !s.IPVersionConfig.sharded {
+		s.maybeGrowIPSet(ipSet, logCxt)
+	}
+
 	if ipSet.pendingReplace == nil {
 		// In delta-writing mode:
 		// - pendingReplace is nil
@@ -768,66 +1347,63 @@ func (s *IPSets) writeUpdates(ipSet *ipSet, w io.Writer) error {
 			logCxt.Debug("Skipping delta write, IP set not dirty.")
 			return nil
 		}
-		logCxt.Info("Calculating deltas to IP set")
-		return s.writeDeltas(ipSet, w, logCxt)
+		needsSwap := ipSet.pendingAdds.Len() > 0 && ipSet.pendingDeletions.Len() > 0 &&
+			s.ipSetIsSwapOnly(ipSet.SetID) && !s.backend.atomicRestore()
+		if needsSwap {
+			logCxt.Info("Mixed update to swap-only IP set, swapping in a replacement")
+			ipSet.queueRewrite()
+		} else if !s.IPVersionConfig.sharded || ipSet.shardsFitDeltas() {
+			logCxt.Info("Calculating deltas to IP set")
+			s.writeDeltas(ipSet, writeLine)
+			return
+		} else {
+			// The deltas would overflow one of the shards, or the IP set has shrunk enough to
+			// need fewer shards, reshard it.
+			logCxt.Info("IP set needs resharding")
+			ipSet.queueRewrite()
+		}
 	}
 	// In full-rewrite mode.
 	// - pendingReplace is non-nil
 	// - membersInDataplane nil
 	// - pendingAdds/Deletions empty.
 	logCxt.Info("Doing full IP set rewrite")
-	return s.writeFullRewrite(ipSet, w, logCxt)
+	if s.IPVersionConfig.sharded {
+		s.writeShardedRewrite(ipSet, writeLine)
+		return
+	}
+	s.backend.writeFullRewrite(writeLine, ipSet,
+		s.existingIPSetNames.Contains(ipSet.MainIPSetName), s.nextFreeTempIPSetName)
+	return
 }
 
-// writeFullRewrite calculates the ipset restore input required to do a full, atomic, idempotent
-// rewrite of the IP set and writes it to the given io.Writer.
-func (s *IPSets) writeFullRewrite(ipSet *ipSet, out io.Writer, logCxt log.FieldLogger) (err error) {
-	// writeLine until an error occurs, writeLine writes a line to the output, after an error,
-	// it is a no-op.
-	writeLine := func(format string, a ...interface{}) {
-		if err != nil {
-			return
-		}
-		line := fmt.Sprintf(format, a...) + "\n"
-		logCxt.WithField("line", line).Debug("Writing line to ipset restore")
-		lineBytes := []byte(line)
-		_, err = out.Write(lineBytes)
-		if err != nil {
-			logCxt.WithError(err).WithFields(log.Fields{
-				"line": lineBytes,
-			}).Error("Failed to write to ipset restore")
-			return
-		}
-		countNumIPSetLinesExecuted.Inc()
+// maybeGrowIPSet increases the maxelem of the IP set if its desired membership is approaching the
+// current maxelem and queues a rewrite to apply the new size.
+func (s *IPSets) maybeGrowIPSet(ipSet *ipSet, logCxt *log.Entry) {
+	var numMembers int
+	if ipSet.pendingReplace != nil {
+		numMembers = ipSet.pendingReplace.Len()
+	} else {
+		// pendingAdds only contains members that aren't already in the IP set.
+		numMembers = ipSet.members.Len() + ipSet.pendingAdds.Len()
 	}
-
-	// Our general approach is to create a temporary IP set with the right contents, then
-	// atomically swap it into place.
-	mainSetName := ipSet.MainIPSetName
-	if !s.existingIPSetNames.Contains(mainSetName) {
-		// Create empty main IP set so we can share the atomic swap logic below.
-		// Note: we can't use the -exist flag (which should make the create idempotent)
-		// because it still fails if the IP set was previously created with different
-		// parameters.
-		logCxt.WithField("setID", ipSet.SetID).Debug("Pre-creating main IP set")
-		writeLine("create %s %s family %s maxelem %d",
-			mainSetName, ipSet.Type, s.IPVersionConfig.Family, ipSet.MaxSize)
-	}
-	tempSetName := s.nextFreeTempIPSetName()
-	// Create the temporary IP set with the current parameters.
-	writeLine("create %s %s family %s maxelem %d",
-		tempSetName, ipSet.Type, s.IPVersionConfig.Family, ipSet.MaxSize)
-	// Write all the members into the temporary IP set.
-	ipSet.pendingReplace.Iter(func(member IPSetMember) error {
-		writeLine("add %s %s", tempSetName, member)
-		return nil
-	})
-	// Atomically swap the temporary set into place.
-	writeLine("swap %s %s", mainSetName, tempSetName)
-	// Then remove the temporary set (which was the old main set).
-	writeLine("destroy %s", tempSetName)
-
-	return
+	newSize := ipSet.MaxSize
+	for float64(numMembers) > float64(newSize)*ipSetResizeThreshold && newSize < s.maxSizeLimit {
+		newSize *= 2
+	}
+	if newSize > s.maxSizeLimit {
+		newSize = s.maxSizeLimit
+	}
+	if newSize <= ipSet.MaxSize {
+		return
+	}
+	logCxt.WithFields(log.Fields{
+		"numMembers": numMembers,
+		"oldMaxSize": ipSet.MaxSize,
+		"newMaxSize": newSize,
+	}).Warn("IP set is close to its maximum size, resizing it")
+	ipSet.MaxSize = newSize
+	ipSet.queueRewrite()
 }
 
 // nextFreeTempIPSetName picks a name for a temporary IP set avoiding any that appear to be in use already.
@@ -846,32 +1422,25 @@ func (s *IPSets) nextFreeTempIPSetName() string {
 	}
 }
 
-// writeDeltas calculates the ipset restore input required to apply the pending adds/deletes to the
-// main IP set.
-func (s *IPSets) writeDeltas(ipSet *ipSet, out io.Writer, logCxt log.FieldLogger) (err error) {
-	mainSetName := ipSet.MainIPSetName
-	ipSet.pendingDeletions.Iter(func(member IPSetMember) error {
-		logCxt.WithField("member", member).Debug("Writing del")
-		_, err = fmt.Fprintf(out, "del %s %s --exist\n", mainSetName, member)
-		if err != nil {
-			return set.StopIteration
+// writeDeltas writes the input required to apply the pending adds/deletes to the main IP set (or,
+// if sharding is enabled, to the relevant shards).
+func (s *IPSets) writeDeltas(ipSet *ipSet, writeLine lineWriter) {
+	setNameFor := func(IPSetMember) string {
+		return ipSet.MainIPSetName
+	}
+	if s.IPVersionConfig.sharded {
+		setNameFor = func(member IPSetMember) string {
+			return s.shardedSetNameFor(ipSet, member)
 		}
-		countNumIPSetLinesExecuted.Inc()
+	}
+	ipSet.pendingDeletions.Iter(func(member IPSetMember) error {
+		s.backend.writeDel(writeLine, setNameFor(member), member)
 		return nil
 	})
-	if err != nil {
-		return
-	}
 	ipSet.pendingAdds.Iter(func(member IPSetMember) error {
-		logCxt.WithField("member", member).Debug("Writing add")
-		_, err = fmt.Fprintf(out, "add %s %s\n", mainSetName, member)
-		if err != nil {
-			return set.StopIteration
-		}
-		countNumIPSetLinesExecuted.Inc()
+		s.backend.writeAdd(writeLine, setNameFor(member), member)
 		return nil
 	})
-	return
 }
 
 // ApplyDeletions tries to delete any IP sets that are no longer needed.
@@ -880,6 +1449,12 @@ func (s *IPSets) ApplyDeletions() {
 	s.pendingIPSetDeletions.Iter(func(setName string) error {
 		logCxt := s.logCxt.WithField("setName", setName)
 		if s.existingIPSetNames.Contains(setName) {
+			if s.isReferenced != nil && s.isReferenced(setName) {
+				// Still in use by a rule that hasn't been removed yet (for example, because
+				// the update that removes it failed); try again after the next apply.
+				logCxt.Info("IP set still referenced by rules, deferring deletion.")
+				return nil
+			}
 			logCxt.Info("Deleting IP set.")
 			if err := s.deleteIPSet(setName); err != nil {
 				// Note: we used to set the resyncRequired flag on this path but that can lead to excessive retries if
@@ -919,44 +1494,42 @@ func (s *IPSets) tryTempIPSetDeletions() {
 
 func (s *IPSets) deleteIPSet(setName string) error {
 	s.logCxt.WithField("setName", setName).Info("Deleting IP set.")
-	cmd := s.newCmd("ipset", "destroy", string(setName))
-	if output, err := cmd.CombinedOutput(); err != nil {
-		s.logCxt.WithError(err).WithFields(log.Fields{
-			"setName": setName,
-			"output":  string(output),
-		}).Warn("Failed to delete IP set, may be out-of-sync.")
-		return err
+	destroyCmd := s.backend.destroyCmd(setName)
+	if s.dryRun {
+		s.emitDryRunCmd(destroyCmd, "")
+	} else {
+		cmd := s.newCmd(destroyCmd[0], destroyCmd[1:]...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			s.logCxt.WithError(err).WithFields(log.Fields{
+				"setName": setName,
+				"output":  string(output),
+			}).Warn("Failed to delete IP set, may be out-of-sync.")
+			s.countVecFailures.WithLabelValues("destroy").Inc()
+			return err
+		}
 	}
 	// Success, update the cache.
 	s.logCxt.WithField("setName", setName).Info("Deleted IP set")
 	s.existingIPSetNames.Discard(setName)
+	s.gaugeVecMembers.DeleteLabelValues(setName)
+	if s.IPVersionConfig.sharded && strings.HasPrefix(setName, s.IPVersionConfig.mainSetNamePrefix) {
+		// Now that the main IP set is gone, its shards are no longer in use.
+		s.deleteShards(setName)
+	}
 	if ipSet := s.mainIPSetNameToIPSet[setName]; ipSet != nil {
 		// We are still tracking this IP set; it has been deleted because it's not currently
 		// in the "needed" set.
 		if s.ipSetNeeded(ipSet.SetID) {
 			s.logCxt.Errorf("Unexpected deletion of an IP set %v that is still needed", ipSet.SetID)
 		}
-		// If we don't already have a pending complete IP set membership...
-		if ipSet.pendingReplace == nil {
-			// Reconstruct what the IP set membership should be from what was
-			// programmed, plus any pending additions, minus any pending deletions.
-			ipSet.pendingReplace = ipSet.members
-			ipSet.members = nil
-			ipSet.pendingAdds.Iter(func(m IPSetMember) error {
-				ipSet.pendingReplace.Add(m)
-				return set.RemoveItem
-			})
-			ipSet.pendingDeletions.Iter(func(m IPSetMember) error {
-				ipSet.pendingReplace.Discard(m)
-				return set.RemoveItem
-			})
-		}
+		ipSet.queueRewrite()
 	}
 	return nil
 }
 
 func (s *IPSets) dumpIPSetsToLog() {
-	cmd := s.newCmd("ipset", "list")
+	listCmd := s.backend.listCmd()
+	cmd := s.newCmd(listCmd[0], listCmd[1:]...)
 	output, err := cmd.Output()
 	if err != nil {
 		s.logCxt.WithError(err).Error("Failed to read IP sets")
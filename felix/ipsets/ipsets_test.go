@@ -15,8 +15,12 @@
 package ipsets_test
 
 import (
+	"bytes"
+	"fmt"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"time"
 
@@ -56,6 +60,15 @@ var _ = Describe("IPSetType", func() {
 	It("should treat hash:ip,port as valid", func() {
 		Expect(IPSetType("hash:ip,port").IsValid()).To(BeTrue())
 	})
+	It("should treat hash:net,port as valid", func() {
+		Expect(IPSetType("hash:net,port").IsValid()).To(BeTrue())
+	})
+	It("should treat hash:ip,port,net as valid", func() {
+		Expect(IPSetType("hash:ip,port,net").IsValid()).To(BeTrue())
+	})
+	It("should treat hash:net,iface as valid", func() {
+		Expect(IPSetType("hash:net,iface").IsValid()).To(BeTrue())
+	})
 })
 
 var _ = Describe("IPSetTypeHashIPPort", func() {
@@ -151,6 +164,116 @@ var _ = Describe("IPSetTypeHashNet", func() {
 	})
 })
 
+var _ = Describe("IPSetTypeHashNetPort", func() {
+	It("should canonicalise an IPv4 net,port", func() {
+		Expect(IPSetTypeHashNetPort.CanonicaliseMember("10.0.0.1/24,TCP:1234")).
+			To(Equal(V4NetPort{
+				Net:      ip.MustParseCIDROrIP("10.0.0.0/24").(ip.V4CIDR),
+				Protocol: labelindex.ProtocolTCP,
+				Port:     1234,
+			}))
+	})
+	It("should canonicalise an IPv4 net,port with no prefix length", func() {
+		// 'ipset list' omits the /32.
+		Expect(IPSetTypeHashNetPort.CanonicaliseMember("10.0.0.1,udp:53")).
+			To(Equal(IPSetTypeHashNetPort.CanonicaliseMember("10.0.0.1/32,udp:53")))
+	})
+	It("should canonicalise an IPv6 net,port", func() {
+		Expect(IPSetTypeHashNetPort.CanonicaliseMember("feed::beef/64,sctp:3456")).
+			To(Equal(V6NetPort{
+				Net:      ip.MustParseCIDROrIP("feed::/64").(ip.V6CIDR),
+				Protocol: labelindex.ProtocolSCTP,
+				Port:     3456,
+			}))
+	})
+	It("should panic on bad net,port", func() {
+		Expect(func() { IPSetTypeHashNetPort.CanonicaliseMember("10.0.0.0/24") }).To(Panic())
+		Expect(func() { IPSetTypeHashNetPort.CanonicaliseMember("foobar,tcp:80") }).To(Panic())
+		Expect(func() { IPSetTypeHashNetPort.CanonicaliseMember("10.0.0.0/24,tcp") }).To(Panic())
+		Expect(func() { IPSetTypeHashNetPort.CanonicaliseMember("10.0.0.0/24,tcp:99999") }).To(Panic())
+	})
+	It("should detect IPv6 for a net,port", func() {
+		Expect(IPSetTypeHashNetPort.IsMemberIPV6("feed:beef::/64,tcp:1234")).To(BeTrue())
+		Expect(IPSetTypeHashNetPort.IsMemberIPV6("10.0.0.0/8,tcp:1234")).To(BeFalse())
+	})
+	It("should stringify correctly", func() {
+		Expect(IPSetTypeHashNetPort.CanonicaliseMember("10.0.0.1,tcp:80").String()).
+			To(Equal("10.0.0.1/32,tcp:80"))
+	})
+})
+
+var _ = Describe("IPSetTypeHashIPPortNet", func() {
+	It("should canonicalise an IPv4 IP,port,net", func() {
+		Expect(IPSetTypeHashIPPortNet.CanonicaliseMember("10.0.0.1,tcp:1234,10.1.2.3/16")).
+			To(Equal(V4IPPortNet{
+				IP:       ip.FromString("10.0.0.1").(ip.V4Addr),
+				Protocol: labelindex.ProtocolTCP,
+				Port:     1234,
+				Net:      ip.MustParseCIDROrIP("10.1.0.0/16").(ip.V4CIDR),
+			}))
+	})
+	It("should canonicalise an IPv6 IP,port,net", func() {
+		Expect(IPSetTypeHashIPPortNet.CanonicaliseMember("feed:0::beef,udp:53,f00d::1")).
+			To(Equal(V6IPPortNet{
+				IP:       ip.FromString("feed::beef").(ip.V6Addr),
+				Protocol: labelindex.ProtocolUDP,
+				Port:     53,
+				Net:      ip.MustParseCIDROrIP("f00d::1/128").(ip.V6CIDR),
+			}))
+	})
+	It("should panic on bad IP,port,net", func() {
+		Expect(func() { IPSetTypeHashIPPortNet.CanonicaliseMember("10.0.0.1,tcp:80") }).To(Panic())
+		Expect(func() { IPSetTypeHashIPPortNet.CanonicaliseMember("foobar,tcp:80,10.0.0.0/8") }).To(Panic())
+		Expect(func() { IPSetTypeHashIPPortNet.CanonicaliseMember("10.0.0.1,foo:80,10.0.0.0/8") }).To(Panic())
+		Expect(func() { IPSetTypeHashIPPortNet.CanonicaliseMember("10.0.0.1,tcp:80,foobar") }).To(Panic())
+		Expect(func() { IPSetTypeHashIPPortNet.CanonicaliseMember("10.0.0.1,tcp:80,feed::/64") }).To(Panic())
+	})
+	It("should detect IPv6 for an IP,port,net", func() {
+		Expect(IPSetTypeHashIPPortNet.IsMemberIPV6("feed::1,tcp:1234,feed::/64")).To(BeTrue())
+		Expect(IPSetTypeHashIPPortNet.IsMemberIPV6("10.0.0.1,tcp:1234,10.0.0.0/8")).To(BeFalse())
+	})
+	It("should stringify correctly", func() {
+		Expect(IPSetTypeHashIPPortNet.CanonicaliseMember("10.0.0.1,tcp:80,10.1.0.0/16").String()).
+			To(Equal("10.0.0.1,tcp:80,10.1.0.0/16"))
+	})
+})
+
+var _ = Describe("IPSetTypeHashNetIface", func() {
+	It("should canonicalise an IPv4 net,iface", func() {
+		Expect(IPSetTypeHashNetIface.CanonicaliseMember("10.0.0.1/24,eth0")).
+			To(Equal(V4NetIface{
+				Net:   ip.MustParseCIDROrIP("10.0.0.0/24").(ip.V4CIDR),
+				Iface: "eth0",
+			}))
+	})
+	It("should canonicalise an IPv4 net,iface with no prefix length", func() {
+		// 'ipset list' omits the /32.
+		Expect(IPSetTypeHashNetIface.CanonicaliseMember("10.0.0.1,eth0")).
+			To(Equal(IPSetTypeHashNetIface.CanonicaliseMember("10.0.0.1/32,eth0")))
+	})
+	It("should canonicalise an IPv6 net,iface", func() {
+		Expect(IPSetTypeHashNetIface.CanonicaliseMember("feed::beef/64,bond0.100")).
+			To(Equal(V6NetIface{
+				Net:   ip.MustParseCIDROrIP("feed::/64").(ip.V6CIDR),
+				Iface: "bond0.100",
+			}))
+	})
+	It("should panic on bad net,iface", func() {
+		Expect(func() { IPSetTypeHashNetIface.CanonicaliseMember("10.0.0.0/24") }).To(Panic())
+		Expect(func() { IPSetTypeHashNetIface.CanonicaliseMember("foobar,eth0") }).To(Panic())
+		Expect(func() { IPSetTypeHashNetIface.CanonicaliseMember("10.0.0.0/24,") }).To(Panic())
+		Expect(func() { IPSetTypeHashNetIface.CanonicaliseMember("10.0.0.0/24,averylonginterface") }).To(Panic())
+	})
+	It("should detect IPv6 for a net,iface", func() {
+		Expect(IPSetTypeHashNetIface.IsMemberIPV6("feed:beef::/64,eth0")).To(BeTrue())
+		Expect(IPSetTypeHashNetIface.IsMemberIPV6("10.0.0.0/8,eth0")).To(BeFalse())
+	})
+	It("should stringify correctly", func() {
+		Expect(IPSetTypeHashNetIface.CanonicaliseMember("10.0.0.1,eth0").String()).
+			To(Equal("10.0.0.1/32,eth0"))
+	})
+})
+
 var _ = Describe("IPPort types", func() {
 	It("V4 should stringify correctly", func() {
 		Expect(V4IPPort{
@@ -300,6 +423,90 @@ var _ = Describe("IP sets dataplane", func() {
 		})
 	})
 
+	Describe("with a reference checker", func() {
+		var referenced set.Set[string]
+
+		BeforeEach(func() {
+			referenced = set.New[string]()
+			ipsets = NewIPSetsWithShims(
+				v4VersionConf,
+				logutils.NewSummarizer("test loop"),
+				dataplane.newCmd,
+				dataplane.sleep,
+				WithReferenceChecker(referenced.Contains),
+			)
+			ipsets.AddOrReplaceIPSet(meta, []string{"10.0.0.1"})
+			apply()
+			referenced.Add(v4MainIPSetName)
+			ipsets.RemoveIPSet(ipSetID)
+		})
+
+		It("should defer deletion of an IP set until it is no longer referenced", func() {
+			apply()
+			Expect(dataplane.IPSetMembers).To(HaveKey(v4MainIPSetName))
+			Expect(dataplane.CmdNames).NotTo(ContainElement("destroy"))
+
+			referenced.Discard(v4MainIPSetName)
+			apply()
+			Expect(dataplane.IPSetMembers).To(BeEmpty())
+		})
+
+		It("should cancel a deferred deletion if the IP set is re-added", func() {
+			apply()
+			ipsets.AddOrReplaceIPSet(meta, []string{"10.0.0.2"})
+			referenced.Discard(v4MainIPSetName)
+			apply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.0.0.2"},
+			})
+		})
+	})
+
+	Describe("with a stale IP set quarantine", func() {
+		var now time.Time
+
+		BeforeEach(func() {
+			now = time.Now()
+			ipsets = NewIPSetsWithShims(
+				v4VersionConf,
+				logutils.NewSummarizer("test loop"),
+				dataplane.newCmd,
+				dataplane.sleep,
+				WithStaleIPSetQuarantine(30*time.Second),
+				WithNowOverride(func() time.Time { return now }),
+			)
+			dataplane.IPSetMembers = map[string]set.Set[string]{
+				v4MainIPSetName:  set.From("10.0.0.1"),
+				v4TempIPSetName1: set.From("10.0.0.2"),
+			}
+		})
+
+		It("should delete temporary IP sets straight away but quarantine others", func() {
+			apply()
+			Expect(dataplane.IPSetMembers).To(Equal(map[string]set.Set[string]{
+				v4MainIPSetName: set.From("10.0.0.1"),
+			}))
+		})
+
+		It("should delete a stale IP set once the quarantine has expired", func() {
+			apply()
+			now = now.Add(29 * time.Second)
+			resyncAndApply()
+			Expect(dataplane.IPSetMembers).To(HaveKey(v4MainIPSetName))
+			now = now.Add(time.Second)
+			resyncAndApply()
+			Expect(dataplane.IPSetMembers).To(BeEmpty())
+		})
+
+		It("should not quarantine an IP set that we remove explicitly", func() {
+			ipsets.AddOrReplaceIPSet(meta, []string{"10.0.0.1"})
+			apply()
+			ipsets.RemoveIPSet(ipSetID)
+			apply()
+			Expect(dataplane.IPSetMembers).To(BeEmpty())
+		})
+	})
+
 	Describe("with a persistent failure to delete a new temporary IP set", func() {
 		BeforeEach(func() {
 			// Lay the trap: this should be the first temp IP set to get used.
@@ -617,6 +824,32 @@ var _ = Describe("IP sets dataplane", func() {
 				Expect(dataplane.CumulativeSleep).To(BeNumerically(">", time.Second))
 			})
 		})
+		Describe("with a persistent ipset restore failure and a failure callback", func() {
+			var failures []error
+			BeforeEach(func() {
+				failures = nil
+				WithPersistentFailureCallback(func(err error) {
+					failures = append(failures, err)
+				})(ipsets)
+				dataplane.FailAllRestores = true
+			})
+			It("should call the callback rather than panicking", func() {
+				ipsets.AddMembers(ipSetID, []string{"10.0.0.5"})
+				ipsets.ApplyUpdates()
+				Expect(failures).To(HaveLen(1))
+				Expect(failures[0].Error()).To(ContainSubstring("failed to update IPv4 IP sets"))
+			})
+			It("should apply the update once the failure clears", func() {
+				ipsets.AddMembers(ipSetID, []string{"10.0.0.5"})
+				ipsets.ApplyUpdates()
+				dataplane.FailAllRestores = false
+				ipsets.ApplyUpdates()
+				Expect(failures).To(HaveLen(1))
+				dataplane.ExpectMembers(map[string][]string{
+					v4MainIPSetName: {"10.0.0.1", "10.0.0.2", "10.0.0.5"},
+				})
+			})
+		})
 		Describe("with a persistent ipset list failure", func() {
 			BeforeEach(func() {
 				dataplane.FailAllLists = true
@@ -757,6 +990,843 @@ var _ = Describe("IP sets dataplane", func() {
 		})
 	})
 
+	Describe("with an IP set driven by trie snapshots", func() {
+		var trie *ip.Trie[struct{}]
+
+		update := func(cidrs ...string) {
+			for _, c := range cidrs {
+				trie.Update(ip.MustParseCIDROrIP(c), struct{}{})
+			}
+		}
+		remove := func(cidrs ...string) {
+			for _, c := range cidrs {
+				trie.Delete(ip.MustParseCIDROrIP(c))
+			}
+		}
+
+		BeforeEach(func() {
+			trie = ip.NewTrie[struct{}]()
+			update("10.0.0.0/16", "10.1.0.0/24", "10.1.1.1/32")
+			ipsets.AddOrReplaceIPSet(metaCIDRs, []string{"10.0.0.0/16", "10.2.0.0/16"})
+			ipsets.UpdateMembersFromTrie(ipSetID, trie.Snapshot())
+			apply()
+			dataplane.RestoreLines = nil
+		})
+
+		It("should program the initial contents of the trie", func() {
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.0.0.0/16", "10.1.0.0/24", "10.1.1.1/32"},
+			})
+		})
+
+		It("should write only the deltas between snapshots", func() {
+			for i := 0; i < 100; i++ {
+				update(fmt.Sprintf("10.3.%d.0/24", i))
+			}
+			ipsets.UpdateMembersFromTrie(ipSetID, trie.Snapshot())
+			apply()
+			dataplane.RestoreLines = nil
+
+			update("10.4.0.0/16")
+			remove("10.1.0.0/24", "10.3.7.0/24")
+			ipsets.UpdateMembersFromTrie(ipSetID, trie.Snapshot())
+			apply()
+			Expect(dataplane.RestoreLines).To(ConsistOf(
+				"add "+v4MainIPSetName+" 10.4.0.0/16",
+				"del "+v4MainIPSetName+" 10.1.0.0/24 --exist",
+				"del "+v4MainIPSetName+" 10.3.7.0/24 --exist",
+			))
+			members, err := ipsets.GetMembers(ipSetID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(members.Len()).To(Equal(102))
+			Expect(members.Contains("10.4.0.0/16")).To(BeTrue())
+			Expect(members.Contains("10.1.0.0/24")).To(BeFalse())
+		})
+
+		It("should squash changes made between applies", func() {
+			update("10.4.0.0/16")
+			ipsets.UpdateMembersFromTrie(ipSetID, trie.Snapshot())
+			remove("10.4.0.0/16", "10.0.0.0/16")
+			ipsets.UpdateMembersFromTrie(ipSetID, trie.Snapshot())
+			apply()
+			Expect(dataplane.RestoreLines).To(ConsistOf("del " + v4MainIPSetName + " 10.0.0.0/16 --exist"))
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.1.0.0/24", "10.1.1.1/32"},
+			})
+		})
+
+		It("should resync against the trie after string-based updates", func() {
+			ipsets.AddMembers(ipSetID, []string{"10.9.0.0/16"})
+			update("10.4.0.0/16")
+			ipsets.UpdateMembersFromTrie(ipSetID, trie.Snapshot())
+			apply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.0.0.0/16", "10.1.0.0/24", "10.1.1.1/32", "10.4.0.0/16"},
+			})
+		})
+
+		It("should recover from the IP set being modified in the dataplane", func() {
+			dataplane.IPSetMembers[v4MainIPSetName] = set.From("10.0.0.0/16", "10.7.0.0/16")
+			update("10.4.0.0/16")
+			ipsets.UpdateMembersFromTrie(ipSetID, trie.Snapshot())
+			resyncAndApply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.0.0.0/16", "10.1.0.0/24", "10.1.1.1/32", "10.4.0.0/16"},
+			})
+		})
+
+		It("should take a snapshot of a mutable trie", func() {
+			update("10.4.0.0/16")
+			ipsets.UpdateMembersFromTrie(ipSetID, trie)
+			// Modifying the trie afterwards shouldn't affect what gets programmed.
+			update("10.5.0.0/16")
+			apply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.0.0.0/16", "10.1.0.0/24", "10.1.1.1/32", "10.4.0.0/16"},
+			})
+		})
+
+		It("should support hash:ip IP sets", func() {
+			ipsets.AddOrReplaceIPSet(meta2, nil)
+			ipTrie := ip.NewTrie[struct{}]()
+			ipTrie.Update(ip.MustParseCIDROrIP("10.0.0.1"), struct{}{})
+			ipTrie.Update(ip.MustParseCIDROrIP("10.0.0.2"), struct{}{})
+			ipTrie.Update(ip.MustParseCIDROrIP("10.0.1.0/24"), struct{}{}) // Not valid for hash:ip.
+			ipsets.UpdateMembersFromTrie(ipSetID2, ipTrie.Snapshot())
+			apply()
+			ipTrie.Delete(ip.MustParseCIDROrIP("10.0.0.1"))
+			ipsets.UpdateMembersFromTrie(ipSetID2, ipTrie.Snapshot())
+			apply()
+			Expect(dataplane.IPSetMembers[v4MainIPSetName2]).To(Equal(set.From("10.0.0.2")))
+		})
+	})
+
+	Describe("with a membership check queued", func() {
+		BeforeEach(func() {
+			ipsets.AddOrReplaceIPSet(meta, v4Members1And2)
+			ipsets.AddOrReplaceIPSet(meta2, []string{"10.0.0.3"})
+			apply()
+			dataplane.CmdNames = nil
+			dataplane.RestoreLines = nil
+			ipsets.QueueMembershipCheck()
+		})
+
+		It("should do nothing if the IP sets are in sync", func() {
+			apply()
+			Expect(dataplane.CmdNames).To(Equal([]string{"list"}))
+		})
+
+		It("should only rewrite the IP set that was modified", func() {
+			dataplane.IPSetMembers[v4MainIPSetName] = set.From("10.0.0.1", "10.0.0.4")
+			apply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName:  v4Members1And2,
+				v4MainIPSetName2: {"10.0.0.3"},
+			})
+			Expect(dataplane.RestoreLines).To(ContainElement("swap " + v4MainIPSetName + " " + v4TempIPSetName2))
+			for _, line := range dataplane.RestoreLines {
+				Expect(line).NotTo(ContainSubstring(v4MainIPSetName2))
+			}
+		})
+
+		It("should spot an extra member", func() {
+			dataplane.IPSetMembers[v4MainIPSetName2].Add("10.0.0.4")
+			apply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName:  v4Members1And2,
+				v4MainIPSetName2: {"10.0.0.3"},
+			})
+		})
+
+		It("should recreate an IP set that was deleted", func() {
+			delete(dataplane.IPSetMembers, v4MainIPSetName2)
+			apply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName:  v4Members1And2,
+				v4MainIPSetName2: {"10.0.0.3"},
+			})
+		})
+
+		It("should skip IP sets with pending updates", func() {
+			dataplane.IPSetMembers[v4MainIPSetName] = set.From("10.0.0.1", "10.0.0.4")
+			ipsets.AddMembers(ipSetID, []string{"10.0.0.5"})
+			apply()
+			Expect(dataplane.RestoreLines).To(Equal([]string{"add " + v4MainIPSetName + " 10.0.0.5"}))
+		})
+
+		It("should be retried after a failure", func() {
+			dataplane.IPSetMembers[v4MainIPSetName] = set.From("10.0.0.1")
+			dataplane.ListOpFailures = []string{"rc"}
+			apply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName:  v4Members1And2,
+				v4MainIPSetName2: {"10.0.0.3"},
+			})
+		})
+	})
+
+	Describe("with sharding enabled", func() {
+		const (
+			shardedMainName = "cali4ls:qMt7iLlGDhvLnCjM0l9nz"
+			shard0Name      = "cali4p00s:qMt7iLlGDhvLnCjM0l9nz"
+			shardMaxSize    = 10
+		)
+		shardedMeta := IPSetMetadata{
+			MaxSize: shardMaxSize,
+			SetID:   ipSetID,
+			Type:    IPSetTypeHashIP,
+		}
+		shardedConf := NewIPVersionConfig(
+			IPFamilyV4,
+			"cali",
+			rules.AllHistoricIPSetNamePrefixes,
+			rules.LegacyV4IPSetNames,
+			WithShardedIPSets(),
+		)
+		ipsRange := func(first, last int) (members []string) {
+			for i := first; i <= last; i++ {
+				members = append(members, fmt.Sprintf("10.0.0.%d", i))
+			}
+			return
+		}
+		// expectSharded checks that the main IP set lists shards that, between them, hold exactly
+		// the expected members, and returns the contents of each shard.
+		expectSharded := func(members []string) map[string]set.Set[string] {
+			ExpectWithOffset(1, dataplane.IPSetMetadata[shardedMainName].Type).To(Equal(IPSetType("list:set")))
+			shards := map[string]set.Set[string]{}
+			allMembers := set.New[string]()
+			dataplane.IPSetMembers[shardedMainName].Iter(func(shardName string) error {
+				shardMembers := dataplane.IPSetMembers[shardName]
+				ExpectWithOffset(1, shardMembers).NotTo(BeNil(), "listed shard doesn't exist")
+				ExpectWithOffset(1, shardMembers.Len()).To(BeNumerically("<=", shardMaxSize))
+				shardMembers.Iter(func(m string) error {
+					ExpectWithOffset(1, allMembers.Contains(m)).To(BeFalse(), "member in more than one shard")
+					allMembers.Add(m)
+					return nil
+				})
+				shards[shardName] = shardMembers.Copy()
+				return nil
+			})
+			ExpectWithOffset(1, allMembers).To(Equal(set.FromArray(members)))
+			return shards
+		}
+
+		BeforeEach(func() {
+			ipsets = NewIPSetsWithShims(
+				shardedConf,
+				logutils.NewSummarizer("test loop"),
+				dataplane.newCmd,
+				dataplane.sleep,
+			)
+		})
+
+		It("should use shorter main IP set names", func() {
+			Expect(shardedConf.NameForMainIPSet(ipSetID)).To(Equal(shardedMainName))
+		})
+
+		It("should put a small IP set in a single shard", func() {
+			ipsets.AddOrReplaceIPSet(shardedMeta, ipsRange(1, 5))
+			apply()
+			Expect(dataplane.IPSetMembers[shardedMainName]).To(Equal(set.From(shard0Name)))
+			expectSharded(ipsRange(1, 5))
+		})
+
+		It("should split an IP set that exceeds its maximum size", func() {
+			ipsets.AddOrReplaceIPSet(shardedMeta, ipsRange(1, 25))
+			apply()
+			Expect(len(expectSharded(ipsRange(1, 25)))).To(BeNumerically(">=", 4))
+		})
+
+		It("should collect the members of all shards in debug info", func() {
+			ipsets.AddOrReplaceIPSet(shardedMeta, ipsRange(1, 25))
+			apply()
+			infos, err := ipsets.DebugInfo(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(infos).To(HaveLen(1))
+			Expect(infos[0].Dataplane).To(ConsistOf(ipsRange(1, 25)))
+			Expect(infos[0].Missing).To(BeEmpty())
+			Expect(infos[0].Unexpected).To(BeEmpty())
+		})
+
+		Describe("with a sharded IP set", func() {
+			var shards map[string]set.Set[string]
+
+			BeforeEach(func() {
+				ipsets.AddOrReplaceIPSet(shardedMeta, ipsRange(1, 25))
+				apply()
+				shards = expectSharded(ipsRange(1, 25))
+				dataplane.CmdNames = nil
+				dataplane.RestoreLines = nil
+			})
+
+			It("should write small deltas to the right shard", func() {
+				ipsets.AddMembers(ipSetID, []string{"10.0.0.26"})
+				apply()
+				Expect(dataplane.RestoreLines).To(HaveLen(1))
+				Expect(dataplane.RestoreLines[0]).To(MatchRegexp(`^add cali4p\d\ds:qMt7iLlGDhvLnCjM0l9nz 10\.0\.0\.26$`))
+				expectSharded(ipsRange(1, 26))
+			})
+
+			It("should reshard as the IP set grows", func() {
+				ipsets.AddMembers(ipSetID, ipsRange(26, 60))
+				apply()
+				Expect(len(expectSharded(ipsRange(1, 60)))).To(BeNumerically(">", len(shards)))
+			})
+
+			It("should reshard as the IP set shrinks and clean up the old shards", func() {
+				ipsets.RemoveMembers(ipSetID, ipsRange(3, 25))
+				apply()
+				expectSharded(ipsRange(1, 2))
+				Expect(dataplane.IPSetMembers[shardedMainName]).To(Equal(set.From(shard0Name)))
+				for shardName := range shards {
+					if shardName != shard0Name {
+						Expect(dataplane.IPSetMembers).NotTo(HaveKey(shardName))
+					}
+				}
+			})
+
+			It("should do nothing on resync if the IP set is in sync", func() {
+				resyncAndApply()
+				Expect(dataplane.CmdNames).To(Equal([]string{"list"}))
+			})
+
+			It("should fix a member that is in the wrong shard", func() {
+				var from, to string
+				for shardName := range shards {
+					if from == "" {
+						from = shardName
+					} else {
+						to = shardName
+						break
+					}
+				}
+				moved := shards[from].Slice()[0]
+				dataplane.IPSetMembers[from].Discard(moved)
+				dataplane.IPSetMembers[to].Add(moved)
+				resyncAndApply()
+				Expect(expectSharded(ipsRange(1, 25))).To(Equal(shards))
+			})
+
+			It("should recreate a shard that was deleted", func() {
+				dataplane.IPSetMembers[shardedMainName].Discard(shard0Name)
+				delete(dataplane.IPSetMembers, shard0Name)
+				resyncAndApply()
+				Expect(expectSharded(ipsRange(1, 25))).To(Equal(shards))
+			})
+
+			It("should spot a modified shard in a membership check", func() {
+				ipsets.QueueMembershipCheck()
+				apply()
+				Expect(dataplane.CmdNames).To(Equal([]string{"list"}))
+
+				dataplane.IPSetMembers[shard0Name].Add("10.0.1.1")
+				ipsets.QueueMembershipCheck()
+				apply()
+				Expect(expectSharded(ipsRange(1, 25))).To(Equal(shards))
+			})
+
+			It("should delete the shards along with the IP set", func() {
+				ipsets.RemoveIPSet(ipSetID)
+				apply()
+				dataplane.ExpectMembers(map[string][]string{})
+			})
+		})
+	})
+
+	Describe("metrics", func() {
+		getMetric := func(name string, labels map[string]string) (float64, bool) {
+			families, err := prometheus.DefaultGatherer.Gather()
+			Expect(err).NotTo(HaveOccurred())
+			for _, mf := range families {
+				if mf.GetName() != name {
+					continue
+				}
+			metrics:
+				for _, m := range mf.GetMetric() {
+					for _, lp := range m.GetLabel() {
+						if v, ok := labels[lp.GetName()]; ok && v != lp.GetValue() {
+							continue metrics
+						}
+					}
+					if m.GetGauge() != nil {
+						return m.GetGauge().GetValue(), true
+					}
+					return m.GetCounter().GetValue(), true
+				}
+			}
+			return 0, false
+		}
+		membersMetric := func(setName string) (float64, bool) {
+			return getMetric("felix_ipset_members", map[string]string{"ip_version": "inet", "ipset": setName})
+		}
+
+		BeforeEach(func() {
+			ipsets.AddOrReplaceIPSet(meta, v4Members1And2)
+			apply()
+		})
+
+		It("should track the number of members of each IP set", func() {
+			numMembers, ok := membersMetric(v4MainIPSetName)
+			Expect(ok).To(BeTrue())
+			Expect(numMembers).To(Equal(2.0))
+			ipsets.AddMembers(ipSetID, []string{"10.0.0.3"})
+			apply()
+			numMembers, _ = membersMetric(v4MainIPSetName)
+			Expect(numMembers).To(Equal(3.0))
+		})
+
+		It("should remove the member count when the IP set is deleted", func() {
+			ipsets.RemoveIPSet(ipSetID)
+			apply()
+			_, ok := membersMetric(v4MainIPSetName)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should count failed restores", func() {
+			labels := map[string]string{"ip_version": "inet", "operation": "restore"}
+			before, _ := getMetric("felix_ipset_failures", labels)
+			dataplane.FailAllRestores = true
+			ipsets.AddMembers(ipSetID, []string{"10.0.0.3"})
+			Expect(func() { apply() }).To(Panic())
+			after, _ := getMetric("felix_ipset_failures", labels)
+			Expect(after).To(BeNumerically(">", before))
+		})
+	})
+
+	Describe("in dry-run mode", func() {
+		var out *bytes.Buffer
+
+		BeforeEach(func() {
+			out = &bytes.Buffer{}
+			ipsets = NewIPSetsWithShims(
+				v4VersionConf,
+				logutils.NewSummarizer("test loop"),
+				dataplane.newCmd,
+				dataplane.sleep,
+				WithDryRun(out),
+			)
+			ipsets.AddOrReplaceIPSet(meta, []string{"10.0.0.1"})
+			apply()
+		})
+
+		It("should only list the dataplane", func() {
+			Expect(dataplane.CmdNames).To(Equal([]string{"list"}))
+			dataplane.ExpectMembers(map[string][]string{})
+		})
+
+		It("should export the restore input", func() {
+			Expect(out.String()).To(Equal("ipset restore <<'EOF'\n" +
+				"create " + v4MainIPSetName + " hash:ip family inet maxelem 1234\n" +
+				"create " + v4TempIPSetName0 + " hash:ip family inet maxelem 1234\n" +
+				"add " + v4TempIPSetName0 + " 10.0.0.1\n" +
+				"swap " + v4MainIPSetName + " " + v4TempIPSetName0 + "\n" +
+				"destroy " + v4TempIPSetName0 + "\n" +
+				"COMMIT\n" +
+				"EOF\n"))
+		})
+
+		It("should only export incremental changes", func() {
+			out.Reset()
+			ipsets.AddMembers(ipSetID, []string{"10.0.0.3"})
+			ipsets.RemoveMembers(ipSetID, []string{"10.0.0.1"})
+			apply()
+			Expect(out.String()).To(Equal("ipset restore <<'EOF'\n" +
+				"del " + v4MainIPSetName + " 10.0.0.1 --exist\n" +
+				"add " + v4MainIPSetName + " 10.0.0.3\n" +
+				"COMMIT\n" +
+				"EOF\n"))
+			Expect(dataplane.CmdNames).To(Equal([]string{"list"}))
+		})
+
+		It("should export deletions", func() {
+			out.Reset()
+			ipsets.RemoveIPSet(ipSetID)
+			apply()
+			Expect(out.String()).To(Equal("ipset destroy " + v4MainIPSetName + "\n"))
+			Expect(dataplane.CmdNames).To(Equal([]string{"list"}))
+		})
+	})
+
+	Describe("with swap-only IP sets", func() {
+		BeforeEach(func() {
+			ipsets.AddOrReplaceIPSet(meta, v4Members1And2)
+			ipsets.AddOrReplaceIPSet(meta2, v4Members1And2)
+			ipsets.SetSwapOnlyIPSets(set.From(ipSetID))
+			apply()
+			dataplane.RestoreLines = nil
+		})
+
+		It("should swap in a replacement for a mixed update", func() {
+			ipsets.AddMembers(ipSetID, []string{"10.0.0.3"})
+			ipsets.RemoveMembers(ipSetID, []string{"10.0.0.1"})
+			apply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName:  {"10.0.0.2", "10.0.0.3"},
+				v4MainIPSetName2: v4Members1And2,
+			})
+			Expect(dataplane.RestoreLines).To(ContainElement(HavePrefix("swap " + v4MainIPSetName + " ")))
+			Expect(dataplane.RestoreLines).NotTo(ContainElement(HavePrefix("del ")))
+		})
+
+		It("should apply pure additions as deltas", func() {
+			ipsets.AddMembers(ipSetID, []string{"10.0.0.3"})
+			apply()
+			Expect(dataplane.RestoreLines).To(Equal([]string{"add " + v4MainIPSetName + " 10.0.0.3"}))
+		})
+
+		It("should apply mixed updates to other IP sets as deltas", func() {
+			ipsets.AddMembers(ipSetID2, []string{"10.0.0.3"})
+			ipsets.RemoveMembers(ipSetID2, []string{"10.0.0.1"})
+			apply()
+			Expect(dataplane.RestoreLines).To(ConsistOf(
+				"del "+v4MainIPSetName2+" 10.0.0.1 --exist",
+				"add "+v4MainIPSetName2+" 10.0.0.3",
+			))
+		})
+
+		It("should swap all IP sets with WithAlwaysSwap", func() {
+			ipsets = NewIPSetsWithShims(
+				v4VersionConf,
+				logutils.NewSummarizer("test loop"),
+				dataplane.newCmd,
+				dataplane.sleep,
+				WithAlwaysSwap(),
+			)
+			ipsets.AddOrReplaceIPSet(meta2, v4Members1And2)
+			apply()
+			dataplane.RestoreLines = nil
+			ipsets.AddMembers(ipSetID2, []string{"10.0.0.3"})
+			ipsets.RemoveMembers(ipSetID2, []string{"10.0.0.1"})
+			apply()
+			dataplane.ExpectMembers(map[string][]string{v4MainIPSetName2: {"10.0.0.2", "10.0.0.3"}})
+			Expect(dataplane.RestoreLines).To(ContainElement(HavePrefix("swap " + v4MainIPSetName2 + " ")))
+		})
+	})
+
+	Describe("debug info", func() {
+		BeforeEach(func() {
+			ipsets.AddOrReplaceIPSet(meta, v4Members1And2)
+			apply()
+		})
+
+		It("should report an in-sync IP set", func() {
+			infos, err := ipsets.DebugInfo([]string{ipSetID, "unknown"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(infos).To(Equal([]*IPSetDebugInfo{{
+				SetID:         ipSetID,
+				Family:        IPFamilyV4,
+				Type:          IPSetTypeHashIP,
+				MainIPSetName: v4MainIPSetName,
+				Desired:       v4Members1And2,
+				Dataplane:     v4Members1And2,
+				Missing:       []string{},
+				Unexpected:    []string{},
+			}}))
+		})
+
+		It("should report differences from the dataplane", func() {
+			dataplane.IPSetMembers[v4MainIPSetName] = set.From("10.0.0.1", "10.0.0.9")
+			ipsets.AddMembers(ipSetID, []string{"10.0.0.3"})
+			infos, err := ipsets.DebugInfo(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(infos).To(HaveLen(1))
+			Expect(infos[0].Desired).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}))
+			Expect(infos[0].Dataplane).To(Equal([]string{"10.0.0.1", "10.0.0.9"}))
+			Expect(infos[0].Missing).To(Equal([]string{"10.0.0.2", "10.0.0.3"}))
+			Expect(infos[0].Unexpected).To(Equal([]string{"10.0.0.9"}))
+			Expect(infos[0].Pending).To(BeTrue())
+		})
+
+		It("should report an IP set that is missing from the dataplane", func() {
+			delete(dataplane.IPSetMembers, v4MainIPSetName)
+			infos, err := ipsets.DebugInfo([]string{ipSetID})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(infos[0].Dataplane).To(BeNil())
+			Expect(infos[0].Missing).To(Equal(v4Members1And2))
+		})
+	})
+
+	Describe("with automatic resizing", func() {
+		resizeMeta := IPSetMetadata{
+			MaxSize: 10,
+			SetID:   ipSetID,
+			Type:    IPSetTypeHashIP,
+		}
+		ipsRange := func(first, last int) (members []string) {
+			for i := first; i <= last; i++ {
+				members = append(members, fmt.Sprintf("10.0.0.%d", i))
+			}
+			return
+		}
+
+		BeforeEach(func() {
+			ipsets = NewIPSetsWithShims(
+				v4VersionConf,
+				logutils.NewSummarizer("test loop"),
+				dataplane.newCmd,
+				dataplane.sleep,
+				WithAutoResize(40),
+			)
+		})
+
+		It("should keep the configured size while there's headroom", func() {
+			ipsets.AddOrReplaceIPSet(resizeMeta, ipsRange(1, 9))
+			apply()
+			dataplane.ExpectMembers(map[string][]string{v4MainIPSetName: ipsRange(1, 9)})
+			Expect(dataplane.IPSetMetadata[v4MainIPSetName].MaxSize).To(Equal(10))
+		})
+
+		It("should grow an IP set that's created close to its maximum size", func() {
+			ipsets.AddOrReplaceIPSet(resizeMeta, ipsRange(1, 10))
+			apply()
+			dataplane.ExpectMembers(map[string][]string{v4MainIPSetName: ipsRange(1, 10)})
+			Expect(dataplane.IPSetMetadata[v4MainIPSetName].MaxSize).To(Equal(20))
+		})
+
+		It("should swap in a larger IP set when deltas approach the maximum size", func() {
+			ipsets.AddOrReplaceIPSet(resizeMeta, ipsRange(1, 5))
+			apply()
+			ipsets.AddMembers(ipSetID, ipsRange(6, 12))
+			dataplane.RestoreLines = nil
+			apply()
+			dataplane.ExpectMembers(map[string][]string{v4MainIPSetName: ipsRange(1, 12)})
+			Expect(dataplane.IPSetMetadata[v4MainIPSetName].MaxSize).To(Equal(20))
+			Expect(dataplane.RestoreLines).To(ContainElement("swap " + v4MainIPSetName + " " + v4TempIPSetName1))
+
+			// Further deltas that fit are applied directly.
+			ipsets.AddMembers(ipSetID, []string{"10.0.0.13"})
+			dataplane.RestoreLines = nil
+			apply()
+			Expect(dataplane.RestoreLines).To(Equal([]string{"add " + v4MainIPSetName + " 10.0.0.13"}))
+		})
+
+		It("should not grow an IP set beyond the limit", func() {
+			ipsets.AddOrReplaceIPSet(resizeMeta, ipsRange(1, 38))
+			apply()
+			dataplane.ExpectMembers(map[string][]string{v4MainIPSetName: ipsRange(1, 38)})
+			Expect(dataplane.IPSetMetadata[v4MainIPSetName].MaxSize).To(Equal(40))
+		})
+	})
+
+	Describe("with a small number of restore lines per commit", func() {
+		BeforeEach(func() {
+			ipsets = NewIPSetsWithShims(
+				v4VersionConf,
+				logutils.NewSummarizer("test loop"),
+				dataplane.newCmd,
+				dataplane.sleep,
+				WithMaxRestoreLinesPerCommit(3),
+			)
+		})
+
+		It("should split the restore input with intermediate COMMITs", func() {
+			members := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}
+			ipsets.AddOrReplaceIPSet(meta, members)
+			apply()
+			dataplane.ExpectMembers(map[string][]string{v4MainIPSetName: members})
+			// create main, create temp, 5 adds, swap, destroy temp: 9 lines, so there are
+			// COMMITs after the third and sixth lines, plus the final one.
+			Expect(dataplane.RestoreLines).To(HaveLen(9))
+			Expect(dataplane.NumRestoreCommits).To(Equal(3))
+		})
+	})
+
+	It("should stream a large update to the dataplane in batches", func() {
+		var members []string
+		for i := 0; i < 12000; i++ {
+			members = append(members, fmt.Sprintf("10.%d.%d.%d", i>>16, (i>>8)&0xff, i&0xff))
+		}
+		meta := meta
+		meta.MaxSize = 65536
+		ipsets.AddOrReplaceIPSet(meta, members)
+		apply()
+		dataplane.ExpectMembers(map[string][]string{v4MainIPSetName: members})
+		Expect(dataplane.NumRestoreCommits).To(Equal(2))
+	})
+
+	Describe("with CIDR aggregation enabled", func() {
+		metaAgg := IPSetMetadata{
+			MaxSize:        1234,
+			SetID:          ipSetID,
+			Type:           IPSetTypeHashNet,
+			AggregateCIDRs: true,
+		}
+
+		BeforeEach(func() {
+			ipsets.AddOrReplaceIPSet(metaAgg, []string{
+				"10.0.0.0/32", "10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32", // Merge to a /30.
+				"10.1.0.0/16", "10.1.2.0/24", // /24 is contained in the /16.
+				"10.2.0.1/32",
+			})
+			apply()
+		})
+
+		It("should program the aggregated members", func() {
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.0.0.0/30", "10.1.0.0/16", "10.2.0.1/32"},
+			})
+		})
+
+		It("should report the unaggregated members", func() {
+			Expect(ipsets.GetMembers(ipSetID)).To(Equal(set.From(
+				"10.0.0.0/32", "10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32",
+				"10.1.0.0/16", "10.1.2.0/24", "10.2.0.1/32",
+			)))
+		})
+
+		It("should report the unaggregated members by name", func() {
+			members, ok := ipsets.GetMembersByName(v4MainIPSetName)
+			Expect(ok).To(BeTrue())
+			Expect(members).To(ConsistOf(
+				"10.0.0.0/32", "10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32",
+				"10.1.0.0/16", "10.1.2.0/24", "10.2.0.1/32",
+			))
+			_, ok = ipsets.GetMembersByName("cali40unknown")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should re-aggregate after deltas, writing only the changes", func() {
+			dataplane.RestoreLines = nil
+			ipsets.AddMembers(ipSetID, []string{"10.2.0.0/32"})
+			ipsets.RemoveMembers(ipSetID, []string{"10.1.0.0/16"})
+			apply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.0.0.0/30", "10.1.2.0/24", "10.2.0.0/31"},
+			})
+			Expect(dataplane.RestoreLines).To(ConsistOf(
+				"del "+v4MainIPSetName+" 10.1.0.0/16 --exist",
+				"del "+v4MainIPSetName+" 10.2.0.1/32 --exist",
+				"add "+v4MainIPSetName+" 10.1.2.0/24",
+				"add "+v4MainIPSetName+" 10.2.0.0/31",
+			))
+		})
+
+		It("should not write anything if the aggregate doesn't change", func() {
+			dataplane.RestoreLines = nil
+			ipsets.AddMembers(ipSetID, []string{"10.1.3.0/24"})
+			apply()
+			Expect(dataplane.RestoreLines).To(BeEmpty())
+		})
+
+		It("should aggregate members from a trie", func() {
+			trie := ip.NewTrie[struct{}]()
+			trie.Update(ip.MustParseCIDROrIP("10.3.0.0/25"), struct{}{})
+			trie.Update(ip.MustParseCIDROrIP("10.3.0.128/25"), struct{}{})
+			ipsets.UpdateMembersFromTrie(ipSetID, trie.Snapshot())
+			apply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.3.0.0/24"},
+			})
+			trie.Delete(ip.MustParseCIDROrIP("10.3.0.0/25"))
+			ipsets.UpdateMembersFromTrie(ipSetID, trie.Snapshot())
+			apply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.3.0.128/25"},
+			})
+		})
+
+		It("should resync against the aggregated members", func() {
+			dataplane.IPSetMembers[v4MainIPSetName] = set.From("10.0.0.0/30", "10.1.2.0/24")
+			resyncAndApply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.0.0.0/30", "10.1.0.0/16", "10.2.0.1/32"},
+			})
+		})
+
+		It("should be ignored for hash:ip IP sets", func() {
+			ipsets.AddOrReplaceIPSet(IPSetMetadata{
+				MaxSize:        1234,
+				SetID:          ipSetID2,
+				Type:           IPSetTypeHashIP,
+				AggregateCIDRs: true,
+			}, []string{"10.0.0.0", "10.0.0.1"})
+			apply()
+			Expect(dataplane.IPSetMembers[v4MainIPSetName2]).To(Equal(set.From("10.0.0.0", "10.0.0.1")))
+		})
+	})
+
+	Describe("with port-based net IP sets", func() {
+		metaNetPort := IPSetMetadata{
+			MaxSize: 1234,
+			SetID:   ipSetID,
+			Type:    IPSetTypeHashNetPort,
+		}
+		metaIPPortNet := IPSetMetadata{
+			MaxSize: 1234,
+			SetID:   ipSetID2,
+			Type:    IPSetTypeHashIPPortNet,
+		}
+
+		BeforeEach(func() {
+			ipsets.AddOrReplaceIPSet(metaNetPort, []string{"10.0.0.0/24,tcp:80", "10.0.1.1/32,udp:53"})
+			ipsets.AddOrReplaceIPSet(metaIPPortNet, []string{"10.0.0.1,tcp:80,10.1.0.0/16"})
+			apply()
+		})
+
+		It("should program the members", func() {
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName:  {"10.0.0.0/24,tcp:80", "10.0.1.1/32,udp:53"},
+				v4MainIPSetName2: {"10.0.0.1,tcp:80,10.1.0.0/16"},
+			})
+			Expect(dataplane.IPSetMetadata[v4MainIPSetName].Type).To(Equal(IPSetTypeHashNetPort))
+			Expect(dataplane.IPSetMetadata[v4MainIPSetName2].Type).To(Equal(IPSetTypeHashIPPortNet))
+		})
+
+		It("should accept the 'ipset list' form of full-length CIDRs on resync", func() {
+			dataplane.IPSetMembers[v4MainIPSetName] = set.From("10.0.0.0/24,tcp:80", "10.0.1.1,udp:53")
+			dataplane.IPSetMembers[v4MainIPSetName2] = set.From("10.0.0.1,tcp:80,10.1.0.0/16")
+			dataplane.RestoreLines = nil
+			resyncAndApply()
+			Expect(dataplane.RestoreLines).To(BeEmpty())
+		})
+
+		It("should fix inconsistencies on resync", func() {
+			dataplane.IPSetMembers[v4MainIPSetName] = set.From("10.0.0.0/24,tcp:81")
+			dataplane.IPSetMembers[v4MainIPSetName2] = set.From("10.0.0.1,tcp:80,10.1.0.0/16", "10.0.0.1,tcp:80,10.2.0.0/16")
+			resyncAndApply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName:  {"10.0.0.0/24,tcp:80", "10.0.1.1/32,udp:53"},
+				v4MainIPSetName2: {"10.0.0.1,tcp:80,10.1.0.0/16"},
+			})
+		})
+	})
+
+	Describe("with net,iface IP sets", func() {
+		metaNetIface := IPSetMetadata{
+			MaxSize: 1234,
+			SetID:   ipSetID,
+			Type:    IPSetTypeHashNetIface,
+		}
+
+		BeforeEach(func() {
+			ipsets.AddOrReplaceIPSet(metaNetIface, []string{"10.0.0.0/24,eth0", "10.0.1.1/32,eth1", "feed::/64,eth0"})
+			apply()
+		})
+
+		It("should program the IPv4 members", func() {
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.0.0.0/24,eth0", "10.0.1.1/32,eth1"},
+			})
+			Expect(dataplane.IPSetMetadata[v4MainIPSetName].Type).To(Equal(IPSetTypeHashNetIface))
+		})
+
+		It("should accept the 'ipset list' form of full-length CIDRs on resync", func() {
+			dataplane.IPSetMembers[v4MainIPSetName] = set.From("10.0.0.0/24,eth0", "10.0.1.1,eth1")
+			dataplane.RestoreLines = nil
+			resyncAndApply()
+			Expect(dataplane.RestoreLines).To(BeEmpty())
+		})
+
+		It("should apply deltas", func() {
+			ipsets.AddMembers(ipSetID, []string{"10.0.0.0/24,eth1"})
+			ipsets.RemoveMembers(ipSetID, []string{"10.0.0.0/24,eth0"})
+			apply()
+			dataplane.ExpectMembers(map[string][]string{
+				v4MainIPSetName: {"10.0.0.0/24,eth1", "10.0.1.1/32,eth1"},
+			})
+		})
+	})
+
 	It("remove set before apply should be no-op", func() {
 		// This checks that the dirty flag is set by the remove method.
 		ipsets.AddOrReplaceIPSet(meta, []string{"10.0.0.1", "10.0.0.2"})
@@ -0,0 +1,126 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipsets
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+const (
+	// restoreBatchSize is the size at which pipelinedWriter hands a batch of input to its
+	// background goroutine.
+	restoreBatchSize = 256 * 1024
+	// restorePipelineDepth is the number of batches that can be queued for the background
+	// goroutine before rendering blocks.
+	restorePipelineDepth = 4
+	// defaultMaxRestoreLinesPerCommit is the default number of lines that we write to ipset
+	// restore between COMMITs.
+	defaultMaxRestoreLinesPerCommit = 10000
+)
+
+// pipelinedWriter is an io.Writer that collects writes into batches and hands each full batch to a
+// background goroutine, which writes it to the underlying writer.  This lets us carry on rendering
+// the input to a restore command while the command is still consuming earlier batches, instead of
+// blocking every time the pipe to the command is full.
+//
+// Errors from the underlying writer are returned by subsequent calls to Write and by Close.  Once
+// the underlying writer has failed, later batches are discarded.
+type pipelinedWriter struct {
+	w         io.Writer
+	batchSize int
+
+	buf    *bytes.Buffer
+	batchC chan *bytes.Buffer
+	freeC  chan *bytes.Buffer
+	doneC  chan struct{}
+
+	lock sync.Mutex
+	err  error
+}
+
+func newPipelinedWriter(w io.Writer, batchSize, depth int) *pipelinedWriter {
+	p := &pipelinedWriter{
+		w:         w,
+		batchSize: batchSize,
+		batchC:    make(chan *bytes.Buffer, depth),
+		freeC:     make(chan *bytes.Buffer, depth+1),
+		doneC:     make(chan struct{}),
+	}
+	p.buf = p.newBuf()
+	go p.loopWritingBatches()
+	return p
+}
+
+func (p *pipelinedWriter) Write(b []byte) (int, error) {
+	if err := p.loadErr(); err != nil {
+		return 0, err
+	}
+	// Writes to a bytes.Buffer can't fail.
+	n, _ := p.buf.Write(b)
+	if p.buf.Len() >= p.batchSize {
+		p.batchC <- p.buf
+		p.buf = p.newBuf()
+	}
+	return n, nil
+}
+
+// Close sends any partial batch to the background goroutine and waits for all the batches to be
+// written.  It returns the first error from the underlying writer, which it does not close.
+func (p *pipelinedWriter) Close() error {
+	if p.buf.Len() > 0 {
+		p.batchC <- p.buf
+	}
+	p.buf = nil
+	close(p.batchC)
+	<-p.doneC
+	return p.loadErr()
+}
+
+func (p *pipelinedWriter) newBuf() *bytes.Buffer {
+	select {
+	case buf := <-p.freeC:
+		return buf
+	default:
+		buf := &bytes.Buffer{}
+		buf.Grow(p.batchSize)
+		return buf
+	}
+}
+
+func (p *pipelinedWriter) loopWritingBatches() {
+	defer close(p.doneC)
+	for buf := range p.batchC {
+		if p.loadErr() == nil {
+			if _, err := p.w.Write(buf.Bytes()); err != nil {
+				p.lock.Lock()
+				p.err = err
+				p.lock.Unlock()
+			}
+		}
+		buf.Reset()
+		select {
+		case p.freeC <- buf:
+		default:
+		}
+	}
+}
+
+func (p *pipelinedWriter) loadErr() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.err
+}
@@ -0,0 +1,353 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipsets
+
+import (
+	"math"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+)
+
+// When sharding is enabled, each of our main IP sets is a list:set whose members are "shard" IP
+// sets.  The shards have the type and maximum size of the IP set and, between them, hold its
+// members.  Each member is assigned to a shard using a consistent hash so that the shard holding
+// a member can be calculated, rather than stored.
+
+const (
+	// maxIPSetShards is the maximum number of shards that an IP set can be split into.  It is
+	// also the size of the list:sets.
+	maxIPSetShards = 64
+	// shardTargetFill is the fraction of their maximum size that the shards are filled to when
+	// an IP set is (re)sharded, leaving room for the IP set to grow.
+	shardTargetFill = 0.75
+	// shardShrinkFill is the fraction below which the shards would need to be filled, with one
+	// fewer shard, before we reshard an IP set that has shrunk.
+	shardShrinkFill = 0.4
+)
+
+// ipSetShard identifies one of the shards of an IP set.
+type ipSetShard struct {
+	ipSet *ipSet
+	index int
+}
+
+// shardForMember returns the index of the shard that holds the given member, for an IP set that
+// has numShards shards.  It uses jump consistent hashing so that, when the number of shards
+// grows, members only move into the new shards (and, when it shrinks, out of the removed ones).
+func shardForMember(m IPSetMember, numShards int) int {
+	key := hashMember(m)
+	var b, j int64 = -1, 0
+	for j < int64(numShards) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// partitionMembers splits the given members into shards.  It uses the fewest shards (starting
+// from the number needed to reach shardTargetFill) for which no shard exceeds maxSize.
+func partitionMembers(members set.Set[IPSetMember], maxSize int) []set.Set[IPSetMember] {
+	numShards := int(math.Ceil(float64(members.Len()) / (float64(maxSize) * shardTargetFill)))
+	if numShards < 1 {
+		numShards = 1
+	} else if numShards > maxIPSetShards {
+		numShards = maxIPSetShards
+	}
+	for {
+		shards := make([]set.Set[IPSetMember], numShards)
+		for i := range shards {
+			shards[i] = set.New[IPSetMember]()
+		}
+		overflow := false
+		members.Iter(func(m IPSetMember) error {
+			shard := shards[shardForMember(m, numShards)]
+			shard.Add(m)
+			if shard.Len() > maxSize && numShards < maxIPSetShards {
+				overflow = true
+				return set.StopIteration
+			}
+			return nil
+		})
+		if !overflow {
+			return shards
+		}
+		numShards++
+	}
+}
+
+// shardsFitDeltas returns true if the IP set's pending deltas can be applied to its current
+// shards; that is, if no shard would overflow and the IP set doesn't need fewer shards.
+func (s *ipSet) shardsFitDeltas() bool {
+	if s.numShards == 0 {
+		return false
+	}
+	sizes := append([]int(nil), s.shardSizes...)
+	numMembers := s.members.Len()
+	s.pendingDeletions.Iter(func(m IPSetMember) error {
+		if s.members.Contains(m) {
+			sizes[shardForMember(m, s.numShards)]--
+			numMembers--
+		}
+		return nil
+	})
+	fits := true
+	s.pendingAdds.Iter(func(m IPSetMember) error {
+		if s.members.Contains(m) {
+			return nil
+		}
+		shard := shardForMember(m, s.numShards)
+		sizes[shard]++
+		numMembers++
+		if sizes[shard] > s.MaxSize && s.numShards < maxIPSetShards {
+			fits = false
+			return set.StopIteration
+		}
+		return nil
+	})
+	if !fits {
+		return false
+	}
+	return s.numShards == 1 ||
+		float64(numMembers) >= float64((s.numShards-1)*s.MaxSize)*shardShrinkFill
+}
+
+// shardToRewrite returns an ipSet representing one of the shards of the IP set, which is to be
+// rewritten with the given members.
+func (s *ipSet) shardToRewrite(shardName string, members set.Set[IPSetMember]) *ipSet {
+	return &ipSet{
+		IPSetMetadata:  s.IPSetMetadata,
+		MainIPSetName:  shardName,
+		pendingReplace: members,
+	}
+}
+
+// shardedSetNameFor returns the name of the IP set that holds (or will hold) the given member.
+func (s *IPSets) shardedSetNameFor(ipSet *ipSet, m IPSetMember) string {
+	return s.IPVersionConfig.nameForShardIPSet(ipSet.MainIPSetName, shardForMember(m, ipSet.numShards))
+}
+
+// writeShardedRewrite is the equivalent of the backend's writeFullRewrite for a sharded IP set.  The
+// members are partitioned into shards, each of which is rewritten in the normal way, then the
+// main IP set is updated to list exactly those shards.
+func (s *IPSets) writeShardedRewrite(ipSet *ipSet, writeLine lineWriter) {
+	backend := s.backend.(listSetBackend)
+	mainSetName := ipSet.MainIPSetName
+	if !s.existingIPSetNames.Contains(mainSetName) {
+		backend.writeCreateListSet(writeLine, mainSetName, maxIPSetShards)
+	}
+	shards := partitionMembers(ipSet.pendingReplace, ipSet.MaxSize)
+	if len(shards) == maxIPSetShards {
+		s.logCxt.WithField("setID", ipSet.SetID).Warn(
+			"IP set has the maximum number of shards; it may overflow.")
+	}
+
+	// Write the shards in reverse order.  When the number of shards grows, members only move into
+	// the new, highest-numbered, shards so writing those (and adding them to the main IP set)
+	// first means that each member is always in one of the listed shards.
+	ipSet.numShards = len(shards)
+	ipSet.shardSizes = make([]int, len(shards))
+	for i := len(shards) - 1; i >= 0; i-- {
+		shardName := s.IPVersionConfig.nameForShardIPSet(mainSetName, i)
+		shard := ipSet.shardToRewrite(shardName, shards[i])
+		s.backend.writeFullRewrite(writeLine, shard, s.existingIPSetNames.Contains(shardName), s.nextFreeTempIPSetName)
+		backend.writeAddToListSet(writeLine, mainSetName, shardName)
+		ipSet.shardSizes[i] = shards[i].Len()
+	}
+
+	// Similarly, when the number of shards shrinks, the members of the removed shards are now in
+	// the remaining shards so we can remove them from the main IP set.  They're deleted later,
+	// once the kernel has released them.
+	s.iterExtraShards(ipSet, func(shardName string) {
+		backend.writeDelFromListSet(writeLine, mainSetName, shardName)
+	})
+}
+
+// iterExtraShards calls f with the name of each shard that is in the dataplane but isn't one of the
+// IP set's current shards.
+func (s *IPSets) iterExtraShards(ipSet *ipSet, f func(shardName string)) {
+	for i := ipSet.numShards; i < maxIPSetShards; i++ {
+		shardName := s.IPVersionConfig.nameForShardIPSet(ipSet.MainIPSetName, i)
+		if s.existingIPSetNames.Contains(shardName) {
+			f(shardName)
+		}
+	}
+}
+
+// deleteShards deletes the shards of a main IP set that has been deleted.
+func (s *IPSets) deleteShards(mainSetName string) {
+	for i := 0; i < maxIPSetShards; i++ {
+		shardName := s.IPVersionConfig.nameForShardIPSet(mainSetName, i)
+		if !s.existingIPSetNames.Contains(shardName) {
+			continue
+		}
+		if err := s.deleteIPSet(shardName); err != nil {
+			s.logCxt.WithError(err).WithField("setName", shardName).Warning(
+				"Failed to delete shard of IP set. Will retry on next resync.")
+		}
+	}
+}
+
+// markShardsWritten updates our record of the dataplane after a sharded IP set has been rewritten.
+func (s *IPSets) markShardsWritten(ipSet *ipSet) {
+	for i := 0; i < ipSet.numShards; i++ {
+		shardName := s.IPVersionConfig.nameForShardIPSet(ipSet.MainIPSetName, i)
+		s.existingIPSetNames.Add(shardName)
+		s.pendingIPSetDeletions.Discard(shardName)
+	}
+	s.iterExtraShards(ipSet, func(shardName string) {
+		s.pendingIPSetDeletions.Add(shardName)
+	})
+}
+
+// currentShards returns the shards of the IP sets that are in sync with the dataplane, indexed by
+// name.
+func (s *IPSets) currentShards() map[string]ipSetShard {
+	shards := map[string]ipSetShard{}
+	for _, ipSet := range s.ipSetIDToIPSet {
+		if ipSet.members == nil {
+			continue
+		}
+		for i := 0; i < ipSet.numShards; i++ {
+			shards[s.IPVersionConfig.nameForShardIPSet(ipSet.MainIPSetName, i)] = ipSetShard{ipSet: ipSet, index: i}
+		}
+	}
+	return shards
+}
+
+// shardedResyncState is the state used by resyncVisitor to check sharded IP sets.  Since the
+// members of an IP set are spread across its shards, they're checked as they are streamed and
+// the results are compared once all the IP sets have been listed.
+type shardedResyncState struct {
+	shards map[string]ipSetShard
+
+	// shardIndex is the index of the shard being listed, or -1 if the members being listed are
+	// the shards of a main IP set.
+	shardIndex   int
+	listedShards set.Set[string]
+
+	seenIPSets       set.Set[*ipSet]
+	misplacedMembers set.Set[*ipSet]
+	numShardMembers  map[*ipSet]int
+}
+
+func (s *IPSets) newShardedResyncState() *shardedResyncState {
+	return &shardedResyncState{
+		shards:           s.currentShards(),
+		seenIPSets:       set.New[*ipSet](),
+		misplacedMembers: set.New[*ipSet](),
+		numShardMembers:  map[*ipSet]int{},
+	}
+}
+
+func (v *resyncVisitor) visitShardedSet(setName string) bool {
+	s := v.ipSets
+	if ipSet := s.mainIPSetNameToIPSet[setName]; ipSet != nil {
+		if ipSet.members == nil {
+			return false
+		}
+		v.ipSet = ipSet
+		v.sharded.seenIPSets.Add(ipSet)
+		v.sharded.shardIndex = -1
+		v.sharded.listedShards = set.New[string]()
+		return true
+	}
+	if shard, ok := v.sharded.shards[setName]; ok {
+		v.ipSet = shard.ipSet
+		v.sharded.shardIndex = shard.index
+		return true
+	}
+	return false
+}
+
+func (v *resyncVisitor) visitShardMember(member string) {
+	if v.sharded.shardIndex < 0 {
+		v.sharded.listedShards.Add(member)
+		return
+	}
+	canonMember := v.ipSet.Type.CanonicaliseMember(member)
+	if !v.ipSet.members.Contains(canonMember) ||
+		shardForMember(canonMember, v.ipSet.numShards) != v.sharded.shardIndex {
+		if v.debug {
+			v.ipSets.logCxt.WithFields(log.Fields{
+				"setID":  v.ipSet.SetID,
+				"shard":  v.sharded.shardIndex,
+				"member": member,
+			}).Debug("Found unexpected member in shard")
+		}
+		v.sharded.misplacedMembers.Add(v.ipSet)
+	}
+	v.sharded.numShardMembers[v.ipSet]++
+}
+
+func (v *resyncVisitor) endShardedSet() {
+	if v.sharded.shardIndex < 0 {
+		// Check that the main IP set lists exactly the expected shards.
+		listedShards := v.sharded.listedShards
+		if listedShards.Len() != v.ipSet.numShards {
+			v.sharded.misplacedMembers.Add(v.ipSet)
+		}
+		for i := 0; i < v.ipSet.numShards; i++ {
+			if !listedShards.Contains(v.ipSets.IPVersionConfig.nameForShardIPSet(v.ipSet.MainIPSetName, i)) {
+				v.sharded.misplacedMembers.Add(v.ipSet)
+			}
+		}
+		v.sharded.listedShards = nil
+	}
+	v.ipSet = nil
+}
+
+// resyncShardedIPSets queues up a rewrite of any sharded IP sets that the resyncVisitor found to be
+// out of sync.  Since resharding rewrites the IP set anyway, we don't try to calculate deltas.
+func (s *IPSets) resyncShardedIPSets(v *resyncVisitor) (numProblems int) {
+	state := v.sharded
+	for _, ipSet := range s.ipSetIDToIPSet {
+		if ipSet.members == nil {
+			continue
+		}
+		if state.seenIPSets.Contains(ipSet) &&
+			!state.misplacedMembers.Contains(ipSet) &&
+			state.numShardMembers[ipSet] == ipSet.members.Len() {
+			continue
+		}
+		s.logCxt.WithFields(log.Fields{
+			"setID":              ipSet.SetID,
+			"numMembers":         ipSet.members.Len(),
+			"numMembersInShards": state.numShardMembers[ipSet],
+			"numShards":          ipSet.numShards,
+		}).Warning("Resync found sharded IP set out of sync with dataplane. Queueing a rewrite.")
+		ipSet.queueRewrite()
+		s.dirtyIPSetIDs.Add(ipSet.SetID)
+		numProblems++
+	}
+	return
+}
+
+// isExpectedShard returns true if the given IP set is a shard that resync should leave alone.  That
+// is, one of the current shards of an IP set that is in sync or any shard of an IP set that is
+// about to be rewritten (which will tidy up any shards that it no longer needs).
+func (s *IPSets) isExpectedShard(v *resyncVisitor, setName string) bool {
+	if _, ok := v.sharded.shards[setName]; ok {
+		return true
+	}
+	mainSetName, ok := s.IPVersionConfig.mainIPSetNameForShard(setName)
+	if !ok {
+		return false
+	}
+	ipSet := s.mainIPSetNameToIPSet[mainSetName]
+	return ipSet != nil && ipSet.members == nil && s.ipSetNeeded(ipSet.SetID)
+}
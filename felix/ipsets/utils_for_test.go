@@ -66,6 +66,10 @@ type mockDataplane struct {
 	TriedToAddExistent       bool
 
 	AttemptedDestroys []string
+	// RestoreLines records every line (other than COMMIT) passed to ipset restore.
+	RestoreLines []string
+	// NumRestoreCommits counts the COMMITs passed to ipset restore.
+	NumRestoreCommits int
 
 	CumulativeSleep time.Duration
 }
@@ -83,6 +87,19 @@ func (d *mockDataplane) ExpectMembers(expected map[string][]string) {
 	Expect(d.IPSetMembers).To(Equal(membersToCompare))
 }
 
+// inListSet returns true if the named IP set is a member of a list:set, and hence in use.
+func (d *mockDataplane) inListSet(name string) bool {
+	for listName, meta := range d.IPSetMetadata {
+		if meta.Type != "list:set" {
+			continue
+		}
+		if members, ok := d.IPSetMembers[listName]; ok && members.Contains(name) {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *mockDataplane) newCmd(name string, arg ...string) CmdIface {
 	if name != "ipset" {
 		Fail("Unknown command: " + name)
@@ -266,10 +283,32 @@ func (c *restoreCmd) main() {
 			"subCmd":  subCmd,
 		}).Info("Mock dataplane, analysing ipset restore line")
 		if subCmd != "COMMIT" {
-			Expect(commitSeen).To(BeFalse())
+			// Intermediate COMMITs are allowed but the input must end with one.
+			commitSeen = false
+			c.Dataplane.RestoreLines = append(c.Dataplane.RestoreLines, line)
 		}
 		switch subCmd {
 		case "create":
+			if parts[2] == "list:set" {
+				Expect(len(parts)).To(Equal(5))
+				name := parts[1]
+				Expect(len(name)).To(BeNumerically("<=", MaxIPSetNameLength))
+				Expect(parts[3]).To(Equal("size"))
+				size, err := strconv.Atoi(parts[4])
+				Expect(err).NotTo(HaveOccurred())
+				if _, ok := c.Dataplane.IPSetMembers[name]; ok {
+					_, _ = c.Stderr.Write([]byte("set exists"))
+					result = &exec.ExitError{}
+					return
+				}
+				c.Dataplane.IPSetMembers[name] = set.New[string]()
+				c.Dataplane.IPSetMetadata[name] = setMetadata{
+					Name:    name,
+					MaxSize: size,
+					Type:    "list:set",
+				}
+				continue
+			}
 			Expect(len(parts)).To(Equal(7))
 
 			name := parts[1]
@@ -312,7 +351,7 @@ func (c *restoreCmd) main() {
 				result = &exec.ExitError{}
 				return
 			}
-			if c.Dataplane.FailDestroyNames.Contains(name) {
+			if c.Dataplane.FailDestroyNames.Contains(name) || c.Dataplane.inListSet(name) {
 				_, _ = c.Stderr.Write([]byte("set is in use"))
 				result = &exec.ExitError{}
 				return
@@ -320,15 +359,35 @@ func (c *restoreCmd) main() {
 			delete(c.Dataplane.IPSetMembers, name)
 			log.WithField("setName", name).Info("Set destroyed")
 		case "add":
-			Expect(len(parts)).To(Equal(3))
+			Expect(len(parts)).To(BeElementOf(3, 4))
 			name := parts[1]
 			newMember := parts[2]
+			allowExisting := len(parts) == 4
+			if allowExisting {
+				Expect(parts[3]).To(Equal("--exist"))
+			}
 			logCxt := log.WithField("setName", name)
 			if currentMembers, ok := c.Dataplane.IPSetMembers[name]; !ok {
 				_, _ = c.Stderr.Write([]byte("set doesn't exist"))
 				result = &exec.ExitError{}
 				return
 			} else {
+				if allowExisting && currentMembers.Contains(newMember) {
+					continue
+				}
+				if c.Dataplane.IPSetMetadata[name].Type == "list:set" {
+					if _, ok := c.Dataplane.IPSetMembers[newMember]; !ok {
+						_, _ = c.Stderr.Write([]byte("member set doesn't exist"))
+						result = &exec.ExitError{}
+						return
+					}
+				}
+				if maxSize := c.Dataplane.IPSetMetadata[name].MaxSize; maxSize > 0 && currentMembers.Len() >= maxSize {
+					logCxt.Warn("Add to full IP set")
+					_, _ = c.Stderr.Write([]byte("set is full"))
+					result = &exec.ExitError{}
+					return
+				}
 				if currentMembers.Contains(newMember) {
 					c.Dataplane.TriedToAddExistent = true
 					logCxt.Warn("Add of existing member")
@@ -395,7 +454,9 @@ func (c *restoreCmd) main() {
 				c.Dataplane.IPSetMetadata[name2] = meta1
 			}
 		case "COMMIT":
+			Expect(commitSeen).To(BeFalse(), "Empty COMMIT")
 			commitSeen = true
+			c.Dataplane.NumRestoreCommits++
 		default:
 			Fail("Unknown action: " + line)
 		}
@@ -472,6 +533,10 @@ func (d *destroyCmd) CombinedOutput() ([]byte, error) {
 		d.Dataplane.FailNextDestroy = false
 		return nil, &exec.ExitError{}
 	}
+	if d.Dataplane.inListSet(d.SetName) {
+		log.WithField("setName", d.SetName).Info("Mock dataplane refusing to delete IP set that is in use")
+		return nil, &exec.ExitError{}
+	}
 	if _, ok := d.Dataplane.IPSetMembers[d.SetName]; ok {
 		// IP set exists.
 		delete(d.Dataplane.IPSetMembers, d.SetName)
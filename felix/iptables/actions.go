@@ -116,6 +116,21 @@ func (g LogAction) String() string {
 	return "Log"
 }
 
+// NflogAction sends the packet to userspace, over the given netlink log group, for logging.
+type NflogAction struct {
+	Group     uint16
+	Prefix    string
+	TypeNflog struct{}
+}
+
+func (g NflogAction) ToFragment(features *environment.Features) string {
+	return fmt.Sprintf(`--jump NFLOG --nflog-group %d --nflog-prefix "%s"`, g.Group, g.Prefix)
+}
+
+func (g NflogAction) String() string {
+	return fmt.Sprintf("Nflog:%d", g.Group)
+}
+
 type AcceptAction struct {
 	TypeAccept struct{}
 }
@@ -223,6 +238,39 @@ func (c SetMaskedMarkAction) String() string {
 	return fmt.Sprintf("Set:%#x", c.Mark)
 }
 
+// TPROXYAction diverts the packet to a local socket listening on Port, without changing its
+// destination, and sets the given mark bits so that it can be routed locally.  It is only valid in
+// the mangle table's PREROUTING chain.
+type TPROXYAction struct {
+	Port       uint16
+	Mark       uint32
+	Mask       uint32
+	TypeTPROXY struct{}
+}
+
+func (c TPROXYAction) ToFragment(features *environment.Features) string {
+	return fmt.Sprintf("--jump TPROXY --on-port %d --tproxy-mark %#x/%#x", c.Port, c.Mark, c.Mask)
+}
+
+func (c TPROXYAction) String() string {
+	return fmt.Sprintf("TPROXY:%d", c.Port)
+}
+
+// SetDSCPAction sets the DSCP field of the packet's IP header to Value.  It is only valid in the
+// mangle table.
+type SetDSCPAction struct {
+	Value       uint8
+	TypeSetDSCP struct{}
+}
+
+func (c SetDSCPAction) ToFragment(features *environment.Features) string {
+	return fmt.Sprintf("--jump DSCP --set-dscp %d", c.Value)
+}
+
+func (c SetDSCPAction) String() string {
+	return fmt.Sprintf("SetDSCP:%d", c.Value)
+}
+
 type NoTrackAction struct {
 	TypeNoTrack struct{}
 }
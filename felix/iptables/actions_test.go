@@ -32,6 +32,7 @@ var _ = DescribeTable("Actions",
 	Entry("DropAction", environment.Features{}, DropAction{}, "--jump DROP"),
 	Entry("AcceptAction", environment.Features{}, AcceptAction{}, "--jump ACCEPT"),
 	Entry("LogAction", environment.Features{}, LogAction{Prefix: "prefix"}, `--jump LOG --log-prefix "prefix: " --log-level 5`),
+	Entry("NflogAction", environment.Features{}, NflogAction{Group: 10, Prefix: "prefix"}, `--jump NFLOG --nflog-group 10 --nflog-prefix "prefix"`),
 	Entry("DNATAction", environment.Features{}, DNATAction{DestAddr: "10.0.0.1", DestPort: 8081}, "--jump DNAT --to-destination 10.0.0.1:8081"),
 	Entry("SNATAction", environment.Features{}, SNATAction{ToAddr: "10.0.0.1"}, "--jump SNAT --to-source 10.0.0.1"),
 	Entry("SNATAction fully random", environment.Features{SNATFullyRandom: true}, SNATAction{ToAddr: "10.0.0.1"}, "--jump SNAT --to-source 10.0.0.1 --random-fully"),
@@ -43,6 +44,12 @@ var _ = DescribeTable("Actions",
 		Mark: 0x1000,
 		Mask: 0xf000,
 	}, "--jump MARK --set-mark 0x1000/0xf000"),
+	Entry("TPROXYAction", environment.Features{}, TPROXYAction{
+		Port: 16001,
+		Mark: 0x400,
+		Mask: 0x400,
+	}, "--jump TPROXY --on-port 16001 --tproxy-mark 0x400/0x400"),
+	Entry("SetDSCPAction", environment.Features{}, SetDSCPAction{Value: 46}, "--jump DSCP --set-dscp 46"),
 	Entry("SaveConnMarkAction", environment.Features{}, SaveConnMarkAction{SaveMask: 0x100}, "--jump CONNMARK --save-mark --mask 0x100"),
 	Entry("RestoreConnMarkAction", environment.Features{}, RestoreConnMarkAction{RestoreMask: 0x100}, "--jump CONNMARK --restore-mark --mask 0x100"),
 	Entry("SaveConnMarkAction", environment.Features{}, SaveConnMarkAction{}, "--jump CONNMARK --save-mark --mask 0xffffffff"),
@@ -0,0 +1,515 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptables
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/projectcalico/calico/felix/environment"
+)
+
+const (
+	VerdictAccept = "ACCEPT"
+	VerdictDrop   = "DROP"
+	VerdictReject = "REJECT"
+	// VerdictReturn means that the packet fell off the end of (or returned from) the chain where
+	// the walk started, without its accept mark being set.
+	VerdictReturn = "RETURN"
+
+	// maxExplainDepth limits the depth of nested jumps, to protect against jump loops.
+	maxExplainDepth = 64
+)
+
+// ErrUnknownChain is returned (wrapped) by Explain() if the starting chain is not programmed.
+var ErrUnknownChain = errors.New("unknown chain")
+
+// Packet describes a packet for Explain().  Fields that are left empty are treated as unknown;
+// rules that match on them are reported as unevaluated.
+type Packet struct {
+	// Protocol is the name or number of the IP protocol.
+	Protocol string `json:"protocol,omitempty"`
+	SrcIP    net.IP `json:"srcIP,omitempty"`
+	DstIP    net.IP `json:"dstIP,omitempty"`
+	SrcPort  uint16 `json:"srcPort,omitempty"`
+	DstPort  uint16 `json:"dstPort,omitempty"`
+	ICMPType uint8  `json:"icmpType,omitempty"`
+	ICMPCode uint8  `json:"icmpCode,omitempty"`
+	InIface  string `json:"inIface,omitempty"`
+	OutIface string `json:"outIface,omitempty"`
+	// ConntrackState is the conntrack state of the packet; it defaults to NEW.
+	ConntrackState string `json:"conntrackState,omitempty"`
+	// Mark is the packet mark when it enters the chain.
+	Mark uint32 `json:"mark,omitempty"`
+}
+
+// ExplainOptions holds the dataplane state, beyond the rules themselves, that Explain() needs.
+type ExplainOptions struct {
+	// IPSetMembers returns the members of the named IP set, in ipset format, or false if the
+	// IP set is not known.
+	IPSetMembers func(setName string) (members []string, known bool)
+	// AcceptMark is the mark bit that the rules set to accept a packet before returning.  If the
+	// walk returns from the starting chain with the bit set, the verdict is ACCEPT.
+	AcceptMark uint32
+}
+
+// Explanation is the result of walking a packet through a chain.
+type Explanation struct {
+	Verdict string `json:"verdict"`
+	// Mark is the packet mark at the end of the walk.
+	Mark uint32 `json:"mark"`
+	// Steps lists, in order, the rules that matched the packet and the rules that might have
+	// matched but could not be evaluated.
+	Steps []*ExplainStep `json:"steps"`
+}
+
+// ExplainStep describes a rule that the packet reached during a walk.
+type ExplainStep struct {
+	Chain string `json:"chain"`
+	// RuleNum is the 1-based index of the rule in its chain.
+	RuleNum int    `json:"ruleNum"`
+	Rule    string `json:"rule"`
+	// Comment holds the rule's comments and ChainComment holds those of the first rule in the
+	// chain, which, for policy and profile chains, name the policy or profile.
+	Comment      []string `json:"comment,omitempty"`
+	ChainComment []string `json:"chainComment,omitempty"`
+	Matched      bool     `json:"matched"`
+	// Unevaluated holds the match criteria that could not be evaluated for the packet.  Rules
+	// with unevaluated criteria are assumed not to match.
+	Unevaluated []string `json:"unevaluated,omitempty"`
+	// Note explains anything unusual about the step, such as a jump to an unknown chain.
+	Note string `json:"note,omitempty"`
+}
+
+// Explain walks a packet through the calculated state of the given chain (and the chains that it
+// jumps to) and reports the rules that the packet would match and the resulting verdict.  It
+// describes the rules that Felix wants to program, which may differ from the dataplane if an
+// update is pending.  It must be called from the goroutine that owns the table.
+func (t *Table) Explain(chainName string, pkt Packet, opts ExplainOptions) (*Explanation, error) {
+	return ExplainChains(func(name string) (*Chain, bool) {
+		return t.desiredStateOfChain(name)
+	}, chainName, pkt, opts, t.featureDetector.GetFeatures())
+}
+
+// ExplainChains implements Explain() for any rules table; lookupChain returns the calculated
+// state of the named chain.
+func ExplainChains(
+	lookupChain func(name string) (*Chain, bool),
+	chainName string,
+	pkt Packet,
+	opts ExplainOptions,
+	features *environment.Features,
+) (*Explanation, error) {
+	if _, ok := lookupChain(chainName); !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownChain, chainName)
+	}
+	if pkt.ConntrackState == "" {
+		pkt.ConntrackState = "NEW"
+	}
+	e := &explainer{
+		lookupChain: lookupChain,
+		pkt:         pkt,
+		opts:        opts,
+		features:    features,
+		mark:        pkt.Mark,
+	}
+	verdict, err := e.walkChain(chainName, 0)
+	if err != nil {
+		return nil, err
+	}
+	if verdict == "" {
+		verdict = VerdictReturn
+		if opts.AcceptMark != 0 && e.mark&opts.AcceptMark != 0 {
+			verdict = VerdictAccept
+		}
+	}
+	return &Explanation{
+		Verdict: verdict,
+		Mark:    e.mark,
+		Steps:   e.steps,
+	}, nil
+}
+
+type explainer struct {
+	lookupChain func(name string) (*Chain, bool)
+	pkt         Packet
+	opts        ExplainOptions
+	features    *environment.Features
+
+	mark  uint32
+	steps []*ExplainStep
+}
+
+// walkChain walks the packet through the named chain.  It returns the verdict if the packet hit
+// a terminating action or "" if it returned from the chain.
+func (e *explainer) walkChain(chainName string, depth int) (verdict string, err error) {
+	if depth > maxExplainDepth {
+		return "", fmt.Errorf("too many nested jumps at chain %q", chainName)
+	}
+	chain, ok := e.lookupChain(chainName)
+	if !ok {
+		e.steps = append(e.steps, &ExplainStep{
+			Chain: chainName,
+			Note:  "Chain is not programmed by Felix; assuming that it returns",
+		})
+		return "", nil
+	}
+	var chainComment []string
+	if len(chain.Rules) > 0 {
+		chainComment = chain.Rules[0].Comment
+	}
+	for i, rule := range chain.Rules {
+		matched, unevaluated := e.evalMatch(rule.Match)
+		if !matched && len(unevaluated) == 0 {
+			continue
+		}
+		step := &ExplainStep{
+			Chain:        chainName,
+			RuleNum:      i + 1,
+			Rule:         strings.TrimSpace(rule.Match.Render() + " " + rule.Action.ToFragment(e.features)),
+			Comment:      rule.Comment,
+			ChainComment: chainComment,
+			Matched:      matched,
+			Unevaluated:  unevaluated,
+		}
+		e.steps = append(e.steps, step)
+		if !matched {
+			continue
+		}
+
+		switch a := rule.Action.(type) {
+		case AcceptAction:
+			return VerdictAccept, nil
+		case DropAction:
+			return VerdictDrop, nil
+		case RejectAction:
+			return VerdictReject, nil
+		case ReturnAction:
+			return "", nil
+		case JumpAction:
+			verdict, err := e.walkChain(a.Target, depth+1)
+			if err != nil || verdict != "" {
+				return verdict, err
+			}
+		case GotoAction:
+			// The target chain returns directly to our caller.
+			return e.walkChain(a.Target, depth+1)
+		case SetMarkAction:
+			e.mark |= a.Mark
+		case ClearMarkAction:
+			e.mark &^= a.Mark
+		case SetMaskedMarkAction:
+			e.mark = (e.mark &^ a.Mask) | a.Mark
+		case LogAction, NflogAction, SetDSCPAction, NoTrackAction, SaveConnMarkAction, RestoreConnMarkAction, SetConnMarkAction:
+			// Non-terminating actions that don't affect the walk.
+		case nil:
+			// Rule with no action, used to count packets.
+		default:
+			// NAT and other terminating targets.
+			return strings.TrimPrefix(rule.Action.ToFragment(e.features), "--jump "), nil
+		}
+	}
+	return "", nil
+}
+
+// evalMatch evaluates each of the criteria of a rule against the packet.  It returns true if all
+// the criteria match, along with any criteria that could not be evaluated.
+func (e *explainer) evalMatch(m MatchCriteria) (matched bool, unevaluated []string) {
+	matched = true
+	for _, criterion := range m {
+		critMatched, ok := e.evalCriterion(strings.Fields(criterion))
+		if !ok {
+			unevaluated = append(unevaluated, criterion)
+			matched = false
+			continue
+		}
+		if !critMatched {
+			// A definite mismatch means that the rule can't match, whatever the unevaluated
+			// criteria would say.
+			return false, nil
+		}
+	}
+	return matched, unevaluated
+}
+
+// evalCriterion evaluates a single match fragment, as generated by the MatchCriteria builder
+// methods.  ok is false if the fragment is not understood or depends on unknown packet fields.
+func (e *explainer) evalCriterion(words []string) (matched, ok bool) {
+	if len(words) == 0 {
+		return true, true
+	}
+	negated := false
+	if words[0] == "!" {
+		negated = true
+		words = words[1:]
+	}
+	if len(words) > 3 && words[0] == "-m" && words[2] == "!" {
+		negated = true
+		words = append(words[:2:2], words[3:]...)
+	}
+	switch {
+	case len(words) == 2 && words[0] == "-p":
+		matched, ok = e.matchProtocol(words[1])
+	case len(words) == 2 && words[0] == "--source":
+		matched, ok = matchAddr(words[1], e.pkt.SrcIP)
+	case len(words) == 2 && words[0] == "--destination":
+		matched, ok = matchAddr(words[1], e.pkt.DstIP)
+	case len(words) == 2 && words[0] == "--in-interface":
+		matched, ok = matchIface(words[1], e.pkt.InIface)
+	case len(words) == 2 && words[0] == "--out-interface":
+		matched, ok = matchIface(words[1], e.pkt.OutIface)
+	case len(words) > 2 && words[0] == "-m":
+		matched, ok = e.evalModuleMatch(words[1], words[2:])
+	}
+	if !ok {
+		return false, false
+	}
+	return matched != negated, true
+}
+
+func (e *explainer) evalModuleMatch(module string, args []string) (matched, ok bool) {
+	switch module {
+	case "mark":
+		if len(args) != 2 || args[0] != "--mark" {
+			return false, false
+		}
+		valueAndMask := strings.SplitN(args[1], "/", 2)
+		if len(valueAndMask) != 2 {
+			return false, false
+		}
+		value, err1 := strconv.ParseUint(valueAndMask[0], 0, 32)
+		mask, err2 := strconv.ParseUint(valueAndMask[1], 0, 32)
+		if err1 != nil || err2 != nil {
+			return false, false
+		}
+		return e.mark&uint32(mask) == uint32(value), true
+	case "set":
+		if len(args) != 3 || args[0] != "--match-set" {
+			return false, false
+		}
+		return e.matchIPSet(args[1], args[2])
+	case "multiport":
+		if len(args) != 2 {
+			return false, false
+		}
+		var port uint16
+		switch args[0] {
+		case "--source-ports":
+			port = e.pkt.SrcPort
+		case "--destination-ports":
+			port = e.pkt.DstPort
+		default:
+			return false, false
+		}
+		if port == 0 {
+			return false, false
+		}
+		return matchPorts(args[1], port)
+	case "conntrack":
+		if len(args) != 2 || args[0] != "--ctstate" {
+			return false, false
+		}
+		for _, s := range strings.Split(args[1], ",") {
+			if strings.EqualFold(s, e.pkt.ConntrackState) {
+				return true, true
+			}
+		}
+		return false, true
+	case "iprange":
+		if len(args) != 2 {
+			return false, false
+		}
+		switch args[0] {
+		case "--src-range":
+			return matchIPRange(args[1], e.pkt.SrcIP)
+		case "--dst-range":
+			return matchIPRange(args[1], e.pkt.DstIP)
+		}
+		return false, false
+	case "icmp", "icmp6":
+		if len(args) != 2 || (args[0] != "--icmp-type" && args[0] != "--icmpv6-type") {
+			return false, false
+		}
+		typeAndCode := strings.SplitN(args[1], "/", 2)
+		t, err := strconv.ParseUint(typeAndCode[0], 10, 8)
+		if err != nil {
+			return false, false
+		}
+		matched = uint8(t) == e.pkt.ICMPType
+		if len(typeAndCode) == 2 {
+			c, err := strconv.ParseUint(typeAndCode[1], 10, 8)
+			if err != nil {
+				return false, false
+			}
+			matched = matched && uint8(c) == e.pkt.ICMPCode
+		}
+		return matched, true
+	}
+	return false, false
+}
+
+func (e *explainer) matchProtocol(protocol string) (matched, ok bool) {
+	want, ok := protocolNumber(protocol)
+	if !ok {
+		return false, false
+	}
+	have, ok := protocolNumber(e.pkt.Protocol)
+	if !ok {
+		return false, false
+	}
+	return want == have, true
+}
+
+// matchIPSet checks whether the packet matches the named IP set.  dims selects the packet fields
+// that are looked up: "src"/"dst" for an address, "src,src"/"dst,dst" for an address, protocol
+// and port.
+func (e *explainer) matchIPSet(setName, dims string) (matched, ok bool) {
+	if e.opts.IPSetMembers == nil {
+		return false, false
+	}
+	members, known := e.opts.IPSetMembers(setName)
+	if !known {
+		return false, false
+	}
+	addr, port := e.pkt.SrcIP, e.pkt.SrcPort
+	if strings.HasPrefix(dims, "dst") {
+		addr, port = e.pkt.DstIP, e.pkt.DstPort
+	}
+	if addr == nil {
+		return false, false
+	}
+	withPort := strings.Contains(dims, ",")
+	protoNum, protoKnown := protocolNumber(e.pkt.Protocol)
+	for _, member := range members {
+		parts := strings.Split(member, ",")
+		if m, ok := matchAddr(parts[0], addr); !ok || !m {
+			continue
+		}
+		if !withPort {
+			return true, true
+		}
+		if len(parts) != 2 || !protoKnown || port == 0 {
+			// Network/interface IP sets, or not enough information about the packet.
+			return false, false
+		}
+		protoAndPort := strings.SplitN(parts[1], ":", 2)
+		if len(protoAndPort) != 2 {
+			return false, false
+		}
+		memberProto, ok := protocolNumber(protoAndPort[0])
+		if !ok {
+			return false, false
+		}
+		if memberProto == protoNum && protoAndPort[1] == strconv.Itoa(int(port)) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+func matchAddr(cidrOrIP string, addr net.IP) (matched, ok bool) {
+	if addr == nil {
+		return false, false
+	}
+	if !strings.Contains(cidrOrIP, "/") {
+		ip := net.ParseIP(cidrOrIP)
+		if ip == nil {
+			return false, false
+		}
+		return ip.Equal(addr), true
+	}
+	_, cidr, err := net.ParseCIDR(cidrOrIP)
+	if err != nil {
+		return false, false
+	}
+	return cidr.Contains(addr), true
+}
+
+// matchIPRange checks whether addr is in the inclusive range "<start>-<end>".
+func matchIPRange(ipRange string, addr net.IP) (matched, ok bool) {
+	if addr == nil {
+		return false, false
+	}
+	startAndEnd := strings.SplitN(ipRange, "-", 2)
+	if len(startAndEnd) != 2 {
+		return false, false
+	}
+	start, end := net.ParseIP(startAndEnd[0]), net.ParseIP(startAndEnd[1])
+	if start == nil || end == nil {
+		return false, false
+	}
+	if (start.To4() == nil) != (addr.To4() == nil) {
+		// Different IP versions never match.
+		return false, true
+	}
+	addr16 := addr.To16()
+	return bytes.Compare(addr16, start.To16()) >= 0 && bytes.Compare(addr16, end.To16()) <= 0, true
+}
+
+func matchIface(pattern, iface string) (matched, ok bool) {
+	if iface == "" {
+		return false, false
+	}
+	if strings.HasSuffix(pattern, "+") {
+		return strings.HasPrefix(iface, strings.TrimSuffix(pattern, "+")), true
+	}
+	return pattern == iface, true
+}
+
+// matchPorts checks a port against a multiport list, such as "80,443,8000:8080".
+func matchPorts(ports string, port uint16) (matched, ok bool) {
+	for _, p := range strings.Split(ports, ",") {
+		firstAndLast := strings.SplitN(p, ":", 2)
+		first, err := strconv.ParseUint(firstAndLast[0], 10, 16)
+		if err != nil {
+			return false, false
+		}
+		last := first
+		if len(firstAndLast) == 2 {
+			last, err = strconv.ParseUint(firstAndLast[1], 10, 16)
+			if err != nil {
+				return false, false
+			}
+		}
+		if uint64(port) >= first && uint64(port) <= last {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+var protocolNumbers = map[string]uint8{
+	"icmp":      1,
+	"tcp":       6,
+	"udp":       17,
+	"icmpv6":    58,
+	"ipv6-icmp": 58,
+	"sctp":      132,
+}
+
+func protocolNumber(protocol string) (uint8, bool) {
+	if n, ok := protocolNumbers[strings.ToLower(protocol)]; ok {
+		return n, true
+	}
+	n, err := strconv.ParseUint(protocol, 10, 8)
+	if err != nil {
+		return 0, false
+	}
+	return uint8(n), true
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptables_test
+
+import (
+	"net"
+
+	. "github.com/projectcalico/calico/felix/iptables"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/environment"
+)
+
+var _ = Describe("Explain", func() {
+	const acceptMark = 0x10
+
+	var (
+		chains  map[string]*Chain
+		ipSets  map[string][]string
+		pkt     Packet
+		explain func() *Explanation
+	)
+
+	BeforeEach(func() {
+		chains = map[string]*Chain{
+			"cali-tw-eth0": {
+				Name: "cali-tw-eth0",
+				Rules: []Rule{
+					{Match: Match().ConntrackState("RELATED,ESTABLISHED"), Action: AcceptAction{}},
+					{Action: ClearMarkAction{Mark: acceptMark}},
+					{Action: JumpAction{Target: "cali-pi-deny-ssh"}},
+					{Match: Match().MarkSingleBitSet(acceptMark), Action: ReturnAction{}},
+					{Action: JumpAction{Target: "cali-pi-allow-web"}},
+					{Match: Match().MarkSingleBitSet(acceptMark), Action: ReturnAction{}},
+					{Action: DropAction{}, Comment: []string{"Drop if no policies passed packet"}},
+				},
+			},
+			"cali-pi-deny-ssh": {
+				Name: "cali-pi-deny-ssh",
+				Rules: []Rule{
+					{
+						Match:   Match().Protocol("tcp").DestPorts(22),
+						Action:  DropAction{},
+						Comment: []string{"Policy deny-ssh ingress"},
+					},
+				},
+			},
+			"cali-pi-allow-web": {
+				Name: "cali-pi-allow-web",
+				Rules: []Rule{
+					{
+						Match:   Match().Protocol("tcp").SourceIPSet("cali40s:web-clients").DestPorts(80, 443),
+						Action:  SetMarkAction{Mark: acceptMark},
+						Comment: []string{"Policy allow-web ingress"},
+					},
+					{Match: Match().MarkSingleBitSet(acceptMark), Action: ReturnAction{}},
+				},
+			},
+		}
+		ipSets = map[string][]string{
+			"cali40s:web-clients": {"10.0.1.0/24"},
+		}
+		pkt = Packet{
+			Protocol: "tcp",
+			SrcIP:    net.ParseIP("10.0.1.5"),
+			DstIP:    net.ParseIP("10.0.0.2"),
+			SrcPort:  34567,
+			DstPort:  443,
+		}
+		explain = func() *Explanation {
+			e, err := ExplainChains(
+				func(name string) (*Chain, bool) {
+					c, ok := chains[name]
+					return c, ok
+				},
+				"cali-tw-eth0",
+				pkt,
+				ExplainOptions{
+					IPSetMembers: func(setName string) ([]string, bool) {
+						m, ok := ipSets[setName]
+						return m, ok
+					},
+					AcceptMark: acceptMark,
+				},
+				&environment.Features{},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			return e
+		}
+	})
+
+	matchedChains := func(e *Explanation) (chains []string) {
+		for _, s := range e.Steps {
+			Expect(s.Matched).To(BeTrue())
+			chains = append(chains, s.Chain)
+		}
+		return
+	}
+
+	It("should accept a packet that a policy allows", func() {
+		e := explain()
+		Expect(e.Verdict).To(Equal(VerdictAccept))
+		Expect(e.Mark).To(Equal(uint32(acceptMark)))
+		Expect(matchedChains(e)).To(Equal([]string{
+			"cali-tw-eth0", "cali-tw-eth0", "cali-tw-eth0",
+			"cali-pi-allow-web", "cali-pi-allow-web",
+			"cali-tw-eth0",
+		}))
+		Expect(e.Steps[3].ChainComment).To(Equal([]string{"Policy allow-web ingress"}))
+		Expect(e.Steps[5].RuleNum).To(Equal(6))
+	})
+
+	It("should drop a packet that a policy denies", func() {
+		pkt.DstPort = 22
+		e := explain()
+		Expect(e.Verdict).To(Equal(VerdictDrop))
+		Expect(e.Steps[len(e.Steps)-1].Chain).To(Equal("cali-pi-deny-ssh"))
+	})
+
+	It("should drop a packet that no policy allows", func() {
+		pkt.SrcIP = net.ParseIP("10.0.2.5")
+		e := explain()
+		Expect(e.Verdict).To(Equal(VerdictDrop))
+		last := e.Steps[len(e.Steps)-1]
+		Expect(last.Chain).To(Equal("cali-tw-eth0"))
+		Expect(last.Comment).To(Equal([]string{"Drop if no policies passed packet"}))
+	})
+
+	It("should accept established packets", func() {
+		pkt.ConntrackState = "ESTABLISHED"
+		e := explain()
+		Expect(e.Verdict).To(Equal(VerdictAccept))
+		Expect(e.Steps).To(HaveLen(1))
+	})
+
+	It("should report criteria that it can't evaluate", func() {
+		delete(ipSets, "cali40s:web-clients")
+		e := explain()
+		Expect(e.Verdict).To(Equal(VerdictDrop))
+		var unevaluated []*ExplainStep
+		for _, s := range e.Steps {
+			if !s.Matched {
+				unevaluated = append(unevaluated, s)
+			}
+		}
+		Expect(unevaluated).To(HaveLen(1))
+		Expect(unevaluated[0].Chain).To(Equal("cali-pi-allow-web"))
+		Expect(unevaluated[0].Unevaluated).To(Equal([]string{"-m set --match-set cali40s:web-clients src"}))
+	})
+
+	It("should evaluate IP range matches", func() {
+		chains["cali-pi-deny-ssh"].Rules = []Rule{{
+			Match:  Match().SourceIPRange("10.0.1.1", "10.0.1.9").NotDestIPRange("10.0.0.10", "10.0.0.20"),
+			Action: DropAction{},
+		}}
+		Expect(explain().Verdict).To(Equal(VerdictDrop))
+
+		pkt.SrcIP = net.ParseIP("10.0.1.10")
+		Expect(explain().Verdict).To(Equal(VerdictAccept))
+	})
+
+	It("should handle gotos and unknown chains", func() {
+		chains["cali-tw-eth0"].Rules = []Rule{
+			{Action: GotoAction{Target: "cali-unknown"}},
+			{Action: DropAction{}},
+		}
+		e := explain()
+		// The goto returns straight to our caller.
+		Expect(e.Verdict).To(Equal(VerdictReturn))
+		Expect(e.Steps).To(HaveLen(2))
+		Expect(e.Steps[1].Note).To(ContainSubstring("not programmed"))
+	})
+
+	It("should detect jump loops", func() {
+		chains["cali-tw-eth0"].Rules = []Rule{{Action: JumpAction{Target: "cali-tw-eth0"}}}
+		_, err := ExplainChains(
+			func(name string) (*Chain, bool) {
+				c, ok := chains[name]
+				return c, ok
+			}, "cali-tw-eth0", pkt, ExplainOptions{}, &environment.Features{})
+		Expect(err).To(MatchError(ContainSubstring("too many nested jumps")))
+	})
+
+	It("should reject an unknown starting chain", func() {
+		_, err := ExplainChains(
+			func(name string) (*Chain, bool) { return nil, false },
+			"cali-tw-eth1", pkt, ExplainOptions{}, &environment.Features{})
+		Expect(err).To(MatchError(ErrUnknownChain))
+	})
+})
@@ -47,6 +47,15 @@ var (
 	countLockRetriesV16 = countLockRetries.WithLabelValues("1.6")
 )
 
+// maxLockProbeIntervalMultiple limits how far the lock probe interval backs off, as a multiple of
+// the configured interval.  Backing off avoids hammering the lock when another process (such as
+// kube-proxy) holds it for a long time, while the limit keeps us responsive once it's released.
+const maxLockProbeIntervalMultiple = 16
+
+// xtablesLockHeldMessage is the start of the messages that iptables-restore prints when another
+// process is holding the xtables lock, both while it waits and when it gives up.
+const xtablesLockHeldMessage = "Another app is currently holding the xtables lock"
+
 func init() {
 	prometheus.MustRegister(
 		summaryLockAcquisitionTime,
@@ -175,6 +184,7 @@ func GrabIptablesLocks(lockFilePath, socketName string, timeout, probeInterval t
 		return nil, fmt.Errorf("failed to open iptables lock %s: %v", lockFilePath, err)
 	}
 
+	backoff := newLockBackoff(probeInterval)
 	startTime := time.Now()
 	for {
 		if err := grabIptablesFileLock(f); err == nil {
@@ -183,10 +193,11 @@ func GrabIptablesLocks(lockFilePath, socketName string, timeout, probeInterval t
 		if time.Since(startTime) > timeout {
 			return nil, Err16LockTimeout
 		}
-		time.Sleep(probeInterval)
+		sleepBeforeLockRetry(&backoff, startTime, timeout)
 		countLockRetriesV16.Inc()
 	}
 
+	backoff = newLockBackoff(probeInterval)
 	startTime14 := time.Now()
 	for {
 		l.Lock14, err = net.ListenUnix("unix", &net.UnixAddr{Name: socketName, Net: "unix"})
@@ -196,7 +207,7 @@ func GrabIptablesLocks(lockFilePath, socketName string, timeout, probeInterval t
 		if time.Since(startTime14) > timeout {
 			return nil, Err14LockTimeout
 		}
-		time.Sleep(probeInterval)
+		sleepBeforeLockRetry(&backoff, startTime14, timeout)
 		countLockRetriesV14.Inc()
 	}
 
@@ -206,6 +217,43 @@ func GrabIptablesLocks(lockFilePath, socketName string, timeout, probeInterval t
 	return l, nil
 }
 
+// lockBackoff tracks the interval between attempts to grab a contended lock.  The interval starts
+// at the configured probe interval and doubles after each attempt, up to a limit.
+type lockBackoff struct {
+	interval    time.Duration
+	maxInterval time.Duration
+}
+
+func newLockBackoff(probeInterval time.Duration) lockBackoff {
+	return lockBackoff{
+		interval:    probeInterval,
+		maxInterval: probeInterval * maxLockProbeIntervalMultiple,
+	}
+}
+
+// next returns the time to wait before the next attempt and backs off the interval for the one
+// after.
+func (b *lockBackoff) next() time.Duration {
+	interval := b.interval
+	b.interval *= 2
+	if b.interval > b.maxInterval {
+		b.interval = b.maxInterval
+	}
+	return interval
+}
+
+// sleepBeforeLockRetry waits for the next backoff interval, but not past the timeout, so that a
+// long interval doesn't delay the final attempt.
+func sleepBeforeLockRetry(b *lockBackoff, startTime time.Time, timeout time.Duration) {
+	sleepTime := b.next()
+	if remaining := timeout - time.Since(startTime); remaining < sleepTime {
+		sleepTime = remaining
+	}
+	if sleepTime > 0 {
+		time.Sleep(sleepTime)
+	}
+}
+
 func grabIptablesFileLock(f *os.File) error {
 	return unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
 }
@@ -112,6 +112,29 @@ var _ = Describe("GrabIptablesLocks FV", func() {
 		Expect(err).NotTo(HaveOccurred())
 		l2.Close()
 	})
+	It("should keep retrying until the lock is released", func() {
+		l, err := GrabIptablesLocks(fileName, "@dummytables", 1*time.Second, 50*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(200 * time.Millisecond)
+			Expect(l.Close()).NotTo(HaveOccurred())
+		}()
+
+		l2, err := GrabIptablesLocks(fileName, "@dummytables", 2*time.Second, 10*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		l2.Close()
+	})
+	It("should not back off past the timeout", func() {
+		l, err := GrabIptablesLocks(fileName, "@dummytables", 1*time.Second, 50*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		defer l.Close()
+
+		startTime := time.Now()
+		_, err = GrabIptablesLocks(fileName, "@dummytables", 100*time.Millisecond, 80*time.Millisecond)
+		Expect(err).To(Equal(Err16LockTimeout))
+		Expect(time.Since(startTime)).To(BeNumerically("<", 200*time.Millisecond))
+	})
 	It("should block concurrent invocations using only iptables 1.4 version of lock", func() {
 		l, err := GrabIptablesLocks(fileName, "@dummytables", 1*time.Second, 50*time.Millisecond)
 		// Sneakily remove the lockfile after it's been locked so that we fall through to
@@ -90,6 +90,21 @@ func (m MatchCriteria) NotMarkMatchesWithMask(mark, mask uint32) MatchCriteria {
 	return append(m, fmt.Sprintf("-m mark ! --mark %#x/%#x", mark, mask))
 }
 
+// ConnMarkMatchesWithMask matches on the connection's mark, rather than the packet's.
+func (m MatchCriteria) ConnMarkMatchesWithMask(mark, mask uint32) MatchCriteria {
+	logCxt := log.WithFields(log.Fields{
+		"mark": mark,
+		"mask": mask,
+	})
+	if mask == 0 {
+		logCxt.Panic("Bug: mask is 0.")
+	}
+	if mark&mask != mark {
+		logCxt.Panic("Bug: mark is not contained in mask")
+	}
+	return append(m, fmt.Sprintf("-m connmark --mark %#x/%#x", mark, mask))
+}
+
 func (m MatchCriteria) InInterface(ifaceMatch string) MatchCriteria {
 	return append(m, fmt.Sprintf("--in-interface %s", ifaceMatch))
 }
@@ -192,6 +207,26 @@ func (m MatchCriteria) NotDestNet(net string) MatchCriteria {
 	return append(m, fmt.Sprintf("! --destination %s", net))
 }
 
+// SourceIPRange matches packets with a source address in the inclusive range from start to end,
+// which must be of the same IP version.  Ranges that don't fall on CIDR boundaries can be matched
+// without splitting them into CIDRs.
+func (m MatchCriteria) SourceIPRange(start, end string) MatchCriteria {
+	return append(m, fmt.Sprintf("-m iprange --src-range %s-%s", start, end))
+}
+
+func (m MatchCriteria) NotSourceIPRange(start, end string) MatchCriteria {
+	return append(m, fmt.Sprintf("-m iprange ! --src-range %s-%s", start, end))
+}
+
+// DestIPRange matches packets with a destination address in the inclusive range from start to end.
+func (m MatchCriteria) DestIPRange(start, end string) MatchCriteria {
+	return append(m, fmt.Sprintf("-m iprange --dst-range %s-%s", start, end))
+}
+
+func (m MatchCriteria) NotDestIPRange(start, end string) MatchCriteria {
+	return append(m, fmt.Sprintf("-m iprange ! --dst-range %s-%s", start, end))
+}
+
 func (m MatchCriteria) SourceIPSet(name string) MatchCriteria {
 	return append(m, fmt.Sprintf("-m set --match-set %s src", name))
 }
@@ -224,6 +259,26 @@ func (m MatchCriteria) NotDestIPPortSet(name string) MatchCriteria {
 	return append(m, fmt.Sprintf("-m set ! --match-set %s dst,dst", name))
 }
 
+// SourceNetIfaceSet matches packets whose source address and incoming interface are in the given
+// hash:net,iface IP set.
+func (m MatchCriteria) SourceNetIfaceSet(name string) MatchCriteria {
+	return append(m, fmt.Sprintf("-m set --match-set %s src,src", name))
+}
+
+func (m MatchCriteria) NotSourceNetIfaceSet(name string) MatchCriteria {
+	return append(m, fmt.Sprintf("-m set ! --match-set %s src,src", name))
+}
+
+// DestNetIfaceSet matches packets whose destination address and outgoing interface are in the
+// given hash:net,iface IP set.
+func (m MatchCriteria) DestNetIfaceSet(name string) MatchCriteria {
+	return append(m, fmt.Sprintf("-m set --match-set %s dst,dst", name))
+}
+
+func (m MatchCriteria) NotDestNetIfaceSet(name string) MatchCriteria {
+	return append(m, fmt.Sprintf("-m set ! --match-set %s dst,dst", name))
+}
+
 func (m MatchCriteria) IPSetNames() (ipSetNames []string) {
 	for _, matchString := range []string(m) {
 		words := strings.Split(matchString, " ")
@@ -308,6 +363,25 @@ func (m MatchCriteria) NotICMPV6TypeAndCode(t, c uint8) MatchCriteria {
 	return append(m, fmt.Sprintf("-m icmp6 ! --icmpv6-type %d/%d", t, c))
 }
 
+// SCTPChunkTypes matches SCTP packets that contain a chunk of any of the given types.  For
+// example, matching on SCTPChunkInit selects the packets that start new associations.  It
+// must be combined with a match on the sctp protocol.
+func (m MatchCriteria) SCTPChunkTypes(chunkTypes ...SCTPChunkType) MatchCriteria {
+	return append(m, fmt.Sprintf("-m sctp --chunk-types any %s", joinSCTPChunkTypes(chunkTypes)))
+}
+
+func (m MatchCriteria) NotSCTPChunkTypes(chunkTypes ...SCTPChunkType) MatchCriteria {
+	return append(m, fmt.Sprintf("-m sctp ! --chunk-types any %s", joinSCTPChunkTypes(chunkTypes)))
+}
+
+func joinSCTPChunkTypes(chunkTypes []SCTPChunkType) string {
+	names := make([]string, len(chunkTypes))
+	for i, t := range chunkTypes {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ",")
+}
+
 // VXLANVNI matches on the VNI contained within the VXLAN header.  It assumes that this is indeed a VXLAN
 // packet; i.e. it should be used with a protocol==UDP and port==VXLAN port match.
 //
@@ -322,6 +396,93 @@ func (m MatchCriteria) VXLANVNI(vni uint32) MatchCriteria {
 		`"`, vni))
 }
 
+// HashLimitMode selects the packet fields that a hashlimit match tracks rates by.
+type HashLimitMode string
+
+const (
+	HashLimitModeSrcIP HashLimitMode = "srcip"
+	HashLimitModeDstIP HashLimitMode = "dstip"
+)
+
+// maxHashLimitNameLen is the longest hashlimit name that the kernel accepts.
+const maxHashLimitNameLen = 15
+
+// HashLimit holds the parameters of a hashlimit match, which limits the rate of packets from (or
+// to) each address.
+type HashLimit struct {
+	// Name of the kernel hash table that tracks the rates.  Rules that use the same name share
+	// their limits.
+	Name string
+	// Mode selects whether rates are tracked per source or per destination address.
+	Mode HashLimitMode
+	// RatePerSecond is the average number of packets per second that each address is allowed.
+	RatePerSecond uint32
+	// Burst is the number of packets that each address may send in excess of the rate before
+	// the limit kicks in.
+	Burst uint32
+	// PrefixLen, if non-zero, groups addresses by the given prefix length before tracking
+	// them; for example, 24 limits each IPv4 /24.
+	PrefixLen uint8
+}
+
+func (l HashLimit) render(op string) string {
+	if l.Name == "" || len(l.Name) > maxHashLimitNameLen {
+		log.WithField("name", l.Name).Panic("Probably bug: invalid hashlimit name")
+	}
+	if l.RatePerSecond == 0 {
+		log.WithField("name", l.Name).Panic("Probably bug: zero hashlimit rate")
+	}
+	frag := fmt.Sprintf("-m hashlimit --hashlimit-%s %d/sec --hashlimit-burst %d --hashlimit-mode %s --hashlimit-name %s",
+		op, l.RatePerSecond, l.Burst, l.Mode, l.Name)
+	if l.PrefixLen != 0 {
+		if l.Mode == HashLimitModeDstIP {
+			frag += fmt.Sprintf(" --hashlimit-dstmask %d", l.PrefixLen)
+		} else {
+			frag += fmt.Sprintf(" --hashlimit-srcmask %d", l.PrefixLen)
+		}
+	}
+	return frag
+}
+
+// HashLimitAbove matches packets from (or to) addresses that have exceeded the given rate limit;
+// it's typically used with a drop action.
+func (m MatchCriteria) HashLimitAbove(l HashLimit) MatchCriteria {
+	return append(m, l.render("above"))
+}
+
+// HashLimitUpTo matches packets from (or to) addresses that are within the given rate limit.
+func (m MatchCriteria) HashLimitUpTo(l HashLimit) MatchCriteria {
+	return append(m, l.render("upto"))
+}
+
+// ConnLimitAbove matches packets from source addresses that already have more than limit
+// connections.  prefixLen groups source addresses by prefix before counting; use 32 (or 128
+// for IPv6) to count each address separately.
+func (m MatchCriteria) ConnLimitAbove(limit uint32, prefixLen uint8) MatchCriteria {
+	return append(m, fmt.Sprintf("-m connlimit --connlimit-above %d --connlimit-mask %d --connlimit-saddr", limit, prefixLen))
+}
+
+// ConnLimitUpTo matches packets from source addresses that have at most limit connections.
+func (m MatchCriteria) ConnLimitUpTo(limit uint32, prefixLen uint8) MatchCriteria {
+	return append(m, fmt.Sprintf("-m connlimit --connlimit-upto %d --connlimit-mask %d --connlimit-saddr", limit, prefixLen))
+}
+
+// SocketTransparent matches packets that belong to a local socket with the IP_TRANSPARENT option
+// set; i.e. to connections that have already been intercepted by a transparent proxy.
+func (m MatchCriteria) SocketTransparent() MatchCriteria {
+	return append(m, "-m socket --transparent")
+}
+
+// U32 matches packets using a u32 expression, which should have been validated with
+// U32Expr.Validate().
+func (m MatchCriteria) U32(expr U32Expr) MatchCriteria {
+	return append(m, fmt.Sprintf("-m u32 --u32 %s", expr))
+}
+
+func (m MatchCriteria) NotU32(expr U32Expr) MatchCriteria {
+	return append(m, fmt.Sprintf("-m u32 ! --u32 %s", expr))
+}
+
 func PortsToMultiport(ports []uint16) string {
 	portFragments := make([]string, len(ports))
 	for i, port := range ports {
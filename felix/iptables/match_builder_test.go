@@ -40,6 +40,16 @@ var _ = Describe("MatchBuilder failure cases", func() {
 			Match().MarkMatchesWithMask(0xf, 0x1)
 		}).To(Panic())
 	})
+	It("should panic if HashLimitAbove is passed a name that is too long", func() {
+		Expect(func() {
+			Match().HashLimitAbove(HashLimit{Name: "cali-hashlimit-1234", RatePerSecond: 1})
+		}).To(Panic())
+	})
+	It("should panic if HashLimitAbove is passed a zero rate", func() {
+		Expect(func() {
+			Match().HashLimitAbove(HashLimit{Name: "cali-hl"})
+		}).To(Panic())
+	})
 	It("should panic if MarkMatchesWithMask is passed a 0 mask", func() {
 		Expect(func() {
 			Match().MarkMatchesWithMask(0x0, 0x0)
@@ -57,6 +67,7 @@ var _ = DescribeTable("MatchBuilder",
 	Entry("MarkSingleBitSet", Match().MarkSingleBitSet(0x4000), "-m mark --mark 0x4000/0x4000"),
 	Entry("MarkMatchesWithMask", Match().MarkMatchesWithMask(0x400a, 0xf00f), "-m mark --mark 0x400a/0xf00f"),
 	Entry("NotMarkMatchesWithMask", Match().NotMarkMatchesWithMask(0x400a, 0xf00f), "-m mark ! --mark 0x400a/0xf00f"),
+	Entry("ConnMarkMatchesWithMask", Match().ConnMarkMatchesWithMask(0x400a, 0xf00f), "-m connmark --mark 0x400a/0xf00f"),
 	// Conntrack.
 	Entry("ConntrackState", Match().ConntrackState("INVALID"), "-m conntrack --ctstate INVALID"),
 	// Interfaces.
@@ -88,6 +99,11 @@ var _ = DescribeTable("MatchBuilder",
 	Entry("NotSourceIPPortSet", Match().NotSourceIPPortSet("calitn:12345abc-_"), "-m set ! --match-set calitn:12345abc-_ src,src"),
 	Entry("DestIPPortSet", Match().DestIPPortSet("calitn:12345abc-_"), "-m set --match-set calitn:12345abc-_ dst,dst"),
 	Entry("NotDestIPPortSet", Match().NotDestIPPortSet("calitn:12345abc-_"), "-m set ! --match-set calitn:12345abc-_ dst,dst"),
+	// Net,iface IP sets.
+	Entry("SourceNetIfaceSet", Match().SourceNetIfaceSet("calitn:12345abc-_"), "-m set --match-set calitn:12345abc-_ src,src"),
+	Entry("NotSourceNetIfaceSet", Match().NotSourceNetIfaceSet("calitn:12345abc-_"), "-m set ! --match-set calitn:12345abc-_ src,src"),
+	Entry("DestNetIfaceSet", Match().DestNetIfaceSet("calitn:12345abc-_"), "-m set --match-set calitn:12345abc-_ dst,dst"),
+	Entry("NotDestNetIfaceSet", Match().NotDestNetIfaceSet("calitn:12345abc-_"), "-m set ! --match-set calitn:12345abc-_ dst,dst"),
 	// Ports.
 	Entry("SourcePorts", Match().SourcePorts(1234, 5678), "-m multiport --source-ports 1234,5678"),
 	Entry("NotSourcePorts", Match().NotSourcePorts(1234, 5678), "-m multiport ! --source-ports 1234,5678"),
@@ -106,10 +122,26 @@ var _ = DescribeTable("MatchBuilder",
 	Entry("NotICMPV6Type", Match().NotICMPV6Type(123), "-m icmp6 ! --icmpv6-type 123"),
 	Entry("ICMPV6TypeAndCode", Match().ICMPV6TypeAndCode(123, 5), "-m icmp6 --icmpv6-type 123/5"),
 	Entry("NotICMPV6TypeAndCode", Match().NotICMPV6TypeAndCode(123, 5), "-m icmp6 ! --icmpv6-type 123/5"),
+	Entry("SCTPChunkTypes", Match().SCTPChunkTypes(SCTPChunkInit), "-m sctp --chunk-types any INIT"),
+	Entry("NotSCTPChunkTypes", Match().NotSCTPChunkTypes(SCTPChunkInit, SCTPChunkCookieEcho), "-m sctp ! --chunk-types any INIT,COOKIE_ECHO"),
 	// Check multiple match criteria are joined correctly.
 	Entry("Protocol and ports", Match().Protocol("tcp").SourcePorts(1234).DestPorts(8080),
 		"-p tcp -m multiport --source-ports 1234 -m multiport --destination-ports 8080"),
 	// IPVS.
 	Entry("IPVSConnection", Match().IPVSConnection(), "-m ipvs --ipvs"),
 	Entry("NotIPVSConnection", Match().NotIPVSConnection(), "-m ipvs ! --ipvs"),
+	// Rate and connection limits.
+	Entry("HashLimitAbove", Match().HashLimitAbove(HashLimit{Name: "cali-hl-abc", Mode: HashLimitModeSrcIP, RatePerSecond: 100, Burst: 20}),
+		"-m hashlimit --hashlimit-above 100/sec --hashlimit-burst 20 --hashlimit-mode srcip --hashlimit-name cali-hl-abc"),
+	Entry("HashLimitUpTo with prefix", Match().HashLimitUpTo(HashLimit{Name: "cali-hl-abc", Mode: HashLimitModeDstIP, RatePerSecond: 10, Burst: 5, PrefixLen: 24}),
+		"-m hashlimit --hashlimit-upto 10/sec --hashlimit-burst 5 --hashlimit-mode dstip --hashlimit-name cali-hl-abc --hashlimit-dstmask 24"),
+	Entry("ConnLimitAbove", Match().ConnLimitAbove(10, 32), "-m connlimit --connlimit-above 10 --connlimit-mask 32 --connlimit-saddr"),
+	Entry("ConnLimitUpTo", Match().ConnLimitUpTo(10, 24), "-m connlimit --connlimit-upto 10 --connlimit-mask 24 --connlimit-saddr"),
+	Entry("SocketTransparent", Match().SocketTransparent(), "-m socket --transparent"),
+	Entry("SourceIPRange", Match().SourceIPRange("10.0.0.1", "10.0.0.20"), "-m iprange --src-range 10.0.0.1-10.0.0.20"),
+	Entry("NotSourceIPRange", Match().NotSourceIPRange("10.0.0.1", "10.0.0.20"), "-m iprange ! --src-range 10.0.0.1-10.0.0.20"),
+	Entry("DestIPRange", Match().DestIPRange("fd00::1", "fd00::ff"), "-m iprange --dst-range fd00::1-fd00::ff"),
+	Entry("NotDestIPRange", Match().NotDestIPRange("fd00::1", "fd00::ff"), "-m iprange ! --dst-range fd00::1-fd00::ff"),
+	Entry("U32", Match().U32(U32Expr{U32At(0).ShiftRight(22).And(0x3C).Move(12).Equals(0x1234)}), "-m u32 --u32 0>>0x16&0x3c@0xc=0x1234"),
+	Entry("NotU32", Match().NotU32(U32Expr{U32At(6).And(0xFF).InRanges(U32Range{Min: 6, Max: 17})}), "-m u32 ! --u32 6&0xff=6:0x11"),
 )
@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptables
+
+import (
+	"strings"
+
+	"github.com/projectcalico/calico/felix/environment"
+)
+
+// ruleBodyCache caches the rendered bodies of rules, keyed on the content of the Rule.  The rule
+// renderer shares the Rules slices of chains that have identical content (for example, the
+// endpoint chains of workloads with the same policies) and many rules recur across chains so,
+// during a full resync, each distinct rule is rendered once rather than once per chain.
+//
+// The cache has two generations, which are rotated on each Apply(); entries that aren't used
+// for a whole generation are dropped, so the cache doesn't grow without bound.
+type ruleBodyCache struct {
+	features environment.Features
+	current  map[ruleKey]string
+	previous map[ruleKey]string
+}
+
+// ruleKey captures everything that a rule's rendered body depends on, other than the dataplane
+// features.  It's cheaper to build than the body itself, which needs the comments to be escaped
+// and the action to be formatted.  Actions are all comparable structs.
+type ruleKey struct {
+	match   string
+	action  Action
+	comment string
+}
+
+func keyOfRule(rule *Rule) ruleKey {
+	return ruleKey{
+		match:   rule.Match.Render(),
+		action:  rule.Action,
+		comment: strings.Join(rule.Comment, "\x00"),
+	}
+}
+
+func newRuleBodyCache() *ruleBodyCache {
+	return &ruleBodyCache{
+		current: map[ruleKey]string{},
+	}
+}
+
+// Get returns the rendered body of the given rule, rendering it if it isn't in the cache.
+func (c *ruleBodyCache) Get(rule *Rule, features *environment.Features) string {
+	if *features != c.features {
+		// Rendering depends on the dataplane features; start over if they've changed.
+		c.features = *features
+		c.current = map[ruleKey]string{}
+		c.previous = nil
+	}
+	key := keyOfRule(rule)
+	if body, ok := c.current[key]; ok {
+		return body
+	}
+	body, ok := c.previous[key]
+	if !ok {
+		body = rule.renderBody(features)
+	}
+	c.current[key] = body
+	return body
+}
+
+// Rotate starts a new generation, dropping any entries that weren't used in the previous one.
+func (c *ruleBodyCache) Rotate() {
+	if len(c.current) == 0 {
+		return
+	}
+	c.previous = c.current
+	c.current = map[ruleKey]string{}
+}
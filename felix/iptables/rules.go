@@ -67,6 +67,17 @@ func (r Rule) renderInner(fragments []string, prefixFragment string, features *e
 	if prefixFragment != "" {
 		fragments = append(fragments, prefixFragment)
 	}
+	if body := r.renderBody(features); body != "" {
+		fragments = append(fragments, body)
+	}
+	return strings.Join(fragments, " ")
+}
+
+// renderBody renders the part of the rule that follows the chain name and any prefix fragment:
+// its comments, match criteria and action.  It doesn't depend on the chain that the rule is in
+// so it can be cached and shared between chains that contain the same rules.
+func (r Rule) renderBody(features *environment.Features) string {
+	fragments := make([]string, 0, len(r.Comment)+2)
 	for _, c := range r.Comment {
 		c = escapeComment(c)
 		c = truncateComment(c)
@@ -117,6 +128,14 @@ type Chain struct {
 }
 
 func (c *Chain) RuleHashes(features *environment.Features) []string {
+	return c.ruleHashes(func(rule *Rule) string {
+		return rule.renderBody(features)
+	})
+}
+
+// ruleHashes calculates the rule hashes for the chain, using renderBody to render each rule's
+// body.  Allows the caller to substitute a cached rendering of the rule.
+func (c *Chain) ruleHashes(renderBody func(rule *Rule) string) []string {
 	if c == nil {
 		return nil
 	}
@@ -133,7 +152,8 @@ func (c *Chain) RuleHashes(features *environment.Features) []string {
 	}
 
 	hash := s.Sum(nil)
-	for ii, rule := range c.Rules {
+	for ii := range c.Rules {
+		rule := &c.Rules[ii]
 		// Each hash chains in the previous hash, so that its position in the chain and
 		// the rules before it affect its hash.
 		s.Reset()
@@ -145,7 +165,10 @@ func (c *Chain) RuleHashes(features *environment.Features) []string {
 				"chain":    c.Name,
 			}).WithError(err).Panic("Failed to write suffix to hash.")
 		}
-		ruleForHashing := rule.RenderAppend(c.Name, "HASH", features)
+		ruleForHashing := "-A " + c.Name + " HASH"
+		if body := renderBody(rule); body != "" {
+			ruleForHashing += " " + body
+		}
 		_, err = s.Write([]byte(ruleForHashing))
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -16,6 +16,8 @@ package iptables
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"io"
 	"strings"
@@ -26,6 +28,7 @@ import (
 
 	"github.com/projectcalico/calico/felix/environment"
 	"github.com/projectcalico/calico/felix/iptables/cmdshim"
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
 )
 
 var (
@@ -67,6 +70,79 @@ var _ = Describe("Rule hashing tests", func() {
 		Expect(len(calculateHashes("foo", rules2))).To(Equal(len(rules2)))
 		Expect(len(calculateHashes("foo", rules3))).To(Equal(len(rules3)))
 	})
+	It("should hash the same as the rendered append line", func() {
+		rules := []Rule{
+			{},
+			{Match: MatchCriteria{"-m foobar --foobar baz"}, Action: JumpAction{Target: "biff"}, Comment: []string{"a comment"}},
+			{Action: AcceptAction{}},
+		}
+		s := sha256.New224()
+		s.Write([]byte("chain"))
+		hash := s.Sum(nil)
+		var expected []string
+		for _, r := range rules {
+			s.Reset()
+			s.Write(hash)
+			s.Write([]byte(r.RenderAppend("chain", "HASH", &environment.Features{})))
+			hash = s.Sum(hash[0:0])
+			expected = append(expected, base64.RawURLEncoding.EncodeToString(hash)[:HashLength])
+		}
+		Expect(calculateHashes("chain", rules)).To(Equal(expected))
+	})
+})
+
+var _ = Describe("Rule body cache tests", func() {
+	var cache *ruleBodyCache
+	features := &environment.Features{}
+
+	BeforeEach(func() {
+		cache = newRuleBodyCache()
+	})
+
+	It("should render the same body as the rule", func() {
+		for i := range rules3 {
+			Expect(cache.Get(&rules3[i], features)).To(Equal(rules3[i].renderBody(features)))
+		}
+	})
+	It("should return the cached body for a rule with the same content", func() {
+		rules := []Rule{{Action: JumpAction{Target: "biff"}}, {Action: JumpAction{Target: "biff"}}}
+		Expect(cache.Get(&rules[0], features)).To(Equal("--jump biff"))
+		Expect(cache.Get(&rules[1], features)).To(Equal("--jump biff"))
+		Expect(cache.current).To(HaveLen(1))
+	})
+	It("should re-render a rule that has been modified in place", func() {
+		rules := []Rule{{Match: MatchCriteria{"-m foobar"}, Action: JumpAction{Target: "biff"}, Comment: []string{"a"}}}
+		Expect(cache.Get(&rules[0], features)).To(Equal(`-m comment --comment "a" -m foobar --jump biff`))
+		rules[0].Action = JumpAction{Target: "boff"}
+		Expect(cache.Get(&rules[0], features)).To(Equal(`-m comment --comment "a" -m foobar --jump boff`))
+		rules[0].Match[0] = "-m bazbiff"
+		Expect(cache.Get(&rules[0], features)).To(Equal(`-m comment --comment "a" -m bazbiff --jump boff`))
+		rules[0].Comment[0] = "b"
+		Expect(cache.Get(&rules[0], features)).To(Equal(`-m comment --comment "b" -m bazbiff --jump boff`))
+	})
+	It("should keep bodies that are used in each generation", func() {
+		rules := []Rule{{Action: JumpAction{Target: "biff"}}}
+		cache.Get(&rules[0], features)
+		cache.Rotate()
+		cache.Get(&rules[0], features)
+		cache.Rotate()
+		Expect(cache.previous).To(HaveKey(keyOfRule(&rules[0])))
+	})
+	It("should drop bodies that aren't used for a generation", func() {
+		rules := []Rule{{Action: JumpAction{Target: "biff"}}, {Action: AcceptAction{}}}
+		cache.Get(&rules[0], features)
+		cache.Rotate()
+		cache.Get(&rules[1], features)
+		cache.Rotate()
+		Expect(cache.previous).NotTo(HaveKey(keyOfRule(&rules[0])))
+		Expect(cache.previous).To(HaveKey(keyOfRule(&rules[1])))
+	})
+	It("should re-render if the features change", func() {
+		rules := []Rule{{Action: MasqAction{}}}
+		Expect(cache.Get(&rules[0], features)).To(Equal("--jump MASQUERADE"))
+		Expect(cache.Get(&rules[0], &environment.Features{MASQFullyRandom: true})).To(
+			Equal("--jump MASQUERADE --random-fully"))
+	})
 })
 
 var _ = Describe("Hash extraction tests", func() {
@@ -98,7 +174,7 @@ var _ = Describe("Hash extraction tests", func() {
 	})
 
 	It("should extract an old felix rule by prefix", func() {
-		hashes, rules, err := table.readHashesAndRulesFrom(newClosableBuf("-A FORWARD -j felix-FORWARD\n"))
+		hashes, rules, _, err := table.readHashesAndRulesFrom(newClosableBuf("-A FORWARD -j felix-FORWARD\n"))
 		Expect(err).NotTo(HaveOccurred())
 		Expect(hashes).To(Equal(map[string][]string{
 			"FORWARD": {"OLD INSERT RULE"},
@@ -108,7 +184,7 @@ var _ = Describe("Hash extraction tests", func() {
 		}))
 	})
 	It("should extract an old felix rule by special case", func() {
-		hashes, rules, err := table.readHashesAndRulesFrom(newClosableBuf(
+		hashes, rules, _, err := table.readHashesAndRulesFrom(newClosableBuf(
 			"-A FORWARD -j an-old-rule\n" +
 				"-A FORWARD -j ignore-me\n",
 		))
@@ -127,7 +203,7 @@ var _ = Describe("Hash extraction tests", func() {
 		}))
 	})
 	It("should extract a rule with a hash", func() {
-		hashes, rules, err := table.readHashesAndRulesFrom(newClosableBuf(
+		hashes, rules, _, err := table.readHashesAndRulesFrom(newClosableBuf(
 			"-A FORWARD -m comment --comment \"cali:wUHhoiAYhphO9Mso\" -j cali-FORWARD\n"))
 		Expect(err).NotTo(HaveOccurred())
 		Expect(hashes).To(Equal(map[string][]string{
@@ -140,7 +216,7 @@ var _ = Describe("Hash extraction tests", func() {
 		}))
 	})
 	It("should extract a hash or a gap from each rule", func() {
-		hashes, rules, err := table.readHashesAndRulesFrom(newClosableBuf(
+		hashes, rules, _, err := table.readHashesAndRulesFrom(newClosableBuf(
 			"-A FORWARD -m comment --comment \"cali:wUHhoiAYhphO9Mso\" -j cali-FORWARD\n" +
 				"-A FORWARD -m comment --comment \"cali:abcdefghij1234-_\" -j cali-FORWARD\n" +
 				"-A FORWARD --src '1.2.3.4'\n" +
@@ -165,7 +241,7 @@ var _ = Describe("Hash extraction tests", func() {
 	})
 
 	It("should handle multiple chains", func() {
-		hashes, rules, err := table.readHashesAndRulesFrom(newClosableBuf(
+		hashes, rules, _, err := table.readHashesAndRulesFrom(newClosableBuf(
 			"-A cali-abcd -m comment --comment \"cali:wUHhoiAYhphO9Mso\" -j cali-FORWARD\n" +
 				"-A cali-abcd -m comment --comment \"cali:abcdefghij1234-_\" -j cali-FORWARD\n" +
 				"-A FORWARD --src '1.2.3.4'\n" +
@@ -189,8 +265,20 @@ var _ = Describe("Hash extraction tests", func() {
 		}))
 	})
 
+	It("should extract the IP sets referenced by each chain", func() {
+		_, _, ipSetRefs, err := table.readHashesAndRulesFrom(newClosableBuf(
+			"-A cali-abcd -m set --match-set cali40s:a src -m set ! --match-set cali40s:b dst,dst -j DROP\n" +
+				"-A cali-abcd -m comment --comment \"cali:wUHhoiAYhphO9Mso\" -j ACCEPT\n" +
+				"-A FORWARD -m set --match-set cali40s:c src -j ACCEPT\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipSetRefs).To(Equal(map[string]set.Set[string]{
+			"cali-abcd": set.From("cali40s:a", "cali40s:b"),
+			"FORWARD":   set.From("cali40s:c"),
+		}))
+	})
+
 	It("should extract a rule with a hash and a label commeent", func() {
-		hashes, rules, err := table.readHashesAndRulesFrom(newClosableBuf(
+		hashes, rules, _, err := table.readHashesAndRulesFrom(newClosableBuf(
 			"-A FORWARD -m comment --comment \"cali:wUHhoiAYhphO9Mso\" -m comment --comment \"key=value\" -j cali-FORWARD\n"))
 		Expect(err).NotTo(HaveOccurred())
 		Expect(hashes).To(Equal(map[string][]string{
@@ -203,6 +291,55 @@ var _ = Describe("Hash extraction tests", func() {
 		}))
 	})
 
+	It("should extract a hash from an unquoted comment", func() {
+		hashes, _, _, err := table.readHashesAndRulesFrom(newClosableBuf(
+			"-A cali-abcd -m comment --comment cali:wUHhoiAYhphO9Mso -j MARK --set-xmark 0x10000/0x10000\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hashes).To(Equal(map[string][]string{
+			"cali-abcd": {"wUHhoiAYhphO9Mso"},
+		}))
+	})
+
+	It("should ignore hashes and IP sets inside other comments", func() {
+		hashes, _, ipSetRefs, err := table.readHashesAndRulesFrom(newClosableBuf(
+			"-A cali-abcd -m comment --comment \"was --comment \\\"cali:wUHhoiAYhphO9Mso\\\" --match-set foo\" -j ACCEPT\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hashes).To(Equal(map[string][]string{
+			"cali-abcd": {""},
+		}))
+		Expect(ipSetRefs).To(BeEmpty())
+	})
+
+	It("should skip lines that it doesn't understand", func() {
+		hashes, _, _, err := table.readHashesAndRulesFrom(newClosableBuf(
+			"*filter\n" +
+				":cali-abcd - [0:0]\n" +
+				"[unsupported revision]\n" +
+				"-X cali-abcd\n" +
+				"-A cali-abcd -m comment --comment \"cali:wUHhoiAYhphO9Mso\" -j ACCEPT\n" +
+				"COMMIT\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hashes).To(Equal(map[string][]string{
+			"cali-abcd": {"wUHhoiAYhphO9Mso"},
+		}))
+	})
+
+	It("should fall back to regexes for a rule that it fails to tokenize", func() {
+		hashes, rules, ipSetRefs, err := table.readHashesAndRulesFrom(newClosableBuf(
+			"-A FORWARD -m comment --comment \"cali:wUHhoiAYhphO9Mso\" -m set --match-set cali40s:a src -m comment --comment \"oops\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hashes).To(Equal(map[string][]string{
+			"FORWARD": {"wUHhoiAYhphO9Mso"},
+		}))
+		Expect(rules).To(Equal(map[string][]string{
+			"FORWARD": {
+				"-A FORWARD -m comment --comment \"cali:wUHhoiAYhphO9Mso\" -m set --match-set cali40s:a src -m comment --comment \"oops",
+			},
+		}))
+		Expect(ipSetRefs).To(Equal(map[string]set.Set[string]{
+			"FORWARD": set.From("cali40s:a"),
+		}))
+	})
 })
 
 var _ = Describe("rule comments", func() {
@@ -0,0 +1,179 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptables
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+type saveLineKind int
+
+const (
+	// saveLineIgnored covers blank lines, comments and the table header and footer.
+	saveLineIgnored saveLineKind = iota
+	// saveLineChain is a chain forward declaration, such as ":cali-FORWARD - [0:0]".
+	saveLineChain
+	// saveLineRule is a rule, such as "-A cali-FORWARD -m comment --comment "cali:abcd" -j DROP".
+	saveLineRule
+)
+
+// saveLine is a line of iptables-save output, split into tokens.
+type saveLine struct {
+	kind      saveLineKind
+	chainName string
+	// args holds the tokens of a rule that follow the chain name, with any quoting removed.
+	args []string
+}
+
+// comments returns the values of the rule's --comment options, in order.
+func (l saveLine) comments() []string {
+	return l.optionValues("--comment")
+}
+
+// ipSetNames returns the names of the IP sets that the rule matches on.  Negated matches are
+// included since "!" comes before the option.
+func (l saveLine) ipSetNames() []string {
+	return l.optionValues("--match-set")
+}
+
+func (l saveLine) optionValues(option string) (values []string) {
+	for i := 0; i < len(l.args)-1; i++ {
+		if l.args[i] == option {
+			values = append(values, l.args[i+1])
+			i++
+		}
+	}
+	return
+}
+
+// hashFromComments returns the rule hash from the first comment that has the given prefix, or ""
+// if there isn't one.  Hashes are made up of the characters of the URL-safe base64 alphabet;
+// anything following the hash is ignored, in line with what we used to accept before tokenizing.
+func (l saveLine) hashFromComments(hashPrefix string) string {
+	for _, comment := range l.comments() {
+		if len(comment) <= len(hashPrefix) || comment[:len(hashPrefix)] != hashPrefix {
+			continue
+		}
+		comment = comment[len(hashPrefix):]
+		end := 0
+		for end < len(comment) && isHashChar(comment[end]) {
+			end++
+		}
+		if end > 0 {
+			return comment[:end]
+		}
+	}
+	return ""
+}
+
+func isHashChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// saveParseWarning records a line of iptables-save output that we failed to parse.
+type saveParseWarning struct {
+	lineNum int
+	line    string
+	err     error
+}
+
+// parseSaveLine classifies and tokenizes a line of iptables-save output.  It returns an error
+// for lines that it doesn't understand; for rules, the error may be accompanied by the chain
+// name if that much could be parsed, so that the caller can still account for the rule.
+func parseSaveLine(line []byte) (saveLine, error) {
+	line = bytes.TrimRight(line, " \t\r")
+	if len(line) == 0 {
+		return saveLine{kind: saveLineIgnored}, nil
+	}
+	switch line[0] {
+	case '#', '*':
+		return saveLine{kind: saveLineIgnored}, nil
+	case ':':
+		// ":chain-name POLICY [packets:bytes]".  Older versions of iptables-nft omit the
+		// counters so we only rely on the name.
+		tokens, err := tokenizeSaveLine(line[1:])
+		if err != nil {
+			return saveLine{}, err
+		}
+		if len(tokens) == 0 {
+			return saveLine{}, errors.New("chain declaration has no name")
+		}
+		return saveLine{kind: saveLineChain, chainName: tokens[0]}, nil
+	case '-':
+		// Rules are always written as appends.
+		fields := bytes.Fields(line)
+		if !bytes.Equal(fields[0], []byte("-A")) {
+			return saveLine{}, fmt.Errorf("unexpected operation %q", fields[0])
+		}
+		if len(fields) < 2 {
+			return saveLine{}, errors.New("rule has no chain name")
+		}
+		l := saveLine{kind: saveLineRule, chainName: string(fields[1])}
+		rest := bytes.TrimLeft(line[len(fields[0]):], " \t")
+		rest = rest[len(fields[1]):]
+		args, err := tokenizeSaveLine(rest)
+		if err != nil {
+			return l, err
+		}
+		l.args = args
+		return l, nil
+	}
+	if bytes.Equal(line, []byte("COMMIT")) {
+		return saveLine{kind: saveLineIgnored}, nil
+	}
+	return saveLine{}, errors.New("unrecognised line")
+}
+
+// tokenizeSaveLine splits a line on whitespace, taking account of quoting.  iptables-save
+// double-quotes strings that contain whitespace or quotes (and, in older versions, all
+// comments), escaping any double quotes and backslashes.  We also accept single quotes, as
+// iptables-restore does.
+func tokenizeSaveLine(line []byte) ([]string, error) {
+	var tokens []string
+	var token []byte
+	inToken := false
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\' && i+1 < len(line):
+			i++
+			token = append(token, line[i])
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+			inToken = true
+		case quote == 0 && (c == ' ' || c == '\t'):
+			if inToken {
+				tokens = append(tokens, string(token))
+				token = token[:0]
+				inToken = false
+			}
+		default:
+			token = append(token, c)
+			inToken = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inToken {
+		tokens = append(tokens, string(token))
+	}
+	return tokens, nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptables
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("iptables-save line parsing",
+	func(line string, expected saveLine) {
+		parsed, err := parseSaveLine([]byte(line))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(Equal(expected))
+	},
+	Entry("blank line", "", saveLine{kind: saveLineIgnored}),
+	Entry("comment", "# Generated by iptables-save v1.8.10", saveLine{kind: saveLineIgnored}),
+	Entry("table header", "*filter", saveLine{kind: saveLineIgnored}),
+	Entry("table footer", "COMMIT", saveLine{kind: saveLineIgnored}),
+	Entry("chain declaration", ":cali-FORWARD - [0:0]", saveLine{kind: saveLineChain, chainName: "cali-FORWARD"}),
+	Entry("chain declaration without counters", ":FORWARD ACCEPT", saveLine{kind: saveLineChain, chainName: "FORWARD"}),
+	Entry("rule", "-A FORWARD -j ACCEPT", saveLine{
+		kind: saveLineRule, chainName: "FORWARD", args: []string{"-j", "ACCEPT"},
+	}),
+	Entry("rule with quoted comment", `-A FORWARD -m comment --comment "a \"quoted\" \\ comment" -j DROP`, saveLine{
+		kind: saveLineRule, chainName: "FORWARD",
+		args: []string{"-m", "comment", "--comment", `a "quoted" \ comment`, "-j", "DROP"},
+	}),
+	Entry("rule with single-quoted value", `-A FORWARD --src '1.2.3.4'`, saveLine{
+		kind: saveLineRule, chainName: "FORWARD", args: []string{"--src", "1.2.3.4"},
+	}),
+	Entry("rule with trailing carriage return", "-A FORWARD  -j ACCEPT\r", saveLine{
+		kind: saveLineRule, chainName: "FORWARD", args: []string{"-j", "ACCEPT"},
+	}),
+	Entry("rule with empty quoted value", `-A FORWARD -m comment --comment "" -j ACCEPT`, saveLine{
+		kind: saveLineRule, chainName: "FORWARD", args: []string{"-m", "comment", "--comment", "", "-j", "ACCEPT"},
+	}),
+)
+
+var _ = DescribeTable("iptables-save line parsing errors",
+	func(line string, expectedKind saveLineKind) {
+		parsed, err := parseSaveLine([]byte(line))
+		Expect(err).To(HaveOccurred())
+		Expect(parsed.kind).To(Equal(expectedKind))
+	},
+	Entry("unknown line", "[unsupported revision]", saveLineIgnored),
+	Entry("non-append operation", "-I FORWARD -j ACCEPT", saveLineIgnored),
+	Entry("append without chain", "-A", saveLineIgnored),
+	Entry("unterminated quote", `-A FORWARD -m comment --comment "oops`, saveLineRule),
+)
+
+var _ = Describe("saveLine", func() {
+	It("should extract the hash from the first comment with the prefix", func() {
+		l := saveLine{kind: saveLineRule, chainName: "FORWARD", args: []string{
+			"-m", "comment", "--comment", "policy=foo",
+			"-m", "comment", "--comment", "cali:abcd-_1234",
+			"-m", "comment", "--comment", "cali:efgh",
+		}}
+		Expect(l.hashFromComments("cali:")).To(Equal("abcd-_1234"))
+	})
+	It("should ignore a bare prefix", func() {
+		l := saveLine{kind: saveLineRule, chainName: "FORWARD", args: []string{"--comment", "cali:"}}
+		Expect(l.hashFromComments("cali:")).To(Equal(""))
+	})
+	It("should extract negated and non-negated IP set names", func() {
+		l, err := parseSaveLine([]byte("-A cali-abcd -m set --match-set a src -m set ! --match-set b dst,dst -j DROP"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l.ipSetNames()).To(Equal([]string{"a", "b"}))
+	})
+})
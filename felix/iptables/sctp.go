@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptables
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SCTPChunkType is the name of an SCTP chunk type, as understood by the sctp match.
+type SCTPChunkType string
+
+const (
+	SCTPChunkData             SCTPChunkType = "DATA"
+	SCTPChunkInit             SCTPChunkType = "INIT"
+	SCTPChunkInitAck          SCTPChunkType = "INIT_ACK"
+	SCTPChunkSack             SCTPChunkType = "SACK"
+	SCTPChunkHeartbeat        SCTPChunkType = "HEARTBEAT"
+	SCTPChunkHeartbeatAck     SCTPChunkType = "HEARTBEAT_ACK"
+	SCTPChunkAbort            SCTPChunkType = "ABORT"
+	SCTPChunkShutdown         SCTPChunkType = "SHUTDOWN"
+	SCTPChunkShutdownAck      SCTPChunkType = "SHUTDOWN_ACK"
+	SCTPChunkError            SCTPChunkType = "ERROR"
+	SCTPChunkCookieEcho       SCTPChunkType = "COOKIE_ECHO"
+	SCTPChunkCookieAck        SCTPChunkType = "COOKIE_ACK"
+	SCTPChunkShutdownComplete SCTPChunkType = "SHUTDOWN_COMPLETE"
+	SCTPChunkASCONF           SCTPChunkType = "ASCONF"
+	SCTPChunkASCONFAck        SCTPChunkType = "ASCONF_ACK"
+	SCTPChunkForwardTSN       SCTPChunkType = "FORWARD_TSN"
+)
+
+var knownSCTPChunkTypes = map[SCTPChunkType]bool{
+	SCTPChunkData:             true,
+	SCTPChunkInit:             true,
+	SCTPChunkInitAck:          true,
+	SCTPChunkSack:             true,
+	SCTPChunkHeartbeat:        true,
+	SCTPChunkHeartbeatAck:     true,
+	SCTPChunkAbort:            true,
+	SCTPChunkShutdown:         true,
+	SCTPChunkShutdownAck:      true,
+	SCTPChunkError:            true,
+	SCTPChunkCookieEcho:       true,
+	SCTPChunkCookieAck:        true,
+	SCTPChunkShutdownComplete: true,
+	SCTPChunkASCONF:           true,
+	SCTPChunkASCONFAck:        true,
+	SCTPChunkForwardTSN:       true,
+}
+
+// ParseSCTPChunkTypes parses a comma-separated list of SCTP chunk type names, such as
+// "INIT,COOKIE_ECHO".  Names are case-insensitive.
+func ParseSCTPChunkTypes(s string) ([]SCTPChunkType, error) {
+	var chunkTypes []SCTPChunkType
+	for _, name := range strings.Split(s, ",") {
+		chunkType := SCTPChunkType(strings.ToUpper(strings.TrimSpace(name)))
+		if chunkType == "" {
+			continue
+		}
+		if !knownSCTPChunkTypes[chunkType] {
+			return nil, fmt.Errorf("unknown SCTP chunk type %q", name)
+		}
+		chunkTypes = append(chunkTypes, chunkType)
+	}
+	if len(chunkTypes) == 0 {
+		return nil, errors.New("no SCTP chunk types")
+	}
+	return chunkTypes, nil
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptables_test
+
+import (
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/projectcalico/calico/felix/iptables"
+)
+
+var _ = DescribeTable("SCTP chunk type parsing",
+	func(s string, expected []SCTPChunkType) {
+		chunkTypes, err := ParseSCTPChunkTypes(s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(chunkTypes).To(Equal(expected))
+	},
+	Entry("single type", "INIT", []SCTPChunkType{SCTPChunkInit}),
+	Entry("lower case with spaces", "init, cookie_echo", []SCTPChunkType{SCTPChunkInit, SCTPChunkCookieEcho}),
+)
+
+var _ = DescribeTable("SCTP chunk type parsing errors",
+	func(s string) {
+		_, err := ParseSCTPChunkTypes(s)
+		Expect(err).To(HaveOccurred())
+	},
+	Entry("empty", ""),
+	Entry("only separators", " , "),
+	Entry("unknown type", "INIT,SYN"),
+)
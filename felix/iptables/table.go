@@ -40,6 +40,10 @@ import (
 const (
 	MaxChainNameLength   = 28
 	minPostWriteInterval = 50 * time.Millisecond
+
+	// PersistentFailureRetryInterval is how long a Table asks to wait before trying again after it
+	// gives up on programming the dataplane; see TableOptions.OnPersistentFailure.
+	PersistentFailureRetryInterval = 10 * time.Second
 )
 
 var (
@@ -51,11 +55,9 @@ var (
 		"raw":    {"PREROUTING", "OUTPUT"},
 	}
 
-	// chainCreateRegexp matches iptables-save output lines for chain forward reference lines.
-	// It captures the name of the chain.
-	chainCreateRegexp = regexp.MustCompile(`^:(\S+)`)
-	// appendRegexp matches an iptables-save output line for an append operation.
-	appendRegexp = regexp.MustCompile(`^-A (\S+)`)
+	// ipSetMatchRegexp matches an IP set match in a rule, capturing the name of the IP set.  Only
+	// used for rules that we fail to tokenize.
+	ipSetMatchRegexp = regexp.MustCompile(`--match-set (\S+)`)
 	// nftErrorRegexp matches a particular error emitted if iptables-nft is run on a system that
 	// uses nft features that iptables-nft doesn't understand.
 	nftErrorRegexp = regexp.MustCompile(`^# Table .* is incompatible, use 'nft' tool.`)
@@ -89,6 +91,44 @@ var (
 		Name: "felix_iptables_lines_executed",
 		Help: "Number of iptables rule updates executed.",
 	}, []string{"ip_version", "table"})
+	histogramVecApplySeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "felix_iptables_apply_seconds",
+		Help: "Time taken to apply pending updates to an iptables table, including retries.",
+	}, []string{"ip_version", "table"})
+	countVecChainUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "felix_iptables_chain_updates",
+		Help: "Number of successful updates to iptables chains, by chain group.",
+	}, []string{"ip_version", "table", "chain_group"})
+	countVecChainUpdateErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "felix_iptables_chain_update_errors",
+		Help: "Number of updates to iptables chains, by chain group, that were part of a failed iptables-restore call.",
+	}, []string{"ip_version", "table", "chain_group"})
+	gaugeVecChainRules = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "felix_iptables_chain_rules",
+		Help: "Number of rules that Felix programs into iptables chains, by chain group.",
+	}, []string{"ip_version", "table", "chain_group"})
+	histogramVecRestoreBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "felix_iptables_restore_bytes",
+		Help:    "Number of bytes written to each iptables-restore call.",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 10),
+	}, []string{"ip_version", "table"})
+	countVecSaveParseWarnings = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "felix_iptables_save_parse_warnings",
+		Help: "Number of lines of iptables-save output that could not be fully parsed.",
+	}, []string{"ip_version", "table"})
+	gaugeVecDriftedChains = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "felix_iptables_drifted_chains",
+		Help: "Number of iptables chains that differed from the expected state at the last audit-only resync.",
+	}, []string{"ip_version", "table"})
+	histogramVecLockWaitSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "felix_iptables_lock_wait_seconds",
+		Help:    "Time spent waiting for Felix's own xtables lock before each iptables-restore call.  Not used when iptables-restore takes the lock itself.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 8),
+	}, []string{"ip_version", "table"})
+	countVecRestoreLockContention = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "felix_iptables_restore_lock_contention",
+		Help: "Number of iptables-restore calls that reported waiting for, or timing out on, the xtables lock.",
+	}, []string{"ip_version", "table"})
 )
 
 func init() {
@@ -99,6 +139,15 @@ func init() {
 	prometheus.MustRegister(gaugeNumChains)
 	prometheus.MustRegister(gaugeNumRules)
 	prometheus.MustRegister(countNumLinesExecuted)
+	prometheus.MustRegister(histogramVecRestoreBytes)
+	prometheus.MustRegister(histogramVecApplySeconds)
+	prometheus.MustRegister(countVecChainUpdates)
+	prometheus.MustRegister(countVecChainUpdateErrors)
+	prometheus.MustRegister(gaugeVecChainRules)
+	prometheus.MustRegister(histogramVecLockWaitSeconds)
+	prometheus.MustRegister(countVecRestoreLockContention)
+	prometheus.MustRegister(gaugeVecDriftedChains)
+	prometheus.MustRegister(countVecSaveParseWarnings)
 }
 
 // Table represents a single one of the iptables tables i.e. "raw", "nat", "filter", etc.  It
@@ -225,9 +274,25 @@ type Table struct {
 	// to slices of rules in that chain.
 	chainToFullRules map[string][]string
 
+	// chainToIPSetRefs contains the names of the IP sets that are referenced by the rules in each
+	// chain, as we think they are in the dataplane.  Chains that don't reference any IP sets are
+	// omitted.
+	chainToIPSetRefs map[string]set.Set[string]
+
+	// chainGroup maps the name of one of our chains to the group that it's counted under in the
+	// per-chain metrics; see TableOptions.ChainGroup.
+	chainGroup func(chainName string) string
+	// chainGroupToNumRules holds the number of rules in each chain group, as last reported.
+	chainGroupToNumRules map[string]int
+
+	// ruleBodies caches the rendered bodies of the rules in our chains, so that rules that recur
+	// in several chains are only rendered once.
+	ruleBodies *ruleBodyCache
+
 	// hashCommentPrefix holds the prefix that we prepend to our rule-tracking hashes.
 	hashCommentPrefix string
-	// hashCommentRegexp matches the rule-tracking comment, capturing the rule hash.
+	// hashCommentRegexp matches the rule-tracking comment, capturing the rule hash.  Only used for
+	// rules that we fail to tokenize.
 	hashCommentRegexp *regexp.Regexp
 	// ourChainsRegexp matches the names of chains that are "ours", i.e. start with one of our
 	// prefixes.
@@ -260,15 +325,34 @@ type Table struct {
 	// lockTimeout is the lock probe interval used for iptables-restore's native xtables lock
 	// implementation.
 	lockProbeInterval time.Duration
+	// nativeLockBackoff backs off the lock probe interval that we pass to iptables-restore while
+	// other processes keep it waiting for the xtables lock.
+	nativeLockBackoff lockBackoff
 
 	logCxt *log.Entry
 
 	gaugeNumChains        prometheus.Gauge
 	gaugeNumRules         prometheus.Gauge
 	countNumLinesExecuted prometheus.Counter
-
-	// Reusable buffer for writing to iptables.
-	restoreInputBuffer RestoreInputBuilder
+	restorePayloadBytes   prometheus.Observer
+	histApplySeconds      prometheus.Observer
+	histLockWaitSeconds   prometheus.Observer
+	countLockContention   prometheus.Counter
+	gaugeDriftedChains    prometheus.Gauge
+	countParseWarnings    prometheus.Counter
+
+	// auditOnly is set if resyncs should only report drift; see TableOptions.AuditOnly.
+	auditOnly bool
+	// loadedDataplaneState is set once we've done the first resync, which always repairs.
+	loadedDataplaneState bool
+
+	// onPersistentFailure is called if we give up on programming iptables; see
+	// TableOptions.OnPersistentFailure.
+	onPersistentFailure func(err error)
+
+	// Reusable buffers for writing to iptables; chain deletions go in a separate iptables-restore.
+	restoreInputBuffer  RestoreInputBuilder
+	deletionInputBuffer RestoreInputBuilder
 
 	// Factory for making commands, used by UTs to shim exec.Command().
 	newCmd cmdshim.CmdFactory
@@ -289,6 +373,18 @@ type TableOptions struct {
 	InsertMode               string
 	RefreshInterval          time.Duration
 	PostWriteInterval        time.Duration
+	// AuditOnly makes resyncs, after the first, report chains that have drifted from the
+	// expected state instead of repairing them; for use where another agent owns remediation.
+	// Drifted chains are still rewritten if we have our own updates to make to them.
+	AuditOnly bool
+	// ChainGroup, if non-nil, maps the name of one of our chains to the group that it's counted
+	// under in the per-chain metrics.  Chain names come and go so they aren't used as metric
+	// labels; updates to individual chains are logged at debug level instead.
+	ChainGroup func(chainName string) string
+	// OnPersistentFailure, if non-nil, is called when the Table fails to program iptables even
+	// after retries, instead of panicking.  The Table leaves the dataplane as it is, keeps its
+	// pending updates queued and asks to be rescheduled so that it can try again later.
+	OnPersistentFailure func(err error)
 
 	// LockTimeout is the timeout to use for iptables-restore's native xtables lock.
 	LockTimeout time.Duration
@@ -385,6 +481,10 @@ func NewTable(
 	if options.LookPathOverride != nil {
 		lookPath = options.LookPathOverride
 	}
+	chainGroup := options.ChainGroup
+	if chainGroup == nil {
+		chainGroup = func(string) string { return "other" }
+	}
 
 	table := &Table{
 		Name:                   name,
@@ -398,6 +498,10 @@ func NewTable(
 		dirtyChains:            set.New[string](),
 		chainToDataplaneHashes: map[string][]string{},
 		chainToFullRules:       map[string][]string{},
+		chainToIPSetRefs:       map[string]set.Set[string]{},
+		chainGroup:             chainGroup,
+		chainGroupToNumRules:   map[string]int{},
+		ruleBodies:             newRuleBodyCache(),
 		logCxt: log.WithFields(log.Fields{
 			"ipVersion": ipVersion,
 			"table":     name,
@@ -422,6 +526,7 @@ func NewTable(
 
 		lockTimeout:       options.LockTimeout,
 		lockProbeInterval: options.LockProbeInterval,
+		nativeLockBackoff: newLockBackoff(options.LockProbeInterval),
 
 		newCmd:    newCmd,
 		timeSleep: sleep,
@@ -431,9 +536,18 @@ func NewTable(
 		gaugeNumChains:        gaugeNumChains.WithLabelValues(fmt.Sprintf("%d", ipVersion), name),
 		gaugeNumRules:         gaugeNumRules.WithLabelValues(fmt.Sprintf("%d", ipVersion), name),
 		countNumLinesExecuted: countNumLinesExecuted.WithLabelValues(fmt.Sprintf("%d", ipVersion), name),
+		restorePayloadBytes:   histogramVecRestoreBytes.WithLabelValues(fmt.Sprintf("%d", ipVersion), name),
+		histApplySeconds:      histogramVecApplySeconds.WithLabelValues(fmt.Sprintf("%d", ipVersion), name),
+		histLockWaitSeconds:   histogramVecLockWaitSeconds.WithLabelValues(fmt.Sprintf("%d", ipVersion), name),
+		countLockContention:   countVecRestoreLockContention.WithLabelValues(fmt.Sprintf("%d", ipVersion), name),
+		gaugeDriftedChains:    gaugeVecDriftedChains.WithLabelValues(fmt.Sprintf("%d", ipVersion), name),
+		countParseWarnings:    countVecSaveParseWarnings.WithLabelValues(fmt.Sprintf("%d", ipVersion), name),
+		auditOnly:             options.AuditOnly,
+		onPersistentFailure:   options.OnPersistentFailure,
 		opReporter:            options.OpRecorder,
 	}
 	table.restoreInputBuffer.NumLinesWritten = table.countNumLinesExecuted
+	table.deletionInputBuffer.NumLinesWritten = table.countNumLinesExecuted
 
 	if options.OnStillAlive != nil {
 		table.onStillAlive = options.OnStillAlive
@@ -456,6 +570,17 @@ func NewTable(
 	return table
 }
 
+// TableName returns the name of the table.  It is a method, rather than the Name field, so that
+// the table can be used through interfaces that it shares with the other rules backend.
+func (t *Table) TableName() string {
+	return t.Name
+}
+
+// TableIPVersion returns the IP version of the table; see TableName.
+func (t *Table) TableIPVersion() uint8 {
+	return t.IPVersion
+}
+
 // Insert or Append rules based on insert mode configuration.
 func (t *Table) InsertOrAppendRules(chainName string, rules []Rule) {
 	t.logCxt.WithField("chainName", chainName).Debug("Updating rule insertions")
@@ -504,6 +629,8 @@ func (t *Table) UpdateChains(chains []*Chain) {
 	}
 }
 
+// UpdateChain queues an update of the given chain.  The Table keeps a reference to the chain so
+// the chain and its rules must not be modified after it is passed in.
 func (t *Table) UpdateChain(chain *Chain) {
 	t.logCxt.WithField("chainName", chain.Name).Info("Queueing update of chain.")
 	oldNumRules := 0
@@ -605,8 +732,28 @@ func (t *Table) loadDataplaneState() {
 	t.opReporter.RecordOperation(fmt.Sprintf("resync-%v-v%d", t.Name, t.IPVersion))
 
 	t.lastReadTime = t.timeNow()
-	dataplaneHashes, dataplaneRules := t.getHashesAndRulesFromDataplane()
+	dataplaneHashes, dataplaneRules, dataplaneIPSetRefs := t.getHashesAndRulesFromDataplane()
+
+	if t.auditOnly && t.loadedDataplaneState {
+		// In audit-only mode, we report chains that are out of sync rather than marking them
+		// for refresh.  The first resync always repairs since it's cleaning up after a previous
+		// run.
+		t.gaugeDriftedChains.Set(float64(t.auditDataplaneState(dataplaneHashes)))
+	} else {
+		t.markOutOfSyncChainsDirty(dataplaneHashes)
+	}
 
+	t.logCxt.Debug("Finished loading iptables state")
+	t.chainToDataplaneHashes = dataplaneHashes
+	t.chainToFullRules = dataplaneRules
+	t.chainToIPSetRefs = dataplaneIPSetRefs
+	t.inSyncWithDataPlane = true
+	t.loadedDataplaneState = true
+}
+
+// markOutOfSyncChainsDirty compares the state loaded from the dataplane with the state we last
+// programmed and marks any chains that differ as dirty.
+func (t *Table) markOutOfSyncChainsDirty(dataplaneHashes map[string][]string) {
 	// Check that the rules we think we've programmed are still there and mark any inconsistent
 	// chains for refresh.
 	for chainName, expectedHashes := range t.chainToDataplaneHashes {
@@ -695,11 +842,58 @@ func (t *Table) loadDataplaneState() {
 		logCxt.Info("Found unexpected chain, marking for cleanup")
 		t.dirtyChains.Add(chainName)
 	}
+}
 
-	t.logCxt.Debug("Finished loading iptables state")
-	t.chainToDataplaneHashes = dataplaneHashes
-	t.chainToFullRules = dataplaneRules
-	t.inSyncWithDataPlane = true
+// auditDataplaneState compares the state loaded from the dataplane with our desired state and
+// logs any chains that differ, without marking them dirty.  Unlike markOutOfSyncChainsDirty, it
+// can't compare against the state we last programmed since, when we don't repair, that is
+// replaced by whatever we find in the dataplane.  Returns the number of chains that differ.
+func (t *Table) auditDataplaneState(dataplaneHashes map[string][]string) int {
+	features := t.featureDetector.GetFeatures()
+	chainNames := set.New[string]()
+	for chainName := range dataplaneHashes {
+		chainNames.Add(chainName)
+	}
+	for chainName := range t.chainNameToChain {
+		chainNames.Add(chainName)
+	}
+	for chainName := range t.chainToInsertedRules {
+		chainNames.Add(chainName)
+	}
+	for chainName := range t.chainToAppendedRules {
+		chainNames.Add(chainName)
+	}
+
+	numDrifted := 0
+	chainNames.Iter(func(chainName string) error {
+		if t.dirtyChains.Contains(chainName) || t.dirtyInsertAppend.Contains(chainName) {
+			// Update pending, which will overwrite whatever is there.
+			return nil
+		}
+		dpHashes, inDataplane := dataplaneHashes[chainName]
+		var expectedHashes []string
+		if !t.ourChainsRegexp.MatchString(chainName) {
+			expectedHashes, _, _ = t.expectedHashesForInsertAppendChain(chainName, numEmptyStrings(dpHashes))
+			if len(dpHashes) == 0 && len(expectedHashes) == 0 || reflect.DeepEqual(dpHashes, expectedHashes) {
+				return nil
+			}
+		} else if chain, ok := t.desiredStateOfChain(chainName); ok {
+			expectedHashes = t.ruleHashes(chain, features)
+			if inDataplane && (len(dpHashes) == 0 && len(expectedHashes) == 0 || reflect.DeepEqual(dpHashes, expectedHashes)) {
+				return nil
+			}
+		} else if !inDataplane {
+			return nil
+		}
+		t.logCxt.WithFields(log.Fields{
+			"chainName":       chainName,
+			"expectedRuleIDs": expectedHashes,
+			"actualRuleIDs":   dpHashes,
+		}).Warn("Detected out-of-sync chain, not repairing in audit-only mode")
+		numDrifted++
+		return nil
+	})
+	return numDrifted
 }
 
 // expectedHashesForInsertAppendChain calculates the expected hashes for a whole top-level chain
@@ -747,7 +941,7 @@ func (t *Table) expectedHashesForInsertAppendChain(
 // represented by an empty string. The 'rules' map contains an entry for each non-Calico chain in the table that
 // contains inserts. It is used to generate deletes using the full rule, rather than deletes by line number, to avoid
 // race conditions on chains we don't fully control.
-func (t *Table) getHashesAndRulesFromDataplane() (hashes map[string][]string, rules map[string][]string) {
+func (t *Table) getHashesAndRulesFromDataplane() (hashes map[string][]string, rules map[string][]string, ipSetRefs map[string]set.Set[string]) {
 	retries := 3
 	retryDelay := 100 * time.Millisecond
 
@@ -755,7 +949,7 @@ func (t *Table) getHashesAndRulesFromDataplane() (hashes map[string][]string, ru
 	// us from spamming a panic into the log when we're being gracefully shut down by a SIGTERM.
 	for {
 		t.onStillAlive()
-		hashes, rules, err := t.attemptToGetHashesAndRulesFromDataplane()
+		hashes, rules, ipSetRefs, err := t.attemptToGetHashesAndRulesFromDataplane()
 		if err != nil {
 			countNumSaveErrors.Inc()
 			var stderr string
@@ -773,13 +967,13 @@ func (t *Table) getHashesAndRulesFromDataplane() (hashes map[string][]string, ru
 			continue
 		}
 
-		return hashes, rules
+		return hashes, rules, ipSetRefs
 	}
 }
 
 // attemptToGetHashesAndRulesFromDataplane starts an iptables-save subprocess and feeds its output to
 // readHashesAndRulesFrom() via a pipe.  It handles the various error cases.
-func (t *Table) attemptToGetHashesAndRulesFromDataplane() (hashes map[string][]string, rules map[string][]string, err error) {
+func (t *Table) attemptToGetHashesAndRulesFromDataplane() (hashes map[string][]string, rules map[string][]string, ipSetRefs map[string]set.Set[string], err error) {
 	cmd := t.newCmd(t.iptablesSaveCmd, "-t", t.Name)
 	countNumSaveCalls.Inc()
 
@@ -799,7 +993,7 @@ func (t *Table) attemptToGetHashesAndRulesFromDataplane() (hashes map[string][]s
 		}
 		return
 	}
-	hashes, rules, err = t.readHashesAndRulesFrom(stdout)
+	hashes, rules, ipSetRefs, err = t.readHashesAndRulesFrom(stdout)
 	if err != nil {
 		// In case readHashesAndRulesFrom() returned due to an error that didn't cause the
 		// process to exit, kill it now.
@@ -822,20 +1016,25 @@ func (t *Table) attemptToGetHashesAndRulesFromDataplane() (hashes map[string][]s
 }
 
 // readHashesAndRulesFrom scans the given reader containing iptables-save output for this table, extracting
-// our rule hashes and, for all chains we insert into, the full rules.  Entries in the returned map are indexed by
-// chain name.  For rules that we wrote, the hash is extracted from a comment that we added to the rule.
+// our rule hashes, the IP sets that are referenced by each chain and, for all chains we insert into, the full
+// rules.  Entries in the returned maps are indexed by chain name.  For rules that we wrote, the hash is extracted from a comment that we added to the rule.
 // For rules written by previous versions of Felix, returns a dummy non-zero value.  For rules not written by Felix,
 // returns a zero string.  Hence, the lengths of the returned values are the lengths of the chains
 // whether written by Felix or not.
-func (t *Table) readHashesAndRulesFrom(r io.ReadCloser) (hashes map[string][]string, rules map[string][]string, err error) {
+func (t *Table) readHashesAndRulesFrom(r io.ReadCloser) (hashes map[string][]string, rules map[string][]string, ipSetRefs map[string]set.Set[string], err error) {
 	hashes = map[string][]string{}
 	rules = map[string][]string{}
+	ipSetRefs = map[string]set.Set[string]{}
 	scanner := bufio.NewScanner(r)
 
 	// Keep track of whether the non-Calico chain has inserts. If the chain does not have inserts, we'll remove the
 	// full rules for that chain.
 	chainHasCalicoRule := set.New[string]()
 
+	// Lines that we couldn't parse; logged once we've finished reading.
+	var warnings []saveParseWarning
+	lineNum := 0
+
 	// Figure out if debug logging is enabled so we can skip some WithFields() calls in the
 	// tight loop below if the log wouldn't be emitted anyway.
 	debug := log.GetLevel() >= log.DebugLevel
@@ -856,46 +1055,63 @@ func (t *Table) readHashesAndRulesFrom(r io.ReadCloser) (hashes map[string][]str
 		if nftErrorRegexp.Match(line) {
 			logCxt.Error("iptables-save failed because there are incompatible nft rules in the table.  " +
 				"Remove the nft rules to continue.")
-			return nil, nil, errors.New(
+			return nil, nil, nil, errors.New(
 				"iptables-save failed because there are incompatible nft rules in the table")
 		}
 
-		// Look for lines of the form ":chain-name - [0:0]", which are forward declarations
-		// for (possibly empty) chains.
-		captures := chainCreateRegexp.FindSubmatch(line)
-		if captures != nil {
+		lineNum++
+		parsed, err := parseSaveLine(line)
+		if err != nil {
+			// Newer versions of iptables (and rules that iptables-nft has translated from
+			// native nftables) can produce output that we don't understand.  Rather than
+			// failing the whole read, which would leave us retrying forever, we skip the
+			// line, or, for a rule that we failed to tokenize, fall back to picking out
+			// what we need with regexes.
+			warnings = append(warnings, saveParseWarning{lineNum: lineNum, line: string(line), err: err})
+			if parsed.kind != saveLineRule {
+				continue
+			}
+		}
+
+		if parsed.kind == saveLineChain {
 			// Chain forward-reference, make sure the chain exists.
-			chainName := string(captures[1])
 			if debug {
-				logCxt.WithField("chainName", chainName).Debug("Found forward-reference")
+				logCxt.WithField("chainName", parsed.chainName).Debug("Found forward-reference")
 			}
-			hashes[chainName] = []string{}
+			hashes[parsed.chainName] = []string{}
 			continue
 		}
-
-		// Look for append lines, such as "-A chain-name -m foo --foo bar"; these are the
-		// actual rules.
-		captures = appendRegexp.FindSubmatch(line)
-		if captures == nil {
+		if parsed.kind != saveLineRule {
 			// Skip any non-append lines.
 			logCxt.Debug("Not an append, skipping")
 			continue
 		}
-		chainName := string(captures[1])
+		chainName := parsed.chainName
 
 		// Look for one of our hashes on the rule.  We record a zero hash for unknown rules
-		// so that they get cleaned up.  Note: we're implicitly capturing the first match
-		// of the regex.  When writing the rules, we ensure that the hash is written as the
-		// first comment.
+		// so that they get cleaned up.  When writing the rules, we ensure that the hash is
+		// written as the first comment.
 		hash := ""
-		captures = t.hashCommentRegexp.FindSubmatch(line)
-		if captures != nil {
-			hash = string(captures[1])
+		var ipSetNames []string
+		if err == nil {
+			hash = parsed.hashFromComments(t.hashCommentPrefix)
+			ipSetNames = parsed.ipSetNames()
+		} else {
+			if captures := t.hashCommentRegexp.FindSubmatch(line); captures != nil {
+				hash = string(captures[1])
+			}
+			for _, captures := range ipSetMatchRegexp.FindAllSubmatch(line, -1) {
+				ipSetNames = append(ipSetNames, string(captures[1]))
+			}
+		}
+		isOldInsert := false
+		if hash != "" {
 			if debug {
 				logCxt.WithField("hash", hash).Debug("Found hash in rule")
 			}
 			chainHasCalicoRule.Add(chainName)
 		} else if t.oldInsertRegexp.Find(line) != nil {
+			isOldInsert = true
 			logCxt.WithFields(log.Fields{
 				"rule":      string(line),
 				"chainName": chainName,
@@ -905,14 +1121,20 @@ func (t *Table) readHashesAndRulesFrom(r io.ReadCloser) (hashes map[string][]str
 		}
 		hashes[chainName] = append(hashes[chainName], hash)
 
+		// Record any IP sets that the rule references, whether it's our rule or not.
+		for _, name := range ipSetNames {
+			if ipSetRefs[chainName] == nil {
+				ipSetRefs[chainName] = set.New[string]()
+			}
+			ipSetRefs[chainName].Add(name)
+		}
+
 		// Not our chain so cache the full rule in case we need to generate deletes later on.
 		// After scanning the input, we prune any chains of full rules that do not contain inserts.
 		if !t.ourChainsRegexp.MatchString(chainName) {
 			// Only store the full rule for Calico rules. Otherwise, we just use the placeholder "-".
 			fullRule := "-"
-			if captures := t.hashCommentRegexp.FindSubmatch(line); captures != nil {
-				fullRule = string(line)
-			} else if t.oldInsertRegexp.Find(line) != nil {
+			if hash != "" || isOldInsert {
 				fullRule = string(line)
 			}
 
@@ -921,8 +1143,15 @@ func (t *Table) readHashesAndRulesFrom(r io.ReadCloser) (hashes map[string][]str
 	}
 	if scanner.Err() != nil {
 		log.WithError(scanner.Err()).Error("Failed to read hashes from dataplane")
-		return nil, nil, scanner.Err()
+		return nil, nil, nil, scanner.Err()
 	}
+	for _, w := range warnings {
+		t.logCxt.WithError(w.err).WithFields(log.Fields{
+			"lineNum": w.lineNum,
+			"line":    w.line,
+		}).Warn("Failed to parse line of iptables-save output, ignoring it")
+	}
+	t.countParseWarnings.Add(float64(len(warnings)))
 
 	// Remove full rules for the non-Calico chain if it does not have inserts.
 	for chainName := range rules {
@@ -932,7 +1161,7 @@ func (t *Table) readHashesAndRulesFrom(r io.ReadCloser) (hashes map[string][]str
 	}
 	t.logCxt.Debugf("Read hashes from dataplane: %#v", hashes)
 	t.logCxt.Debugf("Read rules from dataplane: %#v", rules)
-	return hashes, rules, nil
+	return hashes, rules, ipSetRefs, nil
 }
 
 func (t *Table) InvalidateDataplaneCache(reason string) {
@@ -946,6 +1175,11 @@ func (t *Table) InvalidateDataplaneCache(reason string) {
 }
 
 func (t *Table) Apply() (rescheduleAfter time.Duration) {
+	startTime := time.Now()
+	defer func() {
+		t.histApplySeconds.Observe(time.Since(startTime).Seconds())
+	}()
+
 	now := t.timeNow()
 	// We _think_ we're in sync, check if there are any reasons to think we might
 	// not be in sync.
@@ -971,6 +1205,9 @@ func (t *Table) Apply() (rescheduleAfter time.Duration) {
 		}
 	}
 
+	// Drop any cached rule renderings that weren't used since the last Apply().
+	t.ruleBodies.Rotate()
+
 	// Retry until we succeed.  There are several reasons that updating iptables may fail:
 	//
 	// - A concurrent write may invalidate iptables-restore's compare-and-swap; this manifests
@@ -1002,6 +1239,11 @@ func (t *Table) Apply() (rescheduleAfter time.Duration) {
 				t.logCxt.WithError(err).Warn("Retrying...")
 				failedAtLeastOnce = true
 				continue
+			} else if t.onPersistentFailure != nil {
+				t.logCxt.WithError(err).Error("Failed to program iptables after retries, will try again later.")
+				t.InvalidateDataplaneCache("persistent failure")
+				t.onPersistentFailure(fmt.Errorf("failed to program IPv%d %s table: %w", t.IPVersion, t.Name, err))
+				return PersistentFailureRetryInterval
 			} else {
 				t.logCxt.WithError(err).Error("Failed to program iptables, loading diags before panic.")
 				cmd := t.newCmd(t.iptablesSaveCmd, "-t", t.Name)
@@ -1053,15 +1295,18 @@ func (t *Table) applyUpdates() error {
 	buf.StartTransaction(t.Name)
 
 	// Make a pass over the dirty chains and generate a forward reference for any that we're about to update.
-	// Writing a forward reference ensures that the chain exists and that it is empty.
+	// Writing a forward reference ensures that the chain exists and that it is empty.  Chains that we're about
+	// to delete are left alone until the second iptables-restore, below.
 	t.dirtyChains.Iter(func(chainName string) error {
 		chainNeedsToBeFlushed := false
-		if t.nftablesMode {
+		if _, present := t.desiredStateOfChain(chainName); !present {
+			return nil
+		} else if t.nftablesMode && !features.NFTRestoreReplacesByIndex {
 			// iptables-nft-restore <v1.8.3 has a bug (https://bugzilla.netfilter.org/show_bug.cgi?id=1348)
 			// where only the first replace command sets the rule index.  Work around that by refreshing the
 			// whole chain using a flush.
 			chain, _ := t.desiredStateOfChain(chainName)
-			currentHashes := chain.RuleHashes(features)
+			currentHashes := t.ruleHashes(chain, features)
 			previousHashes := t.chainToDataplaneHashes[chainName]
 			t.logCxt.WithFields(log.Fields{
 				"previous": previousHashes,
@@ -1073,9 +1318,6 @@ func (t *Table) applyUpdates() error {
 				return set.RemoveItem
 			}
 			chainNeedsToBeFlushed = true
-		} else if _, present := t.desiredStateOfChain(chainName); !present {
-			// About to delete this chain, flush it first to sever dependencies.
-			chainNeedsToBeFlushed = true
 		} else if _, ok := t.chainToDataplaneHashes[chainName]; !ok {
 			// Chain doesn't exist in dataplane, mark it for creation.
 			chainNeedsToBeFlushed = true
@@ -1088,20 +1330,24 @@ func (t *Table) applyUpdates() error {
 
 	// Make a second pass over the dirty chains.  This time, we write out the rule changes.
 	newHashes := map[string][]string{}
+	newIPSetRefs := map[string]set.Set[string]{}
 	t.dirtyChains.Iter(func(chainName string) error {
 		if chain, ok := t.desiredStateOfChain(chainName); ok {
 			// Chain update or creation.  Scan the chain against its previous hashes
 			// and replace/append/delete as appropriate.
 			var previousHashes []string
-			if t.nftablesMode {
-				// Due to a bug in iptables nft mode, force a whole-chain rewrite.  (See above.)
+			if t.nftablesMode && !features.NFTRestoreReplacesByIndex {
+				// Due to a bug in older versions of iptables nft mode, force a whole-chain rewrite.  (See
+				// above.)
 				previousHashes = nil
 			} else {
-				// In iptables legacy mode, we compare the rules one by one and apply deltas rule by rule.
+				// Otherwise, we compare the rules one by one and apply deltas rule by rule, so that only
+				// the rules that have changed are sent to iptables-restore.
 				previousHashes = t.chainToDataplaneHashes[chainName]
 			}
-			currentHashes := chain.RuleHashes(features)
+			currentHashes := t.ruleHashes(chain, features)
 			newHashes[chainName] = currentHashes
+			newIPSetRefs[chainName] = ipSetRefsOfRules(chain.Rules)
 			for i := 0; i < len(previousHashes) || i < len(currentHashes); i++ {
 				var line string
 				if i < len(previousHashes) && i < len(currentHashes) {
@@ -1111,7 +1357,7 @@ func (t *Table) applyUpdates() error {
 					// Hash doesn't match, replace the rule.
 					ruleNum := i + 1 // 1-indexed.
 					prefixFrag := t.commentFrag(currentHashes[i])
-					line = chain.Rules[i].RenderReplace(chainName, ruleNum, prefixFrag, features)
+					line = t.renderRuleLine(&chain.Rules[i], fmt.Sprintf("-R %s %d", chainName, ruleNum), prefixFrag, features)
 				} else if i < len(previousHashes) {
 					// previousHashes was longer, remove the old rules from the end.
 					ruleNum := len(currentHashes) + 1 // 1-indexed
@@ -1119,7 +1365,7 @@ func (t *Table) applyUpdates() error {
 				} else {
 					// currentHashes was longer.  Append.
 					prefixFrag := t.commentFrag(currentHashes[i])
-					line = chain.Rules[i].RenderAppend(chainName, prefixFrag, features)
+					line = t.renderRuleLine(&chain.Rules[i], "-A "+chainName, prefixFrag, features)
 				}
 				buf.WriteLine(line)
 			}
@@ -1217,6 +1463,7 @@ func (t *Table) applyUpdates() error {
 
 		newHashes[chainName] = newChainHashes
 		newChainToFullRules[chainName] = newRules
+		newIPSetRefs[chainName] = ipSetRefsOfRules(t.chainToInsertedRules[chainName], t.chainToAppendedRules[chainName])
 
 		return nil // Delay clearing the set until we've programmed iptables.
 	})
@@ -1225,49 +1472,62 @@ func (t *Table) applyUpdates() error {
 		return deleteRenderingErr
 	}
 
-	if t.nftablesMode {
-		// The nftables version of iptables-restore requires that chains are unreferenced at the start of the
-		// transaction before they can be deleted (i.e. it doesn't seem to update the reference calculation as
-		// rules are deleted).  Close the current transaction and open a new one for the deletions in order to
-		// refresh its state.  The buffer will discard a no-op transaction so we don't need to check.
-		t.logCxt.Debug("In nftables mode, restarting transaction between updates and deletions.")
-		buf.EndTransaction()
-		buf.StartTransaction(t.Name)
-
-		t.dirtyChains.Iter(func(chainName string) error {
-			if _, ok := t.desiredStateOfChain(chainName); !ok {
-				// Chain deletion
-				buf.WriteForwardReference(chainName)
-			}
-			return nil // Delay clearing the set until we've programmed iptables.
-		})
-	}
+	buf.EndTransaction()
 
-	// Do deletions at the end.  This ensures that we don't try to delete any chains that
-	// are still referenced (because we'll have removed the references in the modify pass
-	// above).  Note: if a chain is being deleted at the same time as a chain that it refers to
-	// then we'll issue a create+flush instruction in the very first pass, which will sever the
-	// references.
+	// Do deletions in a second iptables-restore, once the first one has removed all references to the
+	// chains.  Until then, the chains keep their rules so, when a chain is renamed (for example, after a
+	// change of ChainNameVersion), packets see either the old chain or the new one, never an empty or missing
+	// chain.  It also suits iptables-nft-restore, which requires that chains are unreferenced at the start of
+	// the transaction before they can be deleted (i.e. it doesn't seem to update the reference calculation as
+	// rules are deleted).
+	deletionsBuf := &t.deletionInputBuffer
+	deletionsBuf.Reset() // Defensive.
+	deletionsBuf.StartTransaction(t.Name)
+	// If a chain is being deleted at the same time as a chain that it refers to then we need to flush it
+	// first to sever the references.
+	t.dirtyChains.Iter(func(chainName string) error {
+		if _, ok := t.desiredStateOfChain(chainName); !ok {
+			deletionsBuf.WriteForwardReference(chainName)
+		}
+		return nil // Delay clearing the set until we've programmed iptables.
+	})
 	t.dirtyChains.Iter(func(chainName string) error {
 		if _, ok := t.desiredStateOfChain(chainName); !ok {
 			// Chain deletion
-			buf.WriteLine(fmt.Sprintf("--delete-chain %s", chainName))
+			deletionsBuf.WriteLine(fmt.Sprintf("--delete-chain %s", chainName))
 			newHashes[chainName] = nil
+			newIPSetRefs[chainName] = nil
 		}
 		return nil // Delay clearing the set until we've programmed iptables.
 	})
+	deletionsBuf.EndTransaction()
 
-	buf.EndTransaction()
-
-	if buf.Empty() {
+	var changedChains []string
+	if buf.Empty() && deletionsBuf.Empty() {
 		t.logCxt.Debug("Update ended up being no-op, skipping call to ip(6)tables-restore.")
 	} else {
 		// Get the contents of the buffer ready to send to iptables-restore.  Warning: for perf, this is directly
 		// accessing the buffer's internal array; don't touch the buffer after this point.
 		t.opReporter.RecordOperation(fmt.Sprintf("update-%v-v%d", t.Name, t.IPVersion))
 
-		if err := t.execIptablesRestore(buf); err != nil {
-			return fmt.Errorf("writting out buffer: %w", err)
+		// Work out which chains this update changes, for the per-chain-group metrics.
+		for chainName, hashes := range newHashes {
+			if !reflect.DeepEqual(hashes, t.chainToDataplaneHashes[chainName]) {
+				changedChains = append(changedChains, chainName)
+			}
+		}
+
+		for _, b := range []*RestoreInputBuilder{buf, deletionsBuf} {
+			if b.Empty() {
+				continue
+			}
+			if err := t.execIptablesRestore(b); err != nil {
+				for _, chainName := range changedChains {
+					t.logCxt.WithField("chainName", chainName).Debug("Failed to update chain.")
+					countVecChainUpdateErrors.WithLabelValues(t.ipVersionLabel(), t.Name, t.groupOfChain(chainName)).Inc()
+				}
+				return fmt.Errorf("writting out buffer: %w", err)
+			}
 		}
 
 		t.lastWriteTime = t.timeNow()
@@ -1288,14 +1548,99 @@ func (t *Table) applyUpdates() error {
 			t.chainToDataplaneHashes[chainName] = hashes
 		}
 	}
+	t.updateChainMetrics(changedChains)
 	t.chainToFullRules = newChainToFullRules
+	for chainName, refs := range newIPSetRefs {
+		if refs == nil || refs.Len() == 0 {
+			delete(t.chainToIPSetRefs, chainName)
+		} else {
+			t.chainToIPSetRefs[chainName] = refs
+		}
+	}
 
 	return nil
 }
 
+// updateChainMetrics updates the per-chain-group metrics after a successful update to the given
+// chains.  It must be called after chainToDataplaneHashes has been updated.
+func (t *Table) updateChainMetrics(changedChains []string) {
+	ipVersion := t.ipVersionLabel()
+	for _, chainName := range changedChains {
+		hashes, ok := t.chainToDataplaneHashes[chainName]
+		if !ok {
+			t.logCxt.WithField("chainName", chainName).Debug("Deleted chain.")
+			continue
+		}
+		t.logCxt.WithFields(log.Fields{
+			"chainName": chainName,
+			"numRules":  len(hashes) - numEmptyStrings(hashes),
+		}).Debug("Updated chain.")
+		countVecChainUpdates.WithLabelValues(ipVersion, t.Name, t.groupOfChain(chainName)).Inc()
+	}
+
+	// Recalculate the number of rules in each group from scratch, since a resync can replace
+	// the hashes of any chain.
+	groupToNumRules := map[string]int{}
+	for chainName, hashes := range t.chainToDataplaneHashes {
+		// Only count our own rules in kernel chains.
+		groupToNumRules[t.groupOfChain(chainName)] += len(hashes) - numEmptyStrings(hashes)
+	}
+	for group := range t.chainGroupToNumRules {
+		if _, ok := groupToNumRules[group]; !ok {
+			groupToNumRules[group] = 0
+		}
+	}
+	for group, numRules := range groupToNumRules {
+		gaugeVecChainRules.WithLabelValues(ipVersion, t.Name, group).Set(float64(numRules))
+	}
+	t.chainGroupToNumRules = groupToNumRules
+}
+
+// groupOfChain returns the chain group that the given chain is counted under in the metrics.
+func (t *Table) groupOfChain(chainName string) string {
+	for _, kernelChain := range tableToKernelChains[t.Name] {
+		if chainName == kernelChain {
+			return "kernel"
+		}
+	}
+	return t.chainGroup(chainName)
+}
+
+func (t *Table) ipVersionLabel() string {
+	return fmt.Sprintf("%d", t.IPVersion)
+}
+
+// ipSetRefsOfRules returns the names of the IP sets that are referenced by the given rules.
+func ipSetRefsOfRules(ruleLists ...[]Rule) set.Set[string] {
+	refs := set.New[string]()
+	for _, rules := range ruleLists {
+		for _, r := range rules {
+			for _, frag := range r.Match {
+				for _, captures := range ipSetMatchRegexp.FindAllStringSubmatch(frag, -1) {
+					refs.Add(captures[1])
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// IPSetIsReferenced returns true if the named IP set is referenced by any of the rules in this
+// table, as we think they are in the dataplane.  After a successful Apply(), that includes any
+// rules that were left behind by another process, up until they are cleaned up.
+func (t *Table) IPSetIsReferenced(setName string) bool {
+	for _, refs := range t.chainToIPSetRefs {
+		if refs.Contains(setName) {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *Table) execIptablesRestore(buf *RestoreInputBuilder) error {
 	features := t.featureDetector.GetFeatures()
 	inputBytes := buf.GetBytesAndReset()
+	t.restorePayloadBytes.Observe(float64(len(inputBytes)))
 
 	if log.GetLevel() >= log.DebugLevel {
 		// Only convert (potentially very large slice) to string at debug level.
@@ -1316,7 +1661,9 @@ func (t *Table) execIptablesRestore(buf *RestoreInputBuilder) error {
 			// lock so we override the default and set it to 10s.
 			lockTimeout = 10
 		}
-		lockProbeMicros := t.lockProbeInterval.Nanoseconds() / 1000
+		// Probe less often while other processes (such as kube-proxy) are holding the lock for
+		// long periods; see noteNativeLockContention().
+		lockProbeMicros := t.nativeLockBackoff.interval.Nanoseconds() / 1000
 		timeoutStr := fmt.Sprintf("%.0f", lockTimeout)
 		intervalStr := fmt.Sprintf("%d", lockProbeMicros)
 		args = append(args,
@@ -1335,9 +1682,16 @@ func (t *Table) execIptablesRestore(buf *RestoreInputBuilder) error {
 	countNumRestoreCalls.Inc()
 	// Note: calicoXtablesLock will be a dummy lock if our xtables lock is disabled (i.e. if iptables-restore
 	// supports the xtables lock itself, or if our implementation is disabled by config.
+	lockStartTime := t.timeNow()
 	t.calicoXtablesLock.Lock()
+	if !features.RestoreSupportsLock {
+		t.histLockWaitSeconds.Observe(t.timeNow().Sub(lockStartTime).Seconds())
+	}
 	err := cmd.Run()
 	t.calicoXtablesLock.Unlock()
+	if features.RestoreSupportsLock {
+		t.noteNativeLockContention(errBuf.String(), err)
+	}
 	if err != nil {
 		// To log out the input, we must convert to string here since, after we return, the buffer can be re-used
 		// (and the logger may convert to string on a background thread).
@@ -1356,6 +1710,25 @@ func (t *Table) execIptablesRestore(buf *RestoreInputBuilder) error {
 	return nil
 }
 
+// noteNativeLockContention updates the native xtables lock backoff and metrics after an
+// iptables-restore call.  iptables-restore only tells us that it waited for the lock through its
+// error output, so that's what we look for.  We back off the probe interval after each call that
+// timed out on the lock and go back to the configured interval once a call succeeds.
+func (t *Table) noteNativeLockContention(errOutput string, err error) {
+	if strings.Contains(errOutput, xtablesLockHeldMessage) {
+		t.countLockContention.Inc()
+		if err != nil {
+			t.nativeLockBackoff.next()
+			t.logCxt.WithField("probeInterval", t.nativeLockBackoff.interval).Info(
+				"iptables-restore timed out waiting for the xtables lock, backing off lock probe interval.")
+			return
+		}
+	}
+	if err == nil {
+		t.nativeLockBackoff = newLockBackoff(t.lockProbeInterval)
+	}
+}
+
 // CheckRulesPresent returns list of rules with the hashes that are already
 // programmed. Return value of nil means that none of the rules are present.
 func (t *Table) CheckRulesPresent(chain string, rules []Rule) []Rule {
@@ -1363,7 +1736,7 @@ func (t *Table) CheckRulesPresent(chain string, rules []Rule) []Rule {
 
 	hashes := CalculateRuleHashes(chain, rules, features)
 
-	dpHashes, _ := t.getHashesAndRulesFromDataplane()
+	dpHashes, _, _ := t.getHashesAndRulesFromDataplane()
 	dpHashesSet := set.New[string]()
 	for _, h := range dpHashes[chain] {
 		dpHashesSet.Add(h)
@@ -1436,6 +1809,27 @@ func (t *Table) renderDeleteByValueLine(chainName string, ruleNum int) (string,
 	return strings.Replace(rule, "-A", "-D", 1), nil
 }
 
+// ruleHashes calculates the rule hashes for one of our chains, using the rule body cache.
+func (t *Table) ruleHashes(chain *Chain, features *environment.Features) []string {
+	return chain.ruleHashes(func(rule *Rule) string {
+		return t.ruleBodies.Get(rule, features)
+	})
+}
+
+// renderRuleLine renders an iptables-restore line for the given rule, using the rule body cache.
+// The result is the same as Rule.RenderAppend() or Rule.RenderReplace() with the matching
+// operation fragment.
+func (t *Table) renderRuleLine(rule *Rule, opFragment, prefixFragment string, features *environment.Features) string {
+	line := opFragment
+	if prefixFragment != "" {
+		line += " " + prefixFragment
+	}
+	if body := t.ruleBodies.Get(rule, features); body != "" {
+		line += " " + body
+	}
+	return line
+}
+
 func CalculateRuleHashes(chainName string, rules []Rule, features *environment.Features) []string {
 	chain := Chain{
 		Name:  chainName,
@@ -1463,6 +1857,8 @@ func NewNoopTable() *NoopTable {
 
 func (t *NoopTable) Name() string                                       { return "" }
 func (t *NoopTable) IPVersion() uint8                                   { return 0 }
+func (t *NoopTable) TableName() string                                  { return "" }
+func (t *NoopTable) TableIPVersion() uint8                              { return 0 }
 func (t *NoopTable) InsertOrAppendRules(chainName string, rules []Rule) {}
 func (t *NoopTable) AppendRules(chainName string, rules []Rule)         {}
 func (t *NoopTable) UpdateChain(chain *Chain)                           {}
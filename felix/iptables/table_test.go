@@ -18,15 +18,18 @@ import (
 	"time"
 
 	"github.com/projectcalico/calico/felix/environment"
+	"github.com/projectcalico/calico/felix/hashutils"
 	. "github.com/projectcalico/calico/felix/iptables"
 	"github.com/projectcalico/calico/felix/iptables/testutils"
 	"github.com/projectcalico/calico/felix/logutils"
+	"github.com/projectcalico/calico/felix/proto"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/projectcalico/calico/felix/rules"
 
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -334,6 +337,144 @@ func describeEmptyDataplaneTests(dataplaneMode string) {
 		})
 	})
 
+	Describe("after adding rules that reference IP sets", func() {
+		BeforeEach(func() {
+			table.InsertOrAppendRules("FORWARD", []Rule{
+				{Match: Match().SourceIPSet("cali40s:insert"), Action: JumpAction{Target: "cali-FORWARD"}},
+			})
+			table.UpdateChain(&Chain{
+				Name: "cali-FORWARD",
+				Rules: []Rule{
+					{Match: Match().SourceIPSet("cali40s:src").NotDestIPSet("cali40s:dst"), Action: DropAction{}},
+				}})
+			table.Apply()
+		})
+
+		It("should report the IP sets as referenced", func() {
+			Expect(table.IPSetIsReferenced("cali40s:insert")).To(BeTrue())
+			Expect(table.IPSetIsReferenced("cali40s:src")).To(BeTrue())
+			Expect(table.IPSetIsReferenced("cali40s:dst")).To(BeTrue())
+			Expect(table.IPSetIsReferenced("cali40s:other")).To(BeFalse())
+		})
+
+		It("should stop reporting an IP set once its last reference is removed", func() {
+			table.UpdateChain(&Chain{
+				Name: "cali-FORWARD",
+				Rules: []Rule{
+					{Match: Match().SourceIPSet("cali40s:src"), Action: DropAction{}},
+				}})
+			Expect(table.IPSetIsReferenced("cali40s:dst")).To(BeTrue(), "reference removed before Apply()")
+			table.Apply()
+			Expect(table.IPSetIsReferenced("cali40s:dst")).To(BeFalse())
+			Expect(table.IPSetIsReferenced("cali40s:src")).To(BeTrue())
+
+			table.InsertOrAppendRules("FORWARD", []Rule{})
+			table.Apply()
+			Expect(table.IPSetIsReferenced("cali40s:insert")).To(BeFalse())
+			Expect(table.IPSetIsReferenced("cali40s:src")).To(BeFalse())
+		})
+
+		It("should reload the references from the dataplane", func() {
+			table.InvalidateDataplaneCache("test")
+			table.Apply()
+			Expect(table.IPSetIsReferenced("cali40s:insert")).To(BeTrue())
+			Expect(table.IPSetIsReferenced("cali40s:dst")).To(BeTrue())
+		})
+	})
+
+	Describe("with chains that share a rules slice", func() {
+		var sharedRules []Rule
+		BeforeEach(func() {
+			sharedRules = []Rule{
+				{Match: Match().SourceNet("10.0.0.1"), Action: JumpAction{Target: "biff"}},
+			}
+			table.InsertOrAppendRules("FORWARD", []Rule{
+				{Action: JumpAction{Target: "cali-shared-a"}},
+				{Action: JumpAction{Target: "cali-shared-b"}},
+			})
+			table.UpdateChains([]*Chain{
+				{Name: "cali-shared-a", Rules: sharedRules},
+				{Name: "cali-shared-b", Rules: sharedRules},
+			})
+			table.Apply()
+		})
+
+		It("should program the rules in both chains", func() {
+			Expect(dataplane.Chains["cali-shared-a"]).To(ConsistOf(HaveSuffix("--source 10.0.0.1 --jump biff")))
+			Expect(dataplane.Chains["cali-shared-b"]).To(ConsistOf(HaveSuffix("--source 10.0.0.1 --jump biff")))
+		})
+
+		It("should program the new rules if the slice is modified in place", func() {
+			sharedRules[0].Action = JumpAction{Target: "boff"}
+			table.UpdateChains([]*Chain{
+				{Name: "cali-shared-a", Rules: sharedRules},
+				{Name: "cali-shared-b", Rules: sharedRules},
+			})
+			table.Apply()
+			Expect(dataplane.Chains["cali-shared-a"]).To(ConsistOf(HaveSuffix("--source 10.0.0.1 --jump boff")))
+			Expect(dataplane.Chains["cali-shared-b"]).To(ConsistOf(HaveSuffix("--source 10.0.0.1 --jump boff")))
+		})
+	})
+
+	Describe("chain metrics", func() {
+		groupLabels := func(group string) map[string]string {
+			return map[string]string{"ip_version": "4", "table": "filter", "chain_group": group}
+		}
+		var updatesBefore float64
+		BeforeEach(func() {
+			updatesBefore, _ = getMetric("felix_iptables_chain_updates", groupLabels("other"))
+			table.InsertOrAppendRules("FORWARD", []Rule{
+				{Action: JumpAction{Target: "cali-metrics"}},
+			})
+			table.UpdateChain(&Chain{Name: "cali-metrics", Rules: []Rule{
+				{Action: AcceptAction{}},
+				{Action: DropAction{}},
+			}})
+			table.Apply()
+		})
+
+		It("should count updates to each chain group", func() {
+			updates, ok := getMetric("felix_iptables_chain_updates", groupLabels("other"))
+			Expect(ok).To(BeTrue())
+			Expect(updates).To(Equal(updatesBefore + 1))
+			table.UpdateChain(&Chain{Name: "cali-metrics", Rules: []Rule{
+				{Action: AcceptAction{}},
+			}})
+			table.Apply()
+			updates, _ = getMetric("felix_iptables_chain_updates", groupLabels("other"))
+			Expect(updates).To(Equal(updatesBefore + 2))
+		})
+
+		It("should track the number of rules in each chain group", func() {
+			numRules, ok := getMetric("felix_iptables_chain_rules", groupLabels("other"))
+			Expect(ok).To(BeTrue())
+			Expect(numRules).To(Equal(2.0))
+			numRules, _ = getMetric("felix_iptables_chain_rules", groupLabels("kernel"))
+			Expect(numRules).To(Equal(1.0))
+		})
+
+		It("should stop counting the rules of a chain when it's deleted", func() {
+			table.InsertOrAppendRules("FORWARD", nil)
+			table.Apply()
+			numRules, ok := getMetric("felix_iptables_chain_rules", groupLabels("other"))
+			Expect(ok).To(BeTrue())
+			Expect(numRules).To(Equal(0.0))
+			numRules, _ = getMetric("felix_iptables_chain_rules", groupLabels("kernel"))
+			Expect(numRules).To(Equal(0.0))
+		})
+
+		It("should count failed updates to each chain group", func() {
+			errorsBefore, _ := getMetric("felix_iptables_chain_update_errors", groupLabels("other"))
+			table.UpdateChain(&Chain{Name: "cali-metrics", Rules: []Rule{
+				{Action: ReturnAction{}},
+			}})
+			dataplane.FailNextRestore = true
+			table.Apply()
+			errors, _ := getMetric("felix_iptables_chain_update_errors", groupLabels("other"))
+			Expect(errors).To(Equal(errorsBefore + 1))
+		})
+	})
+
 	Describe("after adding a chain", func() {
 		BeforeEach(func() {
 			table.UpdateChains([]*Chain{
@@ -1083,6 +1224,250 @@ func describePostUpdateCheckTests(enableRefresh bool, dataplaneMode string) {
 	})
 }
 
+var _ = Describe("Table in audit-only mode", func() {
+	var dataplane *testutils.MockDataplane
+	var table *Table
+
+	BeforeEach(func() {
+		dataplane = testutils.NewMockDataplane("filter", map[string][]string{
+			"FORWARD":  {},
+			"INPUT":    {},
+			"OUTPUT":   {},
+			"cali-old": {`-m comment --comment "cali:oldoldoldoldoldo" --jump DROP`},
+		}, "legacy")
+		featureDetector := environment.NewFeatureDetector(nil)
+		featureDetector.NewCmd = dataplane.NewCmd
+		featureDetector.GetKernelVersionReader = dataplane.GetKernelVersionReader
+		table = NewTable(
+			"filter",
+			4,
+			rules.RuleHashPrefix,
+			&mockMutex{},
+			featureDetector,
+			TableOptions{
+				HistoricChainPrefixes: rules.AllHistoricChainNamePrefixes,
+				NewCmdOverride:        dataplane.NewCmd,
+				SleepOverride:         dataplane.Sleep,
+				NowOverride:           dataplane.Now,
+				BackendMode:           "legacy",
+				LookPathOverride:      testutils.LookPathNoLegacy,
+				OpRecorder:            logutils.NewSummarizer("test loop"),
+				RefreshInterval:       30 * time.Second,
+				AuditOnly:             true,
+			},
+		)
+		table.InsertOrAppendRules("FORWARD", []Rule{
+			{Action: DropAction{}},
+		})
+		table.Apply()
+	})
+
+	driftedChains := func() float64 {
+		drifted, ok := getMetric("felix_iptables_drifted_chains", map[string]string{"ip_version": "4", "table": "filter"})
+		Expect(ok).To(BeTrue())
+		return drifted
+	}
+
+	It("should clean up and program the dataplane on the first resync", func() {
+		Expect(dataplane.Chains).To(Equal(map[string][]string{
+			"FORWARD": {`-m comment --comment "cali:hecdSCslEjdBPBPo" --jump DROP`},
+			"INPUT":   {},
+			"OUTPUT":  {},
+		}))
+	})
+
+	Describe("after another process removes the insertion and adds a chain", func() {
+		drifted := map[string][]string{
+			"FORWARD":  {},
+			"INPUT":    {},
+			"OUTPUT":   {},
+			"cali-new": {},
+		}
+		BeforeEach(func() {
+			dataplane.Chains = map[string][]string{}
+			for name, rules := range drifted {
+				dataplane.Chains[name] = rules
+			}
+			dataplane.AdvanceTimeBy(31 * time.Second)
+			table.Apply()
+		})
+
+		It("should report the drift without repairing it", func() {
+			Expect(dataplane.Chains).To(Equal(drifted))
+			Expect(driftedChains()).To(Equal(2.0))
+		})
+
+		It("should keep reporting the drift at later resyncs", func() {
+			dataplane.AdvanceTimeBy(31 * time.Second)
+			table.Apply()
+			Expect(dataplane.Chains).To(Equal(drifted))
+			Expect(driftedChains()).To(Equal(2.0))
+		})
+
+		It("should report no drift once the dataplane is back in sync", func() {
+			dataplane.Chains = map[string][]string{
+				"FORWARD": {`-m comment --comment "cali:hecdSCslEjdBPBPo" --jump DROP`},
+				"INPUT":   {},
+				"OUTPUT":  {},
+			}
+			dataplane.AdvanceTimeBy(31 * time.Second)
+			table.Apply()
+			Expect(driftedChains()).To(Equal(0.0))
+		})
+
+		It("should still make its own updates", func() {
+			table.InsertOrAppendRules("FORWARD", []Rule{
+				{Action: AcceptAction{}},
+			})
+			table.Apply()
+			Expect(dataplane.Chains["FORWARD"]).To(HaveLen(1))
+			Expect(dataplane.Chains["cali-new"]).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("Table with a persistent failure callback", func() {
+	var dataplane *testutils.MockDataplane
+	var table *Table
+	var failures []error
+
+	BeforeEach(func() {
+		failures = nil
+		dataplane = testutils.NewMockDataplane("filter", map[string][]string{
+			"FORWARD": {},
+			"INPUT":   {},
+			"OUTPUT":  {},
+		}, "legacy")
+		featureDetector := environment.NewFeatureDetector(nil)
+		featureDetector.NewCmd = dataplane.NewCmd
+		featureDetector.GetKernelVersionReader = dataplane.GetKernelVersionReader
+		table = NewTable(
+			"filter",
+			4,
+			rules.RuleHashPrefix,
+			&mockMutex{},
+			featureDetector,
+			TableOptions{
+				HistoricChainPrefixes: rules.AllHistoricChainNamePrefixes,
+				NewCmdOverride:        dataplane.NewCmd,
+				SleepOverride:         dataplane.Sleep,
+				NowOverride:           dataplane.Now,
+				BackendMode:           "legacy",
+				LookPathOverride:      testutils.LookPathNoLegacy,
+				OpRecorder:            logutils.NewSummarizer("test loop"),
+				OnPersistentFailure: func(err error) {
+					failures = append(failures, err)
+				},
+			},
+		)
+		table.InsertOrAppendRules("FORWARD", []Rule{
+			{Action: DropAction{}},
+		})
+		dataplane.FailAllRestores = true
+	})
+
+	It("should call the callback rather than panicking and ask to be rescheduled", func() {
+		Expect(table.Apply()).To(Equal(PersistentFailureRetryInterval))
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Error()).To(ContainSubstring("failed to program IPv4 filter table"))
+		Expect(dataplane.Chains["FORWARD"]).To(BeEmpty())
+	})
+
+	It("should program the pending updates once the failure clears", func() {
+		table.Apply()
+		dataplane.FailAllRestores = false
+		table.Apply()
+		Expect(failures).To(HaveLen(1))
+		Expect(dataplane.Chains["FORWARD"]).To(Equal([]string{
+			`-m comment --comment "cali:hecdSCslEjdBPBPo" --jump DROP`,
+		}))
+	})
+})
+
+var _ = Describe("Table with iptables-restore's native xtables lock", func() {
+	var dataplane *testutils.MockDataplane
+	var table *Table
+
+	BeforeEach(func() {
+		dataplane = testutils.NewMockDataplane("filter", map[string][]string{
+			"FORWARD": {},
+			"INPUT":   {},
+			"OUTPUT":  {},
+		}, "legacy")
+		dataplane.Version = "iptables v1.8.4 (legacy)\n"
+		featureDetector := environment.NewFeatureDetector(nil)
+		featureDetector.NewCmd = dataplane.NewCmd
+		featureDetector.GetKernelVersionReader = dataplane.GetKernelVersionReader
+		table = NewTable(
+			"filter",
+			4,
+			rules.RuleHashPrefix,
+			&mockMutex{},
+			featureDetector,
+			TableOptions{
+				HistoricChainPrefixes: rules.AllHistoricChainNamePrefixes,
+				NewCmdOverride:        dataplane.NewCmd,
+				SleepOverride:         dataplane.Sleep,
+				NowOverride:           dataplane.Now,
+				BackendMode:           "legacy",
+				LookPathOverride:      testutils.LookPathNoLegacy,
+				OpRecorder:            logutils.NewSummarizer("test loop"),
+				LockTimeout:           5 * time.Second,
+				LockProbeInterval:     50 * time.Millisecond,
+			},
+		)
+		table.InsertOrAppendRules("FORWARD", []Rule{
+			{Action: DropAction{}},
+		})
+	})
+
+	waitIntervals := func() (intervals []string) {
+		for _, args := range dataplane.RestoreArgs {
+			Expect(args[2:4]).To(Equal([]string{"--wait", "5"}))
+			intervals = append(intervals, args[5])
+		}
+		return
+	}
+	contentionLabels := map[string]string{"ip_version": "4", "table": "filter"}
+
+	It("should pass the configured lock probe interval to iptables-restore", func() {
+		table.Apply()
+		Expect(waitIntervals()).To(Equal([]string{"50000"}))
+	})
+
+	It("should back off the lock probe interval while iptables-restore times out on the lock", func() {
+		contentionBefore, _ := getMetric("felix_iptables_restore_lock_contention", contentionLabels)
+		dataplane.RestoreStderr = "Another app is currently holding the xtables lock. Stopped waiting after 5s.\n"
+		dataplane.FailAllRestores = true
+		Expect(func() {
+			table.Apply()
+		}).To(Panic())
+		Expect(waitIntervals()).To(Equal([]string{
+			"50000", "100000", "200000", "400000", "800000",
+			"800000", "800000", "800000", "800000", "800000", "800000",
+		}))
+		contention, _ := getMetric("felix_iptables_restore_lock_contention", contentionLabels)
+		Expect(contention).To(Equal(contentionBefore + 11))
+	})
+
+	It("should go back to the configured interval once a restore succeeds", func() {
+		dataplane.RestoreStderr = "Another app is currently holding the xtables lock. Stopped waiting after 5s.\n"
+		dataplane.FailNextRestore = true
+		table.Apply()
+		table.InsertOrAppendRules("FORWARD", []Rule{
+			{Action: AcceptAction{}},
+		})
+		table.Apply()
+		Expect(waitIntervals()).To(Equal([]string{"50000", "100000", "50000"}))
+	})
+
+	It("should not back off for other failures", func() {
+		dataplane.FailNextRestore = true
+		table.Apply()
+		Expect(waitIntervals()).To(Equal([]string{"50000", "50000"}))
+	})
+})
+
 var _ = Describe("Table with a dirty dataplane in append mode (nft)", func() {
 	describeDirtyDataplaneTests(true, "nft")
 })
@@ -1298,7 +1683,7 @@ func describeDirtyDataplaneTests(appendMode bool, dataplaneMode string) {
 		It("with no errors, it should get to correct final state", func() {
 			table.Apply()
 			checkFinalState()
-			Expect(dataplane.Cmds).To(HaveLen(3)) // a version, save and a restore
+			Expect(dataplane.Cmds).To(HaveLen(4)) // a version, save and two restores (updates, then deletions)
 		})
 		It("with no errors, it shouldn't sleep", func() {
 			table.Apply()
@@ -1309,7 +1694,7 @@ func describeDirtyDataplaneTests(appendMode bool, dataplaneMode string) {
 				checkFinalState()
 			})
 			It("it should retry once", func() {
-				Expect(dataplane.Cmds).To(HaveLen(4)) // a version, 2 saves and a restore
+				Expect(dataplane.Cmds).To(HaveLen(5)) // a version, 2 saves and two restores
 			})
 			It("it should sleep", func() {
 				Expect(dataplane.CumulativeSleep).To(Equal(100 * time.Millisecond))
@@ -1413,6 +1798,15 @@ func describeDirtyDataplaneTests(appendMode bool, dataplaneMode string) {
 				// Third rule is incorrect.
 				Expect(dataplane.RuleTouched("cali-foobar", 3)).To(BeTrue())
 			})
+			It("should only rewrite incorrect rules with a fixed iptables-nft-restore", func() {
+				dataplane.Version = "iptables v1.8.4 (nf_tables)\n"
+				table.Apply()
+				Expect(dataplane.ChainFlushed("cali-foobar")).To(BeFalse())
+				Expect(dataplane.RuleTouched("cali-foobar", 1)).To(BeFalse())
+				Expect(dataplane.RuleTouched("cali-foobar", 2)).To(BeFalse())
+				Expect(dataplane.RuleTouched("cali-foobar", 3)).To(BeTrue())
+				checkFinalState()
+			})
 		}
 		It("with a transient error, it should get to correct final state", func() {
 			// First write to iptables fails; Table should simply retry.
@@ -1649,6 +2043,80 @@ func describeInsertAndNonCalicoChainTests(dataplaneMode string) {
 	})
 }
 
+var _ = Describe("Table renaming a chain (legacy)", func() {
+	describeChainRenameTests("legacy")
+})
+var _ = Describe("Table renaming a chain (nft)", func() {
+	describeChainRenameTests("nft")
+})
+
+func describeChainRenameTests(dataplaneMode string) {
+	var dataplane *testutils.MockDataplane
+	var table *Table
+
+	// A policy chain left behind by a Felix that used the old chain name scheme, along with the
+	// endpoint chain that jumps to it.
+	polID := &proto.PolicyID{Tier: "default", Name: "a-policy-name-that-is-long-enough-to-be-hashed"}
+	oldName := rules.VersionedPolicyChainName(hashutils.IDVersion1, rules.PolicyInboundPfx, polID)
+	newName := rules.VersionedPolicyChainName(hashutils.IDVersion2, rules.PolicyInboundPfx, polID)
+
+	BeforeEach(func() {
+		Expect(oldName).NotTo(Equal(newName))
+		dataplane = testutils.NewMockDataplane("filter", map[string][]string{
+			"FORWARD":          {},
+			"cali-tw-cali1234": {"-m comment --comment \"cali:oldjumpxxxxxxxxx\" --jump " + oldName},
+			oldName:            {"-m comment --comment \"cali:oldrulexxxxxxxxx\" --jump ACCEPT"},
+		}, dataplaneMode)
+		featureDetector := environment.NewFeatureDetector(nil)
+		featureDetector.NewCmd = dataplane.NewCmd
+		featureDetector.GetKernelVersionReader = dataplane.GetKernelVersionReader
+		table = NewTable(
+			"filter",
+			4,
+			rules.RuleHashPrefix,
+			&mockMutex{},
+			featureDetector,
+			TableOptions{
+				HistoricChainPrefixes: rules.AllHistoricChainNamePrefixes,
+				NewCmdOverride:        dataplane.NewCmd,
+				SleepOverride:         dataplane.Sleep,
+				NowOverride:           dataplane.Now,
+				BackendMode:           dataplaneMode,
+				LookPathOverride:      testutils.LookPathNoLegacy,
+				OpRecorder:            logutils.NewSummarizer("test loop"),
+			},
+		)
+		table.InsertOrAppendRules("FORWARD", []Rule{
+			{Action: JumpAction{Target: "cali-tw-cali1234"}},
+		})
+		table.UpdateChain(&Chain{
+			Name:  "cali-tw-cali1234",
+			Rules: []Rule{{Action: JumpAction{Target: newName}}},
+		})
+		table.UpdateChain(&Chain{
+			Name:  newName,
+			Rules: []Rule{{Action: AcceptAction{}}},
+		})
+		table.Apply()
+	})
+
+	It("should program the new chain and the jump to it before deleting the old chain", func() {
+		Expect(dataplane.RestoreInputs).To(HaveLen(2))
+		Expect(dataplane.RestoreInputs[0]).To(ContainSubstring("--jump " + newName))
+		Expect(dataplane.RestoreInputs[0]).NotTo(ContainSubstring(oldName))
+		Expect(dataplane.RestoreInputs[1]).To(Equal("*filter\n" +
+			":" + oldName + " - -\n" +
+			"--delete-chain " + oldName + "\n" +
+			"COMMIT\n"))
+	})
+
+	It("should end up with only the new chain", func() {
+		Expect(dataplane.Chains).NotTo(HaveKey(oldName))
+		Expect(dataplane.Chains[newName]).To(HaveLen(1))
+		Expect(dataplane.Chains["cali-tw-cali1234"]).To(ConsistOf(HaveSuffix("--jump " + newName)))
+	})
+}
+
 var _ = Describe("Insert early rules (legacy)", func() {
 	describeInsertEarlyRules("legacy")
 })
@@ -1735,6 +2203,30 @@ func describeInsertEarlyRules(dataplaneMode string) {
 	})
 }
 
+// getMetric returns the value of the counter or gauge with the given name and labels.
+func getMetric(name string, labels map[string]string) (float64, bool) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	Expect(err).NotTo(HaveOccurred())
+	for _, mf := range families {
+		if mf.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				if v, ok := labels[lp.GetName()]; ok && v != lp.GetValue() {
+					continue metrics
+				}
+			}
+			if m.GetGauge() != nil {
+				return m.GetGauge().GetValue(), true
+			}
+			return m.GetCounter().GetValue(), true
+		}
+	}
+	return 0, false
+}
+
 type mockMutex struct {
 	Held     bool
 	WasTaken bool
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -28,6 +29,7 @@ import (
 	. "github.com/onsi/gomega"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/projectcalico/calico/felix/environment"
 	"github.com/projectcalico/calico/felix/iptables/cmdshim"
 
 	"github.com/projectcalico/calico/libcalico-go/lib/set"
@@ -35,6 +37,8 @@ import (
 
 // This file contains shared test infrastructure for testing the iptables package.
 
+var iptablesVersionRegexp = regexp.MustCompile(`v(\d+\.\d+\.\d+)`)
+
 func NewMockDataplane(table string, chains map[string][]string, dataplaneMode string) *MockDataplane {
 	return &MockDataplane{
 		Prologue:      "# generated by dummy iptables-save\n",
@@ -56,16 +60,22 @@ type chainMod struct {
 }
 
 type MockDataplane struct {
-	Prologue                       string
-	Table                          string
-	Chains                         map[string][]string
-	FlushedChains                  set.Set[string]
-	ChainMods                      set.Set[chainMod]
-	DeletedChains                  set.Set[string]
-	Cmds                           []cmdshim.CmdIface
-	CmdNames                       []string
-	FailNextRestore                bool
-	FailAllRestores                bool
+	Prologue        string
+	Table           string
+	Chains          map[string][]string
+	FlushedChains   set.Set[string]
+	ChainMods       set.Set[chainMod]
+	DeletedChains   set.Set[string]
+	Cmds            []cmdshim.CmdIface
+	CmdNames        []string
+	FailNextRestore bool
+	FailAllRestores bool
+	// RestoreStderr, if non-empty, is written to the error output of failed restores.
+	RestoreStderr string
+	// RestoreArgs records the arguments of each restore.
+	RestoreArgs [][]string
+	// RestoreInputs records the input of each restore.
+	RestoreInputs                  []string
 	OnPreRestore                   func()
 	FailNextSaveRead               bool
 	FailNextSaveStdoutPipe         bool
@@ -113,7 +123,14 @@ func (d *MockDataplane) NewCmd(name string, arg ...string) cmdshim.CmdIface {
 	case "iptables-restore", "ip6tables-restore",
 		"iptables-legacy-restore", "ip6tables-legacy-restore",
 		"iptables-nft-restore", "ip6tables-nft-restore":
-		Expect(arg).To(Equal([]string{"--noflush", "--verbose"}))
+		Expect(arg[:2]).To(Equal([]string{"--noflush", "--verbose"}))
+		if len(arg) > 2 {
+			// Versions that support the xtables lock also get its timeouts.
+			Expect(arg[2:]).To(HaveLen(4))
+			Expect(arg[2]).To(Equal("--wait"))
+			Expect(arg[4]).To(Equal("--wait-interval"))
+		}
+		d.RestoreArgs = append(d.RestoreArgs, arg)
 		cmd = &restoreCmd{
 			Dataplane: d,
 		}
@@ -138,6 +155,16 @@ func (d *MockDataplane) NewCmd(name string, arg ...string) cmdshim.CmdIface {
 	return cmd
 }
 
+// nftRestoreReplacesByIndex returns true if the simulated version of iptables-nft-restore handles
+// replace commands correctly, which they do from v1.8.3.
+func (d *MockDataplane) nftRestoreReplacesByIndex() bool {
+	matches := iptablesVersionRegexp.FindStringSubmatch(d.Version)
+	if matches == nil {
+		return false
+	}
+	return environment.MustParseVersion(matches[1]).Compare(environment.MustParseVersion("1.8.3")) >= 0
+}
+
 func (d *MockDataplane) GetKernelVersionReader() (io.Reader, error) {
 	if d.FailNextGetKernelVersionReader {
 		d.FailNextGetKernelVersionReader = false
@@ -227,6 +254,13 @@ func (d *restoreCmd) String() string {
 	return fmt.Sprintf("restoreCmd %#v", d.CapturedStdin)
 }
 
+func (d *restoreCmd) writeRestoreStderr() {
+	if d.Dataplane.RestoreStderr != "" && d.Stderr != nil {
+		_, err := io.WriteString(d.Stderr, d.Dataplane.RestoreStderr)
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
+
 func (d *restoreCmd) Run() error {
 	log.Info("Running simulated iptables-restore")
 	// Get the input.
@@ -234,6 +268,7 @@ func (d *restoreCmd) Run() error {
 	_, err := buf.ReadFrom(d.Stdin)
 	Expect(err).NotTo(HaveOccurred())
 	input := buf.String()
+	d.Dataplane.RestoreInputs = append(d.Dataplane.RestoreInputs, input)
 
 	if d.Dataplane.OnPreRestore != nil {
 		log.Warn("OnPreRestore set, calling it")
@@ -243,10 +278,12 @@ func (d *restoreCmd) Run() error {
 	if d.Dataplane.FailNextRestore {
 		log.Warn("Simulating an iptables-restore failure")
 		d.Dataplane.FailNextRestore = false
+		d.writeRestoreStderr()
 		return errors.New("Simulated failure")
 	}
 	if d.Dataplane.FailAllRestores {
 		log.Warn("Simulating an iptables-restore failure")
+		d.writeRestoreStderr()
 		return errors.New("Simulated failure")
 	}
 
@@ -302,7 +339,7 @@ func (d *restoreCmd) Run() error {
 		switch action {
 		case "-A", "--append":
 			chainName = parts[1]
-			if strings.HasPrefix(chainName, "cali") && d.Dataplane.NftablesMode {
+			if strings.HasPrefix(chainName, "cali") && d.Dataplane.NftablesMode && !d.Dataplane.nftRestoreReplacesByIndex() {
 				Expect(d.Dataplane.FlushedChains.Contains(chainName)).To(BeTrue(),
 					"In nft mode, it's not safe to modify chain without flushing")
 			}
@@ -330,7 +367,10 @@ func (d *restoreCmd) Run() error {
 				d.Dataplane.ChainMods.Add(chainMod{name: chainName, ruleNum: 1})
 			}
 		case "-R", "--replace":
-			Expect(d.Dataplane.NftablesMode).To(BeFalse(), "Replace shouldn't be used in nft mode")
+			if d.Dataplane.NftablesMode {
+				Expect(d.Dataplane.nftRestoreReplacesByIndex()).To(BeTrue(),
+					"Replace shouldn't be used with this version of iptables-nft-restore")
+			}
 			chainName = parts[1]
 			ruleNum, err := strconv.Atoi(parts[2]) // 1-indexed position of rule.
 			Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,274 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptables
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Limits imposed by the kernel's xt_u32 module (XT_U32_MAXSIZE).  iptables rejects expressions
+// that exceed them.
+const (
+	maxU32Tests       = 10
+	maxU32LocationOps = 10
+	maxU32Ranges      = 11
+)
+
+// U32Expr is an expression for the u32 match, which matches packets by extracting 32-bit words
+// from arbitrary offsets in the packet and comparing them with ranges of values.  The packet
+// matches if all the tests match.  Expressions are built from U32At(), for example, to match
+// GTP-U packets with TEID 0x1234 (the TEID is at offset 4 in the GTP header, which follows the
+// 8-byte UDP header):
+//
+//	U32Expr{U32At(0).ShiftRight(22).And(0x3C).Move(12).Equals(0x1234)}
+type U32Expr []U32Test
+
+// U32Test compares the word at a location with a list of ranges; it matches if the word is in
+// any of the ranges.
+type U32Test struct {
+	Location U32Location
+	Ranges   []U32Range
+}
+
+// U32Range is an inclusive range of values.
+type U32Range struct {
+	Min, Max uint32
+}
+
+// U32Location describes how to find the word to test: the word at a starting offset from the
+// IP header, transformed by a sequence of operators.
+type U32Location struct {
+	Start uint32
+	Ops   []U32Op
+}
+
+type U32Operator string
+
+const (
+	// U32OpAnd masks the current value.
+	U32OpAnd U32Operator = "&"
+	// U32OpShiftLeft and U32OpShiftRight shift the current value.
+	U32OpShiftLeft  U32Operator = "<<"
+	U32OpShiftRight U32Operator = ">>"
+	// U32OpMove moves the start of the packet forward by the current value, then reads the
+	// word at the operand's offset from there.  It is used to skip variable-length headers.
+	U32OpMove U32Operator = "@"
+)
+
+type U32Op struct {
+	Operator U32Operator
+	Operand  uint32
+}
+
+// U32At starts a location at the word at the given byte offset from the start of the IP header.
+func U32At(offset uint32) U32Location {
+	return U32Location{Start: offset}
+}
+
+func (l U32Location) And(mask uint32) U32Location {
+	return l.withOp(U32OpAnd, mask)
+}
+
+func (l U32Location) ShiftLeft(bits uint32) U32Location {
+	return l.withOp(U32OpShiftLeft, bits)
+}
+
+func (l U32Location) ShiftRight(bits uint32) U32Location {
+	return l.withOp(U32OpShiftRight, bits)
+}
+
+func (l U32Location) Move(offset uint32) U32Location {
+	return l.withOp(U32OpMove, offset)
+}
+
+func (l U32Location) withOp(operator U32Operator, operand uint32) U32Location {
+	// Copy the ops so that locations can be extended in different ways without aliasing.
+	ops := make([]U32Op, len(l.Ops), len(l.Ops)+1)
+	copy(ops, l.Ops)
+	l.Ops = append(ops, U32Op{Operator: operator, Operand: operand})
+	return l
+}
+
+// Equals returns a test that matches if the word at the location is one of the given values.
+func (l U32Location) Equals(values ...uint32) U32Test {
+	t := U32Test{Location: l}
+	for _, v := range values {
+		t.Ranges = append(t.Ranges, U32Range{Min: v, Max: v})
+	}
+	return t
+}
+
+// InRanges returns a test that matches if the word at the location is in one of the ranges.
+func (l U32Location) InRanges(ranges ...U32Range) U32Test {
+	return U32Test{Location: l, Ranges: ranges}
+}
+
+// Validate checks the expression against the limits of the u32 match.
+func (e U32Expr) Validate() error {
+	if len(e) == 0 {
+		return errors.New("u32 expression has no tests")
+	}
+	if len(e) > maxU32Tests {
+		return fmt.Errorf("u32 expression has %d tests, the limit is %d", len(e), maxU32Tests)
+	}
+	for _, t := range e {
+		if len(t.Location.Ops) > maxU32LocationOps {
+			return fmt.Errorf("u32 location has %d operators, the limit is %d",
+				len(t.Location.Ops), maxU32LocationOps)
+		}
+		for _, op := range t.Location.Ops {
+			switch op.Operator {
+			case U32OpAnd, U32OpMove:
+			case U32OpShiftLeft, U32OpShiftRight:
+				if op.Operand > 31 {
+					return fmt.Errorf("u32 shift of %d bits is too large", op.Operand)
+				}
+			default:
+				return fmt.Errorf("unknown u32 operator %q", op.Operator)
+			}
+		}
+		if len(t.Ranges) == 0 {
+			return errors.New("u32 test has no values")
+		}
+		if len(t.Ranges) > maxU32Ranges {
+			return fmt.Errorf("u32 test has %d values, the limit is %d", len(t.Ranges), maxU32Ranges)
+		}
+		for _, r := range t.Ranges {
+			if r.Min > r.Max {
+				return fmt.Errorf("u32 range %d:%d is empty", r.Min, r.Max)
+			}
+		}
+	}
+	return nil
+}
+
+// String renders the expression in the syntax of iptables' --u32 option.  It contains no spaces
+// so it doesn't need quoting.
+func (e U32Expr) String() string {
+	tests := make([]string, len(e))
+	for i, t := range e {
+		var sb strings.Builder
+		sb.WriteString(formatU32Number(t.Location.Start))
+		for _, op := range t.Location.Ops {
+			sb.WriteString(string(op.Operator))
+			sb.WriteString(formatU32Number(op.Operand))
+		}
+		sb.WriteString("=")
+		for j, r := range t.Ranges {
+			if j > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(formatU32Number(r.Min))
+			if r.Max != r.Min {
+				sb.WriteString(":")
+				sb.WriteString(formatU32Number(r.Max))
+			}
+		}
+		tests[i] = sb.String()
+	}
+	return strings.Join(tests, "&&")
+}
+
+func formatU32Number(n uint32) string {
+	if n < 10 {
+		return strconv.FormatUint(uint64(n), 10)
+	}
+	return fmt.Sprintf("%#x", n)
+}
+
+// ParseU32Expr parses and validates an expression in the syntax of iptables' --u32 option, for
+// example "0>>22&0x3C@12=0x1234".
+func ParseU32Expr(s string) (U32Expr, error) {
+	s = strings.Join(strings.Fields(s), "")
+	var expr U32Expr
+	for _, testStr := range strings.Split(s, "&&") {
+		locStr, rangesStr, found := strings.Cut(testStr, "=")
+		if !found {
+			return nil, fmt.Errorf("u32 test %q has no '='", testStr)
+		}
+		loc, err := parseU32Location(locStr)
+		if err != nil {
+			return nil, err
+		}
+		test := U32Test{Location: loc}
+		for _, rangeStr := range strings.Split(rangesStr, ",") {
+			minStr, maxStr, isRange := strings.Cut(rangeStr, ":")
+			r := U32Range{}
+			if r.Min, err = parseU32Number(minStr); err != nil {
+				return nil, err
+			}
+			r.Max = r.Min
+			if isRange {
+				if r.Max, err = parseU32Number(maxStr); err != nil {
+					return nil, err
+				}
+			}
+			test.Ranges = append(test.Ranges, r)
+		}
+		expr = append(expr, test)
+	}
+	if err := expr.Validate(); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+func parseU32Location(s string) (U32Location, error) {
+	end := strings.IndexAny(s, "&<>@")
+	if end < 0 {
+		end = len(s)
+	}
+	start, err := parseU32Number(s[:end])
+	if err != nil {
+		return U32Location{}, err
+	}
+	loc := U32At(start)
+	s = s[end:]
+	for s != "" {
+		var operator U32Operator
+		for _, o := range []U32Operator{U32OpAnd, U32OpShiftLeft, U32OpShiftRight, U32OpMove} {
+			if strings.HasPrefix(s, string(o)) {
+				operator = o
+				break
+			}
+		}
+		if operator == "" {
+			return U32Location{}, fmt.Errorf("bad u32 operator at %q", s)
+		}
+		s = s[len(operator):]
+		end = strings.IndexAny(s, "&<>@")
+		if end < 0 {
+			end = len(s)
+		}
+		operand, err := parseU32Number(s[:end])
+		if err != nil {
+			return U32Location{}, err
+		}
+		loc = loc.withOp(operator, operand)
+		s = s[end:]
+	}
+	return loc, nil
+}
+
+func parseU32Number(s string) (uint32, error) {
+	n, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("bad u32 number %q", s)
+	}
+	return uint32(n), nil
+}
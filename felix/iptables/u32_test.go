@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptables_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/projectcalico/calico/felix/iptables"
+)
+
+var gtpTEIDExpr = U32Expr{
+	U32At(6).And(0xFF).Equals(17),
+	U32At(0).ShiftRight(22).And(0x3C).Move(12).Equals(0x1234, 0x5678),
+}
+
+var _ = DescribeTable("U32Expr parsing",
+	func(s string, expected U32Expr) {
+		expr, err := ParseU32Expr(s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expr).To(Equal(expected))
+	},
+	Entry("single value", "6&0xFF=17", U32Expr{U32At(6).And(0xFF).Equals(17)}),
+	Entry("ranges", "0&0xFFFF=0x100:0xFFFF,5",
+		U32Expr{U32At(0).And(0xFFFF).InRanges(U32Range{Min: 0x100, Max: 0xFFFF}, U32Range{Min: 5, Max: 5})}),
+	Entry("all operators", "0>>22&0x3C@4<<8=1", U32Expr{U32At(0).ShiftRight(22).And(0x3C).Move(4).ShiftLeft(8).Equals(1)}),
+	Entry("multiple tests with spaces", "6 & 0xFF = 17 && 0>>22&0x3C@12=0x1234,0x5678", gtpTEIDExpr),
+)
+
+var _ = DescribeTable("U32Expr parsing errors",
+	func(s string) {
+		_, err := ParseU32Expr(s)
+		Expect(err).To(HaveOccurred())
+	},
+	Entry("empty", ""),
+	Entry("no values", "0&0xFF"),
+	Entry("bad number", "0&0xFG=1"),
+	Entry("number too large", "0&0x100000000=1"),
+	Entry("bad operator", "0|0xFF=1"),
+	Entry("missing operand", "0&=1"),
+	Entry("large shift", "0>>32=1"),
+	Entry("empty range", "0=5:4"),
+	Entry("too many values", "0=1,2,3,4,5,6,7,8,9,10,11,12"),
+	Entry("too many tests", "0=1&&0=1&&0=1&&0=1&&0=1&&0=1&&0=1&&0=1&&0=1&&0=1&&0=1"),
+	Entry("too many operators", "0&1&1&1&1&1&1&1&1&1&1&1=1"),
+)
+
+var _ = Describe("U32Expr", func() {
+	It("should round trip through String", func() {
+		Expect(gtpTEIDExpr.String()).To(Equal("6&0xff=0x11&&0>>0x16&0x3c@0xc=0x1234,0x5678"))
+		Expect(ParseU32Expr(gtpTEIDExpr.String())).To(Equal(gtpTEIDExpr))
+	})
+	It("should not alias the operators of extended locations", func() {
+		base := U32At(0).ShiftRight(22).And(0x3C)
+		_ = base.Move(4)
+		Expect(base.Move(8)).To(Equal(U32Location{Start: 0, Ops: []U32Op{
+			{Operator: U32OpShiftRight, Operand: 22},
+			{Operator: U32OpAnd, Operand: 0x3C},
+			{Operator: U32OpMove, Operand: 8},
+		}}))
+	})
+})
@@ -731,6 +731,26 @@ func (idx *SelectorAndNamedPortIndex) CalculateEndpointContribution(d *endpointD
 	return
 }
 
+// IPSetContributors returns the endpoints (and network sets) that contribute each member of the
+// given IP set, or nil if the IP set is unknown.  It scans every endpoint that matches the IP set so
+// it is intended for diagnostics only.
+func (idx *SelectorAndNamedPortIndex) IPSetContributors(ipSetID string) map[IPSetMember][]interface{} {
+	ipSetData := idx.ipSetDataByID[ipSetID]
+	if ipSetData == nil {
+		return nil
+	}
+	contributors := map[IPSetMember][]interface{}{}
+	for epID, epData := range idx.endpointDataByID {
+		if epData.cachedMatchingIPSetIDs == nil || !epData.cachedMatchingIPSetIDs.Contains(ipSetID) {
+			continue
+		}
+		for _, member := range idx.CalculateEndpointContribution(epData, ipSetData) {
+			contributors[member] = append(contributors[member], epID)
+		}
+	}
+	return contributors
+}
+
 // RecalcCachedContributions uses the cached set of matching IP set IDs in the endpoint
 // struct to quickly recalculate the endpoint's contribution to all IP sets.
 func (idx *SelectorAndNamedPortIndex) RecalcCachedContributions(epData *endpointData) map[string][]IPSetMember {
@@ -77,6 +77,14 @@ var _ = Describe("SelectorAndNamedPortIndex", func() {
 			set, ok := recorder.ipsets["villains"]
 			Expect(ok).To(BeTrue())
 			Expect(set).To(HaveLen(1))
+
+			By("reporting both network sets as contributors of the CIDR")
+			contributors := uut.IPSetContributors("villains")
+			Expect(contributors).To(HaveLen(1))
+			for _, ids := range contributors {
+				Expect(ids).To(ConsistOf(model.NetworkSetKey{Name: "blinky"}, model.NetworkSetKey{Name: "inky"}))
+			}
+			Expect(uut.IPSetContributors("unknown")).To(BeNil())
 		})
 	})
 
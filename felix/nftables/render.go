@@ -0,0 +1,559 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nftables
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/projectcalico/calico/felix/environment"
+	"github.com/projectcalico/calico/felix/iptables"
+)
+
+const (
+	// maxCommentLen is the longest comment that nft accepts on a rule.
+	maxCommentLen = 128
+)
+
+var (
+	// ErrUnsupported is returned (wrapped) when a match or action has no nftables equivalent.
+	ErrUnsupported = errors.New("not supported by the nftables backend")
+
+	// vxlanVNIRegexp matches the u32 expression generated by MatchCriteria.VXLANVNI(), capturing the VNI.
+	vxlanVNIRegexp = regexp.MustCompile(`^"0>>22&0x3C@12>>8=(0x[0-9a-fA-F]+)"$`)
+	// commentUnsafe matches characters that we don't allow in rule comments.
+	commentUnsafe = regexp.MustCompile(`[^\w :,./-]`)
+)
+
+// ruleRenderer translates rules, which are expressed using iptables match and action fragments,
+// into nft rule expressions.  Only the fragments that the iptables.MatchCriteria builder methods
+// and the iptables Action types generate are understood.
+type ruleRenderer struct {
+	// addrKeyword is the nft payload keyword for the IP header, "ip" or "ip6".
+	addrKeyword string
+	// icmpKeyword is the nft payload keyword for the ICMP header, "icmp" or "icmpv6".
+	icmpKeyword string
+	// chainPrefix is prepended to chain names to give the names of the nft chains.
+	chainPrefix string
+	// hashCommentPrefix is prepended to rule hashes in rule comments.
+	hashCommentPrefix string
+	ipVersion         uint8
+}
+
+func newRuleRenderer(ipVersion uint8, chainPrefix, hashCommentPrefix string) ruleRenderer {
+	r := ruleRenderer{
+		addrKeyword:       "ip",
+		icmpKeyword:       "icmp",
+		chainPrefix:       chainPrefix,
+		hashCommentPrefix: hashCommentPrefix,
+		ipVersion:         ipVersion,
+	}
+	if ipVersion == 6 {
+		r.addrKeyword = "ip6"
+		r.icmpKeyword = "icmpv6"
+	}
+	return r
+}
+
+// chainName returns the name of the nft chain that holds the given chain.
+func (r ruleRenderer) chainName(chain string) string {
+	return r.chainPrefix + chain
+}
+
+// renderRule returns the nft expressions and statements for the given rule, ending with a
+// comment that holds the rule's hash, followed by any comments on the rule.
+func (r ruleRenderer) renderRule(rule iptables.Rule, hash string, features *environment.Features) (string, error) {
+	var parts []string
+	match, err := r.renderMatch(rule.Match, hash)
+	if err != nil {
+		return "", err
+	}
+	if match != "" {
+		parts = append(parts, match)
+	}
+	parts = append(parts, "counter")
+	action, err := r.renderAction(rule.Action, features)
+	if err != nil {
+		return "", err
+	}
+	if action != "" {
+		parts = append(parts, action)
+	}
+	// nft only allows one comment per rule; the hash goes first so that we can find it again.
+	comments := append([]string{r.hashCommentPrefix + hash}, rule.Comment...)
+	comment := commentUnsafe.ReplaceAllString(strings.Join(comments, ", "), "_")
+	if len(comment) > maxCommentLen {
+		comment = comment[:maxCommentLen]
+	}
+	parts = append(parts, fmt.Sprintf(`comment "%s"`, comment))
+	return strings.Join(parts, " "), nil
+}
+
+// renderMatch translates the given match criteria into nft expressions.  hash is the hash of the
+// rule, which is used to name any meters that the rule needs.
+func (r ruleRenderer) renderMatch(m iptables.MatchCriteria, hash string) (string, error) {
+	var exprs []string
+	for i := 0; i < len(m); i++ {
+		words := strings.Fields(m[i])
+		if len(words) == 0 {
+			continue
+		}
+		if len(words) > 1 && words[0] == "-m" && words[1] == "rpfilter" && i+1 < len(m) && m[i+1] == "--accept-local" {
+			// RPFCheckPassed/Failed() add the accept-local flag as a separate fragment.
+			words = append(words, m[i+1])
+			i++
+		}
+		var expr string
+		var err error
+		if len(words) > 2 && words[0] == "-m" && words[1] == "connlimit" {
+			// Unlike hashlimit, connlimit matches don't have a name, so name the meter after
+			// the rule.
+			expr, err = r.renderConnLimit(words[2:], "cl-"+hash)
+		} else {
+			expr, err = r.renderMatchWords(words)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to translate match %q: %w", m[i], err)
+		}
+		exprs = append(exprs, expr)
+	}
+	return strings.Join(exprs, " "), nil
+}
+
+func (r ruleRenderer) renderMatchWords(words []string) (string, error) {
+	negated := false
+	if words[0] == "!" {
+		negated = true
+		words = words[1:]
+	}
+	if len(words) == 2 {
+		switch words[0] {
+		case "-p":
+			return "meta l4proto " + neOp(negated) + words[1], nil
+		case "--source":
+			return r.addrKeyword + " saddr " + neOp(negated) + words[1], nil
+		case "--destination":
+			return r.addrKeyword + " daddr " + neOp(negated) + words[1], nil
+		case "--in-interface":
+			return "iifname " + neOp(negated) + nftIfaceMatch(words[1]), nil
+		case "--out-interface":
+			return "oifname " + neOp(negated) + nftIfaceMatch(words[1]), nil
+		}
+	}
+	if len(words) > 2 && words[0] == "-m" && !negated {
+		return r.renderModuleMatch(words[1], words[2:])
+	}
+	return "", ErrUnsupported
+}
+
+func (r ruleRenderer) renderModuleMatch(module string, args []string) (string, error) {
+	negated := false
+	if args[0] == "!" {
+		negated = true
+		args = args[1:]
+	}
+	switch module {
+	case "mark", "connmark":
+		if len(args) != 2 || args[0] != "--mark" {
+			break
+		}
+		valueAndMask := strings.SplitN(args[1], "/", 2)
+		if len(valueAndMask) != 2 {
+			break
+		}
+		value, err := strconv.ParseUint(valueAndMask[0], 0, 32)
+		if err != nil {
+			return "", err
+		}
+		mask, err := strconv.ParseUint(valueAndMask[1], 0, 32)
+		if err != nil {
+			return "", err
+		}
+		markExpr := "meta mark"
+		if module == "connmark" {
+			markExpr = "ct mark"
+		}
+		return fmt.Sprintf("%s & %#x %s %#x", markExpr, mask, eqOp(negated), value), nil
+	case "set":
+		if len(args) != 3 || args[0] != "--match-set" {
+			break
+		}
+		var key string
+		switch args[2] {
+		case "src":
+			key = r.addrKeyword + " saddr"
+		case "dst":
+			key = r.addrKeyword + " daddr"
+		case "src,src":
+			// Note: the rules only use two-dimensional matches for hash:ip,port IP sets.
+			key = r.addrKeyword + " saddr . meta l4proto . th sport"
+		case "dst,dst":
+			key = r.addrKeyword + " daddr . meta l4proto . th dport"
+		default:
+			return "", ErrUnsupported
+		}
+		return fmt.Sprintf(`%s %s@"%s"`, key, neOp(negated), args[1]), nil
+	case "multiport":
+		if len(args) != 2 {
+			break
+		}
+		var key string
+		switch args[0] {
+		case "--source-ports":
+			key = "th sport"
+		case "--destination-ports":
+			key = "th dport"
+		default:
+			return "", ErrUnsupported
+		}
+		ports := strings.Split(args[1], ",")
+		for i, p := range ports {
+			ports[i] = strings.Replace(p, ":", "-", 1)
+		}
+		return fmt.Sprintf("%s %s{ %s }", key, neOp(negated), strings.Join(ports, ", ")), nil
+	case "conntrack":
+		if len(args) != 2 || args[0] != "--ctstate" {
+			break
+		}
+		states := strings.Split(strings.ToLower(args[1]), ",")
+		if negated {
+			return fmt.Sprintf("ct state & (%s) == 0", strings.Join(states, "|")), nil
+		}
+		return "ct state " + strings.Join(states, ","), nil
+	case "addrtype":
+		if len(args) < 2 || args[1] != string(iptables.AddrTypeLocal) {
+			break
+		}
+		var key string
+		switch {
+		case args[0] == "--src-type" && len(args) == 2:
+			key = "fib saddr type"
+		case args[0] == "--src-type" && len(args) == 3 && args[2] == "--limit-iface-out":
+			key = "fib saddr . oif type"
+		case args[0] == "--dst-type" && len(args) == 2:
+			key = "fib daddr type"
+		default:
+			return "", ErrUnsupported
+		}
+		return key + " " + neOp(negated) + "local", nil
+	case "rpfilter":
+		if negated {
+			break
+		}
+		invert, validMark, acceptLocal := false, false, false
+		for _, a := range args {
+			switch a {
+			case "--invert":
+				invert = true
+			case "--validmark":
+				validMark = true
+			case "--accept-local":
+				acceptLocal = true
+			default:
+				return "", ErrUnsupported
+			}
+		}
+		key := "fib saddr . iif oif"
+		if validMark {
+			key = "fib saddr . mark . iif oif"
+		}
+		if !invert {
+			if acceptLocal {
+				// Would need an "or" of two expressions.
+				return "", ErrUnsupported
+			}
+			return key + " exists", nil
+		}
+		expr := key + " missing"
+		if acceptLocal {
+			expr += " fib saddr type != local"
+		}
+		return expr, nil
+	case "icmp", "icmp6":
+		if len(args) != 2 || (args[0] != "--icmp-type" && args[0] != "--icmpv6-type") {
+			break
+		}
+		typeAndCode := strings.SplitN(args[1], "/", 2)
+		if len(typeAndCode) == 1 {
+			return fmt.Sprintf("%s type %s%s", r.icmpKeyword, neOp(negated), typeAndCode[0]), nil
+		}
+		if negated {
+			return fmt.Sprintf("%s type . %s code != %s . %s",
+				r.icmpKeyword, r.icmpKeyword, typeAndCode[0], typeAndCode[1]), nil
+		}
+		return fmt.Sprintf("%s type %s %s code %s",
+			r.icmpKeyword, typeAndCode[0], r.icmpKeyword, typeAndCode[1]), nil
+	case "sctp":
+		if len(args) != 3 || args[0] != "--chunk-types" || args[1] != "any" {
+			break
+		}
+		chunkTypes := strings.Split(args[2], ",")
+		if len(chunkTypes) > 1 && !negated {
+			// Would need an "or" of the chunk expressions.
+			return "", ErrUnsupported
+		}
+		var exprs []string
+		for _, t := range chunkTypes {
+			// nft names the chunk types in lower case, with dashes rather than underscores.
+			name := strings.ReplaceAll(strings.ToLower(t), "_", "-")
+			if negated {
+				exprs = append(exprs, "sctp chunk "+name+" missing")
+			} else {
+				exprs = append(exprs, "sctp chunk "+name+" exists")
+			}
+		}
+		return strings.Join(exprs, " "), nil
+	case "iprange":
+		if len(args) != 2 {
+			break
+		}
+		var key string
+		switch args[0] {
+		case "--src-range":
+			key = r.addrKeyword + " saddr"
+		case "--dst-range":
+			key = r.addrKeyword + " daddr"
+		default:
+			return "", ErrUnsupported
+		}
+		return fmt.Sprintf("%s %s%s", key, neOp(negated), args[1]), nil
+	case "socket":
+		if negated || len(args) != 1 || args[0] != "--transparent" {
+			break
+		}
+		return "socket transparent 1", nil
+	case "hashlimit":
+		if negated {
+			break
+		}
+		return r.renderHashLimit(args)
+	case "u32":
+		if negated || len(args) != 2 || args[0] != "--u32" {
+			break
+		}
+		captures := vxlanVNIRegexp.FindStringSubmatch(args[1])
+		if captures == nil {
+			break
+		}
+		// The VNI is the 3 bytes that follow the 8-byte UDP header and 4 bytes of VXLAN flags.
+		return "@th,96,24 " + captures[1], nil
+	}
+	return "", ErrUnsupported
+}
+
+// renderHashLimit translates the arguments of a hashlimit match into a meter that applies a limit
+// statement to each address.
+func (r ruleRenderer) renderHashLimit(args []string) (string, error) {
+	params, err := parseModuleArgs(args)
+	if err != nil {
+		return "", err
+	}
+	var key, rate, op string
+	switch {
+	case params["--hashlimit-above"] != "":
+		rate, op = params["--hashlimit-above"], "over "
+	case params["--hashlimit-upto"] != "":
+		rate = params["--hashlimit-upto"]
+	default:
+		return "", ErrUnsupported
+	}
+	if !strings.HasSuffix(rate, "/sec") || params["--hashlimit-name"] == "" {
+		return "", ErrUnsupported
+	}
+	switch params["--hashlimit-mode"] {
+	case string(iptables.HashLimitModeSrcIP):
+		key, err = r.maskedAddr("saddr", params["--hashlimit-srcmask"])
+	case string(iptables.HashLimitModeDstIP):
+		key, err = r.maskedAddr("daddr", params["--hashlimit-dstmask"])
+	default:
+		return "", ErrUnsupported
+	}
+	if err != nil {
+		return "", err
+	}
+	limit := fmt.Sprintf("limit rate %s%s/second", op, strings.TrimSuffix(rate, "/sec"))
+	if burst := params["--hashlimit-burst"]; burst != "" {
+		limit += fmt.Sprintf(" burst %s packets", burst)
+	}
+	return fmt.Sprintf("meter %s { %s %s }", params["--hashlimit-name"], key, limit), nil
+}
+
+// renderConnLimit translates the arguments of a connlimit match into a meter that counts the
+// connections of each source address.
+func (r ruleRenderer) renderConnLimit(args []string, meterName string) (string, error) {
+	if len(args) == 0 || args[len(args)-1] != "--connlimit-saddr" {
+		return "", ErrUnsupported
+	}
+	params, err := parseModuleArgs(args[:len(args)-1])
+	if err != nil {
+		return "", err
+	}
+	var count, op string
+	switch {
+	case params["--connlimit-above"] != "":
+		count, op = params["--connlimit-above"], "over "
+	case params["--connlimit-upto"] != "":
+		count = params["--connlimit-upto"]
+	default:
+		return "", ErrUnsupported
+	}
+	key, err := r.maskedAddr("saddr", params["--connlimit-mask"])
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("meter %s { %s ct count %s%s }", meterName, key, op, count), nil
+}
+
+// maskedAddr returns the nft expression for the given address field, masked to the given prefix
+// length, if any.
+func (r ruleRenderer) maskedAddr(field, prefixLen string) (string, error) {
+	key := r.addrKeyword + " " + field
+	if prefixLen == "" {
+		return key, nil
+	}
+	bits := 32
+	if r.ipVersion == 6 {
+		bits = 128
+	}
+	ones, err := strconv.Atoi(prefixLen)
+	if err != nil || ones < 0 || ones > bits {
+		return "", fmt.Errorf("invalid prefix length %q", prefixLen)
+	}
+	if ones == bits {
+		return key, nil
+	}
+	return fmt.Sprintf("%s and %s", key, net.IP(net.CIDRMask(ones, bits)).String()), nil
+}
+
+// parseModuleArgs parses a list of "--flag value" pairs.
+func parseModuleArgs(args []string) (map[string]string, error) {
+	if len(args)%2 != 0 {
+		return nil, ErrUnsupported
+	}
+	params := map[string]string{}
+	for i := 0; i < len(args); i += 2 {
+		if !strings.HasPrefix(args[i], "--") {
+			return nil, ErrUnsupported
+		}
+		params[args[i]] = args[i+1]
+	}
+	return params, nil
+}
+
+// renderAction translates the given action into an nft statement.
+func (r ruleRenderer) renderAction(action iptables.Action, features *environment.Features) (string, error) {
+	switch a := action.(type) {
+	case nil:
+		return "", nil
+	case iptables.GotoAction:
+		return "goto " + r.chainName(a.Target), nil
+	case iptables.JumpAction:
+		return "jump " + r.chainName(a.Target), nil
+	case iptables.ReturnAction:
+		return "return", nil
+	case iptables.DropAction:
+		return "drop", nil
+	case iptables.RejectAction:
+		return "reject", nil
+	case iptables.AcceptAction:
+		return "accept", nil
+	case iptables.LogAction:
+		return fmt.Sprintf(`log prefix "%s: " level notice`, a.Prefix), nil
+	case iptables.NflogAction:
+		return fmt.Sprintf(`log prefix "%s" group %d`, a.Prefix, a.Group), nil
+	case iptables.DNATAction:
+		if a.DestPort == 0 {
+			return "dnat to " + a.DestAddr, nil
+		}
+		if r.ipVersion == 6 {
+			return fmt.Sprintf("dnat to [%s]:%d", a.DestAddr, a.DestPort), nil
+		}
+		return fmt.Sprintf("dnat to %s:%d", a.DestAddr, a.DestPort), nil
+	case iptables.SNATAction:
+		return "snat to " + a.ToAddr + fullyRandom(features.SNATFullyRandom), nil
+	case iptables.MasqAction:
+		stmt := "masquerade"
+		if a.ToPorts != "" {
+			stmt += " to :" + strings.Replace(a.ToPorts, ":", "-", 1)
+		}
+		return stmt + fullyRandom(features.MASQFullyRandom), nil
+	case iptables.ClearMarkAction:
+		return fmt.Sprintf("meta mark set meta mark & %#x", ^a.Mark), nil
+	case iptables.SetMarkAction:
+		return fmt.Sprintf("meta mark set meta mark | %#x", a.Mark), nil
+	case iptables.SetMaskedMarkAction:
+		return fmt.Sprintf("meta mark set meta mark & %#x | %#x", ^a.Mask, a.Mark), nil
+	case iptables.TPROXYAction:
+		// Unlike the iptables target, the nft statement doesn't terminate the rule.
+		return fmt.Sprintf("meta mark set meta mark & %#x | %#x tproxy to :%d accept", ^a.Mask, a.Mark, a.Port), nil
+	case iptables.SetDSCPAction:
+		return fmt.Sprintf("%s dscp set %d", r.addrKeyword, a.Value), nil
+	case iptables.NoTrackAction:
+		return "notrack", nil
+	case iptables.SaveConnMarkAction:
+		// nft can't combine the packet and connection marks in one expression so only
+		// full-mark saves and restores are supported.
+		if a.SaveMask != 0 && a.SaveMask != 0xffffffff {
+			break
+		}
+		return "ct mark set meta mark", nil
+	case iptables.RestoreConnMarkAction:
+		if a.RestoreMask != 0 && a.RestoreMask != 0xffffffff {
+			break
+		}
+		return "meta mark set ct mark", nil
+	case iptables.SetConnMarkAction:
+		mask := a.Mask
+		if mask == 0 {
+			mask = 0xffffffff
+		}
+		return fmt.Sprintf("ct mark set ct mark & %#x | %#x", ^mask, a.Mark), nil
+	}
+	return "", fmt.Errorf("failed to translate action %v: %w", action, ErrUnsupported)
+}
+
+// nftIfaceMatch converts an iptables interface match, which may end with a "+" wildcard, to
+// nft syntax.
+func nftIfaceMatch(iface string) string {
+	if strings.HasSuffix(iface, "+") {
+		iface = strings.TrimSuffix(iface, "+") + "*"
+	}
+	return `"` + iface + `"`
+}
+
+func neOp(negated bool) string {
+	if negated {
+		return "!= "
+	}
+	return ""
+}
+
+func eqOp(negated bool) string {
+	if negated {
+		return "!="
+	}
+	return "=="
+}
+
+func fullyRandom(enabled bool) string {
+	if enabled {
+		return " fully-random"
+	}
+	return ""
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nftables
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/environment"
+	"github.com/projectcalico/calico/felix/iptables"
+)
+
+var _ = DescribeTable("IPv4 rule rendering",
+	func(rule iptables.Rule, expected string) {
+		r := newRuleRenderer(4, "filter-", "cali:")
+		rendered, err := r.renderRule(rule, "abcd", &environment.Features{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered).To(Equal(expected))
+	},
+	Entry("empty rule", iptables.Rule{},
+		`counter comment "cali:abcd"`),
+	Entry("protocol and source CIDR",
+		iptables.Rule{Match: iptables.Match().Protocol("tcp").SourceNet("10.0.0.0/8"), Action: iptables.AcceptAction{}},
+		`meta l4proto tcp ip saddr 10.0.0.0/8 counter accept comment "cali:abcd"`),
+	Entry("negated destination CIDR",
+		iptables.Rule{Match: iptables.Match().NotDestNet("10.0.0.0/8"), Action: iptables.DropAction{}},
+		`ip daddr != 10.0.0.0/8 counter drop comment "cali:abcd"`),
+	Entry("mark bit set",
+		iptables.Rule{Match: iptables.Match().MarkSingleBitSet(0x10), Action: iptables.ReturnAction{}},
+		`meta mark & 0x10 == 0x10 counter return comment "cali:abcd"`),
+	Entry("conntrack mark bit set",
+		iptables.Rule{Match: iptables.Match().ConnMarkMatchesWithMask(0x10, 0x10), Action: iptables.AcceptAction{}},
+		`ct mark & 0x10 == 0x10 counter accept comment "cali:abcd"`),
+	Entry("mark clear and interface wildcard",
+		iptables.Rule{Match: iptables.Match().MarkClear(0x10).InInterface("cali+"), Action: iptables.JumpAction{Target: "cali-foo"}},
+		`meta mark & 0x10 == 0x0 iifname "cali*" counter jump filter-cali-foo comment "cali:abcd"`),
+	Entry("source IP set",
+		iptables.Rule{Match: iptables.Match().SourceIPSet("cali40s:abc"), Action: iptables.DropAction{}},
+		`ip saddr @"cali40s:abc" counter drop comment "cali:abcd"`),
+	Entry("negated destination IP set with goto",
+		iptables.Rule{Match: iptables.Match().NotDestIPSet("cali40d:abc"), Action: iptables.GotoAction{Target: "cali-bar"}},
+		`ip daddr != @"cali40d:abc" counter goto filter-cali-bar comment "cali:abcd"`),
+	Entry("IP and port set",
+		iptables.Rule{Match: iptables.Match().SourceIPPortSet("cali40ipp:x"), Action: iptables.AcceptAction{}},
+		`ip saddr . meta l4proto . th sport @"cali40ipp:x" counter accept comment "cali:abcd"`),
+	Entry("multiple ports",
+		iptables.Rule{Match: iptables.Match().Protocol("udp").DestPorts(53, 80), Action: iptables.AcceptAction{}},
+		`meta l4proto udp th dport { 53, 80 } counter accept comment "cali:abcd"`),
+	Entry("conntrack state",
+		iptables.Rule{Match: iptables.Match().ConntrackState("RELATED,ESTABLISHED"), Action: iptables.AcceptAction{}},
+		`ct state related,established counter accept comment "cali:abcd"`),
+	Entry("local destination",
+		iptables.Rule{Match: iptables.Match().DestAddrType(iptables.AddrTypeLocal), Action: iptables.AcceptAction{}},
+		`fib daddr type local counter accept comment "cali:abcd"`),
+	Entry("RPF check failed",
+		iptables.Rule{Match: iptables.Match().RPFCheckFailed(false), Action: iptables.DropAction{}},
+		`fib saddr . mark . iif oif missing counter drop comment "cali:abcd"`),
+	Entry("ICMP type",
+		iptables.Rule{Match: iptables.Match().ProtocolNum(1).ICMPType(8), Action: iptables.AcceptAction{}},
+		`meta l4proto 1 icmp type 8 counter accept comment "cali:abcd"`),
+	Entry("SCTP init chunk",
+		iptables.Rule{Match: iptables.Match().Protocol("sctp").SCTPChunkTypes(iptables.SCTPChunkInit), Action: iptables.AcceptAction{}},
+		`meta l4proto sctp sctp chunk init exists counter accept comment "cali:abcd"`),
+	Entry("negated SCTP chunk types",
+		iptables.Rule{Match: iptables.Match().Protocol("sctp").NotSCTPChunkTypes(iptables.SCTPChunkInit, iptables.SCTPChunkCookieEcho), Action: iptables.DropAction{}},
+		`meta l4proto sctp sctp chunk init missing sctp chunk cookie-echo missing counter drop comment "cali:abcd"`),
+	Entry("VXLAN VNI",
+		iptables.Rule{Match: iptables.Match().VXLANVNI(4096), Action: iptables.AcceptAction{}},
+		`@th,96,24 0x1000 counter accept comment "cali:abcd"`),
+	Entry("hashlimit above",
+		iptables.Rule{
+			Match: iptables.Match().HashLimitAbove(iptables.HashLimit{
+				Name: "cali-hl", Mode: iptables.HashLimitModeSrcIP, RatePerSecond: 100, Burst: 20,
+			}),
+			Action: iptables.DropAction{},
+		},
+		`meter cali-hl { ip saddr limit rate over 100/second burst 20 packets } counter drop comment "cali:abcd"`),
+	Entry("hashlimit up to, with prefix length",
+		iptables.Rule{
+			Match: iptables.Match().HashLimitUpTo(iptables.HashLimit{
+				Name: "cali-hl", Mode: iptables.HashLimitModeDstIP, RatePerSecond: 10, Burst: 5, PrefixLen: 24,
+			}),
+			Action: iptables.AcceptAction{},
+		},
+		`meter cali-hl { ip daddr and 255.255.255.0 limit rate 10/second burst 5 packets } counter accept comment "cali:abcd"`),
+	Entry("connlimit above",
+		iptables.Rule{Match: iptables.Match().ConnLimitAbove(10, 32), Action: iptables.RejectAction{}},
+		`meter cl-abcd { ip saddr ct count over 10 } counter reject comment "cali:abcd"`),
+	Entry("connlimit up to, with prefix length",
+		iptables.Rule{Match: iptables.Match().ConnLimitUpTo(10, 16), Action: iptables.AcceptAction{}},
+		`meter cl-abcd { ip saddr and 255.255.0.0 ct count 10 } counter accept comment "cali:abcd"`),
+	Entry("IP ranges",
+		iptables.Rule{
+			Match:  iptables.Match().SourceIPRange("10.0.0.1", "10.0.0.20").NotDestIPRange("10.1.0.1", "10.1.0.5"),
+			Action: iptables.AcceptAction{},
+		},
+		`ip saddr 10.0.0.1-10.0.0.20 ip daddr != 10.1.0.1-10.1.0.5 counter accept comment "cali:abcd"`),
+	Entry("log",
+		iptables.Rule{Action: iptables.LogAction{Prefix: "calico-drop"}},
+		`counter log prefix "calico-drop: " level notice comment "cali:abcd"`),
+	Entry("NFLOG",
+		iptables.Rule{Action: iptables.NflogAction{Group: 10, Prefix: "calico-drop"}},
+		`counter log prefix "calico-drop" group 10 comment "cali:abcd"`),
+	Entry("DNAT",
+		iptables.Rule{Action: iptables.DNATAction{DestAddr: "10.0.0.1", DestPort: 80}},
+		`counter dnat to 10.0.0.1:80 comment "cali:abcd"`),
+	Entry("SNAT",
+		iptables.Rule{Action: iptables.SNATAction{ToAddr: "10.0.0.2"}},
+		`counter snat to 10.0.0.2 comment "cali:abcd"`),
+	Entry("masquerade",
+		iptables.Rule{Action: iptables.MasqAction{}},
+		`counter masquerade comment "cali:abcd"`),
+	Entry("set mark",
+		iptables.Rule{Action: iptables.SetMarkAction{Mark: 0x100}},
+		`counter meta mark set meta mark | 0x100 comment "cali:abcd"`),
+	Entry("clear mark",
+		iptables.Rule{Action: iptables.ClearMarkAction{Mark: 0x100}},
+		`counter meta mark set meta mark & 0xfffffeff comment "cali:abcd"`),
+	Entry("set conntrack mark",
+		iptables.Rule{Action: iptables.SetConnMarkAction{Mark: 0x10, Mask: 0xf0}},
+		`counter ct mark set ct mark & 0xffffff0f | 0x10 comment "cali:abcd"`),
+	Entry("TPROXY",
+		iptables.Rule{
+			Match:  iptables.Match().Protocol("tcp").DestPorts(80),
+			Action: iptables.TPROXYAction{Port: 16001, Mark: 0x400, Mask: 0x400},
+		},
+		`meta l4proto tcp th dport { 80 } counter meta mark set meta mark & 0xfffffbff | 0x400 tproxy to :16001 accept comment "cali:abcd"`),
+	Entry("transparent socket",
+		iptables.Rule{Match: iptables.Match().SocketTransparent(), Action: iptables.SetMarkAction{Mark: 0x400}},
+		`socket transparent 1 counter meta mark set meta mark | 0x400 comment "cali:abcd"`),
+	Entry("set DSCP",
+		iptables.Rule{Action: iptables.SetDSCPAction{Value: 46}},
+		`counter ip dscp set 46 comment "cali:abcd"`),
+	Entry("no track",
+		iptables.Rule{Action: iptables.NoTrackAction{}},
+		`counter notrack comment "cali:abcd"`),
+	Entry("comments are sanitised",
+		iptables.Rule{Action: iptables.AcceptAction{}, Comment: []string{`Allow "VXLAN" (it's fine)`}},
+		`counter accept comment "cali:abcd, Allow _VXLAN_ _it_s fine_"`),
+)
+
+var _ = Describe("Rule rendering", func() {
+	It("should use IPv6 keywords for IPv6", func() {
+		r := newRuleRenderer(6, "filter-", "cali:")
+		rendered, err := r.renderRule(iptables.Rule{
+			Match:  iptables.Match().SourceNet("fd00::/64").ICMPV6Type(128),
+			Action: iptables.AcceptAction{},
+		}, "abcd", &environment.Features{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered).To(Equal(`ip6 saddr fd00::/64 icmpv6 type 128 counter accept comment "cali:abcd"`))
+	})
+
+	It("should mask IPv6 addresses for connlimit", func() {
+		r := newRuleRenderer(6, "filter-", "cali:")
+		rendered, err := r.renderRule(iptables.Rule{
+			Match:  iptables.Match().ConnLimitAbove(5, 64),
+			Action: iptables.DropAction{},
+		}, "abcd", &environment.Features{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered).To(Equal(`meter cl-abcd { ip6 saddr and ffff:ffff:ffff:ffff:: ct count over 5 } counter drop comment "cali:abcd"`))
+	})
+
+	It("should reject matches that it can't translate", func() {
+		r := newRuleRenderer(4, "filter-", "cali:")
+		_, err := r.renderRule(iptables.Rule{
+			Match:  iptables.Match().IPVSConnection(),
+			Action: iptables.AcceptAction{},
+		}, "abcd", &environment.Features{})
+		Expect(err).To(MatchError(ContainSubstring("not supported")))
+	})
+})
@@ -0,0 +1,840 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nftables
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+
+	"github.com/projectcalico/calico/felix/environment"
+	"github.com/projectcalico/calico/felix/iptables"
+	"github.com/projectcalico/calico/felix/iptables/cmdshim"
+	"github.com/projectcalico/calico/felix/logutils"
+)
+
+const (
+	// DefaultTableName is the name of the nftables table that we program our chains into.  It's
+	// shared with the nft IP sets backend so that our rules can refer to its sets.
+	DefaultTableName = "calico"
+
+	minPostWriteInterval = 50 * time.Millisecond
+)
+
+// baseChain describes the hook of one of the base chains that stand in for the kernel chains
+// of an iptables table.
+type baseChain struct {
+	chainType string
+	hook      string
+	priority  int
+}
+
+var (
+	// tableToBaseChains maps from iptables table name to the base chains that we create for it,
+	// indexed by the name of the equivalent iptables kernel chain.  The priorities are the ones
+	// that nft uses for the iptables tables of the same names.
+	tableToBaseChains = map[string]map[string]baseChain{
+		"raw": {
+			"PREROUTING": {"filter", "prerouting", -300},
+			"OUTPUT":     {"filter", "output", -300},
+		},
+		"mangle": {
+			"PREROUTING":  {"filter", "prerouting", -150},
+			"INPUT":       {"filter", "input", -150},
+			"FORWARD":     {"filter", "forward", -150},
+			"OUTPUT":      {"route", "output", -150},
+			"POSTROUTING": {"filter", "postrouting", -150},
+		},
+		"nat": {
+			"PREROUTING":  {"nat", "prerouting", -100},
+			"INPUT":       {"nat", "input", 100},
+			"OUTPUT":      {"nat", "output", -100},
+			"POSTROUTING": {"nat", "postrouting", 100},
+		},
+		"filter": {
+			"INPUT":   {"filter", "input", 0},
+			"FORWARD": {"filter", "forward", 0},
+			"OUTPUT":  {"filter", "output", 0},
+		},
+	}
+
+	// setRefRegexp matches a reference to a named set in nft output, capturing the set name.
+	setRefRegexp = regexp.MustCompile(`@"?([\w:./-]+)"?(?:\s|$)`)
+
+	// Prometheus metrics.
+	countNumRestoreCalls = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "felix_nftables_restore_calls",
+		Help: "Number of nft -f calls.",
+	})
+	countNumRestoreErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "felix_nftables_restore_errors",
+		Help: "Number of nft -f errors.",
+	})
+	countNumListCalls = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "felix_nftables_list_calls",
+		Help: "Number of nft list calls.",
+	})
+	countNumListErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "felix_nftables_list_errors",
+		Help: "Number of nft list errors.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(countNumRestoreCalls)
+	prometheus.MustRegister(countNumRestoreErrors)
+	prometheus.MustRegister(countNumListCalls)
+	prometheus.MustRegister(countNumListErrors)
+}
+
+// Table is the nftables equivalent of iptables.Table; it has the same API and takes the same
+// chains and rules, which it translates into nft syntax.  Each Table manages one of the iptables
+// tables ("raw", "mangle", "nat" or "filter") in a shared nftables table; its chains are named
+// "<iptables table>-<chain>".  The iptables kernel chains are replaced by base chains of our
+// own, which hook the same places, so the rules that would be inserted into (or appended to) a
+// kernel chain make up the whole of the corresponding base chain.  Note: a verdict in one of our
+// base chains doesn't stop packets from traversing other base chains on the same hook, such as
+// those of iptables-nft.
+//
+// Each Apply() writes all the changes as a single 'nft -f' transaction, so the dataplane moves
+// atomically from one ruleset to the next.  Chains that need to change are flushed and rewritten
+// in full.  As with iptables.Table, each rule carries a hash of its contents in its comment;
+// drift is detected by listing the table and comparing the hashes with the ones that we expect.
+//
+// Table doesn't do any internal synchronization, its methods should only be called from one
+// thread.
+type Table struct {
+	Name      string
+	IPVersion uint8
+
+	// family is the nftables address family, "ip" or "ip6".
+	family   string
+	nftTable string
+
+	featureDetector environment.FeatureDetectorIface
+	renderer        ruleRenderer
+
+	// chainToInsertedRules and chainToAppendedRules hold the rules for the base chains, indexed
+	// by the name of the equivalent kernel chain.
+	chainToInsertedRules map[string][]iptables.Rule
+	chainToAppendedRules map[string][]iptables.Rule
+
+	// chainNameToChain contains the desired state of our other chains.  As in iptables.Table,
+	// chains are only programmed while they are referenced.
+	chainNameToChain map[string]*iptables.Chain
+	chainRefCounts   map[string]int
+	// dirtyChains contains the chains, including base chains, that may need to be rewritten.
+	dirtyChains set.Set[string]
+
+	inSyncWithDataPlane bool
+
+	// chainToDataplaneHashes contains the rule hashes that we think are in the dataplane.
+	chainToDataplaneHashes map[string][]string
+	// chainToIPSetRefs contains the names of the IP sets that are referenced by each chain, as
+	// we think they are in the dataplane.
+	chainToIPSetRefs map[string]set.Set[string]
+
+	// hashCommentRegexp matches the rule-tracking comment, capturing the rule hash.
+	hashCommentRegexp *regexp.Regexp
+
+	lastReadTime             time.Time
+	lastWriteTime            time.Time
+	initialPostWriteInterval time.Duration
+	postWriteInterval        time.Duration
+	refreshInterval          time.Duration
+
+	logCxt *log.Entry
+
+	newCmd    cmdshim.CmdFactory
+	timeSleep func(d time.Duration)
+	timeNow   func() time.Time
+
+	onStillAlive        func()
+	onPersistentFailure func(err error)
+	opReporter          logutils.OpRecorder
+}
+
+type TableOptions struct {
+	// TableName overrides the name of the nftables table; it defaults to DefaultTableName.
+	TableName         string
+	RefreshInterval   time.Duration
+	PostWriteInterval time.Duration
+	// OnPersistentFailure, if non-nil, is called instead of panicking if we fail to program the
+	// table after retries; see iptables.TableOptions.OnPersistentFailure.
+	OnPersistentFailure func(err error)
+
+	// NewCmdOverride for tests, if non-nil, factory to use instead of the real exec.Command()
+	NewCmdOverride cmdshim.CmdFactory
+	// SleepOverride for tests, if non-nil, replacement for time.Sleep()
+	SleepOverride func(d time.Duration)
+	// NowOverride for tests, if non-nil, replacement for time.Now()
+	NowOverride func() time.Time
+	// Thunk to call periodically when doing a long-running operation.
+	OnStillAlive func()
+	// OpRecorder to tell when we do resyncs etc.
+	OpRecorder logutils.OpRecorder
+}
+
+func NewTable(
+	name string,
+	ipVersion uint8,
+	hashPrefix string,
+	featureDetector environment.FeatureDetectorIface,
+	options TableOptions,
+) *Table {
+	baseChains, ok := tableToBaseChains[name]
+	if !ok {
+		log.WithField("table", name).Panic("Unknown table")
+	}
+
+	// Pre-populate the base chains with empty lists of rules.  Base chains are referenced by
+	// definition and we always program them, which ensures that we clean up any rules that we
+	// wrote on a previous run.
+	inserts := map[string][]iptables.Rule{}
+	appends := map[string][]iptables.Rule{}
+	refCounts := map[string]int{}
+	dirtyChains := set.New[string]()
+	for chainName := range baseChains {
+		inserts[chainName] = []iptables.Rule{}
+		appends[chainName] = []iptables.Rule{}
+		refCounts[chainName] = 1
+		dirtyChains.Add(chainName)
+	}
+
+	family := "ip"
+	if ipVersion == 6 {
+		family = "ip6"
+	}
+	nftTable := options.TableName
+	if nftTable == "" {
+		nftTable = DefaultTableName
+	}
+	if options.PostWriteInterval <= minPostWriteInterval {
+		options.PostWriteInterval = minPostWriteInterval
+	}
+
+	newCmd := cmdshim.NewRealCmd
+	if options.NewCmdOverride != nil {
+		newCmd = options.NewCmdOverride
+	}
+	sleep := time.Sleep
+	if options.SleepOverride != nil {
+		sleep = options.SleepOverride
+	}
+	now := time.Now
+	if options.NowOverride != nil {
+		now = options.NowOverride
+	}
+	onStillAlive := options.OnStillAlive
+	if onStillAlive == nil {
+		onStillAlive = func() {}
+	}
+
+	return &Table{
+		Name:                   name,
+		IPVersion:              ipVersion,
+		family:                 family,
+		nftTable:               nftTable,
+		featureDetector:        featureDetector,
+		renderer:               newRuleRenderer(ipVersion, name+"-", hashPrefix),
+		chainToInsertedRules:   inserts,
+		chainToAppendedRules:   appends,
+		chainNameToChain:       map[string]*iptables.Chain{},
+		chainRefCounts:         refCounts,
+		dirtyChains:            dirtyChains,
+		chainToDataplaneHashes: map[string][]string{},
+		chainToIPSetRefs:       map[string]set.Set[string]{},
+		hashCommentRegexp:      regexp.MustCompile(`comment "` + hashPrefix + `([a-zA-Z0-9_-]+)`),
+
+		// As in iptables.Table, pretend that we've just done a write so that we recheck the
+		// dataplane at exponentially increasing intervals at start of day.
+		lastWriteTime:            now(),
+		initialPostWriteInterval: options.PostWriteInterval,
+		postWriteInterval:        options.PostWriteInterval,
+		refreshInterval:          options.RefreshInterval,
+
+		logCxt: log.WithFields(log.Fields{
+			"ipVersion": ipVersion,
+			"table":     name,
+		}),
+
+		newCmd:              newCmd,
+		timeSleep:           sleep,
+		timeNow:             now,
+		onStillAlive:        onStillAlive,
+		onPersistentFailure: options.OnPersistentFailure,
+		opReporter:          options.OpRecorder,
+	}
+}
+
+// TableName returns the name of the table.  It is a method, rather than the Name field, so that
+// the table can be used through interfaces that it shares with the other rules backend.
+func (t *Table) TableName() string {
+	return t.Name
+}
+
+// TableIPVersion returns the IP version of the table; see TableName.
+func (t *Table) TableIPVersion() uint8 {
+	return t.IPVersion
+}
+
+// InsertOrAppendRules sets the rules that go at the start of the base chain that stands in for
+// the given kernel chain.
+func (t *Table) InsertOrAppendRules(chainName string, rules []iptables.Rule) {
+	t.logCxt.WithField("chainName", chainName).Debug("Updating rule insertions")
+	t.updateBaseChainRules(t.chainToInsertedRules, chainName, rules)
+}
+
+// AppendRules sets the rules that go at the end of the base chain that stands in for the given
+// kernel chain.
+func (t *Table) AppendRules(chainName string, rules []iptables.Rule) {
+	t.logCxt.WithField("chainName", chainName).Debug("Updating rule appends")
+	t.updateBaseChainRules(t.chainToAppendedRules, chainName, rules)
+}
+
+func (t *Table) updateBaseChainRules(chainToRules map[string][]iptables.Rule, chainName string, rules []iptables.Rule) {
+	if _, ok := tableToBaseChains[t.Name][chainName]; !ok {
+		t.logCxt.WithField("chainName", chainName).Panic("Rules inserted into a chain that isn't a kernel chain")
+	}
+	oldRules := chainToRules[chainName]
+	chainToRules[chainName] = rules
+	t.dirtyChains.Add(chainName)
+	t.increfReferredChains(rules)
+	t.decrefReferredChains(oldRules)
+	t.InvalidateDataplaneCache("insertion")
+}
+
+func (t *Table) UpdateChains(chains []*iptables.Chain) {
+	for _, chain := range chains {
+		t.UpdateChain(chain)
+	}
+}
+
+func (t *Table) UpdateChain(chain *iptables.Chain) {
+	t.logCxt.WithField("chainName", chain.Name).Info("Queueing update of chain.")
+	t.increfReferredChains(chain.Rules)
+	if oldChain := t.chainNameToChain[chain.Name]; oldChain != nil {
+		t.decrefReferredChains(oldChain.Rules)
+	}
+	t.chainNameToChain[chain.Name] = chain
+	if t.chainRefCounts[chain.Name] > 0 {
+		t.dirtyChains.Add(chain.Name)
+	}
+	t.InvalidateDataplaneCache("chain update")
+}
+
+func (t *Table) RemoveChains(chains []*iptables.Chain) {
+	for _, chain := range chains {
+		t.RemoveChainByName(chain.Name)
+	}
+}
+
+func (t *Table) RemoveChainByName(name string) {
+	t.logCxt.WithField("chainName", name).Info("Queuing deletion of chain.")
+	if oldChain, known := t.chainNameToChain[name]; known {
+		delete(t.chainNameToChain, name)
+		if t.chainRefCounts[name] > 0 {
+			t.dirtyChains.Add(name)
+		}
+		t.decrefReferredChains(oldChain.Rules)
+	}
+	t.InvalidateDataplaneCache("chain removal")
+}
+
+func (t *Table) increfReferredChains(rules []iptables.Rule) {
+	for _, r := range rules {
+		if ref, ok := r.Action.(iptables.Referrer); ok {
+			chainName := ref.ReferencedChain()
+			t.chainRefCounts[chainName] += 1
+			if t.chainRefCounts[chainName] == 1 {
+				t.dirtyChains.Add(chainName)
+			}
+		}
+	}
+}
+
+func (t *Table) decrefReferredChains(rules []iptables.Rule) {
+	for _, r := range rules {
+		if ref, ok := r.Action.(iptables.Referrer); ok {
+			chainName := ref.ReferencedChain()
+			t.chainRefCounts[chainName] -= 1
+			if t.chainRefCounts[chainName] == 0 {
+				delete(t.chainRefCounts, chainName)
+				t.dirtyChains.Add(chainName)
+			}
+		}
+	}
+}
+
+// desiredStateOfChain returns the rules, and their hashes, that should be in the given chain.
+// present is false if the chain shouldn't be programmed.
+func (t *Table) desiredStateOfChain(
+	chainName string,
+	features *environment.Features,
+) (rules []iptables.Rule, hashes []string, present bool) {
+	if _, ok := tableToBaseChains[t.Name][chainName]; ok {
+		// Use the same hashes as iptables.Table, which calculates the hashes of the inserts
+		// and appends separately.
+		inserts := t.chainToInsertedRules[chainName]
+		appends := t.chainToAppendedRules[chainName]
+		rules = append(append(rules, inserts...), appends...)
+		hashes = append(hashes, iptables.CalculateRuleHashes(chainName, inserts, features)...)
+		hashes = append(hashes, iptables.CalculateRuleHashes(chainName+"*appends*", appends, features)...)
+		return rules, hashes, true
+	}
+	if t.chainRefCounts[chainName] == 0 {
+		return nil, nil, false
+	}
+	chain, ok := t.chainNameToChain[chainName]
+	if !ok {
+		return nil, nil, false
+	}
+	return chain.Rules, chain.RuleHashes(features), true
+}
+
+func (t *Table) InvalidateDataplaneCache(reason string) {
+	logCxt := t.logCxt.WithField("reason", reason)
+	if !t.inSyncWithDataPlane {
+		logCxt.Debug("Would invalidate dataplane cache but it was already invalid.")
+		return
+	}
+	logCxt.Debug("Invalidating dataplane cache")
+	t.inSyncWithDataPlane = false
+}
+
+// Apply brings the dataplane into sync with the desired state, re-reading the dataplane first
+// if our picture of it may be out of date.  It returns the time after which it should be called
+// again to check for drift, or 0 if no recheck is needed.
+func (t *Table) Apply() (rescheduleAfter time.Duration) {
+	now := t.timeNow()
+	if t.refreshInterval > 0 && now.Sub(t.lastReadTime) > t.refreshInterval {
+		t.InvalidateDataplaneCache("refresh timer")
+	}
+	// Recheck the dataplane after a write at exponentially increasing intervals, in case
+	// another process clobbers our updates.
+	for t.postWriteInterval != 0 &&
+		t.postWriteInterval < time.Hour &&
+		!now.Before(t.lastWriteTime.Add(t.postWriteInterval)) {
+		t.postWriteInterval *= 2
+		t.InvalidateDataplaneCache("post update")
+	}
+
+	retries := 10
+	backoffTime := 1 * time.Millisecond
+	for {
+		if !t.inSyncWithDataPlane {
+			t.loadDataplaneState()
+		}
+		t.onStillAlive()
+		err := t.applyUpdates()
+		if err == nil {
+			break
+		}
+		if retries <= 0 {
+			if t.onPersistentFailure == nil {
+				t.logCxt.WithError(err).Panic("Failed to program nftables, giving up after retries")
+			}
+			t.logCxt.WithError(err).Error("Failed to program nftables after retries, will try again later.")
+			t.InvalidateDataplaneCache("persistent failure")
+			t.onPersistentFailure(fmt.Errorf("failed to program IPv%d %s table: %w", t.IPVersion, t.Name, err))
+			return iptables.PersistentFailureRetryInterval
+		}
+		retries--
+		t.logCxt.WithError(err).Warn("Failed to program nftables, will retry")
+		t.timeSleep(backoffTime)
+		backoffTime *= 2
+		t.InvalidateDataplaneCache("failed update")
+	}
+
+	if t.refreshInterval > 0 {
+		rescheduleAfter = t.refreshInterval - now.Sub(t.lastReadTime)
+	}
+	if t.postWriteInterval < time.Hour {
+		postWriteReched := t.lastWriteTime.Add(t.postWriteInterval).Sub(now)
+		if postWriteReched <= 0 {
+			rescheduleAfter = 1 * time.Millisecond
+		} else if t.refreshInterval <= 0 || postWriteReched < rescheduleAfter {
+			rescheduleAfter = postWriteReched
+		}
+	}
+	return
+}
+
+// loadDataplaneState reads back the hashes of the rules in our chains and marks any chains that
+// don't match what we expect as dirty.
+func (t *Table) loadDataplaneState() {
+	t.featureDetector.RefreshFeatures()
+	t.logCxt.Debug("Loading current nftables state and checking it is correct.")
+	t.opReporter.RecordOperation(fmt.Sprintf("resync-nft-%v-v%d", t.Name, t.IPVersion))
+
+	t.lastReadTime = t.timeNow()
+	dataplaneHashes, dataplaneIPSetRefs := t.getHashesFromDataplane()
+
+	for chainName, expectedHashes := range t.chainToDataplaneHashes {
+		if t.dirtyChains.Contains(chainName) {
+			continue
+		}
+		if !hashesEqual(dataplaneHashes[chainName], expectedHashes) {
+			t.logCxt.WithField("chainName", chainName).Warn("Detected out-of-sync chain, marking for resync")
+			t.dirtyChains.Add(chainName)
+		}
+	}
+	for chainName := range dataplaneHashes {
+		if _, ok := t.chainToDataplaneHashes[chainName]; ok || t.dirtyChains.Contains(chainName) {
+			continue
+		}
+		t.logCxt.WithField("chainName", chainName).Info("Found unexpected chain, marking for cleanup")
+		t.dirtyChains.Add(chainName)
+	}
+
+	t.chainToDataplaneHashes = dataplaneHashes
+	t.chainToIPSetRefs = dataplaneIPSetRefs
+	t.inSyncWithDataPlane = true
+}
+
+func (t *Table) listCmd() []string {
+	// List the whole ruleset for our family, rather than our table, so that the command
+	// succeeds before we've created the table.  Terse mode omits the contents of sets.
+	return []string{"nft", "--terse", "list", "ruleset", t.family}
+}
+
+// getHashesFromDataplane lists the dataplane, retrying a few times before panicking.
+func (t *Table) getHashesFromDataplane() (hashes map[string][]string, ipSetRefs map[string]set.Set[string]) {
+	retries := 3
+	retryDelay := 100 * time.Millisecond
+	for {
+		t.onStillAlive()
+		hashes, ipSetRefs, err := t.attemptToGetHashesFromDataplane()
+		if err == nil {
+			return hashes, ipSetRefs
+		}
+		countNumListErrors.Inc()
+		t.logCxt.WithError(err).Warn("nft list command failed")
+		if retries <= 0 {
+			t.logCxt.Panic("nft list command failed after retries")
+		}
+		retries--
+		t.timeSleep(retryDelay)
+		retryDelay *= 2
+	}
+}
+
+func (t *Table) attemptToGetHashesFromDataplane() (hashes map[string][]string, ipSetRefs map[string]set.Set[string], err error) {
+	listCmd := t.listCmd()
+	cmd := t.newCmd(listCmd[0], listCmd[1:]...)
+	countNumListCalls.Inc()
+	var stderr bytes.Buffer
+	cmd.SetStderr(&stderr)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	hashes, ipSetRefs, err = t.readHashesFrom(bytes.NewReader(output))
+	return
+}
+
+// readHashesFrom scans the output of 'nft list ruleset', which has the following form:
+//
+//	table ip calico {
+//		set cali40s:qMt7iLlGDhvLnCjM0l9nzxb {
+//			type ipv4_addr
+//			flags interval
+//		}
+//
+//		chain filter-FORWARD {
+//			type filter hook forward priority filter; policy accept;
+//			counter packets 0 bytes 0 jump filter-cali-FORWARD comment "cali:wUHhoiAYhphO9Mso"
+//		}
+//
+//		chain filter-cali-FORWARD {
+//			ip saddr @cali40s:qMt7iLlGDhvLnCjM0l9nzxb counter packets 0 bytes 0 drop comment "cali:..."
+//		}
+//	}
+//
+// It returns the hashes of the rules in each of our chains, indexed by chain name (without our
+// prefix), with empty strings for rules that don't have hashes, along with the IP sets that each
+// chain references.
+func (t *Table) readHashesFrom(r io.Reader) (hashes map[string][]string, ipSetRefs map[string]set.Set[string], err error) {
+	hashes = map[string][]string{}
+	ipSetRefs = map[string]set.Set[string]{}
+	tableHeader := fmt.Sprintf("table %s %s {", t.family, t.nftTable)
+	scanner := bufio.NewScanner(r)
+	depth := 0
+	inTable := false
+	chainName := ""
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case depth == 0:
+			inTable = line == tableHeader
+		case depth == 1 && inTable && strings.HasPrefix(line, "chain "):
+			nftChain := strings.Trim(strings.TrimSuffix(strings.TrimPrefix(line, "chain "), " {"), `"`)
+			chainName = ""
+			if strings.HasPrefix(nftChain, t.renderer.chainPrefix) {
+				chainName = strings.TrimPrefix(nftChain, t.renderer.chainPrefix)
+				hashes[chainName] = []string{}
+			}
+		case depth == 2 && chainName != "" && line != "" && line != "}" && !isChainSpec(line):
+			hash := ""
+			if captures := t.hashCommentRegexp.FindStringSubmatch(line); captures != nil {
+				hash = captures[1]
+			}
+			hashes[chainName] = append(hashes[chainName], hash)
+			for _, captures := range setRefRegexp.FindAllStringSubmatch(line, -1) {
+				if ipSetRefs[chainName] == nil {
+					ipSetRefs[chainName] = set.New[string]()
+				}
+				ipSetRefs[chainName].Add(captures[1])
+			}
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth <= 1 {
+			chainName = ""
+		}
+	}
+	if scanner.Err() != nil {
+		return nil, nil, scanner.Err()
+	}
+	t.logCxt.Debugf("Read hashes from dataplane: %#v", hashes)
+	return hashes, ipSetRefs, nil
+}
+
+// isChainSpec returns true for the lines of a chain listing that describe the chain rather than
+// its rules.
+func isChainSpec(line string) bool {
+	return strings.HasPrefix(line, "type ") || strings.HasPrefix(line, "policy ") ||
+		strings.HasPrefix(line, "comment ")
+}
+
+func (t *Table) applyUpdates() error {
+	features := t.featureDetector.GetFeatures()
+
+	// Work out which chains actually need to change.  Iterate in order so that our output is
+	// deterministic.
+	var updates, deletions []string
+	newHashes := map[string][]string{}
+	newRules := map[string][]iptables.Rule{}
+	for _, chainName := range sortedChainNames(t.dirtyChains) {
+		rules, hashes, present := t.desiredStateOfChain(chainName, features)
+		dpHashes, inDataplane := t.chainToDataplaneHashes[chainName]
+		if !present {
+			if inDataplane {
+				deletions = append(deletions, chainName)
+			}
+			continue
+		}
+		if inDataplane && hashesEqual(dpHashes, hashes) {
+			continue
+		}
+		updates = append(updates, chainName)
+		newHashes[chainName] = hashes
+		newRules[chainName] = rules
+	}
+
+	if len(updates) == 0 && len(deletions) == 0 {
+		t.logCxt.Debug("Update ended up being no-op, skipping call to nft.")
+		t.dirtyChains = set.New[string]()
+		return nil
+	}
+
+	// Write the whole update as one transaction.  First make sure that all the chains exist
+	// and flush the ones that we're changing; flushing the chains that we're deleting severs
+	// their references to other chains.  Then write the rules, and finally delete the chains
+	// that are no longer needed, which are now unreferenced.
+	var buf bytes.Buffer
+	writeLine := func(format string, a ...interface{}) {
+		_, _ = fmt.Fprintf(&buf, format, a...)
+		buf.WriteByte('\n')
+	}
+	writeLine("add table %s %s", t.family, t.nftTable)
+	for _, chainName := range updates {
+		writeLine("%s", t.addChainLine(chainName))
+		writeLine("flush chain %s %s %s", t.family, t.nftTable, t.renderer.chainName(chainName))
+	}
+	for _, chainName := range deletions {
+		writeLine("flush chain %s %s %s", t.family, t.nftTable, t.renderer.chainName(chainName))
+	}
+	newIPSetRefs := map[string]set.Set[string]{}
+	for _, chainName := range updates {
+		refs := set.New[string]()
+		for i, rule := range newRules[chainName] {
+			ruleStr, err := t.renderer.renderRule(rule, newHashes[chainName][i], features)
+			if err != nil {
+				return fmt.Errorf("failed to render rule %d of chain %s: %w", i, chainName, err)
+			}
+			writeLine("add rule %s %s %s %s", t.family, t.nftTable, t.renderer.chainName(chainName), ruleStr)
+			for _, name := range rule.Match.IPSetNames() {
+				refs.Add(name)
+			}
+		}
+		newIPSetRefs[chainName] = refs
+	}
+	for _, chainName := range deletions {
+		writeLine("delete chain %s %s %s", t.family, t.nftTable, t.renderer.chainName(chainName))
+	}
+
+	t.opReporter.RecordOperation(fmt.Sprintf("update-nft-%v-v%d", t.Name, t.IPVersion))
+	if err := t.execNFT(buf.Bytes()); err != nil {
+		return err
+	}
+	t.lastWriteTime = t.timeNow()
+	t.postWriteInterval = t.initialPostWriteInterval
+
+	t.dirtyChains = set.New[string]()
+	for chainName, hashes := range newHashes {
+		t.chainToDataplaneHashes[chainName] = hashes
+		if newIPSetRefs[chainName].Len() == 0 {
+			delete(t.chainToIPSetRefs, chainName)
+		} else {
+			t.chainToIPSetRefs[chainName] = newIPSetRefs[chainName]
+		}
+	}
+	for _, chainName := range deletions {
+		delete(t.chainToDataplaneHashes, chainName)
+		delete(t.chainToIPSetRefs, chainName)
+	}
+	return nil
+}
+
+// addChainLine returns the command to (idempotently) create the given chain, including the hook
+// for base chains.
+func (t *Table) addChainLine(chainName string) string {
+	line := fmt.Sprintf("add chain %s %s %s", t.family, t.nftTable, t.renderer.chainName(chainName))
+	if bc, ok := tableToBaseChains[t.Name][chainName]; ok {
+		line += fmt.Sprintf(" { type %s hook %s priority %d; policy accept; }", bc.chainType, bc.hook, bc.priority)
+	}
+	return line
+}
+
+func (t *Table) execNFT(input []byte) error {
+	if log.GetLevel() >= log.DebugLevel {
+		t.logCxt.WithField("nftInput", string(input)).Debug("Writing to nftables")
+	}
+	var outputBuf, errBuf bytes.Buffer
+	cmd := t.newCmd("nft", "-f", "-")
+	cmd.SetStdin(bytes.NewReader(input))
+	cmd.SetStdout(&outputBuf)
+	cmd.SetStderr(&errBuf)
+	countNumRestoreCalls.Inc()
+	if err := cmd.Run(); err != nil {
+		t.logCxt.WithFields(log.Fields{
+			"output":      outputBuf.String(),
+			"errorOutput": errBuf.String(),
+			"error":       err,
+			"input":       string(input),
+		}).Warn("Failed to execute nft -f command")
+		t.inSyncWithDataPlane = false
+		countNumRestoreErrors.Inc()
+		return err
+	}
+	return nil
+}
+
+// Explain walks a packet through the calculated state of the given chain; see
+// iptables.Table.Explain().
+func (t *Table) Explain(chainName string, pkt iptables.Packet, opts iptables.ExplainOptions) (*iptables.Explanation, error) {
+	return iptables.ExplainChains(func(name string) (*iptables.Chain, bool) {
+		if t.chainRefCounts[name] == 0 {
+			return nil, false
+		}
+		chain, ok := t.chainNameToChain[name]
+		return chain, ok
+	}, chainName, pkt, opts, t.featureDetector.GetFeatures())
+}
+
+// IPSetIsReferenced returns true if the named IP set is referenced by any of the rules in this
+// table, as we think they are in the dataplane.
+func (t *Table) IPSetIsReferenced(setName string) bool {
+	for _, refs := range t.chainToIPSetRefs {
+		if refs.Contains(setName) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckRulesPresent returns list of rules with the hashes that are already
+// programmed. Return value of nil means that none of the rules are present.
+func (t *Table) CheckRulesPresent(chain string, rules []iptables.Rule) []iptables.Rule {
+	features := t.featureDetector.GetFeatures()
+	hashes := iptables.CalculateRuleHashes(chain, rules, features)
+
+	dpHashes, _ := t.getHashesFromDataplane()
+	dpHashesSet := set.FromArray(dpHashes[chain])
+
+	var present []iptables.Rule
+	for i, r := range rules {
+		if dpHashesSet.Contains(hashes[i]) {
+			present = append(present, r)
+		}
+	}
+	return present
+}
+
+// InsertRulesNow inserts the given rules at the start of the given base chain immediately,
+// without syncing the rest of the table.  This is primarily useful when bootstrapping and we
+// cannot wait until we have the full state.
+func (t *Table) InsertRulesNow(chain string, rules []iptables.Rule) error {
+	if _, ok := tableToBaseChains[t.Name][chain]; !ok {
+		return fmt.Errorf("%s is not a kernel chain of the %s table", chain, t.Name)
+	}
+	features := t.featureDetector.GetFeatures()
+	hashes := iptables.CalculateRuleHashes(chain, rules, features)
+
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "add table %s %s\n%s\n", t.family, t.nftTable, t.addChainLine(chain))
+	// Each insert goes at the start of the chain so do the inserts in reverse order.
+	for i := len(rules) - 1; i >= 0; i-- {
+		ruleStr, err := t.renderer.renderRule(rules[i], hashes[i], features)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(&buf, "insert rule %s %s %s %s\n", t.family, t.nftTable, t.renderer.chainName(chain), ruleStr)
+	}
+	return t.execNFT(buf.Bytes())
+}
+
+// hashesEqual compares two lists of rule hashes, treating nil and empty lists as equal.
+func hashesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedChainNames(chains set.Set[string]) []string {
+	names := make([]string, 0, chains.Len())
+	chains.Iter(func(name string) error {
+		names = append(names, name)
+		return nil
+	})
+	sort.Strings(names)
+	return names
+}
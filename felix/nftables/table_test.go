@@ -0,0 +1,460 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nftables_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/environment"
+	"github.com/projectcalico/calico/felix/iptables"
+	"github.com/projectcalico/calico/felix/iptables/cmdshim"
+	"github.com/projectcalico/calico/felix/logutils"
+	. "github.com/projectcalico/calico/felix/nftables"
+)
+
+var _ = Describe("Table with an empty dataplane", func() {
+	var dataplane *mockNFT
+	var table *Table
+	BeforeEach(func() {
+		dataplane = newMockNFT()
+		table = NewTable(
+			"filter",
+			4,
+			"cali:",
+			&fakeFeatureDetector{},
+			TableOptions{
+				NewCmdOverride: dataplane.newCmd,
+				SleepOverride:  dataplane.sleep,
+				NowOverride:    dataplane.now,
+				OpRecorder:     logutils.NewSummarizer("test loop"),
+			},
+		)
+	})
+
+	It("should create the base chains with the right hooks", func() {
+		table.Apply()
+		Expect(dataplane.chainSpecs).To(Equal(map[string]string{
+			"filter-INPUT":   "type filter hook input priority 0; policy accept;",
+			"filter-FORWARD": "type filter hook forward priority 0; policy accept;",
+			"filter-OUTPUT":  "type filter hook output priority 0; policy accept;",
+		}))
+		Expect(dataplane.ruleBodies()).To(Equal(map[string][]string{
+			"filter-INPUT":   {},
+			"filter-FORWARD": {},
+			"filter-OUTPUT":  {},
+		}))
+	})
+
+	It("should leave chains that aren't ours alone", func() {
+		dataplane.chains["nat-POSTROUTING"] = []string{`counter masquerade comment "cali:abcd"`}
+		dataplane.chains["other"] = []string{`counter accept`}
+		table.Apply()
+		Expect(dataplane.chains).To(HaveKey("nat-POSTROUTING"))
+		Expect(dataplane.chains).To(HaveKey("other"))
+	})
+
+	It("should clean up stale chains from a previous run", func() {
+		dataplane.chains["filter-cali-stale"] = []string{`counter accept comment "cali:abcd"`}
+		dataplane.chains["filter-INPUT"] = []string{`counter jump filter-cali-stale comment "cali:efgh"`}
+		table.Apply()
+		Expect(dataplane.chains).NotTo(HaveKey("filter-cali-stale"))
+		Expect(dataplane.chains["filter-INPUT"]).To(BeEmpty())
+	})
+
+	Describe("after inserting rules and adding chains", func() {
+		BeforeEach(func() {
+			table.InsertOrAppendRules("FORWARD", []iptables.Rule{
+				{Action: iptables.JumpAction{Target: "cali-FORWARD"}},
+			})
+			table.AppendRules("FORWARD", []iptables.Rule{
+				{Match: iptables.Match().MarkSingleBitSet(0x10), Action: iptables.AcceptAction{}},
+			})
+			table.UpdateChains([]*iptables.Chain{
+				{Name: "cali-FORWARD", Rules: []iptables.Rule{
+					{Match: iptables.Match().SourceIPSet("cali40s:abc"), Action: iptables.DropAction{}},
+				}},
+				{Name: "cali-unreferenced", Rules: []iptables.Rule{
+					{Action: iptables.AcceptAction{}},
+				}},
+			})
+			table.Apply()
+		})
+
+		It("should program the base chain and the referenced chain", func() {
+			Expect(dataplane.ruleBodies()).To(Equal(map[string][]string{
+				"filter-INPUT":        {},
+				"filter-FORWARD":      {`counter jump filter-cali-FORWARD`, `meta mark & 0x10 == 0x10 counter accept`},
+				"filter-OUTPUT":       {},
+				"filter-cali-FORWARD": {`ip saddr @"cali40s:abc" counter drop`},
+			}))
+		})
+
+		It("should write the update in one transaction", func() {
+			Expect(dataplane.numRestores).To(Equal(1))
+		})
+
+		It("should report the IP sets that are referenced", func() {
+			Expect(table.IPSetIsReferenced("cali40s:abc")).To(BeTrue())
+			Expect(table.IPSetIsReferenced("cali40s:def")).To(BeFalse())
+		})
+
+		It("should skip the write if nothing changes", func() {
+			table.UpdateChain(&iptables.Chain{Name: "cali-FORWARD", Rules: []iptables.Rule{
+				{Match: iptables.Match().SourceIPSet("cali40s:abc"), Action: iptables.DropAction{}},
+			}})
+			table.Apply()
+			Expect(dataplane.numRestores).To(Equal(1))
+		})
+
+		It("should rewrite a chain that is updated", func() {
+			table.UpdateChain(&iptables.Chain{Name: "cali-FORWARD", Rules: []iptables.Rule{
+				{Match: iptables.Match().SourceIPSet("cali40s:def"), Action: iptables.DropAction{}},
+			}})
+			table.Apply()
+			Expect(dataplane.ruleBodies()["filter-cali-FORWARD"]).To(Equal([]string{
+				`ip saddr @"cali40s:def" counter drop`,
+			}))
+			Expect(table.IPSetIsReferenced("cali40s:abc")).To(BeFalse())
+			Expect(table.IPSetIsReferenced("cali40s:def")).To(BeTrue())
+		})
+
+		It("should delete a chain once it is no longer referenced", func() {
+			table.InsertOrAppendRules("FORWARD", nil)
+			table.Apply()
+			Expect(dataplane.chains).NotTo(HaveKey("filter-cali-FORWARD"))
+			Expect(dataplane.ruleBodies()["filter-FORWARD"]).To(Equal([]string{
+				`meta mark & 0x10 == 0x10 counter accept`,
+			}))
+		})
+
+		It("should program a chain once it becomes referenced", func() {
+			table.UpdateChain(&iptables.Chain{Name: "cali-FORWARD", Rules: []iptables.Rule{
+				{Action: iptables.GotoAction{Target: "cali-unreferenced"}},
+			}})
+			table.Apply()
+			Expect(dataplane.ruleBodies()["filter-cali-unreferenced"]).To(Equal([]string{`counter accept`}))
+		})
+
+		It("should delete a chain that's removed", func() {
+			table.InsertOrAppendRules("FORWARD", nil)
+			table.RemoveChainByName("cali-FORWARD")
+			table.Apply()
+			Expect(dataplane.chains).NotTo(HaveKey("filter-cali-FORWARD"))
+		})
+
+		It("should only check for drift when the cache is invalidated", func() {
+			dataplane.chains["filter-cali-FORWARD"] = []string{`counter accept comment "cali:tampered"`}
+			table.Apply()
+			Expect(dataplane.ruleBodies()["filter-cali-FORWARD"]).To(Equal([]string{`counter accept`}))
+
+			table.InvalidateDataplaneCache("test")
+			table.Apply()
+			Expect(dataplane.ruleBodies()["filter-cali-FORWARD"]).To(Equal([]string{
+				`ip saddr @"cali40s:abc" counter drop`,
+			}))
+		})
+
+		It("should repair drift after the post-write interval", func() {
+			dataplane.chains["filter-FORWARD"] = nil
+			dataplane.advanceTimeBy(100 * time.Millisecond)
+			table.Apply()
+			Expect(dataplane.ruleBodies()["filter-FORWARD"]).To(Equal([]string{
+				`counter jump filter-cali-FORWARD`, `meta mark & 0x10 == 0x10 counter accept`,
+			}))
+		})
+
+		It("should retry a failed update", func() {
+			table.UpdateChain(&iptables.Chain{Name: "cali-FORWARD", Rules: []iptables.Rule{
+				{Action: iptables.ReturnAction{}},
+			}})
+			dataplane.failNextRestore = true
+			table.Apply()
+			Expect(dataplane.ruleBodies()["filter-cali-FORWARD"]).To(Equal([]string{`counter return`}))
+			Expect(dataplane.cumulativeSleep).To(BeNumerically(">", 0))
+		})
+
+		It("should panic if the updates keep failing", func() {
+			table.UpdateChain(&iptables.Chain{Name: "cali-FORWARD", Rules: []iptables.Rule{
+				{Action: iptables.ReturnAction{}},
+			}})
+			dataplane.failAllRestores = true
+			Expect(func() { table.Apply() }).To(Panic())
+		})
+	})
+
+	It("should reject rules that it can't render", func() {
+		table.InsertOrAppendRules("INPUT", []iptables.Rule{
+			{Match: iptables.Match().IPVSConnection(), Action: iptables.AcceptAction{}},
+		})
+		Expect(func() { table.Apply() }).To(Panic())
+	})
+
+	It("should insert rules immediately with InsertRulesNow", func() {
+		rules := []iptables.Rule{
+			{Action: iptables.AcceptAction{}},
+			{Action: iptables.DropAction{}},
+		}
+		Expect(table.CheckRulesPresent("INPUT", rules)).To(BeNil())
+		Expect(table.InsertRulesNow("INPUT", rules)).To(Succeed())
+		Expect(dataplane.ruleBodies()["filter-INPUT"]).To(Equal([]string{`counter accept`, `counter drop`}))
+		Expect(table.CheckRulesPresent("INPUT", rules)).To(Equal(rules))
+	})
+
+	It("should refuse to insert rules into a chain that isn't a base chain", func() {
+		Expect(table.InsertRulesNow("cali-FORWARD", nil)).NotTo(Succeed())
+	})
+
+	It("should report its name and IP version", func() {
+		Expect(table.Name).To(Equal("filter"))
+		Expect(table.IPVersion).To(Equal(uint8(4)))
+	})
+})
+
+type fakeFeatureDetector struct{}
+
+func (*fakeFeatureDetector) GetFeatures() *environment.Features {
+	return &environment.Features{}
+}
+
+func (*fakeFeatureDetector) RefreshFeatures() {}
+
+// mockNFT simulates the parts of the nft command that Table uses, for the "calico" table in
+// the "ip" family.
+type mockNFT struct {
+	// chains maps from nft chain name to the rules in the chain, in nft syntax.
+	chains     map[string][]string
+	chainSpecs map[string]string
+
+	numRestores     int
+	failNextRestore bool
+	failAllRestores bool
+
+	time            time.Time
+	cumulativeSleep time.Duration
+}
+
+func newMockNFT() *mockNFT {
+	return &mockNFT{
+		chains:     map[string][]string{},
+		chainSpecs: map[string]string{},
+		time:       time.Now(),
+	}
+}
+
+var hashCommentRegexp = regexp.MustCompile(` comment "cali:[^"]*"$`)
+
+// ruleBodies returns the rules in each chain with their hash comments removed.
+func (d *mockNFT) ruleBodies() map[string][]string {
+	bodies := map[string][]string{}
+	for chain, rules := range d.chains {
+		bodies[chain] = []string{}
+		for _, r := range rules {
+			bodies[chain] = append(bodies[chain], hashCommentRegexp.ReplaceAllString(r, ""))
+		}
+	}
+	return bodies
+}
+
+func (d *mockNFT) sleep(t time.Duration) {
+	d.cumulativeSleep += t
+	d.time = d.time.Add(t)
+}
+
+func (d *mockNFT) now() time.Time {
+	return d.time
+}
+
+func (d *mockNFT) advanceTimeBy(t time.Duration) {
+	d.time = d.time.Add(t)
+}
+
+func (d *mockNFT) newCmd(name string, arg ...string) cmdshim.CmdIface {
+	Expect(name).To(Equal("nft"))
+	args := strings.Join(arg, " ")
+	switch args {
+	case "-f -":
+		return &mockNFTCmd{run: d.restore}
+	case "--terse list ruleset ip":
+		return &mockNFTCmd{run: d.list}
+	}
+	Fail(fmt.Sprintf("Unexpected nft arguments: %v", args))
+	return nil
+}
+
+var (
+	addChainRegexp = regexp.MustCompile(`^add chain ip calico (\S+)(?: \{ (.*) \})?$`)
+	chainCmdRegexp = regexp.MustCompile(`^(flush|delete) chain ip calico (\S+)$`)
+	ruleCmdRegexp  = regexp.MustCompile(`^(add|insert) rule ip calico (\S+) (.*)$`)
+	chainRefRegexp = regexp.MustCompile(`(?:jump|goto) (\S+)`)
+)
+
+// restore applies the input to a copy of the dataplane and commits it only if every command
+// succeeds, as nft -f does.
+func (d *mockNFT) restore(stdin, stdout io.Writer) error {
+	d.numRestores++
+	if d.failNextRestore || d.failAllRestores {
+		d.failNextRestore = false
+		return errors.New("simulated failure")
+	}
+	chains := map[string][]string{}
+	for name, rules := range d.chains {
+		chains[name] = append([]string(nil), rules...)
+	}
+	specs := map[string]string{}
+	for name, spec := range d.chainSpecs {
+		specs[name] = spec
+	}
+	for _, line := range strings.Split(strings.TrimSpace(stdin.(*bytes.Buffer).String()), "\n") {
+		if line == "add table ip calico" {
+			continue
+		}
+		if m := addChainRegexp.FindStringSubmatch(line); m != nil {
+			if _, ok := chains[m[1]]; !ok {
+				chains[m[1]] = nil
+				if m[2] != "" {
+					specs[m[1]] = m[2]
+				}
+			}
+			continue
+		}
+		if m := chainCmdRegexp.FindStringSubmatch(line); m != nil {
+			if _, ok := chains[m[2]]; !ok {
+				return fmt.Errorf("no such chain %s", m[2])
+			}
+			if m[1] == "flush" {
+				chains[m[2]] = nil
+				continue
+			}
+			if len(chains[m[2]]) > 0 {
+				return fmt.Errorf("chain %s is not empty", m[2])
+			}
+			for _, rules := range chains {
+				for _, r := range rules {
+					if ref := chainRefRegexp.FindStringSubmatch(r); ref != nil && ref[1] == m[2] {
+						return fmt.Errorf("chain %s is still referenced", m[2])
+					}
+				}
+			}
+			delete(chains, m[2])
+			delete(specs, m[2])
+			continue
+		}
+		if m := ruleCmdRegexp.FindStringSubmatch(line); m != nil {
+			if _, ok := chains[m[2]]; !ok {
+				return fmt.Errorf("no such chain %s", m[2])
+			}
+			if ref := chainRefRegexp.FindStringSubmatch(m[3]); ref != nil {
+				if _, ok := chains[ref[1]]; !ok {
+					return fmt.Errorf("no such chain %s", ref[1])
+				}
+			}
+			if m[1] == "add" {
+				chains[m[2]] = append(chains[m[2]], m[3])
+			} else {
+				chains[m[2]] = append([]string{m[3]}, chains[m[2]]...)
+			}
+			continue
+		}
+		return fmt.Errorf("unexpected input %q", line)
+	}
+	d.chains = chains
+	d.chainSpecs = specs
+	return nil
+}
+
+// list writes the dataplane in the same format as 'nft --terse list ruleset ip'.
+func (d *mockNFT) list(_, stdout io.Writer) error {
+	var names []string
+	for name := range d.chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	_, _ = fmt.Fprintln(stdout, "table ip calico {")
+	_, _ = fmt.Fprintln(stdout, "\tset cali40s:abc {\n\t\ttype ipv4_addr\n\t}")
+	for _, name := range names {
+		_, _ = fmt.Fprintf(stdout, "\n\tchain %s {\n", name)
+		if spec, ok := d.chainSpecs[name]; ok {
+			_, _ = fmt.Fprintf(stdout, "\t\t%s\n", spec)
+		}
+		for _, r := range d.chains[name] {
+			_, _ = fmt.Fprintf(stdout, "\t\t%s\n", r)
+		}
+		_, _ = fmt.Fprintln(stdout, "\t}")
+	}
+	_, _ = fmt.Fprintln(stdout, "}")
+	_, _ = fmt.Fprintln(stdout, "table ip filter {\n\tchain INPUT {\n\t\tcounter accept comment \"cali:abcd\"\n\t}\n}")
+	return nil
+}
+
+type mockNFTCmd struct {
+	run    func(stdin, stdout io.Writer) error
+	stdin  bytes.Buffer
+	stdout io.Writer
+}
+
+func (c *mockNFTCmd) SetStdin(r io.Reader) {
+	_, err := io.Copy(&c.stdin, r)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func (c *mockNFTCmd) SetStdout(w io.Writer) {
+	c.stdout = w
+}
+
+func (c *mockNFTCmd) SetStderr(io.Writer) {}
+
+func (c *mockNFTCmd) Run() error {
+	if c.stdout == nil {
+		c.stdout = io.Discard
+	}
+	return c.run(&c.stdin, c.stdout)
+}
+
+func (c *mockNFTCmd) Output() ([]byte, error) {
+	var buf bytes.Buffer
+	c.stdout = &buf
+	err := c.Run()
+	return buf.Bytes(), err
+}
+
+func (c *mockNFTCmd) Start() error {
+	panic("not implemented")
+}
+
+func (c *mockNFTCmd) Kill() error {
+	panic("not implemented")
+}
+
+func (c *mockNFTCmd) Wait() error {
+	panic("not implemented")
+}
+
+func (c *mockNFTCmd) StdoutPipe() (io.ReadCloser, error) {
+	panic("not implemented")
+}
+
+func (c *mockNFTCmd) String() string {
+	return "nft"
+}
@@ -2062,9 +2062,14 @@ type Rule struct {
 	// Types that are valid to be assigned to Icmp:
 	//	*Rule_IcmpType
 	//	*Rule_IcmpTypeCode
-	Icmp        isRule_Icmp `protobuf_oneof:"icmp"`
-	SrcIpSetIds []string    `protobuf:"bytes,10,rep,name=src_ip_set_ids,json=srcIpSetIds" json:"src_ip_set_ids,omitempty"`
-	DstIpSetIds []string    `protobuf:"bytes,11,rep,name=dst_ip_set_ids,json=dstIpSetIds" json:"dst_ip_set_ids,omitempty"`
+	Icmp isRule_Icmp `protobuf_oneof:"icmp"`
+	// Lists of ICMP types and type/code pairs, for matching on more than one ICMP type in a
+	// single rule.  A packet matches if it matches the icmp field above *or* any entry in
+	// either list.
+	IcmpTypes     []int32            `protobuf:"varint,134,rep,packed,name=icmp_types,json=icmpTypes" json:"icmp_types,omitempty"`
+	IcmpTypeCodes []*IcmpTypeAndCode `protobuf:"bytes,135,rep,name=icmp_type_codes,json=icmpTypeCodes" json:"icmp_type_codes,omitempty"`
+	SrcIpSetIds   []string           `protobuf:"bytes,10,rep,name=src_ip_set_ids,json=srcIpSetIds" json:"src_ip_set_ids,omitempty"`
+	DstIpSetIds   []string           `protobuf:"bytes,11,rep,name=dst_ip_set_ids,json=dstIpSetIds" json:"dst_ip_set_ids,omitempty"`
 	// IP sets on which we should match both IP and port.
 	DstIpPortSetIds []string     `protobuf:"bytes,15,rep,name=dst_ip_port_set_ids,json=dstIpPortSetIds" json:"dst_ip_port_set_ids,omitempty"`
 	NotProtocol     *Protocol    `protobuf:"bytes,102,opt,name=not_protocol,json=notProtocol" json:"not_protocol,omitempty"`
@@ -2075,11 +2080,14 @@ type Rule struct {
 	// Types that are valid to be assigned to NotIcmp:
 	//	*Rule_NotIcmpType
 	//	*Rule_NotIcmpTypeCode
-	NotIcmp                 isRule_NotIcmp `protobuf_oneof:"not_icmp"`
-	NotSrcIpSetIds          []string       `protobuf:"bytes,109,rep,name=not_src_ip_set_ids,json=notSrcIpSetIds" json:"not_src_ip_set_ids,omitempty"`
-	NotDstIpSetIds          []string       `protobuf:"bytes,110,rep,name=not_dst_ip_set_ids,json=notDstIpSetIds" json:"not_dst_ip_set_ids,omitempty"`
-	NotSrcNamedPortIpSetIds []string       `protobuf:"bytes,112,rep,name=not_src_named_port_ip_set_ids,json=notSrcNamedPortIpSetIds" json:"not_src_named_port_ip_set_ids,omitempty"`
-	NotDstNamedPortIpSetIds []string       `protobuf:"bytes,113,rep,name=not_dst_named_port_ip_set_ids,json=notDstNamedPortIpSetIds" json:"not_dst_named_port_ip_set_ids,omitempty"`
+	NotIcmp isRule_NotIcmp `protobuf_oneof:"not_icmp"`
+	// A packet matches only if it matches none of the entries in these lists.
+	NotIcmpTypes            []int32            `protobuf:"varint,136,rep,packed,name=not_icmp_types,json=notIcmpTypes" json:"not_icmp_types,omitempty"`
+	NotIcmpTypeCodes        []*IcmpTypeAndCode `protobuf:"bytes,137,rep,name=not_icmp_type_codes,json=notIcmpTypeCodes" json:"not_icmp_type_codes,omitempty"`
+	NotSrcIpSetIds          []string           `protobuf:"bytes,109,rep,name=not_src_ip_set_ids,json=notSrcIpSetIds" json:"not_src_ip_set_ids,omitempty"`
+	NotDstIpSetIds          []string           `protobuf:"bytes,110,rep,name=not_dst_ip_set_ids,json=notDstIpSetIds" json:"not_dst_ip_set_ids,omitempty"`
+	NotSrcNamedPortIpSetIds []string           `protobuf:"bytes,112,rep,name=not_src_named_port_ip_set_ids,json=notSrcNamedPortIpSetIds" json:"not_src_named_port_ip_set_ids,omitempty"`
+	NotDstNamedPortIpSetIds []string           `protobuf:"bytes,113,rep,name=not_dst_named_port_ip_set_ids,json=notDstNamedPortIpSetIds" json:"not_dst_named_port_ip_set_ids,omitempty"`
 	// These fields pass through the original selectors from the v3 datamodel unmodified as required
 	// for the policy sync API.
 	OriginalSrcSelector          string `protobuf:"bytes,114,opt,name=original_src_selector,json=originalSrcSelector,proto3" json:"original_src_selector,omitempty"`
@@ -2228,6 +2236,20 @@ func (m *Rule) GetIcmpTypeCode() *IcmpTypeAndCode {
 	return nil
 }
 
+func (m *Rule) GetIcmpTypes() []int32 {
+	if m != nil {
+		return m.IcmpTypes
+	}
+	return nil
+}
+
+func (m *Rule) GetIcmpTypeCodes() []*IcmpTypeAndCode {
+	if m != nil {
+		return m.IcmpTypeCodes
+	}
+	return nil
+}
+
 func (m *Rule) GetSrcIpSetIds() []string {
 	if m != nil {
 		return m.SrcIpSetIds
@@ -2298,6 +2320,20 @@ func (m *Rule) GetNotIcmpTypeCode() *IcmpTypeAndCode {
 	return nil
 }
 
+func (m *Rule) GetNotIcmpTypes() []int32 {
+	if m != nil {
+		return m.NotIcmpTypes
+	}
+	return nil
+}
+
+func (m *Rule) GetNotIcmpTypeCodes() []*IcmpTypeAndCode {
+	if m != nil {
+		return m.NotIcmpTypeCodes
+	}
+	return nil
+}
+
 func (m *Rule) GetNotSrcIpSetIds() []string {
 	if m != nil {
 		return m.NotSrcIpSetIds
@@ -3250,6 +3286,11 @@ func (m *NatInfo) GetIntIp() string {
 type ProcessStatusUpdate struct {
 	IsoTimestamp string  `protobuf:"bytes,1,opt,name=iso_timestamp,json=isoTimestamp,proto3" json:"iso_timestamp,omitempty"`
 	Uptime       float64 `protobuf:"fixed64,2,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	// dataplane_state reports whether the dataplane has given up on programming iptables or IP
+	// sets and, if so, which DataplaneApplyFailureMode it has applied.  The failed-open and
+	// failed-closed states have an "Unenforced" suffix if their rules couldn't be programmed.
+	// Empty if not reported.
+	DataplaneState string `protobuf:"bytes,3,opt,name=dataplane_state,json=dataplaneState,proto3" json:"dataplane_state,omitempty"`
 }
 
 func (m *ProcessStatusUpdate) Reset()         { *m = ProcessStatusUpdate{} }
@@ -3273,6 +3314,13 @@ func (m *ProcessStatusUpdate) GetUptime() float64 {
 	return 0
 }
 
+func (m *ProcessStatusUpdate) GetDataplaneState() string {
+	if m != nil {
+		return m.DataplaneState
+	}
+	return ""
+}
+
 type HostEndpointStatusUpdate struct {
 	Id     *HostEndpointID `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
 	Status *EndpointStatus `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
@@ -6232,6 +6280,74 @@ func (m *Rule) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintFelixbackend(dAtA, i, uint64(len(m.OriginalSrcServiceNamespace)))
 		i += copy(dAtA[i:], m.OriginalSrcServiceNamespace)
 	}
+	if len(m.IcmpTypes) > 0 {
+		dAtA84 := make([]byte, len(m.IcmpTypes)*10)
+		var j83 int
+		for _, num1 := range m.IcmpTypes {
+			num := uint64(num1)
+			for num >= 1<<7 {
+				dAtA84[j83] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j83++
+			}
+			dAtA84[j83] = uint8(num)
+			j83++
+		}
+		dAtA[i] = 0xb2
+		i++
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(j83))
+		i += copy(dAtA[i:], dAtA84[:j83])
+	}
+	if len(m.IcmpTypeCodes) > 0 {
+		for _, msg := range m.IcmpTypeCodes {
+			dAtA[i] = 0xba
+			i++
+			dAtA[i] = 0x8
+			i++
+			i = encodeVarintFelixbackend(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if len(m.NotIcmpTypes) > 0 {
+		dAtA86 := make([]byte, len(m.NotIcmpTypes)*10)
+		var j85 int
+		for _, num1 := range m.NotIcmpTypes {
+			num := uint64(num1)
+			for num >= 1<<7 {
+				dAtA86[j85] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j85++
+			}
+			dAtA86[j85] = uint8(num)
+			j85++
+		}
+		dAtA[i] = 0xc2
+		i++
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(j85))
+		i += copy(dAtA[i:], dAtA86[:j85])
+	}
+	if len(m.NotIcmpTypeCodes) > 0 {
+		for _, msg := range m.NotIcmpTypeCodes {
+			dAtA[i] = 0xca
+			i++
+			dAtA[i] = 0x8
+			i++
+			i = encodeVarintFelixbackend(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
 	if len(m.RuleId) > 0 {
 		dAtA[i] = 0xca
 		i++
@@ -7114,6 +7230,12 @@ func (m *ProcessStatusUpdate) MarshalTo(dAtA []byte) (int, error) {
 		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Uptime))))
 		i += 8
 	}
+	if len(m.DataplaneState) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(len(m.DataplaneState)))
+		i += copy(dAtA[i:], m.DataplaneState)
+	}
 	return i, nil
 }
 
@@ -9259,6 +9381,32 @@ func (m *Rule) Size() (n int) {
 	if l > 0 {
 		n += 2 + l + sovFelixbackend(uint64(l))
 	}
+	if len(m.IcmpTypes) > 0 {
+		l = 0
+		for _, e := range m.IcmpTypes {
+			l += sovFelixbackend(uint64(e))
+		}
+		n += 2 + sovFelixbackend(uint64(l)) + l
+	}
+	if len(m.IcmpTypeCodes) > 0 {
+		for _, e := range m.IcmpTypeCodes {
+			l = e.Size()
+			n += 2 + l + sovFelixbackend(uint64(l))
+		}
+	}
+	if len(m.NotIcmpTypes) > 0 {
+		l = 0
+		for _, e := range m.NotIcmpTypes {
+			l += sovFelixbackend(uint64(e))
+		}
+		n += 2 + sovFelixbackend(uint64(l)) + l
+	}
+	if len(m.NotIcmpTypeCodes) > 0 {
+		for _, e := range m.NotIcmpTypeCodes {
+			l = e.Size()
+			n += 2 + l + sovFelixbackend(uint64(l))
+		}
+	}
 	l = len(m.RuleId)
 	if l > 0 {
 		n += 2 + l + sovFelixbackend(uint64(l))
@@ -9655,6 +9803,10 @@ func (m *ProcessStatusUpdate) Size() (n int) {
 	if m.Uptime != 0 {
 		n += 9
 	}
+	l = len(m.DataplaneState)
+	if l > 0 {
+		n += 1 + l + sovFelixbackend(uint64(l))
+	}
 	return n
 }
 
@@ -14747,6 +14899,192 @@ func (m *Rule) Unmarshal(dAtA []byte) error {
 			}
 			m.OriginalSrcServiceNamespace = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 134:
+			if wireType == 0 {
+				var v int32
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowFelixbackend
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= (int32(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.IcmpTypes = append(m.IcmpTypes, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowFelixbackend
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= (int(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthFelixbackend
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				for iNdEx < postIndex {
+					var v int32
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowFelixbackend
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= (int32(b) & 0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.IcmpTypes = append(m.IcmpTypes, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field IcmpTypes", wireType)
+			}
+		case 135:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IcmpTypeCodes", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.IcmpTypeCodes = append(m.IcmpTypeCodes, &IcmpTypeAndCode{})
+			if err := m.IcmpTypeCodes[len(m.IcmpTypeCodes)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 136:
+			if wireType == 0 {
+				var v int32
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowFelixbackend
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= (int32(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.NotIcmpTypes = append(m.NotIcmpTypes, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowFelixbackend
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= (int(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthFelixbackend
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				for iNdEx < postIndex {
+					var v int32
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowFelixbackend
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= (int32(b) & 0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.NotIcmpTypes = append(m.NotIcmpTypes, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field NotIcmpTypes", wireType)
+			}
+		case 137:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NotIcmpTypeCodes", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.NotIcmpTypeCodes = append(m.NotIcmpTypeCodes, &IcmpTypeAndCode{})
+			if err := m.NotIcmpTypeCodes[len(m.NotIcmpTypeCodes)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 201:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field RuleId", wireType)
@@ -17248,6 +17586,35 @@ func (m *ProcessStatusUpdate) Unmarshal(dAtA []byte) error {
 			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
 			iNdEx += 8
 			m.Uptime = float64(math.Float64frombits(v))
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DataplaneState", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DataplaneState = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipFelixbackend(dAtA[iNdEx:])
@@ -21827,267 +22194,272 @@ var (
 func init() { proto1.RegisterFile("felixbackend.proto", fileDescriptorFelixbackend) }
 
 var fileDescriptorFelixbackend = []byte{
-	// 4177 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x5b, 0xcb, 0x73, 0x1c, 0x49,
-	0x5a, 0x57, 0xb7, 0xa4, 0x56, 0xf7, 0xd7, 0xea, 0x56, 0x3b, 0xf5, 0x6a, 0xc9, 0xb6, 0xec, 0x29,
-	0x8f, 0x77, 0x34, 0x66, 0xc7, 0x63, 0x3c, 0xb2, 0xbc, 0x1e, 0x96, 0xd9, 0x90, 0x25, 0xcd, 0xa8,
-	0x67, 0x6c, 0x49, 0x94, 0x34, 0x1e, 0x66, 0xd9, 0x88, 0xa2, 0x54, 0x95, 0x92, 0x0a, 0x57, 0x57,
-	0xd5, 0x54, 0x65, 0xeb, 0xb1, 0x9c, 0x80, 0x25, 0x02, 0x82, 0x20, 0xe0, 0x40, 0x10, 0xfc, 0x01,
-	0x9c, 0x08, 0xfe, 0x03, 0x0e, 0x9c, 0x88, 0xd8, 0x0d, 0x2e, 0xf0, 0x07, 0x10, 0x41, 0x0c, 0x37,
-	0x6e, 0x1c, 0xb8, 0x13, 0x99, 0xf9, 0x65, 0xd6, 0xa3, 0xab, 0x65, 0x99, 0x19, 0x38, 0xa9, 0xf3,
-	0x7b, 0xfc, 0xf2, 0x97, 0x5f, 0x7d, 0xf9, 0x4e, 0x01, 0x39, 0xa6, 0xbe, 0x77, 0x71, 0x64, 0x3b,
-	0xaf, 0x69, 0xe0, 0x3e, 0x8c, 0xe2, 0x90, 0x85, 0x64, 0x52, 0xc8, 0x8c, 0x16, 0x34, 0x0f, 0x2e,
-	0x03, 0xc7, 0xa4, 0xdf, 0x0c, 0x68, 0xc2, 0x8c, 0x7f, 0x5e, 0x80, 0xe6, 0x61, 0xb8, 0x65, 0x33,
-	0x3b, 0xf2, 0xed, 0x80, 0x92, 0x55, 0x98, 0xf2, 0x02, 0x2b, 0xb9, 0x0c, 0x9c, 0x6e, 0xe5, 0x6e,
-	0x65, 0xb5, 0xf9, 0xb8, 0xf5, 0x50, 0xf8, 0x3d, 0xec, 0x05, 0xdc, 0x6d, 0x67, 0xcc, 0xac, 0x79,
-	0xe2, 0x17, 0x79, 0x0a, 0xd3, 0x5e, 0x94, 0x50, 0x66, 0x0d, 0x22, 0xd7, 0x66, 0xb4, 0x5b, 0x15,
-	0xe6, 0x44, 0x99, 0xef, 0x1f, 0x50, 0xf6, 0xa5, 0xd0, 0xec, 0x8c, 0x99, 0x4d, 0x61, 0x29, 0x8b,
-	0xe4, 0x33, 0x20, 0xd2, 0xd1, 0xa5, 0x3e, 0xb3, 0x95, 0xfb, 0xb8, 0x70, 0x5f, 0xcc, 0xba, 0x6f,
-	0x71, 0xbd, 0xc6, 0xe8, 0x08, 0xa7, 0x8c, 0x2c, 0x65, 0x10, 0xd3, 0x7e, 0x78, 0x46, 0xbb, 0x13,
-	0xc3, 0x0c, 0x4c, 0xa1, 0xd1, 0x0c, 0x64, 0x91, 0xec, 0xc3, 0xbc, 0xed, 0x30, 0xef, 0x8c, 0x5a,
-	0x51, 0x1c, 0x1e, 0x7b, 0x3e, 0x55, 0x24, 0x26, 0x05, 0xc2, 0x32, 0x22, 0x6c, 0x08, 0x9b, 0x7d,
-	0x69, 0xa2, 0x79, 0xcc, 0xda, 0xc3, 0xe2, 0x12, 0x44, 0xe4, 0x54, 0x1b, 0x8d, 0xa8, 0xb9, 0xe5,
-	0x11, 0x91, 0xe3, 0x4b, 0x98, 0x53, 0x88, 0xa1, 0xef, 0x39, 0x97, 0x8a, 0xe2, 0x94, 0x00, 0x5c,
-	0xca, 0x03, 0x0a, 0x0b, 0xcd, 0x90, 0xd8, 0x43, 0xd2, 0x61, 0x38, 0xe4, 0x57, 0x1f, 0x09, 0xa7,
-	0xe9, 0xe5, 0xe0, 0x52, 0x76, 0xa7, 0x61, 0xc2, 0x2c, 0x1a, 0xb8, 0x51, 0xe8, 0x05, 0x3a, 0x09,
-	0x1a, 0x39, 0xb8, 0x9d, 0x30, 0x61, 0xdb, 0x68, 0x91, 0xb2, 0x3b, 0x1d, 0x92, 0x0e, 0xc3, 0x21,
-	0x3b, 0x18, 0x09, 0x97, 0xb2, 0x3b, 0x1d, 0x92, 0x92, 0xaf, 0xa1, 0x7b, 0x1e, 0xc6, 0xaf, 0xfd,
-	0xd0, 0x76, 0x87, 0x18, 0x36, 0x05, 0xe4, 0x6d, 0x84, 0xfc, 0x0a, 0xcd, 0x86, 0x58, 0x2e, 0x9c,
-	0x97, 0x6a, 0xca, 0xa1, 0x91, 0xed, 0xf4, 0x95, 0xd0, 0x9a, 0xf1, 0x10, 0x34, 0xb2, 0xfe, 0x18,
-	0x5a, 0x4e, 0x18, 0x1c, 0x7b, 0x27, 0x8a, 0x6a, 0x4b, 0xe0, 0xcd, 0x22, 0xde, 0xa6, 0xd0, 0x69,
-	0x82, 0xd3, 0x4e, 0xa6, 0xac, 0x03, 0xd8, 0xa7, 0xcc, 0x76, 0xed, 0xb4, 0x57, 0xb5, 0x87, 0x02,
-	0xf8, 0x12, 0x2d, 0xf2, 0xdf, 0x23, 0x2f, 0x25, 0xef, 0xc1, 0x4c, 0xc2, 0x07, 0x88, 0xc0, 0xa1,
-	0x56, 0x30, 0xe8, 0x1f, 0xd1, 0xb8, 0x3b, 0x73, 0xb7, 0xb2, 0x3a, 0x61, 0xb6, 0x95, 0x78, 0x57,
-	0x48, 0xc9, 0x06, 0x74, 0xbc, 0xc8, 0xee, 0x5b, 0x51, 0x18, 0xfa, 0xaa, 0xce, 0x8e, 0xa8, 0x73,
-	0x5e, 0x77, 0xc3, 0x8d, 0x97, 0xfb, 0x61, 0xe8, 0xeb, 0xfa, 0xda, 0xdc, 0x21, 0x95, 0xe4, 0x21,
-	0x30, 0x92, 0x37, 0x4a, 0x21, 0x74, 0x04, 0x35, 0x44, 0x21, 0x1b, 0x75, 0xeb, 0x11, 0x86, 0x8c,
-	0x6c, 0x7d, 0x3e, 0x7d, 0xf2, 0x52, 0x72, 0x00, 0x0b, 0x09, 0x8d, 0xcf, 0x3c, 0x87, 0x5a, 0xb6,
-	0xe3, 0x84, 0x83, 0x34, 0x79, 0x66, 0x05, 0xe0, 0x4d, 0x04, 0x3c, 0x90, 0x46, 0x1b, 0xd2, 0x46,
-	0x37, 0x70, 0x2e, 0x29, 0x91, 0x97, 0x81, 0x22, 0xcb, 0xb9, 0x2b, 0x40, 0x35, 0xcf, 0x02, 0x28,
-	0x32, 0xdd, 0x84, 0x4e, 0x60, 0xf7, 0x69, 0x12, 0xd9, 0x8e, 0x1e, 0xc3, 0xe6, 0x05, 0xdc, 0x02,
-	0xc2, 0xed, 0x2a, 0xb5, 0xa6, 0x37, 0x13, 0xe4, 0x45, 0x79, 0x10, 0xe4, 0xb4, 0x50, 0x0e, 0xa2,
-	0xe9, 0xa4, 0x20, 0xc8, 0xe4, 0x29, 0x4c, 0xc7, 0xe1, 0x80, 0x69, 0x16, 0x8b, 0xb9, 0xb1, 0xd8,
-	0xe4, 0xaa, 0x74, 0x36, 0x88, 0xd3, 0x62, 0xea, 0x88, 0x35, 0x77, 0x87, 0x1d, 0xd3, 0x41, 0x3c,
-	0x4e, 0x8b, 0x64, 0x13, 0x9a, 0x67, 0x8c, 0x46, 0xaa, 0xc2, 0x25, 0xe1, 0x77, 0x17, 0xfd, 0x5e,
-	0xfd, 0xf6, 0x8b, 0x8d, 0xdd, 0xc3, 0x41, 0x10, 0x50, 0x7f, 0xa8, 0x6b, 0x03, 0x77, 0xd3, 0x6d,
-	0x97, 0x20, 0x58, 0xf9, 0xf2, 0x9b, 0x40, 0x34, 0x15, 0x01, 0x82, 0x4c, 0x7e, 0x06, 0x4b, 0xe7,
-	0x5e, 0x4c, 0x4f, 0x06, 0x76, 0x3c, 0x3c, 0xde, 0xdc, 0x14, 0x90, 0x2b, 0x6a, 0x50, 0x50, 0x76,
-	0x43, 0xac, 0x16, 0xcf, 0xcb, 0x55, 0x23, 0xd0, 0x91, 0xf0, 0xad, 0xab, 0xd1, 0x35, 0xdd, 0x61,
-	0x74, 0xe4, 0xfe, 0x15, 0x74, 0x4f, 0xfc, 0xf0, 0xc8, 0xf6, 0xad, 0xa3, 0x93, 0xc8, 0xca, 0x8f,
-	0x3f, 0xb7, 0x05, 0xf8, 0x2d, 0x04, 0xff, 0x4c, 0x98, 0x3d, 0xff, 0x6c, 0xbf, 0x30, 0x10, 0xcd,
-	0x4b, 0xff, 0xe7, 0x27, 0x51, 0x56, 0x41, 0x7e, 0x0c, 0x2d, 0x1a, 0x38, 0x76, 0x94, 0x0c, 0x7c,
-	0x9b, 0x79, 0x61, 0xd0, 0x5d, 0x11, 0x68, 0x73, 0x88, 0xb6, 0x9d, 0xd5, 0xed, 0x8c, 0x99, 0x79,
-	0x63, 0xf2, 0x9b, 0xd0, 0x56, 0xbd, 0x05, 0xc9, 0xdc, 0xc9, 0xb9, 0x63, 0x2f, 0xd1, 0x24, 0x5a,
-	0x49, 0x56, 0x90, 0x75, 0xc7, 0x40, 0xdd, 0x2d, 0x73, 0xd7, 0xe1, 0x51, 0xee, 0x18, 0x14, 0x07,
-	0x6e, 0x95, 0x84, 0xfc, 0x6c, 0x5d, 0x71, 0x79, 0x27, 0x97, 0x26, 0x43, 0x51, 0x7f, 0xb5, 0xae,
-	0x79, 0x2d, 0x9d, 0x8f, 0x52, 0x8e, 0xae, 0x04, 0x19, 0x1b, 0x6f, 0xaa, 0x44, 0xb3, 0x2f, 0xab,
-	0x04, 0x5b, 0x72, 0x08, 0x8b, 0xf9, 0x91, 0x31, 0x6d, 0xc4, 0xbd, 0xdc, 0xb0, 0x93, 0x1d, 0x1c,
-	0x33, 0xfc, 0xe7, 0x4e, 0x4b, 0xe4, 0xa5, 0xa8, 0xc8, 0xfa, 0xdd, 0x2b, 0x50, 0xd3, 0xc1, 0xec,
-	0xb4, 0x44, 0x4e, 0x7e, 0x0a, 0x4b, 0x05, 0xd4, 0xb5, 0x94, 0xed, 0xfd, 0xdc, 0xdc, 0x9a, 0xc3,
-	0x5d, 0xcb, 0xf0, 0x5d, 0xc8, 0x21, 0xaf, 0x9d, 0x29, 0xc6, 0xe5, 0xd8, 0xc8, 0xf9, 0x07, 0x57,
-	0x62, 0xa7, 0xf3, 0x76, 0x11, 0x5b, 0x6a, 0x9e, 0x37, 0x60, 0x2a, 0xb2, 0x2f, 0xf9, 0x84, 0x6e,
-	0xfc, 0xf9, 0x24, 0xb4, 0x3e, 0x8d, 0xc3, 0x7e, 0xba, 0x9e, 0xde, 0x87, 0xf9, 0x28, 0x0e, 0x1d,
-	0x9a, 0x24, 0x56, 0xc2, 0x6c, 0x36, 0x48, 0xf2, 0xeb, 0x5d, 0xb5, 0x30, 0xdc, 0x97, 0x36, 0x07,
-	0xc2, 0x24, 0x5d, 0x6a, 0x46, 0xc3, 0x62, 0xf2, 0xbb, 0x70, 0x33, 0xbf, 0x56, 0xca, 0xe3, 0xca,
-	0x45, 0xf0, 0x9d, 0x92, 0x25, 0x53, 0x01, 0xbc, 0x7b, 0x3a, 0x42, 0x37, 0xb2, 0x06, 0x0c, 0xd7,
-	0xe4, 0x1b, 0x6a, 0xd0, 0x01, 0x2b, 0xa9, 0x01, 0x3f, 0xb5, 0x0f, 0x77, 0x86, 0x57, 0x51, 0xf9,
-	0x76, 0xc8, 0x85, 0xf3, 0xbd, 0x11, 0x8b, 0xa9, 0x42, 0x5b, 0x6e, 0x9d, 0x5f, 0xa1, 0xbf, 0xb2,
-	0x36, 0x6c, 0xd3, 0xd4, 0x35, 0x6a, 0xd3, 0xed, 0x1a, 0x51, 0x1b, 0xb6, 0xad, 0x64, 0xed, 0x54,
-	0x2f, 0x5d, 0x3b, 0xbd, 0x82, 0x74, 0x54, 0x2e, 0x34, 0xbe, 0x91, 0x1b, 0x79, 0x75, 0xdf, 0x2f,
-	0xb4, 0x7a, 0xfe, 0xbc, 0x4c, 0x91, 0xcd, 0xc7, 0x7f, 0xad, 0xc2, 0x74, 0x6e, 0x54, 0x7e, 0x0a,
-	0x35, 0x39, 0xc6, 0x77, 0x2b, 0x77, 0xc7, 0x33, 0x5f, 0x31, 0x6b, 0x84, 0x85, 0xed, 0x80, 0xc5,
-	0x97, 0x26, 0x9a, 0x93, 0xdf, 0x81, 0xb9, 0x24, 0x1c, 0xc4, 0x0e, 0xb5, 0x58, 0x68, 0xc5, 0xf6,
-	0x39, 0x4e, 0x15, 0xdd, 0xaa, 0x80, 0x79, 0x50, 0x06, 0x73, 0x20, 0xec, 0x0f, 0x43, 0xd3, 0x3e,
-	0xcf, 0x22, 0xde, 0x48, 0x8a, 0x72, 0xd2, 0x85, 0xa9, 0x3e, 0x4d, 0x12, 0xfb, 0x44, 0x76, 0x8b,
-	0x86, 0xa9, 0x8a, 0xcb, 0xcf, 0xa0, 0x99, 0xf1, 0x25, 0x1d, 0x18, 0x7f, 0x4d, 0x2f, 0xc5, 0xce,
-	0xb4, 0x61, 0xf2, 0x9f, 0x64, 0x0e, 0x26, 0xcf, 0x6c, 0x7f, 0x20, 0xb7, 0x9f, 0x0d, 0x53, 0x16,
-	0x3e, 0xae, 0xfe, 0xa8, 0xb2, 0xfc, 0x0a, 0x16, 0xca, 0x19, 0x64, 0x51, 0x5a, 0x12, 0xe5, 0x07,
-	0x59, 0x94, 0xe6, 0xe3, 0x8e, 0x5a, 0x7d, 0x28, 0xbf, 0x0c, 0xae, 0xf1, 0x57, 0x15, 0x68, 0xa4,
-	0xd4, 0x17, 0xa0, 0x26, 0xdb, 0x83, 0xa4, 0xb0, 0x44, 0xd6, 0x74, 0xa0, 0x65, 0x84, 0x6e, 0x15,
-	0x21, 0xcb, 0xa2, 0xfc, 0x1d, 0x9a, 0x6b, 0xd4, 0xa1, 0x26, 0xb7, 0xe8, 0xc6, 0xdf, 0x54, 0xa0,
-	0x99, 0xd9, 0x7e, 0x93, 0x36, 0x54, 0x3d, 0x17, 0x41, 0xaa, 0x9e, 0x2b, 0xa3, 0xcd, 0x33, 0x30,
-	0x11, 0xdc, 0x44, 0xb4, 0x45, 0x91, 0x3c, 0x82, 0x09, 0x76, 0x19, 0xc9, 0x8f, 0xd0, 0xd6, 0x94,
-	0x33, 0x58, 0xf2, 0xf7, 0xe1, 0x65, 0x44, 0x4d, 0x61, 0x69, 0x7c, 0x00, 0x0d, 0x2d, 0x22, 0x35,
-	0xa8, 0xf6, 0xf6, 0x3b, 0x63, 0x64, 0x86, 0xd7, 0x6f, 0x6d, 0xec, 0x6e, 0x59, 0xfb, 0x7b, 0xe6,
-	0x61, 0xa7, 0x42, 0xa6, 0x60, 0x7c, 0x77, 0xfb, 0xb0, 0x53, 0x35, 0x22, 0xe8, 0x14, 0x77, 0xf6,
-	0x43, 0xf4, 0xee, 0x41, 0xcb, 0x76, 0x5d, 0xea, 0x5a, 0x79, 0x92, 0xd3, 0x42, 0xf8, 0x12, 0x99,
-	0xbe, 0x07, 0x33, 0xb2, 0xe7, 0xa6, 0x66, 0xe3, 0xc2, 0xac, 0x8d, 0x62, 0x34, 0x34, 0x6e, 0x63,
-	0x2c, 0xb0, 0x73, 0x16, 0x2a, 0x33, 0x6c, 0x98, 0x2d, 0xd9, 0xe5, 0x93, 0xbb, 0xda, 0x2c, 0x4d,
-	0x06, 0xb4, 0xe8, 0x6d, 0x09, 0x96, 0xab, 0x30, 0x85, 0x3b, 0x7d, 0xcc, 0x99, 0x76, 0xde, 0xcc,
-	0x54, 0x6a, 0xe3, 0x69, 0xa1, 0x0a, 0x64, 0xf2, 0xc6, 0x2a, 0x8c, 0x3b, 0xd0, 0xd0, 0x02, 0x42,
-	0x60, 0x82, 0x2f, 0xb9, 0x91, 0xba, 0xf8, 0x6d, 0x84, 0x30, 0x85, 0x06, 0xe4, 0x11, 0xb4, 0xbc,
-	0xe0, 0x28, 0x1c, 0x04, 0xae, 0x15, 0x0f, 0x7c, 0x9a, 0x60, 0xf7, 0x6e, 0xaa, 0xac, 0x1b, 0xf8,
-	0xd4, 0x9c, 0x46, 0x0b, 0x5e, 0x48, 0xc8, 0x63, 0x68, 0x87, 0x03, 0x96, 0x75, 0xa9, 0x0e, 0xbb,
-	0xb4, 0x94, 0x89, 0xf0, 0x31, 0x7e, 0x06, 0x64, 0xf8, 0xc0, 0x81, 0xdc, 0xc9, 0xb4, 0x64, 0x46,
-	0xb5, 0x44, 0x18, 0x60, 0xac, 0xee, 0x43, 0x4d, 0x1e, 0x3a, 0x60, 0xa8, 0x5a, 0x39, 0x23, 0x13,
-	0x95, 0xc6, 0x93, 0x3c, 0x3a, 0xc6, 0xe9, 0x4d, 0xe8, 0xc6, 0x63, 0xa8, 0xab, 0x32, 0x8f, 0x12,
-	0xf3, 0x68, 0xac, 0xa2, 0xc4, 0x7f, 0xeb, 0xc8, 0x55, 0x33, 0x91, 0xfb, 0xa7, 0x0a, 0xd4, 0xa4,
-	0xd3, 0xff, 0x4f, 0xe4, 0xc8, 0x2d, 0x68, 0x0c, 0x02, 0x16, 0xdb, 0xce, 0x6b, 0xea, 0x8a, 0xee,
-	0x55, 0x37, 0x53, 0x01, 0x59, 0x82, 0x7a, 0x14, 0x53, 0xcb, 0x0d, 0x6c, 0x26, 0xe6, 0xef, 0x3a,
-	0xcf, 0x1e, 0xba, 0x15, 0xd8, 0x8c, 0x3b, 0xea, 0xad, 0x96, 0x98, 0x79, 0x1b, 0x66, 0x2a, 0x30,
-	0xfe, 0xae, 0x03, 0x13, 0xbc, 0x02, 0x3e, 0x0c, 0xd9, 0x8e, 0x58, 0x66, 0xe3, 0x30, 0x24, 0x4b,
-	0xe4, 0x43, 0x00, 0x2f, 0xb2, 0xce, 0x68, 0x9c, 0x70, 0x5d, 0x55, 0xf4, 0xeb, 0x8e, 0xee, 0xd7,
-	0xaf, 0xa4, 0xdc, 0x6c, 0x78, 0x11, 0xfe, 0x24, 0xbf, 0xc6, 0xa9, 0x84, 0x2c, 0x74, 0x42, 0x1f,
-	0x97, 0x28, 0x33, 0x69, 0x72, 0x0a, 0xb1, 0xa9, 0x0d, 0xc8, 0x22, 0x4c, 0x25, 0xb1, 0x63, 0x05,
-	0x94, 0xd3, 0x1e, 0x17, 0xa3, 0x5f, 0xec, 0xec, 0x52, 0x46, 0x3e, 0x80, 0x06, 0x57, 0x44, 0x61,
-	0xcc, 0x92, 0xee, 0xa4, 0x88, 0x8e, 0xce, 0xf1, 0x30, 0x66, 0xa6, 0x1d, 0x9c, 0x50, 0xb3, 0x9e,
-	0xc4, 0x0e, 0x2f, 0x25, 0x1c, 0xc7, 0x4d, 0x98, 0xc0, 0xa9, 0x49, 0x1c, 0x37, 0x61, 0x88, 0xc3,
-	0x15, 0x12, 0x67, 0x6a, 0x14, 0x8e, 0x9b, 0x30, 0x89, 0x73, 0x1b, 0x1a, 0x9e, 0xd3, 0x8f, 0x2c,
-	0x31, 0x88, 0xf1, 0x49, 0x77, 0x72, 0x67, 0xcc, 0xac, 0x73, 0x91, 0x18, 0x9f, 0x3e, 0x81, 0xb6,
-	0x56, 0x5b, 0x4e, 0xe8, 0xaa, 0x79, 0x56, 0x6d, 0x73, 0x7b, 0x68, 0xb8, 0x11, 0xb8, 0x9b, 0xa1,
-	0x2b, 0x0e, 0x59, 0x94, 0x2f, 0x2f, 0x93, 0x7b, 0xd0, 0xe6, 0xad, 0xf2, 0x22, 0x2b, 0xa1, 0xcc,
-	0xf2, 0xdc, 0xa4, 0x0b, 0x82, 0x6d, 0x33, 0x89, 0x9d, 0x5e, 0x74, 0x40, 0x59, 0xcf, 0x4d, 0xb8,
-	0x11, 0xa7, 0x9c, 0x31, 0x6a, 0x4a, 0x23, 0x37, 0x61, 0xda, 0xe8, 0x29, 0x2c, 0x89, 0xc0, 0xd9,
-	0x7d, 0xea, 0x8a, 0xd6, 0x65, 0xed, 0xa7, 0x85, 0xfd, 0x1c, 0x0f, 0x25, 0xd7, 0xf3, 0xa6, 0x65,
-	0x1d, 0x45, 0xa4, 0x4a, 0x1d, 0x5b, 0xd2, 0x91, 0xc7, 0x6e, 0xc8, 0xf1, 0x87, 0x30, 0x8b, 0xb4,
-	0x84, 0x97, 0x72, 0x99, 0x11, 0x2e, 0x33, 0x82, 0x1b, 0xb7, 0x47, 0xeb, 0xc7, 0x30, 0x1d, 0x84,
-	0xcc, 0xd2, 0x99, 0x70, 0x5c, 0x9e, 0x09, 0xcd, 0x20, 0x64, 0xaa, 0x40, 0x56, 0x80, 0x17, 0x2d,
-	0x95, 0x10, 0x27, 0x02, 0xb9, 0x11, 0x84, 0xec, 0x40, 0xe6, 0xc4, 0x1a, 0xb4, 0x94, 0x5e, 0x7e,
-	0xcf, 0xd3, 0x11, 0xdf, 0xb3, 0x29, 0x7d, 0xe4, 0x27, 0x45, 0x54, 0x95, 0x1e, 0x9e, 0x46, 0xdd,
-	0x92, 0x19, 0x82, 0xa8, 0x69, 0x96, 0xfc, 0xde, 0x15, 0xa8, 0x5b, 0x2a, 0x51, 0xde, 0x95, 0x5e,
-	0x69, 0xb2, 0xbc, 0x16, 0xc9, 0x52, 0x11, 0x56, 0x2a, 0x0d, 0xc8, 0x36, 0x90, 0x9c, 0x95, 0xcc,
-	0x19, 0xff, 0xca, 0x9c, 0xa9, 0x98, 0x33, 0x19, 0x08, 0x91, 0x36, 0x0f, 0x24, 0x4c, 0x21, 0x75,
-	0xfa, 0x72, 0xba, 0x92, 0x6d, 0xd5, 0x9f, 0x09, 0x6d, 0x0b, 0x19, 0x14, 0x68, 0xdb, 0xad, 0x4c,
-	0x12, 0x7d, 0x02, 0xb7, 0x75, 0xc0, 0x4b, 0xf3, 0x21, 0x12, 0x6e, 0x8b, 0xf8, 0x09, 0x86, 0x52,
-	0x02, 0xfd, 0x47, 0xe7, 0xd3, 0x37, 0xda, 0x7f, 0xab, 0x2c, 0xa5, 0x1e, 0xc3, 0x7c, 0x18, 0x7b,
-	0x27, 0x5e, 0x60, 0xfb, 0x82, 0x44, 0x42, 0x7d, 0xea, 0xb0, 0x30, 0xee, 0xc6, 0x62, 0x08, 0x9a,
-	0x55, 0xca, 0x83, 0xd8, 0x39, 0x40, 0x55, 0xce, 0x87, 0x57, 0xac, 0x7d, 0x92, 0xbc, 0xcf, 0x56,
-	0xc2, 0xb4, 0xcf, 0x36, 0xdc, 0xc9, 0xd5, 0x93, 0x1e, 0x56, 0x69, 0x6f, 0x26, 0xbc, 0x6f, 0x65,
-	0x6a, 0xd4, 0x47, 0x56, 0xa5, 0x30, 0xaa, 0xcd, 0x05, 0x98, 0x41, 0x1e, 0x06, 0x5b, 0x9d, 0x87,
-	0x79, 0x06, 0x4b, 0x1a, 0x46, 0x85, 0x5f, 0x03, 0x9c, 0x09, 0x80, 0x05, 0x65, 0xb0, 0x2b, 0x22,
-	0x3f, 0xd2, 0x35, 0x17, 0x80, 0xf3, 0x21, 0xd7, 0x6c, 0x0c, 0xbe, 0x94, 0x03, 0x46, 0xf1, 0x04,
-	0xb1, 0x6f, 0x33, 0xe7, 0xb4, 0x7b, 0x91, 0xdb, 0x4a, 0xe6, 0x0f, 0x10, 0x5f, 0x72, 0x0b, 0x73,
-	0x21, 0xe1, 0x34, 0x86, 0xe4, 0x1c, 0x56, 0x92, 0x28, 0x83, 0xbd, 0x7c, 0x33, 0xac, 0xcb, 0x29,
-	0x0e, 0xc3, 0x7e, 0x08, 0x70, 0xca, 0x58, 0x84, 0x38, 0x3f, 0xcf, 0xad, 0x71, 0x76, 0x0e, 0x0f,
-	0xf7, 0xa5, 0x77, 0x83, 0xdb, 0x28, 0x87, 0xba, 0xda, 0x99, 0x77, 0x7f, 0x3f, 0x77, 0xea, 0xcd,
-	0x67, 0x37, 0x7d, 0x3c, 0xab, 0x8d, 0xc8, 0xaf, 0xc3, 0x5c, 0x21, 0x8f, 0x04, 0x8b, 0xee, 0x1f,
-	0xca, 0xe9, 0x8f, 0xe4, 0xf2, 0x48, 0xa8, 0xc8, 0x16, 0xac, 0x94, 0xb9, 0xa4, 0x79, 0xd0, 0xfd,
-	0x23, 0xe9, 0x7c, 0x73, 0xd8, 0x59, 0xa7, 0x41, 0xae, 0xe2, 0xcc, 0x17, 0xe9, 0xfe, 0xa2, 0x50,
-	0xf1, 0x81, 0x0e, 0x78, 0xae, 0xe2, 0xec, 0x47, 0x4c, 0x2b, 0xfe, 0xe3, 0x42, 0xc5, 0xa9, 0x73,
-	0x5a, 0x71, 0x17, 0xa6, 0xf8, 0x62, 0xc3, 0xf2, 0xdc, 0xee, 0xaf, 0x70, 0x8e, 0xe7, 0xe5, 0x9e,
-	0xfb, 0xbc, 0x06, 0x13, 0x7c, 0x88, 0x7a, 0x0e, 0x50, 0x57, 0xc3, 0xd5, 0xe7, 0xb5, 0xfa, 0x2f,
-	0x2b, 0x9d, 0x5f, 0x55, 0x4c, 0xf0, 0xc3, 0x13, 0x2b, 0x8a, 0xe9, 0xb1, 0x77, 0x61, 0x7c, 0x06,
-	0xb3, 0x65, 0x1f, 0x6b, 0x19, 0xea, 0x3a, 0x09, 0x25, 0xb0, 0x2e, 0xf3, 0xed, 0x86, 0x60, 0x89,
-	0x6b, 0x70, 0x59, 0x30, 0xfe, 0xb6, 0x02, 0x0d, 0xfd, 0x19, 0xe5, 0x76, 0x82, 0x9d, 0x86, 0xae,
-	0x5c, 0x3a, 0x89, 0xed, 0x84, 0x28, 0x92, 0x47, 0x30, 0x19, 0xd9, 0xec, 0x54, 0xad, 0x8f, 0x96,
-	0x8b, 0x19, 0xf0, 0x70, 0xdf, 0x66, 0xa7, 0x32, 0x17, 0xa4, 0xe1, 0xf2, 0x17, 0xd0, 0xd0, 0x32,
-	0xb2, 0x00, 0x93, 0xf4, 0xc2, 0x76, 0x98, 0x64, 0xb5, 0x33, 0x66, 0xca, 0x22, 0xe9, 0x42, 0x4d,
-	0xb6, 0x48, 0x2e, 0xe9, 0x76, 0xc6, 0x4c, 0x2c, 0x3f, 0x9f, 0x06, 0xe0, 0x38, 0x32, 0xef, 0x8c,
-	0xbf, 0xae, 0xc0, 0x74, 0x36, 0x7d, 0xc8, 0xa7, 0xd0, 0xb4, 0x83, 0x20, 0x64, 0xe2, 0x88, 0x51,
-	0x2d, 0xf4, 0xde, 0x2d, 0x49, 0xb4, 0x87, 0x1b, 0xa9, 0x99, 0xdc, 0xa0, 0x65, 0x1d, 0x97, 0x3f,
-	0x81, 0x4e, 0xd1, 0xe0, 0xad, 0xb6, 0x6a, 0xcf, 0x60, 0xa6, 0x30, 0x6d, 0x88, 0x85, 0x2b, 0x9f,
-	0x87, 0xb8, 0xff, 0xa4, 0xdc, 0x5b, 0x71, 0x99, 0x98, 0x70, 0xaa, 0x52, 0xc6, 0x7f, 0x1b, 0x2f,
-	0xa0, 0xae, 0x27, 0xdc, 0x2e, 0xd4, 0xf0, 0x7c, 0xa1, 0x82, 0x4b, 0x1d, 0x2c, 0x93, 0xb9, 0xec,
-	0x92, 0x77, 0x67, 0x4c, 0x2e, 0x7a, 0x9f, 0x77, 0xa0, 0x2d, 0xf5, 0x56, 0x18, 0x8b, 0xe4, 0x33,
-	0x9e, 0x40, 0x43, 0x4f, 0x90, 0x9c, 0xef, 0xb1, 0x17, 0x27, 0x0c, 0x39, 0xc8, 0x02, 0x27, 0xe1,
-	0xdb, 0x09, 0x53, 0x24, 0xf8, 0x6f, 0xe3, 0x2f, 0x2a, 0x40, 0x8a, 0x47, 0x24, 0xbd, 0x2d, 0xbe,
-	0x27, 0x0b, 0x63, 0xe7, 0x94, 0x26, 0x2c, 0xb6, 0x59, 0x18, 0xf3, 0x4c, 0x95, 0x4d, 0x6f, 0x67,
-	0xc5, 0x3d, 0x97, 0xdc, 0x81, 0xa6, 0x3e, 0x8f, 0xf1, 0x5c, 0xdc, 0xf2, 0x83, 0x12, 0x49, 0x03,
-	0x7d, 0x4e, 0xe3, 0xb9, 0x62, 0x49, 0xdc, 0x30, 0x41, 0x89, 0x7a, 0xee, 0xe7, 0x13, 0xf5, 0x4a,
-	0xa7, 0x6a, 0xd6, 0x4f, 0xc3, 0x84, 0x89, 0x86, 0x5c, 0xc0, 0x42, 0xf9, 0x4d, 0x1e, 0x79, 0x3f,
-	0xb3, 0x7d, 0x58, 0x1a, 0x71, 0xbc, 0x83, 0xdb, 0x94, 0x8f, 0xa0, 0xae, 0xaa, 0xc0, 0x33, 0xae,
-	0xc5, 0x51, 0x57, 0x79, 0xda, 0xd0, 0xf8, 0xef, 0x71, 0xe8, 0x14, 0xd5, 0x3c, 0x94, 0x09, 0xb3,
-	0x99, 0xda, 0xad, 0xc9, 0x42, 0xd9, 0x46, 0x84, 0xa7, 0x4d, 0xdf, 0x76, 0x30, 0x04, 0xfc, 0x27,
-	0x6f, 0xbb, 0xba, 0x42, 0xe6, 0x73, 0xb0, 0x5c, 0x57, 0x03, 0x8a, 0xf8, 0xb4, 0x7b, 0x13, 0x1a,
-	0x5e, 0x74, 0xb6, 0xc6, 0x97, 0x43, 0x72, 0x6d, 0xdd, 0x30, 0xeb, 0x5c, 0xb0, 0x4b, 0x99, 0x52,
-	0xae, 0x4b, 0x65, 0x4d, 0x2b, 0xd7, 0x85, 0xf2, 0x3e, 0x4c, 0xf2, 0x1d, 0x91, 0x5a, 0x49, 0xab,
-	0xe5, 0xdc, 0xa1, 0x47, 0xe3, 0x5e, 0x70, 0x1c, 0x9a, 0x52, 0x4b, 0xde, 0x87, 0xba, 0xac, 0xc0,
-	0x66, 0xdd, 0xba, 0xb0, 0x6c, 0xeb, 0x7b, 0x20, 0x26, 0x0c, 0xa7, 0x44, 0x7d, 0x36, 0x43, 0xd3,
-	0x75, 0x61, 0xda, 0x18, 0x69, 0xba, 0xce, 0x4d, 0x37, 0xe0, 0xb6, 0xed, 0xfb, 0xe1, 0xb9, 0x95,
-	0x44, 0x61, 0x78, 0x4c, 0x5d, 0x0b, 0x8f, 0x93, 0x64, 0xd7, 0xa5, 0x6a, 0x2d, 0xbd, 0x2c, 0x8c,
-	0x0e, 0xa4, 0x8d, 0x3c, 0xbf, 0xd9, 0x47, 0x0b, 0xf2, 0x79, 0xbe, 0xff, 0x36, 0x45, 0x85, 0xab,
-	0x23, 0xbe, 0xd1, 0xff, 0x71, 0x1f, 0xde, 0x1c, 0xce, 0x38, 0xdc, 0xb0, 0x5e, 0x3f, 0xe3, 0x8c,
-	0x0d, 0x68, 0x67, 0x8f, 0x4f, 0x7b, 0x5b, 0xc5, 0xcc, 0xaf, 0xbe, 0x31, 0xf3, 0x7d, 0x20, 0xc3,
-	0xb7, 0xec, 0xe4, 0x7e, 0x86, 0xc3, 0x7c, 0xc9, 0x41, 0x2d, 0x66, 0xfc, 0x87, 0x99, 0x8c, 0x1f,
-	0xcf, 0x4d, 0xbb, 0xb9, 0xab, 0xf6, 0x34, 0xdb, 0xff, 0xab, 0x0a, 0xd3, 0x59, 0x55, 0xd9, 0xb1,
-	0x44, 0x31, 0x83, 0xab, 0x43, 0x19, 0xac, 0xf3, 0x70, 0xfc, 0xca, 0x3c, 0x7c, 0x08, 0xb3, 0xf4,
-	0x22, 0xa2, 0x0e, 0xa3, 0xae, 0x25, 0x12, 0xd2, 0x76, 0xdd, 0x58, 0xf5, 0x88, 0x1b, 0x4a, 0xd5,
-	0x8b, 0xce, 0xd6, 0x36, 0xb8, 0xa2, 0x68, 0xbf, 0x8e, 0xf6, 0x93, 0x43, 0xf6, 0xeb, 0xd2, 0xfe,
-	0x47, 0x30, 0xa3, 0xb7, 0xe0, 0x96, 0x24, 0x54, 0x2b, 0x27, 0xd4, 0xd6, 0x76, 0x87, 0x82, 0xd9,
-	0x13, 0x68, 0xab, 0xfd, 0xba, 0x75, 0x65, 0x8f, 0x9a, 0xc6, 0x6d, 0xbc, 0x74, 0x5b, 0x83, 0xd6,
-	0x71, 0x18, 0x9f, 0xdb, 0xb1, 0xaa, 0xae, 0x3e, 0xc2, 0x0b, 0xad, 0x84, 0x97, 0xf1, 0x1b, 0xf9,
-	0x2f, 0x8c, 0x59, 0x76, 0xbd, 0x2f, 0x6c, 0xc4, 0x50, 0x57, 0xb0, 0xa5, 0xdf, 0xea, 0x7d, 0xe8,
-	0x78, 0xc1, 0x49, 0x4c, 0x93, 0x44, 0xbe, 0x0b, 0xf1, 0xf4, 0x5c, 0x3f, 0x83, 0xf2, 0x7d, 0x14,
-	0xf3, 0xe1, 0x9d, 0x16, 0x2c, 0xf1, 0xc8, 0x8d, 0xe6, 0x0c, 0x8d, 0xa7, 0x30, 0x85, 0xbd, 0x9f,
-	0xcc, 0x43, 0x8d, 0x5e, 0xf0, 0x3d, 0x85, 0x1a, 0x09, 0xe9, 0x05, 0xeb, 0x45, 0x5c, 0x2c, 0x12,
-	0x3c, 0x52, 0xfd, 0x8a, 0x13, 0x8e, 0x0c, 0x13, 0x66, 0x4b, 0xee, 0x41, 0xc8, 0x3d, 0x68, 0x79,
-	0x49, 0x68, 0x31, 0xaf, 0x4f, 0x13, 0x66, 0xf7, 0x15, 0xd6, 0xb4, 0x97, 0x84, 0x87, 0x4a, 0x46,
-	0x16, 0xa0, 0x36, 0x88, 0xb8, 0x89, 0x80, 0xac, 0x98, 0x58, 0x32, 0x22, 0xe8, 0x8e, 0xba, 0x03,
-	0xb9, 0x6e, 0x2f, 0xf9, 0x00, 0x6a, 0xf2, 0x74, 0x1e, 0x8f, 0xaf, 0xe6, 0xf5, 0x15, 0x66, 0xee,
-	0xf4, 0x1f, 0x8d, 0x8c, 0x55, 0x68, 0xe7, 0x35, 0xe2, 0x8c, 0x58, 0x02, 0xa8, 0x33, 0x62, 0x69,
-	0xb9, 0x51, 0xc6, 0xed, 0xed, 0xbe, 0xef, 0x05, 0xdc, 0xba, 0xea, 0x6a, 0xe4, 0x6d, 0xa6, 0xbf,
-	0xb7, 0x6c, 0x66, 0x6f, 0x54, 0xcd, 0x6f, 0x3f, 0x0c, 0x9e, 0xc0, 0x7c, 0xe9, 0x15, 0x07, 0xb9,
-	0x0d, 0x10, 0x0d, 0x8e, 0x7c, 0xcf, 0xb1, 0xd2, 0x71, 0xb9, 0x21, 0x25, 0x5f, 0xd0, 0xcb, 0xb7,
-	0x3e, 0xdc, 0x32, 0xfe, 0xa4, 0x0a, 0x0b, 0xe5, 0x57, 0x87, 0x7c, 0x15, 0xac, 0xc6, 0x54, 0xb5,
-	0x0a, 0x56, 0x65, 0x3d, 0xe3, 0xf2, 0xf1, 0x04, 0x33, 0x56, 0xcc, 0x90, 0x7c, 0x18, 0xd1, 0x33,
-	0xae, 0x50, 0x8e, 0x6b, 0xa5, 0x18, 0x63, 0x38, 0xaa, 0x9d, 0xe0, 0x22, 0x4d, 0xae, 0x62, 0x74,
-	0x99, 0x6c, 0x40, 0xcd, 0xb7, 0x8f, 0xa8, 0xaf, 0x0e, 0xc8, 0xde, 0xbf, 0xf2, 0x6e, 0xf3, 0xe1,
-	0x0b, 0x61, 0x8b, 0xd7, 0x05, 0xd2, 0x71, 0xf9, 0x19, 0x34, 0x33, 0xe2, 0xb7, 0x9a, 0xbf, 0x7e,
-	0x6b, 0x38, 0x12, 0xf8, 0xe1, 0xfe, 0xb7, 0x91, 0x30, 0x5e, 0xca, 0x81, 0xaa, 0xf0, 0x94, 0xe8,
-	0xfb, 0x82, 0xfb, 0xae, 0xec, 0xf6, 0x60, 0xae, 0xec, 0x8e, 0xfb, 0x1a, 0x80, 0xeb, 0x45, 0xc0,
-	0xf5, 0x72, 0xc0, 0x6b, 0x33, 0x1c, 0x01, 0xb8, 0x0d, 0xed, 0xfc, 0x63, 0xa9, 0x92, 0xab, 0x91,
-	0x89, 0x28, 0x0c, 0x7d, 0xec, 0xa0, 0x33, 0xc5, 0xe7, 0x51, 0x42, 0x69, 0xdc, 0x4d, 0x61, 0x46,
-	0x5c, 0x7a, 0xfc, 0x1c, 0xea, 0xca, 0x42, 0x6c, 0x32, 0x3c, 0x57, 0x9f, 0x98, 0xf3, 0xdf, 0x64,
-	0x05, 0xa0, 0x6f, 0x27, 0xdf, 0x0c, 0x68, 0x6c, 0xe3, 0xf6, 0xa3, 0x6e, 0x66, 0x24, 0xb2, 0x15,
-	0x5e, 0x64, 0xf5, 0xf9, 0xee, 0x44, 0xa7, 0xbc, 0x17, 0xbd, 0xe4, 0x3b, 0x99, 0xdb, 0x00, 0x67,
-	0x17, 0xbe, 0x1d, 0x48, 0xad, 0x4c, 0xfa, 0x86, 0x90, 0x70, 0xb5, 0xf1, 0x07, 0x15, 0x68, 0xe5,
-	0xde, 0x7e, 0x90, 0x77, 0x60, 0x5a, 0xa0, 0xd1, 0xc0, 0x3e, 0xf2, 0xa9, 0xe4, 0x59, 0x37, 0x9b,
-	0x5c, 0xb6, 0x2d, 0x45, 0x7c, 0x06, 0x90, 0x98, 0xca, 0x46, 0x72, 0x9a, 0x16, 0x42, 0x65, 0xb4,
-	0x0a, 0x9d, 0x9c, 0x91, 0x75, 0xb6, 0x8e, 0x27, 0xed, 0xed, 0xac, 0xdd, 0xab, 0x75, 0xe3, 0x1f,
-	0x2a, 0x30, 0x57, 0xf6, 0x76, 0x8b, 0xbc, 0x97, 0x19, 0xb3, 0x16, 0x4b, 0xcf, 0x3d, 0x70, 0xac,
-	0xfc, 0x89, 0xee, 0xbb, 0x72, 0x6b, 0xfb, 0xde, 0x15, 0x2f, 0xc2, 0xbe, 0xef, 0x9e, 0xfb, 0x93,
-	0x22, 0x79, 0x7d, 0xef, 0x7c, 0x3d, 0xf2, 0xc6, 0x16, 0x74, 0x8a, 0xf2, 0xfc, 0x35, 0x43, 0xa5,
-	0x70, 0xcd, 0x50, 0x7a, 0x85, 0xf2, 0xf7, 0x15, 0x98, 0x29, 0x3c, 0x2e, 0x23, 0x46, 0x86, 0x02,
-	0x29, 0xbe, 0x1d, 0xc3, 0xd0, 0x7d, 0x5c, 0x08, 0x9d, 0x51, 0xfe, 0x50, 0xed, 0xfb, 0x8e, 0xda,
-	0x93, 0x0c, 0x5b, 0x0c, 0xd8, 0x35, 0xd8, 0x1a, 0xef, 0x40, 0x33, 0x23, 0x2a, 0xbd, 0x85, 0x3b,
-	0x04, 0x90, 0x6f, 0xc4, 0x0e, 0x71, 0xd3, 0xce, 0x33, 0x17, 0xb3, 0x58, 0xfc, 0x16, 0xac, 0x78,
-	0x06, 0x62, 0xda, 0xca, 0x02, 0x0f, 0xb9, 0xbe, 0xbf, 0x57, 0x57, 0x42, 0x5a, 0x60, 0xfc, 0x5b,
-	0x15, 0x9a, 0x99, 0x57, 0x73, 0xe4, 0xdd, 0xcc, 0x01, 0x41, 0x3a, 0xcb, 0x09, 0x8b, 0xf4, 0x3a,
-	0x96, 0x7c, 0xc4, 0xfb, 0x92, 0x7c, 0x49, 0x29, 0xac, 0xe5, 0x9c, 0x78, 0x43, 0x0f, 0x14, 0xbc,
-	0xcb, 0x0b, 0x73, 0xf0, 0x22, 0xf5, 0x9b, 0x87, 0xd1, 0x4d, 0x98, 0xda, 0x83, 0xba, 0x09, 0x23,
-	0x06, 0xb4, 0xc4, 0x09, 0x69, 0xe8, 0xca, 0x53, 0x2a, 0xec, 0xc6, 0x4d, 0x37, 0x61, 0xbb, 0xa1,
-	0x2b, 0x0e, 0xa5, 0xc8, 0x0a, 0x34, 0xb5, 0x8d, 0x17, 0xa9, 0xab, 0x29, 0xb4, 0xe8, 0x45, 0x7c,
-	0x17, 0x90, 0xd8, 0x7d, 0x6a, 0x25, 0x83, 0xa3, 0x80, 0x32, 0xf1, 0xc0, 0xa2, 0x6e, 0x02, 0x17,
-	0x1d, 0x08, 0x09, 0xef, 0xf7, 0x7c, 0xfd, 0x1c, 0x0e, 0xd8, 0x49, 0xe8, 0x05, 0x27, 0xe2, 0xbe,
-	0xa6, 0x6e, 0x36, 0x03, 0x9b, 0xed, 0xa1, 0x88, 0xdc, 0x87, 0xb6, 0x1f, 0x3a, 0xb6, 0x6f, 0xa9,
-	0xb3, 0x01, 0x71, 0x61, 0x53, 0x37, 0x5b, 0x42, 0xaa, 0x56, 0x13, 0xe4, 0x31, 0x34, 0x99, 0xf8,
-	0x02, 0xb2, 0xd1, 0xf2, 0xd1, 0xb0, 0x6a, 0x74, 0xfa, 0x6d, 0x4c, 0x60, 0xfa, 0xb7, 0x71, 0x07,
-	0xc3, 0x8b, 0xb9, 0x80, 0x31, 0xa8, 0xea, 0x18, 0x18, 0xff, 0x59, 0x81, 0xa5, 0x91, 0xaf, 0x08,
-	0x45, 0x22, 0xf0, 0xf1, 0x4d, 0x25, 0x02, 0x1f, 0xf9, 0x70, 0x2f, 0x5f, 0x4d, 0xf7, 0xf2, 0xb9,
-	0x09, 0x69, 0xbc, 0xb0, 0x70, 0x58, 0x85, 0x4e, 0x64, 0xc7, 0x34, 0x60, 0x96, 0x4b, 0xc5, 0x79,
-	0xa0, 0x17, 0x61, 0x9c, 0xdb, 0x52, 0xbe, 0x25, 0xc4, 0x72, 0xb9, 0xdc, 0xb7, 0x1d, 0x3e, 0x9e,
-	0xc9, 0x28, 0x4f, 0xf6, 0x6d, 0xe7, 0xd5, 0x7a, 0x7e, 0x32, 0xa9, 0x15, 0x56, 0x1e, 0x3f, 0x04,
-	0x52, 0x44, 0x3f, 0x5b, 0x17, 0x5f, 0xa1, 0x61, 0x76, 0xf2, 0xf8, 0x67, 0xeb, 0xc6, 0x87, 0xa5,
-	0x6d, 0xc5, 0xd8, 0x94, 0xb4, 0xd5, 0xf8, 0x45, 0x05, 0x16, 0x47, 0xbc, 0x65, 0xbc, 0x72, 0x02,
-	0xcc, 0xaf, 0xe8, 0xaa, 0xc5, 0x15, 0xdd, 0x43, 0x98, 0xf5, 0x02, 0x46, 0xe3, 0x63, 0x5b, 0x32,
-	0xce, 0x85, 0xee, 0x86, 0x56, 0xa9, 0x3d, 0x9f, 0xf1, 0xa4, 0x84, 0xc5, 0x9b, 0xa7, 0x61, 0xe3,
-	0xcf, 0x2a, 0xb0, 0x34, 0xf2, 0xd5, 0xde, 0x95, 0xfc, 0x0d, 0x68, 0xa5, 0xfc, 0xf9, 0x17, 0x91,
-	0x4d, 0x68, 0xea, 0x26, 0xbc, 0x5a, 0x1f, 0x6a, 0xc4, 0xfa, 0xc8, 0x46, 0xc8, 0x79, 0xff, 0x69,
-	0x29, 0x99, 0x6b, 0x34, 0xe3, 0x1f, 0x2b, 0x30, 0x5f, 0xfa, 0x2a, 0x93, 0x3c, 0x86, 0x79, 0x75,
-	0xca, 0xec, 0xf8, 0x83, 0x84, 0xd1, 0xd8, 0xe2, 0x33, 0xbb, 0x3a, 0xa1, 0x9d, 0x45, 0xe5, 0xa6,
-	0xd4, 0x6d, 0x72, 0x15, 0x59, 0x4b, 0x1f, 0x28, 0xd3, 0x0b, 0x46, 0xe3, 0xc0, 0xf6, 0xd1, 0xa9,
-	0x8a, 0x17, 0x92, 0x52, 0xbb, 0x8d, 0x4a, 0xe9, 0xf5, 0x63, 0x58, 0x56, 0x5e, 0xbc, 0x2f, 0x1e,
-	0xd9, 0xbe, 0x1d, 0x38, 0xba, 0x3a, 0xb9, 0x41, 0xec, 0xa2, 0xc5, 0x8b, 0x8c, 0x81, 0xf0, 0x36,
-	0xbe, 0x86, 0x26, 0x4e, 0x45, 0xfb, 0x61, 0xcc, 0x78, 0x63, 0xd5, 0xe9, 0xa6, 0x6a, 0xac, 0x3e,
-	0xed, 0x24, 0x30, 0xc1, 0x6d, 0xd4, 0x41, 0xa4, 0xb2, 0xe7, 0xa3, 0x8d, 0x90, 0x8f, 0x0b, 0xb9,
-	0x2e, 0xf3, 0xfe, 0xdb, 0xca, 0xbd, 0x12, 0x2d, 0xdd, 0xff, 0xe6, 0xe6, 0xbd, 0x6a, 0xc9, 0xbc,
-	0xa7, 0xdf, 0xc3, 0x34, 0x70, 0x88, 0xbd, 0x0d, 0xa0, 0x42, 0xaa, 0x3b, 0x6c, 0x03, 0x25, 0xbd,
-	0x88, 0xef, 0x92, 0x73, 0x71, 0xd0, 0x43, 0x63, 0x3b, 0x2b, 0xee, 0x45, 0x7c, 0xf8, 0xd3, 0x61,
-	0xf6, 0x22, 0x75, 0x58, 0xd7, 0x54, 0xb2, 0x5e, 0x94, 0x90, 0x55, 0x98, 0xcc, 0xde, 0x7c, 0x93,
-	0xfc, 0xa4, 0x2e, 0x4e, 0x6e, 0xa5, 0x81, 0xb1, 0xa1, 0xdb, 0x9a, 0xe9, 0xb3, 0x6f, 0xd5, 0xd6,
-	0x07, 0xab, 0xd0, 0xd0, 0x7b, 0x26, 0x32, 0x05, 0xe3, 0x1b, 0xbb, 0x5f, 0x77, 0xc6, 0x48, 0x1d,
-	0x26, 0x7a, 0xfb, 0xaf, 0xd6, 0x3a, 0x13, 0xf8, 0x6b, 0xbd, 0x53, 0x7b, 0xf0, 0xa7, 0x15, 0x68,
-	0xe8, 0x89, 0x87, 0xb4, 0xa0, 0xb1, 0xd9, 0xdb, 0x32, 0xad, 0xde, 0xee, 0xa7, 0x7b, 0x9d, 0x31,
-	0x32, 0x0b, 0x33, 0xe6, 0xf6, 0xcb, 0xbd, 0xc3, 0x6d, 0xeb, 0xab, 0x3d, 0xf3, 0x8b, 0x17, 0x7b,
-	0x1b, 0x5b, 0x9d, 0x0a, 0x99, 0x81, 0x26, 0x0a, 0x77, 0xf6, 0x0e, 0x0e, 0x3b, 0x55, 0x42, 0xa0,
-	0xfd, 0x62, 0x6f, 0x73, 0xe3, 0x45, 0x6a, 0x34, 0x4e, 0xda, 0x00, 0x52, 0x26, 0x6c, 0x26, 0xc8,
-	0x0d, 0x68, 0xa1, 0xd3, 0xe1, 0x97, 0xbb, 0xbb, 0xdb, 0x2f, 0x3a, 0x93, 0xa4, 0x03, 0xd3, 0xd2,
-	0x04, 0x25, 0xb5, 0x07, 0xcf, 0x00, 0xd2, 0x59, 0x8d, 0x73, 0xdc, 0xdd, 0xdb, 0xdd, 0xee, 0x8c,
-	0x91, 0x69, 0xa8, 0xef, 0xee, 0x59, 0xdb, 0xbb, 0x9b, 0x1b, 0xfb, 0x9d, 0x0a, 0x69, 0xc0, 0xa4,
-	0x18, 0xde, 0x3a, 0x55, 0xd9, 0x8c, 0xde, 0x7e, 0x67, 0xfc, 0xf1, 0x27, 0x00, 0xf2, 0x09, 0x88,
-	0xf8, 0x6f, 0xa6, 0x47, 0x30, 0x21, 0xfe, 0xea, 0x20, 0xa7, 0xff, 0x23, 0xb5, 0xac, 0x64, 0x99,
-	0xff, 0x93, 0x7a, 0x54, 0x79, 0xbe, 0xf8, 0xcb, 0x6f, 0x57, 0x2a, 0xff, 0xf2, 0xed, 0x4a, 0xe5,
-	0xdf, 0xbf, 0x5d, 0xa9, 0xfc, 0xe5, 0x7f, 0xac, 0x8c, 0xfd, 0x74, 0x52, 0xdc, 0x97, 0x1f, 0xd5,
-	0xc4, 0x9f, 0x8f, 0xfe, 0x27, 0x00, 0x00, 0xff, 0xff, 0x20, 0xe4, 0x69, 0xa6, 0x85, 0x35, 0x00,
-	0x00,
+	// 4262 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x5b, 0xcd, 0x73, 0x24, 0x47,
+	0x56, 0x57, 0xb7, 0xd4, 0xad, 0xee, 0xd7, 0xea, 0x56, 0x4f, 0xea, 0x63, 0x4a, 0x9a, 0x91, 0x34,
+	0x2e, 0x7b, 0xd6, 0xf2, 0xb0, 0x1e, 0x0f, 0x63, 0x4d, 0xcf, 0xda, 0x2c, 0x76, 0xf4, 0x48, 0xb2,
+	0xd5, 0xf6, 0x4c, 0x4b, 0x94, 0x64, 0x19, 0x2f, 0x1b, 0x51, 0x94, 0xaa, 0x52, 0x52, 0xe1, 0xea,
+	0xaa, 0x72, 0x55, 0xb6, 0x3e, 0x76, 0x4f, 0xc0, 0x02, 0xbb, 0x41, 0x10, 0x70, 0x20, 0x08, 0xfe,
+	0x00, 0x8e, 0xfc, 0x07, 0x1c, 0x38, 0x11, 0xb1, 0x1b, 0x5c, 0x96, 0x1b, 0x17, 0x22, 0x08, 0xfb,
+	0xc6, 0x8d, 0x03, 0x17, 0x4e, 0x44, 0x7e, 0xd6, 0x47, 0x57, 0xf7, 0x68, 0xb0, 0xe1, 0xa4, 0xce,
+	0xf7, 0xf1, 0xcb, 0x97, 0xaf, 0x5e, 0xbe, 0xcc, 0x7c, 0x99, 0x02, 0x74, 0x8a, 0x3d, 0xf7, 0xea,
+	0xc4, 0xb2, 0xbf, 0xc4, 0xbe, 0xf3, 0x30, 0x8c, 0x02, 0x12, 0xa0, 0x0a, 0xa3, 0xe9, 0x4d, 0x68,
+	0x1c, 0x5e, 0xfb, 0xb6, 0x81, 0xbf, 0x1a, 0xe2, 0x98, 0xe8, 0xff, 0xbc, 0x0c, 0x8d, 0xa3, 0x60,
+	0xc7, 0x22, 0x56, 0xe8, 0x59, 0x3e, 0x46, 0x9b, 0x30, 0xeb, 0xfa, 0x66, 0x7c, 0xed, 0xdb, 0x5a,
+	0xe9, 0x5e, 0x69, 0xb3, 0xf1, 0xb8, 0xf9, 0x90, 0xe9, 0x3d, 0xec, 0xf9, 0x54, 0x6d, 0x6f, 0xca,
+	0xa8, 0xba, 0xec, 0x17, 0x7a, 0x0a, 0x73, 0x6e, 0x18, 0x63, 0x62, 0x0e, 0x43, 0xc7, 0x22, 0x58,
+	0x2b, 0x33, 0x71, 0x24, 0xc5, 0x0f, 0x0e, 0x31, 0xf9, 0x8c, 0x71, 0xf6, 0xa6, 0x8c, 0x06, 0x93,
+	0xe4, 0x4d, 0xf4, 0x31, 0x20, 0xae, 0xe8, 0x60, 0x8f, 0x58, 0x52, 0x7d, 0x9a, 0xa9, 0xdf, 0x4e,
+	0xab, 0xef, 0x50, 0xbe, 0xc2, 0x68, 0x33, 0xa5, 0x14, 0x2d, 0xb1, 0x20, 0xc2, 0x83, 0xe0, 0x02,
+	0x6b, 0x33, 0xa3, 0x16, 0x18, 0x8c, 0xa3, 0x2c, 0xe0, 0x4d, 0x74, 0x00, 0x4b, 0x96, 0x4d, 0xdc,
+	0x0b, 0x6c, 0x86, 0x51, 0x70, 0xea, 0x7a, 0x58, 0x1a, 0x51, 0x61, 0x08, 0xab, 0x02, 0xa1, 0xcb,
+	0x64, 0x0e, 0xb8, 0x88, 0xb2, 0x63, 0xc1, 0x1a, 0x25, 0x17, 0x20, 0x0a, 0x9b, 0xaa, 0xe3, 0x11,
+	0x95, 0x6d, 0x0b, 0xd6, 0x28, 0x19, 0xbd, 0x80, 0x45, 0x89, 0x18, 0x78, 0xae, 0x7d, 0x2d, 0x4d,
+	0x9c, 0x65, 0x80, 0x2b, 0x59, 0x40, 0x26, 0xa1, 0x2c, 0x44, 0xd6, 0x08, 0x75, 0x14, 0x4e, 0xd8,
+	0x57, 0x1b, 0x0b, 0xa7, 0xcc, 0x43, 0xd6, 0x08, 0x95, 0xc2, 0x9d, 0x07, 0x31, 0x31, 0xb1, 0xef,
+	0x84, 0x81, 0xeb, 0xab, 0x20, 0xa8, 0x67, 0xe0, 0xf6, 0x82, 0x98, 0xec, 0x0a, 0x89, 0xc4, 0xba,
+	0xf3, 0x11, 0xea, 0x28, 0x9c, 0xb0, 0x0e, 0xc6, 0xc2, 0x25, 0xd6, 0x9d, 0x8f, 0x50, 0xd1, 0x17,
+	0xa0, 0x5d, 0x06, 0xd1, 0x97, 0x5e, 0x60, 0x39, 0x23, 0x16, 0x36, 0x18, 0xe4, 0x9a, 0x80, 0xfc,
+	0x5c, 0x88, 0x8d, 0x58, 0xb9, 0x7c, 0x59, 0xc8, 0x29, 0x86, 0x16, 0xd6, 0xce, 0x4d, 0x84, 0x56,
+	0x16, 0x2f, 0x5f, 0x16, 0x72, 0xd0, 0xfb, 0xd0, 0xb4, 0x03, 0xff, 0xd4, 0x3d, 0x93, 0xa6, 0x36,
+	0x19, 0xde, 0x82, 0xc0, 0xdb, 0x66, 0x3c, 0x65, 0xe0, 0x9c, 0x9d, 0x6a, 0x2b, 0x07, 0x0e, 0x30,
+	0xb1, 0x1c, 0x2b, 0x99, 0x55, 0xad, 0x11, 0x07, 0xbe, 0x10, 0x12, 0xd9, 0xef, 0x91, 0xa5, 0xa2,
+	0x37, 0x61, 0x3e, 0xa6, 0x09, 0xc2, 0xb7, 0xb1, 0xe9, 0x0f, 0x07, 0x27, 0x38, 0xd2, 0xe6, 0xef,
+	0x95, 0x36, 0x67, 0x8c, 0x96, 0x24, 0xf7, 0x19, 0x15, 0x75, 0xa1, 0xed, 0x86, 0xd6, 0xc0, 0x0c,
+	0x83, 0xc0, 0x93, 0x7d, 0xb6, 0x59, 0x9f, 0x4b, 0x6a, 0x1a, 0x76, 0x5f, 0x1c, 0x04, 0x81, 0xa7,
+	0xfa, 0x6b, 0x51, 0x85, 0x84, 0x92, 0x85, 0x10, 0x9e, 0xbc, 0x55, 0x08, 0xa1, 0x3c, 0xa8, 0x20,
+	0x72, 0xd1, 0xa8, 0x46, 0x2f, 0x60, 0xd0, 0xd8, 0xd1, 0x67, 0xc3, 0x27, 0x4b, 0x45, 0x87, 0xb0,
+	0x1c, 0xe3, 0xe8, 0xc2, 0xb5, 0xb1, 0x69, 0xd9, 0x76, 0x30, 0x4c, 0x82, 0x67, 0x81, 0x01, 0xde,
+	0x11, 0x80, 0x87, 0x5c, 0xa8, 0xcb, 0x65, 0xd4, 0x00, 0x17, 0xe3, 0x02, 0x7a, 0x11, 0xa8, 0xb0,
+	0x72, 0x71, 0x02, 0xa8, 0xb2, 0x73, 0x31, 0x2e, 0xa0, 0xa3, 0x6d, 0x68, 0xfb, 0xd6, 0x00, 0xc7,
+	0xa1, 0x65, 0xab, 0x1c, 0xb6, 0xc4, 0xe0, 0x96, 0x05, 0x5c, 0x5f, 0xb2, 0x95, 0x79, 0xf3, 0x7e,
+	0x96, 0x94, 0x05, 0x11, 0x36, 0x2d, 0x17, 0x83, 0x28, 0x73, 0xe6, 0xfd, 0x2c, 0x89, 0xe6, 0xe2,
+	0x28, 0x18, 0x12, 0x65, 0xc5, 0xed, 0x4c, 0x2e, 0x36, 0x28, 0x2b, 0x59, 0x0d, 0xa2, 0xa4, 0x99,
+	0x28, 0x8a, 0x9e, 0xb5, 0x51, 0xc5, 0x24, 0x89, 0x47, 0x49, 0x13, 0x6d, 0x43, 0xe3, 0x82, 0xe0,
+	0x50, 0x76, 0xb8, 0xc2, 0xf4, 0xee, 0x09, 0xbd, 0xe3, 0xdf, 0x7d, 0xde, 0xed, 0x1f, 0x0d, 0x7d,
+	0x1f, 0x7b, 0x23, 0x53, 0x1b, 0xa8, 0x9a, 0x1a, 0x3b, 0x07, 0x11, 0x9d, 0xaf, 0xbe, 0x0c, 0x44,
+	0x99, 0xc2, 0x40, 0x84, 0x25, 0x3f, 0x86, 0x95, 0x4b, 0x37, 0xc2, 0x67, 0x43, 0x2b, 0x1a, 0xcd,
+	0x37, 0x77, 0x18, 0xe4, 0xba, 0x4c, 0x0a, 0x52, 0x6e, 0xc4, 0xaa, 0xdb, 0x97, 0xc5, 0xac, 0x31,
+	0xe8, 0xc2, 0xe0, 0xbb, 0x93, 0xd1, 0x95, 0xb9, 0xb7, 0x2f, 0x8b, 0x59, 0xe8, 0x73, 0xd0, 0xce,
+	0xbc, 0xe0, 0xc4, 0xf2, 0xcc, 0x93, 0xb3, 0xd0, 0xcc, 0xe6, 0x9f, 0x35, 0x06, 0x7e, 0x57, 0x80,
+	0x7f, 0xcc, 0xc4, 0x9e, 0x7d, 0x7c, 0x90, 0x4b, 0x44, 0x4b, 0x5c, 0xff, 0xd9, 0x59, 0x98, 0x66,
+	0xa0, 0x1f, 0x42, 0x13, 0xfb, 0xb6, 0x15, 0xc6, 0x43, 0xcf, 0x22, 0x6e, 0xe0, 0x6b, 0xeb, 0x0c,
+	0x6d, 0x51, 0xa0, 0xed, 0xa6, 0x79, 0x7b, 0x53, 0x46, 0x56, 0x18, 0xfd, 0x36, 0xb4, 0xe4, 0x6c,
+	0x11, 0xc6, 0x6c, 0x64, 0xd4, 0xc5, 0x2c, 0x51, 0x46, 0x34, 0xe3, 0x34, 0x21, 0xad, 0x2e, 0x1c,
+	0x75, 0xaf, 0x48, 0x5d, 0xb9, 0xa7, 0x19, 0xa7, 0x09, 0xc8, 0x86, 0xbb, 0x05, 0x2e, 0xbf, 0xe8,
+	0x48, 0x5b, 0x5e, 0xcb, 0x84, 0xc9, 0x88, 0xd7, 0x8f, 0x3b, 0xca, 0xae, 0x95, 0xcb, 0x71, 0xcc,
+	0xf1, 0x9d, 0x08, 0x8b, 0xf5, 0x97, 0x75, 0xa2, 0xac, 0x5f, 0xb9, 0x1c, 0xc7, 0x44, 0x47, 0x70,
+	0x3b, 0x9b, 0x19, 0x93, 0x41, 0xbc, 0x9e, 0x49, 0x3b, 0xe9, 0xe4, 0x98, 0xb2, 0x7f, 0xf1, 0xbc,
+	0x80, 0x5e, 0x88, 0x2a, 0xac, 0x7e, 0x63, 0x02, 0x6a, 0x92, 0xcc, 0xce, 0x0b, 0xe8, 0xe8, 0x47,
+	0xb0, 0x92, 0x43, 0xdd, 0x4a, 0xac, 0xbd, 0x9f, 0x59, 0x5b, 0x33, 0xb8, 0x5b, 0x29, 0x7b, 0x97,
+	0x33, 0xc8, 0x5b, 0x17, 0xd2, 0xe2, 0x62, 0x6c, 0x61, 0xf3, 0xf7, 0x26, 0x62, 0x27, 0xeb, 0x76,
+	0x1e, 0x9b, 0x73, 0x9e, 0xd5, 0x61, 0x36, 0xb4, 0xae, 0xe9, 0x82, 0xae, 0xff, 0x45, 0x05, 0x9a,
+	0x1f, 0x45, 0xc1, 0x20, 0xd9, 0x4f, 0x1f, 0xc0, 0x52, 0x18, 0x05, 0x36, 0x8e, 0x63, 0x33, 0x26,
+	0x16, 0x19, 0xc6, 0xd9, 0xfd, 0xae, 0xdc, 0x18, 0x1e, 0x70, 0x99, 0x43, 0x26, 0x92, 0x6c, 0x35,
+	0xc3, 0x51, 0x32, 0xfa, 0x7d, 0xb8, 0x93, 0xdd, 0x2b, 0x65, 0x71, 0xf9, 0x26, 0x78, 0xa3, 0x60,
+	0xcb, 0x94, 0x03, 0xd7, 0xce, 0xc7, 0xf0, 0xc6, 0xf6, 0x20, 0xdc, 0x55, 0x79, 0x49, 0x0f, 0xca,
+	0x61, 0xda, 0xf9, 0x18, 0x1e, 0xf2, 0x60, 0x63, 0x74, 0x17, 0x95, 0x1d, 0x07, 0xdf, 0x38, 0xbf,
+	0x3e, 0x66, 0x33, 0x95, 0x1b, 0xcb, 0xdd, 0xcb, 0x09, 0xfc, 0x89, 0xbd, 0x89, 0x31, 0xcd, 0xde,
+	0xa0, 0x37, 0x35, 0xae, 0xbb, 0x97, 0x13, 0xf8, 0x45, 0x7b, 0xa7, 0x5a, 0xe1, 0xde, 0xe9, 0x18,
+	0x92, 0xac, 0x9c, 0x1b, 0x7c, 0x3d, 0x93, 0x79, 0xd5, 0xdc, 0xcf, 0x8d, 0x7a, 0xe9, 0xb2, 0x88,
+	0x91, 0x8e, 0xc7, 0x7f, 0x29, 0xc3, 0x5c, 0x26, 0x2b, 0x3f, 0x85, 0x2a, 0xcf, 0xf1, 0x5a, 0xe9,
+	0xde, 0x74, 0xea, 0x2b, 0xa6, 0x85, 0x44, 0x63, 0xd7, 0x27, 0xd1, 0xb5, 0x21, 0xc4, 0xd1, 0xef,
+	0xc1, 0x62, 0x1c, 0x0c, 0x23, 0x1b, 0x9b, 0x24, 0x30, 0x23, 0xeb, 0x52, 0x2c, 0x15, 0x5a, 0x99,
+	0xc1, 0x3c, 0x28, 0x82, 0x39, 0x64, 0xf2, 0x47, 0x81, 0x61, 0x5d, 0xa6, 0x11, 0x6f, 0xc5, 0x79,
+	0x3a, 0xd2, 0x60, 0x76, 0x80, 0xe3, 0xd8, 0x3a, 0xe3, 0xd3, 0xa2, 0x6e, 0xc8, 0xe6, 0xea, 0x7b,
+	0xd0, 0x48, 0xe9, 0xa2, 0x36, 0x4c, 0x7f, 0x89, 0xaf, 0xd9, 0xc9, 0xb4, 0x6e, 0xd0, 0x9f, 0x68,
+	0x11, 0x2a, 0x17, 0x96, 0x37, 0xe4, 0xc7, 0xcf, 0xba, 0xc1, 0x1b, 0xef, 0x97, 0x7f, 0x50, 0x5a,
+	0x3d, 0x86, 0xe5, 0x62, 0x0b, 0xd2, 0x28, 0x4d, 0x8e, 0xf2, 0xbd, 0x34, 0x4a, 0xe3, 0x71, 0x5b,
+	0xee, 0x3e, 0xa4, 0x5e, 0x0a, 0x57, 0xff, 0xeb, 0x12, 0xd4, 0x13, 0xd3, 0x97, 0xa1, 0xca, 0xc7,
+	0x23, 0x8c, 0x12, 0x2d, 0xb4, 0x05, 0xd5, 0x8c, 0x87, 0xee, 0xe6, 0x21, 0x8b, 0xbc, 0xfc, 0x2d,
+	0x86, 0xab, 0xd7, 0xa0, 0xca, 0x8f, 0xe8, 0xfa, 0xdf, 0x96, 0xa0, 0x91, 0x3a, 0x7e, 0xa3, 0x16,
+	0x94, 0x5d, 0x47, 0x80, 0x94, 0x5d, 0x87, 0x7b, 0x9b, 0x46, 0x60, 0xcc, 0x6c, 0xab, 0x1b, 0xb2,
+	0x89, 0x1e, 0xc1, 0x0c, 0xb9, 0x0e, 0xf9, 0x47, 0x68, 0x29, 0x93, 0x53, 0x58, 0xfc, 0xf7, 0xd1,
+	0x75, 0x88, 0x0d, 0x26, 0xa9, 0xbf, 0x0d, 0x75, 0x45, 0x42, 0x55, 0x28, 0xf7, 0x0e, 0xda, 0x53,
+	0x68, 0x9e, 0xf6, 0x6f, 0x76, 0xfb, 0x3b, 0xe6, 0xc1, 0xbe, 0x71, 0xd4, 0x2e, 0xa1, 0x59, 0x98,
+	0xee, 0xef, 0x1e, 0xb5, 0xcb, 0x7a, 0x08, 0xed, 0xfc, 0xc9, 0x7e, 0xc4, 0xbc, 0xd7, 0xa1, 0x69,
+	0x39, 0x0e, 0x76, 0xcc, 0xac, 0x91, 0x73, 0x8c, 0xf8, 0x42, 0x58, 0xfa, 0x26, 0xcc, 0xf3, 0x99,
+	0x9b, 0x88, 0x4d, 0x33, 0xb1, 0x96, 0x20, 0x0b, 0x41, 0x7d, 0x4d, 0xf8, 0x42, 0x4c, 0xce, 0x5c,
+	0x67, 0xba, 0x05, 0x0b, 0x05, 0xa7, 0x7c, 0x74, 0x4f, 0x89, 0x25, 0xc1, 0x20, 0x24, 0x7a, 0x3b,
+	0xcc, 0xca, 0x4d, 0x98, 0x15, 0x27, 0x7d, 0x11, 0x33, 0xad, 0xac, 0x98, 0x21, 0xd9, 0xfa, 0xd3,
+	0x5c, 0x17, 0xc2, 0x92, 0x97, 0x76, 0xa1, 0x6f, 0x40, 0x5d, 0x11, 0x10, 0x82, 0x19, 0xba, 0xe5,
+	0x16, 0xa6, 0xb3, 0xdf, 0x7a, 0x00, 0xb3, 0x42, 0x00, 0x3d, 0x82, 0xa6, 0xeb, 0x9f, 0x04, 0x43,
+	0xdf, 0x31, 0xa3, 0xa1, 0x87, 0x63, 0x31, 0xbd, 0x1b, 0x32, 0xea, 0x86, 0x1e, 0x36, 0xe6, 0x84,
+	0x04, 0x6d, 0xc4, 0xe8, 0x31, 0xb4, 0x82, 0x21, 0x49, 0xab, 0x94, 0x47, 0x55, 0x9a, 0x52, 0x84,
+	0xe9, 0xe8, 0x3f, 0x06, 0x34, 0x5a, 0x70, 0x40, 0x1b, 0xa9, 0x91, 0xcc, 0xcb, 0x91, 0x30, 0x01,
+	0xe1, 0xab, 0xfb, 0x50, 0xe5, 0x45, 0x07, 0xad, 0x9c, 0x29, 0x29, 0x71, 0x21, 0x43, 0x30, 0xf5,
+	0x27, 0x59, 0x74, 0xe1, 0xa7, 0x97, 0xa1, 0xeb, 0x8f, 0xa1, 0x26, 0xdb, 0xd4, 0x4b, 0xc4, 0xc5,
+	0x91, 0xf4, 0x12, 0xfd, 0xad, 0x3c, 0x57, 0x4e, 0x79, 0xee, 0x9f, 0x4a, 0x50, 0xe5, 0x4a, 0xff,
+	0x3f, 0x9e, 0x43, 0x77, 0xa1, 0x3e, 0xf4, 0x49, 0x44, 0x0b, 0x72, 0x0e, 0x9b, 0x5e, 0x35, 0x23,
+	0x21, 0xa0, 0x15, 0xa8, 0x85, 0x11, 0x36, 0x1d, 0xdf, 0x22, 0x6c, 0xfd, 0xae, 0xd1, 0xe8, 0xc1,
+	0x3b, 0xbe, 0x45, 0xa8, 0xa2, 0x3a, 0x6a, 0xb1, 0x95, 0xb7, 0x6e, 0x24, 0x04, 0xfd, 0xbf, 0x6f,
+	0xc1, 0x0c, 0xed, 0x80, 0xa6, 0x21, 0xcb, 0x66, 0xdb, 0x6c, 0x91, 0x86, 0x78, 0x0b, 0xbd, 0x03,
+	0xe0, 0x86, 0xe6, 0x05, 0x8e, 0x62, 0xca, 0x2b, 0xb3, 0x79, 0xdd, 0x56, 0xf3, 0xfa, 0x98, 0xd3,
+	0x8d, 0xba, 0x1b, 0x8a, 0x9f, 0xe8, 0x37, 0xa8, 0x29, 0x01, 0x09, 0xec, 0xc0, 0xd3, 0xa6, 0xb3,
+	0x4e, 0x17, 0x64, 0x43, 0x09, 0xa0, 0xdb, 0x30, 0x1b, 0x47, 0xb6, 0xe9, 0x63, 0x6a, 0xf6, 0x34,
+	0xcb, 0x7e, 0x91, 0xdd, 0xc7, 0x04, 0xbd, 0x0d, 0x75, 0xca, 0x08, 0x83, 0x88, 0xc4, 0x5a, 0x85,
+	0x79, 0x47, 0xc5, 0x78, 0x10, 0x11, 0xc3, 0xf2, 0xcf, 0xb0, 0x51, 0x8b, 0x23, 0x9b, 0xb6, 0x62,
+	0x8a, 0xe3, 0xc4, 0x84, 0xe1, 0x54, 0x39, 0x8e, 0x13, 0x13, 0x81, 0x43, 0x19, 0x1c, 0x67, 0x76,
+	0x1c, 0x8e, 0x13, 0x13, 0x8e, 0xb3, 0x06, 0x75, 0xd7, 0x1e, 0x84, 0x26, 0x4b, 0x62, 0x74, 0xd1,
+	0xad, 0xec, 0x4d, 0x19, 0x35, 0x4a, 0x62, 0xf9, 0xe9, 0x03, 0x68, 0x29, 0xb6, 0x69, 0x07, 0x8e,
+	0x5c, 0x67, 0xe5, 0x31, 0xb7, 0x27, 0x04, 0xbb, 0xbe, 0xb3, 0x1d, 0x38, 0xac, 0xc8, 0x22, 0x75,
+	0x69, 0x1b, 0xad, 0x03, 0x28, 0xfd, 0x58, 0xfb, 0x53, 0x1a, 0x28, 0x15, 0xa3, 0x2e, 0x45, 0x62,
+	0xf4, 0x21, 0xcc, 0x67, 0xf1, 0x63, 0xed, 0xcf, 0x78, 0x34, 0x8d, 0xe9, 0xc1, 0x68, 0xa6, 0xf1,
+	0x63, 0xf4, 0x3a, 0xb4, 0xa8, 0xdb, 0xdc, 0xd0, 0xa4, 0x55, 0x4d, 0xd7, 0x89, 0x35, 0x60, 0xee,
+	0x68, 0xc4, 0x91, 0xdd, 0x0b, 0x0f, 0x31, 0xe9, 0x39, 0x4c, 0x88, 0xfa, 0x24, 0x25, 0xd4, 0xe0,
+	0x42, 0x4e, 0x4c, 0x94, 0xd0, 0x53, 0x58, 0x61, 0x5f, 0xc6, 0x1a, 0x60, 0x87, 0xb9, 0x2f, 0x2d,
+	0x3f, 0xc7, 0xe4, 0x17, 0xe9, 0xb7, 0xa2, 0x7c, 0xea, 0xbb, 0xb4, 0x22, 0xfb, 0x14, 0x85, 0x8a,
+	0x4d, 0xae, 0x48, 0x3f, 0xce, 0x88, 0xe2, 0xf7, 0x61, 0x41, 0x98, 0xc5, 0xb4, 0xa4, 0xca, 0x3c,
+	0x53, 0x99, 0x67, 0xb6, 0x51, 0x79, 0x21, 0xfd, 0x18, 0xe6, 0xfc, 0x80, 0x98, 0x2a, 0xd4, 0x4e,
+	0x8b, 0x43, 0xad, 0xe1, 0x07, 0x44, 0x36, 0xd0, 0x3a, 0xd0, 0xa6, 0x29, 0x23, 0xee, 0x8c, 0x21,
+	0xd7, 0xfd, 0x80, 0x1c, 0xf2, 0xa0, 0xdb, 0x82, 0xa6, 0xe4, 0xf3, 0x80, 0x39, 0x1f, 0x13, 0x30,
+	0x0d, 0xae, 0xc3, 0x63, 0x46, 0xa0, 0xca, 0xf8, 0x73, 0x15, 0xea, 0x4e, 0x4c, 0x52, 0xa8, 0x49,
+	0x18, 0xfe, 0xc1, 0x04, 0xd4, 0x1d, 0x19, 0x89, 0x6f, 0x70, 0xad, 0x24, 0x1a, 0xbf, 0x64, 0xd1,
+	0x58, 0x62, 0x52, 0x32, 0x0a, 0xd0, 0x2e, 0xa0, 0x8c, 0x14, 0x0f, 0x4a, 0x6f, 0x62, 0x50, 0x96,
+	0x8c, 0xf9, 0x14, 0x04, 0x25, 0xa1, 0xfb, 0xd0, 0xca, 0xc0, 0xc4, 0xda, 0xcf, 0x79, 0x6c, 0xce,
+	0xa5, 0x24, 0x63, 0xf4, 0x11, 0x2c, 0x8c, 0xf6, 0x16, 0x6b, 0xbf, 0x98, 0x1c, 0xa2, 0xed, 0x5c,
+	0x6f, 0x31, 0x7a, 0x00, 0x48, 0xfa, 0x39, 0x15, 0x1b, 0x03, 0xbe, 0xfc, 0x72, 0xd7, 0xaa, 0xa8,
+	0x10, 0xb2, 0xb9, 0x80, 0xf5, 0x95, 0xec, 0x4e, 0x2a, 0x66, 0x3f, 0x80, 0x35, 0xf5, 0x7d, 0x0b,
+	0xc3, 0x2f, 0x64, 0x6a, 0xb7, 0xc5, 0x17, 0x1f, 0x89, 0x40, 0xa1, 0x3f, 0x3e, 0x7c, 0xbf, 0x52,
+	0xfa, 0x3b, 0x45, 0x11, 0xfc, 0x18, 0x96, 0x82, 0xc8, 0x3d, 0x73, 0x7d, 0xcb, 0x63, 0x46, 0xc4,
+	0xd8, 0xc3, 0x36, 0x09, 0x22, 0x2d, 0x62, 0x29, 0x75, 0x41, 0x32, 0x0f, 0x23, 0xfb, 0x50, 0xb0,
+	0x32, 0x3a, 0xb4, 0x63, 0xa5, 0x13, 0x67, 0x75, 0x76, 0x62, 0xa2, 0x74, 0x76, 0x61, 0x23, 0xd3,
+	0x4f, 0x52, 0x7c, 0x53, 0xda, 0x84, 0x69, 0xdf, 0x4d, 0xf5, 0xa8, 0x4a, 0x70, 0x85, 0x30, 0x72,
+	0xcc, 0x39, 0x98, 0x61, 0x16, 0x46, 0x8c, 0x3a, 0x0b, 0xf3, 0x1e, 0xac, 0x28, 0x18, 0xe9, 0x7e,
+	0x05, 0x70, 0xc1, 0x00, 0x96, 0xa5, 0x40, 0x9f, 0x79, 0x7e, 0xac, 0x6a, 0xc6, 0x01, 0x97, 0x23,
+	0xaa, 0x69, 0x1f, 0x7c, 0xc6, 0xf3, 0x53, 0xbe, 0x22, 0x3a, 0xb0, 0x88, 0x7d, 0xae, 0x5d, 0x65,
+	0x8e, 0xc6, 0xd9, 0x82, 0xe8, 0x0b, 0x2a, 0x61, 0x2c, 0xc7, 0x91, 0x5d, 0x40, 0xa7, 0xb0, 0xdc,
+	0x88, 0x22, 0xd8, 0xeb, 0x97, 0xc3, 0x3a, 0x31, 0x29, 0xa0, 0xd3, 0x55, 0xf4, 0x9c, 0x90, 0x50,
+	0xe0, 0xfc, 0x24, 0xb3, 0x67, 0xdb, 0x3b, 0x3a, 0x3a, 0xe0, 0xda, 0x75, 0x2a, 0x23, 0x15, 0x6a,
+	0xb2, 0xd2, 0xa0, 0xfd, 0x34, 0x53, 0xc5, 0xa7, 0xab, 0xb5, 0x2a, 0x37, 0x2b, 0x21, 0xf4, 0x9b,
+	0xb0, 0x98, 0x8b, 0x23, 0x66, 0x85, 0xf6, 0x47, 0x7c, 0x39, 0x47, 0x99, 0x38, 0x62, 0x2c, 0xb4,
+	0x03, 0xeb, 0x45, 0x2a, 0x49, 0x1c, 0x68, 0x7f, 0xcc, 0x95, 0xef, 0x8c, 0x2a, 0xab, 0x30, 0xc8,
+	0x74, 0x9c, 0xfa, 0x22, 0xda, 0xcf, 0x72, 0x1d, 0x1f, 0x46, 0x76, 0x51, 0xc7, 0xe9, 0x8f, 0x98,
+	0x74, 0xfc, 0x27, 0xb9, 0x8e, 0x13, 0xe5, 0xa4, 0x63, 0x0d, 0x66, 0xe9, 0xe6, 0xc9, 0x74, 0x1d,
+	0xed, 0x57, 0x62, 0xcf, 0x42, 0xdb, 0x3d, 0xe7, 0x59, 0x15, 0x66, 0x68, 0x8e, 0x7a, 0x06, 0x50,
+	0x93, 0xf9, 0xea, 0x93, 0x6a, 0xed, 0x97, 0xa5, 0xf6, 0xaf, 0x4a, 0x06, 0x78, 0xc1, 0x99, 0x19,
+	0x46, 0xf8, 0xd4, 0xbd, 0xd2, 0x3f, 0x86, 0x85, 0xa2, 0x8f, 0xb5, 0x0a, 0x35, 0x15, 0x84, 0x1c,
+	0x58, 0xb5, 0xe9, 0xf1, 0x89, 0x59, 0x29, 0xce, 0x14, 0xbc, 0xa1, 0xff, 0x5d, 0x09, 0xea, 0xea,
+	0x33, 0xf2, 0xe3, 0x11, 0x39, 0x0f, 0x1c, 0xbe, 0x15, 0xac, 0x1b, 0xb2, 0x89, 0x1e, 0x41, 0x25,
+	0xb4, 0xc8, 0xb9, 0xdc, 0xef, 0xad, 0xe6, 0x23, 0xe0, 0xe1, 0x81, 0x45, 0xce, 0xd9, 0x2f, 0x83,
+	0x0b, 0xae, 0x7e, 0x0a, 0x75, 0x45, 0x43, 0xcb, 0x50, 0xc1, 0x57, 0x96, 0x4d, 0xb8, 0x55, 0x7b,
+	0x53, 0x06, 0x6f, 0x22, 0x0d, 0xaa, 0x7c, 0x44, 0x7c, 0x8b, 0x4a, 0xaf, 0x58, 0x79, 0xfb, 0xd9,
+	0x1c, 0x00, 0xc5, 0xe1, 0x71, 0xa7, 0xff, 0x4d, 0x09, 0xe6, 0xd2, 0xe1, 0x83, 0x3e, 0x82, 0x86,
+	0xe5, 0xfb, 0x01, 0x61, 0x25, 0x53, 0xb9, 0x71, 0x7d, 0xa3, 0x20, 0xd0, 0x1e, 0x76, 0x13, 0x31,
+	0x7e, 0xe0, 0x4c, 0x2b, 0xae, 0x7e, 0x00, 0xed, 0xbc, 0xc0, 0x2b, 0x1d, 0x3d, 0xdf, 0x83, 0xf9,
+	0xdc, 0xaa, 0xc1, 0x36, 0xe2, 0x74, 0xd9, 0xa3, 0xfa, 0x15, 0x7e, 0x56, 0xa4, 0x34, 0xb6, 0xbe,
+	0x95, 0x39, 0x8d, 0xfe, 0xd6, 0x9f, 0x43, 0x4d, 0xad, 0xef, 0x1a, 0x54, 0x45, 0xbd, 0xa4, 0x24,
+	0xb6, 0x6e, 0xa2, 0x8d, 0x16, 0xd3, 0x5b, 0xf8, 0xbd, 0x29, 0xbe, 0x89, 0x7f, 0xd6, 0x86, 0x16,
+	0xe7, 0x9b, 0x41, 0xc4, 0x82, 0x4f, 0x7f, 0x02, 0x75, 0xb5, 0x1e, 0x53, 0x7b, 0x4f, 0xdd, 0x28,
+	0x26, 0xc2, 0x06, 0xde, 0xa0, 0x46, 0x78, 0x56, 0x4c, 0xa4, 0x11, 0xf4, 0xb7, 0xfe, 0x97, 0x25,
+	0x40, 0xf9, 0x92, 0x4f, 0x6f, 0x87, 0x9e, 0x31, 0x83, 0xc8, 0x3e, 0xc7, 0x31, 0x89, 0x2c, 0x12,
+	0x44, 0x34, 0x52, 0xf9, 0xd0, 0x5b, 0x69, 0x72, 0xcf, 0x41, 0x1b, 0xd0, 0x50, 0xf5, 0x25, 0xd7,
+	0x11, 0x25, 0x0c, 0x90, 0x24, 0x2e, 0xa0, 0xea, 0x4e, 0xae, 0xc3, 0xb6, 0xf8, 0x75, 0x03, 0x24,
+	0xa9, 0xe7, 0x7c, 0x32, 0x53, 0x2b, 0xb5, 0xcb, 0x46, 0x8d, 0xd6, 0xcb, 0xd8, 0x40, 0xae, 0x60,
+	0xb9, 0xf8, 0x66, 0x12, 0xbd, 0x95, 0x3a, 0x0e, 0xad, 0x8c, 0x29, 0x57, 0x89, 0x63, 0xd7, 0xbb,
+	0x50, 0x93, 0x5d, 0x68, 0x95, 0xcc, 0xed, 0x7a, 0x5e, 0xc1, 0x50, 0x82, 0xfa, 0x7f, 0x4d, 0x43,
+	0x3b, 0xcf, 0xa6, 0xae, 0x8c, 0x89, 0x45, 0xe4, 0xe9, 0x93, 0x37, 0x8a, 0x0e, 0x56, 0x34, 0x6c,
+	0x06, 0x96, 0x2d, 0x5c, 0x40, 0x7f, 0xd2, 0xb1, 0xcb, 0x2b, 0x71, 0xba, 0x06, 0xf3, 0x73, 0x02,
+	0x08, 0x12, 0x5d, 0x76, 0xef, 0x40, 0xdd, 0x0d, 0x2f, 0xb6, 0xe8, 0xee, 0x8b, 0x9f, 0x15, 0xea,
+	0x46, 0x8d, 0x12, 0xfa, 0x98, 0x48, 0x66, 0x87, 0x33, 0xab, 0x8a, 0xd9, 0x61, 0xcc, 0xfb, 0x50,
+	0xa1, 0x27, 0x3c, 0x79, 0x32, 0x90, 0xbb, 0xc7, 0x23, 0x17, 0x47, 0x3d, 0xff, 0x34, 0x30, 0x38,
+	0x17, 0xbd, 0x05, 0x35, 0xde, 0x81, 0x45, 0xb4, 0xda, 0xbd, 0xe9, 0xd4, 0x59, 0xbd, 0x6f, 0x11,
+	0x26, 0x38, 0xcb, 0xfa, 0xb3, 0x88, 0x10, 0xed, 0x30, 0xd1, 0xfa, 0x58, 0xd1, 0x0e, 0x15, 0xed,
+	0xc2, 0x9a, 0xe5, 0x79, 0xc1, 0xa5, 0x19, 0x87, 0x41, 0x70, 0x8a, 0x1d, 0x53, 0x94, 0xc7, 0xf8,
+	0xd4, 0xc5, 0x72, 0xeb, 0xbe, 0xca, 0x84, 0x0e, 0xb9, 0x0c, 0xaf, 0x47, 0x1d, 0x08, 0x09, 0xf4,
+	0x49, 0x76, 0xfe, 0x36, 0x58, 0x87, 0x9b, 0x63, 0xbe, 0xd1, 0xff, 0xf1, 0x1c, 0xde, 0x1e, 0x8d,
+	0x38, 0x71, 0x00, 0xbf, 0x79, 0xc4, 0xe9, 0x5d, 0x68, 0xa5, 0xcb, 0xc1, 0xbd, 0x9d, 0x7c, 0xe4,
+	0x97, 0x5f, 0x1a, 0xf9, 0x1e, 0xa0, 0xd1, 0x57, 0x03, 0xe8, 0x7e, 0xca, 0x86, 0xa5, 0x82, 0xc2,
+	0xb3, 0x88, 0xf8, 0x77, 0x52, 0x11, 0x3f, 0x9d, 0x59, 0x76, 0xd3, 0xc2, 0xa9, 0x68, 0xff, 0xcf,
+	0x32, 0xcc, 0xa5, 0x59, 0x45, 0x65, 0x96, 0x7c, 0x04, 0x97, 0x47, 0x22, 0x58, 0xc5, 0xe1, 0xf4,
+	0xc4, 0x38, 0x7c, 0x08, 0x0b, 0xf8, 0x2a, 0xc4, 0x36, 0xc1, 0x8e, 0xc9, 0x02, 0xd2, 0x72, 0x9c,
+	0x48, 0xce, 0x88, 0x5b, 0x92, 0xd5, 0x0b, 0x2f, 0xb6, 0xba, 0x8e, 0x33, 0x2a, 0xdf, 0x11, 0xf2,
+	0x95, 0x11, 0xf9, 0x0e, 0x97, 0xff, 0x01, 0xcc, 0xab, 0x92, 0x82, 0xc9, 0x0d, 0xaa, 0x16, 0x1b,
+	0xd4, 0x52, 0x72, 0x47, 0xcc, 0xb2, 0x27, 0xd0, 0x92, 0xf5, 0x07, 0x73, 0xe2, 0x8c, 0x9a, 0x13,
+	0x65, 0x09, 0xae, 0xb6, 0x05, 0xcd, 0xd3, 0x20, 0xba, 0xa4, 0xe5, 0x6b, 0xae, 0x55, 0x1b, 0xa3,
+	0x25, 0xa4, 0x98, 0x96, 0xfe, 0x5b, 0xd9, 0x2f, 0x2c, 0xa2, 0xec, 0x66, 0x5f, 0x58, 0x8f, 0xa0,
+	0x26, 0x61, 0x0b, 0xbf, 0xd5, 0x5b, 0xd0, 0x76, 0xfd, 0xb3, 0x88, 0x5e, 0xb7, 0xb0, 0xaa, 0x92,
+	0xab, 0xd6, 0xfa, 0x79, 0x41, 0x3f, 0x10, 0x64, 0x9a, 0xde, 0x71, 0x4e, 0x52, 0x94, 0x10, 0x71,
+	0x46, 0x50, 0x7f, 0x0a, 0xb3, 0x62, 0xf6, 0xa3, 0x25, 0xa8, 0xe2, 0x2b, 0x7a, 0xa6, 0x90, 0x99,
+	0x10, 0x5f, 0x91, 0x5e, 0x48, 0xc9, 0x2c, 0xc0, 0x43, 0x39, 0xaf, 0xa8, 0xc1, 0xa1, 0xfe, 0x53,
+	0x58, 0x28, 0xb8, 0xd7, 0xa1, 0x05, 0x4e, 0x37, 0x0e, 0x4c, 0xe2, 0x0e, 0x70, 0x4c, 0xac, 0x81,
+	0xc4, 0x9a, 0x73, 0xe3, 0xe0, 0x48, 0xd2, 0x68, 0x41, 0x67, 0x18, 0x52, 0x11, 0x06, 0x59, 0x32,
+	0x44, 0x8b, 0x5a, 0xed, 0xc8, 0xcb, 0x25, 0x93, 0x27, 0x65, 0x9e, 0x6c, 0x5b, 0x8a, 0x4c, 0x3b,
+	0xc3, 0x7a, 0x08, 0xda, 0xb8, 0xcb, 0x9f, 0x9b, 0x4e, 0xa7, 0xb7, 0xa1, 0xca, 0xaf, 0x25, 0xb4,
+	0x72, 0x46, 0x34, 0x8b, 0x69, 0x08, 0x21, 0x7d, 0x13, 0x5a, 0x59, 0x0e, 0x1d, 0x84, 0x00, 0x90,
+	0xc5, 0x71, 0x2e, 0xd9, 0x2d, 0xb2, 0xed, 0xd5, 0x02, 0xe1, 0x0a, 0xee, 0x4e, 0xba, 0x13, 0x7a,
+	0x95, 0x75, 0xf2, 0x15, 0x87, 0xd9, 0x1b, 0xd7, 0xf3, 0xab, 0xe7, 0xcb, 0x33, 0x58, 0x2a, 0xbc,
+	0xdb, 0x41, 0x6b, 0x00, 0xe1, 0xf0, 0xc4, 0x73, 0x6d, 0x33, 0x49, 0xe0, 0x75, 0x4e, 0xf9, 0x14,
+	0x5f, 0xbf, 0x72, 0x55, 0x4f, 0xff, 0x79, 0x19, 0x96, 0x8b, 0xef, 0x4c, 0xe9, 0x76, 0x59, 0x26,
+	0x5f, 0xb9, 0x5d, 0x96, 0x6d, 0xb5, 0x34, 0xd3, 0xc4, 0x23, 0x42, 0x9b, 0x2d, 0xa5, 0x34, 0xdf,
+	0xa8, 0xa5, 0x99, 0x31, 0xa7, 0x15, 0x93, 0x25, 0x23, 0x8a, 0x6a, 0xc5, 0x62, 0x37, 0xc7, 0xb7,
+	0x3b, 0xaa, 0x8d, 0xba, 0x50, 0xf5, 0xac, 0x13, 0xec, 0xc9, 0xca, 0xe0, 0x5b, 0x13, 0x2f, 0x75,
+	0x1f, 0x3e, 0x67, 0xb2, 0xe2, 0x9e, 0x84, 0x2b, 0xd2, 0x7b, 0x92, 0x14, 0xf9, 0x95, 0x16, 0xba,
+	0xdf, 0x19, 0xf5, 0x84, 0xf8, 0x70, 0xff, 0x5b, 0x4f, 0xe8, 0x2f, 0x00, 0xa5, 0x21, 0xbf, 0xa5,
+	0x63, 0xf3, 0x70, 0xdf, 0xd6, 0xba, 0x7d, 0x58, 0x2c, 0xba, 0xdc, 0xbf, 0x01, 0x60, 0x27, 0x0f,
+	0xd8, 0x29, 0x06, 0xbc, 0xb1, 0x85, 0x63, 0x00, 0x77, 0xa1, 0x95, 0x7d, 0x25, 0x56, 0x70, 0x27,
+	0x34, 0x13, 0x06, 0x81, 0x27, 0x26, 0xe8, 0x7c, 0xfe, 0x5d, 0x18, 0x63, 0xea, 0xf7, 0x12, 0x98,
+	0x31, 0xb7, 0x3d, 0x3f, 0x81, 0x9a, 0x94, 0x60, 0xa7, 0x11, 0xd7, 0x51, 0x57, 0x05, 0xf4, 0x37,
+	0x2d, 0xf0, 0x0e, 0xac, 0xf8, 0xab, 0x21, 0x8e, 0x2c, 0x71, 0x4e, 0xa9, 0x19, 0x29, 0x0a, 0x1f,
+	0x85, 0x1b, 0x9a, 0x03, 0x7a, 0x8c, 0x51, 0x21, 0xef, 0x86, 0x2f, 0xe8, 0x91, 0x67, 0x0d, 0xe0,
+	0xe2, 0xca, 0xb3, 0x7c, 0xce, 0xe5, 0x41, 0x5f, 0x67, 0x14, 0xca, 0xd6, 0xff, 0xb0, 0x04, 0xcd,
+	0xcc, 0xa3, 0x17, 0xf4, 0x1a, 0x7d, 0xbe, 0xea, 0x86, 0x26, 0xf6, 0xad, 0x13, 0x0f, 0x73, 0x3b,
+	0x6b, 0xf4, 0xa1, 0xaa, 0x1b, 0xee, 0x72, 0x12, 0x5d, 0x2a, 0x38, 0xa6, 0x94, 0xe1, 0x36, 0xcd,
+	0x31, 0xa2, 0x14, 0xda, 0x84, 0x76, 0x46, 0xc8, 0xbc, 0xe8, 0x88, 0x2b, 0x86, 0x56, 0x5a, 0xee,
+	0xb8, 0xa3, 0xff, 0x43, 0x09, 0x16, 0x8b, 0x1e, 0xad, 0xa1, 0x37, 0x53, 0x39, 0xeb, 0x76, 0x61,
+	0x81, 0x44, 0xe4, 0xca, 0x0f, 0xd5, 0xdc, 0xe5, 0x67, 0xe0, 0x37, 0x27, 0x3c, 0x85, 0xfb, 0xae,
+	0x67, 0xee, 0x87, 0x79, 0xe3, 0xd5, 0x85, 0xfb, 0xcd, 0x8c, 0xd7, 0x77, 0xa0, 0x9d, 0xa7, 0x67,
+	0xef, 0x57, 0x4a, 0xb9, 0xfb, 0x95, 0xc2, 0xbb, 0xa3, 0xbf, 0x2f, 0xc1, 0x7c, 0xee, 0x55, 0x1d,
+	0xd2, 0x53, 0x26, 0xa0, 0xfc, 0xa3, 0x39, 0xe1, 0xba, 0xf7, 0x73, 0xae, 0xd3, 0x8b, 0x5f, 0xe8,
+	0x7d, 0xd7, 0x5e, 0x7b, 0x92, 0xb2, 0x56, 0x38, 0xec, 0x06, 0xd6, 0xea, 0xaf, 0x41, 0x23, 0x45,
+	0x2a, 0xbc, 0x7e, 0x3c, 0x02, 0xe0, 0x8f, 0xe3, 0x8e, 0xc4, 0xe9, 0x9e, 0x46, 0xae, 0x88, 0x62,
+	0xf6, 0x9b, 0x59, 0x45, 0x23, 0x50, 0x84, 0x2d, 0x6f, 0x50, 0x97, 0xab, 0x87, 0x0b, 0xf2, 0x2e,
+	0x4c, 0x11, 0xf4, 0x7f, 0x2b, 0x43, 0x23, 0xf5, 0x5c, 0x10, 0xbd, 0x91, 0xaa, 0x24, 0x24, 0xab,
+	0x1c, 0x93, 0x48, 0xee, 0xa1, 0xd1, 0xbb, 0x74, 0x2e, 0xf1, 0x27, 0xa4, 0x4c, 0x9a, 0xaf, 0x89,
+	0xb7, 0x54, 0xa2, 0xa0, 0x53, 0x9e, 0x89, 0x83, 0x1b, 0xca, 0xdf, 0xd4, 0x8d, 0x4e, 0x4c, 0xe4,
+	0x61, 0xd5, 0x89, 0x09, 0xd2, 0xa1, 0xc9, 0x4a, 0xa9, 0x81, 0xc3, 0xcb, 0x59, 0x62, 0x1a, 0xd3,
+	0xab, 0x95, 0x7e, 0xe0, 0xb0, 0xea, 0x15, 0xbd, 0x30, 0x50, 0x32, 0x6e, 0x28, 0xef, 0xe4, 0x84,
+	0x44, 0x2f, 0xa4, 0xc7, 0x85, 0xd8, 0x1a, 0x60, 0x33, 0x1e, 0x9e, 0xd0, 0x0b, 0x85, 0x59, 0x9e,
+	0x45, 0x28, 0xe9, 0x90, 0x51, 0xe8, 0xbc, 0xa7, 0x1b, 0xed, 0x60, 0x48, 0xce, 0x02, 0xd7, 0x3f,
+	0x63, 0x17, 0x55, 0x35, 0xa3, 0xe1, 0x5b, 0x64, 0x5f, 0x90, 0x68, 0x45, 0xdf, 0x0b, 0x6c, 0xcb,
+	0x33, 0x65, 0x11, 0x81, 0xdd, 0x54, 0xd5, 0x8c, 0x26, 0xa3, 0xca, 0xdd, 0x04, 0x7a, 0x0c, 0x0d,
+	0xc2, 0xbe, 0x00, 0x1f, 0x34, 0x7f, 0x2d, 0x2d, 0x07, 0x9d, 0x7c, 0x1b, 0x03, 0x88, 0xfa, 0xad,
+	0x6f, 0x08, 0xf7, 0x8a, 0x58, 0x10, 0x3e, 0x28, 0x2b, 0x1f, 0xe8, 0xff, 0x51, 0x82, 0x95, 0xb1,
+	0xcf, 0x27, 0x59, 0x20, 0x04, 0x0e, 0xff, 0x1c, 0x34, 0x10, 0x02, 0x47, 0x1d, 0xfa, 0xcb, 0xc9,
+	0xa1, 0x3f, 0xb3, 0x20, 0x4d, 0xe7, 0x36, 0x0e, 0x9b, 0xd0, 0x0e, 0xad, 0x08, 0xfb, 0xc4, 0x74,
+	0x30, 0x2b, 0x1c, 0xba, 0xa1, 0xf0, 0x73, 0x8b, 0xd3, 0x77, 0x18, 0x99, 0xef, 0xab, 0x07, 0x96,
+	0x4d, 0xf3, 0x19, 0xf7, 0x72, 0x65, 0x60, 0xd9, 0xc7, 0x9d, 0xec, 0x62, 0x52, 0xcd, 0xed, 0x3c,
+	0xbe, 0x0f, 0x28, 0x8f, 0x7e, 0xd1, 0x61, 0x5f, 0xa1, 0x6e, 0xb4, 0xb3, 0xf8, 0x17, 0x1d, 0xfd,
+	0x9d, 0xc2, 0xb1, 0x0a, 0xdf, 0x14, 0x8c, 0x55, 0xff, 0x59, 0x09, 0x6e, 0x8f, 0x79, 0xc4, 0x39,
+	0x71, 0x01, 0xcc, 0xee, 0xe8, 0xca, 0xf9, 0x1d, 0xdd, 0x43, 0x58, 0x70, 0x7d, 0x82, 0xa3, 0x53,
+	0x8b, 0x5b, 0x9c, 0x71, 0xdd, 0x2d, 0xc5, 0x92, 0x87, 0x43, 0xfd, 0x49, 0x81, 0x15, 0x2f, 0x5f,
+	0x86, 0xf5, 0x3f, 0x2f, 0xc1, 0xca, 0xd8, 0xe7, 0x8a, 0x13, 0xed, 0xd7, 0xa1, 0x99, 0xd8, 0x4f,
+	0xbf, 0x08, 0x1f, 0x42, 0x43, 0x0d, 0xe1, 0xb8, 0x33, 0x32, 0x88, 0xce, 0xd8, 0x41, 0xf0, 0x75,
+	0xff, 0x69, 0xa1, 0x31, 0x37, 0x18, 0xc6, 0x3f, 0x96, 0x60, 0xa9, 0xf0, 0x39, 0x2a, 0xbd, 0x8f,
+	0x91, 0xe5, 0x68, 0xdb, 0x1b, 0xc6, 0x04, 0x47, 0x26, 0x5d, 0xd9, 0x65, 0x29, 0x77, 0x41, 0x30,
+	0xb7, 0x39, 0x6f, 0x9b, 0xb2, 0xd0, 0x56, 0xf2, 0x32, 0x1b, 0x5f, 0x11, 0x1c, 0xd1, 0xba, 0x36,
+	0x57, 0x2a, 0x8b, 0x8b, 0x52, 0xce, 0xdd, 0x15, 0x4c, 0xae, 0xf5, 0x43, 0x58, 0x95, 0x5a, 0x74,
+	0x2e, 0x9e, 0x58, 0x9e, 0xe5, 0xdb, 0xaa, 0x3b, 0x7e, 0x92, 0xd4, 0x84, 0xc4, 0xf3, 0x94, 0x00,
+	0xd3, 0xd6, 0xbf, 0x80, 0x86, 0x58, 0x8a, 0x68, 0xc1, 0x12, 0xad, 0x26, 0x65, 0x50, 0x39, 0x58,
+	0xd9, 0xa6, 0x51, 0x48, 0x65, 0x64, 0xc5, 0x52, 0xca, 0xd3, 0x6c, 0xc3, 0xe8, 0xd3, 0x8c, 0xae,
+	0xda, 0x74, 0xfe, 0x36, 0x33, 0xcf, 0x63, 0x0b, 0x0f, 0xca, 0x99, 0x75, 0xaf, 0x5c, 0xb0, 0xee,
+	0xa9, 0x87, 0x40, 0x75, 0x91, 0x62, 0xd7, 0x00, 0xa4, 0x4b, 0xd5, 0x84, 0xad, 0x0b, 0x4a, 0x2f,
+	0xa4, 0x07, 0xd3, 0x8c, 0x1f, 0x54, 0x6a, 0x6c, 0xa5, 0xc9, 0xbd, 0x90, 0xa6, 0x3f, 0xe5, 0x66,
+	0x37, 0x94, 0x55, 0xbd, 0x86, 0xa4, 0xf5, 0xc2, 0x18, 0x6d, 0x42, 0x25, 0x7d, 0xe5, 0x8f, 0xb2,
+	0x8b, 0x3a, 0x1d, 0xa5, 0xc1, 0x05, 0xf4, 0xae, 0x1a, 0x6b, 0x6a, 0xce, 0xbe, 0xd2, 0x58, 0x1f,
+	0x6c, 0xd2, 0x27, 0x4c, 0xf2, 0xf9, 0xc3, 0x2c, 0x4c, 0x77, 0xfb, 0x5f, 0xb4, 0xa7, 0x50, 0x0d,
+	0x66, 0x7a, 0x07, 0xc7, 0x5b, 0xed, 0x19, 0xf1, 0xab, 0xd3, 0xae, 0x3e, 0xf8, 0x05, 0x7d, 0xf9,
+	0x25, 0x17, 0x1e, 0xd4, 0x84, 0xfa, 0x76, 0x6f, 0xc7, 0x30, 0x7b, 0xfd, 0x8f, 0xf6, 0xdb, 0x53,
+	0x68, 0x01, 0xe6, 0x8d, 0xdd, 0x17, 0xfb, 0x47, 0xbb, 0xe6, 0xe7, 0xfb, 0xc6, 0xa7, 0xcf, 0xf7,
+	0xbb, 0x3b, 0xed, 0x12, 0x7d, 0x09, 0x25, 0x88, 0x7b, 0xfb, 0x87, 0x47, 0xed, 0x32, 0x42, 0xd0,
+	0x7a, 0xbe, 0xbf, 0xdd, 0x7d, 0x9e, 0x08, 0x4d, 0xa3, 0x16, 0x00, 0xa7, 0x31, 0x99, 0x19, 0x74,
+	0x0b, 0x9a, 0x42, 0xe9, 0xe8, 0xb3, 0x7e, 0x7f, 0xf7, 0x79, 0xbb, 0x82, 0xda, 0x30, 0xc7, 0x45,
+	0x04, 0xa5, 0xfa, 0xe0, 0x3d, 0x80, 0x64, 0x55, 0xa3, 0x36, 0xf6, 0xf7, 0xfb, 0xbb, 0xed, 0x29,
+	0x34, 0x07, 0xb5, 0xfe, 0xbe, 0xb9, 0xdb, 0xdf, 0xee, 0x1e, 0xb4, 0x4b, 0xa8, 0x0e, 0x15, 0x96,
+	0xde, 0xda, 0x65, 0x3e, 0x8c, 0xde, 0x41, 0x7b, 0xfa, 0xf1, 0x07, 0x00, 0xfc, 0xed, 0x0b, 0xfb,
+	0x37, 0xae, 0x47, 0x30, 0xc3, 0xfe, 0x2a, 0x27, 0x27, 0xff, 0x1c, 0xb6, 0x2a, 0x69, 0xa9, 0x7f,
+	0x10, 0x7b, 0x54, 0x7a, 0xb6, 0xf1, 0xcb, 0xaf, 0xd7, 0x4b, 0xbf, 0xfe, 0x7a, 0xbd, 0xf4, 0xef,
+	0x5f, 0xaf, 0x97, 0xfe, 0xea, 0x9b, 0xf5, 0xa9, 0x5f, 0x7f, 0xb3, 0x3e, 0xf5, 0xaf, 0xdf, 0xac,
+	0x4f, 0xfd, 0xa8, 0xc2, 0xee, 0xf3, 0x4f, 0xaa, 0xec, 0xcf, 0xbb, 0xff, 0x33, 0x00, 0xab, 0xbe,
+	0x8b, 0x0d, 0x86, 0x36, 0x00, 0x00,
 }
@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	. "github.com/projectcalico/calico/felix/iptables"
+)
+
+// Traffic classes that can be allowed while the dataplane has failed open.
+const (
+	// TrafficClassWorkloadIngress is traffic to workloads, whether forwarded or from the host.
+	TrafficClassWorkloadIngress = "WorkloadIngress"
+	// TrafficClassWorkloadEgress is traffic from workloads that is forwarded.
+	TrafficClassWorkloadEgress = "WorkloadEgress"
+	// TrafficClassWorkloadToHost is traffic from workloads to the host itself.
+	TrafficClassWorkloadToHost = "WorkloadToHost"
+)
+
+// ApplyFailureRules returns the rules to insert at the start of the filter table's kernel chains,
+// keyed on chain name, once we've given up on programming the dataplane.  If failOpen is true,
+// the rules accept workload traffic in the given classes, bypassing (possibly stale) policy;
+// other traffic is left to the rules that are already programmed.  Otherwise, the rules drop all
+// traffic to and from workloads.
+func (r *DefaultRuleRenderer) ApplyFailureRules(failOpen bool, classes []string) map[string][]Rule {
+	action := Action(DropAction{})
+	comment := "Dataplane programming failed; failing closed"
+	if failOpen {
+		action = AcceptAction{}
+		comment = "Dataplane programming failed; failing open"
+	} else {
+		classes = []string{
+			TrafficClassWorkloadIngress,
+			TrafficClassWorkloadEgress,
+			TrafficClassWorkloadToHost,
+		}
+	}
+
+	chainToRules := map[string][]Rule{}
+	for _, class := range classes {
+		for _, prefix := range r.WorkloadIfacePrefixes {
+			ifaceMatch := prefix + "+"
+			switch class {
+			case TrafficClassWorkloadIngress:
+				chainToRules["FORWARD"] = append(chainToRules["FORWARD"], Rule{
+					Match:   Match().OutInterface(ifaceMatch),
+					Action:  action,
+					Comment: []string{comment},
+				})
+				chainToRules["OUTPUT"] = append(chainToRules["OUTPUT"], Rule{
+					Match:   Match().OutInterface(ifaceMatch),
+					Action:  action,
+					Comment: []string{comment},
+				})
+			case TrafficClassWorkloadEgress:
+				chainToRules["FORWARD"] = append(chainToRules["FORWARD"], Rule{
+					Match:   Match().InInterface(ifaceMatch),
+					Action:  action,
+					Comment: []string{comment},
+				})
+			case TrafficClassWorkloadToHost:
+				chainToRules["INPUT"] = append(chainToRules["INPUT"], Rule{
+					Match:   Match().InInterface(ifaceMatch),
+					Action:  action,
+					Comment: []string{comment},
+				})
+			}
+		}
+	}
+	return chainToRules
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ipsets"
+	. "github.com/projectcalico/calico/felix/iptables"
+	. "github.com/projectcalico/calico/felix/rules"
+)
+
+var _ = Describe("Apply failure rules", func() {
+	var rr RuleRenderer
+
+	BeforeEach(func() {
+		rr = NewRenderer(Config{
+			WorkloadIfacePrefixes: []string{"cali", "tap"},
+			IPSetConfigV4:         ipsets.NewIPVersionConfig(ipsets.IPFamilyV4, "cali", nil, nil),
+			IPSetConfigV6:         ipsets.NewIPVersionConfig(ipsets.IPFamilyV6, "cali", nil, nil),
+			IptablesMarkAccept:    0x10,
+			IptablesMarkPass:      0x20,
+			IptablesMarkScratch0:  0x40,
+			IptablesMarkScratch1:  0x80,
+			IptablesMarkEndpoint:  0xff00,
+		})
+	})
+
+	failOpen := []string{"Dataplane programming failed; failing open"}
+	failClosed := []string{"Dataplane programming failed; failing closed"}
+
+	It("should accept only the given classes when failing open", func() {
+		Expect(rr.ApplyFailureRules(true, []string{TrafficClassWorkloadEgress})).To(Equal(map[string][]Rule{
+			"FORWARD": {
+				{Match: Match().InInterface("cali+"), Action: AcceptAction{}, Comment: failOpen},
+				{Match: Match().InInterface("tap+"), Action: AcceptAction{}, Comment: failOpen},
+			},
+		}))
+	})
+
+	It("should accept traffic to and from workloads when failing open for all classes", func() {
+		Expect(rr.ApplyFailureRules(true, []string{
+			TrafficClassWorkloadIngress,
+			TrafficClassWorkloadToHost,
+		})).To(Equal(map[string][]Rule{
+			"FORWARD": {
+				{Match: Match().OutInterface("cali+"), Action: AcceptAction{}, Comment: failOpen},
+				{Match: Match().OutInterface("tap+"), Action: AcceptAction{}, Comment: failOpen},
+			},
+			"OUTPUT": {
+				{Match: Match().OutInterface("cali+"), Action: AcceptAction{}, Comment: failOpen},
+				{Match: Match().OutInterface("tap+"), Action: AcceptAction{}, Comment: failOpen},
+			},
+			"INPUT": {
+				{Match: Match().InInterface("cali+"), Action: AcceptAction{}, Comment: failOpen},
+				{Match: Match().InInterface("tap+"), Action: AcceptAction{}, Comment: failOpen},
+			},
+		}))
+	})
+
+	It("should render nothing when failing open with no classes", func() {
+		Expect(rr.ApplyFailureRules(true, nil)).To(BeEmpty())
+	})
+
+	It("should drop all workload traffic when failing closed", func() {
+		Expect(rr.ApplyFailureRules(false, []string{TrafficClassWorkloadEgress})).To(Equal(map[string][]Rule{
+			"FORWARD": {
+				{Match: Match().OutInterface("cali+"), Action: DropAction{}, Comment: failClosed},
+				{Match: Match().OutInterface("tap+"), Action: DropAction{}, Comment: failClosed},
+				{Match: Match().InInterface("cali+"), Action: DropAction{}, Comment: failClosed},
+				{Match: Match().InInterface("tap+"), Action: DropAction{}, Comment: failClosed},
+			},
+			"OUTPUT": {
+				{Match: Match().OutInterface("cali+"), Action: DropAction{}, Comment: failClosed},
+				{Match: Match().OutInterface("tap+"), Action: DropAction{}, Comment: failClosed},
+			},
+			"INPUT": {
+				{Match: Match().InInterface("cali+"), Action: DropAction{}, Comment: failClosed},
+				{Match: Match().InInterface("tap+"), Action: DropAction{}, Comment: failClosed},
+			},
+		}))
+	})
+})
@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/projectcalico/calico/felix/iptables"
+)
+
+// maxCachedEndpointRuleSets is the number of distinct endpoint rule sets that we keep in each
+// generation of the endpointRulesCache.  Typically, there are far fewer distinct sets of policies
+// than there are endpoints.
+const maxCachedEndpointRuleSets = 10000
+
+// endpointRulesCache caches the rules of endpoint chains, keyed on a hash of the inputs that
+// determine their content.  Endpoints that have the same policies and profiles get the same
+// rules (only the chain name differs) so, rather than recompiling the rules for each endpoint,
+// we share one slice between them, which saves CPU and memory.  Shared slices must not be
+// modified since they may also be in other endpoints' chains.
+//
+// The cache has two generations; when the current generation fills up it becomes the previous
+// generation and entries that are still in use are promoted back into the new one on lookup.
+type endpointRulesCache struct {
+	lock     sync.Mutex
+	current  map[string][]iptables.Rule
+	previous map[string][]iptables.Rule
+}
+
+func newEndpointRulesCache() *endpointRulesCache {
+	return &endpointRulesCache{
+		current: map[string][]iptables.Rule{},
+	}
+}
+
+// GetOrCompute returns the cached rules for the given key, calling compute to calculate them
+// if they're not in the cache.
+func (c *endpointRulesCache) GetOrCompute(key string, compute func() []iptables.Rule) []iptables.Rule {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if rules, ok := c.current[key]; ok {
+		return rules
+	}
+	rules, ok := c.previous[key]
+	if !ok {
+		rules = compute()
+	}
+	if len(c.current) >= maxCachedEndpointRuleSets {
+		c.previous = c.current
+		c.current = map[string][]iptables.Rule{}
+	}
+	c.current[key] = rules
+	return rules
+}
+
+// endpointRulesKey calculates the cache key for an endpoint chain from the inputs to
+// endpointIptablesChain, other than the interface name.
+func endpointRulesKey(
+	policyNames []string,
+	profileIds []string,
+	policyPrefix PolicyChainNamePrefix,
+	profilePrefix ProfileChainNamePrefix,
+	failsafeChain string,
+	chainType endpointChainType,
+	adminUp bool,
+	allowAction iptables.Action,
+	allowVXLANEncap bool,
+	allowIPIPEncap bool,
+) string {
+	s := sha256.New224()
+	// Policy and profile names can't contain newlines so they're safe to use as a separator.
+	_, _ = fmt.Fprintf(s, "%s\n%s\n%s\n%d\n%t\n%T:%v\n%t\n%t\n",
+		policyPrefix, profilePrefix, failsafeChain, chainType, adminUp,
+		allowAction, allowAction, allowVXLANEncap, allowIPIPEncap)
+	_, _ = fmt.Fprintf(s, "policies:%d\n%s\n", len(policyNames), strings.Join(policyNames, "\n"))
+	_, _ = fmt.Fprintf(s, "profiles:%d\n%s\n", len(profileIds), strings.Join(profileIds, "\n"))
+	return string(s.Sum(nil))
+}
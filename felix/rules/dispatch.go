@@ -43,7 +43,7 @@ func (r *DefaultRuleRenderer) WorkloadDispatchChains(
 			Comment: []string{"Unknown interface"},
 		},
 	}
-	return r.interfaceNameDispatchChains(
+	chains := r.interfaceNameDispatchChains(
 		names,
 		WorkloadFromEndpointPfx,
 		WorkloadToEndpointPfx,
@@ -52,6 +52,8 @@ func (r *DefaultRuleRenderer) WorkloadDispatchChains(
 		endRules,
 		endRules,
 	)
+	r.prependCachedVerdictRules(chains)
+	return chains
 }
 
 func (r *DefaultRuleRenderer) WorkloadInterfaceAllowChains(
@@ -118,6 +120,11 @@ func (r *DefaultRuleRenderer) EndpointMarkDispatchChains(
 	}
 	hepNames := make([]string, 0, len(hepEndpoints))
 	for ifaceName := range hepEndpoints {
+		if r.isTrustedHostInterface(ifaceName) {
+			// Leave forwarded traffic from trusted interfaces with the non-Calico endpoint
+			// mark so that it skips host endpoint policy.
+			continue
+		}
 		hepNames = append(hepNames, ifaceName)
 	}
 
@@ -211,8 +218,10 @@ func (r *DefaultRuleRenderer) hostDispatchChains(
 		}
 	}
 
-	if directions == "from" {
-		return r.interfaceNameDispatchChains(
+	var chains []*Chain
+	switch {
+	case directions == "from":
+		chains = r.interfaceNameDispatchChains(
 			names,
 			HostFromEndpointPfx,
 			"",
@@ -221,10 +230,8 @@ func (r *DefaultRuleRenderer) hostDispatchChains(
 			fromEndRules,
 			toEndRules,
 		)
-	}
-
-	if directions == "to" {
-		return r.interfaceNameDispatchChains(
+	case directions == "to":
+		chains = r.interfaceNameDispatchChains(
 			names,
 			"",
 			HostToEndpointPfx,
@@ -233,10 +240,8 @@ func (r *DefaultRuleRenderer) hostDispatchChains(
 			fromEndRules,
 			toEndRules,
 		)
-	}
-
-	if !applyOnForward {
-		return r.interfaceNameDispatchChains(
+	case !applyOnForward:
+		chains = r.interfaceNameDispatchChains(
 			names,
 			HostFromEndpointPfx,
 			HostToEndpointPfx,
@@ -245,28 +250,134 @@ func (r *DefaultRuleRenderer) hostDispatchChains(
 			fromEndRules,
 			toEndRules,
 		)
+	default:
+		chains = append(
+			r.interfaceNameDispatchChains(
+				names,
+				HostFromEndpointPfx,
+				HostToEndpointPfx,
+				ChainDispatchFromHostEndpoint,
+				ChainDispatchToHostEndpoint,
+				fromEndRules,
+				toEndRules,
+			),
+			r.interfaceNameDispatchChains(
+				names,
+				HostFromEndpointForwardPfx,
+				HostToEndpointForwardPfx,
+				ChainDispatchFromHostEndPointForward,
+				ChainDispatchToHostEndpointForward,
+				fromEndForwardRules,
+				toEndForwardRules,
+			)...,
+		)
 	}
+	r.prependTrustedInterfaceRules(chains)
+	r.prependCachedVerdictRules(chains)
+	return chains
+}
 
-	return append(
-		r.interfaceNameDispatchChains(
-			names,
-			HostFromEndpointPfx,
-			HostToEndpointPfx,
-			ChainDispatchFromHostEndpoint,
-			ChainDispatchToHostEndpoint,
-			fromEndRules,
-			toEndRules,
-		),
-		r.interfaceNameDispatchChains(
-			names,
-			HostFromEndpointForwardPfx,
-			HostToEndpointForwardPfx,
-			ChainDispatchFromHostEndPointForward,
-			ChainDispatchToHostEndpointForward,
-			fromEndForwardRules,
-			toEndForwardRules,
-		)...,
-	)
+// prependTrustedInterfaceRules adds rules for the trusted host interfaces to the top of the root
+// host endpoint dispatch chains.  Their traffic skips all the endpoint rules and goes to
+// ChainTrustedHostInterface, which marks it as accepted.  Since that's a goto, the packet then
+// returns straight to the chain that jumped to the dispatch chain.
+func (r *DefaultRuleRenderer) prependTrustedInterfaceRules(chains []*Chain) {
+	if len(r.TrustedHostInterfaces) == 0 {
+		return
+	}
+	for _, chain := range chains {
+		var getMatch func(name string) MatchCriteria
+		switch chain.Name {
+		case ChainDispatchFromHostEndpoint, ChainDispatchFromHostEndPointForward:
+			getMatch = func(name string) MatchCriteria { return Match().InInterface(name) }
+		case ChainDispatchToHostEndpoint, ChainDispatchToHostEndpointForward:
+			getMatch = func(name string) MatchCriteria { return Match().OutInterface(name) }
+		default:
+			continue
+		}
+		rules := make([]Rule, 0, len(r.TrustedHostInterfaces)+len(chain.Rules))
+		for _, name := range r.TrustedHostInterfaces {
+			rules = append(rules, Rule{
+				Match:   getMatch(name),
+				Action:  GotoAction{Target: ChainTrustedHostInterface},
+				Comment: []string{"Trusted interface"},
+			})
+		}
+		chain.Rules = append(rules, chain.Rules...)
+	}
+}
+
+func (r *DefaultRuleRenderer) isTrustedHostInterface(ifaceName string) bool {
+	for _, name := range r.TrustedHostInterfaces {
+		if name == ifaceName {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedHostInterfaceChain returns the chain that marks traffic on the trusted host interfaces
+// as accepted, or nil if there are no trusted interfaces.  It's needed in each table that has host
+// endpoint dispatch chains.
+func (r *DefaultRuleRenderer) trustedHostInterfaceChain() *Chain {
+	if len(r.TrustedHostInterfaces) == 0 {
+		return nil
+	}
+	return &Chain{
+		Name: ChainTrustedHostInterface,
+		Rules: []Rule{{
+			Action: SetMarkAction{Mark: r.IptablesMarkAccept},
+		}},
+	}
+}
+
+// prependCachedVerdictRules adds a rule to the top of the root dispatch chains that cali-FORWARD
+// jumps to, which sends packets of connections that policy has already accepted (as recorded in
+// the connmark by StaticFilterForwardAppendRules) to ChainCachedVerdictAccept.  That saves them
+// from walking the rest of the dispatch tree and the endpoint chains.  The workload dispatch
+// chains are also used for traffic to and from the host but that is never marked.
+func (r *DefaultRuleRenderer) prependCachedVerdictRules(chains []*Chain) {
+	if r.VerdictCacheConnmark == 0 {
+		return
+	}
+	for _, chain := range chains {
+		switch chain.Name {
+		case ChainFromWorkloadDispatch, ChainToWorkloadDispatch,
+			ChainDispatchFromHostEndPointForward, ChainDispatchToHostEndpointForward:
+		default:
+			continue
+		}
+		rule := Rule{
+			// Checking the conntrack state too means that we still drop invalid packets.
+			Match: Match().ConntrackState("RELATED,ESTABLISHED").
+				ConnMarkMatchesWithMask(r.VerdictCacheConnmark, r.VerdictCacheConnmark),
+			Action:  GotoAction{Target: ChainCachedVerdictAccept},
+			Comment: []string{"Connection already accepted"},
+		}
+		chain.Rules = append([]Rule{rule}, chain.Rules...)
+	}
+}
+
+// cachedVerdictAcceptChain returns the chain that accepts packets of connections that policy has
+// already accepted, or nil if verdict caching is disabled.  Like the endpoint chains' conntrack
+// rules, it flags the packet as accepted in case the allow action is to return.
+func (r *DefaultRuleRenderer) cachedVerdictAcceptChain() *Chain {
+	if r.VerdictCacheConnmark == 0 {
+		return nil
+	}
+	var rules []Rule
+	if r.filterAllowAction != (AcceptAction{}) {
+		rules = append(rules, Rule{
+			Action: SetMarkAction{Mark: r.IptablesMarkAccept},
+		})
+	}
+	rules = append(rules, Rule{
+		Action: r.filterAllowAction,
+	})
+	return &Chain{
+		Name:  ChainCachedVerdictAccept,
+		Rules: rules,
+	}
 }
 
 func (r *DefaultRuleRenderer) interfaceNameDispatchChains(
@@ -1120,6 +1120,181 @@ var _ = Describe("Dispatch chains", func() {
 	}
 })
 
+var _ = Describe("Dispatch chains with trusted host interfaces", func() {
+	var renderer RuleRenderer
+	BeforeEach(func() {
+		renderer = NewRenderer(Config{
+			IPSetConfigV4:               ipsets.NewIPVersionConfig(ipsets.IPFamilyV4, "cali", nil, nil),
+			IPSetConfigV6:               ipsets.NewIPVersionConfig(ipsets.IPFamilyV6, "cali", nil, nil),
+			IptablesMarkAccept:          0x8,
+			IptablesMarkPass:            0x10,
+			IptablesMarkScratch0:        0x20,
+			IptablesMarkScratch1:        0x40,
+			IptablesMarkEndpoint:        0xff00,
+			IptablesMarkNonCaliEndpoint: 0x0100,
+			WorkloadIfacePrefixes:       []string{"cali"},
+			TrustedHostInterfaces:       []string{"storage0", "storage1"},
+		})
+	})
+
+	trustedRule := func(match iptables.MatchCriteria) iptables.Rule {
+		return iptables.Rule{
+			Match:   match,
+			Action:  iptables.GotoAction{Target: "cali-trusted-iface"},
+			Comment: []string{"Trusted interface"},
+		}
+	}
+
+	It("should send trusted interfaces to the trusted chain ahead of the endpoints", func() {
+		input := map[string]proto.HostEndpointID{"eth0": {}}
+		Expect(renderer.HostDispatchChains(input, "", true)).To(Equal([]*iptables.Chain{
+			{
+				Name: "cali-from-host-endpoint",
+				Rules: []iptables.Rule{
+					trustedRule(iptables.Match().InInterface("storage0")),
+					trustedRule(iptables.Match().InInterface("storage1")),
+					inboundGotoRule("eth0", "cali-fh-eth0"),
+				},
+			},
+			{
+				Name: "cali-to-host-endpoint",
+				Rules: []iptables.Rule{
+					trustedRule(iptables.Match().OutInterface("storage0")),
+					trustedRule(iptables.Match().OutInterface("storage1")),
+					outboundGotoRule("eth0", "cali-th-eth0"),
+				},
+			},
+			{
+				Name: "cali-from-hep-forward",
+				Rules: []iptables.Rule{
+					trustedRule(iptables.Match().InInterface("storage0")),
+					trustedRule(iptables.Match().InInterface("storage1")),
+					inboundGotoRule("eth0", "cali-fhfw-eth0"),
+				},
+			},
+			{
+				Name: "cali-to-hep-forward",
+				Rules: []iptables.Rule{
+					trustedRule(iptables.Match().OutInterface("storage0")),
+					trustedRule(iptables.Match().OutInterface("storage1")),
+					outboundGotoRule("eth0", "cali-thfw-eth0"),
+				},
+			},
+		}))
+	})
+
+	It("should skip trusted interfaces in the endpoint mark chains", func() {
+		epMarkMapper := NewEndpointMarkMapper(0xff00, 0x0100)
+		chains := renderer.EndpointMarkDispatchChains(epMarkMapper, nil,
+			map[string]proto.HostEndpointID{"eth0": {}, "storage0": {}})
+		for _, chain := range chains {
+			for _, rule := range chain.Rules {
+				Expect(rule.Match.Render()).NotTo(ContainSubstring("storage0"))
+			}
+		}
+	})
+
+	It("should mark traffic as accepted in the trusted chain in each table", func() {
+		expected := &iptables.Chain{
+			Name:  "cali-trusted-iface",
+			Rules: []iptables.Rule{{Action: iptables.SetMarkAction{Mark: 0x8}}},
+		}
+		Expect(renderer.StaticFilterTableChains(4)).To(ContainElement(expected))
+		Expect(renderer.StaticMangleTableChains(4)).To(ContainElement(expected))
+		Expect(renderer.StaticRawTableChains(4)).To(ContainElement(expected))
+	})
+})
+
+var _ = Describe("Dispatch chains with verdict caching", func() {
+	var config Config
+	BeforeEach(func() {
+		config = Config{
+			IPSetConfigV4:               ipsets.NewIPVersionConfig(ipsets.IPFamilyV4, "cali", nil, nil),
+			IPSetConfigV6:               ipsets.NewIPVersionConfig(ipsets.IPFamilyV6, "cali", nil, nil),
+			IptablesMarkAccept:          0x8,
+			IptablesMarkPass:            0x10,
+			IptablesMarkScratch0:        0x20,
+			IptablesMarkScratch1:        0x40,
+			IptablesMarkEndpoint:        0xff00,
+			IptablesMarkNonCaliEndpoint: 0x0100,
+			WorkloadIfacePrefixes:       []string{"cali"},
+			VerdictCacheConnmark:        0x1000000,
+		}
+	})
+
+	cachedRule := iptables.Rule{
+		Match:   iptables.Match().ConntrackState("RELATED,ESTABLISHED").ConnMarkMatchesWithMask(0x1000000, 0x1000000),
+		Action:  iptables.GotoAction{Target: "cali-cached-accept"},
+		Comment: []string{"Connection already accepted"},
+	}
+
+	It("should check the connmark at the top of the workload dispatch chains", func() {
+		renderer := NewRenderer(config)
+		input := map[proto.WorkloadEndpointID]*proto.WorkloadEndpoint{
+			{OrchestratorId: "k8s", WorkloadId: "pod1", EndpointId: "eth0"}: {Name: "cali1234"},
+		}
+		chains := renderer.WorkloadDispatchChains(input)
+		Expect(chains).To(HaveLen(2))
+		for _, chain := range chains {
+			Expect(chain.Name).To(BeElementOf("cali-from-wl-dispatch", "cali-to-wl-dispatch"))
+			Expect(chain.Rules[0]).To(Equal(cachedRule))
+			Expect(chain.Rules).To(HaveLen(3))
+		}
+	})
+
+	It("should only check the connmark in the forward host endpoint dispatch chains", func() {
+		renderer := NewRenderer(config)
+		input := map[string]proto.HostEndpointID{"eth0": {}}
+		for _, chain := range renderer.HostDispatchChains(input, "", true) {
+			switch chain.Name {
+			case "cali-from-hep-forward", "cali-to-hep-forward":
+				Expect(chain.Rules[0]).To(Equal(cachedRule))
+			default:
+				Expect(chain.Rules).NotTo(ContainElement(cachedRule))
+			}
+		}
+	})
+
+	It("should cache the verdict before accepting forwarded traffic", func() {
+		renderer := NewRenderer(config)
+		Expect(renderer.StaticFilterForwardAppendRules()[0]).To(Equal(iptables.Rule{
+			Match:   iptables.Match().MarkSingleBitSet(0x8),
+			Action:  iptables.SetConnMarkAction{Mark: 0x1000000, Mask: 0x1000000},
+			Comment: []string{"Cache accept verdict"},
+		}))
+	})
+
+	It("should accept cached connections directly with the ACCEPT allow action", func() {
+		config.IptablesFilterAllowAction = "ACCEPT"
+		renderer := NewRenderer(config)
+		Expect(renderer.StaticFilterTableChains(4)).To(ContainElement(&iptables.Chain{
+			Name:  "cali-cached-accept",
+			Rules: []iptables.Rule{{Action: iptables.AcceptAction{}}},
+		}))
+	})
+
+	It("should mark cached connections as accepted with the RETURN allow action", func() {
+		config.IptablesFilterAllowAction = "RETURN"
+		renderer := NewRenderer(config)
+		Expect(renderer.StaticFilterTableChains(4)).To(ContainElement(&iptables.Chain{
+			Name: "cali-cached-accept",
+			Rules: []iptables.Rule{
+				{Action: iptables.SetMarkAction{Mark: 0x8}},
+				{Action: iptables.ReturnAction{}},
+			},
+		}))
+	})
+
+	It("should render nothing extra when disabled", func() {
+		config.VerdictCacheConnmark = 0
+		renderer := NewRenderer(config)
+		for _, chain := range renderer.StaticFilterTableChains(4) {
+			Expect(chain.Name).NotTo(Equal("cali-cached-accept"))
+		}
+		Expect(renderer.StaticFilterForwardAppendRules()).To(HaveLen(2))
+	})
+})
+
 func gotoRule(target string) iptables.Rule {
 	return iptables.Rule{
 		Action: iptables.GotoAction{Target: target},
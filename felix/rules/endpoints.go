@@ -322,8 +322,33 @@ func (r *DefaultRuleRenderer) endpointIptablesChain(
 	allowVXLANEncap bool,
 	allowIPIPEncap bool,
 ) *Chain {
+	// The rules don't depend on the name of the endpoint so endpoints with the same policies
+	// and profiles share the same (immutable) slice of rules.
+	key := endpointRulesKey(policyNames, profileIds, policyPrefix, profilePrefix, failsafeChain,
+		chainType, adminUp, allowAction, allowVXLANEncap, allowIPIPEncap)
+	rules := r.endpointRules.GetOrCompute(key, func() []Rule {
+		return r.endpointIptablesRules(policyNames, profileIds, policyPrefix, profilePrefix,
+			failsafeChain, chainType, adminUp, allowAction, allowVXLANEncap, allowIPIPEncap)
+	})
+	return &Chain{
+		Name:  EndpointChainName(endpointPrefix, name),
+		Rules: rules,
+	}
+}
+
+func (r *DefaultRuleRenderer) endpointIptablesRules(
+	policyNames []string,
+	profileIds []string,
+	policyPrefix PolicyChainNamePrefix,
+	profilePrefix ProfileChainNamePrefix,
+	failsafeChain string,
+	chainType endpointChainType,
+	adminUp bool,
+	allowAction Action,
+	allowVXLANEncap bool,
+	allowIPIPEncap bool,
+) []Rule {
 	rules := []Rule{}
-	chainName := EndpointChainName(endpointPrefix, name)
 
 	if !adminUp {
 		// Endpoint is admin-down, drop all traffic to/from it.
@@ -332,10 +357,7 @@ func (r *DefaultRuleRenderer) endpointIptablesChain(
 			Action:  r.IptablesFilterDenyAction(),
 			Comment: []string{"Endpoint admin disabled"},
 		})
-		return &Chain{
-			Name:  chainName,
-			Rules: rules,
-		}
+		return rules
 	}
 
 	if chainType != chainTypeUntracked {
@@ -387,7 +409,7 @@ func (r *DefaultRuleRenderer) endpointIptablesChain(
 
 		// Then, jump to each policy in turn.
 		for _, polID := range policyNames {
-			polChainName := PolicyChainName(
+			polChainName := r.policyChainName(
 				policyPrefix,
 				&proto.PolicyID{Name: polID},
 			)
@@ -444,7 +466,7 @@ func (r *DefaultRuleRenderer) endpointIptablesChain(
 	if chainType == chainTypeNormal {
 		// Then, jump to each profile in turn.
 		for _, profileID := range profileIds {
-			profChainName := ProfileChainName(profilePrefix, &proto.ProfileID{Name: profileID})
+			profChainName := r.profileChainName(profilePrefix, &proto.ProfileID{Name: profileID})
 			rules = append(rules,
 				Rule{Action: JumpAction{Target: profChainName}},
 				// If policy marked packet as accepted, it returns, setting the
@@ -470,10 +492,7 @@ func (r *DefaultRuleRenderer) endpointIptablesChain(
 		//}
 	}
 
-	return &Chain{
-		Name:  chainName,
-		Rules: rules,
-	}
+	return rules
 }
 
 func (r *DefaultRuleRenderer) appendConntrackRules(rules []Rule, allowAction Action) []Rule {
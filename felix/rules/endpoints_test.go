@@ -183,6 +183,30 @@ var _ = Describe("Endpoints", func() {
 				})))
 			})
 
+			It("should share rules between endpoints with the same policies", func() {
+				chains1 := renderer.WorkloadEndpointToIptablesChains(
+					"cali1234", epMarkMapper, true, []string{"ai", "bi"}, []string{"ae", "be"}, []string{"prof1"})
+				chains2 := renderer.WorkloadEndpointToIptablesChains(
+					"cali5678", epMarkMapper, true, []string{"ai", "bi"}, []string{"ae", "be"}, []string{"prof1"})
+				Expect(chains1[0].Name).To(Equal("cali-tw-cali1234"))
+				Expect(chains2[0].Name).To(Equal("cali-tw-cali5678"))
+				Expect(chains2[0].Rules).To(Equal(chains1[0].Rules))
+				Expect(&chains2[0].Rules[0]).To(BeIdenticalTo(&chains1[0].Rules[0]))
+				Expect(&chains2[1].Rules[0]).To(BeIdenticalTo(&chains1[1].Rules[0]))
+				Expect(&chains1[1].Rules[0]).NotTo(BeIdenticalTo(&chains1[0].Rules[0]))
+			})
+
+			It("should not share rules between endpoints with different policies", func() {
+				chains1 := renderer.WorkloadEndpointToIptablesChains(
+					"cali1234", epMarkMapper, true, []string{"ai", "bi"}, nil, nil)
+				chains2 := renderer.WorkloadEndpointToIptablesChains(
+					"cali5678", epMarkMapper, true, []string{"bi", "ai"}, nil, nil)
+				chains3 := renderer.WorkloadEndpointToIptablesChains(
+					"cali9abc", epMarkMapper, false, []string{"ai", "bi"}, nil, nil)
+				Expect(chains2[0].Rules).NotTo(Equal(chains1[0].Rules))
+				Expect(chains3[0].Rules).NotTo(Equal(chains1[0].Rules))
+			})
+
 			It("should render a fully-loaded workload endpoint", func() {
 				Expect(renderer.WorkloadEndpointToIptablesChains(
 					"cali1234",
@@ -0,0 +1,173 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/ipsets"
+	"github.com/projectcalico/calico/felix/iptables"
+	"github.com/projectcalico/calico/felix/proto"
+)
+
+// ruleFamily captures the few places where rendering a policy rule differs between IPv4 and
+// IPv6: the names of IP sets and the flavour of ICMP match.  Everything else in the policy
+// renderer is family-neutral, so the v4 and v6 renderings of a rule can only differ in these
+// places.
+type ruleFamily struct {
+	ipVersion   uint8
+	ipSetConfig *ipsets.IPVersionConfig
+}
+
+func (r *DefaultRuleRenderer) ruleFamily(ipVersion uint8) ruleFamily {
+	return ruleFamily{
+		ipVersion:   ipVersion,
+		ipSetConfig: r.ipSetConfig(ipVersion),
+	}
+}
+
+func (f ruleFamily) nameForIPSet(ipSetID string) string {
+	return f.ipSetConfig.NameForMainIPSet(ipSetID)
+}
+
+func (f ruleFamily) icmpName() string {
+	if f.ipVersion == 4 {
+		return "ICMP"
+	}
+	return "ICMPv6"
+}
+
+func (f ruleFamily) matchICMPType(m iptables.MatchCriteria, t uint8, negated bool) iptables.MatchCriteria {
+	log.WithFields(log.Fields{"icmpType": t, "negated": negated}).Debugf(
+		"Adding %s type-only match.", f.icmpName())
+	switch {
+	case f.ipVersion == 4 && negated:
+		return m.NotICMPType(t)
+	case f.ipVersion == 4:
+		return m.ICMPType(t)
+	case negated:
+		return m.NotICMPV6Type(t)
+	default:
+		return m.ICMPV6Type(t)
+	}
+}
+
+func (f ruleFamily) matchICMPTypeAndCode(m iptables.MatchCriteria, t, c uint8, negated bool) iptables.MatchCriteria {
+	log.WithFields(log.Fields{"icmpType": t, "icmpCode": c, "negated": negated}).Debugf(
+		"Adding %s type/code match.", f.icmpName())
+	switch {
+	case f.ipVersion == 4 && negated:
+		return m.NotICMPTypeAndCode(t, c)
+	case f.ipVersion == 4:
+		return m.ICMPTypeAndCode(t, c)
+	case negated:
+		return m.NotICMPV6TypeAndCode(t, c)
+	default:
+		return m.ICMPV6TypeAndCode(t, c)
+	}
+}
+
+// isICMPProtocol returns true if the protocol match selects the family's flavour of ICMP.
+func (f ruleFamily) isICMPProtocol(protocol *proto.Protocol) bool {
+	switch p := protocol.GetNumberOrName().(type) {
+	case *proto.Protocol_Name:
+		name := strings.ToLower(p.Name)
+		if f.ipVersion == 4 {
+			return name == "icmp"
+		}
+		return name == "icmpv6" || name == "ipv6-icmp"
+	case *proto.Protocol_Number:
+		if f.ipVersion == 4 {
+			return p.Number == 1
+		}
+		return p.Number == 58
+	}
+	return false
+}
+
+// isDefinedICMPType returns true if the type is assigned in the family's IANA registry, or is
+// set aside there for experiments.
+func (f ruleFamily) isDefinedICMPType(t int32) bool {
+	if f.ipVersion == 4 {
+		// 0-43 are assigned, although many are deprecated; 253 and 254 are for experiments.
+		return t <= 43 || t == 253 || t == 254
+	}
+	// 1-4 are error messages and 128-161 informational messages; 100, 101, 200 and 201 are for
+	// private experimentation.
+	return (t >= 1 && t <= 4) || (t >= 128 && t <= 161) ||
+		t == 100 || t == 101 || t == 200 || t == 201
+}
+
+// ValidateICMPLists checks that the rule's lists of ICMP matches can be rendered for the given
+// IP version.  Rules that fail the check should be skipped, since rendering them without the
+// ICMP match would widen them.
+func ValidateICMPLists(ipVersion uint8, pRule *proto.Rule) error {
+	return ruleFamily{ipVersion: ipVersion}.validateICMPLists(pRule)
+}
+
+// validateICMPLists checks the rule's lists of ICMP type and type/code matches.  The kernel's
+// icmp and icmp6 matches only load alongside a protocol match for the same flavour of ICMP, so
+// an ICMP list in a rule for the other family (or for no particular protocol) would make
+// iptables-restore reject the whole table.  Type 255 is reserved by the kernel to mean "any
+// type" so it can't be matched on.  Types that the family doesn't define are rejected too,
+// since they are most likely types of the other family, such as 128 (ICMPv6 echo request) in
+// an IPv4 rule.
+func (f ruleFamily) validateICMPLists(pRule *proto.Rule) error {
+	numEntries := len(pRule.IcmpTypes) + len(pRule.IcmpTypeCodes) +
+		len(pRule.NotIcmpTypes) + len(pRule.NotIcmpTypeCodes)
+	if numEntries == 0 {
+		return nil
+	}
+	if !f.isICMPProtocol(pRule.Protocol) {
+		return fmt.Errorf("%s type matches require a %s protocol match, not %v",
+			f.icmpName(), f.icmpName(), pRule.Protocol)
+	}
+	checkType := func(t int32) error {
+		if t < 0 || t > 254 {
+			return fmt.Errorf("%s type %d out of range 0-254", f.icmpName(), t)
+		}
+		if !f.isDefinedICMPType(t) {
+			return fmt.Errorf("%d is not an %s type", t, f.icmpName())
+		}
+		return nil
+	}
+	checkTypeAndCode := func(tc *proto.IcmpTypeAndCode) error {
+		if err := checkType(tc.Type); err != nil {
+			return err
+		}
+		if tc.Code < 0 || tc.Code > 255 {
+			return fmt.Errorf("%s code %d out of range 0-255", f.icmpName(), tc.Code)
+		}
+		return nil
+	}
+	for _, types := range [][]int32{pRule.IcmpTypes, pRule.NotIcmpTypes} {
+		for _, t := range types {
+			if err := checkType(t); err != nil {
+				return err
+			}
+		}
+	}
+	for _, typeCodes := range [][]*proto.IcmpTypeAndCode{pRule.IcmpTypeCodes, pRule.NotIcmpTypeCodes} {
+		for _, tc := range typeCodes {
+			if err := checkTypeAndCode(tc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -93,6 +93,8 @@ func (r *DefaultRuleRenderer) NATOutgoingChain(natOutgoingActive bool, ipVersion
 				r.MakeNatOutgoingRule("tcp", iptables.ReturnAction{}, ipVersion),
 				r.MakeNatOutgoingRule("udp", portRangeSnatRule, ipVersion),
 				r.MakeNatOutgoingRule("udp", iptables.ReturnAction{}, ipVersion),
+				r.MakeNatOutgoingRule("sctp", portRangeSnatRule, ipVersion),
+				r.MakeNatOutgoingRule("sctp", iptables.ReturnAction{}, ipVersion),
 				r.MakeNatOutgoingRule("", defaultSnatRule, ipVersion),
 			}
 		} else {
@@ -112,6 +112,18 @@ var _ = Describe("NAT", func() {
 						SourceIPSet("cali40masq-ipam-pools").
 						NotDestIPSet("cali40all-ipam-pools").Protocol("udp"),
 				},
+				{
+					Action: MasqAction{ToPorts: "99-100"},
+					Match: Match().
+						SourceIPSet("cali40masq-ipam-pools").
+						NotDestIPSet("cali40all-ipam-pools").Protocol("sctp"),
+				},
+				{
+					Action: ReturnAction{},
+					Match: Match().
+						SourceIPSet("cali40masq-ipam-pools").
+						NotDestIPSet("cali40all-ipam-pools").Protocol("sctp"),
+				},
 				{
 					Action: MasqAction{},
 					Match: Match().
@@ -160,6 +172,20 @@ var _ = Describe("NAT", func() {
 						NotDestIPSet("cali40all-ipam-pools").Protocol("udp").
 						OutInterface("cali-123"),
 				},
+				{
+					Action: MasqAction{ToPorts: "99-100"},
+					Match: Match().
+						SourceIPSet("cali40masq-ipam-pools").
+						NotDestIPSet("cali40all-ipam-pools").Protocol("sctp").
+						OutInterface("cali-123"),
+				},
+				{
+					Action: ReturnAction{},
+					Match: Match().
+						SourceIPSet("cali40masq-ipam-pools").
+						NotDestIPSet("cali40all-ipam-pools").Protocol("sctp").
+						OutInterface("cali-123"),
+				},
 				{
 					Action: MasqAction{},
 					Match: Match().
@@ -208,6 +234,18 @@ var _ = Describe("NAT", func() {
 						SourceIPSet("cali40masq-ipam-pools").
 						NotDestIPSet("cali40all-ipam-pools").Protocol("udp"),
 				},
+				{
+					Action: SNATAction{ToAddr: expectedAddress},
+					Match: Match().
+						SourceIPSet("cali40masq-ipam-pools").
+						NotDestIPSet("cali40all-ipam-pools").Protocol("sctp"),
+				},
+				{
+					Action: ReturnAction{},
+					Match: Match().
+						SourceIPSet("cali40masq-ipam-pools").
+						NotDestIPSet("cali40all-ipam-pools").Protocol("sctp"),
+				},
 				{
 					Action: SNATAction{ToAddr: snatAddress},
 					Match: Match().
@@ -21,7 +21,6 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/projectcalico/calico/felix/hashutils"
-	"github.com/projectcalico/calico/felix/ipsets"
 	"github.com/projectcalico/calico/felix/iptables"
 	"github.com/projectcalico/calico/felix/proto"
 )
@@ -30,32 +29,39 @@ import (
 
 func (r *DefaultRuleRenderer) PolicyToIptablesChains(policyID *proto.PolicyID, policy *proto.Policy, ipVersion uint8) []*iptables.Chain {
 	inbound := iptables.Chain{
-		Name:  PolicyChainName(PolicyInboundPfx, policyID),
-		Rules: r.ProtoRulesToIptablesRules(policy.InboundRules, ipVersion, fmt.Sprintf("Policy %s ingress", policyID.Name)),
+		Name:  r.policyChainName(PolicyInboundPfx, policyID),
+		Rules: r.protoRulesToIptablesRules(policy.InboundRules, ipVersion, policyID.Name, fmt.Sprintf("Policy %s ingress", policyID.Name)),
 	}
 	outbound := iptables.Chain{
-		Name:  PolicyChainName(PolicyOutboundPfx, policyID),
-		Rules: r.ProtoRulesToIptablesRules(policy.OutboundRules, ipVersion, fmt.Sprintf("Policy %s egress", policyID.Name)),
+		Name:  r.policyChainName(PolicyOutboundPfx, policyID),
+		Rules: r.protoRulesToIptablesRules(policy.OutboundRules, ipVersion, policyID.Name, fmt.Sprintf("Policy %s egress", policyID.Name)),
 	}
 	return []*iptables.Chain{&inbound, &outbound}
 }
 
 func (r *DefaultRuleRenderer) ProfileToIptablesChains(profileID *proto.ProfileID, profile *proto.Profile, ipVersion uint8) (inbound, outbound *iptables.Chain) {
+	owner := "profile:" + profileID.Name
 	inbound = &iptables.Chain{
-		Name:  ProfileChainName(ProfileInboundPfx, profileID),
-		Rules: r.ProtoRulesToIptablesRules(profile.InboundRules, ipVersion, fmt.Sprintf("Profile %s ingress", profileID.Name)),
+		Name:  r.profileChainName(ProfileInboundPfx, profileID),
+		Rules: r.protoRulesToIptablesRules(profile.InboundRules, ipVersion, owner, fmt.Sprintf("Profile %s ingress", profileID.Name)),
 	}
 	outbound = &iptables.Chain{
-		Name:  ProfileChainName(ProfileOutboundPfx, profileID),
-		Rules: r.ProtoRulesToIptablesRules(profile.OutboundRules, ipVersion, fmt.Sprintf("Profile %s egress", profileID.Name)),
+		Name:  r.profileChainName(ProfileOutboundPfx, profileID),
+		Rules: r.protoRulesToIptablesRules(profile.OutboundRules, ipVersion, owner, fmt.Sprintf("Profile %s egress", profileID.Name)),
 	}
 	return
 }
 
 func (r *DefaultRuleRenderer) ProtoRulesToIptablesRules(protoRules []*proto.Rule, ipVersion uint8, chainComments ...string) []iptables.Rule {
+	return r.protoRulesToIptablesRules(protoRules, ipVersion, "", chainComments...)
+}
+
+// protoRulesToIptablesRules renders the rules of a policy or profile.  owner names the policy or
+// profile in the log prefix of policy logging rules; if it is empty, the rules are not logged.
+func (r *DefaultRuleRenderer) protoRulesToIptablesRules(protoRules []*proto.Rule, ipVersion uint8, owner string, chainComments ...string) []iptables.Rule {
 	var rules []iptables.Rule
 	for _, protoRule := range protoRules {
-		rules = append(rules, r.ProtoRuleToIptablesRules(protoRule, ipVersion)...)
+		rules = append(rules, r.protoRuleToIptablesRules(protoRule, ipVersion, owner)...)
 	}
 	if len(chainComments) > 0 {
 		if len(rules) == 0 {
@@ -134,11 +140,45 @@ func FilterRuleToIPVersion(ipVersion uint8, pRule *proto.Rule) *proto.Rule {
 }
 
 func (r *DefaultRuleRenderer) ProtoRuleToIptablesRules(pRule *proto.Rule, ipVersion uint8) []iptables.Rule {
+	return r.protoRuleToIptablesRules(pRule, ipVersion, "")
+}
 
+func (r *DefaultRuleRenderer) protoRuleToIptablesRules(pRule *proto.Rule, ipVersion uint8, owner string) []iptables.Rule {
 	ruleCopy := FilterRuleToIPVersion(ipVersion, pRule)
 	if ruleCopy == nil {
 		return nil
 	}
+	var u32Expr iptables.U32Expr
+	if exprStr, ok := pRule.GetMetadata().GetAnnotations()[RuleAnnotationU32Match]; ok {
+		var err error
+		u32Expr, err = iptables.ParseU32Expr(exprStr)
+		if err != nil {
+			// Skip the rule rather than rendering it without the match, which would widen it.
+			log.WithError(err).WithField("expr", exprStr).Warn(
+				"Invalid u32 match in rule annotation, skipping rule")
+			return nil
+		}
+	}
+	var sctpChunkTypes []iptables.SCTPChunkType
+	if chunkTypesStr, ok := pRule.GetMetadata().GetAnnotations()[RuleAnnotationSCTPChunkTypes]; ok {
+		var err error
+		sctpChunkTypes, err = iptables.ParseSCTPChunkTypes(chunkTypesStr)
+		if err == nil && !isSCTPProtocol(pRule.Protocol) {
+			err = fmt.Errorf("SCTP chunk type matches require an sctp protocol match, not %v", pRule.Protocol)
+		}
+		if err != nil {
+			// As above, skip the rule rather than widening it.
+			log.WithError(err).WithField("chunkTypes", chunkTypesStr).Warn(
+				"Invalid SCTP chunk type match in rule annotation, skipping rule")
+			return nil
+		}
+	}
+	family := r.ruleFamily(ipVersion)
+	if err := family.validateICMPLists(ruleCopy); err != nil {
+		// As above, skip the rule rather than widening it.
+		log.WithError(err).WithField("rule", pRule).Warn("Invalid ICMP match in rule, skipping rule")
+		return nil
+	}
 	// There are a few areas where our data model doesn't fit with iptables, requiring us to
 	// render multiple iptables rules for one of our rules:
 	//
@@ -153,12 +193,16 @@ func (r *DefaultRuleRenderer) ProtoRuleToIptablesRules(pRule *proto.Rule, ipVers
 	//     - our datamodel includes named ports, which we render as (IP, port) IP sets, these are
 	//       or-ed with the numeric ports; the "or" operation can't be done in a single rule.
 	//
+	//     - our datamodel allows for a list of ICMP types and type/code pairs, which are or-ed
+	//       together, but an icmp match only takes a single type.
+	//
 	// To work around these limitations, where needed, we break the rule into blocks,
 	// each of which implements a part of the match as follows:
 	//
 	//     rule to initialise mark bits
 	//     positive matches on source ports
 	//     positive matches on dest ports
+	//     positive matches on ICMP type/code
 	//     positive matches on source address
 	//     positive matches on dest address
 	//     negated matches on source address
@@ -192,16 +236,10 @@ func (r *DefaultRuleRenderer) ProtoRuleToIptablesRules(pRule *proto.Rule, ipVers
 	//
 	// Split the port list into blocks of 15, as per iptables limit and add in the number of
 	// named ports.
-	var ipSetConfig *ipsets.IPVersionConfig
-	if ipVersion == 4 {
-		ipSetConfig = r.IPSetConfigV4
-	} else {
-		ipSetConfig = r.IPSetConfigV6
-	}
 	srcPortSplits := SplitPortList(ruleCopy.SrcPorts)
 	if len(srcPortSplits)+len(ruleCopy.SrcNamedPortIpSetIds) > 1 {
 		// Render a block for the source ports.
-		matchBlockBuilder.AppendPortMatchBlock(ipSetConfig, ruleCopy.Protocol, srcPortSplits, ruleCopy.SrcNamedPortIpSetIds, src)
+		matchBlockBuilder.AppendPortMatchBlock(family, ruleCopy.Protocol, srcPortSplits, ruleCopy.SrcNamedPortIpSetIds, src)
 		// And remove them from the rule since they're already handled.
 		ruleCopy.SrcPorts = nil
 		ruleCopy.SrcNamedPortIpSetIds = nil
@@ -209,12 +247,22 @@ func (r *DefaultRuleRenderer) ProtoRuleToIptablesRules(pRule *proto.Rule, ipVers
 	dstPortSplits := SplitPortList(ruleCopy.DstPorts)
 	if len(dstPortSplits)+len(ruleCopy.DstNamedPortIpSetIds) > 1 {
 		// Render a block for the destination ports.
-		matchBlockBuilder.AppendPortMatchBlock(ipSetConfig, ruleCopy.Protocol, dstPortSplits, ruleCopy.DstNamedPortIpSetIds, dst)
+		matchBlockBuilder.AppendPortMatchBlock(family, ruleCopy.Protocol, dstPortSplits, ruleCopy.DstNamedPortIpSetIds, dst)
 		// And remove them from the rule since they're already handled.
 		ruleCopy.DstPorts = nil
 		ruleCopy.DstNamedPortIpSetIds = nil
 	}
 
+	// Similarly, the single ICMP match and the lists of ICMP matches are or-ed together so, if
+	// there's more than one in total, we render a block for them.
+	icmpTypes, icmpTypeCodes := PositiveICMPMatches(ruleCopy)
+	if len(icmpTypes)+len(icmpTypeCodes) > 1 {
+		matchBlockBuilder.AppendICMPMatchBlock(family, ruleCopy.Protocol, icmpTypes, icmpTypeCodes)
+		ruleCopy.Icmp = nil
+		ruleCopy.IcmpTypes = nil
+		ruleCopy.IcmpTypeCodes = nil
+	}
+
 	// If there's more than one positive source/destination CIDR match, we have to render a block.
 	// Otherwise, if there's exactly one, we'll include it in the main rule below.
 	if len(ruleCopy.SrcNet) > 1 {
@@ -258,6 +306,12 @@ func (r *DefaultRuleRenderer) ProtoRuleToIptablesRules(pRule *proto.Rule, ipVers
 
 	// Render the rest of the rule.
 	match := r.CalculateRuleMatch(ruleCopy, ipVersion)
+	if u32Expr != nil {
+		match = match.U32(u32Expr)
+	}
+	if sctpChunkTypes != nil {
+		match = match.SCTPChunkTypes(sctpChunkTypes...)
+	}
 
 	if matchBlockBuilder.UsingMatchBlocks {
 		// The CIDR or port matches in the rule overflowed and we rendered them
@@ -266,6 +320,10 @@ func (r *DefaultRuleRenderer) ProtoRuleToIptablesRules(pRule *proto.Rule, ipVers
 		match = match.MarkSingleBitSet(matchBlockBuilder.markAllBlocksPass)
 	}
 	markBit, actions := r.CalculateActions(ruleCopy, ipVersion)
+	if logAction := r.policyLogAction(ruleCopy.Action, owner); logAction != nil {
+		// Log before applying the verdict; the log prefix records the verdict and the policy.
+		actions = append([]iptables.Action{logAction}, actions...)
+	}
 	rs := matchBlockBuilder.Rules
 	if markBit != 0 {
 		// The rule needs to do more than one action. Render a rule that
@@ -305,7 +363,7 @@ type matchBlockBuilder struct {
 }
 
 func (r *matchBlockBuilder) AppendPortMatchBlock(
-	ipSetConfig *ipsets.IPVersionConfig,
+	family ruleFamily,
 	protocol *proto.Protocol,
 	numericPortSplits [][]*proto.PortRange,
 	namedPortIPSetIDs []string,
@@ -332,7 +390,7 @@ func (r *matchBlockBuilder) AppendPortMatchBlock(
 	}
 
 	for _, namedPortIPSetID := range namedPortIPSetIDs {
-		ipsetName := ipSetConfig.NameForMainIPSet(namedPortIPSetID)
+		ipsetName := family.nameForIPSet(namedPortIPSetID)
 		r.Rules = append(r.Rules, iptables.Rule{
 			Match:  srcOrDst.MatchIPPortIPSet(ipsetName),
 			Action: iptables.SetMarkAction{Mark: markToSet},
@@ -343,6 +401,38 @@ func (r *matchBlockBuilder) AppendPortMatchBlock(
 	r.finishPositiveBlock()
 }
 
+func (r *matchBlockBuilder) AppendICMPMatchBlock(
+	family ruleFamily,
+	protocol *proto.Protocol,
+	icmpTypes []int32,
+	icmpTypeCodes []*proto.IcmpTypeAndCode,
+) {
+	// Write out the initial "reset" rule if this is the first block.
+	r.maybeAppendInitialRule(0)
+	// Figure out which bit to set.  See comment in positiveBlockMarkToSet() for details.
+	markToSet := r.positiveBlockMarkToSet()
+
+	// The icmp match needs a protocol match in the same rule.
+	logCxt := log.WithField("protocol", protocol)
+	for _, t := range icmpTypes {
+		m := appendProtocolMatch(iptables.Match(), protocol, logCxt)
+		r.Rules = append(r.Rules, iptables.Rule{
+			Match:  family.matchICMPType(m, uint8(t), false),
+			Action: iptables.SetMarkAction{Mark: markToSet},
+		})
+	}
+	for _, tc := range icmpTypeCodes {
+		m := appendProtocolMatch(iptables.Match(), protocol, logCxt)
+		r.Rules = append(r.Rules, iptables.Rule{
+			Match:  family.matchICMPTypeAndCode(m, uint8(tc.Type), uint8(tc.Code), false),
+			Action: iptables.SetMarkAction{Mark: markToSet},
+		})
+	}
+
+	// Append the end-of-block rules.
+	r.finishPositiveBlock()
+}
+
 func (r *matchBlockBuilder) AppendCIDRMatchBlock(cidrs []string, srcOrDst srcOrDst) {
 	// Write out the initial "reset" rule if this is the first block.
 	r.maybeAppendInitialRule(0)
@@ -434,6 +524,20 @@ func (r *matchBlockBuilder) finishPositiveBlock() {
 	})
 }
 
+// PositiveICMPMatches returns all the rule's positive ICMP matches: the single match from the
+// icmp oneof, if present, followed by the lists.
+func PositiveICMPMatches(pRule *proto.Rule) (icmpTypes []int32, icmpTypeCodes []*proto.IcmpTypeAndCode) {
+	switch icmp := pRule.Icmp.(type) {
+	case *proto.Rule_IcmpType:
+		icmpTypes = append(icmpTypes, icmp.IcmpType)
+	case *proto.Rule_IcmpTypeCode:
+		icmpTypeCodes = append(icmpTypeCodes, icmp.IcmpTypeCode)
+	}
+	icmpTypes = append(icmpTypes, pRule.IcmpTypes...)
+	icmpTypeCodes = append(icmpTypeCodes, pRule.IcmpTypeCodes...)
+	return
+}
+
 // srcOrDst is an enum for selecting source or destination rule rendering.
 type srcOrDst int
 
@@ -536,6 +640,40 @@ func (r *DefaultRuleRenderer) CalculateActions(pRule *proto.Rule, ipVersion uint
 	return
 }
 
+// policyLogAction returns the action that logs packets that hit a rule with the given action in
+// the given policy or profile, or nil if they shouldn't be logged.
+func (r *DefaultRuleRenderer) policyLogAction(ruleAction, owner string) iptables.Action {
+	if owner == "" {
+		return nil
+	}
+	var verdict string
+	switch ruleAction {
+	case "", "allow":
+		if r.PolicyLogVerdicts != PolicyLogVerdictsAllow && r.PolicyLogVerdicts != PolicyLogVerdictsAll {
+			return nil
+		}
+		verdict = "A"
+	case "deny":
+		if r.PolicyLogVerdicts != PolicyLogVerdictsDeny && r.PolicyLogVerdicts != PolicyLogVerdictsAll {
+			return nil
+		}
+		verdict = "D"
+	default:
+		return nil
+	}
+	prefix := fmt.Sprintf("%s %s %s", r.PolicyLogPrefix, verdict, owner)
+	if r.PolicyLogNFLOGGroup == 0 {
+		if len(prefix) > maxLogPrefixLen {
+			prefix = prefix[:maxLogPrefixLen]
+		}
+		return iptables.LogAction{Prefix: prefix}
+	}
+	if len(prefix) > maxNflogPrefixLen {
+		prefix = prefix[:maxNflogPrefixLen]
+	}
+	return iptables.NflogAction{Group: r.PolicyLogNFLOGGroup, Prefix: prefix}
+}
+
 func appendProtocolMatch(match iptables.MatchCriteria, protocol *proto.Protocol, logCxt *log.Entry) iptables.MatchCriteria {
 	if protocol == nil {
 		return match
@@ -553,6 +691,17 @@ func appendProtocolMatch(match iptables.MatchCriteria, protocol *proto.Protocol,
 	return match
 }
 
+// isSCTPProtocol returns true if the protocol match selects SCTP.
+func isSCTPProtocol(protocol *proto.Protocol) bool {
+	switch p := protocol.GetNumberOrName().(type) {
+	case *proto.Protocol_Name:
+		return strings.ToLower(p.Name) == "sctp"
+	case *proto.Protocol_Number:
+		return p.Number == ProtoSCTP
+	}
+	return false
+}
+
 func (r *DefaultRuleRenderer) CalculateRuleMatch(pRule *proto.Rule, ipVersion uint8) iptables.MatchCriteria {
 	match := iptables.Match()
 
@@ -572,13 +721,8 @@ func (r *DefaultRuleRenderer) CalculateRuleMatch(pRule *proto.Rule, ipVersion ui
 			"CalculateRuleMatch() passed more than one CIDR in SrcNet.")
 	}
 
-	nameForIPSet := func(ipsetID string) string {
-		if ipVersion == 4 {
-			return r.IPSetConfigV4.NameForMainIPSet(ipsetID)
-		} else {
-			return r.IPSetConfigV6.NameForMainIPSet(ipsetID)
-		}
-	}
+	family := r.ruleFamily(ipVersion)
+	nameForIPSet := family.nameForIPSet
 
 	for _, ipsetID := range pRule.SrcIpSetIds {
 		ipsetName := nameForIPSet(ipsetID)
@@ -655,26 +799,22 @@ func (r *DefaultRuleRenderer) CalculateRuleMatch(pRule *proto.Rule, ipVersion ui
 		match = match.DestIPPortSet(ipsetName)
 	}
 
-	if ipVersion == 4 {
-		switch icmp := pRule.Icmp.(type) {
-		case *proto.Rule_IcmpTypeCode:
-			logCxt.WithField("icmpTypeCode", icmp).Debug("Adding ICMP type/code match.")
-			match = match.ICMPTypeAndCode(
-				uint8(icmp.IcmpTypeCode.Type), uint8(icmp.IcmpTypeCode.Code))
-		case *proto.Rule_IcmpType:
-			logCxt.WithField("icmpType", icmp).Debug("Adding ICMP type-only match.")
-			match = match.ICMPType(uint8(icmp.IcmpType))
-		}
-	} else {
-		switch icmp := pRule.Icmp.(type) {
-		case *proto.Rule_IcmpTypeCode:
-			logCxt.WithField("icmpTypeCode", icmp).Debug("Adding ICMPv6 type/code match.")
-			match = match.ICMPV6TypeAndCode(
-				uint8(icmp.IcmpTypeCode.Type), uint8(icmp.IcmpTypeCode.Code))
-		case *proto.Rule_IcmpType:
-			logCxt.WithField("icmpTypeCode", icmp).Debug("Adding ICMPv6 type-only match.")
-			match = match.ICMPV6Type(uint8(icmp.IcmpType))
-		}
+	switch icmp := pRule.Icmp.(type) {
+	case *proto.Rule_IcmpTypeCode:
+		match = family.matchICMPTypeAndCode(match,
+			uint8(icmp.IcmpTypeCode.Type), uint8(icmp.IcmpTypeCode.Code), false)
+	case *proto.Rule_IcmpType:
+		match = family.matchICMPType(match, uint8(icmp.IcmpType), false)
+	}
+	if icmpTypes, icmpTypeCodes := PositiveICMPMatches(pRule); len(icmpTypes)+len(icmpTypeCodes) > 1 {
+		log.WithField("rule", pRule).Panic(
+			"CalculateRuleMatch() passed more than one ICMP match.")
+	}
+	for _, t := range pRule.IcmpTypes {
+		match = family.matchICMPType(match, uint8(t), false)
+	}
+	for _, tc := range pRule.IcmpTypeCodes {
+		match = family.matchICMPTypeAndCode(match, uint8(tc.Type), uint8(tc.Code), false)
 	}
 
 	// Now, the negated versions.
@@ -758,32 +898,32 @@ func (r *DefaultRuleRenderer) CalculateRuleMatch(pRule *proto.Rule, ipVersion ui
 		match = match.NotDestIPPortSet(ipsetName)
 	}
 
-	if ipVersion == 4 {
-		switch icmp := pRule.NotIcmp.(type) {
-		case *proto.Rule_NotIcmpTypeCode:
-			logCxt.WithField("icmpTypeCode", icmp).Debug("Adding ICMP type/code match.")
-			match = match.NotICMPTypeAndCode(
-				uint8(icmp.NotIcmpTypeCode.Type), uint8(icmp.NotIcmpTypeCode.Code))
-		case *proto.Rule_NotIcmpType:
-			logCxt.WithField("icmpType", icmp).Debug("Adding ICMP type-only match.")
-			match = match.NotICMPType(uint8(icmp.NotIcmpType))
-		}
-	} else {
-		switch icmp := pRule.NotIcmp.(type) {
-		case *proto.Rule_NotIcmpTypeCode:
-			logCxt.WithField("icmpTypeCode", icmp).Debug("Adding ICMPv6 type/code match.")
-			match = match.NotICMPV6TypeAndCode(
-				uint8(icmp.NotIcmpTypeCode.Type), uint8(icmp.NotIcmpTypeCode.Code))
-		case *proto.Rule_NotIcmpType:
-			logCxt.WithField("icmpTypeCode", icmp).Debug("Adding ICMPv6 type-only match.")
-			match = match.NotICMPV6Type(uint8(icmp.NotIcmpType))
-		}
+	switch icmp := pRule.NotIcmp.(type) {
+	case *proto.Rule_NotIcmpTypeCode:
+		match = family.matchICMPTypeAndCode(match,
+			uint8(icmp.NotIcmpTypeCode.Type), uint8(icmp.NotIcmpTypeCode.Code), true)
+	case *proto.Rule_NotIcmpType:
+		match = family.matchICMPType(match, uint8(icmp.NotIcmpType), true)
+	}
+	// Each negated match has to fail, so the lists can simply be and-ed into the rule.
+	for _, t := range pRule.NotIcmpTypes {
+		match = family.matchICMPType(match, uint8(t), true)
+	}
+	for _, tc := range pRule.NotIcmpTypeCodes {
+		match = family.matchICMPTypeAndCode(match, uint8(tc.Type), uint8(tc.Code), true)
 	}
 	return match
 }
 
 func PolicyChainName(prefix PolicyChainNamePrefix, polID *proto.PolicyID) string {
-	return hashutils.GetLengthLimitedID(
+	return VersionedPolicyChainName(hashutils.IDVersion1, prefix, polID)
+}
+
+// VersionedPolicyChainName returns the name of a policy chain, shortened, if needed, with the
+// given version of the chain name scheme.
+func VersionedPolicyChainName(version hashutils.IDVersion, prefix PolicyChainNamePrefix, polID *proto.PolicyID) string {
+	return hashutils.GetVersionedLengthLimitedID(
+		version,
 		string(prefix),
 		polID.Name,
 		iptables.MaxChainNameLength,
@@ -791,9 +931,31 @@ func PolicyChainName(prefix PolicyChainNamePrefix, polID *proto.PolicyID) string
 }
 
 func ProfileChainName(prefix ProfileChainNamePrefix, profID *proto.ProfileID) string {
-	return hashutils.GetLengthLimitedID(
+	return VersionedProfileChainName(hashutils.IDVersion1, prefix, profID)
+}
+
+// VersionedProfileChainName returns the name of a profile chain, shortened, if needed, with the
+// given version of the chain name scheme.
+func VersionedProfileChainName(version hashutils.IDVersion, prefix ProfileChainNamePrefix, profID *proto.ProfileID) string {
+	return hashutils.GetVersionedLengthLimitedID(
+		version,
 		string(prefix),
 		profID.Name,
 		iptables.MaxChainNameLength,
 	)
 }
+
+func (r *DefaultRuleRenderer) policyChainName(prefix PolicyChainNamePrefix, polID *proto.PolicyID) string {
+	return VersionedPolicyChainName(r.chainNameVersion(), prefix, polID)
+}
+
+func (r *DefaultRuleRenderer) profileChainName(prefix ProfileChainNamePrefix, profID *proto.ProfileID) string {
+	return VersionedProfileChainName(r.chainNameVersion(), prefix, profID)
+}
+
+func (r *DefaultRuleRenderer) chainNameVersion() hashutils.IDVersion {
+	if r.ChainNameVersion == 0 {
+		return hashutils.IDVersion1
+	}
+	return r.ChainNameVersion
+}
@@ -15,6 +15,8 @@
 package rules_test
 
 import (
+	"strings"
+
 	"github.com/projectcalico/calico/felix/environment"
 	. "github.com/projectcalico/calico/felix/rules"
 
@@ -22,6 +24,7 @@ import (
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 
+	"github.com/projectcalico/calico/felix/hashutils"
 	"github.com/projectcalico/calico/felix/ipsets"
 	"github.com/projectcalico/calico/felix/iptables"
 	"github.com/projectcalico/calico/felix/proto"
@@ -70,6 +73,18 @@ var ruleTestData = []TableEntry{
 	Entry("ICMP with code", 6,
 		proto.Rule{Icmp: &proto.Rule_IcmpTypeCode{IcmpTypeCode: &proto.IcmpTypeAndCode{Type: 10, Code: 12}}},
 		"-m icmp6 --icmpv6-type 10/12"),
+	Entry("ICMP type list with one entry", 4,
+		proto.Rule{
+			Protocol:  &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "icmp"}},
+			IcmpTypes: []int32{10},
+		},
+		"-p icmp -m icmp --icmp-type 10"),
+	Entry("ICMP type/code list with one entry", 6,
+		proto.Rule{
+			Protocol:      &proto.Protocol{NumberOrName: &proto.Protocol_Number{Number: 58}},
+			IcmpTypeCodes: []*proto.IcmpTypeAndCode{{Type: 1, Code: 4}},
+		},
+		"-p 58 -m icmp6 --icmpv6-type 1/4"),
 
 	Entry("Dest net", 4,
 		proto.Rule{DstNet: []string{"10.0.0.0/16"}},
@@ -146,6 +161,21 @@ var ruleTestData = []TableEntry{
 	Entry("ICMP with code", 6,
 		proto.Rule{NotIcmp: &proto.Rule_NotIcmpTypeCode{NotIcmpTypeCode: &proto.IcmpTypeAndCode{Type: 10, Code: 12}}},
 		"-m icmp6 ! --icmpv6-type 10/12"),
+	Entry("ICMP lists", 4,
+		proto.Rule{
+			Protocol:         &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "icmp"}},
+			NotIcmp:          &proto.Rule_NotIcmpType{NotIcmpType: 8},
+			NotIcmpTypes:     []int32{0, 13},
+			NotIcmpTypeCodes: []*proto.IcmpTypeAndCode{{Type: 3, Code: 4}},
+		},
+		"-p icmp -m icmp ! --icmp-type 8 -m icmp ! --icmp-type 0 -m icmp ! --icmp-type 13 -m icmp ! --icmp-type 3/4"),
+	Entry("ICMP lists", 6,
+		proto.Rule{
+			Protocol:         &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "icmpv6"}},
+			NotIcmpTypes:     []int32{128},
+			NotIcmpTypeCodes: []*proto.IcmpTypeAndCode{{Type: 1, Code: 3}, {Type: 1, Code: 4}},
+		},
+		"-p icmpv6 -m icmp6 ! --icmpv6-type 128 -m icmp6 ! --icmpv6-type 1/3 -m icmp6 ! --icmpv6-type 1/4"),
 
 	Entry("Dest net", 4,
 		proto.Rule{NotDstNet: []string{"10.0.0.0/16"}},
@@ -477,6 +507,19 @@ var _ = Describe("Protobuf rule to iptables rule conversion", func() {
 			allowIfAllMarkAndUDPRule,
 			returnRule,
 		),
+		namedPortEntry(
+			"SCTP named port and port range need a block",
+			proto.Rule{
+				Protocol:             &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "sctp"}},
+				DstPorts:             []*proto.PortRange{{First: 38412, Last: 38422}},
+				DstNamedPortIpSetIds: []string{"ipset-1"},
+			},
+			clearBothMarksRule,
+			"-A test -p sctp -m multiport --destination-ports 38412:38422 --jump MARK --set-mark 0x200/0x200",
+			"-A test -m set --match-set cali40ipset-1 dst,dst --jump MARK --set-mark 0x200/0x200",
+			"-A test -p sctp -m mark --mark 0x200/0x200 --jump MARK --set-mark 0x80/0x80",
+			returnRule,
+		),
 		namedPortEntry(
 			"Multiple named + numeric ports",
 			proto.Rule{
@@ -836,11 +879,94 @@ var _ = Describe("Protobuf rule to iptables rule conversion", func() {
 		),
 	)
 
+	DescribeTable(
+		"ICMP list tests",
+		func(ipVersion int, pRule proto.Rule, expected []string) {
+			renderer := NewRenderer(rrConfigNormal)
+			iptRules := renderer.ProtoRuleToIptablesRules(&pRule, uint8(ipVersion))
+			rendered := []string{}
+			for _, ir := range iptRules {
+				s := ir.RenderAppend("test", "", &environment.Features{})
+				rendered = append(rendered, s)
+			}
+			Expect(rendered).To(Equal(expected))
+		},
+		Entry("Single ICMP match and list are or-ed together", 4,
+			proto.Rule{
+				Protocol:      &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "icmp"}},
+				Icmp:          &proto.Rule_IcmpType{IcmpType: 8},
+				IcmpTypes:     []int32{0},
+				IcmpTypeCodes: []*proto.IcmpTypeAndCode{{Type: 3, Code: 4}},
+			},
+			[]string{
+				clearBothMarksRule,
+				"-A test -p icmp -m icmp --icmp-type 8 --jump MARK --set-mark 0x200/0x200",
+				"-A test -p icmp -m icmp --icmp-type 0 --jump MARK --set-mark 0x200/0x200",
+				"-A test -p icmp -m icmp --icmp-type 3/4 --jump MARK --set-mark 0x200/0x200",
+				"-A test -p icmp -m mark --mark 0x200/0x200 --jump MARK --set-mark 0x80/0x80",
+				returnRule,
+			},
+		),
+		Entry("ICMPv6 list after a port block", 6,
+			proto.Rule{
+				Protocol:             &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "icmpv6"}},
+				SrcNamedPortIpSetIds: []string{"ipset-1", "ipset-2"},
+				IcmpTypeCodes:        []*proto.IcmpTypeAndCode{{Type: 1, Code: 3}, {Type: 1, Code: 4}},
+				NotIcmpTypes:         []int32{128},
+			},
+			[]string{
+				clearBothMarksRule,
+				"-A test -m set --match-set cali60ipset-1 src,src --jump MARK --set-mark 0x200/0x200",
+				"-A test -m set --match-set cali60ipset-2 src,src --jump MARK --set-mark 0x200/0x200",
+				"-A test -p icmpv6 -m icmp6 --icmpv6-type 1/3 --jump MARK --set-mark 0x400/0x400",
+				"-A test -p icmpv6 -m icmp6 --icmpv6-type 1/4 --jump MARK --set-mark 0x400/0x400",
+				allBlocksPassAndEqThisBlockPassRule,
+				"-A test -p icmpv6 -m icmp6 ! --icmpv6-type 128 -m mark --mark 0x200/0x200 --jump MARK --set-mark 0x80/0x80",
+				returnRule,
+			},
+		),
+	)
+
 	var renderer *DefaultRuleRenderer
 	BeforeEach(func() {
 		renderer = NewRenderer(rrConfigNormal).(*DefaultRuleRenderer)
 	})
 
+	DescribeTable("should skip rules with invalid ICMP lists",
+		func(ipVersion int, pRule *proto.Rule) {
+			Expect(renderer.ProtoRuleToIptablesRules(pRule, uint8(ipVersion))).To(BeEmpty())
+		},
+		Entry("no protocol", 4, &proto.Rule{IcmpTypes: []int32{8}}),
+		Entry("ICMPv6 protocol for IPv4", 4, &proto.Rule{
+			Protocol:  &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "icmpv6"}},
+			IcmpTypes: []int32{128},
+		}),
+		Entry("ICMP protocol for IPv6", 6, &proto.Rule{
+			Protocol:     &proto.Protocol{NumberOrName: &proto.Protocol_Number{Number: 1}},
+			NotIcmpTypes: []int32{8},
+		}),
+		Entry("type out of range", 4, &proto.Rule{
+			Protocol:  &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "icmp"}},
+			IcmpTypes: []int32{255},
+		}),
+		Entry("code out of range", 6, &proto.Rule{
+			Protocol:         &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "ipv6-icmp"}},
+			NotIcmpTypeCodes: []*proto.IcmpTypeAndCode{{Type: 1, Code: 256}},
+		}),
+		Entry("ICMPv6 type in IPv4 rule", 4, &proto.Rule{
+			Protocol:  &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "icmp"}},
+			IcmpTypes: []int32{0, 128},
+		}),
+		Entry("ICMP type in IPv6 rule", 6, &proto.Rule{
+			Protocol:      &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "icmpv6"}},
+			IcmpTypeCodes: []*proto.IcmpTypeAndCode{{Type: 11, Code: 0}},
+		}),
+		Entry("unassigned ICMPv6 type in negated list", 6, &proto.Rule{
+			Protocol:     &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "icmpv6"}},
+			NotIcmpTypes: []int32{128, 8},
+		}),
+	)
+
 	It("should skip rules of incorrect IP version", func() {
 		rules := renderer.ProtoRulesToIptablesRules([]*proto.Rule{{IpVersion: 4}}, 6)
 		Expect(rules).To(BeEmpty())
@@ -885,6 +1011,28 @@ var _ = Describe("Protobuf rule to iptables rule conversion", func() {
 		rules := renderer.ProtoRulesToIptablesRules([]*proto.Rule{{NotDstNet: []string{"feed::beef"}}}, 4)
 		Expect(rules).To(BeEmpty())
 	})
+
+	It("should render a rule without family-specific matches the same for both IP versions", func() {
+		rule := &proto.Rule{
+			Protocol:             &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "tcp"}},
+			SrcIpSetIds:          []string{"ipset-1"},
+			NotDstIpSetIds:       []string{"ipset-2"},
+			SrcPorts:             []*proto.PortRange{{First: 10, Last: 12}},
+			SrcNamedPortIpSetIds: []string{"ipset-3", "ipset-4"},
+			DstPorts:             []*proto.PortRange{{First: 80, Last: 80}},
+		}
+		render := func(ipVersion uint8) (rendered []string) {
+			for _, r := range renderer.ProtoRuleToIptablesRules(rule, ipVersion) {
+				rendered = append(rendered, r.RenderAppend("test", "", &environment.Features{}))
+			}
+			return
+		}
+		v4Rules := render(4)
+		for i := range v4Rules {
+			v4Rules[i] = strings.ReplaceAll(v4Rules[i], "cali40", "cali60")
+		}
+		Expect(render(6)).To(Equal(v4Rules))
+	})
 })
 
 var _ = DescribeTable("Port split tests",
@@ -1161,4 +1309,162 @@ var _ = Describe("rule metadata tests", func() {
 			},
 		))
 	})
+	It("should shorten chain names with the configured version", func() {
+		config := rrConfigNormal
+		config.ChainNameVersion = hashutils.IDVersion2
+		renderer := NewRenderer(config)
+		polID := &proto.PolicyID{Name: "long-policy-name-that-gets-hashed"}
+		chains := renderer.PolicyToIptablesChains(polID, &proto.Policy{}, 4)
+		Expect(chains[0].Name).To(HavePrefix("cali-pi-_2"))
+		Expect(chains[0].Name).To(HaveLen(iptables.MaxChainNameLength))
+		Expect(chains[0].Name).To(Equal(VersionedPolicyChainName(hashutils.IDVersion2, PolicyInboundPfx, polID)))
+		Expect(chains[1].Name).To(Equal(VersionedPolicyChainName(hashutils.IDVersion2, PolicyOutboundPfx, polID)))
+
+		inbound, _ := renderer.ProfileToIptablesChains(&proto.ProfileID{Name: polID.Name}, &proto.Profile{}, 4)
+		Expect(inbound.Name).To(HavePrefix("cali-pri-_2"))
+	})
+	It("should add a u32 match from the rule annotation", func() {
+		renderer := NewRenderer(rrConfigNormal)
+		rs := renderer.ProtoRuleToIptablesRules(&proto.Rule{
+			Action: "deny",
+			Metadata: &proto.RuleMetadata{Annotations: map[string]string{
+				RuleAnnotationU32Match: "0>>22&0x3C@12=0x1234",
+			}},
+			Protocol: &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "udp"}},
+		}, 4)
+		Expect(rs).To(HaveLen(1))
+		Expect(rs[0].Match).To(Equal(iptables.Match().Protocol("udp").
+			U32(iptables.U32Expr{iptables.U32At(0).ShiftRight(22).And(0x3C).Move(12).Equals(0x1234)})))
+	})
+
+	It("should skip a rule with an invalid u32 match", func() {
+		renderer := NewRenderer(rrConfigNormal)
+		rs := renderer.ProtoRuleToIptablesRules(&proto.Rule{
+			Action: "deny",
+			Metadata: &proto.RuleMetadata{Annotations: map[string]string{
+				RuleAnnotationU32Match: "0>>32=1",
+			}},
+		}, 4)
+		Expect(rs).To(BeEmpty())
+	})
+
+	It("should add an SCTP chunk type match from the rule annotation", func() {
+		renderer := NewRenderer(rrConfigNormal)
+		rs := renderer.ProtoRuleToIptablesRules(&proto.Rule{
+			Action: "deny",
+			Metadata: &proto.RuleMetadata{Annotations: map[string]string{
+				RuleAnnotationSCTPChunkTypes: "init",
+			}},
+			Protocol: &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "sctp"}},
+			DstPorts: []*proto.PortRange{{First: 36412, Last: 36422}},
+		}, 4)
+		Expect(rs).To(HaveLen(1))
+		Expect(rs[0].Match).To(Equal(iptables.Match().Protocol("sctp").
+			DestPortRanges([]*proto.PortRange{{First: 36412, Last: 36422}}).
+			SCTPChunkTypes(iptables.SCTPChunkInit)))
+	})
+
+	DescribeTable("should skip a rule with an invalid SCTP chunk type match",
+		func(protocol *proto.Protocol, chunkTypes string) {
+			renderer := NewRenderer(rrConfigNormal)
+			rs := renderer.ProtoRuleToIptablesRules(&proto.Rule{
+				Action: "deny",
+				Metadata: &proto.RuleMetadata{Annotations: map[string]string{
+					RuleAnnotationSCTPChunkTypes: chunkTypes,
+				}},
+				Protocol: protocol,
+			}, 4)
+			Expect(rs).To(BeEmpty())
+		},
+		Entry("unknown chunk type", &proto.Protocol{NumberOrName: &proto.Protocol_Number{Number: 132}}, "SYN"),
+		Entry("no protocol", nil, "INIT"),
+		Entry("TCP protocol", &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "tcp"}}, "INIT"),
+	)
 })
+
+var _ = Describe("policy logging", func() {
+	var rrConfig Config
+	BeforeEach(func() {
+		rrConfig = Config{
+			IPSetConfigV4:        ipsets.NewIPVersionConfig(ipsets.IPFamilyV4, "cali", nil, nil),
+			IPSetConfigV6:        ipsets.NewIPVersionConfig(ipsets.IPFamilyV6, "cali", nil, nil),
+			IptablesMarkAccept:   0x80,
+			IptablesMarkPass:     0x100,
+			IptablesMarkScratch0: 0x200,
+			IptablesMarkScratch1: 0x400,
+			IptablesMarkEndpoint: 0xff000,
+			IptablesLogPrefix:    "calico-packet",
+			PolicyLogVerdicts:    PolicyLogVerdictsDeny,
+			PolicyLogPrefix:      "calico-pol",
+		}
+	})
+
+	policy := &proto.Policy{
+		InboundRules: []*proto.Rule{
+			{Action: "deny", Protocol: &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "tcp"}}},
+			{Action: "allow"},
+		},
+	}
+	render := func() []iptables.Rule {
+		renderer := NewRenderer(rrConfig)
+		chains := renderer.PolicyToIptablesChains(&proto.PolicyID{Name: "default.deny"}, policy, 4)
+		return chains[0].Rules
+	}
+
+	It("should log denied packets before dropping them", func() {
+		rules := render()
+		Expect(rules).To(HaveLen(4))
+		Expect(rules[0].Match).To(Equal(iptables.Match().Protocol("tcp")))
+		Expect(rules[0].Action).To(Equal(iptables.LogAction{Prefix: "calico-pol D default.deny"}))
+		Expect(rules[1].Match).To(Equal(iptables.Match().Protocol("tcp")))
+		Expect(rules[1].Action).To(Equal(iptables.DropAction{}))
+		Expect(rules[3].Action).To(Equal(iptables.ReturnAction{}))
+	})
+
+	It("should log allowed packets after setting the accept mark", func() {
+		rrConfig.PolicyLogVerdicts = PolicyLogVerdictsAll
+		rules := render()
+		Expect(rules).To(HaveLen(5))
+		Expect(rules[2].Action).To(Equal(iptables.SetMarkAction{Mark: 0x80}))
+		Expect(rules[3].Match).To(Equal(iptables.Match().MarkSingleBitSet(0x80)))
+		Expect(rules[3].Action).To(Equal(iptables.LogAction{Prefix: "calico-pol A default.deny"}))
+		Expect(rules[4].Action).To(Equal(iptables.ReturnAction{}))
+	})
+
+	It("should log to NFLOG if configured", func() {
+		rrConfig.PolicyLogNFLOGGroup = 20
+		Expect(render()[0].Action).To(Equal(iptables.NflogAction{Group: 20, Prefix: "calico-pol D default.deny"}))
+	})
+
+	It("should truncate long prefixes", func() {
+		rrConfig.PolicyLogPrefix = "calico-policy-audit"
+		Expect(render()[0].Action).To(Equal(iptables.LogAction{Prefix: "calico-policy-audit D defau"}))
+	})
+
+	It("should name the profile", func() {
+		renderer := NewRenderer(rrConfig)
+		inbound, _ := renderer.ProfileToIptablesChains(&proto.ProfileID{Name: "kns.ns1"}, &proto.Profile{
+			InboundRules: []*proto.Rule{{Action: "deny"}},
+		}, 4)
+		Expect(inbound.Rules[0].Action).To(Equal(iptables.LogAction{Prefix: "calico-pol D profile:kns.ns"}))
+	})
+
+	It("should not log by default", func() {
+		rrConfig.PolicyLogVerdicts = PolicyLogVerdictsNone
+		Expect(render()).To(HaveLen(3))
+	})
+})
+
+var _ = DescribeTable("ChainGroup",
+	func(chainName, expected string) {
+		Expect(ChainGroup(chainName)).To(Equal(expected))
+	},
+	Entry("inbound policy", PolicyChainName(PolicyInboundPfx, &proto.PolicyID{Name: "default.allow"}), "policy"),
+	Entry("outbound policy", PolicyChainName(PolicyOutboundPfx, &proto.PolicyID{Name: "default.allow"}), "policy"),
+	Entry("inbound profile", ProfileChainName(ProfileInboundPfx, &proto.ProfileID{Name: "kns.ns1"}), "profile"),
+	Entry("outbound profile", ProfileChainName(ProfileOutboundPfx, &proto.ProfileID{Name: "kns.ns1"}), "profile"),
+	Entry("workload", EndpointChainName(WorkloadToEndpointPfx, "cali1234"), "endpoint"),
+	Entry("host endpoint", EndpointChainName(HostFromEndpointForwardPfx, "eth0"), "endpoint"),
+	Entry("static", ChainFilterForward, "static"),
+	Entry("dispatch", ChainFromWorkloadDispatch, "static"),
+)
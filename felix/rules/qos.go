@@ -0,0 +1,43 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"github.com/projectcalico/calico/felix/iptables"
+)
+
+// QoSPolicy sets the DSCP field of packets sent from a workload, so that they can be given a
+// matching quality of service by the network.
+type QoSPolicy struct {
+	// SrcAddrs is the address (or CIDR) of the workload.
+	SrcAddrs string
+	DSCP     uint8
+}
+
+// QoSPolicyChain renders the mangle table chain that applies the given QoS policies, in order.
+// The policies must all be for the same IP version.
+func (r *DefaultRuleRenderer) QoSPolicyChain(policies []QoSPolicy) *iptables.Chain {
+	rules := []iptables.Rule{}
+	for _, p := range policies {
+		rules = append(rules, iptables.Rule{
+			Match:  iptables.Match().SourceNet(p.SrcAddrs),
+			Action: iptables.SetDSCPAction{Value: p.DSCP},
+		})
+	}
+	return &iptables.Chain{
+		Name:  ChainQoSPolicy,
+		Rules: rules,
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/ipsets"
+	. "github.com/projectcalico/calico/felix/iptables"
+	. "github.com/projectcalico/calico/felix/rules"
+)
+
+var _ = Describe("QoS policy", func() {
+	var renderer RuleRenderer
+	BeforeEach(func() {
+		renderer = NewRenderer(Config{
+			IPSetConfigV4:        ipsets.NewIPVersionConfig(ipsets.IPFamilyV4, "cali", nil, nil),
+			IPSetConfigV6:        ipsets.NewIPVersionConfig(ipsets.IPFamilyV6, "cali", nil, nil),
+			IptablesMarkAccept:   0x8,
+			IptablesMarkPass:     0x10,
+			IptablesMarkScratch0: 0x20,
+			IptablesMarkScratch1: 0x40,
+			IptablesMarkEndpoint: 0xff00,
+		})
+	})
+
+	It("should render an empty chain with no policies", func() {
+		Expect(renderer.QoSPolicyChain(nil)).To(Equal(&Chain{
+			Name:  "cali-qos-policy",
+			Rules: []Rule{},
+		}))
+	})
+
+	It("should set the DSCP of traffic from each workload", func() {
+		Expect(renderer.QoSPolicyChain([]QoSPolicy{
+			{SrcAddrs: "10.0.0.1/32", DSCP: 46},
+			{SrcAddrs: "10.0.0.2/32", DSCP: 10},
+		})).To(Equal(&Chain{
+			Name: "cali-qos-policy",
+			Rules: []Rule{
+				{Match: Match().SourceNet("10.0.0.1/32"), Action: SetDSCPAction{Value: 46}},
+				{Match: Match().SourceNet("10.0.0.2/32"), Action: SetDSCPAction{Value: 10}},
+			},
+		}))
+	})
+})
@@ -24,6 +24,7 @@ import (
 	"github.com/projectcalico/api/pkg/lib/numorstring"
 
 	"github.com/projectcalico/calico/felix/config"
+	"github.com/projectcalico/calico/felix/hashutils"
 	"github.com/projectcalico/calico/felix/ipsets"
 	"github.com/projectcalico/calico/felix/iptables"
 	"github.com/projectcalico/calico/felix/proto"
@@ -57,6 +58,8 @@ const (
 
 	ChainManglePrerouting  = ChainNamePrefix + "PREROUTING"
 	ChainManglePostrouting = ChainNamePrefix + "POSTROUTING"
+	ChainMangleTPROXY      = ChainNamePrefix + "tproxy"
+	ChainQoSPolicy         = ChainNamePrefix + "qos-policy"
 
 	IPSetIDNATOutgoingAllPools  = "all-ipam-pools"
 	IPSetIDNATOutgoingMasqPools = "masq-ipam-pools"
@@ -64,6 +67,7 @@ const (
 	IPSetIDAllHostNets        = "all-hosts-net"
 	IPSetIDAllVXLANSourceNets = "all-vxlan-net"
 	IPSetIDThisHostIPs        = "this-host"
+	IPSetIDNoTrackNets        = "notrack-nets"
 
 	ChainFIPDnat = ChainNamePrefix + "fip-dnat"
 	ChainFIPSnat = ChainNamePrefix + "fip-snat"
@@ -85,6 +89,8 @@ const (
 	ChainDispatchFromHostEndPointForward = ChainNamePrefix + "from-hep-forward"
 	ChainDispatchSetEndPointMark         = ChainNamePrefix + "set-endpoint-mark"
 	ChainDispatchFromEndPointMark        = ChainNamePrefix + "from-endpoint-mark"
+	ChainTrustedHostInterface            = ChainNamePrefix + "trusted-iface"
+	ChainCachedVerdictAccept             = ChainNamePrefix + "cached-accept"
 
 	ChainForwardCheck        = ChainNamePrefix + "forward-check"
 	ChainForwardEndpointMark = ChainNamePrefix + "forward-endpoint-mark"
@@ -121,6 +127,28 @@ const (
 		`-A POSTROUTING -o tunl0 -m addrtype ! --src-type LOCAL --limit-iface-out -m addrtype --src-type LOCAL -j MASQUERADE`
 
 	KubeProxyInsertRuleRegex = `-j KUBE-[a-zA-Z0-9-]*SERVICES|-j KUBE-FORWARD`
+
+	// RuleAnnotationU32Match, set in a policy rule's metadata, restricts the rule to packets that
+	// match the given iptables u32 expression; for example, "0>>22&0x3C@12=0x1234" matches
+	// GTP-U packets with TEID 0x1234.  Rules with an invalid expression are not rendered.
+	RuleAnnotationU32Match = "policy.projectcalico.org/u32-match"
+
+	// RuleAnnotationSCTPChunkTypes, set in the metadata of an SCTP policy rule, restricts the
+	// rule to packets that contain any of the given comma-separated SCTP chunk types; for
+	// example, "INIT" matches only the packets that start new associations.  Rules with unknown
+	// chunk types, or that don't match on the sctp protocol, are not rendered.
+	RuleAnnotationSCTPChunkTypes = "policy.projectcalico.org/sctp-chunk-types"
+
+	// Values of Config.PolicyLogVerdicts.
+	PolicyLogVerdictsNone  = "None"
+	PolicyLogVerdictsDeny  = "Deny"
+	PolicyLogVerdictsAllow = "Allow"
+	PolicyLogVerdictsAll   = "All"
+
+	// maxLogPrefixLen is the longest prefix that the LOG target accepts, allowing for the ": "
+	// that LogAction appends; maxNflogPrefixLen is the equivalent for NFLOG.
+	maxLogPrefixLen   = 29 - 2
+	maxNflogPrefixLen = 64
 )
 
 // Typedefs to prevent accidentally passing the wrong prefix to the Policy/ProfileChainName()
@@ -170,6 +198,29 @@ var (
 	}
 )
 
+// ChainGroup returns the group that one of our chains belongs to: "policy", "profile", "endpoint"
+// or "static".  Policy, profile and endpoint chains come and go so the group, rather than the
+// chain name, is used to label the per-chain iptables metrics.
+func ChainGroup(chainName string) string {
+	switch {
+	case strings.HasPrefix(chainName, string(PolicyInboundPfx)),
+		strings.HasPrefix(chainName, string(PolicyOutboundPfx)):
+		return "policy"
+	case strings.HasPrefix(chainName, string(ProfileInboundPfx)),
+		strings.HasPrefix(chainName, string(ProfileOutboundPfx)):
+		return "profile"
+	case strings.HasPrefix(chainName, WorkloadToEndpointPfx),
+		strings.HasPrefix(chainName, WorkloadFromEndpointPfx),
+		strings.HasPrefix(chainName, SetEndPointMarkPfx),
+		strings.HasPrefix(chainName, HostToEndpointPfx),
+		strings.HasPrefix(chainName, HostFromEndpointPfx),
+		strings.HasPrefix(chainName, HostToEndpointForwardPfx),
+		strings.HasPrefix(chainName, HostFromEndpointForwardPfx):
+		return "endpoint"
+	}
+	return "static"
+}
+
 type RuleRenderer interface {
 	StaticFilterTableChains(ipVersion uint8) []*iptables.Chain
 	StaticNATTableChains(ipVersion uint8) []*iptables.Chain
@@ -178,6 +229,7 @@ type RuleRenderer interface {
 	StaticBPFModeRawChains(ipVersion uint8, wgEncryptHost, disableConntrack bool) []*iptables.Chain
 	StaticMangleTableChains(ipVersion uint8) []*iptables.Chain
 	StaticFilterForwardAppendRules() []iptables.Rule
+	ApplyFailureRules(failOpen bool, classes []string) map[string][]iptables.Rule
 
 	WorkloadDispatchChains(map[proto.WorkloadEndpointID]*proto.WorkloadEndpoint) []*iptables.Chain
 	WorkloadEndpointToIptablesChains(
@@ -238,6 +290,7 @@ type RuleRenderer interface {
 	DNATsToIptablesChains(dnats map[string]string) []*iptables.Chain
 	SNATsToIptablesChains(snats map[string]string) []*iptables.Chain
 	BlockedCIDRsToIptablesChains(cidrs []string, ipVersion uint8) []*iptables.Chain
+	QoSPolicyChain(policies []QoSPolicy) *iptables.Chain
 
 	WireguardIncomingMarkChain() *iptables.Chain
 
@@ -251,6 +304,10 @@ type DefaultRuleRenderer struct {
 	mangleAllowAction        iptables.Action
 	blockCIDRAction          iptables.Action
 	iptablesFilterDenyAction iptables.Action
+
+	// endpointRules caches the rules of endpoint chains so that endpoints with the same
+	// policies share them.
+	endpointRules *endpointRulesCache
 }
 
 func (r *DefaultRuleRenderer) IptablesFilterDenyAction() iptables.Action {
@@ -273,6 +330,10 @@ type Config struct {
 	IPSetConfigV6 *ipsets.IPVersionConfig
 
 	WorkloadIfacePrefixes []string
+	// TrustedHostInterfaces are host interfaces whose traffic bypasses host endpoint policy.
+	// The host endpoint dispatch chains send their traffic to ChainTrustedHostInterface, which
+	// marks it as accepted.
+	TrustedHostInterfaces []string
 
 	IptablesMarkAccept   uint32
 	IptablesMarkPass     uint32
@@ -317,17 +378,51 @@ type Config struct {
 	WireguardEncryptHostTraffic bool
 	RouteSource                 string
 
+	// TPROXYEnabled enables interception of workload traffic to the destinations in TPROXYPorts;
+	// matching connections are diverted, with the TPROXY target, to the transparent proxy
+	// listening on TPROXYPort.  The diverted packets are marked with TPROXYIptablesMark, which
+	// the route rule manager uses to route them locally.
+	TPROXYEnabled      bool
+	TPROXYPort         uint16
+	TPROXYPorts        []config.ProtoPort
+	TPROXYIptablesMark uint32
+
 	IptablesLogPrefix         string
 	EndpointToHostAction      string
 	IptablesFilterAllowAction string
 	IptablesMangleAllowAction string
 	IptablesFilterDenyAction  string
 
+	// PolicyLogVerdicts selects the verdicts of policy and profile rules that are logged, before
+	// they are applied; one of the PolicyLogVerdicts... constants.  The log prefix is
+	// PolicyLogPrefix, followed by the verdict and the name of the policy or profile.  Packets are
+	// logged to PolicyLogNFLOGGroup if it is non-zero, otherwise with the LOG target.
+	PolicyLogVerdicts   string
+	PolicyLogPrefix     string
+	PolicyLogNFLOGGroup uint16
+
+	// ChainNameVersion selects the scheme that is used to shorten the names of policy and
+	// profile chains that would otherwise be too long; zero means version 1.
+	ChainNameVersion hashutils.IDVersion
+
 	FailsafeInboundHostPorts  []config.ProtoPort
 	FailsafeOutboundHostPorts []config.ProtoPort
 
 	DisableConntrackInvalid bool
 
+	// NoTrackCIDRs lists CIDRs whose traffic, to or from, is exempted from connection tracking
+	// in the raw table.  The CIDRs of each IP version are matched with the IPSetIDNoTrackNets
+	// IP set.  Since the packets are untracked, policy must allow them in both directions.
+	NoTrackCIDRs []string
+
+	// VerdictCacheConnmark, if non-zero, is a connmark bit that caches policy's accept verdict for
+	// forwarded connections.  The bit is set when a packet leaves cali-FORWARD accepted; later
+	// packets of the connection skip the dispatch chains, and hence the endpoint chains, through a
+	// rule at the top of the forward dispatch chains.  Like the endpoint chains' conntrack rules,
+	// that means that policy changes don't affect established connections, but, unlike them, nor
+	// does taking an endpoint admin-down.
+	VerdictCacheConnmark uint32
+
 	NATPortRange                       numorstring.Port
 	IptablesNATOutgoingInterfaceFilter string
 
@@ -344,6 +439,18 @@ var unusedBitsInBPFMode = map[string]bool{
 	"IptablesMarkNonCaliEndpoint": true,
 }
 
+// NoTrackCIDRsForIPVersion returns the NoTrackCIDRs of the given IP version.
+func (c *Config) NoTrackCIDRsForIPVersion(ipVersion uint8) []string {
+	var cidrs []string
+	for _, cidr := range c.NoTrackCIDRs {
+		isV6 := strings.Contains(cidr, ":")
+		if isV6 == (ipVersion == 6) {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+	return cidrs
+}
+
 func (c *Config) validate() {
 	// Scan for unset iptables mark bits.  We use reflection so that we have a hope of catching
 	// newly-added fields.
@@ -449,5 +556,6 @@ func NewRenderer(config Config) RuleRenderer {
 		mangleAllowAction:        mangleAllowAction,
 		blockCIDRAction:          blockCIDRAction,
 		iptablesFilterDenyAction: iptablesFilterDenyAction,
+		endpointRules:            newEndpointRulesCache(),
 	}
 }
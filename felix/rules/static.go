@@ -29,6 +29,12 @@ func (r *DefaultRuleRenderer) StaticFilterTableChains(ipVersion uint8) (chains [
 	chains = append(chains, r.StaticFilterForwardChains()...)
 	chains = append(chains, r.StaticFilterInputChains(ipVersion)...)
 	chains = append(chains, r.StaticFilterOutputChains(ipVersion)...)
+	if chain := r.trustedHostInterfaceChain(); chain != nil {
+		chains = append(chains, chain)
+	}
+	if chain := r.cachedVerdictAcceptChain(); chain != nil {
+		chains = append(chains, chain)
+	}
 	return
 }
 
@@ -37,6 +43,7 @@ const (
 	ProtoTCP    = 6
 	ProtoUDP    = 17
 	ProtoICMPv6 = 58
+	ProtoSCTP   = 132
 )
 
 func (r *DefaultRuleRenderer) StaticFilterInputChains(ipVersion uint8) []*Chain {
@@ -112,6 +119,13 @@ func (r *DefaultRuleRenderer) StaticFilterInputForwardCheckChain(ipVersion uint8
 				Action:  GotoAction{Target: ChainDispatchSetEndPointMark},
 				Comment: []string{"To kubernetes NodePort service"},
 			},
+			Rule{
+				Match: Match().Protocol("sctp").
+					DestPortRanges(portSplit).
+					DestIPSet(hostIPSet),
+				Action:  GotoAction{Target: ChainDispatchSetEndPointMark},
+				Comment: []string{"To kubernetes NodePort service"},
+			},
 		)
 	}
 
@@ -437,6 +451,17 @@ func (r *DefaultRuleRenderer) filterWorkloadToHostChain(ipVersion uint8) *Chain
 		Action: JumpAction{Target: ChainFromWorkloadDispatch},
 	})
 
+	if r.TPROXYEnabled {
+		// Traffic that was diverted to the transparent proxy is addressed to a remote
+		// destination, so, once egress policy has allowed it, the proxy should see it,
+		// whatever the DefaultEndpointToHostAction.
+		rules = append(rules, Rule{
+			Match:   Match().MarkSingleBitSet(r.TPROXYIptablesMark),
+			Action:  r.filterAllowAction,
+			Comment: []string{"Allow traffic diverted to the transparent proxy"},
+		})
+	}
+
 	// If the dispatch chain accepts the packet, it returns to us here.  Apply the configured
 	// action.  Note: we may have done work above to allow the packet and then end up dropping
 	// it here.  We can't optimize that away because there may be other rules (such as log
@@ -647,8 +672,18 @@ func (r *DefaultRuleRenderer) StaticFilterForwardChains() []*Chain {
 // StaticFilterForwardAppendRules returns rules which should be statically appended to the end of the filter
 // table's forward chain.
 func (r *DefaultRuleRenderer) StaticFilterForwardAppendRules() []Rule {
-	return []Rule{
-		{
+	var rules []Rule
+	if r.VerdictCacheConnmark != 0 {
+		// Record the verdict so that the connection's later packets can skip the dispatch
+		// chains; see prependCachedVerdictRules().
+		rules = append(rules, Rule{
+			Match:   Match().MarkSingleBitSet(r.IptablesMarkAccept),
+			Action:  SetConnMarkAction{Mark: r.VerdictCacheConnmark, Mask: r.VerdictCacheConnmark},
+			Comment: []string{"Cache accept verdict"},
+		})
+	}
+	return append(rules,
+		Rule{
 			Match:   Match().MarkSingleBitSet(r.IptablesMarkAccept),
 			Action:  r.filterAllowAction,
 			Comment: []string{"Policy explicitly accepted packet."},
@@ -656,10 +691,10 @@ func (r *DefaultRuleRenderer) StaticFilterForwardAppendRules() []Rule {
 
 		// Set IptablesMarkAccept bit here, to indicate to our mangle-POSTROUTING chain that this is
 		// forwarded traffic and should not be subject to normal host endpoint policy.
-		{
+		Rule{
 			Action: SetMarkAction{Mark: r.IptablesMarkAccept},
 		},
-	}
+	)
 }
 
 func (r *DefaultRuleRenderer) StaticFilterOutputChains(ipVersion uint8) []*Chain {
@@ -968,12 +1003,81 @@ func (r *DefaultRuleRenderer) StaticMangleTableChains(ipVersion uint8) []*Chain
 		r.StaticManglePostroutingChain(ipVersion),
 	)
 
+	if r.TPROXYEnabled {
+		chains = append(chains, r.StaticMangleTPROXYChain(ipVersion))
+	}
+	if chain := r.trustedHostInterfaceChain(); chain != nil {
+		chains = append(chains, chain)
+	}
+
 	return chains
 }
 
+// StaticMangleTPROXYChain returns the chain that diverts selected workload traffic to the local
+// transparent proxy.  Packets of connections that the proxy has already accepted are matched by
+// their socket and only need to be marked, so that they are routed locally; new connections to the
+// configured destinations are diverted with the TPROXY target, which also sets the mark.
+func (r *DefaultRuleRenderer) StaticMangleTPROXYChain(ipVersion uint8) *Chain {
+	rules := []Rule{
+		{
+			Match:  Match().SocketTransparent(),
+			Action: SetMarkAction{Mark: r.TPROXYIptablesMark},
+		},
+		{
+			Match:   Match().MarkSingleBitSet(r.TPROXYIptablesMark),
+			Action:  ReturnAction{},
+			Comment: []string{"Packet belongs to a proxied connection."},
+		},
+	}
+
+	for _, protoPort := range r.TPROXYPorts {
+		if protoPort.Net != "" {
+			ip, _, err := cnet.ParseCIDROrIP(protoPort.Net)
+			if err != nil {
+				log.WithError(err).Error("Failed to parse CIDR in TPROXY port. Skipping TPROXY rule")
+				continue
+			}
+			if int(ipVersion) != ip.Version() {
+				continue
+			}
+		}
+
+		for _, prefix := range r.WorkloadIfacePrefixes {
+			match := Match().
+				InInterface(prefix + "+").
+				Protocol(protoPort.Protocol).
+				DestPorts(protoPort.Port)
+			if protoPort.Net != "" {
+				match = match.DestNet(protoPort.Net)
+			}
+			rules = append(rules, Rule{
+				Match: match,
+				Action: TPROXYAction{
+					Port: r.TPROXYPort,
+					Mark: r.TPROXYIptablesMark,
+					Mask: r.TPROXYIptablesMark,
+				},
+			})
+		}
+	}
+
+	return &Chain{
+		Name:  ChainMangleTPROXY,
+		Rules: rules,
+	}
+}
+
 func (r *DefaultRuleRenderer) StaticManglePreroutingChain(ipVersion uint8) *Chain {
 	rules := []Rule{}
 
+	// Divert traffic to the transparent proxy first, since the proxy's own connections would
+	// otherwise be accepted by the conntrack rule below without being marked for local delivery.
+	if r.TPROXYEnabled {
+		rules = append(rules, Rule{
+			Action: JumpAction{Target: ChainMangleTPROXY},
+		})
+	}
+
 	// ACCEPT or RETURN immediately if packet matches an existing connection.  Note that we also
 	// have a rule like this at the start of each pre-endpoint chain; the functional difference
 	// with placing this rule here is that it will also apply to packets that may be unrelated
@@ -1031,6 +1135,12 @@ func (r *DefaultRuleRenderer) StaticManglePostroutingChain(ipVersion uint8) *Cha
 	// mangle table is typically used, if at all, for packet manipulations that might need to
 	// apply to our allowed traffic.
 
+	// Set the DSCP field of traffic from workloads that have a QoS policy.  This has to come
+	// first because the forwarded traffic that it applies to returns from the next rule.
+	rules = append(rules, Rule{
+		Action: JumpAction{Target: ChainQoSPolicy},
+	})
+
 	// Allow immediately if IptablesMarkAccept is set.  Our filter-FORWARD chain sets this for
 	// any packets that reach the end of that chain.  The principle is that we don't want to
 	// apply normal host endpoint policy to forwarded traffic.
@@ -1096,13 +1206,17 @@ func (r *DefaultRuleRenderer) StaticManglePostroutingChain(ipVersion uint8) *Cha
 }
 
 func (r *DefaultRuleRenderer) StaticRawTableChains(ipVersion uint8) []*Chain {
-	return []*Chain{
+	chains := []*Chain{
 		r.failsafeInChain("raw", ipVersion),
 		r.failsafeOutChain("raw", ipVersion),
 		r.StaticRawPreroutingChain(ipVersion),
 		r.WireguardIncomingMarkChain(),
-		r.StaticRawOutputChain(0),
+		r.StaticRawOutputChain(ipVersion, 0),
 	}
+	if chain := r.trustedHostInterfaceChain(); chain != nil {
+		chains = append(chains, chain)
+	}
+	return chains
 }
 
 func (r *DefaultRuleRenderer) StaticBPFModeRawChains(ipVersion uint8,
@@ -1224,7 +1338,7 @@ func (r *DefaultRuleRenderer) StaticBPFModeRawChains(ipVersion uint8,
 
 	if ipVersion == 4 {
 		chains = append(chains,
-			r.StaticRawOutputChain(tcdefs.MarkSeenBypass))
+			r.StaticRawOutputChain(ipVersion, tcdefs.MarkSeenBypass))
 	}
 
 	return chains
@@ -1238,6 +1352,7 @@ func (r *DefaultRuleRenderer) StaticRawPreroutingChain(ipVersion uint8) *Chain {
 	rules = append(rules,
 		Rule{Action: ClearMarkAction{Mark: r.allCalicoMarkBits()}},
 	)
+	rules = append(rules, r.noTrackCIDRRules(ipVersion)...)
 
 	// Set a mark on encapsulated packets coming from WireGuard to ensure the RPF check allows it
 	if ((r.WireguardEnabled && len(r.WireguardInterfaceName) > 0) || (r.WireguardEnabledV6 && len(r.WireguardInterfaceNameV6) > 0)) && r.Config.WireguardEncryptHostTraffic {
@@ -1288,6 +1403,28 @@ func (r *DefaultRuleRenderer) StaticRawPreroutingChain(ipVersion uint8) *Chain {
 	}
 }
 
+// noTrackCIDRRules returns the rules that exempt traffic to and from the NoTrackCIDRs from
+// connection tracking.  NOTRACK doesn't end processing so the packets still go on to the
+// untracked policy.
+func (r *DefaultRuleRenderer) noTrackCIDRRules(ipVersion uint8) []Rule {
+	if r.BPFEnabled || len(r.NoTrackCIDRsForIPVersion(ipVersion)) == 0 {
+		return nil
+	}
+	ipSetName := r.ipSetConfig(ipVersion).NameForMainIPSet(IPSetIDNoTrackNets)
+	return []Rule{
+		{
+			Match:   Match().SourceIPSet(ipSetName),
+			Action:  NoTrackAction{},
+			Comment: []string{"Skip conntrack for traffic from NoTrackCIDRs"},
+		},
+		{
+			Match:   Match().DestIPSet(ipSetName),
+			Action:  NoTrackAction{},
+			Comment: []string{"Skip conntrack for traffic to NoTrackCIDRs"},
+		},
+	}
+}
+
 // RPFilter returns rules that implement RPF
 func RPFilter(ipVersion uint8, mark, mask uint32, openStackSpecialCasesEnabled, acceptLocal bool, dropActionOverride Action) []Rule {
 	rules := make([]Rule, 0, 2)
@@ -1370,17 +1507,20 @@ func (r *DefaultRuleRenderer) WireguardIncomingMarkChain() *Chain {
 	}
 }
 
-func (r *DefaultRuleRenderer) StaticRawOutputChain(tcBypassMark uint32) *Chain {
+func (r *DefaultRuleRenderer) StaticRawOutputChain(ipVersion uint8, tcBypassMark uint32) *Chain {
 	rules := []Rule{
 		// For safety, clear all our mark bits before we start.  (We could be in
 		// append mode and another process' rules could have left the mark bit set.)
 		{Action: ClearMarkAction{Mark: r.allCalicoMarkBits()}},
+	}
+	rules = append(rules, r.noTrackCIDRRules(ipVersion)...)
+	rules = append(rules,
 		// Then, jump to the untracked policy chains.
-		{Action: JumpAction{Target: ChainDispatchToHostEndpoint}},
+		Rule{Action: JumpAction{Target: ChainDispatchToHostEndpoint}},
 		// Then, if the packet was marked as allowed, accept it.  Packets also
 		// return here without the mark bit set if the interface wasn't one that
 		// we're policing.
-	}
+	)
 	if tcBypassMark == 0 {
 		rules = append(rules, []Rule{
 			{Match: Match().MarkSingleBitSet(r.IptablesMarkAccept),
@@ -42,6 +42,8 @@ var _ = Describe("Static", func() {
 	checkManglePostrouting := func(ipVersion uint8, ipvs bool) {
 		It("should generate expected cali-POSTROUTING chain in the mangle table", func() {
 			expRules := []Rule{
+				// Apply QoS policy.
+				{Action: JumpAction{Target: ChainQoSPolicy}},
 				// Accept already accepted.
 				{Match: Match().MarkSingleBitSet(0x10),
 					Action: ReturnAction{},
@@ -266,6 +268,13 @@ var _ = Describe("Static", func() {
 								Action:  GotoAction{Target: ChainDispatchSetEndPointMark},
 								Comment: []string{"To kubernetes NodePort service"},
 							},
+							{
+								Match: Match().Protocol("sctp").
+									DestPortRanges(portRanges).
+									DestIPSet(ipSetThisHost),
+								Action:  GotoAction{Target: ChainDispatchSetEndPointMark},
+								Comment: []string{"To kubernetes NodePort service"},
+							},
 							{
 								Match:   Match().NotDestIPSet(ipSetThisHost),
 								Action:  JumpAction{Target: ChainDispatchSetEndPointMark},
@@ -1129,6 +1138,13 @@ var _ = Describe("Static", func() {
 						Action:  GotoAction{Target: ChainDispatchSetEndPointMark},
 						Comment: []string{"To kubernetes NodePort service"},
 					},
+					{
+						Match: Match().Protocol("sctp").
+							DestPortRanges(portRanges1).
+							DestIPSet(ipSetThisHost),
+						Action:  GotoAction{Target: ChainDispatchSetEndPointMark},
+						Comment: []string{"To kubernetes NodePort service"},
+					},
 					{
 						Match: Match().Protocol("tcp").
 							DestPortRanges(portRanges2).
@@ -1143,6 +1159,13 @@ var _ = Describe("Static", func() {
 						Action:  GotoAction{Target: ChainDispatchSetEndPointMark},
 						Comment: []string{"To kubernetes NodePort service"},
 					},
+					{
+						Match: Match().Protocol("sctp").
+							DestPortRanges(portRanges2).
+							DestIPSet(ipSetThisHost),
+						Action:  GotoAction{Target: ChainDispatchSetEndPointMark},
+						Comment: []string{"To kubernetes NodePort service"},
+					},
 					{
 						Match:   Match().NotDestIPSet(ipSetThisHost),
 						Action:  JumpAction{Target: ChainDispatchSetEndPointMark},
@@ -1554,6 +1577,116 @@ var _ = Describe("Static", func() {
 		}
 	})
 
+	Describe("with TPROXY enabled", func() {
+		BeforeEach(func() {
+			conf = Config{
+				WorkloadIfacePrefixes:       []string{"cali", "tap"},
+				IPSetConfigV4:               ipsets.NewIPVersionConfig(ipsets.IPFamilyV4, "cali", nil, nil),
+				IPSetConfigV6:               ipsets.NewIPVersionConfig(ipsets.IPFamilyV6, "cali", nil, nil),
+				IptablesMarkAccept:          0x10,
+				IptablesMarkPass:            0x20,
+				IptablesMarkScratch0:        0x40,
+				IptablesMarkScratch1:        0x80,
+				IptablesMarkEndpoint:        0xff00,
+				IptablesMarkNonCaliEndpoint: 0x100,
+				IptablesMangleAllowAction:   "ACCEPT",
+				IptablesFilterAllowAction:   "ACCEPT",
+				TPROXYEnabled:               true,
+				TPROXYPort:                  16001,
+				TPROXYPorts: []config.ProtoPort{
+					{Protocol: "tcp", Port: 80},
+					{Net: "10.0.0.0/8", Protocol: "tcp", Port: 443},
+				},
+				TPROXYIptablesMark: 0x100000,
+			}
+		})
+
+		tproxyAction := TPROXYAction{Port: 16001, Mark: 0x100000, Mask: 0x100000}
+
+		It("IPv4: should return the expected TPROXY chain in the mangle table", func() {
+			Expect(findChain(rr.StaticMangleTableChains(4), "cali-tproxy")).To(Equal(&Chain{
+				Name: "cali-tproxy",
+				Rules: []Rule{
+					{Match: Match().SocketTransparent(),
+						Action: SetMarkAction{Mark: 0x100000}},
+					{Match: Match().MarkSingleBitSet(0x100000),
+						Action:  ReturnAction{},
+						Comment: []string{"Packet belongs to a proxied connection."}},
+					{Match: Match().InInterface("cali+").Protocol("tcp").DestPorts(80),
+						Action: tproxyAction},
+					{Match: Match().InInterface("tap+").Protocol("tcp").DestPorts(80),
+						Action: tproxyAction},
+					{Match: Match().InInterface("cali+").Protocol("tcp").DestPorts(443).DestNet("10.0.0.0/8"),
+						Action: tproxyAction},
+					{Match: Match().InInterface("tap+").Protocol("tcp").DestPorts(443).DestNet("10.0.0.0/8"),
+						Action: tproxyAction},
+				},
+			}))
+		})
+		It("IPv6: should skip TPROXY ports with an IPv4 CIDR", func() {
+			Expect(findChain(rr.StaticMangleTableChains(6), "cali-tproxy").Rules).To(HaveLen(4))
+		})
+		It("should jump to the TPROXY chain before accepting established connections", func() {
+			Expect(findChain(rr.StaticMangleTableChains(4), "cali-PREROUTING").Rules[0]).To(Equal(
+				Rule{Action: JumpAction{Target: "cali-tproxy"}}))
+		})
+		It("should allow diverted traffic in the workload-to-host chain", func() {
+			Expect(findChain(rr.StaticFilterTableChains(4), "cali-wl-to-host")).To(Equal(&Chain{
+				Name: "cali-wl-to-host",
+				Rules: []Rule{
+					{Action: JumpAction{Target: "cali-from-wl-dispatch"}},
+					{Match: Match().MarkSingleBitSet(0x100000),
+						Action:  AcceptAction{},
+						Comment: []string{"Allow traffic diverted to the transparent proxy"}},
+					{Action: ReturnAction{},
+						Comment: []string{"Configured DefaultEndpointToHostAction"}},
+				},
+			}))
+		})
+	})
+
+	Describe("with NoTrackCIDRs", func() {
+		BeforeEach(func() {
+			conf = Config{
+				WorkloadIfacePrefixes:       []string{"cali"},
+				IPSetConfigV4:               ipsets.NewIPVersionConfig(ipsets.IPFamilyV4, "cali", nil, nil),
+				IPSetConfigV6:               ipsets.NewIPVersionConfig(ipsets.IPFamilyV6, "cali", nil, nil),
+				IptablesMarkAccept:          0x10,
+				IptablesMarkPass:            0x20,
+				IptablesMarkScratch0:        0x40,
+				IptablesMarkScratch1:        0x80,
+				IptablesMarkEndpoint:        0xff00,
+				IptablesMarkNonCaliEndpoint: 0x100,
+				NoTrackCIDRs:                []string{"10.1.0.0/16", "10.2.0.0/16"},
+			}
+		})
+
+		noTrackRules := []Rule{
+			{Match: Match().SourceIPSet("cali40notrack-nets"),
+				Action:  NoTrackAction{},
+				Comment: []string{"Skip conntrack for traffic from NoTrackCIDRs"}},
+			{Match: Match().DestIPSet("cali40notrack-nets"),
+				Action:  NoTrackAction{},
+				Comment: []string{"Skip conntrack for traffic to NoTrackCIDRs"}},
+		}
+
+		It("should skip conntrack after clearing the marks in the raw PREROUTING chain", func() {
+			Expect(findChain(rr.StaticRawTableChains(4), "cali-PREROUTING").Rules[1:3]).To(Equal(noTrackRules))
+		})
+		It("should skip conntrack after clearing the marks in the raw OUTPUT chain", func() {
+			Expect(findChain(rr.StaticRawTableChains(4), "cali-OUTPUT").Rules[1:3]).To(Equal(noTrackRules))
+		})
+		It("should render no rules for an IP version without CIDRs", func() {
+			Expect(findChain(rr.StaticRawTableChains(6), "cali-OUTPUT").Rules[1]).To(Equal(
+				Rule{Action: JumpAction{Target: "cali-to-host-endpoint"}}))
+		})
+		It("should split the CIDRs by IP version", func() {
+			conf.NoTrackCIDRs = append(conf.NoTrackCIDRs, "fd00::/64")
+			Expect(conf.NoTrackCIDRsForIPVersion(4)).To(Equal([]string{"10.1.0.0/16", "10.2.0.0/16"}))
+			Expect(conf.NoTrackCIDRsForIPVersion(6)).To(Equal([]string{"fd00::/64"}))
+		})
+	})
+
 	Describe("with BPF mode raw chains", func() {
 		staticBPFModeRawRules := []Rule{
 			{
@@ -89,6 +89,12 @@ func (options PolicyListOptions) KeyFromDefaultPath(path string) Key {
 	return PolicyKey{Name: name}
 }
 
+// AnnotationInsertAtTop, set to "true" on a GlobalNetworkPolicy, asks Felix to apply the policy
+// to host endpoints ahead of all the other policies, whatever their order.  (Felix puts all
+// policies in a single tier, so this includes policies that allow or pass traffic.)  It is
+// intended for emergency block rules; Felix ignores it on policies that can allow traffic.
+const AnnotationInsertAtTop = "policy.projectcalico.org/insert-at-top"
+
 type Policy struct {
 	Namespace      string            `json:"namespace,omitempty" validate:"omitempty"`
 	Order          *float64          `json:"order,omitempty" validate:"omitempty"`
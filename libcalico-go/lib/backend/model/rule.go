@@ -41,6 +41,14 @@ type Rule struct {
 	NotICMPType *int `json:"!icmp_type,omitempty" validate:"omitempty,gte=0,lt=255"`
 	NotICMPCode *int `json:"!icmp_code,omitempty" validate:"omitempty,gte=0,lte=255"`
 
+	// ICMPTypes and ICMPTypeCodes let a rule match more than one ICMP type: a packet matches if
+	// it matches ICMPType/ICMPCode or any entry in either list.  A packet matches the negated
+	// lists only if it matches none of their entries.
+	ICMPTypes        []int             `json:"icmp_types,omitempty" validate:"omitempty,dive,gte=0,lt=255"`
+	ICMPTypeCodes    []ICMPTypeAndCode `json:"icmp_type_codes,omitempty" validate:"omitempty,dive"`
+	NotICMPTypes     []int             `json:"!icmp_types,omitempty" validate:"omitempty,dive,gte=0,lt=255"`
+	NotICMPTypeCodes []ICMPTypeAndCode `json:"!icmp_type_codes,omitempty" validate:"omitempty,dive"`
+
 	SrcTag              string             `json:"src_tag,omitempty" validate:"omitempty,tag"`
 	SrcNet              *net.IPNet         `json:"src_net,omitempty" validate:"omitempty"`
 	SrcNets             []*net.IPNet       `json:"src_nets,omitempty" validate:"omitempty"`
@@ -89,6 +97,15 @@ type Rule struct {
 	Metadata *RuleMetadata `json:"metadata,omitempty" validate:"omitempty"`
 }
 
+type ICMPTypeAndCode struct {
+	Type int `json:"type" validate:"gte=0,lt=255"`
+	Code int `json:"code" validate:"gte=0,lte=255"`
+}
+
+func (tc ICMPTypeAndCode) String() string {
+	return fmt.Sprintf("%d/%d", tc.Type, tc.Code)
+}
+
 type HTTPMatch struct {
 	Methods []string         `json:"methods,omitempty" validate:"omitempty"`
 	Paths   []apiv3.HTTPPath `json:"paths,omitempty" validate:"omitempty"`
@@ -135,6 +152,22 @@ func joinNets(nets []*net.IPNet) string {
 	return strings.Join(parts, ",")
 }
 
+func joinInts(ints []int) string {
+	parts := make([]string, len(ints))
+	for i, n := range ints {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ",")
+}
+
+func joinICMPTypeCodes(typeCodes []ICMPTypeAndCode) string {
+	parts := make([]string, len(typeCodes))
+	for i, tc := range typeCodes {
+		parts[i] = tc.String()
+	}
+	return strings.Join(parts, ",")
+}
+
 func (r Rule) String() string {
 	parts := make([]string, 0)
 	// Action.
@@ -164,6 +197,18 @@ func (r Rule) String() string {
 	if r.NotICMPCode != nil {
 		parts = append(parts, "!code", strconv.Itoa(*r.NotICMPCode))
 	}
+	if len(r.ICMPTypes) > 0 {
+		parts = append(parts, "types", joinInts(r.ICMPTypes))
+	}
+	if len(r.ICMPTypeCodes) > 0 {
+		parts = append(parts, "type/codes", joinICMPTypeCodes(r.ICMPTypeCodes))
+	}
+	if len(r.NotICMPTypes) > 0 {
+		parts = append(parts, "!types", joinInts(r.NotICMPTypes))
+	}
+	if len(r.NotICMPTypeCodes) > 0 {
+		parts = append(parts, "!type/codes", joinICMPTypeCodes(r.NotICMPTypeCodes))
+	}
 
 	{
 		// Source attributes.  New block ensures that fromParts goes out-of-scope before
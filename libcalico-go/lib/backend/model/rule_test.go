@@ -76,6 +76,11 @@ var ruleStringTests = []ruleTest{
 		"Deny ICMP !type 10"},
 	{model.Rule{Protocol: &icmpProto, NotICMPType: &icmpType, NotICMPCode: &icmpCode},
 		"Allow ICMP !type 10 !code 6"},
+	// Lists of ICMP matches.
+	{model.Rule{Protocol: &icmpProto, ICMPTypes: []int{0, 8}, ICMPTypeCodes: []model.ICMPTypeAndCode{{Type: 3, Code: 4}}},
+		"Allow ICMP types 0,8 type/codes 3/4"},
+	{model.Rule{Protocol: &icmpProto, NotICMPTypes: []int{5}, NotICMPTypeCodes: []model.ICMPTypeAndCode{{Type: 3, Code: 1}, {Type: 3, Code: 2}}},
+		"Allow ICMP !types 5 !type/codes 3/1,3/2"},
 
 	// From rules.
 	{model.Rule{SrcPorts: ports}, "Allow from ports 1234,10:20"},
@@ -181,4 +181,8 @@ type StatusReport struct {
 	Timestamp     string  `json:"time"`
 	UptimeSeconds float64 `json:"uptime"`
 	FirstUpdate   bool    `json:"first_update"`
+	// DataplaneState is the state of Felix's dataplane programming, for example "FailedOpen" if
+	// Felix has given up on programming the dataplane and has failed open, or
+	// "FailedOpenUnenforced" if it couldn't program the rules to do so either.
+	DataplaneState string `json:"dataplane_state,omitempty"`
 }
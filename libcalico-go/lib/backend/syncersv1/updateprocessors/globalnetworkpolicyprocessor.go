@@ -73,6 +73,10 @@ func convertGlobalNetworkPolicyV2ToV1Value(val interface{}) (interface{}, error)
 		PreDNAT:        spec.PreDNAT,
 		ApplyOnForward: spec.ApplyOnForward,
 	}
+	if v, ok := v3res.Annotations[model.AnnotationInsertAtTop]; ok {
+		// Only pass through the annotation that Felix uses, the others can be large.
+		v1value.Annotations = map[string]string{model.AnnotationInsertAtTop: v}
+	}
 
 	return v1value, nil
 }
@@ -121,6 +121,20 @@ var _ = Describe("Test the GlobalNetworkPolicy update processor", func() {
 			Expect(kvps).To(Equal([]*model.KVPair{{Key: v1Key, Value: nil}}))
 		})
 
+		It("should only pass through the insert-at-top annotation", func() {
+			gnp := apiv3.NewGlobalNetworkPolicy()
+			gnp.Annotations = map[string]string{
+				model.AnnotationInsertAtTop: "true",
+				"other":                     "value",
+			}
+			kvps, err := up.Process(&model.KVPair{Key: minimalGNPKey, Value: gnp, Revision: testRev})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kvps).To(HaveLen(1))
+			Expect(kvps[0].Value.(*model.Policy).Annotations).To(Equal(map[string]string{
+				model.AnnotationInsertAtTop: "true",
+			}))
+		})
+
 		It("should NOT accept a GlobalNetworkPolicy with the wrong Key type", func() {
 			_, err := up.Process(&model.KVPair{
 				Key:      model.GlobalBGPPeerKey{PeerIP: cnet.MustParseIP("1.2.3.4")},
@@ -126,6 +126,22 @@ func getEndpointSelector(namespaceSelector, endpointSelector, serviceAccountSele
 	return selector
 }
 
+// icmpFieldsToTypesAndTypeCodes splits a list of API ICMP matches into the backend's lists of
+// type-only and type and code matches.  The validator rejects entries without a type.
+func icmpFieldsToTypesAndTypeCodes(icmps []apiv3.ICMPFields) (types []int, typeCodes []model.ICMPTypeAndCode) {
+	for _, icmp := range icmps {
+		if icmp.Type == nil {
+			continue
+		}
+		if icmp.Code == nil {
+			types = append(types, *icmp.Type)
+		} else {
+			typeCodes = append(typeCodes, model.ICMPTypeAndCode{Type: *icmp.Type, Code: *icmp.Code})
+		}
+	}
+	return
+}
+
 // RuleAPIToBackend converts an API Rule structure to a Backend Rule structure.
 func RuleAPIV2ToBackend(ar apiv3.Rule, ns string) model.Rule {
 	var icmpCode, icmpType, notICMPCode, notICMPType *int
@@ -138,6 +154,8 @@ func RuleAPIV2ToBackend(ar apiv3.Rule, ns string) model.Rule {
 		notICMPCode = ar.NotICMP.Code
 		notICMPType = ar.NotICMP.Type
 	}
+	icmpTypes, icmpTypeCodes := icmpFieldsToTypesAndTypeCodes(ar.ICMPs)
+	notICMPTypes, notICMPTypeCodes := icmpFieldsToTypesAndTypeCodes(ar.NotICMPs)
 
 	sourceSelector := GetEntityRuleSelector(&ar.Source, ns, "source")
 	destSelector := GetEntityRuleSelector(&ar.Destination, ns, "destination")
@@ -172,6 +190,11 @@ func RuleAPIV2ToBackend(ar apiv3.Rule, ns string) model.Rule {
 		NotICMPCode: notICMPCode,
 		NotICMPType: notICMPType,
 
+		ICMPTypes:        icmpTypes,
+		ICMPTypeCodes:    icmpTypeCodes,
+		NotICMPTypes:     notICMPTypes,
+		NotICMPTypeCodes: notICMPTypeCodes,
+
 		SrcNets:             ConvertStringsToNets(ar.Source.Nets),
 		SrcSelector:         sourceSelector,
 		SrcPorts:            ar.Source.Ports,
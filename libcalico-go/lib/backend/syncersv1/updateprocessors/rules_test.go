@@ -24,11 +24,36 @@ import (
 	"github.com/projectcalico/api/pkg/lib/numorstring"
 
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/k8s/conversion"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/syncersv1/updateprocessors"
 	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
 )
 
 var _ = Describe("Test the Rules Conversion Functions", func() {
+	It("should convert lists of ICMP matches", func() {
+		icmpProto := numorstring.ProtocolFromString("ICMP")
+		t0, t3, t8, c1, c4 := 0, 3, 8, 1, 4
+		rulev1 := updateprocessors.RuleAPIV2ToBackend(apiv3.Rule{
+			Action:   apiv3.Allow,
+			Protocol: &icmpProto,
+			ICMPs: []apiv3.ICMPFields{
+				{Type: &t8},
+				{Type: &t3, Code: &c4},
+				{Type: &t0},
+			},
+			NotICMPs: []apiv3.ICMPFields{
+				{Type: &t3, Code: &c1},
+			},
+		}, "")
+
+		Expect(rulev1.ICMPType).To(BeNil())
+		Expect(rulev1.ICMPCode).To(BeNil())
+		Expect(rulev1.ICMPTypes).To(Equal([]int{8, 0}))
+		Expect(rulev1.ICMPTypeCodes).To(Equal([]model.ICMPTypeAndCode{{Type: 3, Code: 4}}))
+		Expect(rulev1.NotICMPTypes).To(BeNil())
+		Expect(rulev1.NotICMPTypeCodes).To(Equal([]model.ICMPTypeAndCode{{Type: 3, Code: 1}}))
+	})
+
 	It("should handle the conversion of rules", func() {
 		By("Creating and converting an inbound rule")
 		v4 := 4
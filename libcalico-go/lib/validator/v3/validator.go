@@ -1175,6 +1175,26 @@ func validateRule(structLevel validator.StructLevel) {
 		structLevel.ReportError(reflect.ValueOf(rule.ICMP), "ICMP", "", reason(protocolIcmpMsg), "")
 	}
 
+	// The ICMP lists are rendered with the kernel's icmp or icmp6 match, which needs a protocol
+	// match for the same flavour of ICMP, even for the negated list.  An entry without a type
+	// would match every ICMP packet.
+	for _, f := range []struct {
+		name   string
+		fields []api.ICMPFields
+	}{{"ICMPs", rule.ICMPs}, {"NotICMPs", rule.NotICMPs}} {
+		if len(f.fields) == 0 {
+			continue
+		}
+		if rule.Protocol == nil || (*rule.Protocol != icmp && *rule.Protocol != icmpv6) {
+			structLevel.ReportError(reflect.ValueOf(f.fields), f.name, "", reason(protocolIcmpMsg), "")
+		}
+		for _, icmpFields := range f.fields {
+			if icmpFields.Type == nil {
+				structLevel.ReportError(reflect.ValueOf(f.fields), f.name, "", reason("ICMP type must be specified"), "")
+			}
+		}
+	}
+
 	// Check that the IPVersion of the protocol matches the IPVersion of the ICMP protocol.
 	if (rule.Protocol != nil && *rule.Protocol == icmp) || (rule.NotProtocol != nil && *rule.NotProtocol == icmp) {
 		if rule.IPVersion != nil && *rule.IPVersion != 4 {
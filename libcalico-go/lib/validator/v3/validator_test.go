@@ -1287,6 +1287,41 @@ func init() {
 					Type: &V0,
 				},
 			}, true),
+		Entry("should allow Rule with lists of icmp matches",
+			api.Rule{
+				Action:   "Allow",
+				Protocol: protocolFromString("ICMP"),
+				ICMPs: []api.ICMPFields{
+					{Type: &V0},
+					{Type: &V128, Code: &V0},
+				},
+				NotICMPs: []api.ICMPFields{
+					{Type: &V128, Code: &V255},
+				},
+			}, true),
+		Entry("should reject Rule with a list of icmp matches and no protocol",
+			api.Rule{
+				Action: "Allow",
+				ICMPs:  []api.ICMPFields{{Type: &V0}},
+			}, false),
+		Entry("should reject Rule with a list of negated icmp matches and a non-icmp protocol",
+			api.Rule{
+				Action:   "Allow",
+				Protocol: protocolFromString("TCP"),
+				NotICMPs: []api.ICMPFields{{Type: &V0}},
+			}, false),
+		Entry("should reject Rule with an icmp list entry without a type",
+			api.Rule{
+				Action:   "Allow",
+				Protocol: protocolFromString("ICMPv6"),
+				ICMPs:    []api.ICMPFields{{Type: &V128}, {}},
+			}, false),
+		Entry("should reject Rule with an icmp list entry with a code too high",
+			api.Rule{
+				Action:   "Allow",
+				Protocol: protocolFromString("ICMP"),
+				NotICMPs: []api.ICMPFields{{Type: &V128, Code: &V256}},
+			}, false),
 		Entry("should reject Rule with source ports and protocol type 7",
 			api.Rule{
 				Action:   "Allow",